@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"kanban_api/internal/analytics"
+	"kanban_api/internal/migrations"
+	"kanban_api/internal/repository"
+	"log/slog"
+	"os"
+)
+
+// analyticsCommand 子命令 kanban-server analytics，执行完返回退出码，不启动服务
+//
+//	analytics views          在库里创建（或重建）脱敏的只读视图 analytics_*
+//	analytics export <path>  把这些视图的内容导出成一个单独的 SQLite 文件
+//
+// 读 SQLite 文件；DB_DRIVER=mysql 时用户和看板从 MySQL 读，视图也建在 MySQL 里
+func analyticsCommand(sqlitePath string, args []string) int {
+	switch {
+	case len(args) == 1 && args[0] == "views":
+	case len(args) == 2 && args[0] == "export":
+	default:
+		fmt.Fprintln(os.Stderr, "usage: kanban-server analytics views | export <path>")
+		return 2
+	}
+
+	sqliteDB, err := migrations.OpenSQLite(sqlitePath)
+	if err != nil {
+		slog.Error("analytics failed", "err", err)
+		return 1
+	}
+	defer migrations.Close(sqliteDB)
+	src := analytics.Sources{SQLite: sqliteDB}
+	if os.Getenv("DB_DRIVER") == "mysql" {
+		cfg, err := repository.MySQLConfigFromEnv()
+		if err != nil {
+			slog.Error("analytics failed", "err", err)
+			return 1
+		}
+		mysqlDB, err := repository.OpenMySQL(cfg)
+		if err != nil {
+			slog.Error("analytics failed", "err", err)
+			return 1
+		}
+		defer migrations.Close(mysqlDB)
+		src.MySQL = mysqlDB
+	}
+
+	ctx := context.Background()
+	if args[0] == "views" {
+		names, err := analytics.CreateViews(ctx, src)
+		for _, name := range names {
+			fmt.Printf("created view %s\n", name)
+		}
+		if err != nil {
+			slog.Error("analytics failed", "err", err)
+			return 1
+		}
+		return 0
+	}
+
+	out, err := analytics.Export(ctx, src, args[1])
+	if err != nil {
+		slog.Error("analytics failed", "err", err)
+		return 1
+	}
+	for _, e := range out {
+		fmt.Printf("%-28s %d rows\n", e.Name, e.Rows)
+	}
+	fmt.Printf("exported to %s\n", args[1])
+	return 0
+}
+
+// keepAnalyticsViews 执行迁移 migrate，前后处理库里的分析视图
+// 先删掉视图，否则 SQLite 上删列的迁移（包括回滚）会失败；迁移完按当前的表结构重建，迁移失败也重建
+// 回滚之后有的视图可能建不起来（要的列已经没了），只打印警告，不算迁移失败
+// 库里没有建过分析视图时什么也不做
+func keepAnalyticsViews(db *gorm.DB, migrate func() error) error {
+	ctx := context.Background()
+	views, err := analytics.DropViews(ctx, db)
+	if err != nil {
+		return err
+	}
+	err = migrate()
+	if rerr := analytics.RestoreViews(ctx, db, views); rerr != nil {
+		slog.Warn("analytics views not restored, run `kanban-server analytics views` again", "err", rerr)
+	}
+	return err
+}
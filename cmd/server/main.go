@@ -3,38 +3,320 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"       // Gin Web 框架
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin" // Gin Web 框架
+	"gorm.io/gorm"
+	"kanban_api/internal/analytics"
+	"kanban_api/internal/blob"
+	"kanban_api/internal/buildinfo"
+	"kanban_api/internal/capture"
+	"kanban_api/internal/events"
 	httpx "kanban_api/internal/http" // 导入时使用别名 httpx，避免与标准库 http 冲突
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/mail"
 	"kanban_api/internal/middleware"
+	"kanban_api/internal/model"
+	"kanban_api/internal/oauth"
 	"kanban_api/internal/repository"
 	"kanban_api/internal/service"
-	"log"
+	"kanban_api/internal/statsd"
+	"kanban_api/internal/tracing"
+	"kanban_api/internal/webhook"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // main 函数是程序的入口点
 // 程序启动时会自动执行这个函数
 func main() {
+	// 最先创建 logger（环境变量 LOG_FORMAT：text / json，LOG_LEVEL：debug / info / warn / error）
+	// 设为默认 logger 后，标准库 log 包（以及用它打日志的第三方库）的输出也会走这里
+	logger, err := logging.FromEnv()
+	if err != nil {
+		// logger 还没建好，只能直接写标准错误
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// 子命令 migrate：手动升级、回滚或查看数据库迁移，执行完直接退出，不启动服务
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrateCommand(envString("SQLITE_DSN", repository.DefaultSQLiteDSN), os.Args[2:]))
+	}
+	// 子命令 analytics：创建给数据分析用的只读视图，或者导出一份脱敏的 SQLite 文件，执行完直接退出
+	if len(os.Args) > 1 && os.Args[1] == "analytics" {
+		os.Exit(analyticsCommand(envString("SQLITE_DSN", repository.DefaultSQLiteDSN), os.Args[2:]))
+	}
+
+	// 启动日志的第一行写明构建信息，排查问题时先确认跑的是哪个版本
+	build := buildinfo.Get()
+	logger.Info("starting kanban_api", "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime, "go", build.GoVersion)
+
 	// ========== 第一步：初始化数据访问层（Repository） ==========
 	// 采用"依赖注入"的方式，从底层往上层构建
-	userRepo, err := repository.NewSQLiteUserRepo("file:kanban.db?cache=shared&_fk=1")
+
+	// 初始化链路追踪：设置了 OTEL_EXPORTER_OTLP_ENDPOINT 才会开启，见 internal/tracing
+	// 要在创建仓储之前调用，数据库的追踪回调在打开连接时注册
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		fatal(err)
+	}
+	// 注意：r.Run() 正常情况下不会返回，按 Ctrl+C 退出时这里不会执行，
+	// 最后一批（最多几秒内）还没发出去的 span 会丢失
+	defer shutdownTracing(context.Background())
+	if tracing.Enabled() {
+		logger.Info("tracing enabled, exporting spans via OTLP")
+	}
+
+	// 选择 ID 生成策略（环境变量 ID_STRATEGY：uuid / ulid / ksuid，默认 uuid）
+	// ULID 和 KSUID 可以按时间排序，SQLite 索引局部性和分页效果更好
+	// 已有的 UUID 数据不受影响，依然可以正常访问
+	idGen, err := repository.NewIDGenerator(os.Getenv("ID_STRATEGY"))
+	if err != nil {
+		fatal(err)
+	}
+	repository.SetIDGenerator(idGen)
+
+	// 打开 SQLite 数据库：整个进程只打开一次，所有 SQLite 仓储共用这一个连接池
+	// 连接字符串和连接池参数从 SQLITE_* 环境变量读取，见 repository.SQLiteConfigFromEnv
+	sqliteCfg, err := repository.SQLiteConfigFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	sqliteDB, err := repository.NewDB(sqliteCfg.DSN, sqliteCfg.PoolConfig)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 数据库迁移：表和索引都由 internal/migrations 创建，必须在创建仓储之前执行
+	// 环境变量 MIGRATE_ON_START：apply（默认，执行没执行过的迁移）/ verify（只检查，有没执行的迁移时不启动）
+	migrateMode := envString("MIGRATE_ON_START", "apply")
+	if err := migrateOnStart(logger, "sqlite", sqliteDB, migrateMode); err != nil {
+		fatal(err)
+	}
+
+	// 选择用户和看板的存储后端（环境变量 DB_DRIVER：sqlite / mysql，默认 sqlite）
+	// MySQL 目前只支持用户和看板，列表、卡片等其它数据仍然保存在 SQLite 中
+	var userRepo repository.UserRepository
+	var boardRepo repository.BoardRepository
+	var mysqlDB *gorm.DB
+	switch os.Getenv("DB_DRIVER") {
+	case "", "sqlite":
+		userRepo, err = repository.NewSQLiteUserRepo(sqliteDB)
+		if err != nil {
+			// fatal 会打印错误信息并退出程序（调用 os.Exit(1)）
+			// 适用于启动时的致命错误
+			fatal(err)
+		}
+		// 创建看板仓储（SQLite 数据库实现）
+		boardRepo, err = repository.NewSQLiteBoardRepo(sqliteDB)
+		if err != nil {
+			fatal(err)
+		}
+	case "mysql":
+		// 连接池参数从 MYSQL_* 环境变量读取，见 repository.MySQLConfigFromEnv
+		cfg, err := repository.MySQLConfigFromEnv()
+		if err != nil {
+			fatal(err)
+		}
+		mysqlDB, err = repository.OpenMySQL(cfg)
+		if err != nil {
+			fatal(err)
+		}
+		// MySQL 上的迁移（用户表和看板表），和上面 SQLite 的迁移分开执行
+		if err := migrateOnStart(logger, "mysql", mysqlDB, migrateMode); err != nil {
+			fatal(err)
+		}
+		if userRepo, err = repository.NewMySQLUserRepo(mysqlDB); err != nil {
+			fatal(err)
+		}
+		if boardRepo, err = repository.NewMySQLBoardRepo(mysqlDB); err != nil {
+			fatal(err)
+		}
+	default:
+		fatal(fmt.Errorf("unknown DB_DRIVER %q (want sqlite or mysql)", os.Getenv("DB_DRIVER")))
+	}
+
+	// 创建列表仓储（看板中的列）
+	listRepo, err := repository.NewSQLiteListRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建卡片仓储（列表中的任务）
+	cardRepo, err := repository.NewSQLiteCardRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建检查清单仓储（卡片中的清单和条目）
+	checklistRepo, err := repository.NewSQLiteChecklistRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建嵌入令牌仓储，用于吊销已经分享出去的嵌入链接
+	embedRepo, err := repository.NewSQLiteEmbedTokenRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建刷新令牌仓储，只保存令牌哈希
+	refreshRepo, err := repository.NewSQLiteRefreshTokenRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建 webhook 仓储（看板的出站通知）
+	webhookRepo, err := repository.NewSQLiteWebhookRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建卡片镜像链接仓储（卡片和它在别的看板上的镜像）
+	cardLinkRepo, err := repository.NewSQLiteCardLinkRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建看板动态仓储（每次修改的操作记录）
+	activityRepo, err := repository.NewSQLiteActivityRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建看板成员仓储（共享看板）
+	memberRepo, err := repository.NewSQLiteMemberRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建密码重置令牌仓储
+	resetRepo, err := repository.NewSQLitePasswordResetRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建附件仓储（附件记录和文件 blob 的引用计数）
+	attachmentRepo, err := repository.NewSQLiteAttachmentRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建看板附件占用空间的计数器仓储
+	storageRepo, err := repository.NewSQLiteStorageRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建 Idempotency-Key 幂等记录仓储
+	idempotencyRepo, err := repository.NewSQLiteIdempotencyRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建看板每日快照仓储
+	snapshotRepo, err := repository.NewSQLiteSnapshotRepo(sqliteDB)
 	if err != nil {
-		// log.Fatal 会打印错误信息并退出程序（调用 os.Exit(1)）
-		// 适用于启动时的致命错误
-		log.Fatal(err)
+		fatal(err)
 	}
-	// 创建看板仓储（SQLite 数据库实现）
-	// 连接字符串参数说明：
-	// - file:kanban.db: 数据库文件路径
-	// - cache=shared: 启用共享缓存，多个连接可以共享缓存
-	// - _fk=1: 启用外键约束
-	boardRepo, err := repository.NewSQLiteBoardRepo("file:kanban.db?cache=shared&_fk=1")
+
+	// 创建看板的卡片模板仓储
+	cardTemplateRepo, err := repository.NewSQLiteCardTemplateRepo(sqliteDB)
 	if err != nil {
-		// log.Fatal 会打印错误信息并退出程序（调用 os.Exit(1)）
-		// 适用于启动时的致命错误
-		log.Fatal(err)
+		fatal(err)
 	}
 
+	// 创建异常检测用的登录尝试记录仓储和检测结果仓储
+	loginAttemptRepo, err := repository.NewSQLiteLoginAttemptRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+	anomalyRepo, err := repository.NewSQLiteAnomalyRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建第三方登录身份仓储（用户关联的 Google、GitHub 账号）
+	identityRepo, err := repository.NewSQLiteUserIdentityRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建个人 API 密钥仓储，只保存密钥哈希
+	apiKeyRepo, err := repository.NewSQLiteAPIKeyRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 创建健康探测记录和事故公告仓储，公开状态页使用
+	healthCheckRepo, err := repository.NewSQLiteHealthCheckRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+	incidentRepo, err := repository.NewSQLiteIncidentRepo(sqliteDB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// 用统计装饰器包装所有仓储，记录每个方法的调用次数和耗时
+	// 装饰器实现了同样的接口，所以上层的 Service 完全不需要改动
+	// 连接池按数据库记录：SQLite 一个，用了 MySQL 时再加一个
+	queryMetrics := repository.NewQueryMetrics()
+	queryMetrics.AddPool("sqlite", sqliteDB)
+	if mysqlDB != nil {
+		queryMetrics.AddPool("mysql", mysqlDB)
+	}
+	userRepo = repository.InstrumentUserRepo(userRepo, queryMetrics)
+	boardRepo = repository.InstrumentBoardRepo(boardRepo, queryMetrics)
+	listRepo = repository.InstrumentListRepo(listRepo, queryMetrics)
+	cardRepo = repository.InstrumentCardRepo(cardRepo, queryMetrics)
+	checklistRepo = repository.InstrumentChecklistRepo(checklistRepo, queryMetrics)
+	embedRepo = repository.InstrumentEmbedTokenRepo(embedRepo, queryMetrics)
+	refreshRepo = repository.InstrumentRefreshTokenRepo(refreshRepo, queryMetrics)
+	resetRepo = repository.InstrumentPasswordResetRepo(resetRepo, queryMetrics)
+	memberRepo = repository.InstrumentMemberRepo(memberRepo, queryMetrics)
+	webhookRepo = repository.InstrumentWebhookRepo(webhookRepo, queryMetrics)
+	cardLinkRepo = repository.InstrumentCardLinkRepo(cardLinkRepo, queryMetrics)
+	activityRepo = repository.InstrumentActivityRepo(activityRepo, queryMetrics)
+	attachmentRepo = repository.InstrumentAttachmentRepo(attachmentRepo, queryMetrics)
+	storageRepo = repository.InstrumentStorageRepo(storageRepo, queryMetrics)
+	idempotencyRepo = repository.InstrumentIdempotencyRepo(idempotencyRepo, queryMetrics)
+	snapshotRepo = repository.InstrumentSnapshotRepo(snapshotRepo, queryMetrics)
+	loginAttemptRepo = repository.InstrumentLoginAttemptRepo(loginAttemptRepo, queryMetrics)
+	anomalyRepo = repository.InstrumentAnomalyRepo(anomalyRepo, queryMetrics)
+	cardTemplateRepo = repository.InstrumentCardTemplateRepo(cardTemplateRepo, queryMetrics)
+	identityRepo = repository.InstrumentUserIdentityRepo(identityRepo, queryMetrics)
+	apiKeyRepo = repository.InstrumentAPIKeyRepo(apiKeyRepo, queryMetrics)
+	healthCheckRepo = repository.InstrumentHealthCheckRepo(healthCheckRepo, queryMetrics)
+	incidentRepo = repository.InstrumentIncidentRepo(incidentRepo, queryMetrics)
+
+	// 创建搜索仓储：SQLite 下优先使用 FTS5 全文索引
+	// 没有编译 FTS5（需要 go build -tags sqlite_fts5），或者看板保存在 MySQL 中时，
+	// 退回到逐条扫描的朴素实现，功能一样，只是数据多了会慢
+	var searchRepo repository.SearchRepository
+	if os.Getenv("DB_DRIVER") != "mysql" {
+		searchRepo, err = repository.NewSQLiteSearchRepo(sqliteDB)
+		if errors.Is(err, repository.ErrFTS5Unavailable) {
+			logger.Warn("FTS5 unavailable, falling back to naive search", "err", err)
+		} else if err != nil {
+			fatal(err)
+		}
+	}
+	if searchRepo == nil {
+		searchRepo = repository.NewMemSearchRepo(boardRepo, listRepo, cardRepo)
+	}
+	searchRepo = repository.InstrumentSearchRepo(searchRepo, queryMetrics)
+
 	// 如果想使用内存实现（不持久化），可以取消下面这行的注释：
 	// boardRepo := repository.NewMemBoardRepo()
 	// 创建用户仓储（内存实现）
@@ -43,37 +325,481 @@ func main() {
 	// ========== 第二步：初始化业务逻辑层（Service） ==========
 
 	// 获取 JWT 密钥（从环境变量读取）
+	// 删除确认令牌和嵌入令牌总是用 JWT_SECRET 派生的密钥签名；
+	// 登录令牌默认也用它（HS256），配置了 JWT_SIGNING_KEY_FILE 时改用私钥签名（RS256 / EdDSA），见 jwtKeySet
 	jwtSecret := service.MustJWTSecret()
+	jwtKeys := jwtKeySet(jwtSecret)
+	logger.Info("jwt signing", "alg", jwtKeys.Alg())
 
 	// 创建认证服务
-	// 参数：用户仓储、JWT密钥、令牌有效期（24小时）
-	authSvc := service.NewAuthService(userRepo, jwtSecret, 24*time.Hour)
+	// 参数：用户仓储、刷新令牌仓储、JWT密钥、访问令牌有效期（24小时）、刷新令牌有效期（30天）
+	accessTTL, refreshTTL := 24*time.Hour, 30*24*time.Hour
+	authSvc := service.NewAuthService(userRepo, refreshRepo, resetRepo, jwtKeys, accessTTL, refreshTTL)
+
+	// 创建第三方登录服务，令牌和密码登录一样颁发
+	// 提供方从 OAUTH_* 环境变量读取（见 oauth.FromEnv），都没配置时第三方登录的接口只会返回 404
+	oauthProviders, err := oauth.FromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	oauthSvc := service.NewOAuthService(oauthProviders, userRepo, identityRepo, refreshRepo, jwtKeys, accessTTL, refreshTTL)
+	if len(oauthProviders) > 0 {
+		logger.Info("oauth login enabled", "providers", strings.Join(oauthSvc.Providers(), ","))
+	}
+
+	// 每次登录记下邮箱、IP 和成败，异常检测统计登录失败
+	authSvc = service.RecordLoginAttempts(authSvc, loginAttemptRepo)
+
+	// 创建个人 API 密钥服务：脚本、CI 用 X-API-Key 请求头代替登录令牌访问接口
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo)
+
+	// 把 ADMIN_EMAILS（逗号分隔）里的用户提升为管理员
+	// 否则第一个管理员没法产生：修改角色的接口本身就需要管理员权限
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		email = strings.TrimSpace(strings.ToLower(email))
+		if email == "" {
+			continue
+		}
+		u, err := userRepo.GetByEmail(context.Background(), email)
+		if err != nil {
+			logger.Warn("ADMIN_EMAILS: user must register first", "email", email, "err", err)
+			continue
+		}
+		if _, err := authSvc.SetRole(context.Background(), u.ID, model.UserRoleAdmin); err != nil {
+			fatal(err)
+		}
+	}
+
+	// 选择邮件发送器：设置了 SMTP_HOST 就用 SMTP 发送，否则用 noop（只把邮件打印到日志，开发用）
+	var sender mail.Sender
+	if os.Getenv("SMTP_HOST") != "" {
+		smtpCfg, err := mail.SMTPConfigFromEnv()
+		if err != nil {
+			fatal(err)
+		}
+		sender = mail.NewSMTPSender(smtpCfg)
+	} else {
+		logger.Info("SMTP_HOST not set, password reset mails will only be logged")
+		sender = mail.NewNoopSender()
+	}
+
+	// 重置链接前缀（环境变量 PASSWORD_RESET_URL），令牌拼在后面
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:8080/reset-password?token="
+	}
+
+	// 创建密码重置服务，重置令牌 30 分钟内有效
+	passwordSvc := service.NewPasswordService(userRepo, resetRepo, refreshRepo, sender, resetURL, 30*time.Minute)
+
+	// 删除看板时需要二次确认的卡片数阈值（环境变量 BOARD_DELETE_CONFIRM_THRESHOLD，默认 20）
+	deleteThreshold := envInt("BOARD_DELETE_CONFIRM_THRESHOLD", 20)
+
+	// 检查清单完成进度的缓存：清单 -> 卡片 -> 列表 -> 看板逐级汇总，看板第一次被读到时加载，
+	// 之后监听事件总线增量更新（下面的 bus.Listen），返回卡片、列表、看板时直接读
+	rollups := service.NewProgressRollups(listRepo, cardRepo, checklistRepo)
+
+	// StatsD 指标（可选）：设置了 STATSD_ADDR 才开启，给不用 Prometheus 的部署使用
+	// 每个请求的计数和耗时由 middleware.StatsD 发送，
+	// 看板的卡片统计每隔 STATSD_BOARD_INTERVAL（默认 1m）发送一次
+	var statsdClient *statsd.Client
+	if os.Getenv("STATSD_ADDR") != "" {
+		statsdCfg, err := statsd.ConfigFromEnv()
+		if err != nil {
+			fatal(err)
+		}
+		statsdClient, err = statsd.New(statsdCfg)
+		if err != nil {
+			fatal(err)
+		}
+		defer statsdClient.Close()
+
+		interval := time.Minute
+		if v := os.Getenv("STATSD_BOARD_INTERVAL"); v != "" {
+			interval, err = time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				fatal(fmt.Errorf("invalid STATSD_BOARD_INTERVAL: %q", v))
+			}
+		}
+		go service.NewBoardMetrics(cardRepo, rollups, statsdClient).Run(context.Background(), interval)
+		logger.Info("statsd enabled", "addr", statsdCfg.Addr, "board_interval", interval.String())
+	}
+
+	// 看板附件占用空间的计数器
+	// 每个看板的附件最多 BOARD_STORAGE_QUOTA 字节（默认 0，不限制）
+	// 每隔 BOARD_STORAGE_RECALC_INTERVAL（默认 24h，设为 0 关闭）按实际的附件重算一次，修正计数的偏差
+	storageCounters := service.NewStorageCounters(storageRepo, attachmentRepo, listRepo, cardRepo, int64(envInt("BOARD_STORAGE_QUOTA", 0)))
+	if interval := envDuration("BOARD_STORAGE_RECALC_INTERVAL", 24*time.Hour); interval > 0 {
+		go storageCounters.Run(context.Background(), interval)
+	}
+
+	// 创建看板时选了默认列表（"defaultLists": true）时创建的列表
+	// BOARD_DEFAULT_LISTS 用逗号分隔，默认 "待办,进行中,已完成"
+	var defaultLists []string
+	for _, t := range strings.Split(envString("BOARD_DEFAULT_LISTS", "待办,进行中,已完成"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			defaultLists = append(defaultLists, t)
+		}
+	}
 
 	// 创建看板服务
-	boardSvc := service.NewBoardService(boardRepo)
+	// 卡片数超过阈值的看板删除时要先确认，确认令牌用 JWT 密钥派生的密钥签名
+	boardSvc := service.NewBoardService(boardRepo, listRepo, cardRepo, checklistRepo, attachmentRepo, cardTemplateRepo, storageCounters, memberRepo, jwtSecret, deleteThreshold, defaultLists)
+
+	// 创建列表服务
+	listSvc := service.NewListService(listRepo, boardRepo, cardRepo, checklistRepo, attachmentRepo, storageCounters, memberRepo)
+
+	// 创建卡片服务
+	// 卡片上的清单进度从进度缓存里读
+	cardSvc := service.NewCardService(cardRepo, listRepo, rollups.Checklists(checklistRepo), attachmentRepo, storageCounters, cardTemplateRepo, boardRepo, memberRepo)
+
+	// 创建卡片模板服务（看板上预先定义好的卡片内容）
+	cardTemplateSvc := service.NewCardTemplateService(cardTemplateRepo, boardRepo, memberRepo)
+
+	// 创建检查清单服务（卡片中的清单和条目）
+	checklistSvc := service.NewChecklistService(checklistRepo, cardRepo, listRepo, boardRepo, memberRepo)
+
+	// 创建附件服务
+	// 文件保存在 ATTACHMENT_DIR（默认 ./attachments）下，按内容的 SHA-256 去重，同样的文件只保存一份
+	// 单个附件最大 ATTACHMENT_MAX_BYTES 字节（默认 25 MiB）
+	// 分块上传超过 ATTACHMENT_UPLOAD_TTL（默认 24h）没有收到新的内容就过期
+	// 图片附件（JPEG、PNG）保存前去掉 EXIF、GPS 等元数据，ATTACHMENT_STRIP_METADATA=false 关闭
+	// 没有附件再引用的文件和过期的分块上传每隔 ATTACHMENT_GC_INTERVAL（默认 10m，设为 0 关闭）删除一次
+	attachmentDir := envString("ATTACHMENT_DIR", "attachments")
+	blobStore, err := blob.NewDirStore(attachmentDir)
+	if err != nil {
+		fatal(err)
+	}
+	// 冷存储：归档看板上的文件移到 ATTACHMENT_COLD_DIR（默认 ATTACHMENT_DIR 下的 cold 目录），
+	// 可以挂到更便宜的磁盘上；下载时自动恢复
+	coldStore, err := blob.NewDirStore(envString("ATTACHMENT_COLD_DIR", filepath.Join(attachmentDir, "cold")))
+	if err != nil {
+		fatal(err)
+	}
+	attachmentBlobs := service.NewAttachmentBlobs(attachmentRepo, blobStore, coldStore)
+	attachmentMax := int64(envInt("ATTACHMENT_MAX_BYTES", 25<<20))
+	attachmentUploadTTL := envDuration("ATTACHMENT_UPLOAD_TTL", 24*time.Hour)
+	attachmentStrip := envBool("ATTACHMENT_STRIP_METADATA", true)
+	attachmentSvc := service.NewAttachmentService(attachmentRepo, attachmentBlobs, storageCounters, cardRepo, listRepo, boardRepo, memberRepo, attachmentMax, attachmentUploadTTL, attachmentStrip)
+	if interval := envDuration("ATTACHMENT_GC_INTERVAL", 10*time.Minute); interval > 0 {
+		go attachmentBlobs.Run(context.Background(), interval)
+	}
+	// 只读超过 ATTACHMENT_ARCHIVE_AFTER（默认 720h，设为 0 关闭）的看板，附件文件移到冷存储
+	// 每隔 ATTACHMENT_ARCHIVE_INTERVAL（默认 1h）检查一次
+	if after := envDuration("ATTACHMENT_ARCHIVE_AFTER", 30*24*time.Hour); after > 0 {
+		archiver := service.NewAttachmentArchiver(attachmentBlobs, storageCounters, boardRepo, after)
+		go archiver.Run(context.Background(), envDuration("ATTACHMENT_ARCHIVE_INTERVAL", time.Hour))
+	}
+
+	// 设置了 ANALYTICS_EXPORT_PATH 时，每隔 ANALYTICS_EXPORT_INTERVAL（默认 24h）把脱敏的分析视图导出到这个 SQLite 文件
+	// 分析人员的 BI 工具读导出的文件，不碰线上的库，见 internal/analytics
+	if path := os.Getenv("ANALYTICS_EXPORT_PATH"); path != "" {
+		src := analytics.Sources{SQLite: sqliteDB, MySQL: mysqlDB}
+		go analytics.Run(context.Background(), src, path, envDuration("ANALYTICS_EXPORT_INTERVAL", 24*time.Hour))
+	}
+
+	// 创建看板成员服务（邀请、移除成员）
+	memberSvc := service.NewMemberService(memberRepo, boardRepo, userRepo)
+
+	// 创建搜索服务（看板标题、卡片标题和描述）
+	searchSvc := service.NewSearchService(searchRepo, boardRepo, memberRepo)
+
+	// 创建看板嵌入服务（只读嵌入令牌的签发和校验）
+	embedSvc := service.NewEmbedService(embedRepo, boardRepo, listRepo, cardRepo, jwtSecret)
+
+	// 创建 webhook 服务（登记、删除看板的 webhook）
+	webhookSvc := service.NewWebhookService(webhookRepo, boardRepo, memberRepo)
+
+	// 创建卡片镜像服务（在别的看板上放一份卡片，标题和状态由下面的 MirrorSync 同步）
+	cardLinkSvc := service.NewCardLinkService(cardLinkRepo, cardRepo, listRepo, boardRepo, memberRepo)
+
+	// 创建看板动态服务（查询看板的操作记录）
+	activitySvc := service.NewActivityService(activityRepo, boardRepo, memberRepo)
+
+	// 创建看板每日快照（定时记录，查询给趋势图用）
+	snapshots := service.NewBoardSnapshots(snapshotRepo, boardRepo, listRepo, cardRepo, memberRepo)
+	var snapshotSvc service.SnapshotService = snapshots
+
+	// 创建分享链接解析服务（把粘贴的看板、卡片链接解析成对象 ID）
+	resolveSvc := service.NewResolveService(boardRepo, listRepo, cardRepo, memberRepo)
+
+	// 用看板动态装饰器包装会修改看板内容的服务，修改成功后写一条操作记录（谁、做了什么、改之前和改之后的内容）
+	boardSvc = service.RecordBoardService(boardSvc, activityRepo, boardRepo)
+	listSvc = service.RecordListService(listSvc, activityRepo)
+	cardSvc = service.RecordCardService(cardSvc, activityRepo)
+	cardLinkSvc = service.RecordCardLinkService(cardLinkSvc, activityRepo)
+	checklistSvc = service.RecordChecklistService(checklistSvc, activityRepo)
+	attachmentSvc = service.RecordAttachmentService(attachmentSvc, activityRepo)
+	memberSvc = service.RecordMemberService(memberSvc, activityRepo)
+	webhookSvc = service.RecordWebhookService(webhookSvc, activityRepo)
+	cardTemplateSvc = service.RecordCardTemplateService(cardTemplateSvc, activityRepo)
+	embedSvc = service.RecordEmbedService(embedSvc, activityRepo)
+
+	// 用事件发布装饰器包装会修改看板内容的服务，修改成功后往事件总线上发布事件，
+	// 通过 WebSocket 推送给订阅了这个看板的客户端
+	// 要包在链路追踪装饰器里面，发布事件的耗时算在服务 span 里
+	bus := events.NewBus()
+	boardSvc = service.PublishBoardService(boardSvc, bus)
+	listSvc = service.PublishListService(listSvc, bus)
+	cardSvc = service.PublishCardService(cardSvc, bus)
+	cardLinkSvc = service.PublishCardLinkService(cardLinkSvc, bus)
+	checklistSvc = service.PublishChecklistService(checklistSvc, bus)
+	attachmentSvc = service.PublishAttachmentService(attachmentSvc, bus)
+	memberSvc = service.PublishMemberService(memberSvc, bus)
+
+	// 进度缓存按事件增量更新；填进度的装饰器包在事件发布装饰器外面，返回的就是这次修改之后的进度
+	bus.Listen(rollups.Handle)
+	boardSvc = service.ProgressBoardService(boardSvc, rollups)
+	listSvc = service.ProgressListService(listSvc, rollups)
+
+	// 声明式看板配置通过上面包装好的服务执行，和手动操作一样记录活动、推送事件
+	provisionSvc := service.NewProvisionService(boardSvc, listSvc, memberSvc, userRepo)
+
+	// webhook 投递：分发器监听事件总线，把事件异步 POST 到看板登记的 URL
+	// 配置见 webhook.ConfigFromEnv（WEBHOOK_WORKERS、WEBHOOK_MAX_ATTEMPTS、WEBHOOK_TIMEOUT、WEBHOOK_ALLOW_PRIVATE）
+	webhookCfg, err := webhook.ConfigFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	dispatcher := webhook.NewDispatcher(webhookRepo, webhookCfg)
+	bus.Listen(dispatcher.Handle)
+	go dispatcher.Run(context.Background())
+	if webhookCfg.AllowPrivate {
+		logger.Warn("WEBHOOK_ALLOW_PRIVATE is set, webhooks may target internal addresses")
+	}
+
+	// 卡片镜像同步：监听卡片的修改、移动、删除，把标题和状态（所在列表）同步到链接另一头的卡片
+	mirrorSync := service.NewMirrorSync(cardLinkRepo, cardRepo, listRepo, boardRepo, bus)
+	bus.Listen(mirrorSync.Handle)
+	go mirrorSync.Run(context.Background())
+
+	// 截止日期提醒：每隔 REMINDER_INTERVAL（默认 1m，设为 0 关闭）检查一次，
+	// 打开了提醒、截止日期在 REMINDER_LEAD（默认 24h）之内的卡片提醒一次（日志、card.due_soon 事件、邮件）
+	// 放在 bus.Listen 之后启动，第一轮检查发出的事件也能投递给 webhook
+	if interval := envDuration("REMINDER_INTERVAL", time.Minute); interval > 0 {
+		lead := envDuration("REMINDER_LEAD", 24*time.Hour)
+		go service.NewReminders(cardRepo, boardRepo, memberRepo, userRepo, bus, sender, lead).Run(context.Background(), interval)
+		logger.Info("card reminders enabled", "interval", interval.String(), "lead", lead.String())
+	}
+
+	// 看板每日快照：启动时记一次，之后每天 UTC 的 SNAPSHOT_TIME（默认 23:55）记一次，设为 off 关闭
+	snapshotAt := envString("SNAPSHOT_TIME", "23:55")
+	if at, ok := snapshotTime(snapshotAt); ok {
+		go snapshots.Run(context.Background(), at)
+		logger.Info("board snapshots enabled", "at", snapshotAt+" UTC")
+	}
+
+	// 异常检测：每个 ANOMALY_WINDOW（默认 10m，设为 0 关闭）统计一次看板动态和登录失败，
+	// 超过阈值的记下来、写日志、在看板动态里标记，并给管理员发邮件；阈值见 ANOMALY_* 环境变量
+	anomalyWindow := envDuration("ANOMALY_WINDOW", 10*time.Minute)
+	detector := service.NewAnomalyDetector(activityRepo, loginAttemptRepo, anomalyRepo, userRepo, sender, anomalyWindow, service.AnomalyThresholds{
+		ActivitySpike: envInt("ANOMALY_ACTIVITY_SPIKE", 500),
+		MassDeletion:  envInt("ANOMALY_MASS_DELETION", 50),
+		LoginFailures: envInt("ANOMALY_LOGIN_FAILURES", 20),
+	})
+	var anomalySvc service.AnomalyService = detector
+
+	// 公开状态页：每个 HEALTH_CHECK_INTERVAL（默认 1m，设为 0 关闭）探测一轮，结果记下来算可用率和 SLO
+	// 用户和看板可能在 MySQL 中，卡片总是在 SQLite 中，和就绪检查（/readyz）探测同样的三个存储；
+	// 另外探测附件目录能不能写入，和 webhook 队列有没有积压
+	stores := map[string]repository.Pinger{
+		"users":  userRepo,
+		"boards": boardRepo,
+		"cards":  cardRepo,
+	}
+	probes := map[string]service.HealthProbe{
+		"attachments":   service.PingProbe(blobStore),
+		"webhook_queue": service.QueueProbe(dispatcher.QueueDepth),
+	}
+	for name, p := range stores {
+		probes[name] = service.PingProbe(p)
+	}
+	healthInterval := envDuration("HEALTH_CHECK_INTERVAL", time.Minute)
+	sloTarget := envFloat("HEALTH_SLO_TARGET", 99.9)
+	if sloTarget <= 0 || sloTarget > 100 {
+		fatal(fmt.Errorf("invalid HEALTH_SLO_TARGET: %v, must be in (0, 100]", sloTarget))
+	}
+	monitor := service.NewHealthMonitor(healthCheckRepo, incidentRepo, probes, healthInterval, sloTarget)
+	if healthInterval > 0 {
+		go monitor.Run(context.Background())
+		logger.Info("health checks enabled", "interval", healthInterval.String())
+	}
+	var statusSvc service.StatusService = monitor
+
+	// 运维接口（/admin/v1）：查找用户和看板、停用账号、汇总统计
+	adminSvc := service.NewAdminService(userRepo, refreshRepo, boardRepo, listRepo, cardRepo, memberRepo)
+	if anomalyWindow > 0 {
+		go detector.Run(context.Background())
+		logger.Info("anomaly detection enabled", "window", anomalyWindow.String())
+	}
+
+	// 用链路追踪装饰器包装所有服务，每次服务方法调用生成一个 span
+	// 和仓储的统计装饰器一样，处理器拿到的仍然是同样的接口
+	authSvc = service.TraceAuthService(authSvc)
+	oauthSvc = service.TraceOAuthService(oauthSvc)
+	apiKeySvc = service.TraceAPIKeyService(apiKeySvc)
+	passwordSvc = service.TracePasswordService(passwordSvc)
+	boardSvc = service.TraceBoardService(boardSvc)
+	listSvc = service.TraceListService(listSvc)
+	cardSvc = service.TraceCardService(cardSvc)
+	checklistSvc = service.TraceChecklistService(checklistSvc)
+	attachmentSvc = service.TraceAttachmentService(attachmentSvc)
+	memberSvc = service.TraceMemberService(memberSvc)
+	embedSvc = service.TraceEmbedService(embedSvc)
+	searchSvc = service.TraceSearchService(searchSvc)
+	webhookSvc = service.TraceWebhookService(webhookSvc)
+	cardLinkSvc = service.TraceCardLinkService(cardLinkSvc)
+	cardTemplateSvc = service.TraceCardTemplateService(cardTemplateSvc)
+	activitySvc = service.TraceActivityService(activitySvc)
+	resolveSvc = service.TraceResolveService(resolveSvc)
+	snapshotSvc = service.TraceSnapshotService(snapshotSvc)
+	anomalySvc = service.TraceAnomalyService(anomalySvc)
+	adminSvc = service.TraceAdminService(adminSvc)
+	statusSvc = service.TraceStatusService(statusSvc)
+
+	// 注销账号：转让和删除看板走包装好的看板服务，撤销注销后走包装好的认证服务登录
+	// 注销后 ACCOUNT_DELETION_GRACE（默认 14 天）内可以撤销，之后由每小时一次的清理任务处理看板、删除用户
+	accounts := service.NewAccountDeleter(userRepo, refreshRepo, boardRepo, memberRepo, boardSvc, authSvc, envDuration("ACCOUNT_DELETION_GRACE", 14*24*time.Hour))
+	go accounts.Run(context.Background(), time.Hour)
+	accountSvc := service.TraceAccountService(accounts)
+	provisionSvc = service.TraceProvisionService(provisionSvc)
 
 	// ========== 第三步：初始化 HTTP 处理器层（Handler） ==========
 
 	// 创建认证处理器
 	authH := httpx.NewAuthHandler(authSvc)
 
+	// 创建第三方登录处理器
+	// 登录完成后跳转到 OAUTH_SUCCESS_URL（令牌在 # 后面），没有设置时回调直接返回 JSON
+	// 回调地址是 https 时，state cookie 只通过 HTTPS 发送
+	oauthH := httpx.NewOAuthHandler(oauthSvc, os.Getenv("OAUTH_SUCCESS_URL"), strings.HasPrefix(os.Getenv("OAUTH_REDIRECT_BASE"), "https://"))
+
+	// 创建个人 API 密钥处理器
+	apiKeyH := httpx.NewAPIKeyHandler(apiKeySvc)
+
+	// 创建密码重置处理器
+	passwordH := httpx.NewPasswordHandler(passwordSvc)
+
 	// 创建看板处理器
 	boardH := httpx.NewBoardHandler(boardSvc)
 
+	// 创建列表处理器
+	listH := httpx.NewListHandler(listSvc)
+
+	// 创建卡片处理器
+	cardH := httpx.NewCardHandler(cardSvc)
+
+	// 创建检查清单处理器
+	checklistH := httpx.NewChecklistHandler(checklistSvc)
+
+	// 创建附件处理器
+	attachmentH := httpx.NewAttachmentHandler(attachmentSvc, attachmentMax)
+
+	// 创建看板成员处理器
+	memberH := httpx.NewMemberHandler(memberSvc)
+
+	// 创建搜索处理器
+	searchH := httpx.NewSearchHandler(searchSvc)
+
+	// 创建看板嵌入处理器
+	embedH := httpx.NewEmbedHandler(embedSvc)
+
+	// 创建看板动态处理器
+	activityH := httpx.NewActivityHandler(activitySvc)
+
+	// 创建看板每日快照处理器
+	snapshotH := httpx.NewSnapshotHandler(snapshotSvc)
+
+	// 创建卡片镜像处理器
+	cardLinkH := httpx.NewCardLinkHandler(cardLinkSvc)
+
+	// 创建 webhook 处理器
+	webhookH := httpx.NewWebhookHandler(webhookSvc)
+
+	// 创建卡片模板处理器
+	cardTemplateH := httpx.NewCardTemplateHandler(cardTemplateSvc)
+
+	// 创建分享链接解析处理器
+	resolveH := httpx.NewResolveHandler(resolveSvc)
+
+	// 创建声明式看板配置处理器
+	provisionH := httpx.NewProvisionHandler(provisionSvc)
+
+	// 创建管理员处理器
+	adminH := httpx.NewAdminHandler(authSvc, boardSvc)
+
+	// 创建注销账号处理器
+	accountH := httpx.NewAccountHandler(accountSvc)
+
+	// 创建运维接口处理器（/admin/v1）
+	operatorH := httpx.NewOperatorHandler(adminSvc, boardSvc)
+
+	// 创建健康检查处理器，三个存储都能 ping 通才算就绪
+	healthH := httpx.NewHealthHandler(stores)
+
+	// 创建公开状态页处理器
+	statusH := httpx.NewStatusHandler(statusSvc)
+
+	// 创建登录令牌公钥处理器，其它服务用公钥验证我们签发的访问令牌
+	jwksH := httpx.NewJWKSHandler(jwtKeys)
+
+	// 创建运维指标处理器
+	// /metrics 需要 METRICS_TOKEN，数据库统计只给管理员看
+	metricsH := httpx.NewMetricsHandler(queryMetrics, os.Getenv("METRICS_TOKEN"), monitor)
+
+	// 创建运行时日志设置处理器（管理员接口）
+	logH := httpx.NewLogHandler()
+
+	// 创建版本信息处理器
+	versionH := httpx.NewVersionHandler()
+
+	// 创建请求抓包处理器（管理员接口）
+	// 抓到的请求保存在环形缓冲区里，最多 CAPTURE_BUFFER_SIZE 条（默认 100），满了覆盖最旧的
+	captureRec := capture.NewRecorder(envInt("CAPTURE_BUFFER_SIZE", 100))
+	captureH := httpx.NewCaptureHandler(captureRec)
+
+	// 创建异常检测结果处理器（管理员接口）
+	anomalyH := httpx.NewAnomalyHandler(anomalySvc)
+
+	// 创建弃用接口使用报告处理器（管理员接口）
+	deprecationH := httpx.NewDeprecationHandler()
+
+	// 创建 WebSocket 实时推送处理器
+	wsH := httpx.NewWSHandler(boardSvc, bus)
+
+	// 创建看板变更事件流处理器（SSE），和 WebSocket 推送同样的事件
+	eventsH := httpx.NewEventStreamHandler(boardSvc, bus)
+
 	// ========== 第四步：配置路由和中间件 ==========
 
 	// gin.New() 创建一个不带默认中间件的 Gin 引擎
 	// 对比：gin.Default() 会自动添加 Logger 和 Recovery 中间件
 	r := gin.New()
 
+	// 受信任的反向代理（环境变量 TRUSTED_PROXIES，逗号分隔的 IP 或 CIDR，例如 10.0.0.0/8）
+	// 只有请求来自这些地址时，c.ClientIP() 才会读 X-Forwarded-For，否则用 TCP 连接的对端地址
+	// Gin 默认信任所有代理，客户端伪造 X-Forwarded-For 就能冒充任意 IP，绕过按 IP 的限流
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		fatal(err)
+	}
+
 	// r.Use() 注册全局中间件
 	// 中间件按注册顺序执行
-	// 执行顺序：RequestID -> Logger -> Recovery -> RecoverJSON -> 处理器
+	// 执行顺序：RequestID -> Logger -> Tracing -> StatsD -> Capture -> Recovery -> RecoverJSON -> 处理器
 	r.Use(
-		middleware.RequestID(),   // 为每个请求生成唯一 ID
-		middleware.Logger(),      // 记录请求日志
-		gin.Recovery(),           // Gin 自带的 panic 恢复中间件
-		middleware.RecoverJSON(), // 自定义的 JSON 格式错误恢复
+		middleware.RequestID(),          // 为每个请求生成唯一 ID
+		middleware.Logger(logger),       // 记录请求日志，并把带请求 ID 的 logger 放进请求 ctx
+		middleware.Tracing(),            // 链路追踪，放在 Recovery 外面才能看到 panic 后的 500
+		middleware.StatsD(statsdClient), // 请求计数和耗时，没有配置 STATSD_ADDR 时什么都不做
+		middleware.Capture(captureRec),  // 请求抓包，管理员打开之前什么都不做
+		gin.Recovery(),                  // Gin 自带的 panic 恢复中间件
+		middleware.RecoverJSON(),        // 自定义的 JSON 格式错误恢复
 	)
 
 	// 注意：gin.Recovery() 和 middleware.RecoverJSON() 功能类似
@@ -88,38 +814,220 @@ func main() {
 	// 1. 统一路径前缀（这里是 "api/v1"）
 	// 2. 统一应用中间件
 
+	// 运维接口：直接挂在根路径下
+	if !metricsH.Register(r) {
+		logger.Info("metrics endpoint disabled, set METRICS_TOKEN to enable /metrics")
+	}
+	healthH.Register(r)
+	versionH.Register(r)
+
 	// 公共路由组：不需要认证
 	// 包含：注册、登录接口
-	public := r.Group("api/v1")
-	authH.RegisterRoutes(public)
+	// 限流（令牌桶，单位是每分钟请求数，设为 0 关闭）：
+	// - 公共接口按 IP：RATE_LIMIT_IP_RPM，默认 120
+	// - 登录后的接口按用户：RATE_LIMIT_USER_RPM，默认 300
+	// - 注册、登录、刷新令牌、忘记密码和重置密码在按 IP 限流之外再加一道更严格的限制：RATE_LIMIT_AUTH_RPM，默认 10
+	// userLimit 同时挂在私有和管理员路由组上，同一个用户在两组接口上共用一个桶
+	ipLimit := middleware.RateLimit(envInt("RATE_LIMIT_IP_RPM", 120), middleware.KeyByIP)
+	userLimit := middleware.RateLimit(envInt("RATE_LIMIT_USER_RPM", 300), middleware.KeyByUser)
+	authLimit := middleware.RateLimit(envInt("RATE_LIMIT_AUTH_RPM", 10), middleware.KeyByIP)
+
+	public := r.Group("api/v1", ipLimit)
+	authH.RegisterRoutes(public, authLimit)
+	passwordH.RegisterRoutes(public, authLimit)
+	oauthH.RegisterRoutes(public, authLimit)
+	accountH.RegisterRoutes(public, authLimit)
+	embedH.RegisterPublic(public) // 嵌入接口凭嵌入令牌访问，不需要登录
+
+	// 状态页和 JWKS 挂在根路径下，和公共接口共用按 IP 的限流
+	statusH.RegisterPublic(r, ipLimit)
+	jwksH.Register(r, ipLimit)
 
 	// 私有路由组：需要认证
-	// middleware.AuthRequired(jwtSecret) 是认证中间件
-	// 只有携带有效 JWT 令牌的请求才能访问这组路由
-	private := r.Group("api/v1", middleware.AuthRequired(jwtSecret))
-	boardH.Register(private)
+	// middleware.AuthRequired(jwtKeys, authSvc, apiKeySvc) 是认证中间件
+	// 只有携带有效 JWT 令牌（或者 X-API-Key 请求头里有效的 API 密钥）的请求才能访问这组路由
+	private := r.Group("api/v1", middleware.AuthRequired(jwtKeys, authSvc, apiKeySvc), userLimit)
+
+	// 账号路由组：改密码、注销账号、管理 API 密钥，只接受登录令牌，不接受 API 密钥
+	session := r.Group("api/v1", middleware.AuthRequired(jwtKeys, authSvc, apiKeySvc), userLimit, middleware.SessionOnly())
+	authH.RegisterPrivate(session)
+	accountH.RegisterPrivate(session)
+	apiKeyH.Register(session)
+
+	// 创建看板和卡片支持 Idempotency-Key：同一个键重发的请求直接返回第一次的响应
+	// 记录保存 IDEMPOTENCY_KEY_TTL（默认 24h），过期的记录每小时清理一次
+	idempotent := middleware.Idempotency(idempotencyRepo, envDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour))
+	go middleware.PurgeIdempotencyKeys(context.Background(), idempotencyRepo, time.Hour)
+	boardH.Register(private, idempotent)
+	listH.Register(private)
+	cardH.Register(private, idempotent)
+	checklistH.Register(private)
+	attachmentH.Register(private)
+	embedH.Register(private)
+	memberH.Register(private)
+	searchH.Register(private)
+	webhookH.Register(private)
+	cardLinkH.Register(private)
+	cardTemplateH.Register(private)
+	activityH.Register(private)
+	snapshotH.Register(private)
+	resolveH.Register(private)
+	provisionH.Register(private)
+
+	// 实时推送路由组（WebSocket、SSE）：和私有路由组一样需要认证，
+	// 但浏览器没法给 WebSocket 和 EventSource 加 Authorization 请求头，所以另外允许用 ?token= 传令牌
+	realtime := r.Group("api/v1", middleware.TokenFromQuery("token"), middleware.AuthRequired(jwtKeys, authSvc, apiKeySvc), userLimit)
+	wsH.Register(realtime)
+	eventsH.Register(realtime)
+
+	// 管理员路由组：在认证之后再检查用户当前的角色，不是 admin 返回 403
+	// 不接受 API 密钥（AuthRequired 的最后一个参数是 nil）
+	admin := r.Group("api/v1", middleware.AuthRequired(jwtKeys, authSvc, nil), userLimit, middleware.RequireRole(model.UserRoleAdmin))
+	adminH.Register(admin)
+	logH.Register(admin)
+	captureH.Register(admin)
+	deprecationH.Register(admin)
+	anomalyH.Register(admin)
+	metricsH.RegisterAdmin(admin)
+	statusH.RegisterAdmin(admin)
+
+	// 运维接口路由组（/admin/v1）：和管理员路由组一样先认证再检查角色，
+	// 单独一个前缀，方便在网关上只对内网开放，版本也和面向用户的 /api/v1 分开演进
+	operator := r.Group("admin/v1", middleware.AuthRequired(jwtKeys, authSvc, nil), userLimit, middleware.RequireRole(model.UserRoleAdmin))
+	operatorH.Register(operator)
 
 	// ========== 第六步：启动 HTTP 服务器 ==========
 
-	log.Println("listen on :8080")
-	log.Println("公共接口（无需登录）：")
-	log.Println("  POST http://localhost:8080/api/v1/auth/register")
-	log.Println("  POST http://localhost:8080/api/v1/auth/login")
-	log.Println("私有接口（需要登录）：")
-	log.Println("  GET    http://localhost:8080/api/v1/boards")
-	log.Println("  POST   http://localhost:8080/api/v1/boards")
-	log.Println("  GET    http://localhost:8080/api/v1/boards/:id")
-	log.Println("  PUT    http://localhost:8080/api/v1/boards/:id")
-	log.Println("  DELETE http://localhost:8080/api/v1/boards/:id")
+	// 启动时列出所有路由，方便对照 README 调试；debug 级别，默认不打印
+	// 想看的话用 LOG_LEVEL=debug 启动
+	for _, rt := range r.Routes() {
+		logger.Debug("route", "method", rt.Method, "path", rt.Path)
+	}
+	logger.Info("listen on :8080")
 
 	// r.Run() 启动 HTTP 服务器
 	// 参数 ":8080" 表示监听所有网络接口的 8080 端口
 	// 等价于 "0.0.0.0:8080"
 	// 如果只想本地访问，可以用 "127.0.0.1:8080" 或 "localhost:8080"
 	if err := r.Run(":8080"); err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	// 注意：r.Run() 会阻塞，下面的代码不会执行
 	// 除非服务器停止（例如按 Ctrl+C）
 }
+
+// envInt 读取非负整数环境变量，没有设置时返回默认值，格式不对时退出
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		fatal(fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return n
+}
+
+// envString 读取字符串环境变量，没有设置时返回默认值
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envDuration 读取非负时长环境变量（例如 30s、24h），没有设置时返回默认值，格式不对时退出
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		fatal(fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return d
+}
+
+// envFloat 读取非负小数环境变量，没有设置时返回默认值，格式不对时退出
+func envFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		fatal(fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return f
+}
+
+// envBool 读取布尔环境变量（true / false / 1 / 0），没有设置时返回默认值，格式不对时退出
+func envBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		fatal(fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return b
+}
+
+// snapshotTime 解析 SNAPSHOT_TIME（UTC 的 HH:MM），返回从零点算起的时间；off 表示关闭，格式不对时退出
+func snapshotTime(v string) (time.Duration, bool) {
+	if v == "off" {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		fatal(fmt.Errorf("invalid SNAPSHOT_TIME: %q", v))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// jwtKeySet 创建登录令牌的签名和验证密钥，配置不对时退出
+// - 没有设置 JWT_SIGNING_KEY_FILE：用 JWT_SECRET 按 HS256 签名和验证
+// - JWT_SIGNING_KEY_FILE：PEM 格式的 RSA 或 Ed25519 私钥，按 RS256 / EdDSA 签名，公钥出现在 /.well-known/jwks.json
+// - JWT_VERIFY_KEY_FILES：逗号分隔的公钥文件，换私钥后继续接受旧私钥签发的令牌
+// - JWT_ACCEPT_HS256=true：从 HS256 切换过来时继续接受已经签发的 HS256 令牌，等它们过期（访问令牌的有效期）后去掉
+func jwtKeySet(secret []byte) *jwtkeys.Set {
+	path := os.Getenv("JWT_SIGNING_KEY_FILE")
+	if path == "" {
+		return jwtkeys.NewHMAC(secret)
+	}
+	signKey, err := jwtkeys.LoadPrivateKey(path)
+	if err != nil {
+		fatal(fmt.Errorf("invalid JWT_SIGNING_KEY_FILE: %w", err))
+	}
+	var extra []crypto.PublicKey
+	for _, p := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		k, err := jwtkeys.LoadPublicKey(p)
+		if err != nil {
+			fatal(fmt.Errorf("invalid JWT_VERIFY_KEY_FILES: %w", err))
+		}
+		extra = append(extra, k)
+	}
+	var hmacSecret []byte
+	if envBool("JWT_ACCEPT_HS256", false) {
+		hmacSecret = secret
+	}
+	keys, err := jwtkeys.NewAsymmetric(signKey, hmacSecret, extra...)
+	if err != nil {
+		fatal(fmt.Errorf("invalid jwt keys: %w", err))
+	}
+	return keys
+}
+
+// fatal 打印启动阶段的致命错误并退出
+// 替代 log.Fatal：错误按 error 级别输出，JSON 格式下也是一条完整的结构化日志
+func fatal(err error) {
+	slog.Error("startup failed", "err", err)
+	os.Exit(1)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"kanban_api/internal/migrations"
+	"kanban_api/internal/repository"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// migrateOnStart 服务启动时处理数据库迁移，name 只用于日志（sqlite / mysql）
+// mode 来自环境变量 MIGRATE_ON_START：
+//   - apply：执行所有还没执行过的迁移（默认）
+//   - verify：只检查，有没执行的迁移时返回错误，服务不启动；迁移由发布流程用 migrate up 单独执行
+//
+// 被跳过的迁移（例如已有重复邮箱导致无法建唯一索引）只打印警告，不阻止启动
+// 库里建过分析视图时，迁移前后会删掉再重建，见 keepAnalyticsViews
+func migrateOnStart(logger *slog.Logger, name string, db *gorm.DB, mode string) error {
+	switch mode {
+	case "apply":
+		var applied, warnings []string
+		err := keepAnalyticsViews(db, func() (err error) {
+			applied, warnings, err = migrations.Up(db, 0)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, id := range applied {
+			logger.Info("migration applied", "db", name, "id", id)
+		}
+		for _, w := range warnings {
+			logger.Warn("migration skipped", "db", name, "detail", w)
+		}
+	case "verify":
+		if err := migrations.Verify(db); err != nil {
+			return fmt.Errorf("%s: %w (run `kanban-server migrate up` first)", name, err)
+		}
+	default:
+		return fmt.Errorf("unknown MIGRATE_ON_START %q (want apply or verify)", mode)
+	}
+
+	// 启动检查：期望的索引缺失时打印警告，提醒运维处理
+	for _, idx := range migrations.MissingIndexes(db) {
+		logger.Warn("missing index", "db", name, "index", idx)
+	}
+	return nil
+}
+
+// migrateTarget migrate 子命令要处理的一个数据库
+type migrateTarget struct {
+	name string
+	db   *gorm.DB
+}
+
+// migrateCommand 子命令 kanban-server migrate，执行完返回退出码，不启动服务
+//
+//	migrate up [n]    执行还没执行过的迁移，给了 n 时最多执行 n 个
+//	migrate down [n]  回滚最后 n 个已执行的迁移，默认 1 个
+//	migrate status    列出所有迁移和执行时间
+//
+// 作用于 SQLite 文件；DB_DRIVER=mysql 时 MySQL 也执行同样的操作（n 对两个库分别计算）
+func migrateCommand(sqlitePath string, args []string) int {
+	if len(args) == 0 || len(args) > 2 {
+		return migrateUsage()
+	}
+	switch args[0] {
+	case "up", "down", "status":
+	default:
+		return migrateUsage()
+	}
+	n := 0
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v <= 0 || args[0] == "status" {
+			return migrateUsage()
+		}
+		n = v
+	}
+
+	sqliteDB, err := migrations.OpenSQLite(sqlitePath)
+	if err != nil {
+		slog.Error("migrate failed", "err", err)
+		return 1
+	}
+	defer migrations.Close(sqliteDB)
+	targets := []migrateTarget{{"sqlite", sqliteDB}}
+	if os.Getenv("DB_DRIVER") == "mysql" {
+		cfg, err := repository.MySQLConfigFromEnv()
+		if err != nil {
+			slog.Error("migrate failed", "err", err)
+			return 1
+		}
+		mysqlDB, err := repository.OpenMySQL(cfg)
+		if err != nil {
+			slog.Error("migrate failed", "err", err)
+			return 1
+		}
+		defer migrations.Close(mysqlDB)
+		targets = append(targets, migrateTarget{"mysql", mysqlDB})
+	}
+
+	for _, t := range targets {
+		if err := runMigrate(t.name, t.db, args[0], n); err != nil {
+			slog.Error("migrate failed", "db", t.name, "err", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// runMigrate 在一个数据库上执行 migrate 的子命令，结果打印到标准输出
+func runMigrate(name string, db *gorm.DB, cmd string, n int) error {
+	switch cmd {
+	case "up":
+		var applied, warnings []string
+		err := keepAnalyticsViews(db, func() (err error) {
+			applied, warnings, err = migrations.Up(db, n)
+			return err
+		})
+		for _, id := range applied {
+			fmt.Printf("%s: applied %s\n", name, id)
+		}
+		for _, w := range warnings {
+			fmt.Printf("%s: skipped %s\n", name, w)
+		}
+		if err == nil && len(applied) == 0 && len(warnings) == 0 {
+			fmt.Printf("%s: up to date\n", name)
+		}
+		return err
+	case "down":
+		var reverted []string
+		err := keepAnalyticsViews(db, func() (err error) {
+			reverted, err = migrations.Down(db, n)
+			return err
+		})
+		for _, id := range reverted {
+			fmt.Printf("%s: reverted %s\n", name, id)
+		}
+		return err
+	case "status":
+		statuses, err := migrations.Statuses(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s: %-32s %s\n", name, s.ID, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", cmd)
+	}
+	return nil
+}
+
+// migrateUsage 打印 migrate 子命令的用法，返回退出码 2
+func migrateUsage() int {
+	fmt.Fprintln(os.Stderr, "usage: kanban-server migrate up [n] | down [n] | status")
+	return 2
+}
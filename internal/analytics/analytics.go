@@ -0,0 +1,259 @@
+// Package analytics 给数据分析用的只读 SQL 接口
+//
+// 分析人员用 BI 工具查看板、卡片的数据时，不应该直接连业务表：业务表里有密码哈希、各种令牌和 webhook 密钥，
+// 表结构也会随着迁移变化。这里定义一组脱敏的视图（analytics_ 开头），只包含统计需要的列，
+// 有两种用法：
+//
+//   - CreateViews 在数据库里建视图，给分析账号只授予这些视图的 SELECT 权限
+//   - Export 把视图的内容导出成一个单独的 SQLite 文件，BI 工具打开这个文件，完全不碰线上的库；
+//     服务可以定时导出（见 Run）
+//
+// 不包含的内容：邮箱、密码哈希、刷新令牌、密码重置令牌、嵌入令牌、webhook（地址和密钥）、
+// 卡片描述和清单条目正文这类大段的自由文本、动态里变更前后的完整内容
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"kanban_api/internal/logging"
+	"os"
+	"strings"
+	"time"
+)
+
+// View 一个分析视图
+type View struct {
+	// Name 视图名，也是导出文件里的表名
+	Name string
+
+	// Select 视图的查询，只用 SQLite 和 MySQL 都支持的写法
+	Select string
+
+	// Core 数据在用户 / 看板库里：DB_DRIVER=mysql 时从 MySQL 读，否则和其它视图一样从 SQLite 读
+	Core bool
+}
+
+// Views 所有分析视图
+// 业务表加了列不会自动出现在这里，要在这里加上，确认不是敏感内容
+var Views = []View{
+	{Name: "analytics_users", Core: true, Select: "SELECT `id`, `role`, `created_at` FROM `user_rows`"},
+	{Name: "analytics_boards", Core: true, Select: "SELECT `id`, `owner_id`, `title`, `slug`, `state`, `version`, `created_at`, `updated_at` FROM `board_rows`"},
+	{Name: "analytics_board_members", Select: "SELECT `board_id`, `user_id`, `role`, `created_at` FROM `board_member_rows`"},
+	{Name: "analytics_lists", Select: "SELECT `id`, `board_id`, `title`, `position`, `created_at`, `updated_at` FROM `list_rows`"},
+	{Name: "analytics_cards", Select: "SELECT `id`, `board_id`, `list_id`, `title`, `position`, `due_date`, `created_at`, `updated_at` FROM `card_rows`"},
+	{Name: "analytics_checklists", Select: "SELECT `id`, `card_id`, `title`, `position`, `created_at` FROM `checklist_rows`"},
+	{Name: "analytics_checklist_items", Select: "SELECT `id`, `checklist_id`, `done`, `position`, `completed_at`, `created_at` FROM `checklist_item_rows`"},
+	{Name: "analytics_attachments", Select: "SELECT `id`, `card_id`, `content_type`, `size`, `created_by`, `created_at` FROM `attachment_rows`"},
+	{Name: "analytics_activity", Select: "SELECT `seq`, `board_id`, `actor_id`, `action`, `target_id`, `created_at` FROM `activity_rows`"},
+}
+
+// Sources 从哪里读数据
+// MySQL 为 nil 时所有视图都从 SQLite 读
+type Sources struct {
+	SQLite *gorm.DB
+	MySQL  *gorm.DB
+}
+
+// db 视图 v 的数据所在的库
+func (s Sources) db(v View) *gorm.DB {
+	if v.Core && s.MySQL != nil {
+		return s.MySQL
+	}
+	return s.SQLite
+}
+
+// CreateViews 在各自的库里创建（或者按最新的定义重建）分析视图，返回建好的视图名
+// 视图没有写入的路径，本身就是只读的；MySQL 上还要给分析账号单独授权，只授予这些视图的 SELECT
+func CreateViews(ctx context.Context, src Sources) ([]string, error) {
+	var names []string
+	for _, v := range Views {
+		if err := createView(src.db(v).WithContext(ctx), v); err != nil {
+			return names, err
+		}
+		names = append(names, v.Name)
+	}
+	return names, nil
+}
+
+func createView(db *gorm.DB, v View) error {
+	if err := db.Exec(fmt.Sprintf("DROP VIEW IF EXISTS `%s`", v.Name)).Error; err != nil {
+		return fmt.Errorf("drop view %s: %w", v.Name, err)
+	}
+	if err := db.Exec(fmt.Sprintf("CREATE VIEW `%s` AS %s", v.Name, v.Select)).Error; err != nil {
+		return fmt.Errorf("create view %s: %w", v.Name, err)
+	}
+	return nil
+}
+
+// DropViews 删除 db 里已有的分析视图，返回删掉的视图名，迁移之后用 RestoreViews 重建
+// SQLite 删列、改表时会检查引用这张表的视图，视图还在的话 ALTER TABLE ... DROP COLUMN 直接失败
+func DropViews(ctx context.Context, db *gorm.DB) ([]string, error) {
+	db = db.WithContext(ctx)
+	q := "SELECT name FROM sqlite_master WHERE type = 'view'"
+	if db.Dialector.Name() == "mysql" {
+		q = "SELECT table_name FROM information_schema.views WHERE table_schema = DATABASE()"
+	}
+	var existing []string
+	if err := db.Raw(q).Scan(&existing).Error; err != nil {
+		return nil, err
+	}
+	var dropped []string
+	for _, name := range existing {
+		if _, ok := viewByName(name); !ok {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("DROP VIEW IF EXISTS `%s`", name)).Error; err != nil {
+			return dropped, fmt.Errorf("drop view %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
+
+// RestoreViews 按当前的定义重建 DropViews 删掉的视图
+// 回滚之后表里可能少了视图要的列，这样的视图建不起来；其它视图照常重建，错误合在一起返回
+func RestoreViews(ctx context.Context, db *gorm.DB, names []string) error {
+	var errs []error
+	for _, name := range names {
+		if v, ok := viewByName(name); ok {
+			errs = append(errs, createView(db.WithContext(ctx), v))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// viewByName 按名字查找视图定义
+func viewByName(name string) (View, bool) {
+	for _, v := range Views {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return View{}, false
+}
+
+// Exported 导出的一张表
+type Exported struct {
+	Name string
+	Rows int
+}
+
+// Export 把所有分析视图的内容导出到 path（一个新的 SQLite 文件），返回每张表导出了多少行
+//
+// 直接执行视图的查询，不要求库里已经建了视图；每张表在一个只读的查询里读完，看到的是同一时刻的数据
+// 先写到 path.tmp，全部成功后再改名覆盖 path：BI 工具读到的要么是上一次的文件，要么是这次完整的文件
+// 文件里另有一张 analytics_export 表，记录导出时间
+func Export(ctx context.Context, src Sources, path string) ([]Exported, error) {
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	dst, err := gorm.Open(sqlite.Open(tmp), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	out, err := exportTo(ctx, src, dst)
+	if sqlDB, cerr := dst.DB(); cerr == nil {
+		_ = sqlDB.Close()
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	return out, nil
+}
+
+func exportTo(ctx context.Context, src Sources, dst *gorm.DB) ([]Exported, error) {
+	out := make([]Exported, 0, len(Views))
+	for _, v := range Views {
+		n, err := copyView(ctx, src.db(v), dst, v)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", v.Name, err)
+		}
+		out = append(out, Exported{Name: v.Name, Rows: n})
+	}
+	if err := dst.Exec("CREATE TABLE `analytics_export` (`exported_at` datetime)").Error; err != nil {
+		return nil, err
+	}
+	if err := dst.Exec("INSERT INTO `analytics_export` (`exported_at`) VALUES (?)", time.Now().UTC()).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copyView 执行 v 的查询，按结果的列建表，把所有行写进 dst
+// 列的类型沿用源库报告的类型名，SQLite 按类型名决定列的亲和性
+func copyView(ctx context.Context, from, dst *gorm.DB, v View) (int, error) {
+	rows, err := from.WithContext(ctx).Raw(v.Select).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+
+	defs := make([]string, len(cols))
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = "`" + c.Name() + "`"
+		defs[i] = strings.TrimSpace(names[i] + " " + strings.ToLower(c.DatabaseTypeName()))
+	}
+	if err := dst.Exec(fmt.Sprintf("CREATE TABLE `%s` (%s)", v.Name, strings.Join(defs, ","))).Error; err != nil {
+		return 0, err
+	}
+	insert := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", v.Name, strings.Join(names, ","),
+		strings.TrimSuffix(strings.Repeat("?,", len(cols)), ","))
+
+	n := 0
+	err = dst.Transaction(func(tx *gorm.DB) error {
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				return err
+			}
+			if err := tx.Exec(insert, vals...).Error; err != nil {
+				return err
+			}
+			n++
+		}
+		return rows.Err()
+	})
+	return n, err
+}
+
+// Run 立即导出一次，之后每隔 interval 导出一次，直到 ctx 被取消
+// 导出失败只记日志，上一次导出的文件保留不动
+func Run(ctx context.Context, src Sources, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if out, err := Export(ctx, src, path); err != nil {
+			logging.FromContext(ctx).Warn("analytics export", "err", err)
+		} else {
+			rows := 0
+			for _, e := range out {
+				rows += e.Rows
+			}
+			logging.FromContext(ctx).Info("analytics exported", "path", path, "tables", len(out), "rows", rows, "elapsed_ms", float64(time.Since(start).Microseconds())/1000)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
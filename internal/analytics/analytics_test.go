@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"kanban_api/internal/migrations"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := migrations.OpenSQLite(filepath.Join(t.TempDir(), "live.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { migrations.Close(db) })
+	if _, _, err := migrations.Up(db, 0); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, q := range []struct {
+		sql  string
+		args []any
+	}{
+		{"INSERT INTO user_rows (id, email, password_hash, role, created_at) VALUES (?, ?, ?, ?, ?)", []any{"u1", "a@example.com", "$2a$10$hash", "user", now}},
+		{"INSERT INTO board_rows (id, owner_id, title, slug, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)", []any{"b1", "u1", "Board", "board", now, now}},
+		{"INSERT INTO card_rows (id, board_id, list_id, title, description, position, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", []any{"c1", "b1", "l1", "Card", "secret notes", 0, now, now}},
+		{"INSERT INTO refresh_token_rows (id, user_id, token_hash, created_at) VALUES (?, ?, ?, ?)", []any{"r1", "u1", "tokenhash", now}},
+	} {
+		if err := db.Exec(q.sql, q.args...).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// columns 表或视图的所有列名
+func columns(t *testing.T, db *gorm.DB, table string) map[string]bool {
+	t.Helper()
+	cols, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]bool)
+	for _, c := range cols {
+		out[c.Name()] = true
+	}
+	return out
+}
+
+// TestExport 导出的文件里每个视图一张表，带着数据，没有邮箱、密码哈希、卡片描述，也没有令牌表
+func TestExport(t *testing.T) {
+	db := openTestDB(t)
+	path := filepath.Join(t.TempDir(), "analytics.db")
+
+	out, err := Export(context.Background(), Sources{SQLite: db}, path)
+	if err != nil || len(out) != len(Views) {
+		t.Fatalf("export = %v, %v", out, err)
+	}
+	dst, err := migrations.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrations.Close(dst)
+
+	var n int64
+	if err := dst.Table("analytics_users").Count(&n).Error; err != nil || n != 1 {
+		t.Fatalf("analytics_users rows = %d, %v", n, err)
+	}
+	if cols := columns(t, dst, "analytics_users"); cols["email"] || cols["password_hash"] || !cols["role"] {
+		t.Fatalf("analytics_users columns = %v", cols)
+	}
+	if cols := columns(t, dst, "analytics_cards"); cols["description"] || !cols["title"] {
+		t.Fatalf("analytics_cards columns = %v", cols)
+	}
+	if dst.Migrator().HasTable("refresh_token_rows") || !dst.Migrator().HasTable("analytics_export") {
+		t.Fatal("export should only contain the analytics tables")
+	}
+
+	// 再导出一次覆盖旧文件
+	if _, err := Export(context.Background(), Sources{SQLite: db}, path); err != nil {
+		t.Fatalf("second export: %v", err)
+	}
+}
+
+// TestCreateViews 视图可以查询，重复执行会按最新的定义重建
+func TestCreateViews(t *testing.T) {
+	db := openTestDB(t)
+	for i := 0; i < 2; i++ {
+		names, err := CreateViews(context.Background(), Sources{SQLite: db})
+		if err != nil || len(names) != len(Views) {
+			t.Fatalf("create views = %v, %v", names, err)
+		}
+	}
+	var titles []string
+	if err := db.Table("analytics_boards").Pluck("title", &titles).Error; err != nil || len(titles) != 1 || titles[0] != "Board" {
+		t.Fatalf("analytics_boards titles = %v, %v", titles, err)
+	}
+	if cols := columns(t, db, "analytics_users"); cols["password_hash"] {
+		t.Fatalf("analytics_users columns = %v", cols)
+	}
+}
@@ -0,0 +1,331 @@
+// Package blob 附件文件的内容寻址存储
+//
+// 文件按内容的 SHA-256 保存，同样的内容只保存一份，key 就是哈希本身（64 位十六进制）
+// 谁在引用一个 blob、什么时候可以删除，由附件仓储的引用计数决定（见 repository.AttachmentRepository），
+// 这个包只负责读写文件，不知道附件的存在
+//
+// 上传分两步：
+//   - Stage 把内容写进临时文件，边写边算哈希，这时还不知道 key
+//   - Commit 把临时文件放到哈希对应的位置；已经有同样内容的文件时直接丢掉临时文件
+//
+// 写到一半失败（超过大小限制、客户端断开）只留下临时文件，调用 Discard 删除
+//
+// 大文件可以分块上传（断点续传）：每一块用 Append 追加到以上传 ID 命名的文件末尾，
+// 中途断开时已经写入的部分保留下来，客户端从 PartialSize 的位置接着传；
+// 写完之后 Seal 把它变成 Staged，之后和一次性上传一样 Commit
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrTooLarge 内容超过了 Stage 的大小限制
+var ErrTooLarge = errors.New("blob too large")
+
+// ErrNotFound blob 不存在
+var ErrNotFound = errors.New("blob not found")
+
+// ErrOffsetMismatch Append 的起始位置和分块上传已经写入的字节数不一致
+var ErrOffsetMismatch = errors.New("offset mismatch")
+
+// sniffLen Staged.Head 最多保存多少字节，和 http.DetectContentType 看的长度一致
+const sniffLen = 512
+
+// Store blob 存储接口
+type Store interface {
+	// Stage 把 r 的内容写进临时文件并计算 SHA-256
+	// 内容超过 limit 字节时返回 ErrTooLarge，临时文件已经删除
+	Stage(r io.Reader, limit int64) (*Staged, error)
+
+	// Commit 把暂存的内容保存为 s.Hash，之后就能 Open
+	// 同样内容的 blob 已经存在时什么也不写；无论成功与否，临时文件都不再需要 Discard
+	Commit(s *Staged) error
+
+	// Open 打开 blob 读取内容，不存在时返回 ErrNotFound
+	Open(hash string) (io.ReadSeekCloser, error)
+
+	// Delete 删除 blob，不存在时不报错
+	Delete(hash string) error
+
+	// Append 把 r 的内容追加到分块上传 id 的末尾，返回追加之后一共写入了多少字节
+	// offset 必须等于已经写入的字节数，否则返回 ErrOffsetMismatch 和当前的字节数
+	// 追加之后超过 limit 字节时这一块整块作废，返回 ErrTooLarge；读 r 出错时已经写入的部分保留
+	Append(id string, offset int64, r io.Reader, limit int64) (int64, error)
+
+	// PartialSize 分块上传 id 已经写入的字节数，还没写过时是 0
+	PartialSize(id string) (int64, error)
+
+	// Seal 结束分块上传 id，计算 SHA-256 并转成 Staged，之后用 Commit 保存或者 Discard 丢掉
+	Seal(id string) (*Staged, error)
+
+	// DeletePartial 删除分块上传 id 已经写入的内容，不存在时不报错
+	DeletePartial(id string) error
+
+	// Ping 检查存储能不能写入，健康探测使用（实现了 repository.Pinger）
+	Ping(ctx context.Context) error
+}
+
+// Staged Stage 写好、还没有 Commit 的内容
+type Staged struct {
+	// Hash 内容的 SHA-256（十六进制）
+	Hash string
+
+	// Size 内容的字节数
+	Size int64
+
+	// Head 内容开头最多 512 字节，用来猜测文件类型
+	Head []byte
+
+	// path 临时文件的路径
+	path string
+}
+
+// Discard 删除临时文件，没有 Commit 的内容都要调用，Commit 之后再调用没有影响
+func (s *Staged) Discard() {
+	if s.path != "" {
+		_ = os.Remove(s.path)
+		s.path = ""
+	}
+}
+
+// Open 打开临时文件读取内容，Commit 之前需要检查或改写内容时使用，调用方负责 Close
+func (s *Staged) Open() (io.ReadCloser, error) {
+	if s.path == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(s.path)
+}
+
+// headBuffer 记下写进来的前 sniffLen 个字节，其余的丢掉
+type headBuffer []byte
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if n := sniffLen - len(*b); n > 0 {
+		*b = append(*b, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// dirStore 保存在本地目录里的 blob 存储
+// 布局：dir/ab/abcdef...（按哈希前两位分子目录，避免一个目录里文件太多），
+// 临时文件在 dir/tmp 里，分块上传的文件在 dir/partial 里
+type dirStore struct {
+	dir string
+}
+
+// NewDirStore 创建保存在 dir 目录里的 blob 存储，目录不存在时自动创建
+func NewDirStore(dir string) (Store, error) {
+	for _, sub := range []string{"tmp", "partial"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &dirStore{dir: dir}, nil
+}
+
+// path blob 文件的路径
+func (s *dirStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Stage 写临时文件
+// 临时文件和最终位置在同一个目录树下，Commit 时的重命名是原子的
+func (s *dirStore) Stage(r io.Reader, limit int64) (*Staged, error) {
+	f, err := os.CreateTemp(filepath.Join(s.dir, "tmp"), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	staged := &Staged{path: f.Name()}
+
+	h, head := sha256.New(), headBuffer{}
+	// 多读一个字节，能读到就说明超过了限制
+	n, err := io.Copy(io.MultiWriter(f, h, &head), io.LimitReader(r, limit+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > limit {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		staged.Discard()
+		return nil, err
+	}
+	staged.Hash, staged.Size, staged.Head = hex.EncodeToString(h.Sum(nil)), n, head
+	return staged, nil
+}
+
+// Commit 把临时文件重命名到哈希对应的位置
+func (s *dirStore) Commit(st *Staged) error {
+	defer st.Discard()
+	dst := s.path(st.Hash)
+	if _, err := os.Stat(dst); err == nil {
+		// 已经有同样的内容了
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(st.path, dst); err != nil {
+		return err
+	}
+	st.path = ""
+	return nil
+}
+
+// Ping 在临时目录里写一个空文件再删掉
+// 磁盘满了、挂载点掉了、权限不对都能发现，只检查目录存在发现不了
+func (s *dirStore) Ping(ctx context.Context) error {
+	f, err := os.CreateTemp(filepath.Join(s.dir, "tmp"), "ping-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// Open 打开 blob 文件
+func (s *dirStore) Open(hash string) (io.ReadSeekCloser, error) {
+	if !validHash(hash) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(s.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete 删除 blob 文件
+func (s *dirStore) Delete(hash string) error {
+	if !validHash(hash) {
+		return nil
+	}
+	err := os.Remove(s.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// partialPath 分块上传文件的路径
+func (s *dirStore) partialPath(id string) string {
+	return filepath.Join(s.dir, "partial", id)
+}
+
+// Append 以追加方式打开分块上传的文件，先检查大小和 offset 一致
+// 同一个 id 的 Append 不能并发调用，由调用方保证
+func (s *dirStore) Append(id string, offset int64, r io.Reader, limit int64) (int64, error) {
+	if !validID(id) {
+		return 0, ErrNotFound
+	}
+	f, err := os.OpenFile(s.partialPath(id), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() != offset {
+		return info.Size(), ErrOffsetMismatch
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, limit-offset+1))
+	if err == nil && offset+n > limit {
+		// 截掉这一块，客户端还可以从原来的位置重新传正确的内容
+		if terr := f.Truncate(offset); terr != nil {
+			return offset + n, terr
+		}
+		return offset, ErrTooLarge
+	}
+	return offset + n, err
+}
+
+// PartialSize 分块上传文件的大小
+func (s *dirStore) PartialSize(id string) (int64, error) {
+	if !validID(id) {
+		return 0, ErrNotFound
+	}
+	info, err := os.Stat(s.partialPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Seal 读一遍分块上传的文件计算哈希，再把它移到临时目录，和 Stage 写出来的临时文件一样处理
+func (s *dirStore) Seal(id string) (*Staged, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	src := s.partialPath(id)
+	f, err := os.Open(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	h, head := sha256.New(), headBuffer{}
+	n, err := io.Copy(io.MultiWriter(h, &head), f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	dst := filepath.Join(s.dir, "tmp", "partial-"+id)
+	if err := os.Rename(src, dst); err != nil {
+		return nil, err
+	}
+	return &Staged{Hash: hex.EncodeToString(h.Sum(nil)), Size: n, Head: head, path: dst}, nil
+}
+
+// DeletePartial 删除分块上传的文件
+func (s *dirStore) DeletePartial(id string) error {
+	if !validID(id) {
+		return nil
+	}
+	err := os.Remove(s.partialPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// validID 分块上传的 ID 只能是字母、数字和连字符（UUID、ULID、KSUID 都满足）
+// 和 validHash 一样，ID 会拼进文件路径
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// validHash 是不是 64 位小写十六进制
+// key 会拼进文件路径，不是哈希的字符串（例如带 ../）不能拿去访问文件
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
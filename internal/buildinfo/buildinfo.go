@@ -0,0 +1,70 @@
+// Package buildinfo 构建信息：版本号、git 提交、构建时间
+//
+// 这些值在编译时通过 -ldflags "-X ..." 写进变量，代码里不用改：
+//
+//	go build -ldflags "\
+//	  -X kanban_api/internal/buildinfo.Version=v1.4.0 \
+//	  -X kanban_api/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X kanban_api/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  -o kanban ./cmd/server
+//
+// 运维和提 bug 的人通过 GET /version 或启动日志就能知道部署的到底是哪个版本
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 由 -ldflags -X 设置，只能是 string 类型的包级变量
+var (
+	// Version 版本号，没有设置时是 dev
+	Version = "dev"
+
+	// Commit git 提交哈希
+	Commit = ""
+
+	// BuildTime 构建时间，建议用 RFC 3339 格式
+	BuildTime = ""
+)
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get 返回构建信息
+// 没有通过 -ldflags 设置提交哈希时，从 Go 工具链自动嵌入的 VCS 信息里取（在 git 仓库里 go build 会带上），
+// 工作区有未提交的修改时后面加 -dirty；go run 不嵌入 VCS 信息，这时为空
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if info.Commit != "" {
+		return info
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	var dirty bool
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if info.Commit != "" && dirty {
+		info.Commit += "-dirty"
+	}
+	return info
+}
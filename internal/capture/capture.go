@@ -0,0 +1,218 @@
+// Package capture 请求抓包：把指定用户或指定路由的完整请求、响应记录下来
+//
+// 有些客户端问题只在某个用户身上、某个接口上出现，本地复现不了，访问日志里又只有状态码。
+// 管理员可以临时打开抓包，让服务端把匹配的请求和响应（请求头、请求体、响应体）
+// 存进一个固定大小的环形缓冲区，再通过管理员接口取出来看
+//
+// 注意：
+// - 只保存在进程内存里，多实例部署时每个实例各抓各的，重启就没了
+// - 密码、令牌等敏感内容写进缓冲区之前就脱敏（规则和请求体日志相同，见 logging.RedactJSON）
+// - 不是 JSON 的请求体、响应体没法判断哪些是敏感内容，整个隐藏
+// - 抓包有时限，到期自动停止，避免忘了关一直在抓
+package capture
+
+import (
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxBody 每个请求体、响应体最多保存的字节数
+// 超过的部分被截断，截断后不是合法 JSON，没法脱敏，整个隐藏
+const MaxBody = 16 << 10
+
+// Filter 抓哪些请求
+// 两个条件都设置时必须同时满足
+type Filter struct {
+	// UserID 只抓这个用户的请求
+	UserID string `json:"userId,omitempty"`
+
+	// Route 只抓这个路由的请求，使用注册路由时的模板，例如 /api/v1/boards/:id
+	Route string `json:"route,omitempty"`
+}
+
+// Exchange 一次抓到的请求和响应
+type Exchange struct {
+	ID              int64             `json:"id"`
+	Time            model.Timestamp   `json:"time"`
+	RequestID       string            `json:"requestId"`
+	UserID          string            `json:"userId,omitempty"`
+	ClientIP        string            `json:"clientIp"`
+	Method          string            `json:"method"`
+	Route           string            `json:"route"`
+	Path            string            `json:"path"`
+	Query           string            `json:"query,omitempty"`
+	RequestHeaders  map[string]string `json:"requestHeaders"`
+	RequestBody     string            `json:"requestBody,omitempty"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"responseHeaders"`
+	ResponseBody    string            `json:"responseBody,omitempty"`
+	LatencyMs       float64           `json:"latencyMs"`
+}
+
+// Status 抓包状态
+type Status struct {
+	Active    bool             `json:"active"`
+	Filter    Filter           `json:"filter"`
+	ExpiresAt *model.Timestamp `json:"expiresAt,omitempty"`
+
+	// Captured 缓冲区里现有的条数，Capacity 缓冲区的容量，满了以后新的覆盖最旧的
+	Captured int `json:"captured"`
+	Capacity int `json:"capacity"`
+}
+
+// session 一次抓包的条件，开始后不再修改
+type session struct {
+	filter    Filter
+	expiresAt time.Time
+}
+
+// Recorder 抓包记录器，并发安全
+type Recorder struct {
+	// current 当前的抓包条件，nil 表示没有在抓
+	// 每个请求都要看一眼有没有在抓，用原子指针，不抓的时候不需要加锁
+	current atomic.Pointer[session]
+
+	mu   sync.Mutex
+	buf  []Exchange // 环形缓冲区
+	next int        // 下一条写入的位置
+	full bool       // 是否已经写满过一圈
+	seq  int64      // 最近一条的 ID
+}
+
+// NewRecorder 创建抓包记录器，capacity 是最多保存的条数
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Recorder{buf: make([]Exchange, capacity)}
+}
+
+// Start 开始抓包，d 之后自动停止
+// 会清空缓冲区里上一次抓到的内容，避免两次的结果混在一起
+func (r *Recorder) Start(f Filter, d time.Duration) Status {
+	r.mu.Lock()
+	clear(r.buf)
+	r.next, r.full = 0, false
+	r.current.Store(&session{filter: f, expiresAt: time.Now().Add(d)})
+	r.mu.Unlock()
+	return r.Status()
+}
+
+// Stop 停止抓包
+// 已经抓到的内容保留，还可以取出来看
+func (r *Recorder) Stop() Status {
+	r.current.Store(nil)
+	return r.Status()
+}
+
+// Status 返回当前状态
+func (r *Recorder) Status() Status {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = len(r.buf)
+	}
+	st := Status{Captured: n, Capacity: len(r.buf)}
+	r.mu.Unlock()
+
+	if s := r.active(time.Now()); s != nil {
+		st.Active = true
+		st.Filter = s.filter
+		st.ExpiresAt = model.TimestampPtr(&s.expiresAt)
+	}
+	return st
+}
+
+// Active 现在是否在抓包
+// 中间件先用它判断要不要准备抓包（读请求体、包装响应），不抓的时候几乎没有开销
+func (r *Recorder) Active() bool {
+	return r.active(time.Now()) != nil
+}
+
+// Matches 这个请求是否符合抓包条件
+// userID 在认证之后才知道，所以要等请求处理完再判断
+func (r *Recorder) Matches(userID, route string) bool {
+	s := r.active(time.Now())
+	if s == nil {
+		return false
+	}
+	if s.filter.UserID != "" && s.filter.UserID != userID {
+		return false
+	}
+	if s.filter.Route != "" && s.filter.Route != route {
+		return false
+	}
+	return true
+}
+
+// active 返回还没到期的抓包条件，已经到期的顺便清掉
+func (r *Recorder) active(now time.Time) *session {
+	s := r.current.Load()
+	if s == nil {
+		return nil
+	}
+	if now.After(s.expiresAt) {
+		r.current.CompareAndSwap(s, nil)
+		return nil
+	}
+	return s
+}
+
+// Add 保存一条记录，缓冲区满了覆盖最旧的一条
+// 调用方负责脱敏（见 Headers、Query、Body）
+func (r *Recorder) Add(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.ID = r.seq
+	r.buf[r.next] = e
+	r.next++
+	if r.next == len(r.buf) {
+		r.next, r.full = 0, true
+	}
+}
+
+// List 按时间顺序（旧的在前）返回缓冲区里的所有记录
+func (r *Recorder) List() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Exchange(nil), r.buf[:r.next]...)
+	}
+	out := make([]Exchange, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+// Headers 脱敏后的请求头或响应头
+// Authorization、Cookie 以及名字里带 token、secret 之类的请求头只保留名字，值替换掉；
+// 第三方登录跳回前端时令牌在 Location 的 # 后面，# 后面的部分替换掉
+func Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		switch {
+		case logging.IsSensitive(k), strings.EqualFold(k, "Cookie"), strings.EqualFold(k, "Set-Cookie"):
+			out[k] = "[REDACTED]"
+		case strings.EqualFold(k, "Location") && strings.Contains(v[0], "#"):
+			target, _, _ := strings.Cut(v[0], "#")
+			out[k] = target + "#[REDACTED]"
+		default:
+			out[k] = strings.Join(v, ", ")
+		}
+	}
+	return out
+}
+
+// Body 脱敏后的请求体或响应体
+// truncated 表示原始内容超过了 MaxBody，只拿到了前一部分
+func Body(body []byte, truncated bool) string {
+	if truncated {
+		return "[REDACTED]"
+	}
+	return logging.RedactJSON(body)
+}
@@ -0,0 +1,274 @@
+// Package events 进程内的看板变更事件总线
+//
+// 看板、列表、卡片被创建、修改、删除，以及看板成员变化之后，服务层（见 service/events.go）往总线上发布一个事件，
+// 订阅了这个看板的 WebSocket 连接收到后推给客户端，客户端不用轮询就能看到别人的修改
+//
+// 注意：
+// - 只在进程内转发，多实例部署时只能收到同一个实例上发生的修改，需要换成 Redis 等外部的发布订阅
+// - 不保存历史，订阅之前发生的修改收不到，客户端订阅后应该先拉一次完整数据
+// - 发布不会阻塞：订阅方处理不过来、缓冲区满了，就把它断开（见 Subscriber.Lagged）
+//
+// 断开比悄悄丢掉几个事件要好：客户端重连后重新拉一次数据，不会出现客户端和服务端的数据对不上还不知道
+package events
+
+import (
+	"encoding/json"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// 事件类型，格式是 "对象.动作"
+const (
+	BoardCreated = "board.created"
+	BoardUpdated = "board.updated"
+	BoardDeleted = "board.deleted"
+
+	ListCreated = "list.created"
+	ListUpdated = "list.updated"
+	ListMoved   = "list.moved"
+	ListDeleted = "list.deleted"
+
+	CardCreated = "card.created"
+	CardUpdated = "card.updated"
+	CardMoved   = "card.moved"
+	CardDeleted = "card.deleted"
+
+	// CardDueSoon 卡片快到截止日期（打开了提醒的卡片，见 service.Reminders），不是用户的修改，没有 ActorID
+	CardDueSoon = "card.due_soon"
+
+	// 检查清单的条目增删、勾选、排序都算清单的修改，发 checklist.updated，带着整个清单
+	ChecklistCreated = "checklist.created"
+	ChecklistUpdated = "checklist.updated"
+	ChecklistMoved   = "checklist.moved"
+	ChecklistDeleted = "checklist.deleted"
+
+	// 附件只有添加和删除，没有修改
+	AttachmentCreated = "attachment.created"
+	AttachmentDeleted = "attachment.deleted"
+
+	MemberAdded   = "member.added"
+	MemberRemoved = "member.removed"
+)
+
+// 只记在看板动态里、不在总线上发布的操作
+// webhook、嵌入令牌和卡片模板是看板的配置，不是看板内容，订阅方不需要实时知道；
+// 但谁在什么时候把看板的数据发到了外部，要能在动态里查到
+const (
+	WebhookCreated = "webhook.created"
+	WebhookDeleted = "webhook.deleted"
+
+	EmbedTokenCreated = "embed_token.created"
+	EmbedTokenRevoked = "embed_token.revoked"
+
+	CardTemplateCreated = "card_template.created"
+	CardTemplateUpdated = "card_template.updated"
+	CardTemplateDeleted = "card_template.deleted"
+
+	// AnomalyDetected 异常检测在看板上发现了异常（操作激增、大量删除），见 service.AnomalyDetector
+	AnomalyDetected = "anomaly.detected"
+)
+
+// Types 所有事件类型，webhook 按类型过滤时用来检查类型写得对不对
+var Types = []string{
+	BoardCreated, BoardUpdated, BoardDeleted,
+	ListCreated, ListUpdated, ListMoved, ListDeleted,
+	CardCreated, CardUpdated, CardMoved, CardDeleted, CardDueSoon,
+	ChecklistCreated, ChecklistUpdated, ChecklistMoved, ChecklistDeleted,
+	AttachmentCreated, AttachmentDeleted,
+	MemberAdded, MemberRemoved,
+}
+
+// IsType 是否是已知的事件类型
+func IsType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event 一次变更
+type Event struct {
+	Type    string `json:"type"`
+	BoardID string `json:"boardId"`
+
+	// ActorID 做出这次修改的用户，客户端可以据此忽略自己的修改（已经在本地应用过了）
+	// 管理员在后台删除看板时为空
+	ActorID string `json:"actorId,omitempty"`
+
+	// Data 修改后的对象，删除时只有 ID，具体字段见 README
+	Data any `json:"data"`
+
+	Time time.Time `json:"time"`
+}
+
+// MarshalJSON 时间按 model.TimeLayout 输出，和接口响应里的时间格式一致
+func (e Event) MarshalJSON() ([]byte, error) {
+	type plain Event
+	return json.Marshal(struct {
+		plain
+		Time model.Timestamp `json:"time"`
+	}{plain(e), model.Timestamp(e.Time)})
+}
+
+// Bus 事件总线，并发安全
+type Bus struct {
+	mu sync.RWMutex
+
+	// subs 看板 ID -> 订阅了这个看板的订阅方
+	subs map[string]map[*Subscriber]struct{}
+
+	// listeners 接收所有看板事件的回调，见 Listen
+	listeners []func(Event)
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string]map[*Subscriber]struct{})}
+}
+
+// Publish 把事件发给所有订阅了 e.BoardID 的订阅方
+// 不会阻塞，e.Time 为零值时填上当前时间
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs[e.BoardID] {
+		s.send(e)
+	}
+	for _, fn := range b.listeners {
+		fn(e)
+	}
+}
+
+// Listen 注册一个接收所有看板事件的回调，例如 webhook 投递（见 webhook.Dispatcher）
+// 和订阅方不同，总线不会因为回调处理慢就断开它，积压了怎么办由回调自己决定；
+// 回调在 Publish 里同步执行，必须立刻返回（把事件放进自己的队列），不能在里面发网络请求
+// 启动时调用，不要在运行中注册
+func (b *Bus) Listen(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// Subscribers 当前订阅了 boardID 的订阅方数量
+func (b *Bus) Subscribers(boardID string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[boardID])
+}
+
+// NewSubscriber 创建一个订阅方，buffer 是事件缓冲区的大小
+// 一个订阅方可以订阅多个看板，所有看板的事件从同一个 Events() 里出来
+// 用完必须调用 Close
+func (b *Bus) NewSubscriber(buffer int) *Subscriber {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &Subscriber{
+		bus:    b,
+		ch:     make(chan Event, buffer),
+		boards: make(map[string]struct{}),
+	}
+}
+
+// Subscriber 订阅方，一般对应一个 WebSocket 连接
+type Subscriber struct {
+	bus *Bus
+	ch  chan Event
+
+	// boards 订阅了哪些看板，由 bus.mu 保护
+	boards map[string]struct{}
+
+	mu     sync.Mutex
+	closed bool
+	lagged bool
+}
+
+// Subscribe 订阅一个看板的事件，重复订阅没有影响
+// 调用方负责先确认用户能查看这个看板
+func (s *Subscriber) Subscribe(boardID string) {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	set, ok := s.bus.subs[boardID]
+	if !ok {
+		set = make(map[*Subscriber]struct{})
+		s.bus.subs[boardID] = set
+	}
+	set[s] = struct{}{}
+	s.boards[boardID] = struct{}{}
+}
+
+// Unsubscribe 取消订阅一个看板
+func (s *Subscriber) Unsubscribe(boardID string) {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s, boardID)
+}
+
+// Subscribed 订阅了多少个看板
+func (s *Subscriber) Subscribed() int {
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+	return len(s.boards)
+}
+
+// Events 事件通道
+// 通道被关闭说明订阅方已经 Close，或者处理太慢被断开了（Lagged 返回 true）
+func (s *Subscriber) Events() <-chan Event {
+	return s.ch
+}
+
+// Lagged 是否因为缓冲区满了被总线断开
+func (s *Subscriber) Lagged() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lagged
+}
+
+// Close 取消所有订阅并关闭事件通道，可以重复调用
+func (s *Subscriber) Close() {
+	s.bus.mu.Lock()
+	for id := range s.boards {
+		s.bus.remove(s, id)
+	}
+	s.bus.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// send 非阻塞地投递一个事件，缓冲区满了就关闭通道
+// 调用时持有 bus.mu 的读锁，这里不能再去拿 bus.mu，从总线上移除交给订阅方自己的 Close
+func (s *Subscriber) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- e:
+	default:
+		s.closed, s.lagged = true, true
+		close(s.ch)
+	}
+}
+
+// remove 调用时必须持有 b.mu 的写锁
+func (b *Bus) remove(s *Subscriber, boardID string) {
+	delete(s.boards, boardID)
+	if set, ok := b.subs[boardID]; ok {
+		delete(set, s)
+		if len(set) == 0 {
+			delete(b.subs, boardID)
+		}
+	}
+}
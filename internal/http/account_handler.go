@@ -0,0 +1,76 @@
+// Package http 注销账号处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// AccountHandler 注销账号处理器
+type AccountHandler struct {
+	svc service.AccountService
+}
+
+// NewAccountHandler 创建注销账号处理器实例
+func NewAccountHandler(svc service.AccountService) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+// RegisterRoutes 注册公共路由
+// 注销之后不能登录，撤销注销凭邮箱和密码调用，所以放在公共路由组；credential 和登录一样是更严格的限流
+func (h *AccountHandler) RegisterRoutes(rg *gin.RouterGroup, credential ...gin.HandlerFunc) {
+	rg.POST("/auth/restore", append(credential, h.restore)...)
+}
+
+// RegisterPrivate 注册需要登录的路由
+func (h *AccountHandler) RegisterPrivate(rg *gin.RouterGroup) {
+	rg.DELETE("/auth/me", h.deleteMe)
+}
+
+// deleteMe 注销当前用户的账号
+// HTTP 方法：DELETE
+// 路径：/api/v1/auth/me
+// 请求体：{"password": "...", "transferBoards": true}
+// 返回 202：数据在宽限期（purgeAt）过后才处理，在那之前可以撤销
+func (h *AccountHandler) deleteMe(c *gin.Context) {
+	var req deleteAccountRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	d, err := h.svc.DeleteAccount(c.Request.Context(), c.GetString("userID"), req.Password, req.TransferBoards)
+	if err != nil {
+		// 密码不正确返回 403，和修改密码相同
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"data": d})
+}
+
+// restore 撤销注销并登录
+// HTTP 方法：POST
+// 路径：/api/v1/auth/restore
+// 请求体：{"email": "...", "password": "..."}，响应和登录相同
+func (h *AccountHandler) restore(c *gin.Context) {
+	var req loginRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := service.WithClientIP(c.Request.Context(), c.ClientIP())
+	u, tokens, err := h.svc.RestoreAccount(ctx, req.Email, req.Password)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"user":         gin.H{"id": u.ID, "email": u.Email, "createdAt": model.Timestamp(u.CreatedAt)},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
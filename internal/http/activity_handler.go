@@ -0,0 +1,43 @@
+// Package http 看板动态处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// ActivityHandler 看板动态处理器
+type ActivityHandler struct {
+	svc service.ActivityService
+}
+
+// NewActivityHandler 创建看板动态处理器实例
+func NewActivityHandler(svc service.ActivityService) *ActivityHandler {
+	return &ActivityHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET /boards/:id/activities?limit=20&cursor=...: 看板动态，从新到旧
+func (h *ActivityHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/activities", h.list)
+}
+
+// list 列出看板动态
+// GET /api/v1/boards/:id/activities?limit=20&cursor=<上一页返回的 nextCursor>
+func (h *ActivityHandler) list(c *gin.Context) {
+	// limit 不传时为 0，由服务层使用默认值
+	q := httpx.Query(c)
+	limit := q.Int("limit", 0, 1, 100)
+	if !q.Valid() {
+		return
+	}
+
+	page, err := h.svc.ListActivities(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Query("cursor"), limit)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
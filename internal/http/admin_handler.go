@@ -0,0 +1,88 @@
+// Package http 管理员处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// AdminHandler 管理员处理器
+// 路由组上必须挂 AuthRequired 和 RequireRole("admin") 两个中间件，处理器本身不再检查角色
+type AdminHandler struct {
+	auth   service.AuthService
+	boards service.BoardService
+}
+
+// NewAdminHandler 创建管理员处理器实例
+func NewAdminHandler(auth service.AuthService, boards service.BoardService) *AdminHandler {
+	return &AdminHandler{auth: auth, boards: boards}
+}
+
+// Register 注册路由
+// - GET    /admin/users: 列出所有用户
+// - PUT    /admin/users/:userId/role: 修改用户的系统角色
+// - DELETE /admin/boards/:id: 删除任意看板
+// - PUT    /admin/boards/:id/state: 修改看板状态（active / read_only / suspended）
+func (h *AdminHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/users", h.listUsers)
+	rg.PUT("/admin/users/:userId/role", h.setRole)
+	rg.DELETE("/admin/boards/:id", h.deleteBoard)
+	rg.PUT("/admin/boards/:id/state", h.setBoardState)
+}
+
+// listUsers 列出所有用户
+// GET /api/v1/admin/users
+func (h *AdminHandler) listUsers(c *gin.Context) {
+	users, err := h.auth.ListUsers(c.Request.Context())
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+// setRole 修改用户角色
+// PUT /api/v1/admin/users/:userId/role
+// 请求体：{"role": "admin"}
+func (h *AdminHandler) setRole(c *gin.Context) {
+	var req setRoleRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	u, err := h.auth.SetRole(c.Request.Context(), c.Param("userId"), req.Role)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": u})
+}
+
+// deleteBoard 删除任意看板
+// DELETE /api/v1/admin/boards/:id
+func (h *AdminHandler) deleteBoard(c *gin.Context) {
+	if err := h.boards.AdminDeleteBoard(c.Request.Context(), c.Param("id")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setBoardState 修改看板状态
+// PUT /api/v1/admin/boards/:id/state
+// 请求体：{"state": "read_only", "reason": "storage quota exceeded"}
+func (h *AdminHandler) setBoardState(c *gin.Context) {
+	var req setBoardStateRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	b, err := h.boards.AdminSetBoardState(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.State, req.Reason)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": b})
+}
@@ -0,0 +1,43 @@
+// Package http 异常检测处理器（管理员接口）
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// AnomalyHandler 异常检测结果处理器
+// 和 AdminHandler 一样挂在管理员路由组上，处理器本身不检查角色
+type AnomalyHandler struct {
+	svc service.AnomalyService
+}
+
+// NewAnomalyHandler 创建异常检测结果处理器实例
+func NewAnomalyHandler(svc service.AnomalyService) *AnomalyHandler {
+	return &AnomalyHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET /admin/anomalies?limit=50: 最近发现的异常，从新到旧
+func (h *AnomalyHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/anomalies", h.list)
+}
+
+// list 列出最近发现的异常
+// GET /api/v1/admin/anomalies?limit=50
+func (h *AnomalyHandler) list(c *gin.Context) {
+	q := httpx.Query(c)
+	limit := q.Int("limit", 0, 1, 500)
+	if !q.Valid() {
+		return
+	}
+
+	anomalies, err := h.svc.ListAnomalies(c.Request.Context(), limit)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": anomalies})
+}
@@ -0,0 +1,68 @@
+// Package http 个人 API 密钥处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+// APIKeyHandler 个人 API 密钥处理器
+type APIKeyHandler struct {
+	svc service.APIKeyService
+}
+
+// NewAPIKeyHandler 创建 API 密钥处理器实例
+func NewAPIKeyHandler(svc service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+// Register 注册路由
+// rg 应该挂了 AuthRequired 和 SessionOnly：只能登录后管理密钥，不能拿一个密钥再去创建密钥
+func (h *APIKeyHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/me/api-keys", h.create)
+	rg.GET("/me/api-keys", h.list)
+	rg.DELETE("/me/api-keys/:id", h.revoke)
+}
+
+// create 创建 API 密钥
+// POST /api/v1/me/api-keys
+// 请求体：{"name": "CI", "scopes": ["read"], "expiresInDays": 90}
+// 响应里的 secret 是密钥本身，只返回这一次
+func (h *APIKeyHandler) create(c *gin.Context) {
+	var req apiKeyRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	in := service.APIKeyInput{Name: req.Name, Scopes: req.Scopes, TTL: time.Duration(req.ExpiresInDays) * 24 * time.Hour}
+	k, secret, err := h.svc.CreateKey(c.Request.Context(), c.GetString("userID"), in)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": gin.H{"apiKey": k, "secret": secret}})
+}
+
+// list 列出自己没有吊销的 API 密钥
+// GET /api/v1/me/api-keys
+func (h *APIKeyHandler) list(c *gin.Context) {
+	keys, err := h.svc.ListKeys(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// revoke 吊销 API 密钥，立即生效
+// DELETE /api/v1/me/api-keys/:id
+func (h *APIKeyHandler) revoke(c *gin.Context) {
+	if err := h.svc.RevokeKey(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,266 @@
+// Package http 卡片附件处理器
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
+	"kanban_api/internal/service"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// multipartOverhead 上传请求体除了文件本身之外最多多少字节（multipart 边界、各部分的头、其它表单字段）
+const multipartOverhead = 1 << 20
+
+// restoreRetryAfter 文件正在从冷存储恢复时，建议客户端过几秒再请求
+const restoreRetryAfter = "5"
+
+// AttachmentHandler 卡片附件处理器
+// 附件挂在卡片下面：/boards/:id/lists/:listId/cards/:cardId/attachments
+type AttachmentHandler struct {
+	svc service.AttachmentService
+
+	// maxSize 单个附件最大字节数，和服务里的限制一样，这里用来限制整个请求体
+	maxSize int64
+}
+
+// NewAttachmentHandler 创建附件处理器实例
+func NewAttachmentHandler(svc service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{svc: svc, maxSize: maxSize}
+}
+
+// Register 注册路由，前缀 .../cards/:cardId/attachments 省略为 ...
+// - GET    ...: 列出卡片的所有附件
+// - POST   ...: 上传附件（multipart/form-data，文件放在 file 字段）
+// - GET    .../:attachmentId: 下载附件（支持 Range）
+// - DELETE .../:attachmentId: 删除附件
+//
+// 分块上传（断点续传），大文件和网络不稳定的客户端使用：
+// - POST   .../uploads: 开始上传，声明文件名和大小
+// - HEAD   .../uploads/:uploadId: 查询已经收到的字节数（Upload-Offset 头），GET 同时返回 JSON
+// - PATCH  .../uploads/:uploadId: 从 Upload-Offset 开始追加一块，收满之后返回创建的附件
+// - DELETE .../uploads/:uploadId: 放弃上传
+//
+// 另外 GET /boards/:id/storage 查询看板的附件占用了多少空间、配额是多少
+func (h *AttachmentHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/storage", h.storage)
+
+	g := rg.Group("/boards/:id/lists/:listId/cards/:cardId/attachments")
+	g.GET("", h.list)
+	g.POST("", h.upload)
+	g.GET("/:attachmentId", h.download)
+	g.DELETE("/:attachmentId", h.delete)
+
+	g.POST("/uploads", h.startUpload)
+	g.HEAD("/uploads/:uploadId", h.uploadStatus)
+	g.GET("/uploads/:uploadId", h.uploadStatus)
+	g.PATCH("/uploads/:uploadId", h.appendUpload)
+	g.DELETE("/uploads/:uploadId", h.cancelUpload)
+}
+
+// list 列出卡片的所有附件
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments
+func (h *AttachmentHandler) list(c *gin.Context) {
+	items, err := h.svc.ListAttachments(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// upload 上传附件
+// POST /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments
+// 请求体：multipart/form-data，文件放在 file 字段，例如 curl -F file=@report.pdf
+//
+// 不用 c.FormFile：它会先把整个请求体解析完（大文件写临时文件），
+// 这里边读边交给服务，文件只在 blob 存储里写一次
+func (h *AttachmentHandler) upload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "body must be multipart/form-data")
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "file field required")
+			return
+		}
+		if err != nil {
+			h.readError(c, err)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			// 其它字段直接跳过，读下一部分时会把它剩下的内容读掉
+			continue
+		}
+
+		a, err := h.svc.UploadAttachment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), service.AttachmentUpload{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Body:        part,
+		})
+		if err != nil {
+			h.readError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"data": a})
+		return
+	}
+}
+
+// readError 读取请求体时的错误
+// 整个请求体超过了 MaxBytesReader 的限制时和文件太大一样返回 413，其它读取错误（客户端断开等）按请求无效处理
+func (h *AttachmentHandler) readError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		httpx.Abort(c, http.StatusRequestEntityTooLarge, httpx.CodeTooLarge, "request body too large")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "incomplete upload")
+	default:
+		httpx.ServiceError(c, err)
+	}
+}
+
+// download 下载附件
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/:attachmentId
+//
+// 总是以附件（Content-Disposition: attachment）的方式返回，并禁止浏览器猜测类型：
+// 上传的 HTML、SVG 如果在 API 的域名下直接打开，里面的脚本就能拿到这个域名下的凭证
+// ETag 是内容的 SHA-256，内容不变就不会变
+//
+// Range 请求（断点续传下载）由 http.ServeContent 处理：Range: bytes=1048576- 返回 206 和剩下的内容；
+// 带 If-Range 时，只有 ETag 还对得上才返回部分内容，否则返回整个文件
+//
+// 文件在冷存储里时这次请求开始恢复，返回 202、Retry-After 和附件记录（storageClass 为 restoring），
+// 客户端过一会儿再请求；不用 503，恢复是正常流程，不应该算成服务端出错
+func (h *AttachmentHandler) download(c *gin.Context) {
+	a, f, err := h.svc.OpenAttachment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("attachmentId"))
+	if errors.Is(err, service.ErrRestoring) {
+		c.Header("Retry-After", restoreRetryAfter)
+		c.JSON(http.StatusAccepted, gin.H{"data": a})
+		return
+	}
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", a.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+a.SHA256+`"`)
+	c.Header("Cache-Control", "private, no-cache")
+	http.ServeContent(c.Writer, c.Request, a.Filename, a.CreatedAt, f)
+}
+
+// delete 删除附件
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/:attachmentId
+func (h *AttachmentHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteAttachment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("attachmentId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setUploadHeaders 分块上传的进度放在响应头里，HEAD 请求只看这些
+func setUploadHeaders(c *gin.Context, u model.ResumableUpload) {
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Size, 10))
+	c.Header("Cache-Control", "no-store")
+}
+
+// startUpload 开始分块上传
+// POST /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/uploads
+// 请求体：{"filename": "video.mp4", "size": 104857600}，contentType 可选
+// 响应：201，Location 头是之后追加内容的地址
+func (h *AttachmentHandler) startUpload(c *gin.Context) {
+	var req uploadStartRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+	u, err := h.svc.StartUpload(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), service.UploadStart{
+		Filename:    req.Filename,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	setUploadHeaders(c, u)
+	c.Header("Location", c.Request.URL.Path+"/"+u.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": u})
+}
+
+// uploadStatus 查询分块上传的进度
+// HEAD /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/uploads/:uploadId
+// 连接断开之后先调这个接口，从 Upload-Offset 开始接着传
+func (h *AttachmentHandler) uploadStatus(c *gin.Context) {
+	u, err := h.svc.GetUpload(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("uploadId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	setUploadHeaders(c, u)
+	c.JSON(http.StatusOK, gin.H{"data": u})
+}
+
+// appendUpload 追加一块内容
+// PATCH /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/uploads/:uploadId
+// 请求头 Upload-Offset 是这一块在文件里的起始位置，必须等于已经收到的字节数，否则返回 409；
+// 请求体就是这一块的原始字节（Content-Type: application/offset+octet-stream）
+// 响应：还没传完时 200 和上传进度；这一块传完了整个文件时 201 和创建的附件
+func (h *AttachmentHandler) appendUpload(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "Upload-Offset header must be a non-negative integer")
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+1)
+
+	u, a, err := h.svc.AppendUpload(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("uploadId"), offset, c.Request.Body)
+	if u.ID != "" {
+		// 出错（包括 offset 不对、连接中断）时也告诉客户端已经收到了多少
+		setUploadHeaders(c, u)
+	}
+	if err != nil {
+		h.readError(c, err)
+		return
+	}
+	if a != nil {
+		c.JSON(http.StatusCreated, gin.H{"data": a})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": u})
+}
+
+// cancelUpload 放弃分块上传，已经收到的内容马上删除
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId/attachments/uploads/:uploadId
+func (h *AttachmentHandler) cancelUpload(c *gin.Context) {
+	if err := h.svc.CancelUpload(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("uploadId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// storage 看板的附件占用空间和配额
+// GET /api/v1/boards/:id/storage
+func (h *AttachmentHandler) storage(c *gin.Context) {
+	st, err := h.svc.BoardStorage(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
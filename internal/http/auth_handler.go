@@ -9,9 +9,10 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
 	"kanban_api/internal/service"
 	"net/http"
-	"strings"
 )
 
 // AuthHandler 认证处理器
@@ -30,56 +31,55 @@ func NewAuthHandler(svc service.AuthService) *AuthHandler {
 // RegisterRoutes 注册路由
 // 将 HTTP 路径和处理方法关联起来
 // rg *gin.RouterGroup 是 Gin 的路由组，可以给一组路由添加统一的前缀或中间件
-func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
+// credential 只挂在注册、登录和刷新上的中间件，例如更严格的限流，拖慢暴力破解密码、刷新令牌和批量注册
+func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup, credential ...gin.HandlerFunc) {
 	// POST 方法表示要创建资源
 	// "/auth/register" 是完整路径（会加上路由组的前缀）
-	// h.register 是处理函数
-	rg.POST("/auth/register", h.register)
-	rg.POST("/auth/login", h.login)
+	// h.register 是处理函数，放在中间件后面
+	rg.POST("/auth/register", append(credential, h.register)...)
+	rg.POST("/auth/login", append(credential, h.login)...)
+
+	// 刷新和登出凭刷新令牌调用，访问令牌过期了也能用，所以放在公共路由组
+	// 刷新接口收的是凭证，和登录一样限流；登出只会吊销令牌，不用限制
+	rg.POST("/auth/refresh", append(credential, h.refresh)...)
+	rg.POST("/auth/logout", h.logout)
+}
+
+// RegisterPrivate 注册需要登录的路由
+// rg 应该是挂了 AuthRequired 中间件的路由组，处理函数通过 c.GetString("userID") 拿到当前用户
+func (h *AuthHandler) RegisterPrivate(rg *gin.RouterGroup) {
+	rg.GET("/auth/me", h.me)
+	rg.PATCH("/auth/me", h.updateMe)
+	rg.PUT("/auth/password", h.changePassword)
 }
 
 // register 处理用户注册请求
 // HTTP 方法：POST
 // 路径：/api/v1/auth/register
-// 请求体：{"email": "user@example.com", "password": "123456"}
+// 请求体：{"email": "user@example.com", "password": "secret123"}
 func (h *AuthHandler) register(c *gin.Context) {
-	// 定义请求体的结构
-	// 使用匿名结构体，只在这个函数内使用
+	// 请求体的结构定义在 requests.go
 	// `json:"email"` 标签：JSON 中的字段名映射到结构体字段
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	// `binding:"required,email"` 标签：校验规则（必填、邮箱格式）
+	var req registerRequest
 
-	// ShouldBindJSON 解析 JSON 请求体
+	// httpx.BindJSON 解析 JSON 请求体
 	// 它会：
 	// 1. 读取 HTTP 请求体
 	// 2. 将 JSON 解析到 req 结构体
-	// 3. 进行基本的数据验证（如果有验证标签的话）
-	// 如果解析失败（JSON 格式错误、字段类型不匹配等），返回错误
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// http.StatusBadRequest = 400（错误的请求）
-		// gin.H 是 map[string]interface{} 的别名，用于构建 JSON 响应
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	// 3. 按 binding 标签校验字段
+	// 如果失败（JSON 格式错误、邮箱格式不对、密码太短等），它已经写好了 400 响应，
+	// 响应的 details 里按字段列出了原因，这里直接 return
+	if !httpx.BindJSON(c, &req) {
 		return
 	}
 
 	// 调用 Service 层处理注册逻辑
-	u, token, err := h.svc.Register(req.Email, req.Password)
+	u, tokens, err := h.svc.Register(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		// 注册失败，根据错误类型返回不同的 HTTP 状态码
-		msg := err.Error()
-
-		// 如果是邮箱已存在的错误
-		if strings.Contains(msg, "exists") {
-			// http.StatusConflict = 409（冲突）
-			// 表示请求与当前资源状态冲突（邮箱已注册）
-			c.JSON(http.StatusConflict, gin.H{"error": msg})
-			return
-		}
-
-		// 其他错误（邮箱格式错误、密码为空等）
-		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		// 注册失败，根据错误类别返回不同的 HTTP 状态码：
+		// 邮箱已注册是 409（冲突），邮箱或密码为空是 400
+		httpx.ServiceError(c, err)
 		return
 	}
 
@@ -92,10 +92,12 @@ func (h *AuthHandler) register(c *gin.Context) {
 			"user": gin.H{
 				"id":        u.ID,
 				"email":     u.Email,
-				"createdAt": u.CreatedAt,
+				"createdAt": model.Timestamp(u.CreatedAt),
 			},
 			// 返回 JWT 令牌，客户端保存后用于后续请求的认证
-			"token": token,
+			"token": tokens.AccessToken,
+			// 返回刷新令牌，访问令牌过期后用它换新令牌
+			"refreshToken": tokens.RefreshToken,
 		},
 	})
 }
@@ -103,30 +105,23 @@ func (h *AuthHandler) register(c *gin.Context) {
 // login 处理用户登录请求
 // HTTP 方法：POST
 // 路径：/api/v1/auth/login
-// 请求体：{"email": "user@example.com", "password": "123456"}
+// 请求体：{"email": "user@example.com", "password": "secret123"}
 func (h *AuthHandler) login(c *gin.Context) {
-	// 请求体结构（与注册相同）
-	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	// 解析 JSON 请求体
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid body",
-		})
+	// 登录只要求两个字段都有值，不检查密码长度（见 requests.go）
+	var req loginRequest
+	if !httpx.BindJSON(c, &req) {
 		return
 	}
 
 	// 调用 Service 层验证登录
-	u, token, err := h.svc.Login(req.Email, req.Password)
+	// 带上客户端 IP，登录失败按 IP 统计（异常检测）
+	ctx := service.WithClientIP(c.Request.Context(), c.ClientIP())
+	u, tokens, err := h.svc.Login(ctx, req.Email, req.Password)
 	if err != nil {
-		// 登录失败（用户不存在或密码错误）
-		// http.StatusUnauthorized = 401（未授权）
-		// 注意：无论是邮箱不存在还是密码错误，都返回相同的错误信息
+		// 登录失败（用户不存在或密码错误）返回 401（未授权）
+		// 注意：无论是邮箱不存在还是密码错误，Service 层都返回相同的错误信息
 		// 这是安全最佳实践，防止攻击者枚举有效邮箱
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		httpx.ServiceError(c, err)
 		return
 	}
 
@@ -135,9 +130,123 @@ func (h *AuthHandler) login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
 			// 返回用户信息
-			"user": gin.H{"id": u.ID, "email": u.Email, "createdAt": u.CreatedAt},
-			// 返回 JWT 令牌
-			"token": token,
+			"user": gin.H{"id": u.ID, "email": u.Email, "createdAt": model.Timestamp(u.CreatedAt)},
+			// 返回 JWT 令牌和刷新令牌
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+// refresh 用刷新令牌换一对新令牌
+// HTTP 方法：POST
+// 路径：/api/v1/auth/refresh
+// 请求体：{"refreshToken": "..."}
+// 旧的刷新令牌用过一次就失效，客户端必须保存响应里新的 refreshToken
+func (h *AuthHandler) refresh(c *gin.Context) {
+	var req refreshTokenRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.svc.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		// 令牌无效是 401，数据库出错是 500
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+// logout 登出，吊销这次登录的所有刷新令牌
+// HTTP 方法：POST
+// 路径：/api/v1/auth/logout
+// 请求体：{"refreshToken": "..."}
+// 注意：已经颁发的访问令牌（JWT）无法收回，会在过期后自然失效
+func (h *AuthHandler) logout(c *gin.Context) {
+	var req refreshTokenRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.svc.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// me 获取当前登录用户的资料
+// HTTP 方法：GET
+// 路径：/api/v1/auth/me
+func (h *AuthHandler) me(c *gin.Context) {
+	u, err := h.svc.Me(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		// 令牌有效但用户已经不存在时返回 404
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	// 和注册、登录一样，只返回公开字段，不返回密码哈希
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"id":        u.ID,
+		"email":     u.Email,
+		"createdAt": model.Timestamp(u.CreatedAt),
+	}})
+}
+
+// updateMe 修改当前登录用户的资料（目前只能改邮箱）
+// HTTP 方法：PATCH
+// 路径：/api/v1/auth/me
+// 请求体：{"email": "new@example.com", "currentPassword": "..."}
+func (h *AuthHandler) updateMe(c *gin.Context) {
+	var req updateMeRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	u, err := h.svc.UpdateEmail(c.Request.Context(), c.GetString("userID"), req.Email, req.CurrentPassword)
+	if err != nil {
+		// 邮箱已被其他用户占用时和注册一样返回 409，密码不对和修改密码一样
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"id":        u.ID,
+		"email":     u.Email,
+		"createdAt": model.Timestamp(u.CreatedAt),
+	}})
+}
+
+// changePassword 修改密码
+// HTTP 方法：PUT
+// 路径：/api/v1/auth/password
+// 请求体：{"currentPassword": "...", "newPassword": "..."}
+// 成功后其它设备上的登录失效，响应里返回当前设备要使用的新令牌
+func (h *AuthHandler) changePassword(c *gin.Context) {
+	var req changePasswordRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.svc.ChangePassword(c.Request.Context(), c.GetString("userID"), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		// 当前密码不正确返回 403：已经登录，但没有通过当前密码的验证
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
 		},
 	})
 }
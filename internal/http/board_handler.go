@@ -2,13 +2,25 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
 	"kanban_api/internal/service"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+// maxImportBody 导入请求体的大小上限（10 MiB），Trello 的导出带着大量用不到的字段，比普通请求大得多
+const maxImportBody = 10 << 20
+
 // BoardHandler 看板处理器
 // 处理看板相关的 HTTP 请求
+// 所有路由都注册在需要认证的路由组下，
+// 当前用户 ID 由 AuthRequired 中间件通过 c.Set("userID", ...) 注入
 type BoardHandler struct {
 	svc service.BoardService
 }
@@ -24,35 +36,52 @@ func NewBoardHandler(svc service.BoardService) *BoardHandler {
 // - POST /boards: 创建新资源
 // - GET /boards/:id: 获取单个资源
 // - PUT /boards/:id: 更新资源
+// - PATCH /boards/:id: 部分更新资源
 // - DELETE /boards/:id: 删除资源
-func (h *BoardHandler) Register(rg *gin.RouterGroup) {
+// 另外 GET /boards/by-slug/:slug 可以通过可读的 slug 获取看板，
+// POST /boards/import 从导出的 JSON（本服务或 Trello）导入看板
+// create 只挂在 POST /boards 上的中间件，例如 Idempotency-Key
+func (h *BoardHandler) Register(rg *gin.RouterGroup, create ...gin.HandlerFunc) {
 	// GET 用于查询数据
 	rg.GET("/boards", h.list)
 
 	// POST 用于创建新资源
-	rg.POST("/boards", h.create)
+	rg.POST("/boards", append(create, h.create)...)
+
+	// 导入看板，同样是创建新资源
+	rg.POST("/boards/import", h.importBoard)
 
 	// :id 是路径参数，会匹配任意值
 	// 例如：/boards/123 中的 123 就是 id
 	rg.GET("/boards/:id", h.get)
 
-	// PUT 用于完整更新资源（替换整个资源）
-	// PATCH 用于部分更新（只更新部分字段）
-	// 这里用 PUT，虽然实际上只更新了 title 字段
+	// 通过 slug 获取看板，例如 /boards/by-slug/project-alpha
+	// Gin 会优先匹配静态路径 by-slug，不会和 :id 冲突
+	rg.GET("/boards/by-slug/:slug", h.getBySlug)
+
+	// PUT 用于完整更新资源（替换整个资源）：标题必填，slug 不传时保留原值
+	// PATCH 用于部分更新（只更新部分字段）：只修改请求体里出现的字段
 	rg.PUT("/boards/:id", h.update)
+	rg.PATCH("/boards/:id", h.patch)
 
 	// DELETE 用于删除资源
 	rg.DELETE("/boards/:id", h.delete)
+
+	// 清除创建看板时生成的示例卡片
+	rg.DELETE("/boards/:id/sample", h.clearSample)
 }
 
 // list 列出所有看板
 // GET /api/v1/boards
 func (h *BoardHandler) list(c *gin.Context) {
-	// 调用 Service 层获取所有看板
-	items, err := h.svc.ListBoards()
+	// 调用 Service 层获取当前用户的所有看板
+	// c.GetString("userID") 读取认证中间件存入上下文的用户 ID
+	// c.Request.Context() 是这次 HTTP 请求的 context，客户端断开连接时会被取消
+	items, err := h.svc.ListBoards(c.Request.Context(), c.GetString("userID"))
 	if err != nil {
-		// http.StatusInternalServerError = 500（服务器内部错误）
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// 查询失败是服务器的问题，返回 500（服务器内部错误）
+		// 错误原文只写进日志，不返回给客户端
+		httpx.ServiceError(c, err)
 		return
 	}
 
@@ -63,29 +92,33 @@ func (h *BoardHandler) list(c *gin.Context) {
 
 // create 创建新看板
 // POST /api/v1/boards
-// 请求体：{"title": "我的看板"}
+// 请求体：{"title": "我的看板"}；{"title": "我的看板", "defaultLists": true} 同时创建默认列表；
+// "sample": true 再生成一组示例卡片
 func (h *BoardHandler) create(c *gin.Context) {
 	// 定义请求体结构
-	var req struct {
-		Title string `json:"title"`
-	}
+	var req boardRequest
 
-	// 解析 JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	// 解析 JSON 并校验（标题必填、长度上限等）
+	if !httpx.BindJSON(c, &req) {
 		return
 	}
 
 	// 调用 Service 层创建看板
-	b, err := h.svc.CreateBoard(req.Title)
+	b, err := h.svc.CreateBoard(c.Request.Context(), c.GetString("userID"), service.BoardInput{
+		Title:        req.Title,
+		Lists:        req.Lists,
+		DefaultLists: req.DefaultLists,
+		Sample:       req.Sample,
+	})
 	if err != nil {
 		// 注意：这里缺少 return
 		// 如果不加 return，会继续执行下面的代码，导致返回两个响应（会报错）
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		httpx.ServiceError(c, err)
 		return // 应该加上 return
 	}
 
 	// 创建成功，返回 201
+	c.Header("ETag", boardETag(b.Version))
 	c.JSON(http.StatusCreated, gin.H{"data": b})
 }
 
@@ -103,57 +136,164 @@ func (h *BoardHandler) get(c *gin.Context) {
 	id := c.Param("id")
 
 	// 调用 Service 层获取看板
-	b, err := h.svc.GetBoard(id)
+	b, err := h.svc.GetBoard(c.Request.Context(), c.GetString("userID"), id)
+	if err != nil {
+		// 看板不存在（或者用户看不到）时返回 404（未找到）
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	// 返回看板数据，ETag 是看板的版本号，更新时放进 If-Match
+	c.Header("ETag", boardETag(b.Version))
+	c.JSON(http.StatusOK, gin.H{"data": b})
+}
+
+// getBySlug 通过 slug 获取单个看板
+// GET /api/v1/boards/by-slug/:slug
+func (h *BoardHandler) getBySlug(c *gin.Context) {
+	b, err := h.svc.GetBoardBySlug(c.Request.Context(), c.GetString("userID"), c.Param("slug"))
 	if err != nil {
-		// http.StatusNotFound = 404（未找到）
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		httpx.ServiceError(c, err)
 		return
 	}
 
-	// 返回看板数据
+	c.Header("ETag", boardETag(b.Version))
 	c.JSON(http.StatusOK, gin.H{"data": b})
 }
 
 // update 更新看板
 // PUT /api/v1/boards/:id
-// 请求体：{"title": "新标题"}
+// 请求体：{"title": "新标题", "slug": "new-slug", "version": 3}，slug 可选
+//
+// 必须带上读到的看板版本，二选一：If-Match 请求头（GET 返回的 ETag），或者请求体里的 version
+// 都没带时返回 428；看板已经被别人修改过时，用 If-Match 的返回 412，用 version 的返回 409
+// If-Match: * 表示不检查版本
 func (h *BoardHandler) update(c *gin.Context) {
 	// 获取路径参数（看板 ID）
 	id := c.Param("id")
 
 	// 定义请求体结构
-	var req struct {
-		Title string `json:"title"`
-	}
+	var req updateBoardRequest
 
-	// 解析 JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+	// 解析 JSON 并校验
+	if !httpx.BindJSON(c, &req) {
 		return // 应该加上 return
 	}
 
+	version, ok := boardVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
 	// 调用 Service 层更新看板
-	b, err := h.svc.UpdateBoard(id, req.Title)
+	b, err := h.svc.UpdateBoard(c.Request.Context(), c.GetString("userID"), id, req.Title, req.Slug, version)
+	h.updated(c, b, err)
+}
+
+// patch 部分更新看板
+// PATCH /api/v1/boards/:id
+// 只修改请求体中出现的字段，例如：{"slug": "q3-roadmap"}；{"slug": ""} 按标题重新生成 slug
+// 版本的要求和 PUT 相同（If-Match 或者请求体里的 version）
+func (h *BoardHandler) patch(c *gin.Context) {
+	var req boardPatchRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+	version, ok := boardVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	b, err := h.svc.PatchBoard(c.Request.Context(), c.GetString("userID"), c.Param("id"), service.BoardPatch{Title: req.Title, Slug: req.Slug}, version)
+	h.updated(c, b, err)
+}
+
+// boardVersion 取出更新看板时带的版本：If-Match 请求头或者请求体里的 version，两个都带了以 If-Match 为准
+// 都没带、If-Match 格式不对时已经写好响应，返回 false
+func boardVersion(c *gin.Context, body int64) (int64, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if body == 0 {
+			// http.StatusPreconditionRequired = 428（需要前置条件）
+			httpx.Abort(c, http.StatusPreconditionRequired, httpx.CodePreconditionRequired, "If-Match header or version is required")
+			return 0, false
+		}
+		return body, true
+	}
+	v, ok := parseBoardETag(ifMatch)
+	if !ok {
+		httpx.Abort(c, http.StatusPreconditionFailed, httpx.CodePreconditionFailed, "If-Match does not match the board")
+		return 0, false
+	}
+	return v, true
+}
+
+// updated 写更新看板（PUT / PATCH）的响应
+func (h *BoardHandler) updated(c *gin.Context, b model.Board, err error) {
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return // 应该加上 return
+		// http.StatusPreconditionFailed = 412：If-Match 这个前置条件不成立
+		if c.GetHeader("If-Match") != "" && errors.Is(err, service.ErrVersionConflict) {
+			httpx.Abort(c, http.StatusPreconditionFailed, httpx.CodePreconditionFailed, err.Error())
+			return
+		}
+		// 只读成员不能修改看板（403）
+		// slug 被其他看板占用、请求体里的 version 已经过时返回 409，与注册时邮箱已存在的处理一致
+		httpx.ServiceError(c, err)
+		return
 	}
 
-	// 更新成功，返回更新后的看板
+	// 更新成功，返回更新后的看板和新的 ETag
+	c.Header("ETag", boardETag(b.Version))
 	c.JSON(http.StatusOK, gin.H{"data": b})
 }
 
+// boardETag 看板的 ETag，就是带引号的版本号，例如 "3"
+func boardETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseBoardETag 解析 If-Match 里的 ETag，只支持一个值
+// * 返回 0（不检查版本）；弱 ETag（W/"3"）按 RFC 9110 不能用于 If-Match，和格式不对一样返回 false
+func parseBoardETag(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return 0, true
+	}
+	if len(s) < 3 || s[0] != '"' || s[len(s)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s[1:len(s)-1], 10, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
 // delete 删除看板
-// DELETE /api/v1/boards/:id
+// DELETE /api/v1/boards/:id[?confirm=<confirmToken>]
+// 卡片很多的看板要分两步删除：
+// 1. 不带 confirm 调用，返回 428 和确认令牌、影响范围（会删除多少列表和卡片）
+// 2. 用户确认后带上 ?confirm=<confirmToken> 再调用一次，才真正删除
 func (h *BoardHandler) delete(c *gin.Context) {
 	// 获取要删除的看板 ID
 	id := c.Param("id")
 
 	// 调用 Service 层删除看板
-	if err := h.svc.DeleteBoard(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	confirm, err := h.svc.DeleteBoard(c.Request.Context(), c.GetString("userID"), id, c.Query("confirm"))
+	if err != nil {
+		// 确认令牌无效是 400，成员能看到看板但不能删除是 403
+		httpx.ServiceError(c, err)
 		return // 应该加上 return
 	}
+	if confirm != nil {
+		// http.StatusPreconditionRequired = 428（需要前置条件）
+		// 表示这次请求没有执行，需要先满足条件（带上确认令牌）再来
+		// 错误格式和其它接口一样，另外在 data 里带上确认令牌和影响范围
+		body := httpx.ErrorBody(c, httpx.CodeConfirmationRequired, "confirmation required")
+		body["data"] = confirm
+		c.JSON(http.StatusPreconditionRequired, body)
+		return
+	}
 
 	// http.StatusNoContent = 204（无内容）
 	// 204 表示请求成功，但没有内容返回
@@ -161,3 +301,72 @@ func (h *BoardHandler) delete(c *gin.Context) {
 	// c.Status 只设置状态码，不返回响应体
 	c.Status(http.StatusNoContent)
 }
+
+// importBoard 导入看板
+// POST /api/v1/boards/import?format=export|trello
+// 请求体是本服务的导出（service.BoardExport，默认）或者 Trello 的看板导出（format=trello）
+// 导出里的 ID 只用来对应列表、卡片和清单，导入后全部换成新的 ID，看板属于当前用户
+func (h *BoardHandler) importBoard(c *gin.Context) {
+	q := httpx.Query(c)
+	format := q.String("format", "export")
+	if !q.Valid() {
+		return
+	}
+
+	// 各种格式都先转换成 service.BoardImport
+	var src interface {
+		BoardImport() (service.BoardImport, error)
+	}
+	switch format {
+	case "export":
+		src = &service.BoardExport{}
+	case "trello":
+		src = &service.TrelloBoard{}
+	default:
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "format must be export or trello")
+		return
+	}
+	if err := decodeImport(c, src); err != nil {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "invalid import: "+err.Error())
+		return
+	}
+
+	in, err := src.BoardImport()
+	if err != nil {
+		// 结构问题（引用了不存在的列表等）是 ErrInvalidInput，交给 ServiceError 统一处理
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	res, err := h.svc.ImportBoard(c.Request.Context(), c.GetString("userID"), in)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": res})
+}
+
+// decodeImport 解析导入的请求体
+// 导出文件有嵌套的对象，而且 Trello 的导出有大量用不到的字段，这里不用 httpx.BindJSON，也不拒绝不认识的字段
+func decodeImport(c *gin.Context, v any) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBody))
+	if err != nil {
+		return errors.New("body too large")
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// clearSample 删除看板上的示例卡片，自己创建的卡片不受影响
+// DELETE /api/v1/boards/:id/sample
+// 返回删除了多少张：{"data": {"deleted": 6}}；没有示例卡片时是 0
+func (h *BoardHandler) clearSample(c *gin.Context) {
+	cards, err := h.svc.ClearSample(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"deleted": len(cards)}})
+}
@@ -0,0 +1,86 @@
+// Package http 请求抓包处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/capture"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"net/http"
+	"time"
+)
+
+// defaultCaptureDuration 没有指定时长时抓多久
+const defaultCaptureDuration = 15 * time.Minute
+
+// CaptureHandler 请求抓包处理器
+// 排查只在某个用户、某个接口上出现的问题：打开抓包，让用户重现一次，再把请求和响应取出来看
+// 路由组上必须挂 AuthRequired 和 RequireRole("admin")
+type CaptureHandler struct {
+	rec *capture.Recorder
+}
+
+// NewCaptureHandler 创建抓包处理器实例
+func NewCaptureHandler(rec *capture.Recorder) *CaptureHandler {
+	return &CaptureHandler{rec: rec}
+}
+
+// Register 注册路由
+// - GET    /admin/capture: 查看抓包状态和抓到的请求
+// - PUT    /admin/capture: 开始抓包（清空上一次的结果）
+// - DELETE /admin/capture: 停止抓包（结果保留）
+func (h *CaptureHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/admin/capture", skipCapture)
+	g.GET("", h.get)
+	g.PUT("", h.start)
+	g.DELETE("", h.stop)
+}
+
+// skipCapture 抓包接口自己的请求不抓，见 middleware.Capture
+func skipCapture(c *gin.Context) {
+	c.Set("captureSkip", true)
+	c.Next()
+}
+
+// get 查看抓包状态和抓到的请求
+// GET /api/v1/admin/capture
+func (h *CaptureHandler) get(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"status":    h.rec.Status(),
+		"exchanges": h.rec.List(),
+	}})
+}
+
+// start 开始抓包
+// PUT /api/v1/admin/capture
+// 请求体：{"userId": "...", "route": "/api/v1/boards/:id", "durationMinutes": 15}
+// userId 和 route 至少传一个，不允许不加条件地抓所有请求
+func (h *CaptureHandler) start(c *gin.Context) {
+	var req captureRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+	if req.UserID == "" && req.Route == "" {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "userId or route required")
+		return
+	}
+
+	d := defaultCaptureDuration
+	if req.DurationMinutes > 0 {
+		d = time.Duration(req.DurationMinutes) * time.Minute
+	}
+	st := h.rec.Start(capture.Filter{UserID: req.UserID, Route: req.Route}, d)
+
+	// 和修改日志设置一样按 warn 级别记录，事后知道是谁、什么时候抓了谁的请求
+	logging.FromContext(c.Request.Context()).Warn("request capture started",
+		"filter_user_id", req.UserID, "filter_route", req.Route, "duration", d.String())
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
+
+// stop 停止抓包
+// DELETE /api/v1/admin/capture
+func (h *CaptureHandler) stop(c *gin.Context) {
+	st := h.rec.Stop()
+	logging.FromContext(c.Request.Context()).Warn("request capture stopped", "captured", st.Captured)
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
@@ -0,0 +1,215 @@
+// Package http 卡片处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+// CardHandler 卡片处理器
+// 卡片挂在 /boards/:id/lists/:listId/cards 下面
+// 注意：Gin 要求同一位置的路径参数名一致，所以看板 ID 沿用 :id 而不是 :boardId
+type CardHandler struct {
+	svc service.CardService
+}
+
+// NewCardHandler 创建卡片处理器实例
+func NewCardHandler(svc service.CardService) *CardHandler {
+	return &CardHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET    .../cards: 列出卡片
+// - POST   .../cards: 创建卡片；带 ?template=:tid 时按看板的卡片模板创建
+// - GET    .../cards/:cardId: 获取单张卡片
+// - PUT    .../cards/:cardId: 整体替换卡片
+// - PATCH  .../cards/:cardId: 部分更新卡片
+// - PATCH  .../cards/:cardId/move: 移动卡片（拖拽排序）
+// - DELETE .../cards/:cardId: 删除卡片
+// - GET    /cards/upcoming?days=7&overdue=true: 所有看板中快到期和已经过期的卡片
+// create 只挂在创建卡片上的中间件，例如 Idempotency-Key
+func (h *CardHandler) Register(rg *gin.RouterGroup, create ...gin.HandlerFunc) {
+	rg.GET("/cards/upcoming", h.upcoming)
+
+	cards := rg.Group("/boards/:id/lists/:listId/cards")
+	cards.GET("", h.list)
+	cards.POST("", append(create, h.create)...)
+	cards.GET("/:cardId", h.get)
+	cards.PUT("/:cardId", h.replace)
+	cards.PATCH("/:cardId", h.patch)
+	cards.PATCH("/:cardId/move", h.move)
+	cards.DELETE("/:cardId", h.delete)
+}
+
+// list 列出列表中的卡片
+// GET /api/v1/boards/:id/lists/:listId/cards
+func (h *CardHandler) list(c *gin.Context) {
+	items, err := h.svc.ListCards(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// create 创建卡片
+// POST /api/v1/boards/:id/lists/:listId/cards
+// 请求体：{"title": "写文档", "description": "...", "dueDate": "2024-01-31T18:00:00Z", "labels": ["文档"]}
+func (h *CardHandler) create(c *gin.Context) {
+	if templateID := c.Query("template"); templateID != "" {
+		h.createFromTemplate(c, templateID)
+		return
+	}
+
+	var req cardRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	card, err := h.svc.CreateCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), service.CardInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate.Ptr(),
+		Reminder:    req.Reminder,
+		Labels:      req.Labels,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": card})
+}
+
+// createFromTemplate 按卡片模板创建卡片
+// POST /api/v1/boards/:id/lists/:listId/cards?template=:tid
+// 请求体和 create 一样，但是都可以不传：{"title": "登录页白屏"}
+// title 填进模板标题的 {{title}}，description 不为空时代替模板的描述，labels 追加在模板的标签后面
+func (h *CardHandler) createFromTemplate(c *gin.Context, templateID string) {
+	var req templatedCardRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	card, err := h.svc.CreateCardFromTemplate(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), templateID, service.CardInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate.Ptr(),
+		Reminder:    req.Reminder,
+		Labels:      req.Labels,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": card})
+}
+
+// get 获取单张卡片
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId
+func (h *CardHandler) get(c *gin.Context) {
+	card, err := h.svc.GetCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": card})
+}
+
+// replace 整体替换卡片
+// PUT /api/v1/boards/:id/lists/:listId/cards/:cardId
+// 没有传的字段会被清空（例如不传 dueDate 就会去掉截止日期，不传 labels 就会去掉所有标签）
+func (h *CardHandler) replace(c *gin.Context) {
+	var req cardRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	card, err := h.svc.ReplaceCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), service.CardInput{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate.Ptr(),
+		Reminder:    req.Reminder,
+		Labels:      req.Labels,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": card})
+}
+
+// patch 部分更新卡片
+// PATCH /api/v1/boards/:id/lists/:listId/cards/:cardId
+// 只修改请求体中出现的字段，例如：{"description": "补充说明"}；{"dueDate": null} 清空截止日期
+func (h *CardHandler) patch(c *gin.Context) {
+	var req cardPatchRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	card, err := h.svc.PatchCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), service.CardPatch{
+		Title:        req.Title,
+		Description:  req.Description,
+		DueDate:      req.DueDate.Time,
+		ClearDueDate: req.DueDate.Set && req.DueDate.Time == nil,
+		Reminder:     req.Reminder,
+		Labels:       req.Labels,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": card})
+}
+
+// move 移动卡片到目标列表的指定位置
+// PATCH /api/v1/boards/:id/lists/:listId/cards/:cardId/move
+// 请求体：{"boardId": "目标看板 ID", "listId": "目标列表 ID", "position": 0}
+// boardId 不传表示在同一看板内移动；listId 不传表示在原列表内调整顺序（跨看板时必须传）
+// position 从 0 开始，超出范围时放到末尾
+// 返回移动后目标列表的全部卡片，拖拽客户端可以直接用它刷新界面
+func (h *CardHandler) move(c *gin.Context) {
+	var req moveCardRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	items, err := h.svc.MoveCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), req.BoardID, req.ListID, *req.Position)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// delete 删除卡片
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId
+func (h *CardHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteCard(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// upcoming 快到期和已经过期的卡片
+// GET /api/v1/cards/upcoming?days=7&overdue=true
+// days 是往后看几天（1-90，默认 7）；overdue=false 时不返回已经过期的卡片
+func (h *CardHandler) upcoming(c *gin.Context) {
+	q := httpx.Query(c)
+	days := q.Int("days", 7, 1, 90)
+	overdue := q.Bool("overdue", true)
+	if !q.Valid() {
+		return
+	}
+
+	out, err := h.svc.UpcomingCards(c.Request.Context(), c.GetString("userID"), time.Duration(days)*24*time.Hour, overdue)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": out})
+}
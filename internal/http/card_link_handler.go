@@ -0,0 +1,65 @@
+// Package http 卡片镜像处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// CardLinkHandler 卡片镜像处理器
+// 只负责创建镜像和管理链接，标题、状态的同步在 service.MirrorSync 里异步进行
+type CardLinkHandler struct {
+	svc service.CardLinkService
+}
+
+// NewCardLinkHandler 创建卡片镜像处理器实例
+func NewCardLinkHandler(svc service.CardLinkService) *CardLinkHandler {
+	return &CardLinkHandler{svc: svc}
+}
+
+// Register 注册路由
+func (h *CardLinkHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/boards/:id/lists/:listId/cards/:cardId/mirrors", h.mirror)
+	rg.GET("/boards/:id/lists/:listId/cards/:cardId/links", h.list)
+	rg.DELETE("/boards/:id/lists/:listId/cards/:cardId/links/:linkId", h.delete)
+}
+
+// mirror 在另一个看板的列表里创建卡片的镜像
+// POST /api/v1/boards/:id/lists/:listId/cards/:cardId/mirrors
+// 请求体：{"boardId": "目标看板 ID", "listId": "目标列表 ID"}
+func (h *CardLinkHandler) mirror(c *gin.Context) {
+	var req mirrorCardRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	card, err := h.svc.CreateMirror(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), req.BoardID, req.ListID)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": card})
+}
+
+// list 列出卡片的链接
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId/links
+func (h *CardLinkHandler) list(c *gin.Context) {
+	items, err := h.svc.ListLinks(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// delete 删除链接，两张卡片都保留
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId/links/:linkId
+func (h *CardLinkHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteLink(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("linkId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+// Package http 卡片模板处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// CardTemplateHandler 卡片模板处理器
+// 只负责管理模板，从模板创建卡片是 POST .../cards?template=:tid，见 CardHandler
+type CardTemplateHandler struct {
+	svc service.CardTemplateService
+}
+
+// NewCardTemplateHandler 创建卡片模板处理器实例
+func NewCardTemplateHandler(svc service.CardTemplateService) *CardTemplateHandler {
+	return &CardTemplateHandler{svc: svc}
+}
+
+// Register 注册路由
+func (h *CardTemplateHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/card-templates", h.list)
+	rg.POST("/boards/:id/card-templates", h.create)
+	rg.GET("/boards/:id/card-templates/:templateId", h.get)
+	rg.PUT("/boards/:id/card-templates/:templateId", h.replace)
+	rg.DELETE("/boards/:id/card-templates/:templateId", h.delete)
+}
+
+// templateInput 把请求体转成服务层的输入
+func templateInput(r cardTemplateRequest) service.CardTemplateInput {
+	in := service.CardTemplateInput{
+		Name:        r.Name,
+		Title:       r.Title,
+		Description: r.Description,
+		Labels:      r.Labels,
+	}
+	if r.Checklist != nil {
+		in.Checklist = &model.TemplateChecklist{Title: r.Checklist.Title, Items: r.Checklist.Items}
+	}
+	return in
+}
+
+// list 列出看板的卡片模板
+// GET /api/v1/boards/:id/card-templates
+func (h *CardTemplateHandler) list(c *gin.Context) {
+	items, err := h.svc.ListTemplates(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// create 创建卡片模板
+// POST /api/v1/boards/:id/card-templates
+// 请求体：{"name": "Bug 报告", "title": "[Bug] {{title}}", "description": "复现步骤：\n", "labels": ["bug"],
+// "checklist": {"title": "修复流程", "items": ["复现", "修复", "回归测试"]}}
+func (h *CardTemplateHandler) create(c *gin.Context) {
+	var req cardTemplateRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	t, err := h.svc.CreateTemplate(c.Request.Context(), c.GetString("userID"), c.Param("id"), templateInput(req))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": t})
+}
+
+// get 获取单个卡片模板
+// GET /api/v1/boards/:id/card-templates/:templateId
+func (h *CardTemplateHandler) get(c *gin.Context) {
+	t, err := h.svc.GetTemplate(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("templateId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": t})
+}
+
+// replace 整体替换卡片模板
+// PUT /api/v1/boards/:id/card-templates/:templateId
+// 请求体和 create 一样，没有传的字段会被清空（例如不传 checklist 就会去掉模板的清单）
+func (h *CardTemplateHandler) replace(c *gin.Context) {
+	var req cardTemplateRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	t, err := h.svc.ReplaceTemplate(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("templateId"), templateInput(req))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": t})
+}
+
+// delete 删除卡片模板，已经从模板创建的卡片不受影响
+// DELETE /api/v1/boards/:id/card-templates/:templateId
+func (h *CardTemplateHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteTemplate(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("templateId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
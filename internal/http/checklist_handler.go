@@ -0,0 +1,195 @@
+// Package http 检查清单处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// ChecklistHandler 检查清单处理器
+// 清单挂在卡片下面：/boards/:id/lists/:listId/cards/:cardId/checklists
+type ChecklistHandler struct {
+	svc service.ChecklistService
+}
+
+// NewChecklistHandler 创建检查清单处理器实例
+func NewChecklistHandler(svc service.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{svc: svc}
+}
+
+// Register 注册路由，前缀 .../cards/:cardId/checklists 省略为 ...
+// - GET    ...: 列出卡片的所有清单
+// - POST   ...: 创建清单
+// - GET    .../:checklistId: 获取单个清单
+// - PUT    .../:checklistId: 重命名清单
+// - PUT    .../:checklistId/position: 移动清单
+// - DELETE .../:checklistId: 删除清单
+// - POST   .../:checklistId/items: 添加条目
+// - PATCH  .../:checklistId/items/:itemId: 修改条目（勾选、取消勾选、改内容）
+// - PUT    .../:checklistId/items/:itemId/position: 移动条目
+// - DELETE .../:checklistId/items/:itemId: 删除条目
+func (h *ChecklistHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/boards/:id/lists/:listId/cards/:cardId/checklists")
+	g.GET("", h.list)
+	g.POST("", h.create)
+	g.GET("/:checklistId", h.get)
+	g.PUT("/:checklistId", h.rename)
+	g.PUT("/:checklistId/position", h.move)
+	g.DELETE("/:checklistId", h.delete)
+	g.POST("/:checklistId/items", h.addItem)
+	g.PATCH("/:checklistId/items/:itemId", h.updateItem)
+	g.PUT("/:checklistId/items/:itemId/position", h.moveItem)
+	g.DELETE("/:checklistId/items/:itemId", h.deleteItem)
+}
+
+// list 列出卡片的所有清单
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists
+func (h *ChecklistHandler) list(c *gin.Context) {
+	items, err := h.svc.ListChecklists(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// create 创建清单
+// POST /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists
+// 请求体：{"title": "上线前检查"}
+func (h *ChecklistHandler) create(c *gin.Context) {
+	var req checklistRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	cl, err := h.svc.CreateChecklist(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), req.Title)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": cl})
+}
+
+// get 获取单个清单
+// GET /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId
+func (h *ChecklistHandler) get(c *gin.Context) {
+	cl, err := h.svc.GetChecklist(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cl})
+}
+
+// rename 重命名清单
+// PUT /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId
+// 请求体：{"title": "新标题"}
+func (h *ChecklistHandler) rename(c *gin.Context) {
+	var req checklistRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	cl, err := h.svc.RenameChecklist(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), req.Title)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cl})
+}
+
+// move 移动清单到指定位置
+// PUT /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId/position
+// 请求体：{"position": 0}，返回移动后卡片的全部清单
+func (h *ChecklistHandler) move(c *gin.Context) {
+	var req positionRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	items, err := h.svc.MoveChecklist(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), *req.Position)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// delete 删除清单
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId
+func (h *ChecklistHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteChecklist(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// addItem 添加条目
+// POST /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId/items
+// 请求体：{"text": "跑一遍回归测试"}
+// 和下面几个条目接口一样，返回修改后的整个清单（带进度）
+func (h *ChecklistHandler) addItem(c *gin.Context) {
+	var req checklistItemRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	cl, err := h.svc.AddItem(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), req.Text)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": cl})
+}
+
+// updateItem 修改条目
+// PATCH /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId/items/:itemId
+// 请求体：{"done": true} 或 {"text": "新内容"}，只修改出现的字段
+func (h *ChecklistHandler) updateItem(c *gin.Context) {
+	var req checklistItemPatchRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	cl, err := h.svc.UpdateItem(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), c.Param("itemId"), service.ChecklistItemPatch{
+		Text: req.Text,
+		Done: req.Done,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cl})
+}
+
+// moveItem 移动条目到指定位置
+// PUT /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId/items/:itemId/position
+// 请求体：{"position": 0}
+func (h *ChecklistHandler) moveItem(c *gin.Context) {
+	var req positionRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	cl, err := h.svc.MoveItem(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), c.Param("itemId"), *req.Position)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cl})
+}
+
+// deleteItem 删除条目
+// DELETE /api/v1/boards/:id/lists/:listId/cards/:cardId/checklists/:checklistId/items/:itemId
+// 和其它删除接口不同，返回 200 和修改后的清单，客户端要用新的进度
+func (h *ChecklistHandler) deleteItem(c *gin.Context) {
+	cl, err := h.svc.DeleteItem(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), c.Param("cardId"), c.Param("checklistId"), c.Param("itemId"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cl})
+}
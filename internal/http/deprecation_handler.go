@@ -0,0 +1,30 @@
+// Package http 弃用接口使用报告处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/middleware"
+	"net/http"
+)
+
+// DeprecationHandler 弃用接口使用报告处理器
+// 下线一个接口之前先看看还有谁在调用，通知到这些调用方再删
+// 路由组上必须挂 AuthRequired 和 RequireRole("admin")
+type DeprecationHandler struct{}
+
+// NewDeprecationHandler 创建弃用接口使用报告处理器实例
+func NewDeprecationHandler() *DeprecationHandler {
+	return &DeprecationHandler{}
+}
+
+// Register 注册路由
+// - GET /admin/deprecations: 所有弃用接口的调用次数和调用方
+func (h *DeprecationHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/deprecations", h.report)
+}
+
+// report 弃用接口使用报告
+// GET /api/v1/admin/deprecations
+func (h *DeprecationHandler) report(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": middleware.DeprecationReport()})
+}
@@ -0,0 +1,309 @@
+// Package http 看板嵌入处理器
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/middleware"
+	"kanban_api/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// embedPagePath 分享页面的路径，聊天软件展开链接时读取页面里的 OpenGraph 标签
+	embedPagePath = "/api/v1/embed/board/page"
+
+	// oembedMaxCacheAge oEmbed 响应允许缓存的最长时间
+	// 卡片的状态和截止日期随时会改，不宜缓存太久；令牌更早过期时以令牌为准
+	oembedMaxCacheAge = time.Hour
+
+	// providerName oEmbed 和 OpenGraph 里的站点名称
+	providerName = "Kanban"
+)
+
+// EmbedHandler 看板嵌入处理器
+// 管理嵌入令牌（需要登录），以及提供只读的嵌入接口（凭令牌访问，无需登录）
+type EmbedHandler struct {
+	svc service.EmbedService
+}
+
+// NewEmbedHandler 创建嵌入处理器实例
+func NewEmbedHandler(svc service.EmbedService) *EmbedHandler {
+	return &EmbedHandler{svc: svc}
+}
+
+// Register 注册需要登录的令牌管理路由
+func (h *EmbedHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/boards/:id/embed-tokens", h.createToken)
+	rg.GET("/boards/:id/embed-tokens", h.listTokens)
+	rg.DELETE("/boards/:id/embed-tokens/:tokenId", h.revokeToken)
+
+	// 旧的单数路径，和列出、吊销令牌的路径不一致，改用上面的复数路径
+	middleware.Deprecate(rg, http.MethodPost, "/boards/:id/embed-token", middleware.Deprecation{
+		Since:     time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC),
+		Successor: "/api/v1/boards/:id/embed-tokens",
+	}, h.createToken)
+}
+
+// RegisterPublic 注册公共的只读嵌入路由
+// 这个接口不经过 AuthRequired，只认嵌入令牌
+// - GET /embed/board?token=...: 看板 JSON
+// - GET /embed/board/page?token=...&card=...: 分享页面（HTML，带 OpenGraph 标签），card 不传时是看板本身
+// - GET /oembed?url=<分享页面地址>: oEmbed 接口，聊天软件用它生成链接预览
+func (h *EmbedHandler) RegisterPublic(rg *gin.RouterGroup) {
+	rg.GET("/embed/board", h.board)
+	rg.GET("/embed/board/page", h.page)
+	rg.GET("/oembed", h.oembed)
+}
+
+// createToken 为看板签发嵌入令牌
+// POST /api/v1/boards/:id/embed-tokens（旧路径 /embed-token 已弃用）
+// 请求体（可选）：{"ttlMinutes": 60}
+func (h *EmbedHandler) createToken(c *gin.Context) {
+	var req embedTokenRequest
+
+	// 请求体是可选的，只有带了内容才解析
+	if c.Request.ContentLength > 0 && !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	rec, token, err := h.svc.CreateToken(c.Request.Context(), c.GetString("userID"), c.Param("id"), time.Duration(req.TTLMinutes)*time.Minute)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"embedToken": rec,
+			"token":      token,
+			// 嵌入地址，可以直接放进 iframe 的 src
+			"url": "/api/v1/embed/board?token=" + token,
+			// 分享页面，贴到 Slack、Teams 里会展开成预览；分享单张卡片时加上 &card=<卡片 ID>
+			"pageUrl": embedPagePath + "?token=" + token,
+		},
+	})
+}
+
+// listTokens 列出看板的嵌入令牌
+// GET /api/v1/boards/:id/embed-tokens
+func (h *EmbedHandler) listTokens(c *gin.Context) {
+	items, err := h.svc.ListTokens(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// revokeToken 吊销嵌入令牌
+// DELETE /api/v1/boards/:id/embed-tokens/:tokenId
+func (h *EmbedHandler) revokeToken(c *gin.Context) {
+	if _, err := h.svc.RevokeToken(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("tokenId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// board 凭嵌入令牌只读地获取看板
+// GET /api/v1/embed/board?token=<embed token>
+func (h *EmbedHandler) board(c *gin.Context) {
+	b, err := h.svc.ResolveBoard(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		// 令牌无效和看板已删除都返回 401，不泄露看板是否存在
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": b})
+}
+
+// page 分享页面
+// GET /api/v1/embed/board/page?token=<embed token>&card=<卡片 ID，可选>
+// 页面本身很简单，主要给聊天软件读 OpenGraph 标签和 oEmbed 地址
+func (h *EmbedHandler) page(c *gin.Context) {
+	q := httpx.Query(c)
+	token := q.String("token", "")
+	cardID := q.String("card", "")
+	if !q.Valid() {
+		return
+	}
+
+	p, err := h.svc.Preview(c.Request.Context(), token, cardID)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	base := baseURL(c)
+	pageURL := base + embedPageURL(token, cardID)
+	data := embedPageData{
+		Title:       previewTitle(p),
+		Description: previewDescription(p),
+		SiteName:    providerName,
+		URL:         pageURL,
+		OEmbedURL:   base + "/api/v1/oembed?" + url.Values{"url": {pageURL}}.Encode(),
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := embedPageTemplate.Execute(c.Writer, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// oembedResponse oEmbed 1.0 的 link 类型响应，字段名按规范使用下划线
+// description 不是规范里的字段，规范允许附加字段，能识别的客户端会显示状态和截止日期
+type oembedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	AuthorName   string `json:"author_name,omitempty"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+}
+
+// oembed 返回分享链接的 oEmbed 数据
+// GET /api/v1/oembed?url=<分享页面地址>&format=json
+// 按 oEmbed 规范：不支持的格式返回 501，不认识的链接返回 404，令牌无效返回 401；
+// 成功时响应体就是 oEmbed 对象，不包在 data 里
+func (h *EmbedHandler) oembed(c *gin.Context) {
+	q := httpx.Query(c)
+	rawURL := q.String("url", "")
+	format := q.String("format", "json")
+	if !q.Valid() {
+		return
+	}
+	if format != "json" {
+		httpx.Abort(c, http.StatusNotImplemented, httpx.CodeInvalidInput, "only json format is supported")
+		return
+	}
+	token, cardID, ok := parseEmbedURL(rawURL)
+	if !ok {
+		httpx.Abort(c, http.StatusNotFound, httpx.CodeNotFound, "unsupported url")
+		return
+	}
+
+	p, err := h.svc.Preview(c.Request.Context(), token, cardID)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	cacheAge := min(time.Until(p.ExpiresAt), oembedMaxCacheAge)
+	resp := oembedResponse{
+		Version:      "1.0",
+		Type:         "link",
+		Title:        previewTitle(p),
+		Description:  previewDescription(p),
+		ProviderName: providerName,
+		ProviderURL:  baseURL(c),
+		CacheAge:     max(int(cacheAge.Seconds()), 0),
+	}
+	if p.Card != nil {
+		resp.AuthorName = p.Board.Title
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseEmbedURL 从分享页面或者嵌入接口的地址里取出令牌和卡片 ID
+// 只看路径和查询参数，不检查域名
+func parseEmbedURL(raw string) (token, cardID string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Path != embedPagePath && u.Path != "/api/v1/embed/board") {
+		return "", "", false
+	}
+	token = u.Query().Get("token")
+	return token, u.Query().Get("card"), token != ""
+}
+
+// embedPageURL 分享页面的路径和查询参数
+func embedPageURL(token, cardID string) string {
+	v := url.Values{"token": {token}}
+	if cardID != "" {
+		v.Set("card", cardID)
+	}
+	return embedPagePath + "?" + v.Encode()
+}
+
+// baseURL 当前请求的站点地址（scheme://host），拼 og:url、oEmbed 地址这些绝对地址用
+// 部署在终止 TLS 的反向代理后面时，按代理传来的 X-Forwarded-Proto 判断是不是 https
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// previewTitle 预览标题：卡片标题或者看板标题
+func previewTitle(p service.LinkPreview) string {
+	if p.Card != nil {
+		return p.Card.Title
+	}
+	return p.Board.Title
+}
+
+// previewDescription 预览说明
+// 卡片：所在列表（状态）和截止日期，例如 "Status: Doing · Due: 2026-10-20"
+// 看板：列表数和卡片数，例如 "3 lists · 12 cards"
+func previewDescription(p service.LinkPreview) string {
+	if p.Card == nil {
+		return countNoun(p.ListCount, "list") + " · " + countNoun(p.CardCount, "card")
+	}
+	parts := []string{"Status: " + p.Status}
+	if p.Card.DueDate != nil {
+		parts = append(parts, "Due: "+p.Card.DueDate.UTC().Format(time.DateOnly))
+		if p.Card.DueDate.Before(time.Now()) {
+			parts[len(parts)-1] += " (overdue)"
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// countNoun 数量加英文名词，1 个时用单数，例如 "1 list"、"3 lists"
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// embedPageData 分享页面模板的数据
+type embedPageData struct {
+	Title       string
+	Description string
+	SiteName    string
+	URL         string
+	OEmbedURL   string
+}
+
+// embedPageTemplate 分享页面
+// html/template 会按位置转义（属性里的 URL、正文里的文本），标题里的 <script> 之类不会被执行
+var embedPageTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="{{.SiteName}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.URL}}">
+<meta name="twitter:card" content="summary">
+<meta name="robots" content="noindex">
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Title}}">
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Description}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,69 @@
+// Package http 健康检查处理器
+package http
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/repository"
+	"net/http"
+	"time"
+)
+
+// readyTimeout 就绪检查中每个存储 ping 的超时时间
+// 要比探针自己的超时（Kubernetes 默认 1 秒起）短，否则探针先超时，看不到是哪个存储出了问题
+const readyTimeout = 800 * time.Millisecond
+
+// HealthHandler 健康检查处理器
+// 给 Kubernetes 探针和负载均衡器使用，不需要认证
+type HealthHandler struct {
+	checks map[string]repository.Pinger
+}
+
+// NewHealthHandler 创建健康检查处理器实例
+// checks 是就绪检查要 ping 的存储，key 是展示用的名字（如 "users"）
+func NewHealthHandler(checks map[string]repository.Pinger) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Register 注册路由
+// - GET /healthz: 存活检查，进程能处理请求就返回 200，不访问数据库
+// - GET /readyz: 就绪检查，所有存储都能 ping 通才返回 200，否则返回 503
+// 和运维指标接口一样挂在根路径下
+func (h *HealthHandler) Register(r gin.IRoutes) {
+	r.GET("/healthz", h.healthz)
+	r.GET("/readyz", h.readyz)
+}
+
+// healthz 存活检查
+// GET /healthz
+// 不检查数据库：数据库故障时重启本服务也没用，这种情况应该由 /readyz 把流量摘掉
+func (h *HealthHandler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz 就绪检查
+// GET /readyz
+// 响应里只写每个存储是否可用，具体错误只打日志，这个接口不需要认证，不能泄露数据库信息
+func (h *HealthHandler) readyz(c *gin.Context) {
+	status := http.StatusOK
+	results := make(gin.H, len(h.checks))
+	for name, p := range h.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+		err := p.Ping(ctx)
+		cancel()
+		if err != nil {
+			logging.FromContext(ctx).Error("readyz check failed", "check", name, "err", err)
+			results[name] = "unavailable"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		results[name] = "ok"
+	}
+
+	overall := "ok"
+	if status != http.StatusOK {
+		overall = "unavailable"
+	}
+	c.JSON(status, gin.H{"status": overall, "checks": results})
+}
@@ -0,0 +1,34 @@
+// Package http 登录令牌公钥（JWKS）处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/jwtkeys"
+	"net/http"
+)
+
+// JWKSHandler 公开验证登录令牌用的公钥，其它服务用它验证我们签发的访问令牌
+type JWKSHandler struct {
+	keys *jwtkeys.Set
+}
+
+// NewJWKSHandler 创建 JWKS 处理器实例
+func NewJWKSHandler(keys *jwtkeys.Set) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Register 注册路由
+// - GET /.well-known/jwks.json: 公钥列表（RFC 7517）
+// 按惯例挂在根路径下；handlers 是限流中间件
+func (h *JWKSHandler) Register(r gin.IRoutes, handlers ...gin.HandlerFunc) {
+	r.GET("/.well-known/jwks.json", append(handlers, h.jwks)...)
+}
+
+// jwks 公钥列表
+// GET /.well-known/jwks.json
+// 响应不包在 {"data": ...} 里：各种 JWT 库直接按 RFC 7517 的格式读取
+// 只用 HS256 签名时列表为空，共享密钥不能公开；允许缓存 5 分钟，换私钥时新旧公钥要一起配置一段时间
+func (h *JWKSHandler) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,107 @@
+// Package http 列表处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// ListHandler 列表处理器
+// 列表是看板的子资源，所有路由都挂在 /boards/:id/lists 下面
+type ListHandler struct {
+	svc service.ListService
+}
+
+// NewListHandler 创建列表处理器实例
+func NewListHandler(svc service.ListService) *ListHandler {
+	return &ListHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET    /boards/:id/lists: 列出看板的所有列表
+// - POST   /boards/:id/lists: 创建列表
+// - PUT    /boards/:id/lists/:listId: 重命名列表
+// - PUT    /boards/:id/lists/:listId/position: 移动列表
+// - DELETE /boards/:id/lists/:listId: 删除列表
+func (h *ListHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/lists", h.list)
+	rg.POST("/boards/:id/lists", h.create)
+	rg.PUT("/boards/:id/lists/:listId", h.rename)
+	rg.PUT("/boards/:id/lists/:listId/position", h.move)
+	rg.DELETE("/boards/:id/lists/:listId", h.delete)
+}
+
+// list 列出看板的所有列表
+// GET /api/v1/boards/:id/lists
+func (h *ListHandler) list(c *gin.Context) {
+	items, err := h.svc.ListLists(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// create 创建列表
+// POST /api/v1/boards/:id/lists
+// 请求体：{"title": "待办"}
+func (h *ListHandler) create(c *gin.Context) {
+	var req listRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	l, err := h.svc.CreateList(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Title)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": l})
+}
+
+// rename 重命名列表
+// PUT /api/v1/boards/:id/lists/:listId
+// 请求体：{"title": "进行中"}
+func (h *ListHandler) rename(c *gin.Context) {
+	var req listRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	l, err := h.svc.RenameList(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), req.Title)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": l})
+}
+
+// move 移动列表到指定位置
+// PUT /api/v1/boards/:id/lists/:listId/position
+// 请求体：{"position": 0}，位置从 0 开始
+// 返回移动后看板的全部列表，客户端可以直接用它刷新界面
+func (h *ListHandler) move(c *gin.Context) {
+	var req positionRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	items, err := h.svc.MoveList(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId"), *req.Position)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// delete 删除列表
+// DELETE /api/v1/boards/:id/lists/:listId
+func (h *ListHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteList(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("listId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
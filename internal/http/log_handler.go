@@ -0,0 +1,70 @@
+// Package http 运行时日志设置处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"log/slog"
+	"net/http"
+)
+
+// LogHandler 运行时日志设置处理器
+// 排查线上问题时临时调高日志级别、打开 SQL 或请求体日志，查完再关掉，不需要重启服务
+// 路由组上必须挂 AuthRequired 和 RequireRole("admin")
+type LogHandler struct{}
+
+// NewLogHandler 创建日志设置处理器实例
+func NewLogHandler() *LogHandler {
+	return &LogHandler{}
+}
+
+// Register 注册路由
+// - GET /admin/log-level: 查看当前设置
+// - PUT /admin/log-level: 修改设置
+func (h *LogHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/log-level", h.get)
+	rg.PUT("/admin/log-level", h.update)
+}
+
+// get 查看当前日志设置
+// GET /api/v1/admin/log-level
+func (h *LogHandler) get(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": logging.Current()})
+}
+
+// update 修改日志设置
+// PUT /api/v1/admin/log-level
+// 请求体：{"level": "debug", "verboseSql": true, "requestBodies": false}
+// 三个字段都是可选的，没传的保持不变，所以用指针区分"没传"和"传了零值"
+func (h *LogHandler) update(c *gin.Context) {
+	var req logSettingsRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	var level slog.Level
+	if req.Level != nil {
+		if err := level.UnmarshalText([]byte(*req.Level)); err != nil {
+			httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "invalid level (want debug, info, warn or error)")
+			return
+		}
+	}
+
+	s := logging.Update(func(s *logging.Settings) {
+		if req.Level != nil {
+			s.Level = level
+		}
+		if req.VerboseSQL != nil {
+			s.VerboseSQL = *req.VerboseSQL
+		}
+		if req.RequestBodies != nil {
+			s.RequestBodies = *req.RequestBodies
+		}
+	})
+
+	// 按 warn 级别记录，调到 error 级别之前的最后一次修改也能留在日志里，事后知道是谁改的
+	logging.FromContext(c.Request.Context()).Warn("log settings changed",
+		"level", s.Level.String(), "verbose_sql", s.VerboseSQL, "request_bodies", s.RequestBodies)
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
@@ -0,0 +1,69 @@
+// Package http 看板成员处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// MemberHandler 看板成员处理器
+// 路由注册在需要认证的路由组下
+type MemberHandler struct {
+	svc service.MemberService
+}
+
+// NewMemberHandler 创建成员处理器实例
+func NewMemberHandler(svc service.MemberService) *MemberHandler {
+	return &MemberHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET    /boards/:id/members: 列出成员（任何成员都可以查看）
+// - POST   /boards/:id/members: 邀请成员或修改角色（仅所有者）
+// - DELETE /boards/:id/members/:userId: 移除成员（所有者），或者自己退出看板
+func (h *MemberHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/members", h.list)
+	rg.POST("/boards/:id/members", h.add)
+	rg.DELETE("/boards/:id/members/:userId", h.remove)
+}
+
+// list 列出看板成员
+// GET /api/v1/boards/:id/members
+func (h *MemberHandler) list(c *gin.Context) {
+	members, err := h.svc.ListMembers(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// add 邀请成员
+// POST /api/v1/boards/:id/members
+// 请求体：{"email": "friend@example.com", "role": "editor"}
+// 被邀请的用户必须已经注册；已经是成员时修改其角色
+func (h *MemberHandler) add(c *gin.Context) {
+	var req memberRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	m, err := h.svc.AddMember(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Email, req.Role)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": m})
+}
+
+// remove 移除成员
+// DELETE /api/v1/boards/:id/members/:userId
+func (h *MemberHandler) remove(c *gin.Context) {
+	if err := h.svc.RemoveMember(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("userId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+// Package http 运维指标处理器
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/repository"
+	"net/http"
+	"strings"
+)
+
+// PrometheusWriter 往 /metrics 输出额外指标的来源，例如健康探测和 SLO
+type PrometheusWriter interface {
+	WritePrometheus(w io.Writer)
+}
+
+// MetricsHandler 运维指标处理器
+// 对外暴露仓储层的调用统计和数据库连接池状态
+type MetricsHandler struct {
+	metrics *repository.QueryMetrics
+
+	// extra 在仓储指标后面依次输出的其他指标
+	extra []PrometheusWriter
+
+	// token /metrics 的访问令牌（METRICS_TOKEN），为空时不提供 /metrics
+	token string
+}
+
+// NewMetricsHandler 创建指标处理器实例
+func NewMetricsHandler(metrics *repository.QueryMetrics, token string, extra ...PrometheusWriter) *MetricsHandler {
+	return &MetricsHandler{metrics: metrics, token: token, extra: extra}
+}
+
+// Register 注册 Prometheus 抓取接口
+// - GET /metrics: Prometheus 文本格式，不挂在 /api/v1 下面
+// Prometheus 没法走用户登录，所以用单独的访问令牌（Authorization: Bearer <METRICS_TOKEN>）；
+// 没有配置令牌时不注册这个接口，避免把仓储调用统计和连接池状态暴露给任何人
+func (h *MetricsHandler) Register(r gin.IRoutes) bool {
+	if h.token == "" {
+		return false
+	}
+	r.GET("/metrics", h.requireToken, h.prometheus)
+	return true
+}
+
+// RegisterAdmin 注册管理员接口，rg 需要已经挂上管理员角色检查
+// - GET /admin/db-stats: JSON 格式，方便人直接查看
+func (h *MetricsHandler) RegisterAdmin(rg *gin.RouterGroup) {
+	rg.GET("/admin/db-stats", h.dbStats)
+}
+
+// requireToken 检查抓取请求带着 METRICS_TOKEN
+// 用 subtle.ConstantTimeCompare 比较，避免按响应时间逐字节猜出令牌
+func (h *MetricsHandler) requireToken(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		httpx.Abort(c, http.StatusUnauthorized, httpx.CodeUnauthorized, "invalid metrics token")
+		return
+	}
+	c.Next()
+}
+
+// prometheus 输出 Prometheus 文本格式的指标
+// GET /metrics
+func (h *MetricsHandler) prometheus(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	h.metrics.WritePrometheus(c.Writer)
+	for _, w := range h.extra {
+		w.WritePrometheus(c.Writer)
+	}
+}
+
+// dbStats 输出仓储方法统计和连接池状态
+// GET /api/v1/admin/db-stats
+// methods 按总耗时从高到低排序，排在前面的就是最热或最慢的数据访问
+func (h *MetricsHandler) dbStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"methods": h.metrics.Methods(),
+		"pools":   h.metrics.Pools(),
+	}})
+}
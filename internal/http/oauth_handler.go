@@ -0,0 +1,127 @@
+// Package http 第三方登录处理器
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/model"
+	"kanban_api/internal/service"
+	"net/http"
+	"net/url"
+)
+
+// stateCookie 保存 state 的 cookie，只在登录的两个请求之间存在
+const stateCookie = "oauth_state"
+
+// stateMaxAge state cookie 的有效期（秒），用户在提供方的授权页上停留太久就要重新开始
+const stateMaxAge = 10 * 60
+
+// OAuthHandler 第三方登录处理器
+// 两个接口都是浏览器直接访问的（页面跳转），不是前端用 fetch 调用的
+type OAuthHandler struct {
+	svc service.OAuthService
+
+	// successURL 登录完成后浏览器跳转到的前端页面，令牌放在 URL 的 # 后面
+	// 为空时回调直接返回和密码登录一样的 JSON
+	successURL string
+
+	// secure state cookie 是否只通过 HTTPS 发送，回调地址是 https 时打开
+	secure bool
+}
+
+// NewOAuthHandler 创建第三方登录处理器实例
+func NewOAuthHandler(svc service.OAuthService, successURL string, secure bool) *OAuthHandler {
+	return &OAuthHandler{svc: svc, successURL: successURL, secure: secure}
+}
+
+// RegisterRoutes 注册路由
+// 第三方登录当然是在登录之前，放在公共路由组
+// credential 和 AuthHandler.RegisterRoutes 一样是更严格的限流，挂在收凭证（授权码）的回调上
+func (h *OAuthHandler) RegisterRoutes(rg *gin.RouterGroup, credential ...gin.HandlerFunc) {
+	rg.GET("/auth/oauth", h.providers)
+	rg.GET("/auth/oauth/:provider", h.start)
+	rg.GET("/auth/oauth/:provider/callback", append(credential, h.callback)...)
+}
+
+// providers 列出启用了的提供方，前端据此决定显示哪些登录按钮
+// HTTP 方法：GET
+// 路径：/api/v1/auth/oauth
+func (h *OAuthHandler) providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.svc.Providers()})
+}
+
+// start 跳转到提供方的授权页
+// HTTP 方法：GET
+// 路径：/api/v1/auth/oauth/:provider
+// state 同时放进 URL 和 cookie：回调时两个对不上说明回调不是这个浏览器发起的登录（登录 CSRF）
+func (h *OAuthHandler) start(c *gin.Context) {
+	authURL, state, err := h.svc.Start(c.Param("provider"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	// 提供方跳回来是跨站的顶层 GET 请求，SameSite=Lax 的 cookie 会带上
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(stateCookie, state, stateMaxAge, "/api/v1/auth/oauth", "", h.secure, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// callback 提供方跳回来的回调
+// HTTP 方法：GET
+// 路径：/api/v1/auth/oauth/:provider/callback?code=...&state=...
+// 配置了 successURL 时跳转到 successURL#token=...&refreshToken=...，失败时跳转到 successURL#error=错误码；
+// 否则和登录接口一样返回 JSON
+func (h *OAuthHandler) callback(c *gin.Context) {
+	// state 用过一次就删掉
+	cookie, _ := c.Cookie(stateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(stateCookie, "", -1, "/api/v1/auth/oauth", "", h.secure, true)
+
+	state := c.Query("state")
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		h.fail(c, http.StatusBadRequest, httpx.CodeInvalidInput, "invalid oauth state")
+		return
+	}
+	// 用户在授权页上点了拒绝
+	if e := c.Query("error"); e != "" {
+		h.fail(c, http.StatusUnauthorized, httpx.CodeUnauthorized, "oauth login cancelled")
+		return
+	}
+
+	ctx := service.WithClientIP(c.Request.Context(), c.ClientIP())
+	u, tokens, err := h.svc.Login(ctx, c.Param("provider"), c.Query("code"))
+	if err != nil {
+		status, code, message := httpx.Classify(err)
+		if status >= http.StatusInternalServerError {
+			// 内部错误照常走 ServiceError，错误日志里有原因
+			httpx.ServiceError(c, err)
+			return
+		}
+		h.fail(c, status, code, message)
+		return
+	}
+
+	if h.successURL != "" {
+		// 令牌放在 # 后面：浏览器不会把它发给前端页面所在的服务器，也不会出现在 Referer 里
+		fragment := url.Values{"token": {tokens.AccessToken}, "refreshToken": {tokens.RefreshToken}}
+		c.Redirect(http.StatusFound, h.successURL+"#"+fragment.Encode())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"user":         gin.H{"id": u.ID, "email": u.Email, "createdAt": model.Timestamp(u.CreatedAt)},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+// fail 回调失败：配置了 successURL 时带着错误码跳回前端，否则返回 JSON 错误
+func (h *OAuthHandler) fail(c *gin.Context, status int, code, message string) {
+	if h.successURL != "" {
+		c.Redirect(http.StatusFound, h.successURL+"#"+url.Values{"error": {code}}.Encode())
+		return
+	}
+	httpx.Abort(c, status, code, message)
+}
@@ -0,0 +1,147 @@
+// Package http 运维接口处理器（/admin/v1）
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+// OperatorHandler 运维接口处理器，给运维人员和后台工具用
+// 和 AdminHandler 一样，路由组上必须挂 AuthRequired 和 RequireRole("admin")，处理器本身不再检查角色
+type OperatorHandler struct {
+	svc    service.AdminService
+	boards service.BoardService
+}
+
+// NewOperatorHandler 创建运维接口处理器实例
+func NewOperatorHandler(svc service.AdminService, boards service.BoardService) *OperatorHandler {
+	return &OperatorHandler{svc: svc, boards: boards}
+}
+
+// Register 注册路由，rg 是 /admin/v1 路由组
+// - GET    /users?email=&disabled=&limit=&offset=: 查找用户
+// - POST   /users/:userId/disable: 停用用户
+// - POST   /users/:userId/enable: 恢复用户
+// - GET    /boards?ownerId=&title=&limit=&offset=: 查找所有用户的看板
+// - GET    /boards/:id: 任意看板的概况
+// - DELETE /boards/:id: 删除任意看板
+// - GET    /stats?days=30: 用户数、看板数、每天的注册数
+func (h *OperatorHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/users", h.listUsers)
+	rg.POST("/users/:userId/disable", h.disableUser)
+	rg.POST("/users/:userId/enable", h.enableUser)
+	rg.GET("/boards", h.listBoards)
+	rg.GET("/boards/:id", h.getBoard)
+	rg.DELETE("/boards/:id", h.deleteBoard)
+	rg.GET("/stats", h.stats)
+}
+
+// listUsers 查找用户
+// GET /admin/v1/users?email=alice&disabled=true&limit=50&offset=0
+func (h *OperatorHandler) listUsers(c *gin.Context) {
+	q := httpx.Query(c)
+	in := service.UserQuery{
+		Email:  q.String("email", ""),
+		Limit:  q.Int("limit", 0, 1, 100),
+		Offset: q.Int("offset", 0, 0, 1<<30),
+	}
+	// 没传 disabled 时停用和没停用的都列出来
+	if c.Query("disabled") != "" {
+		disabled := q.Bool("disabled", false)
+		in.Disabled = &disabled
+	}
+	if !q.Valid() {
+		return
+	}
+
+	page, err := h.svc.SearchUsers(c.Request.Context(), in)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
+
+// disableUser 停用用户
+// POST /admin/v1/users/:userId/disable
+func (h *OperatorHandler) disableUser(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// enableUser 恢复用户
+// POST /admin/v1/users/:userId/enable
+func (h *OperatorHandler) enableUser(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *OperatorHandler) setDisabled(c *gin.Context, disabled bool) {
+	u, err := h.svc.SetUserDisabled(c.Request.Context(), c.GetString("userID"), c.Param("userId"), disabled)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": u})
+}
+
+// listBoards 查找看板
+// GET /admin/v1/boards?ownerId=<用户 ID>&title=roadmap&limit=50&offset=0
+func (h *OperatorHandler) listBoards(c *gin.Context) {
+	q := httpx.Query(c)
+	in := service.BoardQuery{
+		OwnerID: q.String("ownerId", ""),
+		Title:   q.String("title", ""),
+		Limit:   q.Int("limit", 0, 1, 100),
+		Offset:  q.Int("offset", 0, 0, 1<<30),
+	}
+	if !q.Valid() {
+		return
+	}
+
+	page, err := h.svc.SearchBoards(c.Request.Context(), in)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
+
+// getBoard 查看任意看板的概况
+// GET /admin/v1/boards/:id
+func (h *OperatorHandler) getBoard(c *gin.Context) {
+	ov, err := h.svc.GetBoard(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ov})
+}
+
+// deleteBoard 删除任意看板，和 DELETE /api/v1/admin/boards/:id 相同
+// DELETE /admin/v1/boards/:id
+func (h *OperatorHandler) deleteBoard(c *gin.Context) {
+	if err := h.boards.AdminDeleteBoard(c.Request.Context(), c.Param("id")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// stats 汇总统计
+// GET /admin/v1/stats?days=30
+func (h *OperatorHandler) stats(c *gin.Context) {
+	q := httpx.Query(c)
+	days := q.Int("days", 0, 1, 366)
+	if !q.Valid() {
+		return
+	}
+
+	st, err := h.svc.Stats(c.Request.Context(), days, time.Now())
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": st})
+}
@@ -0,0 +1,69 @@
+// Package http 密码重置处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// PasswordHandler 密码重置处理器
+// 处理忘记密码、重置密码的 HTTP 请求
+type PasswordHandler struct {
+	svc service.PasswordService
+}
+
+// NewPasswordHandler 创建密码重置处理器实例
+func NewPasswordHandler(svc service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{svc: svc}
+}
+
+// RegisterRoutes 注册路由
+// 忘记密码时用户当然没有登录，所以这两个接口放在公共路由组
+// credential 和 AuthHandler.RegisterRoutes 一样是更严格的限流：忘记密码会发邮件，重置密码收的是重置令牌，
+// 只有按 IP 的普通限流时可以拿它轰炸别人的邮箱、猜令牌
+func (h *PasswordHandler) RegisterRoutes(rg *gin.RouterGroup, credential ...gin.HandlerFunc) {
+	rg.POST("/auth/forgot-password", append(credential, h.forgot)...)
+	rg.POST("/auth/reset-password", append(credential, h.reset)...)
+}
+
+// forgot 申请重置密码
+// HTTP 方法：POST
+// 路径：/api/v1/auth/forgot-password
+// 请求体：{"email": "..."}
+// 不管邮箱有没有注册都返回 202，客户端统一提示"如果邮箱已注册，你会收到一封邮件"
+func (h *PasswordHandler) forgot(c *gin.Context) {
+	var req forgotPasswordRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.svc.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	// http.StatusAccepted = 202（已接受），邮件在后台发送
+	c.JSON(http.StatusAccepted, gin.H{"data": gin.H{
+		"message": "if the email is registered, a reset link has been sent",
+	}})
+}
+
+// reset 凭重置令牌设置新密码
+// HTTP 方法：POST
+// 路径：/api/v1/auth/reset-password
+// 请求体：{"token": "...", "password": "..."}
+// 成功返回 204；令牌无效、过期或已使用返回 400
+func (h *PasswordHandler) reset(c *gin.Context) {
+	var req resetPasswordRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	if err := h.svc.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+// Package http 声明式看板配置处理器
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+	"io"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"strings"
+)
+
+// maxProvisionBody 配置请求体的大小上限（1 MiB）
+const maxProvisionBody = 1 << 20
+
+// ProvisionHandler 声明式看板配置处理器
+type ProvisionHandler struct {
+	svc service.ProvisionService
+}
+
+// NewProvisionHandler 创建声明式看板配置处理器实例
+func NewProvisionHandler(svc service.ProvisionService) *ProvisionHandler {
+	return &ProvisionHandler{svc: svc}
+}
+
+// Register 注册路由
+// - POST /provision?mode=plan|apply&prune=true: 按声明的看板、列表、成员计算变更（plan）或者执行（apply）
+func (h *ProvisionHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/provision", h.provision)
+}
+
+// provision 声明式看板配置
+// POST /api/v1/provision?mode=plan
+// 请求体是 service.ProvisionSpec，Content-Type 为 application/yaml（或 text/yaml）时按 YAML 解析，否则按 JSON
+// mode 默认 plan，只返回变更不修改数据，确认无误后用 mode=apply 执行
+func (h *ProvisionHandler) provision(c *gin.Context) {
+	q := httpx.Query(c)
+	mode := q.String("mode", "plan")
+	prune := q.Bool("prune", false)
+	if !q.Valid() {
+		return
+	}
+	if mode != "plan" && mode != "apply" {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "mode must be plan or apply")
+		return
+	}
+
+	spec, err := decodeSpec(c)
+	if err != nil {
+		httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "invalid spec: "+err.Error())
+		return
+	}
+
+	res, err := h.svc.Provision(c.Request.Context(), c.GetString("userID"), spec, service.ProvisionOptions{
+		Apply: mode == "apply",
+		Prune: prune,
+	})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+// decodeSpec 解析配置请求体
+// 配置有嵌套的对象，httpx.BindJSON 只处理一层，这里直接用标准库解析，同样拒绝不认识的字段
+// YAML 先转成 JSON 再解析，两种格式的规则完全一样
+func decodeSpec(c *gin.Context) (service.ProvisionSpec, error) {
+	var spec service.ProvisionSpec
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxProvisionBody))
+	if err != nil {
+		return spec, errors.New("body too large")
+	}
+
+	switch c.ContentType() {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		if body, err = yaml.YAMLToJSON(body); err != nil {
+			// YAML 的错误带着多行的源码片段，只取第一行
+			msg, _, _ := strings.Cut(err.Error(), "\n")
+			return spec, errors.New(msg)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if dec.More() {
+		return spec, errors.New("unexpected data after spec")
+	}
+	return spec, nil
+}
@@ -0,0 +1,270 @@
+// Package http 请求体结构（DTO）
+//
+// 每个接口的请求体都在这里定义成一个结构体，binding 标签声明校验规则，
+// 处理器用 httpx.BindJSON 解析：格式和长度这类"看一眼就知道不对"的问题在这一层拦下，
+// 返回按字段列出的错误；需要查数据库的业务规则（邮箱是否已注册、角色够不够）仍然由 Service 层检查
+//
+// 解析是严格的（见 httpx/decode.go）：请求体里出现结构体没有的字段直接返回 400，
+// 所以客户端要传的每个字段都必须在这里声明，哪怕处理器暂时用不到
+//
+// 常用的 binding 标签（go-playground/validator）：
+// - required: 必填；字符串不能是空串，指针不能是 nil（所以 0 这种合法的零值要用指针）
+// - email: 邮箱格式
+// - min / max: 字符串是字符数，数字是取值范围
+// - oneof: 只能是列出的几个值之一
+// - omitempty: 没传（或零值）时跳过后面的规则
+//
+// 长度限制（标签里只能写字面量，不能引用常量）：
+// - 邮箱 191：和 user_rows.email 列的长度一致（MySQL 上带长度的 varchar 才能建唯一索引）
+// - 密码 8-72：bcrypt 只使用前 72 个字节，更长的部分会被忽略，所以直接拒绝
+// - 标题 200、slug 100、卡片描述 10000
+package http
+
+import "kanban_api/internal/httpx"
+
+// registerRequest 注册
+// 密码的长度在这里检查；登录时不检查，否则改规则之前注册的老用户就登录不了了
+type registerRequest struct {
+	Email    string `json:"email" binding:"required,email,max=191"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// loginRequest 登录
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// refreshTokenRequest 刷新令牌、登出
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// updateMeRequest 修改当前用户资料
+type updateMeRequest struct {
+	Email string `json:"email" binding:"required,email,max=191"`
+
+	// CurrentPassword 修改邮箱要验证当前密码
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+}
+
+// deleteAccountRequest 注销账号
+// transferBoards 为 true 时宽限期过后把看板转给编辑者，否则删除
+type deleteAccountRequest struct {
+	Password       string `json:"password" binding:"required"`
+	TransferBoards bool   `json:"transferBoards"`
+}
+
+// changePasswordRequest 修改密码
+// 新密码还要包含字母和数字，这条规则由 Service 层检查（重置密码也要用）
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=72"`
+}
+
+// forgotPasswordRequest 忘记密码
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// resetPasswordRequest 凭重置令牌设置新密码
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+// boardRequest 创建看板
+// slug 不使用，创建时由标题生成；保留这个字段是为了兼容和更新请求共用同一个请求体的客户端
+// lists 是和看板一起创建的列表标题；没传 lists 时 defaultLists 为 true 创建服务端配置的默认列表
+// sample 为 true 时在列表里生成示例卡片，之后可以用 DELETE /boards/:id/sample 一次清除
+type boardRequest struct {
+	Title        string   `json:"title" binding:"required,max=200"`
+	Slug         string   `json:"slug" binding:"omitempty,max=100"`
+	Lists        []string `json:"lists" binding:"max=20,dive,required,max=200"`
+	DefaultLists bool     `json:"defaultLists"`
+	Sample       bool     `json:"sample"`
+}
+
+// updateBoardRequest 更新看板
+// slug 可选；Version 是读到的看板版本，没带 If-Match 请求头时必填
+type updateBoardRequest struct {
+	Title   string `json:"title" binding:"required,max=200"`
+	Slug    string `json:"slug" binding:"omitempty,max=100"`
+	Version int64  `json:"version" binding:"omitempty,min=1"`
+}
+
+// boardPatchRequest 部分更新看板，只修改出现的字段
+// 使用指针字段区分没传和传了空串：slug 传 "" 表示按标题重新生成；title 传 "" 是错误
+type boardPatchRequest struct {
+	Title   *string `json:"title" binding:"omitempty,max=200"`
+	Slug    *string `json:"slug" binding:"omitempty,max=100"`
+	Version int64   `json:"version" binding:"omitempty,min=1"`
+}
+
+// listRequest 创建、重命名列表
+type listRequest struct {
+	Title string `json:"title" binding:"required,max=200"`
+}
+
+// positionRequest 移动列表、检查清单和清单条目
+type positionRequest struct {
+	// 使用指针区分"没传"和"传了 0"，0 是合法的位置
+	Position *int `json:"position" binding:"required,min=0"`
+}
+
+// cardRequest 创建和整体替换卡片的请求体
+// dueDate 可以是 RFC3339 时间（"2024-01-31T18:00:00+08:00"），也可以只有日期（"2024-01-31"），
+// 接受的全部格式见 httpx/time.go；没有时区的按 UTC 理解
+// reminder 为 true 时截止日期快到会发提醒，默认不提醒
+// labels 是标签数组，去空白、去重、数量和长度由 Service 层检查
+type cardRequest struct {
+	Title       string      `json:"title" binding:"required,max=200"`
+	Description string      `json:"description" binding:"max=10000"`
+	DueDate     *httpx.Time `json:"dueDate"`
+	Reminder    bool        `json:"reminder"`
+	Labels      []string    `json:"labels"`
+}
+
+// templatedCardRequest 从模板创建卡片（POST .../cards?template=:tid）
+// 和 cardRequest 一样，只是 title 可以不传：模板的标题里没有 {{title}} 时用不到
+type templatedCardRequest struct {
+	Title       string      `json:"title" binding:"max=200"`
+	Description string      `json:"description" binding:"max=10000"`
+	DueDate     *httpx.Time `json:"dueDate"`
+	Reminder    bool        `json:"reminder"`
+	Labels      []string    `json:"labels"`
+}
+
+// cardPatchRequest 部分更新卡片
+// 使用指针字段：JSON 中没有出现的字段保持为 nil
+// dueDate 可以传 null 清空截止日期，所以用 httpx.NullTime 区分没传和 null
+type cardPatchRequest struct {
+	Title       *string        `json:"title" binding:"omitempty,max=200"`
+	Description *string        `json:"description" binding:"omitempty,max=10000"`
+	DueDate     httpx.NullTime `json:"dueDate"`
+	Reminder    *bool          `json:"reminder"`
+	Labels      *[]string      `json:"labels"`
+}
+
+// cardTemplateRequest 创建和整体替换卡片模板
+// checklist 不传或者传 null 表示模板不带清单；标签和清单条目的规则由 Service 层检查
+type cardTemplateRequest struct {
+	Name        string                    `json:"name" binding:"required,max=100"`
+	Title       string                    `json:"title" binding:"max=200"`
+	Description string                    `json:"description" binding:"max=10000"`
+	Labels      []string                  `json:"labels"`
+	Checklist   *templateChecklistRequest `json:"checklist"`
+}
+
+// templateChecklistRequest 卡片模板里的检查清单，items 是条目内容，最多 50 条
+type templateChecklistRequest struct {
+	Title string   `json:"title" binding:"required,max=200"`
+	Items []string `json:"items" binding:"max=50,dive,required,max=500"`
+}
+
+// checklistRequest 创建、重命名检查清单
+type checklistRequest struct {
+	Title string `json:"title" binding:"required,max=200"`
+}
+
+// checklistItemRequest 添加清单条目
+type checklistItemRequest struct {
+	Text string `json:"text" binding:"required,max=500"`
+}
+
+// checklistItemPatchRequest 修改清单条目，只修改出现的字段
+// 勾选：{"done": true}
+type checklistItemPatchRequest struct {
+	Text *string `json:"text" binding:"omitempty,max=500"`
+	Done *bool   `json:"done"`
+}
+
+// uploadStartRequest 开始分块上传附件
+// 大小上限和文件名由 Service 层检查，超过上限返回 413
+type uploadStartRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"contentType" binding:"max=255"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+}
+
+// moveCardRequest 移动卡片
+type moveCardRequest struct {
+	BoardID string `json:"boardId"`
+	ListID  string `json:"listId"`
+	// 使用指针区分"没传"和"传了 0"，0 是合法的位置
+	Position *int `json:"position" binding:"required,min=0"`
+}
+
+// mirrorCardRequest 在另一个看板上创建卡片的镜像
+type mirrorCardRequest struct {
+	BoardID string `json:"boardId" binding:"required"`
+	ListID  string `json:"listId" binding:"required"`
+}
+
+// memberRequest 邀请成员
+type memberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+// embedTokenRequest 签发嵌入令牌
+// ttlMinutes 不传（0）时使用默认有效期，上限由 Service 层检查
+type embedTokenRequest struct {
+	TTLMinutes int `json:"ttlMinutes" binding:"min=0"`
+}
+
+// apiKeyRequest 创建个人 API 密钥
+// expiresInDays 不传（0）时密钥不过期
+type apiKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,max=2,dive,oneof=read write"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"min=0,max=365"`
+}
+
+// incidentRequest 管理员发布状态页事故公告
+// severity 不传时是 minor
+type incidentRequest struct {
+	Message  string `json:"message" binding:"required,max=500"`
+	Severity string `json:"severity" binding:"omitempty,oneof=minor major maintenance"`
+}
+
+// setRoleRequest 管理员修改用户角色
+type setRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// setBoardStateRequest 管理员修改看板状态
+type setBoardStateRequest struct {
+	State  string `json:"state" binding:"required,oneof=active read_only suspended"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// logSettingsRequest 修改运行时日志设置，三个字段都可选
+type logSettingsRequest struct {
+	Level         *string `json:"level"`
+	VerboseSQL    *bool   `json:"verboseSql"`
+	RequestBodies *bool   `json:"requestBodies"`
+}
+
+// captureRequest 开始抓包
+// durationMinutes 不传（0）时抓 15 分钟，最长一天
+type captureRequest struct {
+	UserID          string `json:"userId"`
+	Route           string `json:"route"`
+	DurationMinutes int    `json:"durationMinutes" binding:"min=0,max=1440"`
+}
+
+// wsClientMessage WebSocket 客户端发来的消息
+// type 是 subscribe 或 unsubscribe
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	BoardID string `json:"boardId"`
+}
+
+// webhookRequest 登记 webhook
+// url 的格式（只能是 http/https、不能带用户名密码）和事件类型由 Service 层检查
+// events 不传或为空表示接收所有事件
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required,max=2000"`
+	Events []string `json:"events" binding:"max=20"`
+}
@@ -0,0 +1,43 @@
+// Package http 分享链接解析处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// ResolveHandler 分享链接解析处理器
+type ResolveHandler struct {
+	svc service.ResolveService
+}
+
+// NewResolveHandler 创建分享链接解析处理器实例
+func NewResolveHandler(svc service.ResolveService) *ResolveHandler {
+	return &ResolveHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET /resolve?url=...: 把用户粘贴的看板、卡片链接解析成对象类型、ID 和当前用户的角色
+func (h *ResolveHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/resolve", h.resolve)
+}
+
+// resolve 解析分享链接
+// GET /api/v1/resolve?url=https://kanban.example.com/b/project-alpha/cards/<cardId>
+// url 参数里的 ? 和 & 需要编码，客户端用 encodeURIComponent 处理
+func (h *ResolveHandler) resolve(c *gin.Context) {
+	q := httpx.Query(c)
+	rawURL := q.String("url", "")
+	if !q.Valid() {
+		return
+	}
+
+	link, err := h.svc.Resolve(c.Request.Context(), c.GetString("userID"), rawURL)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": link})
+}
@@ -0,0 +1,43 @@
+// Package http 搜索处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// SearchHandler 全文搜索处理器
+type SearchHandler struct {
+	svc service.SearchService
+}
+
+// NewSearchHandler 创建搜索处理器实例
+func NewSearchHandler(svc service.SearchService) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET /search?q=关键词&limit=20: 搜索看板和卡片
+func (h *SearchHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/search", h.search)
+}
+
+// search 搜索
+// GET /api/v1/search?q=release notes&limit=20
+func (h *SearchHandler) search(c *gin.Context) {
+	// limit 不传时为 0，由服务层使用默认值
+	q := httpx.Query(c)
+	limit := q.Int("limit", 0, 1, 50)
+	if !q.Valid() {
+		return
+	}
+
+	results, err := h.svc.Search(c.Request.Context(), c.GetString("userID"), c.Query("q"), limit)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
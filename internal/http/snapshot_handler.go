@@ -0,0 +1,45 @@
+// Package http 看板每日快照处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+// SnapshotHandler 看板每日快照处理器，给趋势图用
+type SnapshotHandler struct {
+	svc service.SnapshotService
+}
+
+// NewSnapshotHandler 创建每日快照处理器实例
+func NewSnapshotHandler(svc service.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{svc: svc}
+}
+
+// Register 注册路由
+// - GET /boards/:id/snapshots?from=2026-01-01&to=2026-03-31: 看板每天的快照，按日期从早到晚
+func (h *SnapshotHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/snapshots", h.list)
+}
+
+// list 列出看板的每日快照
+// GET /api/v1/boards/:id/snapshots?from=YYYY-MM-DD&to=YYYY-MM-DD
+// 都不传时是最近 90 天；范围检查由服务层负责
+func (h *SnapshotHandler) list(c *gin.Context) {
+	q := httpx.Query(c)
+	from := q.Date("from", time.Time{})
+	to := q.Date("to", time.Time{})
+	if !q.Valid() {
+		return
+	}
+
+	snapshots, err := h.svc.ListSnapshots(c.Request.Context(), c.GetString("userID"), c.Param("id"), from, to)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": snapshots})
+}
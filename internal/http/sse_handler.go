@@ -0,0 +1,124 @@
+// Package http 看板变更事件流（Server-Sent Events）处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/events"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// sseHeartbeat 多久发一次心跳注释
+	// 没有事件时连接上什么都不传，代理和负载均衡会把空闲的连接断掉（常见的超时是 60 秒）
+	sseHeartbeat = 15 * time.Second
+
+	// sseRetry 建议浏览器断线后多久重连，单位毫秒
+	sseRetry = 3000
+
+	// sseEventBuffer 每个连接的事件缓冲区，写满说明客户端太慢，连接会被断开
+	sseEventBuffer = 64
+)
+
+// EventStreamHandler 看板变更事件流处理器
+// 和 WebSocket（见 ws_handler.go）推送同样的事件，来自同一个事件总线，
+// 给不能用 WebSocket 的客户端（只需要接收、不需要发送，或者中间的代理不支持 WebSocket）使用
+// 一个连接只接收一个看板的事件，浏览器直接用 EventSource 就能接收，断线后会自动重连
+//
+// 路由组上必须挂 AuthRequired；EventSource 不能加请求头，令牌可以用 ?token= 传（见 middleware.TokenFromQuery）
+type EventStreamHandler struct {
+	boards service.BoardService
+	bus    *events.Bus
+}
+
+// NewEventStreamHandler 创建事件流处理器实例
+// boards 用来在连接时检查用户能不能查看看板
+func NewEventStreamHandler(boards service.BoardService, bus *events.Bus) *EventStreamHandler {
+	return &EventStreamHandler{boards: boards, bus: bus}
+}
+
+// Register 注册路由
+// - GET /boards/:id/events: 看板变更事件流
+func (h *EventStreamHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/boards/:id/events", h.stream)
+}
+
+// stream 看板变更事件流
+// GET /api/v1/boards/:id/events
+//
+// 每个事件的 event 字段是事件类型（例如 card.created），data 字段和 WebSocket 推送的事件相同
+// 看板被删除、用户被移出看板时先发送这个事件，再发送 event: end 并结束；
+// 客户端接收太慢被断开时发送 event: lagged 并结束，客户端重连后重新拉取数据
+func (h *EventStreamHandler) stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetString("userID")
+	boardID := c.Param("id")
+
+	// 和 GET /boards/:id 一样：看不到的看板返回 404
+	// 检查在开始写事件流之前，出错时还能返回普通的 JSON 错误
+	if _, err := h.boards.GetBoard(ctx, userID, boardID); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	// 连接断开（ctx 被取消）后 Close 取消订阅，总线上不会留下这个连接
+	sub := h.bus.NewSubscriber(sseEventBuffer)
+	defer sub.Close()
+	sub.Subscribe(boardID)
+
+	h.setHeaders(c)
+	log := logging.FromContext(ctx)
+	log.Debug("event stream opened", "board_id", boardID)
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	// c.Stream 反复调用回调直到它返回 false 或者客户端断开，每次调用之后自动 Flush
+	reason := "client closed"
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+
+		case <-heartbeat.C:
+			// 冒号开头的行是注释，EventSource 会忽略，只用来保持连接
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				reason = "lagged"
+				c.SSEvent("lagged", gin.H{"message": "too slow, reconnect"})
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			if r, done := endsSubscription(e, userID); done {
+				reason = r
+				c.SSEvent("end", gin.H{"boardId": boardID, "reason": r})
+				return false
+			}
+			return true
+		}
+	})
+	log.Debug("event stream closed", "board_id", boardID, "reason", reason)
+}
+
+// setHeaders 写事件流的响应头和开头
+func (h *EventStreamHandler) setHeaders(c *gin.Context) {
+	hd := c.Writer.Header()
+	hd.Set("Content-Type", "text/event-stream")
+	hd.Set("Cache-Control", "no-cache")
+	hd.Set("Connection", "keep-alive")
+	// 让 Nginx 不要缓冲这个响应，否则事件要攒够一个缓冲区才会发给客户端
+	hd.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// 先发一行 retry 并立即 Flush：客户端马上收到响应头，知道连接已经建立
+	_, _ = io.WriteString(c.Writer, "retry: "+strconv.Itoa(sseRetry)+"\n\n")
+	c.Writer.Flush()
+}
@@ -0,0 +1,102 @@
+// Package http 公开状态页处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+// StatusHandler 公开状态页处理器
+// 状态页不需要登录，用户登录不了的时候也能看；事故公告的发布和解除是管理员接口
+type StatusHandler struct {
+	svc service.StatusService
+}
+
+// NewStatusHandler 创建状态页处理器实例
+func NewStatusHandler(svc service.StatusService) *StatusHandler {
+	return &StatusHandler{svc: svc}
+}
+
+// RegisterPublic 注册公开路由
+// - GET /status: 状态页
+// 和 /healthz 一样挂在根路径下；handlers 是限流中间件，不需要登录的接口也要按 IP 限流
+func (h *StatusHandler) RegisterPublic(r gin.IRoutes, handlers ...gin.HandlerFunc) {
+	r.GET("/status", append(handlers, h.status)...)
+}
+
+// RegisterAdmin 注册管理员路由
+// - PUT /admin/status/incident: 发布事故公告，替换正在显示的公告
+// - DELETE /admin/status/incident: 解除事故公告
+// - GET /admin/health/history: 健康探测记录
+func (h *StatusHandler) RegisterAdmin(rg *gin.RouterGroup) {
+	rg.PUT("/admin/status/incident", h.setIncident)
+	rg.DELETE("/admin/status/incident", h.resolveIncident)
+	rg.GET("/admin/health/history", h.healthHistory)
+}
+
+// status 状态页
+// GET /status
+func (h *StatusHandler) status(c *gin.Context) {
+	s, err := h.svc.Status(c.Request.Context())
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// setIncident 发布事故公告
+// PUT /api/v1/admin/status/incident
+// 请求体：{"message": "部分用户无法上传附件，正在处理", "severity": "major"}
+func (h *StatusHandler) setIncident(c *gin.Context) {
+	var req incidentRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	inc, err := h.svc.SetIncident(c.Request.Context(), c.GetString("userID"), service.IncidentInput{Message: req.Message, Severity: req.Severity})
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": inc})
+}
+
+// resolveIncident 解除事故公告，返回被解除的公告；没有公告时返回 404
+// DELETE /api/v1/admin/status/incident
+func (h *StatusHandler) resolveIncident(c *gin.Context) {
+	inc, err := h.svc.ResolveIncident(c.Request.Context())
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": inc})
+}
+
+// healthHistory 健康探测记录，从新到旧
+// GET /api/v1/admin/health/history?component=cards&from=2024-01-31T00:00:00Z&to=2024-02-01&failed=true&limit=100
+// 排查状态页上的可用率为什么掉了：哪个组件、什么时候、探测花了多久
+func (h *StatusHandler) healthHistory(c *gin.Context) {
+	// limit 不传时为 0，由服务层使用默认值
+	q := httpx.Query(c)
+	query := service.HealthHistoryQuery{
+		Component:  q.String("component", ""),
+		From:       q.Time("from", time.Time{}),
+		To:         q.Time("to", time.Time{}),
+		FailedOnly: q.Bool("failed", false),
+		Limit:      q.Int("limit", 0, 1, 1000),
+	}
+	if !q.Valid() {
+		return
+	}
+
+	checks, err := h.svc.HealthHistory(c.Request.Context(), query)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": checks})
+}
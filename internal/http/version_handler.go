@@ -0,0 +1,31 @@
+// Package http 版本信息处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/buildinfo"
+	"net/http"
+)
+
+// VersionHandler 版本信息处理器
+type VersionHandler struct {
+	info buildinfo.Info
+}
+
+// NewVersionHandler 创建版本信息处理器实例
+// 构建信息在进程运行期间不会变，创建时读一次就行
+func NewVersionHandler() *VersionHandler {
+	return &VersionHandler{info: buildinfo.Get()}
+}
+
+// Register 注册路由
+// 和健康检查一样挂在根路径下，不需要认证
+func (h *VersionHandler) Register(r gin.IRoutes) {
+	r.GET("/version", h.version)
+}
+
+// version 返回构建信息
+// GET /version
+func (h *VersionHandler) version(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.info})
+}
@@ -0,0 +1,72 @@
+// Package http webhook 处理器
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// WebhookHandler webhook 处理器
+// 只负责登记和删除，事件的投递在 webhook.Dispatcher 里异步进行
+type WebhookHandler struct {
+	svc service.WebhookService
+}
+
+// NewWebhookHandler 创建 webhook 处理器实例
+func NewWebhookHandler(svc service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// Register 注册路由
+func (h *WebhookHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/boards/:id/webhooks", h.create)
+	rg.GET("/boards/:id/webhooks", h.list)
+	rg.DELETE("/boards/:id/webhooks/:webhookId", h.delete)
+}
+
+// create 为看板登记 webhook
+// POST /api/v1/boards/:id/webhooks
+// 请求体：{"url": "https://example.com/hook", "events": ["card.created", "card.moved"]}
+func (h *WebhookHandler) create(c *gin.Context) {
+	var req webhookRequest
+	if !httpx.BindJSON(c, &req) {
+		return
+	}
+
+	w, secret, err := h.svc.CreateWebhook(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.URL, req.Events)
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": gin.H{
+			"webhook": w,
+			// 签名密钥只在这里返回一次，丢了只能删掉重新登记
+			"secret": secret,
+		},
+	})
+}
+
+// list 列出看板的 webhook（不含签名密钥）
+// GET /api/v1/boards/:id/webhooks
+func (h *WebhookHandler) list(c *gin.Context) {
+	items, err := h.svc.ListWebhooks(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// delete 删除 webhook
+// DELETE /api/v1/boards/:id/webhooks/:webhookId
+func (h *WebhookHandler) delete(c *gin.Context) {
+	if err := h.svc.DeleteWebhook(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("webhookId")); err != nil {
+		httpx.ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,268 @@
+// Package http WebSocket 实时推送处理器
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"kanban_api/internal/events"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/service"
+	"net/http"
+	"time"
+)
+
+const (
+	// wsWriteWait 写一条消息最多等多久，客户端网络卡住时不会一直占着连接
+	wsWriteWait = 10 * time.Second
+
+	// wsPongWait 多久没收到客户端的任何消息（包括 pong）就认为连接断了
+	// 服务端每 wsPingPeriod 发一次 ping，必须比 wsPongWait 短
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = 50 * time.Second
+
+	// wsMaxMessage 客户端发来的单条消息最大字节数，订阅消息很短
+	wsMaxMessage = 4 << 10
+
+	// wsMaxBoards 一个连接最多同时订阅多少个看板
+	wsMaxBoards = 50
+
+	// wsEventBuffer 每个连接的事件缓冲区，写满说明客户端太慢，连接会被断开
+	wsEventBuffer = 64
+)
+
+// WSHandler WebSocket 实时推送处理器
+// 客户端连上 GET /api/v1/ws 之后发送订阅消息，之后订阅的看板上发生的修改都会推送过来，
+// 事件由服务层的事件发布装饰器产生（见 service/events.go）
+//
+// 客户端发送的消息：
+//
+//	{"type": "subscribe", "boardId": "..."}
+//	{"type": "unsubscribe", "boardId": "..."}
+//
+// 服务端发送的消息：
+//
+//	{"type": "subscribed", "boardId": "..."}                      订阅成功
+//	{"type": "unsubscribed", "boardId": "...", "reason": "..."}   取消订阅（客户端要求、看板被删除、用户被移出看板）
+//	{"type": "error", "boardId": "...", "error": {"code": "...", "message": "..."}}
+//	{"type": "card.created", "boardId": "...", "actorId": "...", "data": {...}, "time": "..."}  变更事件，见 events 包
+//
+// 路由组上必须挂 AuthRequired；浏览器不能给 WebSocket 加请求头，令牌可以用 ?token= 传（见 middleware.TokenFromQuery）
+type WSHandler struct {
+	boards   service.BoardService
+	bus      *events.Bus
+	upgrader websocket.Upgrader
+}
+
+// NewWSHandler 创建 WebSocket 处理器实例
+// boards 用来在订阅时检查用户能不能查看看板
+func NewWSHandler(boards service.BoardService, bus *events.Bus) *WSHandler {
+	return &WSHandler{
+		boards: boards,
+		bus:    bus,
+		// CheckOrigin 不设置时使用默认规则：带了 Origin 请求头的（浏览器发起的）连接，
+		// Origin 必须和 Host 一致，防止别的网站借用户的浏览器连进来；不带 Origin 的客户端不受影响
+		upgrader: websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096},
+	}
+}
+
+// Register 注册路由
+// - GET /ws: 升级为 WebSocket 连接
+func (h *WSHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/ws", h.serve)
+}
+
+// wsReply 服务端发给客户端的控制消息（变更事件直接发送 events.Event）
+type wsReply struct {
+	Type    string   `json:"type"`
+	BoardID string   `json:"boardId,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+	Error   *wsError `json:"error,omitempty"`
+}
+
+type wsError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// serve 升级连接并收发消息
+// GET /api/v1/ws
+//
+// 一个连接两个 goroutine：当前 goroutine 负责写（gorilla/websocket 不允许并发写），
+// readLoop 负责读客户端的订阅消息，要回复的内容通过 replies 交给写的一方
+func (h *WSHandler) serve(c *gin.Context) {
+	// 握手失败（不是 WebSocket 请求、Origin 不对）时 Upgrade 默认写纯文本，换成统一的错误格式
+	up := h.upgrader
+	up.Error = func(_ http.ResponseWriter, _ *http.Request, status int, reason error) {
+		code := httpx.CodeInvalidInput
+		if status == http.StatusForbidden {
+			code = httpx.CodeForbidden
+		}
+		httpx.Abort(c, status, code, reason.Error())
+	}
+	conn, err := up.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// 连接结束后 gin.Context 会被回收复用，读写两边只能用提前取出来的值
+	ctx := c.Request.Context()
+	userID := c.GetString("userID")
+	log := logging.FromContext(ctx)
+	log.Debug("websocket connected")
+
+	sub := h.bus.NewSubscriber(wsEventBuffer)
+	defer sub.Close()
+
+	replies := make(chan wsReply, 8)
+	stop := make(chan struct{})
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		h.readLoop(ctx, conn, sub, userID, replies, stop)
+	}()
+
+	reason := h.writeLoop(conn, sub, userID, replies, readDone)
+	log.Debug("websocket disconnected", "reason", reason)
+
+	// 让 readLoop 退出：关闭连接使 ReadMessage 返回错误，关闭 stop 使等待回复的发送返回
+	close(stop)
+	conn.Close()
+	<-readDone
+}
+
+// readLoop 读客户端的消息，直到连接出错或者被关闭
+func (h *WSHandler) readLoop(ctx context.Context, conn *websocket.Conn, sub *events.Subscriber, userID string, replies chan<- wsReply, stop <-chan struct{}) {
+	conn.SetReadLimit(wsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			// 客户端断开、超时没有 pong、消息超过 wsMaxMessage，或者写的一方已经关闭了连接
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		reply := wsReply{Type: "error", Error: &wsError{Code: httpx.CodeInvalidInput, Message: "invalid message"}}
+		var msg wsClientMessage
+		if json.Unmarshal(data, &msg) == nil {
+			reply = h.handleMessage(ctx, sub, userID, msg)
+		}
+		if !sendReply(replies, stop, reply) {
+			return
+		}
+	}
+}
+
+// handleMessage 处理一条订阅或取消订阅消息，返回要回复给客户端的内容
+func (h *WSHandler) handleMessage(ctx context.Context, sub *events.Subscriber, userID string, msg wsClientMessage) wsReply {
+	if msg.BoardID == "" {
+		return wsReply{Type: "error", Error: &wsError{Code: httpx.CodeInvalidInput, Message: "boardId required"}}
+	}
+	switch msg.Type {
+	case "subscribe":
+		if sub.Subscribed() >= wsMaxBoards {
+			return wsReply{Type: "error", BoardID: msg.BoardID, Error: &wsError{Code: httpx.CodeInvalidInput, Message: "too many subscriptions"}}
+		}
+		// 和 GET /boards/:id 一样：看不到的看板返回 not_found
+		if _, err := h.boards.GetBoard(ctx, userID, msg.BoardID); err != nil {
+			_, code, message := httpx.Classify(err)
+			if code == httpx.CodeInternal {
+				logging.FromContext(ctx).Error("websocket subscribe failed", "board_id", msg.BoardID, "err", err)
+			}
+			return wsReply{Type: "error", BoardID: msg.BoardID, Error: &wsError{Code: code, Message: message}}
+		}
+		sub.Subscribe(msg.BoardID)
+		return wsReply{Type: "subscribed", BoardID: msg.BoardID}
+	case "unsubscribe":
+		sub.Unsubscribe(msg.BoardID)
+		return wsReply{Type: "unsubscribed", BoardID: msg.BoardID, Reason: "requested"}
+	default:
+		return wsReply{Type: "error", BoardID: msg.BoardID, Error: &wsError{Code: httpx.CodeInvalidInput, Message: "type must be subscribe or unsubscribe"}}
+	}
+}
+
+// writeLoop 把事件和回复写给客户端，定时发送 ping，返回断开的原因
+func (h *WSHandler) writeLoop(conn *websocket.Conn, sub *events.Subscriber, userID string, replies <-chan wsReply, readDone <-chan struct{}) string {
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	write := func(v any) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(v) == nil
+	}
+
+	for {
+		select {
+		case <-readDone:
+			return "client closed"
+
+		case r := <-replies:
+			if !write(r) {
+				return "write failed"
+			}
+
+		case e, ok := <-sub.Events():
+			if !ok {
+				// 客户端处理太慢，缓冲区满了：断开让客户端重连，重连后重新拉取数据
+				_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, reconnect"))
+				return "lagged"
+			}
+			if !write(e) {
+				return "write failed"
+			}
+			if r, done := h.afterEvent(sub, userID, e); done {
+				if !write(r) {
+					return "write failed"
+				}
+			}
+
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)) != nil {
+				return "ping failed"
+			}
+		}
+	}
+}
+
+// afterEvent 某些事件之后要取消订阅，返回要通知客户端的消息
+func (h *WSHandler) afterEvent(sub *events.Subscriber, userID string, e events.Event) (wsReply, bool) {
+	reason, ok := endsSubscription(e, userID)
+	if !ok {
+		return wsReply{}, false
+	}
+	sub.Unsubscribe(e.BoardID)
+	return wsReply{Type: "unsubscribed", BoardID: e.BoardID, Reason: reason}, true
+}
+
+// endsSubscription 用户收到这个事件之后是否应该停止接收这个看板的事件，返回原因
+// 看板被删除、用户被移出看板之后不会再有这个看板的事件，也不应该再收到；WebSocket 和 SSE 共用
+func endsSubscription(e events.Event, userID string) (string, bool) {
+	switch e.Type {
+	case events.BoardDeleted:
+		return "board deleted", true
+	case events.MemberRemoved:
+		if m, ok := e.Data.(service.MemberRef); ok && m.UserID == userID {
+			return "removed from board", true
+		}
+	}
+	return "", false
+}
+
+// sendReply 把回复交给写的一方，连接已经结束时返回 false
+func sendReply(replies chan<- wsReply, stop <-chan struct{}, r wsReply) bool {
+	select {
+	case replies <- r:
+		return true
+	case <-stop:
+		return false
+	}
+}
@@ -0,0 +1,202 @@
+// Package httpx 严格的 JSON 请求体解析
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 严格解析的规则（BindJSON 使用）：
+//
+//  1. 请求体必须是一个 JSON 对象，后面不能再有别的内容
+//  2. 不认识的字段直接拒绝：标准库默认悄悄忽略，客户端把 title 拼成 titel 时请求"成功"了，标题却没改。
+//     错误里列出所有不认识的字段，拼写接近的附上正确的字段名
+//  3. 整数字段除了 JSON 数字，也接受内容是十进制整数的字符串（"3"），
+//     很多客户端把表单里的值一律当字符串发；"3.5"、"abc"、"" 和带空格的 " 3" 仍然拒绝
+//  4. 反过来不转换：字符串字段传数字、布尔字段传 "true" 都是类型错误
+//  5. 时间字段（httpx.Time）接受几种常见格式（见 time.go），格式不对时错误对应到字段
+//
+// 只处理请求体顶层的字段，requests.go 里的请求体都是一层，没有嵌套对象
+
+var (
+	// errNotObject 请求体不是 JSON 对象，说不出是哪个字段的问题
+	errNotObject = errors.New("body must be a JSON object")
+
+	// fieldCache 结构体类型 -> JSON 字段表，每个请求体类型只反射一次
+	fieldCache sync.Map
+
+	// timeType、nullTimeType 时间字段的类型
+	timeType     = reflect.TypeOf(Time{})
+	nullTimeType = reflect.TypeOf(NullTime{})
+)
+
+// fieldErrorList 能对应到字段的解析错误
+type fieldErrorList []FieldError
+
+func (l fieldErrorList) Error() string {
+	parts := make([]string, len(l))
+	for i, fe := range l {
+		parts[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// decodeStrict 按上面的规则把 body 解析进 obj（结构体指针）
+// 返回的错误是 fieldErrorList、*json.UnmarshalTypeError，或者其它说不出字段的错误
+func decodeStrict(body []byte, obj any) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		// 请求体是 null
+		return errNotObject
+	}
+
+	fields := jsonFields(reflect.TypeOf(obj).Elem())
+
+	var errs fieldErrorList
+	for _, name := range sortedKeys(raw) {
+		t, ok := fields[name]
+		if !ok {
+			errs = append(errs, FieldError{Field: name, Message: unknownFieldMessage(name, fields)})
+			continue
+		}
+		if t == timeType || t == nullTimeType {
+			// 先解析一次拿到错误说明；标准库会把 UnmarshalJSON 的错误原样返回，不带字段名
+			var v Time
+			if err := v.UnmarshalJSON(raw[name]); err != nil {
+				errs = append(errs, FieldError{Field: name, Message: err.Error()})
+			}
+			continue
+		}
+		if isInteger(t.Kind()) {
+			v, ok := coerceInteger(raw[name])
+			if !ok {
+				errs = append(errs, FieldError{Field: name, Message: "must be an integer"})
+				continue
+			}
+			raw[name] = v
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// 转换过的字段写回去，再按结构体真正解析一次，类型错误由标准库报告
+	// DisallowUnknownFields 在这里只是兜底，不认识的字段上面已经拦下了
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.DisallowUnknownFields()
+	return dec.Decode(obj)
+}
+
+// jsonFields 结构体的 JSON 字段名 -> 字段类型（指针取指向的类型）
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if v, ok := fieldCache.Load(t); ok {
+		return v.(map[string]reflect.Type)
+	}
+	out := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		out[name] = ft
+	}
+	fieldCache.Store(t, out)
+	return out
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// coerceInteger 整数字段的值：JSON 数字和 null 原样返回（是不是整数、有没有越界交给标准库判断），
+// 内容是十进制整数的字符串转成数字，其它字符串返回 false
+func coerceInteger(v json.RawMessage) (json.RawMessage, bool) {
+	if len(v) == 0 || v[0] != '"' {
+		return v, true
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return nil, false
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+		return nil, false
+	}
+	return json.RawMessage(s), true
+}
+
+// unknownFieldMessage 不认识的字段的说明，和某个已知字段只差一两个字母时提示正确的名字
+func unknownFieldMessage(name string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for known := range fields {
+		d := editDistance(strings.ToLower(name), strings.ToLower(known))
+		if d < bestDist || (d == bestDist && known < best) {
+			best, bestDist = known, d
+		}
+	}
+	if best == "" {
+		return "unknown field"
+	}
+	return fmt.Sprintf("unknown field, did you mean %q?", best)
+}
+
+// editDistance 两个字符串的编辑距离（Levenshtein），字段名很短，直接按定义算
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// sortedKeys 按字母顺序返回 map 的键，错误列表的顺序固定
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+// Package httpx HTTP 层的公共工具：统一的错误响应、请求体校验
+//
+// 所有接口出错时返回同一种格式：
+//
+//	{"error": {"code": "not_found", "message": "board not found", "requestId": "..."}}
+//
+// - code 是固定的机器可读错误码，客户端按它判断错误类型，不要去匹配 message
+// - message 是给人看的说明，措辞以后可能会改
+// - requestId 和响应头 X-Request-ID 相同，报 bug 时带上它就能在日志里找到这个请求
+//
+// 处理器和中间件都用这个包写错误，所以它不能依赖 http 包和 middleware 包
+package httpx
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/service"
+	"net/http"
+)
+
+// 错误码
+const (
+	CodeInvalidInput         = "invalid_input"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeBoardReadOnly        = "board_read_only"
+	CodeBoardSuspended       = "board_suspended"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeTooLarge             = "too_large"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeConfirmationRequired = "confirmation_required"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeGone                 = "gone"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeInternal             = "internal_error"
+)
+
+// ErrorBody 构造错误响应体
+// 需要在错误旁边附带数据时（例如删除大看板要求确认），在返回的 map 里再加字段
+func ErrorBody(c *gin.Context, code, message string) gin.H {
+	return gin.H{"error": gin.H{
+		"code":      code,
+		"message":   message,
+		"requestId": c.GetString("requestID"),
+	}}
+}
+
+// Abort 写错误响应并终止后续处理器
+// 中间件里必须用它（而不是只写响应），否则后面的处理器还会继续执行
+func Abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, ErrorBody(c, code, message))
+}
+
+// InvalidBody 请求体不是合法 JSON 或者缺少必填字段
+func InvalidBody(c *gin.Context) {
+	Abort(c, http.StatusBadRequest, CodeInvalidInput, "invalid body")
+}
+
+// ServiceError 把 Service 层返回的错误转换成 HTTP 响应
+// 状态码、错误码和 message 见 Classify
+func ServiceError(c *gin.Context, err error) {
+	status, code, message := Classify(err)
+	if status == http.StatusInternalServerError {
+		// 记进 c.Errors，500 的访问日志是 error 级别，err 字段会带上原文
+		_ = c.Error(err)
+	}
+	Abort(c, status, code, message)
+}
+
+// Classify 按错误类别（service.ErrInvalidInput 等）决定状态码和错误码，message 使用错误本身的说明
+// 不是普通 HTTP 响应的地方（例如 WebSocket 消息）也用它，保证错误码一致
+//
+// 不属于任何类别的错误是数据库故障之类的内部错误：
+// 原文可能带着 SQL、文件路径等内部细节，只写进日志，客户端只看到 500 和 requestId
+func Classify(err error) (status int, code, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest, CodeInvalidInput, err.Error()
+	case errors.Is(err, service.ErrUnauthorized):
+		return http.StatusUnauthorized, CodeUnauthorized, err.Error()
+	case errors.Is(err, service.ErrBoardReadOnly):
+		return http.StatusForbidden, CodeBoardReadOnly, err.Error()
+	case errors.Is(err, service.ErrBoardSuspended):
+		return http.StatusForbidden, CodeBoardSuspended, err.Error()
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden, CodeForbidden, err.Error()
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound, "not found"
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, CodeConflict, err.Error()
+	case errors.Is(err, service.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, CodeTooLarge, err.Error()
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge, CodeQuotaExceeded, err.Error()
+	default:
+		return http.StatusInternalServerError, CodeInternal, "internal error"
+	}
+}
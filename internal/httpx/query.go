@@ -0,0 +1,121 @@
+// Package httpx 查询参数解析
+package httpx
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryParams 解析 URL 查询参数，出错的参数先记下来，最后由 Valid 一起返回
+// 错误响应和请求体校验失败（BindJSON）的格式相同，details 里每个参数一条：
+//
+//	q := httpx.Query(c)
+//	limit := q.Int("limit", 0, 1, 50)
+//	archived := q.Bool("archived", false)
+//	if !q.Valid() {
+//		return
+//	}
+//
+// 参数没传或者是空串时使用默认值；传了但格式不对、超出范围时报错，不悄悄换成默认值或边界值，
+// 否则客户端传错了参数也拿到 200，很难发现
+type QueryParams struct {
+	c    *gin.Context
+	errs []FieldError
+}
+
+// Query 创建查询参数解析器
+func Query(c *gin.Context) *QueryParams {
+	return &QueryParams{c: c}
+}
+
+// String 字符串参数，去掉首尾空白
+func (q *QueryParams) String(name, def string) string {
+	v := strings.TrimSpace(q.c.Query(name))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// Int 整数参数，必须在 [min, max] 范围内
+// 默认值不做范围检查，可以用 0 表示"没传，交给服务层决定"
+func (q *QueryParams) Int(name string, def, min, max int) int {
+	v := strings.TrimSpace(q.c.Query(name))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		q.fail(name, "must be an integer")
+		return def
+	}
+	if n < min || n > max {
+		q.fail(name, fmt.Sprintf("must be between %d and %d", min, max))
+		return def
+	}
+	return n
+}
+
+// Bool 布尔参数，接受 true/false、1/0（不区分大小写）
+func (q *QueryParams) Bool(name string, def bool) bool {
+	v := strings.TrimSpace(q.c.Query(name))
+	if v == "" {
+		return def
+	}
+	switch strings.ToLower(v) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	}
+	q.fail(name, "must be true or false")
+	return def
+}
+
+// Date 日期参数，格式 YYYY-MM-DD，按 UTC 零点解析
+func (q *QueryParams) Date(name string, def time.Time) time.Time {
+	v := strings.TrimSpace(q.c.Query(name))
+	if v == "" {
+		return def
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		q.fail(name, "must be a date (YYYY-MM-DD)")
+		return def
+	}
+	return t
+}
+
+// Time 日期时间参数，格式和请求体里的时间字段一样（见 timeLayouts），返回 UTC 时间
+func (q *QueryParams) Time(name string, def time.Time) time.Time {
+	v := strings.TrimSpace(q.c.Query(name))
+	if v == "" {
+		return def
+	}
+	t, err := ParseTime(v)
+	if err != nil {
+		q.fail(name, err.Error())
+		return def
+	}
+	return t
+}
+
+// Valid 没有错误时返回 true
+// 有错误时已经写好 400 响应，返回 false，处理器直接 return
+func (q *QueryParams) Valid() bool {
+	if len(q.errs) == 0 {
+		return true
+	}
+	body := ErrorBody(q.c, CodeInvalidInput, "invalid query parameters")
+	body["error"].(gin.H)["details"] = q.errs
+	q.c.AbortWithStatusJSON(http.StatusBadRequest, body)
+	return false
+}
+
+func (q *QueryParams) fail(name, message string) {
+	q.errs = append(q.errs, FieldError{Field: name, Message: message})
+}
@@ -0,0 +1,98 @@
+// Package httpx 请求体里的日期时间
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// timeLayouts 请求体里接受的时间格式，按顺序尝试
+// 带时区的按给出的时区换算；没有时区的（表单里的 datetime-local、只有日期）按 UTC 理解
+var timeLayouts = []string{
+	time.RFC3339Nano,      // 2024-01-31T18:00:00Z、2024-01-31T18:00:00.5+08:00
+	"2006-01-02T15:04:05", // 2024-01-31T18:00:00
+	"2006-01-02T15:04",    // 2024-01-31T18:00（<input type="datetime-local"> 的格式）
+	"2006-01-02 15:04:05", // 2024-01-31 18:00:00
+	"2006-01-02 15:04",    // 2024-01-31 18:00
+	time.DateOnly,         // 2024-01-31，当天 00:00 UTC
+}
+
+var (
+	// errTimeFormat 格式不认识，说明里给出例子，客户端能直接显示给用户
+	errTimeFormat = errors.New("must be a date like 2024-01-31 or a time like 2024-01-31T18:00:00Z")
+
+	// errTimeRange 换算成 UTC 后年份超出 1-9999，输出时没法按 RFC3339 表示
+	errTimeRange = errors.New("must be between years 1 and 9999")
+)
+
+// Time 请求体里的日期时间字段，接受 timeLayouts 里的任意一种格式
+// 请求体结构里用 *httpx.Time：没传或者传 null 是 nil，格式不对时 BindJSON 返回这个字段的错误说明
+// （time.Time 自己只认 RFC3339，解析失败的错误也对应不到字段，只能返回笼统的 "invalid body"）
+type Time struct {
+	time.Time
+}
+
+// ParseTime 按 timeLayouts 解析，返回 UTC 时间
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		t = t.UTC()
+		if t.Year() < 1 || t.Year() > 9999 {
+			return time.Time{}, errTimeRange
+		}
+		return t, nil
+	}
+	return time.Time{}, errTimeFormat
+}
+
+// UnmarshalJSON 只接受字符串，null 保持零值
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errTimeFormat
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Ptr 转成服务层用的 *time.Time，nil 还是 nil
+func (t *Time) Ptr() *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+// NullTime PATCH 请求体里可以清空的日期时间字段，区分三种情况：
+//   - 没传：Set 为 false，不修改
+//   - 传了 null：Set 为 true，Time 为 nil，清空
+//   - 传了时间：Set 为 true，Time 是解析出来的 UTC 时间
+//
+// 请求体结构里直接用 httpx.NullTime，不要用指针：字段是指针时标准库遇到 null 只会把指针设成 nil，
+// 和没传分不出来；不是指针时 null 也会交给 UnmarshalJSON
+type NullTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+// UnmarshalJSON 字段出现了就是 Set，格式规则和 Time 相同
+func (n *NullTime) UnmarshalJSON(b []byte) error {
+	var t *Time
+	if err := json.Unmarshal(b, &t); err != nil {
+		return err
+	}
+	n.Set, n.Time = true, t.Ptr()
+	return nil
+}
@@ -0,0 +1,283 @@
+// Package imagemeta 去掉上传图片里的元数据
+//
+// 手机拍的照片和截图里常带着 EXIF（拍摄时间、设备型号、GPS 位置）、XMP、IPTC、注释等，
+// 附件一旦分享出去这些信息也跟着出去了。这个包在保存之前把它们去掉：
+//   - JPEG：删除 APP1（EXIF、XMP）、APP13（IPTC）、注释等段和文件结尾之后附加的数据，
+//     保留 JFIF、ICC 颜色配置和 Adobe 段，图像数据原样保留
+//   - PNG：删除 eXIf、tEXt、zTXt、iTXt、tIME 块
+//
+// EXIF 里的方向（Orientation）决定照片怎么摆正，直接删掉的话竖着拍的照片会横过来显示。
+// 所以 JPEG 方向不是 1 时按方向旋转 / 翻转后重新编码，把方向"画"进像素里（ICC 颜色配置这时也会丢掉）；
+// 像素太多（超过 MaxPixels）不值得解码时不重新编码，只保留一个只有方向的最小 EXIF
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// MaxPixels 重新编码的图片最多多少像素，超过时不解码（解码后每个像素 4 字节，50M 像素约 200 MiB 内存）
+const MaxPixels = 50_000_000
+
+// jpegQuality 重新编码 JPEG 的质量
+const jpegQuality = 90
+
+// ErrInvalid 图片格式不对，无法解析
+var ErrInvalid = errors.New("invalid image")
+
+// Supported 是否能处理这种文件类型
+func Supported(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Strip 去掉图片的元数据，返回新的内容
+// 没有元数据可去时 changed 为 false，out 就是 data
+func Strip(data []byte, contentType string) (out []byte, changed bool, err error) {
+	switch contentType {
+	case "image/jpeg":
+		out, err = stripJPEG(data)
+	case "image/png":
+		out, err = stripPNG(data)
+	default:
+		return data, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(out, data) {
+		return data, false, nil
+	}
+	return out, true, nil
+}
+
+// ========== JPEG ==========
+
+// keepJPEGSegment 去元数据时保留的 APPn 段
+//   - APP0：JFIF / JFXX，说明像素密度，没有个人信息
+//   - APP2 的 ICC_PROFILE：颜色配置，删掉之后广色域照片颜色会变
+//   - APP14：Adobe，CMYK / YCCK 的 JPEG 要靠它才能正确解码
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch marker {
+	case 0xE0, 0xEE:
+		return true
+	case 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	}
+	return false
+}
+
+// stripJPEG 逐段复制 JPEG，去掉元数据段
+// 段的格式：0xFF、标记、2 字节长度（包括长度本身）、内容；遇到 SOS（图像数据开始）之后原样复制到 EOI
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrInvalid
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	orientation := 1
+
+	for i := 2; ; {
+		// 标记前面可以有多余的 0xFF 填充
+		for i < len(data) && data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) || data[i] != 0xFF {
+			return nil, ErrInvalid
+		}
+		marker := data[i+1]
+		// 没有长度的标记：TEM 和 RSTn
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+		if marker == 0xD9 {
+			out = append(out, data[i:i+2]...)
+			break
+		}
+		if i+4 > len(data) {
+			return nil, ErrInvalid
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if n < 2 || i+2+n > len(data) {
+			return nil, ErrInvalid
+		}
+		segment, payload := data[i:i+2+n], data[i+4:i+2+n]
+
+		switch {
+		case marker == 0xDA:
+			// SOS：后面都是图像数据，复制到 EOI 为止
+			// 图像数据里的 0xFF 都会写成 0xFF 0x00，第一个 0xFF 0xD9 就是文件结尾；
+			// EOI 后面有些手机还会附加数据（厂商信息、动态照片的视频），一起去掉
+			end := len(data)
+			if k := bytes.Index(data[i+2+n:], []byte{0xFF, 0xD9}); k >= 0 {
+				end = i + 2 + n + k + 2
+			}
+			out = append(out, data[i:end]...)
+			return finishJPEG(out, orientation)
+		case marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")):
+			orientation = exifOrientation(payload[6:])
+		case (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE:
+			if keepJPEGSegment(marker, payload) {
+				out = append(out, segment...)
+			}
+		default:
+			out = append(out, segment...)
+		}
+		i += 2 + n
+	}
+	return finishJPEG(out, orientation)
+}
+
+// finishJPEG 去掉元数据之后处理方向
+func finishJPEG(stripped []byte, orientation int) ([]byte, error) {
+	if orientation < 2 || orientation > 8 {
+		return stripped, nil
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		// 太大了不解码，在 SOI 后面放一个只有方向的 EXIF
+		out := make([]byte, 0, len(stripped)+64)
+		out = append(out, stripped[:2]...)
+		out = append(out, orientationOnlyEXIF(orientation)...)
+		return append(out, stripped[2:]...), nil
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exifOrientation 从 EXIF 的 TIFF 数据里读出 IFD0 的 Orientation（0x0112），没有或者读不出来时返回 1
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for k := 0; k < count; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(tiff) {
+			return 1
+		}
+		// 类型 3 是 SHORT，值直接放在条目的最后 4 字节里
+		if order.Uint16(tiff[e:e+2]) == 0x0112 && order.Uint16(tiff[e+2:e+4]) == 3 {
+			return int(order.Uint16(tiff[e+8 : e+10]))
+		}
+	}
+	return 1
+}
+
+// orientationOnlyEXIF 只有一个 Orientation 条目的 APP1 段
+func orientationOnlyEXIF(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // 大端，IFD0 在偏移 8
+		0x00, 0x01, // 1 个条目
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(orientation), 0x00, 0x00, // Orientation，SHORT，1 个
+		0x00, 0x00, 0x00, 0x00, // 没有下一个 IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// orient 按 EXIF 方向把图片摆正
+// 方向 2-8 分别是：水平翻转、旋转 180°、垂直翻转、转置、顺时针 90°、反转置、逆时针 90°
+func orient(src image.Image, orientation int) image.Image {
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], rgba.Pix[rgba.PixOffset(sx, sy):rgba.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// ========== PNG ==========
+
+// pngSignature PNG 文件开头的 8 字节
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks 去元数据时删除的块：EXIF、文本（作者、注释、软件等）、修改时间
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// stripPNG 逐块复制 PNG，去掉元数据块
+// 块的格式：4 字节长度、4 字节类型、内容、4 字节 CRC
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrInvalid
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrInvalid
+		}
+		n := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + 12 + n
+		if n < 0 || end > len(data) || end < i {
+			return nil, ErrInvalid
+		}
+		typ := string(data[i+4 : i+8])
+		if !pngMetadataChunks[typ] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+		if typ == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testImage 32x16 的图片，左半边红色、右半边蓝色
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 16 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment 带方向和一个假 GPS 条目（0x8825）的 APP1 段，小端
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00}
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	gps := make([]byte, 12)
+	binary.LittleEndian.PutUint16(gps[0:], 0x8825)
+	binary.LittleEndian.PutUint16(gps[2:], 4)
+	binary.LittleEndian.PutUint32(gps[4:], 1)
+	tiff = append(append(tiff, gps...), 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withSegments 在 JPEG 的 SOI 后面插入 segs
+func withSegments(jpg []byte, segs ...[]byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	for _, s := range segs {
+		out = append(out, s...)
+	}
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestStripJPEGLossless 方向正常时只删除元数据段，图像数据一个字节不变
+func TestStripJPEGLossless(t *testing.T) {
+	plain := encodeJPEG(t)
+	comment := []byte{0xFF, 0xFE, 0x00, 0x07, 'h', 'e', 'l', 'l', 'o'}
+	in := withSegments(plain, exifSegment(1), comment)
+	in = append(in, []byte("trailing vendor data")...)
+
+	out, changed, err := Strip(in, "image/jpeg")
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Fatalf("stripped jpeg differs from the original without metadata (%d vs %d bytes)", len(out), len(plain))
+	}
+
+	// 没有元数据时原样返回
+	if _, changed, err := Strip(plain, "image/jpeg"); err != nil || changed {
+		t.Fatalf("plain jpeg: changed = %v, err = %v", changed, err)
+	}
+}
+
+// TestStripJPEGOrientation 方向是 6（顺时针转 90° 显示）时重新编码成竖图，左边的红色转到上面
+func TestStripJPEGOrientation(t *testing.T) {
+	in := withSegments(encodeJPEG(t), exifSegment(6))
+
+	out, changed, err := Strip(in, "image/jpeg")
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	if bytes.Contains(out, []byte("Exif")) {
+		t.Fatal("output still has EXIF")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Fatalf("size = %dx%d, want 16x32", b.Dx(), b.Dy())
+	}
+	top, bottom := color.RGBAModel.Convert(img.At(8, 8)).(color.RGBA), color.RGBAModel.Convert(img.At(8, 24)).(color.RGBA)
+	if top.R < 200 || top.B > 50 || bottom.B < 200 || bottom.R > 50 {
+		t.Fatalf("top = %v, bottom = %v, want red on top and blue below", top, bottom)
+	}
+}
+
+// TestExifOrientationOnly 只有方向的最小 EXIF 能被读回来
+func TestExifOrientationOnly(t *testing.T) {
+	seg := orientationOnlyEXIF(8)
+	if got := exifOrientation(seg[4+6:]); got != 8 {
+		t.Fatalf("orientation = %d, want 8", got)
+	}
+	if got := exifOrientation(exifSegment(3)[4+6:]); got != 3 {
+		t.Fatalf("orientation = %d, want 3", got)
+	}
+}
+
+// TestStripPNG 删除文本和 EXIF 块，其它块不变
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+
+	// tEXt 块插在 IHDR（8 字节签名 + 25 字节）后面，CRC 内容不重要，解析时不校验
+	text := []byte{0, 0, 0, 12, 't', 'E', 'X', 't', 'A', 'u', 't', 'h', 'o', 'r', 0, 'a', 'l', 'i', 'c', 'e', 0, 0, 0, 0}
+	exif := []byte{0, 0, 0, 0, 'e', 'X', 'I', 'f', 0, 0, 0, 0}
+	in := append(append(append(append([]byte{}, plain[:33]...), text...), exif...), plain[33:]...)
+
+	out, changed, err := Strip(in, "image/png")
+	if err != nil || !changed {
+		t.Fatalf("changed = %v, err = %v", changed, err)
+	}
+	if !bytes.Equal(out, plain) {
+		t.Fatal("stripped png differs from the original")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestStripInvalid 内容和类型对不上时返回 ErrInvalid，不认识的类型原样返回
+func TestStripInvalid(t *testing.T) {
+	if _, _, err := Strip([]byte("not a jpeg"), "image/jpeg"); err != ErrInvalid {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+	if _, changed, err := Strip([]byte("GIF89a..."), "image/gif"); err != nil || changed {
+		t.Fatalf("gif: changed = %v, err = %v", changed, err)
+	}
+}
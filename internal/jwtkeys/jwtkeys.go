@@ -0,0 +1,278 @@
+// Package jwtkeys 登录令牌（访问令牌）的签名和验证密钥
+//
+// 默认用 JWT_SECRET 按 HS256 签名，只有我们自己能验证。配置了私钥（RSA 或 Ed25519）时改用 RS256 / EdDSA 签名，
+// 公钥通过 /.well-known/jwks.json 公开，其它服务拿公钥就能验证我们的令牌，不需要知道任何密钥。
+//
+// 非对称签名的令牌头里带 kid（公钥的 RFC 7638 指纹），验证时按 kid 找公钥；
+// 换私钥时把旧的公钥也配置上，已经签发的令牌在过期前仍然有效
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"sort"
+)
+
+// minRSABits RSA 密钥的最小长度
+const minRSABits = 2048
+
+var (
+	// ErrUnknownKey 令牌头里的 kid 不是我们的公钥（或者没有 kid）
+	ErrUnknownKey = errors.New("unknown signing key")
+
+	// ErrHMACDisabled 使用非对称签名、没有配置继续接受 HS256 时收到了 HS256 令牌
+	ErrHMACDisabled = errors.New("hs256 tokens are not accepted")
+)
+
+// publicKey 一个验证用的公钥
+type publicKey struct {
+	method jwt.SigningMethod
+
+	// key *rsa.PublicKey 或 ed25519.PublicKey
+	key crypto.PublicKey
+}
+
+// Set 签名用的密钥和验证时接受的密钥，创建后只读，可以在多个 goroutine 里使用
+type Set struct {
+	method  jwt.SigningMethod
+	signKey any
+
+	// kid 签名私钥对应公钥的指纹，HS256 时为空
+	kid string
+
+	// secret HS256 密钥，为 nil 时不接受 HS256 令牌
+	secret []byte
+
+	// public 按 kid 索引的公钥，包括签名私钥的公钥
+	public map[string]publicKey
+}
+
+// NewHMAC 用共享密钥按 HS256 签名和验证（默认配置）
+func NewHMAC(secret []byte) *Set {
+	return &Set{method: jwt.SigningMethodHS256, signKey: secret, secret: secret}
+}
+
+// NewAsymmetric 用私钥签名（RSA 为 RS256，Ed25519 为 EdDSA）
+// extra 是另外接受的公钥（换私钥之前的旧公钥），也出现在 JWKS 里；
+// hmacSecret 不为 nil 时还接受 HS256 令牌，从 HS256 切换过来时用，等旧令牌都过期后就不要再传了
+func NewAsymmetric(signKey crypto.Signer, hmacSecret []byte, extra ...crypto.PublicKey) (*Set, error) {
+	s := &Set{signKey: signKey, secret: hmacSecret, public: map[string]publicKey{}}
+	kid, err := s.add(signKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	s.kid, s.method = kid, s.public[kid].method
+	for _, k := range extra {
+		if _, err := s.add(k); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// add 加一个验证用的公钥，返回它的 kid
+func (s *Set) add(k crypto.PublicKey) (string, error) {
+	var method jwt.SigningMethod
+	switch k := k.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return "", fmt.Errorf("rsa key too short: %d bits, need at least %d", k.N.BitLen(), minRSABits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return "", fmt.Errorf("unsupported key type %T, need RSA or Ed25519", k)
+	}
+	kid := toJWK(k).Kid
+	s.public[kid] = publicKey{method: method, key: k}
+	return kid, nil
+}
+
+// Alg 签名算法：HS256、RS256 或 EdDSA
+func (s *Set) Alg() string {
+	return s.method.Alg()
+}
+
+// Sign 签名，非对称签名时令牌头里带 kid
+func (s *Set) Sign(claims jwt.Claims) (string, error) {
+	tok := jwt.NewWithClaims(s.method, claims)
+	if s.kid != "" {
+		tok.Header["kid"] = s.kid
+	}
+	return tok.SignedString(s.signKey)
+}
+
+// Parse 验证签名并解析令牌，只接受配置的算法
+// 算法由我们的配置决定，不由令牌头决定：不接受 alg: none，也不会拿公钥当 HS256 密钥验证
+func (s *Set) Parse(raw string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(raw, claims, s.keyfunc, jwt.WithValidMethods(s.methods()))
+}
+
+// methods 验证时接受的算法
+func (s *Set) methods() []string {
+	var out []string
+	if s.secret != nil {
+		out = append(out, jwt.SigningMethodHS256.Alg())
+	}
+	seen := map[string]bool{}
+	for _, k := range s.public {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			out = append(out, alg)
+		}
+	}
+	return out
+}
+
+// keyfunc 按令牌的算法和 kid 选验证密钥
+func (s *Set) keyfunc(t *jwt.Token) (any, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.secret == nil {
+			return nil, ErrHMACDisabled
+		}
+		return s.secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	k, ok := s.public[kid]
+	if !ok || k.method.Alg() != t.Method.Alg() {
+		return nil, ErrUnknownKey
+	}
+	return k.key, nil
+}
+
+// JWK 一个公钥（RFC 7517），只包含公开的部分
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// N、E RSA 公钥的模数和指数
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Crv、X Ed25519 公钥
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS /.well-known/jwks.json 的内容
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 所有验证用的公钥，签名用的排在最前面，其余按 kid 排序
+// HS256 的密钥不能公开，只用 HS256 时返回空列表
+func (s *Set) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	for _, k := range s.public {
+		jwk := toJWK(k.key)
+		jwk.Alg = k.method.Alg()
+		out.Keys = append(out.Keys, jwk)
+	}
+	sort.Slice(out.Keys, func(i, j int) bool {
+		a, b := out.Keys[i], out.Keys[j]
+		if (a.Kid == s.kid) != (b.Kid == s.kid) {
+			return a.Kid == s.kid
+		}
+		return a.Kid < b.Kid
+	})
+	return out
+}
+
+// toJWK 公钥转成 JWK，kid 是 RFC 7638 指纹：必需字段按字典序排列的 JSON 的 SHA-256
+func toJWK(k crypto.PublicKey) JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	var jwk JWK
+	var canonical []byte
+	switch k := k.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{Kty: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}
+		canonical, _ = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N})
+	case ed25519.PublicKey:
+		jwk = JWK{Kty: "OKP", Crv: "Ed25519", X: b64(k)}
+		canonical, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X})
+	}
+	sum := sha256.Sum256(canonical)
+	jwk.Kid, jwk.Use = b64(sum[:]), "sig"
+	return jwk
+}
+
+// LoadPrivateKey 读取 PEM 格式的私钥文件（PKCS#8 的 PRIVATE KEY，或者 PKCS#1 的 RSA PRIVATE KEY）
+//
+//	openssl genpkey -algorithm ed25519 -out jwt.pem
+//	openssl genpkey -algorithm rsa -pkeyopt rsa_keygen_bits:2048 -out jwt.pem
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch k := k.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case ed25519.PrivateKey:
+			return k, nil
+		}
+		return nil, fmt.Errorf("%s: unsupported key type %T, need RSA or Ed25519", path, k)
+	}
+	return nil, fmt.Errorf("%s: unexpected pem block %q, need a private key", path, block.Type)
+}
+
+// LoadPublicKey 读取 PEM 格式的公钥文件（PUBLIC KEY），也接受私钥文件，取它的公钥
+//
+//	openssl pkey -in old.pem -pubout -out old.pub.pem
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "PUBLIC KEY" {
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	k, err := LoadPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return k.Public(), nil
+}
+
+// readPEM 读取文件里的第一个 PEM 块
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no pem block found", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,161 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Subject: "u1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+// TestAsymmetric RS256 和 EdDSA 签名的令牌带 kid，能用 JWKS 里的公钥验证；换私钥后旧公钥签的令牌仍然有效
+func TestAsymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := NewAsymmetric(rsaKey, nil)
+	if err != nil || old.Alg() != "RS256" {
+		t.Fatalf("rsa set = %v, %v", old, err)
+	}
+	oldToken, err := old.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewAsymmetric(edKey, nil, &rsaKey.PublicKey)
+	if err != nil || keys.Alg() != "EdDSA" {
+		t.Fatalf("ed25519 set = %v, %v", keys, err)
+	}
+	token, err := keys.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, raw := range []string{token, oldToken} {
+		var claims jwt.RegisteredClaims
+		if tok, err := keys.Parse(raw, &claims); err != nil || !tok.Valid || claims.Subject != "u1" {
+			t.Fatalf("parse = %v, %+v", err, claims)
+		}
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].Alg != "EdDSA" || jwks.Keys[1].Kty != "RSA" || jwks.Keys[1].E != "AQAB" {
+		t.Fatalf("jwks = %+v", jwks)
+	}
+	if jwks.Keys[0].Kid != toJWK(edPub).Kid || jwks.Keys[1].Kid != old.kid {
+		t.Fatalf("kids = %s %s", jwks.Keys[0].Kid, jwks.Keys[1].Kid)
+	}
+
+	// 不认识的 kid：只配置了新私钥，没有把旧公钥加进来
+	fresh, err := NewAsymmetric(edKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.Parse(oldToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatal("token signed by an unknown key accepted")
+	}
+}
+
+// TestAlgorithmConfusion 非对称签名时不接受 HS256 令牌（除非配置了继续接受），也不接受 alg: none
+func TestAlgorithmConfusion(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+	hsToken, err := NewHMAC(secret).Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewAsymmetric(edKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Parse(hsToken, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatal("hs256 token accepted")
+	}
+	// 拿公钥当 HS256 密钥签名，想骗过只看令牌头选算法的实现
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Parse(forged, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatal("token signed with the public key as hmac secret accepted")
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Parse(none, &jwt.RegisteredClaims{}); err == nil {
+		t.Fatal("alg none accepted")
+	}
+
+	// 切换期间两种都接受
+	both, err := NewAsymmetric(edKey, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := both.Parse(hsToken, &jwt.RegisteredClaims{}); err != nil {
+		t.Fatalf("hs256 token during transition: %v", err)
+	}
+	if len(NewHMAC(secret).JWKS().Keys) != 0 {
+		t.Fatal("hmac secret published in jwks")
+	}
+}
+
+// TestLoadKeys 读取 PKCS#8 / PKCS#1 私钥和 PKIX 公钥；太短的 RSA 密钥不能用
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	if k, err := LoadPrivateKey(write("ed.pem", "PRIVATE KEY", der)); err != nil || !edKey.Equal(k) {
+		t.Fatalf("load ed25519 = %v, %v", k, err)
+	}
+	der, _ = x509.MarshalPKIXPublicKey(edPub)
+	if k, err := LoadPublicKey(write("ed.pub.pem", "PUBLIC KEY", der)); err != nil || !edPub.Equal(k) {
+		t.Fatalf("load public = %v, %v", k, err)
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := LoadPrivateKey(write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAsymmetric(k, nil); err == nil {
+		t.Fatal("1024-bit rsa key accepted")
+	}
+
+	if _, err := LoadPrivateKey(write("cert.pem", "CERTIFICATE", []byte("x"))); err == nil {
+		t.Fatal("certificate accepted as private key")
+	}
+	if _, err := LoadPrivateKey(filepath.Join(dir, "missing.pem")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("missing file: %v", err)
+	}
+}
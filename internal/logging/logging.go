@@ -0,0 +1,78 @@
+// Package logging 结构化日志
+//
+// 基于标准库 log/slog，日志由一条消息加若干 key=value 字段组成，
+// 输出格式可以切换：开发时用人类可读的文本格式，生产环境用 JSON 格式方便日志系统采集和检索
+//
+// 每个请求都有一个自己的 logger，带着请求 ID（登录后还有用户 ID），
+// 通过 context.Context 一层层往下传；服务层、仓储层用 FromContext 取出来打日志，
+// 同一个请求的所有日志都能按 request_id 串起来
+//
+// 日志级别和几个调试开关保存在包级的设置快照（Settings）里，整个进程共用一份，
+// 运行中可以通过管理员接口修改，不需要重启，见 settings.go
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 输出格式
+const (
+	// FormatText 人类可读的 key=value 格式（默认）
+	FormatText = "text"
+
+	// FormatJSON 每行一个 JSON 对象
+	FormatJSON = "json"
+)
+
+// New 创建一个 logger，写到 w
+// format 是 text 或 json，level 是 debug、info、warn、error（不区分大小写），空字符串使用默认值
+// level 写进设置快照作为初始级别，logger 每条日志都按快照里的当前级别过滤
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		// slog.Level 自己会解析 DEBUG、INFO、WARN、ERROR，还支持 INFO+2 这种写法
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	Update(func(s *Settings) { s.Level = lvl })
+	opts := &slog.HandlerOptions{Level: settingsLeveler{}}
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (want text or json)", format)
+	}
+}
+
+// FromEnv 按环境变量创建写到标准错误的 logger
+// - LOG_FORMAT: text（默认）或 json
+// - LOG_LEVEL: debug、info（默认）、warn、error
+func FromEnv() (*slog.Logger, error) {
+	return New(os.Stderr, os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+}
+
+// ctxKey context 中保存 logger 的 key，用私有类型避免和其它包冲突
+type ctxKey struct{}
+
+// WithLogger 返回一个带着 l 的新 ctx
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext 取出 ctx 里的 logger
+// 没有时（例如启动阶段、后台任务）返回 slog.Default()，调用方不需要判空
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Settings 运行时可以修改的日志设置
+// 整份设置是一个不可变的快照：修改时构造一份新的整体替换，
+// 读的一方拿到的要么是旧快照要么是新快照，不会看到改了一半的状态
+type Settings struct {
+	// Level 日志级别，低于它的日志不输出
+	Level slog.Level `json:"level"`
+
+	// VerboseSQL 打开后每条 SQL 都按 info 级别输出（只有 SQL 文本，不带参数值）
+	// 关闭时只在 debug 级别输出；出错和慢查询不受影响，总是输出
+	VerboseSQL bool `json:"verboseSql"`
+
+	// RequestBodies 打开后访问日志带上请求体，密码、令牌等字段会被替换成 [REDACTED]
+	RequestBodies bool `json:"requestBodies"`
+}
+
+// current 当前的设置快照，零值是 info 级别、两个开关都关闭
+var current atomic.Pointer[Settings]
+
+func init() {
+	current.Store(&Settings{})
+}
+
+// Current 返回当前设置的副本
+func Current() Settings {
+	return *current.Load()
+}
+
+// Update 在当前设置的基础上修改，用新快照整体替换，立即对所有 logger 生效，返回修改后的设置
+// 用 CompareAndSwap 实现：两个管理员同时修改不同的开关时，不会用旧快照覆盖掉对方的修改
+func Update(fn func(*Settings)) Settings {
+	for {
+		old := current.Load()
+		s := *old
+		fn(&s)
+		if current.CompareAndSwap(old, &s) {
+			return s
+		}
+	}
+}
+
+// settingsLeveler 实现 slog.Leveler，每次判断级别时读当前快照
+// slog 的 handler 在创建时拿到的是这个对象而不是固定的级别，所以修改设置后已经创建的 logger 也会跟着变
+type settingsLeveler struct{}
+
+func (settingsLeveler) Level() slog.Level {
+	return current.Load().Level
+}
+
+// redacted 替换敏感字段值的占位符
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 字段名（转小写后）包含这些词的值会被隐藏
+// api-key 是 API 密钥的请求头 X-API-Key
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "api-key"}
+
+// RedactJSON 把 JSON 请求体里的敏感字段替换成 [REDACTED]，返回适合写进日志的字符串
+// 任意层级的对象都会检查；不是合法 JSON（包括被截断的请求体）时没法判断哪些是敏感内容，整个隐藏
+func RedactJSON(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redacted
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactValue 递归处理对象和数组
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if IsSensitive(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
+
+// IsSensitive 字段名、请求头名或查询参数名是否可能带着密码、令牌之类的敏感内容
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactQuery 脱敏后的查询字符串，访问日志和请求抓包共用
+// 嵌入令牌、删除确认令牌、第三方登录的授权码都是通过查询参数传的（?token=、?confirm=、?code=），
+// 名字敏感的参数和 confirm、code 的值替换掉；
+// oEmbed 的 ?url= 里是整个分享链接，链接自己的查询参数里也带着嵌入令牌，同样处理一遍
+// 参数按名字排序，同一个请求每次输出都一样
+func RedactQuery(q url.Values) string {
+	if len(q) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range q[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k) + "=")
+			if IsSensitive(k) || k == "confirm" || k == "code" {
+				b.WriteString(redacted)
+				continue
+			}
+			b.WriteString(url.QueryEscape(redactURL(v)))
+		}
+	}
+	return b.String()
+}
+
+// redactURL 参数值是带查询参数的链接时，把链接里的敏感参数替换掉，其它值原样返回
+func redactURL(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.RawQuery == "" || (u.Scheme == "" && !strings.HasPrefix(v, "/")) {
+		return v
+	}
+	u.RawQuery = RedactQuery(u.Query())
+	return u.String()
+}
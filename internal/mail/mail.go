@@ -0,0 +1,176 @@
+// Package mail 邮件发送
+// 业务代码只依赖 Sender 接口，生产环境用 SMTP 实现，开发环境用 noop 实现（只打日志）
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"kanban_api/internal/logging"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	// To 收件人地址
+	To string
+
+	// Subject 邮件标题
+	Subject string
+
+	// Body 邮件正文（纯文本）
+	Body string
+}
+
+// Sender 邮件发送接口
+type Sender interface {
+	// Send 发送一封邮件
+	Send(ctx context.Context, msg Message) error
+}
+
+// ========== noop 实现 ==========
+
+// noopSender 不真正发送邮件，只把邮件内容打印到日志
+type noopSender struct{}
+
+// NewNoopSender 创建 noop 发送器
+// 开发环境没有邮件服务器时使用，可以从日志里拿到重置链接
+// 注意：日志里会出现邮件正文（包括重置令牌），不要在生产环境使用
+func NewNoopSender() Sender {
+	return noopSender{}
+}
+
+func (noopSender) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).Info("mail (noop)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// ========== SMTP 实现 ==========
+
+// SMTPConfig SMTP 服务器配置
+type SMTPConfig struct {
+	// Host SMTP 服务器地址，例如 smtp.example.com
+	Host string
+
+	// Port 端口，默认 587（STARTTLS）
+	Port string
+
+	// Username / Password 登录凭据，Username 为空时不登录
+	Username string
+	Password string
+
+	// From 发件人地址
+	From string
+}
+
+// SMTPConfigFromEnv 从环境变量读取 SMTP 配置
+// - SMTP_HOST: 服务器地址（必填）
+// - SMTP_PORT: 端口（默认 587）
+// - SMTP_USERNAME / SMTP_PASSWORD: 登录凭据（可选）
+// - SMTP_FROM: 发件人地址（必填）
+func SMTPConfigFromEnv() (SMTPConfig, error) {
+	cfg := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.Host == "" {
+		return cfg, errors.New("SMTP_HOST is required")
+	}
+	if cfg.From == "" {
+		return cfg, errors.New("SMTP_FROM is required")
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return cfg, nil
+}
+
+// smtpSender 通过 SMTP 服务器发送邮件
+type smtpSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender 创建 SMTP 发送器
+func NewSMTPSender(cfg SMTPConfig) Sender {
+	return &smtpSender{cfg: cfg}
+}
+
+// Send 发送邮件
+// 没有直接用 smtp.SendMail，因为它不支持 context：
+// 这里自己拨号，并把 ctx 的截止时间设置到连接上，SMTP 服务器卡住时不会一直等下去
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	// 收件人地址来自用户输入，带换行符的话可以往邮件里注入额外的头部
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return errors.New("invalid recipient address")
+	}
+
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, s.cfg.Port))
+	if err != nil {
+		return err
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(dl)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// 服务器支持 STARTTLS 就升级成加密连接
+	// 凭据只有在加密连接上才会发送（smtp.PlainAuth 会检查）
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.build(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// build 组装邮件原文（头部 + 正文）
+// 标题可能包含中文，用 MIME 编码（RFC 2047）
+func (s *smtpSender) build(msg Message) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + s.cfg.From + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
@@ -2,8 +2,13 @@
 package middleware
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
 	"net/http"
 	"strings"
 )
@@ -12,16 +17,38 @@ import (
 // 必须与 service/auth.go 中的 customClaims 保持一致
 type CustomClaims struct {
 	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// SessionChecker 查访问令牌所属用户当前的状态，service.AuthService 实现了它
+// 用户被停用、注销或者删除时返回 401 类的错误
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID string) (model.User, error)
+}
+
+// APIKeyAuthenticator 校验 X-API-Key 请求头里的个人 API 密钥，service.APIKeyService 实现了它
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (model.APIKey, model.User, error)
+}
+
 // AuthRequired 认证中间件
 // 要求请求必须携带有效的 JWT 令牌
 // 用于保护需要登录才能访问的接口
-func AuthRequired(secret []byte) gin.HandlerFunc {
-	// 返回一个闭包（closure），捕获了 secret 变量
-	// 这样每次请求都可以使用同一个密钥来验证令牌
+// keys 不为 nil 时也接受 X-API-Key 请求头里的 API 密钥（给脚本、CI 这类非浏览器的集成用），见 apiKeyAuth；
+// 管理员接口传 nil，只认登录令牌
+// jwtKeys 决定接受哪些签名算法（HS256，或者 RS256 / EdDSA，切换期间两者都接受），见 jwtkeys 包
+// 签名验证通过后再用 sessions 查一次用户：停用、注销的用户的令牌马上失效，角色按数据库里当前的值检查，
+// 不用等访问令牌过期
+func AuthRequired(jwtKeys *jwtkeys.Set, sessions SessionChecker, keys APIKeyAuthenticator) gin.HandlerFunc {
+	// 返回一个闭包（closure），捕获了 jwtKeys 变量
+	// 这样每次请求都可以使用同样的密钥来验证令牌
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && keys != nil {
+			apiKeyAuth(c, keys, key)
+			return
+		}
+
 		// 从请求头获取 Authorization 字段
 		// 标准格式是：Authorization: Bearer <token>
 		// Bearer 是一种认证类型，表示"持有者令牌"
@@ -30,31 +57,27 @@ func AuthRequired(secret []byte) gin.HandlerFunc {
 		// 检查是否以 "Bearer " 开头
 		if !strings.HasPrefix(authz, "Bearer ") {
 			// 没有令牌或格式错误
-			// AbortWithStatusJSON 会终止请求处理，不再调用后续的处理器
+			// Abort 会终止请求处理，不再调用后续的处理器
 			// http.StatusUnauthorized = 401（未授权）
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			httpx.Abort(c, http.StatusUnauthorized, httpx.CodeUnauthorized, "missing bearer token")
 			return
 		}
 
 		// 提取令牌字符串（去掉 "Bearer " 前缀）
 		raw := strings.TrimPrefix(authz, "Bearer ")
 
-		// jwt.ParseWithClaims 解析并验证 JWT
+		// jwtKeys.Parse 解析并验证 JWT
 		// 参数说明：
 		// 1. raw: JWT 字符串
 		// 2. &CustomClaims{}: 用于存储解析结果的结构体
-		// 3. 回调函数：返回用于验证签名的密钥
-		tok, err := jwt.ParseWithClaims(raw, &CustomClaims{}, func(t *jwt.Token) (interface{}, error) {
-			// 这个函数会被 JWT 库调用，用于获取验证密钥
-			// 返回签名时使用的同一个密钥
-			return secret, nil
-		})
+		// 验证密钥按令牌头里的算法和 kid 选，但只接受配置的算法，不接受 alg: none
+		tok, err := jwtKeys.Parse(raw, &CustomClaims{})
 
 		// 检查解析和验证结果
 		// err != nil: 解析失败（格式错误、签名不匹配等）
 		// !tok.Valid: 令牌无效（过期、未生效等）
 		if err != nil || !tok.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			httpx.Abort(c, http.StatusUnauthorized, httpx.CodeUnauthorized, "invalid token")
 			return
 		}
 
@@ -63,17 +86,103 @@ func AuthRequired(secret []byte) gin.HandlerFunc {
 		// ok 表示转换是否成功
 		claims, ok := tok.Claims.(*CustomClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			httpx.Abort(c, http.StatusUnauthorized, httpx.CodeUnauthorized, "invalid token")
+			return
+		}
+
+		// 令牌是真的，但用户可能在签发之后被停用、注销了，或者角色变了
+		// 数据库出错是 500，用户不能用了是 401
+		u, err := sessions.CheckSession(c.Request.Context(), claims.Subject)
+		if err != nil {
+			httpx.ServiceError(c, err)
 			return
 		}
 
 		// 验证通过！将用户信息存入上下文
 		// 后续的处理器可以通过 c.GetString("userID") 获取当前用户的 ID
-		c.Set("userID", claims.Subject) // Subject 存储的是用户 ID
-		c.Set("email", claims.Email)
+		// 邮箱和角色用数据库里当前的值，而不是令牌里签发时的值
+		c.Set("userID", u.ID)
+		c.Set("email", u.Email)
+		c.Set("role", u.Role)
+
+		// 请求 ctx 里的 logger 加上用户 ID，之后这个请求打的日志都能按用户检索
+		ctx := c.Request.Context()
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, logging.FromContext(ctx).With("user_id", claims.Subject)))
 
 		// 继续执行后续的处理器
 		// 此时请求已经通过认证，可以访问受保护的资源
 		c.Next()
 	}
 }
+
+// apiKeyAuth 用 API 密钥认证
+// 密钥代表创建它的用户，但不带系统角色：即使用户是管理员，密钥也过不了 RequireRole
+// 只读密钥只能发 GET / HEAD 请求，其它方法返回 403
+func apiKeyAuth(c *gin.Context, keys APIKeyAuthenticator, raw string) {
+	key, u, err := keys.Authenticate(c.Request.Context(), raw)
+	if err != nil {
+		// 密钥无效是 401，数据库出错是 500
+		httpx.ServiceError(c, err)
+		return
+	}
+	if !key.Allows(c.Request.Method) {
+		httpx.Abort(c, http.StatusForbidden, httpx.CodeForbidden, "api key scope does not allow this request")
+		return
+	}
+
+	c.Set("userID", u.ID)
+	c.Set("email", u.Email)
+	c.Set("apiKeyID", key.ID)
+
+	ctx := c.Request.Context()
+	c.Request = c.Request.WithContext(logging.WithLogger(ctx, logging.FromContext(ctx).With("user_id", u.ID, "api_key_id", key.ID)))
+	c.Next()
+}
+
+// SessionOnly 拒绝用 API 密钥认证的请求，必须放在 AuthRequired 之后
+// 改密码、注销账号、管理 API 密钥这类账号操作只能登录后做：泄露的密钥不能用来给自己再开一个密钥或者锁住账号
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			httpx.Abort(c, http.StatusForbidden, httpx.CodeForbidden, "api keys cannot access this endpoint")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 角色检查中间件
+// 必须放在 AuthRequired 之后使用，它读取 AuthRequired 存进上下文的 role
+// 令牌中的角色属于 roles 之一才放行，否则返回 403
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		// 401 表示"你是谁不知道"，403 表示"知道你是谁，但你没有权限"
+		httpx.Abort(c, http.StatusForbidden, httpx.CodeForbidden, "forbidden")
+	}
+}
+
+// TokenFromQuery 允许用查询参数 ?<param>=<令牌> 代替 Authorization 请求头，必须放在 AuthRequired 前面
+// 浏览器的 WebSocket API 不能自定义请求头，只能把令牌放在 URL 里；只给这类接口用，普通接口仍然只认请求头
+//
+// 令牌转成请求头之后从 URL 里删掉，访问日志和请求抓包记录的查询字符串里就不会出现令牌
+// 已经带了 Authorization 请求头时以请求头为准
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := c.Request.URL.Query()
+		if tok := q.Get(param); tok != "" {
+			if c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+tok)
+			}
+			q.Del(param)
+			c.Request.URL.RawQuery = q.Encode()
+		}
+		c.Next()
+	}
+}
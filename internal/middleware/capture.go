@@ -0,0 +1,94 @@
+// Package middleware 请求抓包中间件
+package middleware
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/capture"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// Capture 请求抓包中间件
+// 管理员打开抓包后（见 http/capture_handler.go），把匹配条件的请求和响应脱敏后存进 rec
+//
+// 没在抓包时直接放行；在抓包时每个请求都要先读出请求体、包装响应，
+// 因为用户 ID 要等 AuthRequired 执行完才知道，只能在请求处理完之后再判断匹不匹配
+// 必须注册在 RequestID 之后
+func Capture(rec *capture.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rec.Active() {
+			c.Next()
+			return
+		}
+		start := time.Now()
+
+		// 和访问日志一样，读出请求体的前一部分，再拼回去交给处理器
+		var reqBody []byte
+		if c.Request.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, capture.MaxBody+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+		}
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		// 抓包接口自己的请求不抓，否则取结果的响应里又套着之前的结果
+		if c.GetBool("captureSkip") || !rec.Matches(c.GetString("userID"), c.FullPath()) {
+			return
+		}
+
+		reqTruncated := len(reqBody) > capture.MaxBody
+		if reqTruncated {
+			reqBody = reqBody[:capture.MaxBody]
+		}
+		rec.Add(capture.Exchange{
+			Time:            model.Timestamp(start),
+			RequestID:       c.GetString("requestID"),
+			UserID:          c.GetString("userID"),
+			ClientIP:        c.ClientIP(),
+			Method:          c.Request.Method,
+			Route:           c.FullPath(),
+			Path:            c.Request.URL.Path,
+			Query:           logging.RedactQuery(c.Request.URL.Query()),
+			RequestHeaders:  capture.Headers(c.Request.Header),
+			RequestBody:     capture.Body(reqBody, reqTruncated),
+			Status:          w.Status(),
+			ResponseHeaders: capture.Headers(w.Header()),
+			ResponseBody:    capture.Body(w.body.Bytes(), w.truncated),
+			LatencyMs:       float64(time.Since(start).Microseconds()) / 1000,
+		})
+	}
+}
+
+// captureWriter 在写响应的同时留一份副本，最多 capture.MaxBody 字节
+type captureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) keep(b []byte) {
+	if room := capture.MaxBody - w.body.Len(); len(b) > room {
+		b = b[:room]
+		w.truncated = true
+	}
+	w.body.Write(b)
+}
@@ -0,0 +1,229 @@
+// Package middleware 接口弃用
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"log/slog"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deprecation 一个接口的弃用说明
+type Deprecation struct {
+	// Since 从什么时候开始弃用
+	Since time.Time
+
+	// Sunset 计划下线的时间，零值表示还没定
+	// 过了这个时间接口不再执行，直接返回 410 Gone
+	Sunset time.Time
+
+	// Successor 替代的接口路径，可以带路由参数，例如 /api/v1/boards/:id/embed-tokens，
+	// 响应头里会换成这次请求的实际值
+	Successor string
+
+	// Doc 迁移说明文档的地址（可选）
+	Doc string
+}
+
+// maxCallersPerRoute 每个弃用接口最多分别统计多少个调用方
+// 超过的调用方只计入 OtherCalls，防止被大量不同 IP 调用时占满内存
+const maxCallersPerRoute = 1000
+
+// DeprecatedRoute 弃用接口的使用情况
+type DeprecatedRoute struct {
+	Method    string           `json:"method"`
+	Route     string           `json:"route"`
+	Since     model.Timestamp  `json:"since"`
+	Sunset    *model.Timestamp `json:"sunset,omitempty"`
+	Successor string           `json:"successor,omitempty"`
+	Doc       string           `json:"doc,omitempty"`
+
+	// Calls 进程启动以来的调用次数（包括下线后被拒绝的），LastCalledAt 最近一次调用的时间
+	Calls        int64            `json:"calls"`
+	LastCalledAt *model.Timestamp `json:"lastCalledAt,omitempty"`
+
+	// Callers 按调用次数从多到少排列的调用方
+	Callers []CallerUsage `json:"callers"`
+
+	// OtherCalls 超过 maxCallersPerRoute 之后的调用方的调用次数之和
+	OtherCalls int64 `json:"otherCalls,omitempty"`
+}
+
+// CallerUsage 一个调用方的使用情况
+// Caller 是 user:<用户 ID>，没有登录的接口是 ip:<客户端 IP>
+type CallerUsage struct {
+	Caller       string          `json:"caller"`
+	Calls        int64           `json:"calls"`
+	LastCalledAt model.Timestamp `json:"lastCalledAt"`
+}
+
+// deprecatedRoutes 所有声明过的弃用接口
+// 和日志设置一样整个进程共用一份：弃用是接口本身的属性，处理器注册路由时声明，不需要从 main 传进来
+var deprecatedRoutes = &deprecationRegistry{}
+
+type deprecationRegistry struct {
+	mu     sync.Mutex
+	routes []*routeUsage
+}
+
+type routeUsage struct {
+	info    DeprecatedRoute
+	callers map[string]*CallerUsage
+}
+
+// Deprecate 注册一个弃用的接口，用法和 rg.Handle 一样：
+//
+//	middleware.Deprecate(rg, http.MethodPost, "/boards/:id/embed-token", middleware.Deprecation{
+//		Since:     time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+//		Sunset:    time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC),
+//		Successor: "/api/v1/boards/:id/embed-tokens",
+//	}, h.createToken)
+//
+// 接口照常工作，但每个响应都带上弃用相关的响应头：
+// - Deprecation: @<Unix 时间戳>（RFC 9745），从什么时候开始弃用
+// - Sunset: <HTTP 日期>（RFC 8594），什么时候下线
+// - Link: <替代接口>; rel="successor-version"，<文档>; rel="deprecation"
+//
+// 每次调用都按调用方计数，管理员通过 GET /admin/deprecations 查看（见 DeprecationReport），
+// 确认没人在用了再删掉；还没有调用过的接口也会列出来，调用次数是 0
+func Deprecate(rg *gin.RouterGroup, method, relativePath string, d Deprecation, handlers ...gin.HandlerFunc) {
+	u := &routeUsage{
+		info: DeprecatedRoute{
+			Method:    method,
+			Route:     joinPath(rg.BasePath(), relativePath),
+			Since:     model.Timestamp(d.Since),
+			Successor: d.Successor,
+			Doc:       d.Doc,
+			Callers:   []CallerUsage{},
+		},
+		callers: make(map[string]*CallerUsage),
+	}
+	if !d.Sunset.IsZero() {
+		u.info.Sunset = model.TimestampPtr(&d.Sunset)
+	}
+
+	deprecatedRoutes.mu.Lock()
+	deprecatedRoutes.routes = append(deprecatedRoutes.routes, u)
+	deprecatedRoutes.mu.Unlock()
+
+	rg.Handle(method, relativePath, append([]gin.HandlerFunc{deprecated(u, d)}, handlers...)...)
+}
+
+// DeprecationReport 所有弃用接口的使用情况，按路由排序
+// 计数保存在进程内存里，重启后清零；多实例部署时要汇总每个实例的结果
+func DeprecationReport() []DeprecatedRoute {
+	deprecatedRoutes.mu.Lock()
+	defer deprecatedRoutes.mu.Unlock()
+
+	out := make([]DeprecatedRoute, 0, len(deprecatedRoutes.routes))
+	for _, u := range deprecatedRoutes.routes {
+		r := u.info
+		r.Callers = make([]CallerUsage, 0, len(u.callers))
+		for _, cu := range u.callers {
+			r.Callers = append(r.Callers, *cu)
+		}
+		sort.Slice(r.Callers, func(i, j int) bool { return r.Callers[i].Calls > r.Callers[j].Calls })
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Route != out[j].Route {
+			return out[i].Route < out[j].Route
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// deprecated 弃用接口前面挂的中间件
+func deprecated(u *routeUsage, d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		caller := KeyByUser(c)
+
+		// 只有每个调用方第一次调用时按 warn 级别记录，之后是 debug，
+		// 否则一个还没迁移的客户端就能刷满日志；完整的计数看使用报告
+		level := slog.LevelDebug
+		if first := u.record(caller, now); first {
+			level = slog.LevelWarn
+		}
+		logging.FromContext(c.Request.Context()).Log(c.Request.Context(), level, "deprecated endpoint called",
+			"method", u.info.Method, "route", u.info.Route, "caller", caller, "ua", c.Request.UserAgent())
+
+		h := c.Writer.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			h.Add("Link", "<"+expandParams(d.Successor, c)+`>; rel="successor-version"`)
+		}
+		if d.Doc != "" {
+			h.Add("Link", "<"+d.Doc+`>; rel="deprecation"`)
+		}
+
+		// 已经过了下线时间：不再执行，告诉客户端去用替代接口
+		if !d.Sunset.IsZero() && now.After(d.Sunset) {
+			msg := "this endpoint was retired on " + d.Sunset.UTC().Format(time.DateOnly)
+			if d.Successor != "" {
+				msg += ", use " + d.Successor
+			}
+			httpx.Abort(c, http.StatusGone, httpx.CodeGone, msg)
+			return
+		}
+		c.Next()
+	}
+}
+
+// record 记一次调用，返回是不是这个调用方第一次调用
+func (u *routeUsage) record(caller string, now time.Time) bool {
+	deprecatedRoutes.mu.Lock()
+	defer deprecatedRoutes.mu.Unlock()
+
+	u.info.Calls++
+	u.info.LastCalledAt = model.TimestampPtr(&now)
+
+	if cu, ok := u.callers[caller]; ok {
+		cu.Calls++
+		cu.LastCalledAt = model.Timestamp(now)
+		return false
+	}
+	if len(u.callers) >= maxCallersPerRoute {
+		u.info.OtherCalls++
+		return false
+	}
+	u.callers[caller] = &CallerUsage{Caller: caller, Calls: 1, LastCalledAt: model.Timestamp(now)}
+	return true
+}
+
+// expandParams 把路径模板里的 :name 换成这次请求的参数值
+func expandParams(route string, c *gin.Context) string {
+	parts := strings.Split(route, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") {
+			if v := c.Param(p[1:]); v != "" {
+				parts[i] = v
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// joinPath 拼接路由组前缀和相对路径，规则和 Gin 注册路由时一样
+func joinPath(base, rel string) string {
+	if rel == "" {
+		return base
+	}
+	p := path.Join(base, rel)
+	if strings.HasSuffix(rel, "/") && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
 	"net/http"
 )
 
@@ -25,12 +26,10 @@ func RecoverJSON() gin.HandlerFunc {
 				// 捕获到 panic！
 				// 不要让程序崩溃，而是返回一个友好的 JSON 错误响应
 
-				// AbortWithStatusJSON 终止请求处理并返回 JSON
+				// Abort 终止请求处理并返回统一格式的错误
 				// http.StatusInternalServerError = 500（服务器内部错误）
-				// gin.H 是 map[string]interface{} 的简写，用于构建 JSON
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "internal server error",
-				})
+				// 响应里带着 requestId，用户反馈问题时凭它就能在日志里找到这次请求
+				httpx.Abort(c, http.StatusInternalServerError, httpx.CodeInternal, "internal server error")
 
 				// 注意：实际生产环境中，应该：
 				// 1. 记录详细的错误日志（包括堆栈信息）
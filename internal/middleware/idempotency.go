@@ -0,0 +1,199 @@
+// Package middleware Idempotency-Key 幂等中间件
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"kanban_api/internal/httpx"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdempotencyKey 幂等键的最大长度
+	maxIdempotencyKey = 255
+
+	// maxIdempotentBody 请求体和响应体的最大长度
+	// 只挂在创建看板、卡片这种请求体很小的接口上，超过的请求直接拒绝，超过的响应不保存
+	maxIdempotentBody = 1 << 20
+
+	// idempotencyLockTimeout 第一次请求处理了多久还没完成就认为它已经失败（例如进程中途退出）
+	// 超过之后同一个键可以重新执行
+	idempotencyLockTimeout = time.Minute
+)
+
+// replayHeaders 重放时原样带上的响应头
+var replayHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotency 幂等中间件，必须注册在 AuthRequired 之后
+//
+// 请求带 Idempotency-Key 请求头时，同一个用户用同一个键重发的请求不再执行，直接返回第一次的响应，
+// 响应头里带 Idempotent-Replayed: true；客户端超时重试不会创建出两个看板或两张卡片
+// 没带这个请求头的请求照常处理
+//
+//   - 同一个键对应的方法、路径和请求体必须一样，不一样返回 422 idempotency_key_reused
+//   - 第一次请求还在处理时重发返回 409，客户端稍后再试
+//   - 5xx 响应不保存，客户端可以用同一个键重试
+//
+// 记录保存 ttl 之后过期，过期之后同一个键当作新的请求处理
+func Idempotency(store repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			httpx.Abort(c, http.StatusBadRequest, httpx.CodeInvalidInput, "Idempotency-Key is too long")
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+			if err != nil {
+				httpx.InvalidBody(c)
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				httpx.Abort(c, http.StatusRequestEntityTooLarge, httpx.CodeTooLarge, "request body is too large")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		userID := c.GetString("userID")
+		fingerprint := idempotencyFingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+		now := time.Now()
+		rec, reserved, err := reserveIdempotencyKey(c.Request.Context(), store, model.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(ttl),
+			CreatedAt:   now,
+		})
+		if err != nil {
+			httpx.ServiceError(c, err)
+			return
+		}
+		if !reserved {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				httpx.Abort(c, http.StatusUnprocessableEntity, httpx.CodeIdempotencyKeyReused, "Idempotency-Key was used with a different request")
+			case rec.Status == 0:
+				c.Header("Retry-After", "1")
+				httpx.Abort(c, http.StatusConflict, httpx.CodeConflict, "a request with this Idempotency-Key is still in progress")
+			default:
+				for k, v := range rec.Headers {
+					c.Header(k, v)
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(rec.Status, rec.Headers["Content-Type"], rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		// 客户端断开之后也要保存或释放，否则这个键会一直处于处理中，直到超时
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := w.Status()
+		if status >= http.StatusInternalServerError || w.truncated {
+			if err := store.Release(ctx, userID, key); err != nil {
+				logging.FromContext(ctx).Warn("release idempotency key", "err", err)
+			}
+			return
+		}
+		headers := make(map[string]string)
+		for _, h := range replayHeaders {
+			if v := w.Header().Get(h); v != "" {
+				headers[h] = v
+			}
+		}
+		if err := store.Complete(ctx, userID, key, status, headers, w.body.Bytes()); err != nil {
+			logging.FromContext(ctx).Warn("save idempotent response", "err", err)
+			_ = store.Release(ctx, userID, key)
+		}
+	}
+}
+
+// reserveIdempotencyKey 占用幂等键
+// 已有的记录处理了超过 idempotencyLockTimeout 还没完成时，释放掉再占用一次
+func reserveIdempotencyKey(ctx context.Context, store repository.IdempotencyRepository, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	got, reserved, err := store.Reserve(ctx, rec)
+	if err != nil || reserved || got.Status != 0 || rec.CreatedAt.Sub(got.CreatedAt) < idempotencyLockTimeout {
+		return got, reserved, err
+	}
+	if err := store.Release(ctx, rec.UserID, rec.Key); err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	return store.Reserve(ctx, rec)
+}
+
+// idempotencyFingerprint 请求的指纹：方法、路径（带查询参数）和请求体的 SHA-256
+// 查询参数也算：同一个键换了 ?template= 就是另一个请求；没有查询参数时和只用路径算出的一样
+func idempotencyFingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PurgeIdempotencyKeys 立即清理一次过期的幂等记录，之后每隔 interval 清理一次，直到 ctx 被取消
+func PurgeIdempotencyKeys(ctx context.Context, store repository.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := store.DeleteExpired(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warn("purge idempotency keys", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("purged idempotency keys", "count", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// idempotencyWriter 在写响应的同时留一份副本，超过 maxIdempotentBody 时不再保存
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *idempotencyWriter) keep(b []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(b) > maxIdempotentBody {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
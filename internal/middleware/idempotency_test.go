@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestIdempotency 同一个键重发返回第一次的响应，不再执行；换了请求体返回 422；
+// 5xx 不保存，可以用同一个键重试；不同用户的同一个键互不影响
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	created, fail := 0, false
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", c.GetHeader("X-User")) })
+	r.POST("/boards", Idempotency(repository.NewMemIdempotencyRepo(), time.Hour), func(c *gin.Context) {
+		if fail {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		created++
+		c.Header("Location", "/boards/"+strconv.Itoa(created))
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+	post := func(user, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/boards", strings.NewReader(body))
+		req.Header.Set("X-User", user)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("u1", "k1", `{"title":"a"}`)
+	again := post("u1", "k1", `{"title":"a"}`)
+	if created != 1 || again.Code != http.StatusCreated || again.Body.String() != first.Body.String() ||
+		again.Header().Get("Location") != "/boards/1" || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: created %d, %d %q %v", created, again.Code, again.Body.String(), again.Header())
+	}
+	if w := post("u1", "k1", `{"title":"b"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key with another body = %d, want 422", w.Code)
+	}
+	if w := post("u2", "k1", `{"title":"a"}`); w.Code != http.StatusCreated || created != 2 {
+		t.Fatalf("same key for another user = %d, created %d", w.Code, created)
+	}
+	post("u1", "", `{}`)
+	if w := post("u1", "", `{}`); w.Code != http.StatusCreated || created != 4 {
+		t.Fatalf("requests without a key should not be deduplicated, created %d", created)
+	}
+
+	fail = true
+	if w := post("u1", "k2", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("failing request = %d", w.Code)
+	}
+	fail = false
+	if w := post("u1", "k2", `{}`); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry after 5xx = %d %v, want a fresh 201", w.Code, w.Header())
+	}
+}
@@ -2,26 +2,46 @@
 package middleware
 
 import (
+	"bytes"
 	"github.com/gin-gonic/gin"
-	"log"
+	"io"
+	"kanban_api/internal/logging"
+	"log/slog"
+	"net/http"
 	"time"
 )
 
+// maxLoggedBody 访问日志最多记录的请求体字节数
+// 超过的请求体被截断后不是合法 JSON，没法脱敏，整个隐藏
+const maxLoggedBody = 4 << 10
+
 // Logger 日志记录中间件
 // 记录每个 HTTP 请求的详细信息
 // 这对于调试、监控、审计都非常重要
-func Logger() gin.HandlerFunc {
+//
+// 除了打访问日志，它还为每个请求派生一个带 request_id 的 logger，放进请求的 ctx：
+// 处理器、服务层用 logging.FromContext(ctx) 打的日志都会自动带上请求 ID
+// 必须注册在 RequestID 之后
+func Logger(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 记录请求开始时间
 		// 用于后续计算请求处理耗时
 		start := time.Now()
 
-		// 收集请求信息
-		path := c.Request.URL.Path           // 请求路径，如 /api/v1/boards
-		raw := c.Request.URL.RawQuery        // 查询参数，如 page=1&size=10
-		method := c.Request.Method           // HTTP 方法，如 GET、POST
-		ip := c.ClientIP()                   // 客户端 IP 地址
-		ua := c.Request.UserAgent()          // User-Agent（浏览器/客户端信息）
+		// 请求级别的 logger，AuthRequired 认证通过后还会再加上 user_id
+		reqLogger := logger.With("request_id", c.GetString("requestID"))
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), reqLogger))
+
+		// 打开了 RequestBodies 开关时先把请求体读出来一份
+		// 请求体只能读一次，读完要拼回去：已经读出的部分加上没读的剩余部分，处理器照常绑定 JSON
+		var body []byte
+		if logging.Current().RequestBodies && c.Request.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+		}
 
 		// 执行下一个中间件/处理器
 		// 注意：这里是分界线！
@@ -30,51 +50,42 @@ func Logger() gin.HandlerFunc {
 		c.Next()
 
 		// 处理器执行完毕，收集响应信息
-		status := c.Writer.Status()          // HTTP 状态码，如 200、404、500
-		latency := time.Since(start)         // 请求处理耗时
-		size := c.Writer.Size()              // 响应体大小（字节）
+		status := c.Writer.Status()
+		attrs := []any{
+			"status", status,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"query", logging.RedactQuery(c.Request.URL.Query()),
+			"ip", c.ClientIP(),
+			"size", c.Writer.Size(),
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ua", c.Request.UserAgent(),
+		}
 
-		// 获取用户 ID（如果已登录）
-		// 从上下文中获取，由认证中间件设置
-		userID := c.GetString("userID")
-		if userID == "" {
-			userID = "-"  // 未登录用 - 表示
+		// 用户 ID 由认证中间件设置，公共接口没有
+		if userID := c.GetString("userID"); userID != "" {
+			attrs = append(attrs, "user_id", userID)
 		}
 
-		// 获取请求 ID
-		reqID := c.GetString("requestID")
-		if reqID == "" {
-			reqID = "-"
+		// 密码、令牌等字段替换成 [REDACTED] 再写进日志
+		if len(body) > 0 {
+			attrs = append(attrs, "body", logging.RedactJSON(body))
 		}
 
-		// 获取错误信息（如果有）
 		// c.Errors 是 Gin 收集的错误列表
-		errMsg := ""
 		if len(c.Errors) > 0 {
-			errMsg = c.Errors.String()
+			attrs = append(attrs, "err", c.Errors.String())
 		}
 
-		// 构建完整的查询字符串
-		q := ""
-		if raw != "" {
-			q = "?" + raw
+		// 按状态码决定级别：5xx 是服务端出错，4xx 是客户端的问题，其余是正常请求
+		// 生产环境把 LOG_LEVEL 设成 warn 就只留下出问题的请求
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
 		}
-
-		// 打印结构化的日志
-		// 使用 key=value 格式，方便日志分析工具解析
-		// 生产环境建议使用专业的日志库（如 zap、logrus）
-		log.Printf(
-			"req_id=%s status=%d method=%s path=%s%s ip=%s user=%s size=%dB latency=%s ua=%q err=%q",
-			reqID,   // 请求 ID
-			status,  // 状态码
-			method,  // HTTP 方法
-			path, q, // 路径和查询参数
-			ip,      // 客户端 IP
-			userID,  // 用户 ID
-			size,    // 响应大小
-			latency, // 耗时
-			ua,      // User-Agent
-			errMsg,  // 错误信息
-		)
+		reqLogger.Log(c.Request.Context(), level, "request", attrs...)
 	}
 }
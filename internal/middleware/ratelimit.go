@@ -0,0 +1,121 @@
+// Package middleware 限流中间件
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/httpx"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit 限流中间件，使用令牌桶算法
+//
+// 每个客户端（由 key 决定，例如 IP 或用户 ID）有一个桶：
+// - 桶里最多装 perMinute 个令牌，一开始是满的
+// - 令牌按每分钟 perMinute 个的速度匀速补充
+// - 每个请求消耗一个令牌，桶空了就返回 429
+//
+// 效果是平均每分钟最多 perMinute 个请求，短时间内可以一次性用完积攒的令牌（突发），
+// 比"每分钟清零一次的计数器"平滑：不会出现窗口边界前后各打满一次的情况
+//
+// 桶保存在进程内存里，多实例部署时每个实例各自计数
+// perMinute <= 0 表示不限流，直接放行
+func RateLimit(perMinute int, key func(*gin.Context) string) gin.HandlerFunc {
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	l := newRateLimiter(perMinute)
+	return func(c *gin.Context) {
+		ok, retryAfter := l.allow(key(c), time.Now())
+		if !ok {
+			// Retry-After 以秒为单位，向上取整：告诉客户端至少等多久才会有新令牌
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			httpx.Abort(c, http.StatusTooManyRequests, httpx.CodeTooManyRequests, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+// KeyByIP 按客户端 IP 限流，未登录的接口使用
+// IP 来自 c.ClientIP()：只有请求来自受信任的代理（main 中的 TRUSTED_PROXIES）时才读 X-Forwarded-For，
+// 否则客户端随便伪造一个请求头就能绕过限流
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按用户限流，必须放在 AuthRequired 之后
+// 同一个用户换 IP 也共用一个桶；没有用户 ID 时退回按 IP
+func KeyByUser(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(c)
+}
+
+// rateLimiter 一组令牌桶
+type rateLimiter struct {
+	capacity float64 // 桶的容量
+	rate     float64 // 每秒补充的令牌数
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket 一个令牌桶
+// 不需要定时器补充令牌：记下上次更新的时间，用到时按经过的时间一次性补上
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// allow 从 key 的桶里取一个令牌
+// 取不到时返回还要等多久才会有一个令牌
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / l.rate
+		return false, time.Duration(wait * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep 每分钟清理一次已经补满的桶
+// 补满的桶和新建的桶没有区别，删掉不影响限流结果，否则访问过的 IP 会一直占着内存
+// 调用方必须持有锁
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package middleware StatsD 请求指标中间件
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"kanban_api/internal/statsd"
+	"strconv"
+	"time"
+)
+
+// StatsD 请求指标中间件
+// 每个请求发两个指标，标签是请求方法、路由模板和状态码：
+// - http.requests: 计数器，算 QPS 和错误率
+// - http.request.duration: 耗时，agent 会算出 p50、p95、p99
+//
+// 和链路追踪一样用路由模板（/api/v1/boards/:id）作标签，不用实际路径，
+// 否则每个看板 ID 都是一个新的标签值，指标数量会无限增长
+//
+// client 为 nil（没有配置 STATSD_ADDR）时直接放行，main 里不需要判断是否注册
+func StatsD(client *statsd.Client) gin.HandlerFunc {
+	if client == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // 没有匹配到路由（404），所有这类请求合成一个标签值
+		}
+		tags := []string{
+			statsd.Tag("method", c.Request.Method),
+			statsd.Tag("route", route),
+			statsd.Tag("status", strconv.Itoa(c.Writer.Status())),
+		}
+		client.Count("http.requests", 1, tags...)
+		client.Timing("http.request.duration", time.Since(start), tags...)
+	}
+}
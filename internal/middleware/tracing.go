@@ -0,0 +1,60 @@
+// Package middleware 链路追踪中间件
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Tracing 链路追踪中间件
+// 为每个请求创建一个 server span，作为这个请求所有子 span（服务方法、SQL）的根
+// 必须注册在 RequestID 之后，才能把请求 ID 记到 span 上，方便从日志跳到链路
+//
+// 没有调用 tracing.Setup（没有配置 OTLP 地址）时，otel 的全局实现是 noop，这个中间件几乎没有开销
+func Tracing() gin.HandlerFunc {
+	tracer := otel.Tracer("kanban_api/internal/middleware")
+	return func(c *gin.Context) {
+		// 上游服务通过 traceparent 请求头传来的链路，从这里接上
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// span 名使用路由模板（/api/v1/boards/:id），而不是实际路径，
+		// 否则每个看板 ID 都会变成一个不同的名字，追踪后端没法聚合
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method // 没有匹配到路由（404）
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("request.id", c.GetString("requestID")),
+			),
+		)
+		defer span.End()
+
+		// 把带 span 的 ctx 放回请求，处理器里的 c.Request.Context() 就是它，
+		// 一路传给服务层和仓储层，后面创建的 span 都会挂在这个 span 下面
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		// 用户 ID 由 AuthRequired 设置，公共接口没有
+		if userID := c.GetString("userID"); userID != "" {
+			span.SetAttributes(attribute.String("enduser.id", userID))
+		}
+		// 按 OpenTelemetry 的约定，server span 只有 5xx 才算错误，4xx 是客户端的问题
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// indexDef 一个期望存在的索引
+type indexDef struct {
+	Name    string
+	Table   string
+	Columns string
+	Unique  bool
+}
+
+// expectedIndexes 所有查询依赖的索引
+// 索引统一在这里定义，而不是分散在各个 row 结构体的 gorm 标签里
+// 新增查询模式时，在这里加一条，再加一个对应的迁移
+var expectedIndexes = []indexDef{
+	{Name: "idx_user_rows_email", Table: "user_rows", Columns: "email", Unique: true},
+	{Name: "idx_board_rows_owner_id", Table: "board_rows", Columns: "owner_id"},
+	{Name: "idx_board_rows_slug", Table: "board_rows", Columns: "slug", Unique: true},
+	{Name: "idx_list_board_position", Table: "list_rows", Columns: "board_id, position"},
+	{Name: "idx_card_list_position", Table: "card_rows", Columns: "list_id, position"},
+	{Name: "idx_card_rows_board_id", Table: "card_rows", Columns: "board_id"},
+	{Name: "idx_embed_token_rows_board_id", Table: "embed_token_rows", Columns: "board_id"},
+	{Name: "idx_refresh_token_rows_token_hash", Table: "refresh_token_rows", Columns: "token_hash", Unique: true},
+	{Name: "idx_refresh_token_rows_family_id", Table: "refresh_token_rows", Columns: "family_id"},
+	{Name: "idx_refresh_token_rows_user_id", Table: "refresh_token_rows", Columns: "user_id"},
+	{Name: "idx_password_reset_rows_token_hash", Table: "password_reset_rows", Columns: "token_hash", Unique: true},
+	{Name: "idx_board_member_rows_user_id", Table: "board_member_rows", Columns: "user_id"},
+	{Name: "idx_webhook_rows_board_id", Table: "webhook_rows", Columns: "board_id"},
+	{Name: "idx_activity_board_seq", Table: "activity_rows", Columns: "board_id, seq"},
+	{Name: "idx_card_rows_reminder", Table: "card_rows", Columns: "reminder, reminded_at"},
+	{Name: "idx_checklist_card_position", Table: "checklist_rows", Columns: "card_id, position"},
+	{Name: "idx_checklist_item_position", Table: "checklist_item_rows", Columns: "checklist_id, position"},
+	{Name: "idx_attachment_rows_card_id", Table: "attachment_rows", Columns: "card_id"},
+	{Name: "idx_attachment_rows_sha256", Table: "attachment_rows", Columns: "sha256"},
+	{Name: "idx_attachment_upload_rows_expires_at", Table: "attachment_upload_rows", Columns: "expires_at"},
+	{Name: "idx_idempotency_key_rows_expires_at", Table: "idempotency_key_rows", Columns: "expires_at"},
+	{Name: "idx_activity_rows_created_at", Table: "activity_rows", Columns: "created_at"},
+	{Name: "idx_login_attempt_rows_created_at", Table: "login_attempt_rows", Columns: "created_at"},
+	{Name: "idx_anomaly_rows_window", Table: "anomaly_rows", Columns: "kind, board_id, subject, window_start", Unique: true},
+	{Name: "idx_card_template_rows_board_id", Table: "card_template_rows", Columns: "board_id"},
+	{Name: "idx_user_identity_rows_subject", Table: "user_identity_rows", Columns: "provider, subject", Unique: true},
+	{Name: "idx_api_key_rows_key_hash", Table: "api_key_rows", Columns: "key_hash", Unique: true},
+	{Name: "idx_api_key_rows_user_id", Table: "api_key_rows", Columns: "user_id"},
+	{Name: "idx_health_check_rows_checked_at", Table: "health_check_rows", Columns: "checked_at"},
+	{Name: "idx_card_link_rows_source_card_id", Table: "card_link_rows", Columns: "source_card_id"},
+	{Name: "idx_card_link_rows_mirror_card_id", Table: "card_link_rows", Columns: "mirror_card_id", Unique: true},
+}
+
+// indexByName 按名字查找索引定义
+func indexByName(name string) indexDef {
+	for _, idx := range expectedIndexes {
+		if idx.Name == name {
+			return idx
+		}
+	}
+	panic("unknown index " + name)
+}
+
+// createIndexes 按名字创建索引（已存在时什么也不做）
+// 这个数据库里没有对应的表时跳过：MySQL 里只有用户和看板表，其它表的索引只在 SQLite 里创建
+// MySQL 不支持 CREATE INDEX IF NOT EXISTS，所以先用 HasIndex 检查
+func createIndexes(db *gorm.DB, names ...string) error {
+	m := db.Migrator()
+	for _, name := range names {
+		idx := indexByName(name)
+		if !m.HasTable(idx.Table) || m.HasIndex(idx.Table, idx.Name) {
+			continue
+		}
+		unique := ""
+		if idx.Unique {
+			unique = "UNIQUE "
+		}
+		sql := fmt.Sprintf("CREATE %sINDEX %s ON %s(%s)", unique, idx.Name, idx.Table, idx.Columns)
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropIndexes 按名字删除索引，createIndexes 的反操作
+func dropIndexes(db *gorm.DB, names ...string) error {
+	m := db.Migrator()
+	for _, name := range names {
+		idx := indexByName(name)
+		if !m.HasTable(idx.Table) || !m.HasIndex(idx.Table, idx.Name) {
+			continue
+		}
+		if err := m.DropIndex(idx.Table, idx.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexMigration 只创建索引的迁移，回滚时删除这些索引
+func indexMigration(id string, names ...string) Migration {
+	return Migration{
+		ID:   id,
+		Up:   func(db *gorm.DB) error { return createIndexes(db, names...) },
+		Down: func(db *gorm.DB) error { return dropIndexes(db, names...) },
+	}
+}
+
+// MissingIndexes 检查期望的索引是否都存在，返回缺失的索引说明
+// 启动时调用，用来发现迁移被跳过或者有人手动删除了索引的情况
+// 只检查这个数据库中存在的表
+func MissingIndexes(db *gorm.DB) []string {
+	var missing []string
+	m := db.Migrator()
+	for _, idx := range expectedIndexes {
+		if m.HasTable(idx.Table) && !m.HasIndex(idx.Table, idx.Name) {
+			missing = append(missing, fmt.Sprintf("%s on %s(%s)", idx.Name, idx.Table, idx.Columns))
+		}
+	}
+	return missing
+}
@@ -0,0 +1,600 @@
+// Package migrations 版本化的数据库迁移
+//
+// 表结构和索引都在这里用固定的 SQL 定义，仓储不再用 AutoMigrate 建表：
+// 每个迁移有一个编号和 Up / Down 两个方向，已经执行过的记录在 schema_migrations 表里
+// 服务启动时执行（或者只检查）还没执行过的迁移，也可以用 `kanban-server migrate` 手动升级和回滚
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// ErrMigrationSkipped 迁移因为数据问题暂时无法执行
+// 被跳过的迁移不会记录为已完成，下次执行迁移时会重新尝试
+var ErrMigrationSkipped = errors.New("migration skipped")
+
+// ErrPending 还有没执行的迁移，Verify 返回
+var ErrPending = errors.New("pending migrations")
+
+// Migration 一次数据库迁移
+// ID 按顺序编号，一旦发布就不要再修改，否则会被当成新的迁移重新执行
+// Up 和 Down 在同一个事务里执行并更新 schema_migrations
+// 注意：MySQL 的 DDL 会隐式提交事务，失败时可能留下一半的修改
+type Migration struct {
+	ID   string
+	Up   func(db *gorm.DB) error
+	Down func(db *gorm.DB) error
+}
+
+// Status 一个迁移的执行状态
+type Status struct {
+	ID string
+
+	// AppliedAt 执行时间，还没执行时为 nil
+	AppliedAt *time.Time
+}
+
+// schemaMigration 记录已经执行过的迁移
+type schemaMigration struct {
+	ID        string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+// all 按顺序执行的迁移列表
+// 同一份列表用于 SQLite 和 MySQL，迁移内部按数据库类型和表是否存在决定具体做什么
+var all = []Migration{
+	{
+		// 0000 建表，原来由各个仓储启动时 AutoMigrate
+		ID:   "0000_baseline_tables",
+		Up:   createTables,
+		Down: dropTables,
+	},
+	// 0001 把原来写在 gorm 标签里的索引改为显式创建
+	// 索引名与之前 AutoMigrate 生成的一致，老数据库上已存在时直接跳过
+	indexMigration("0001_core_indexes",
+		"idx_board_rows_owner_id",
+		"idx_board_rows_slug",
+		"idx_list_board_position",
+		"idx_card_list_position",
+		"idx_card_rows_board_id",
+		"idx_embed_token_rows_board_id",
+	),
+	{
+		// 0002 邮箱唯一索引
+		// 早期版本没有这个约束，库里可能已经有重复的邮箱
+		// 这种情况需要人工处理重复账号，在那之前先跳过，不阻止服务启动
+		ID: "0002_user_email_unique",
+		Up: func(db *gorm.DB) error {
+			var dups []string
+			if err := db.Table("user_rows").Select("email").
+				Group("email").Having("COUNT(*) > 1").Pluck("email", &dups).Error; err != nil {
+				return err
+			}
+			if len(dups) > 0 {
+				return fmt.Errorf("%w: duplicate emails in user_rows: %v", ErrMigrationSkipped, dups)
+			}
+			return createIndexes(db, "idx_user_rows_email")
+		},
+		Down: func(db *gorm.DB) error {
+			return dropIndexes(db, "idx_user_rows_email")
+		},
+	},
+	// 0003 刷新令牌：按哈希查令牌、按家族批量吊销
+	indexMigration("0003_refresh_token_indexes", "idx_refresh_token_rows_token_hash", "idx_refresh_token_rows_family_id"),
+	// 0004 密码重置：按哈希查重置令牌；重置成功后按用户吊销所有刷新令牌
+	indexMigration("0004_password_reset_indexes", "idx_password_reset_rows_token_hash", "idx_refresh_token_rows_user_id"),
+	// 0005 看板成员：按用户列出加入的看板（按看板查询走联合主键）
+	indexMigration("0005_board_member_indexes", "idx_board_member_rows_user_id"),
+	// 0006 webhook：每次投递事件都要按看板查出 webhook
+	indexMigration("0006_webhook_indexes", "idx_webhook_rows_board_id"),
+	// 0007 看板动态：按看板分页，从新到旧
+	indexMigration("0007_activity_indexes", "idx_activity_board_seq"),
+	// 0008 截止日期提醒：每分钟查一次打开了提醒、还没提醒过的卡片，大部分卡片不用看
+	indexMigration("0008_card_reminder_index", "idx_card_rows_reminder"),
+	// 0009 检查清单：按卡片列出清单、按清单列出条目，都按位置排序
+	indexMigration("0009_checklist_indexes", "idx_checklist_card_position", "idx_checklist_item_position"),
+	// 0010 附件：按卡片列出附件；删除卡片时按 blob 分组统计要减少的引用计数
+	indexMigration("0010_attachment_indexes", "idx_attachment_rows_card_id", "idx_attachment_rows_sha256"),
+	{
+		// 0011 附件分块上传（断点续传）；附件只保存在 SQLite 里，MySQL 上什么也不做
+		// expires_at 上的索引给定时清理过期的上传用
+		ID: "0011_attachment_uploads",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, attachmentUploadTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_attachment_upload_rows_expires_at")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, attachmentUploadTable)
+		},
+	},
+	{
+		// 0012 看板附件占用空间的计数器，按现有的附件回填；和附件一样只在 SQLite 里
+		ID: "0012_board_storage",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, boardStorageTable); err != nil {
+				return err
+			}
+			return db.Exec(`INSERT INTO board_storage_rows (board_id, bytes, attachments)
+				SELECT c.board_id, SUM(a.size), COUNT(*) FROM attachment_rows a JOIN card_rows c ON c.id = a.card_id
+				GROUP BY c.board_id`).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, boardStorageTable)
+		},
+	},
+	{
+		// 0013 附件文件的存储类别（普通 / 冷存储 / 恢复中），已有的文件都在普通存储里
+		// class_changed_at 记录最后一次移动的时间，刚恢复的文件过一段时间才会再移到冷存储
+		ID: "0013_attachment_storage_class",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := db.Exec("ALTER TABLE `attachment_blob_rows` ADD COLUMN `storage_class` text NOT NULL DEFAULT 'standard'").Error; err != nil {
+				return err
+			}
+			return db.Exec("ALTER TABLE `attachment_blob_rows` ADD COLUMN `class_changed_at` datetime").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := db.Exec("ALTER TABLE `attachment_blob_rows` DROP COLUMN `class_changed_at`").Error; err != nil {
+				return err
+			}
+			return db.Exec("ALTER TABLE `attachment_blob_rows` DROP COLUMN `storage_class`").Error
+		},
+	},
+	{
+		// 0014 Idempotency-Key 的幂等记录；和附件一样只在 SQLite 里
+		// expires_at 上的索引给定时清理过期的记录用
+		ID: "0014_idempotency_keys",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, idempotencyKeyTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_idempotency_key_rows_expires_at")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, idempotencyKeyTable)
+		},
+	},
+	{
+		// 0015 看板版本号，更新看板时检查版本，防止并发的修改互相覆盖
+		// 看板在 SQLite 和 MySQL 里都可能有，两边都加；已有的看板从 1 开始
+		ID: "0015_board_version",
+		Up: func(db *gorm.DB) error {
+			return db.Exec("ALTER TABLE `board_rows` ADD COLUMN `version` bigint NOT NULL DEFAULT 1").Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Exec("ALTER TABLE `board_rows` DROP COLUMN `version`").Error
+		},
+	},
+	{
+		// 0016 看板每日快照，趋势图直接查这张表；主键 (board_id, day) 已经覆盖按看板查日期范围
+		ID: "0016_board_snapshots",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return createTable(db, boardSnapshotTable)
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, boardSnapshotTable)
+		},
+	},
+	// 0017 异常检测按时间统计看板动态
+	indexMigration("0017_activity_created_at_index", "idx_activity_rows_created_at"),
+	{
+		// 0018 异常检测：登录尝试记录和检测结果
+		// anomaly_rows 上的唯一索引保证同一个窗口里同一条异常只记一次
+		ID: "0018_anomaly_detection",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			for _, t := range []tableDef{loginAttemptTable, anomalyTable} {
+				if err := createTable(db, t); err != nil {
+					return err
+				}
+			}
+			return createIndexes(db, "idx_login_attempt_rows_created_at", "idx_anomaly_rows_window")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := dropTable(db, anomalyTable); err != nil {
+				return err
+			}
+			return dropTable(db, loginAttemptTable)
+		},
+	},
+	{
+		// 0019 管理员停用账号；用户在 SQLite 和 MySQL 里都可能有，两边都加，已有的用户都没有停用
+		ID: "0019_user_disabled_at",
+		Up: func(db *gorm.DB) error {
+			col := "datetime"
+			if isMySQL(db) {
+				col = "datetime(3) NULL"
+			}
+			return db.Exec("ALTER TABLE `user_rows` ADD COLUMN `disabled_at` " + col).Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Exec("ALTER TABLE `user_rows` DROP COLUMN `disabled_at`").Error
+		},
+	},
+	{
+		// 0020 用户注销账号：注销时间和是否把看板转给成员，两边都加；已有的用户都没有注销
+		ID: "0020_user_deleted_at",
+		Up: func(db *gorm.DB) error {
+			deletedAt, transfer := "datetime", "numeric NOT NULL DEFAULT 0"
+			if isMySQL(db) {
+				deletedAt, transfer = "datetime(3) NULL", "boolean NOT NULL DEFAULT false"
+			}
+			if err := db.Exec("ALTER TABLE `user_rows` ADD COLUMN `deleted_at` " + deletedAt).Error; err != nil {
+				return err
+			}
+			return db.Exec("ALTER TABLE `user_rows` ADD COLUMN `transfer_boards` " + transfer).Error
+		},
+		Down: func(db *gorm.DB) error {
+			if err := db.Exec("ALTER TABLE `user_rows` DROP COLUMN `transfer_boards`").Error; err != nil {
+				return err
+			}
+			return db.Exec("ALTER TABLE `user_rows` DROP COLUMN `deleted_at`").Error
+		},
+	},
+	{
+		// 0021 卡片标签，逗号连接保存；卡片只在 SQLite 里，已有的卡片没有标签
+		ID: "0021_card_labels",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `card_rows` ADD COLUMN `labels` text NOT NULL DEFAULT ''").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `card_rows` DROP COLUMN `labels`").Error
+		},
+	},
+	{
+		// 0022 看板的卡片模板；列出模板时按看板查
+		ID: "0022_card_templates",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, cardTemplateTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_card_template_rows_board_id")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, cardTemplateTable)
+		},
+	},
+	{
+		// 0023 示例卡片标记；已有的卡片都不是示例
+		ID: "0023_card_sample",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `card_rows` ADD COLUMN `sample` numeric NOT NULL DEFAULT 0").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `card_rows` DROP COLUMN `sample`").Error
+		},
+	},
+	{
+		// 0024 第三方登录身份；登录时按提供方和提供方的用户 ID 查
+		ID: "0024_user_identities",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, userIdentityTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_user_identity_rows_subject")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, userIdentityTable)
+		},
+	},
+	{
+		// 0025 个人 API 密钥；认证时按哈希查，列出时按用户查
+		ID: "0025_api_keys",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, apiKeyTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_api_key_rows_key_hash", "idx_api_key_rows_user_id")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, apiKeyTable)
+		},
+	},
+	{
+		// 0026 公开状态页：健康探测记录（按时间统计可用率、清理旧记录）和事故公告
+		ID: "0026_status_page",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			for _, t := range []tableDef{healthCheckTable, incidentTable} {
+				if err := createTable(db, t); err != nil {
+					return err
+				}
+			}
+			return createIndexes(db, "idx_health_check_rows_checked_at")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := dropTable(db, incidentTable); err != nil {
+				return err
+			}
+			return dropTable(db, healthCheckTable)
+		},
+	},
+	{
+		// 0027 健康探测记录的读数（例如队列深度）
+		ID: "0027_health_check_value",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `health_check_rows` ADD COLUMN `value` integer").Error
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return db.Exec("ALTER TABLE `health_check_rows` DROP COLUMN `value`").Error
+		},
+	},
+	{
+		// 0028 确认邮箱的时间，两边都加
+		// 已有的用户都当作没确认过：注册时从来没验证过邮箱，之前按邮箱关联的第三方身份不受影响
+		ID: "0028_user_email_verified_at",
+		Up: func(db *gorm.DB) error {
+			col := "datetime"
+			if isMySQL(db) {
+				col = "datetime(3) NULL"
+			}
+			return db.Exec("ALTER TABLE `user_rows` ADD COLUMN `email_verified_at` " + col).Error
+		},
+		Down: func(db *gorm.DB) error {
+			return db.Exec("ALTER TABLE `user_rows` DROP COLUMN `email_verified_at`").Error
+		},
+	},
+	{
+		// 0029 看板按所有者隔离之前建的看板没有 owner_id，谁也看不到
+		// 那时只有一个人在用（所有看板大家共用），都交给最早注册的用户；
+		// 还没有用户时跳过，有人注册之后下次启动再回填
+		ID: "0029_board_owner_backfill",
+		Up: backfillBoardOwners,
+		// 分不清哪些看板是回填的，回滚时保持原样
+		Down: func(db *gorm.DB) error { return nil },
+	},
+	{
+		// 0030 卡片镜像链接；同步时按卡片查它作为原卡片和镜像的链接，一张卡片最多是一个镜像
+		ID: "0030_card_links",
+		Up: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			if err := createTable(db, cardLinkTable); err != nil {
+				return err
+			}
+			return createIndexes(db, "idx_card_link_rows_source_card_id", "idx_card_link_rows_mirror_card_id")
+		},
+		Down: func(db *gorm.DB) error {
+			if isMySQL(db) {
+				return nil
+			}
+			return dropTable(db, cardLinkTable)
+		},
+	},
+}
+
+// backfillBoardOwners 0029 迁移：没有所有者的看板交给最早注册的用户
+func backfillBoardOwners(db *gorm.DB) error {
+	var orphans int64
+	if err := db.Table("board_rows").Where("owner_id IS NULL OR owner_id = ''").Count(&orphans).Error; err != nil {
+		return err
+	}
+	if orphans == 0 {
+		return nil
+	}
+	var owner []string
+	if err := db.Table("user_rows").Order("created_at, id").Limit(1).Pluck("id", &owner).Error; err != nil {
+		return err
+	}
+	if len(owner) == 0 {
+		return fmt.Errorf("%w: %d boards without an owner and no users to assign them to", ErrMigrationSkipped, orphans)
+	}
+	return db.Exec("UPDATE board_rows SET owner_id = ? WHERE owner_id IS NULL OR owner_id = ''", owner[0]).Error
+}
+
+// OpenSQLite 为迁移单独打开一个 SQLite 连接，用完调用 Close
+func OpenSQLite(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{})
+}
+
+// Close 关闭 gorm 底层的连接池
+func Close(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// ensureTable 创建 schema_migrations 表
+func ensureTable(db *gorm.DB) error {
+	ddl := "CREATE TABLE IF NOT EXISTS `schema_migrations` (`id` text,`applied_at` datetime,PRIMARY KEY (`id`))"
+	if isMySQL(db) {
+		ddl = "CREATE TABLE IF NOT EXISTS `schema_migrations` (`id` varchar(191),`applied_at` datetime(3) NULL,PRIMARY KEY (`id`))"
+	}
+	return db.Exec(ddl).Error
+}
+
+// Statuses 按顺序列出所有迁移的执行状态
+// 只读，schema_migrations 表还不存在时所有迁移都是没执行过
+func Statuses(db *gorm.DB) ([]Status, error) {
+	var rows []schemaMigration
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	applied := make(map[string]time.Time, len(rows))
+	for _, r := range rows {
+		applied[r.ID] = r.AppliedAt
+	}
+
+	out := make([]Status, 0, len(all))
+	for _, m := range all {
+		s := Status{ID: m.ID}
+		if at, ok := applied[m.ID]; ok {
+			s.AppliedAt = &at
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Up 按顺序执行还没执行过的迁移，n > 0 时最多执行 n 个
+// 返回值 applied 是这次执行的迁移，warnings 是被跳过的迁移说明，调用方应该打印出来提醒运维
+// 被跳过的迁移不计入 n，后面的迁移照常执行
+func Up(db *gorm.DB, n int) (applied, warnings []string, err error) {
+	if err := ensureTable(db); err != nil {
+		return nil, nil, err
+	}
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, s := range statuses {
+		if s.AppliedAt != nil {
+			continue
+		}
+		if n > 0 && len(applied) >= n {
+			break
+		}
+		m := all[i]
+		// 每个迁移放在一个事务里，失败时不会留下一半的修改
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+		})
+		if errors.Is(err, ErrMigrationSkipped) {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", m.ID, err))
+			continue
+		}
+		if err != nil {
+			return applied, warnings, fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		applied = append(applied, m.ID)
+	}
+	return applied, warnings, nil
+}
+
+// Down 从最后一个开始回滚已经执行过的迁移，最多回滚 n 个（n <= 0 时按 1 处理）
+// 返回值是回滚了的迁移
+// 回滚 0000 会删除所有表和数据，只在开发环境或者确认有备份时使用
+func Down(db *gorm.DB, n int) ([]string, error) {
+	if n <= 0 {
+		n = 1
+	}
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+	var reverted []string
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < n; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		m := all[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{ID: m.ID}).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("rollback %s: %w", m.ID, err)
+		}
+		reverted = append(reverted, m.ID)
+	}
+	return reverted, nil
+}
+
+// Verify 只检查、不执行：还有没执行过的迁移时返回 ErrPending
+// 用于迁移由发布流程单独执行（kanban-server migrate up）、服务启动时不改表结构的部署方式
+func Verify(db *gorm.DB) error {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.ID)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPending, strings.Join(pending, ", "))
+	}
+	return nil
+}
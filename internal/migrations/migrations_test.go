@@ -0,0 +1,207 @@
+package migrations
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(db) })
+	return db
+}
+
+// TestUpDown 空库上全部执行、回滚最后一个、再补上，最后全部回滚把表删光
+func TestUpDown(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Verify(db); !errors.Is(err, ErrPending) {
+		t.Fatalf("verify on empty db: err = %v, want ErrPending", err)
+	}
+	applied, warnings, err := Up(db, 0)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("up: %v, warnings %v", err, warnings)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(all))
+	}
+	if err := Verify(db); err != nil {
+		t.Fatalf("verify after up: %v", err)
+	}
+	if missing := MissingIndexes(db); len(missing) != 0 {
+		t.Fatalf("missing indexes: %v", missing)
+	}
+
+	// 回滚最后一个迁移：Verify 报告它没执行
+	reverted, err := Down(db, 0)
+	if err != nil || len(reverted) != 1 || reverted[0] != all[len(all)-1].ID {
+		t.Fatalf("down = %v, %v", reverted, err)
+	}
+	if err := Verify(db); !errors.Is(err, ErrPending) {
+		t.Fatalf("verify after down: err = %v, want ErrPending", err)
+	}
+	if missing := MissingIndexes(db); len(missing) != 0 {
+		t.Fatalf("missing indexes after down: %v", missing)
+	}
+	if applied, _, err := Up(db, 0); err != nil || len(applied) != 1 {
+		t.Fatalf("up again = %v, %v", applied, err)
+	}
+
+	if _, err := Down(db, len(all)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tbl := range append(sqliteTables, attachmentUploadTable, boardStorageTable, idempotencyKeyTable, boardSnapshotTable, loginAttemptTable, anomalyTable, cardLinkTable) {
+		if db.Migrator().HasTable(tbl.Name) {
+			t.Fatalf("table %s still exists after rolling back everything", tbl.Name)
+		}
+	}
+}
+
+// TestUpLimit up n 只执行前 n 个
+func TestUpLimit(t *testing.T) {
+	db := openTestDB(t)
+
+	applied, _, err := Up(db, 2)
+	if err != nil || len(applied) != 2 || applied[0] != all[0].ID || applied[1] != all[1].ID {
+		t.Fatalf("up 2 = %v, %v", applied, err)
+	}
+	statuses, err := Statuses(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[1].AppliedAt == nil || statuses[2].AppliedAt != nil {
+		t.Fatalf("statuses = %+v", statuses[:3])
+	}
+}
+
+// TestDuplicateEmailsSkipped 已有重复邮箱时跳过唯一索引，其它迁移照常执行，清理之后再补上
+func TestDuplicateEmailsSkipped(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, _, err := Up(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"u1", "u2"} {
+		if err := db.Exec("INSERT INTO user_rows (id, email) VALUES (?, ?)", id, "a@example.com").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	applied, warnings, err := Up(db, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || len(applied) != len(all)-2 {
+		t.Fatalf("applied %v, warnings %v", applied, warnings)
+	}
+	if err := Verify(db); !errors.Is(err, ErrPending) {
+		t.Fatalf("verify: err = %v, want ErrPending", err)
+	}
+
+	if err := db.Exec("DELETE FROM user_rows WHERE id = 'u2'").Error; err != nil {
+		t.Fatal(err)
+	}
+	if applied, warnings, err := Up(db, 0); err != nil || len(warnings) != 0 || len(applied) != 1 {
+		t.Fatalf("up after cleanup = %v, %v, %v", applied, warnings, err)
+	}
+	if err := Verify(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBoardStorageBackfill 0012 按已有的附件回填每个看板的计数器
+func TestBoardStorageBackfill(t *testing.T) {
+	db := openTestDB(t)
+
+	before := 0
+	for i, m := range all {
+		if m.ID == "0012_board_storage" {
+			before = i
+		}
+	}
+	if _, _, err := Up(db, before); err != nil {
+		t.Fatal(err)
+	}
+	for _, sql := range []string{
+		"INSERT INTO card_rows (id, board_id) VALUES ('c1', 'b1'), ('c2', 'b1'), ('c3', 'b2')",
+		"INSERT INTO attachment_rows (id, card_id, size) VALUES ('a1', 'c1', 10), ('a2', 'c2', 5), ('a3', 'c2', 5), ('a4', 'c3', 7)",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := Up(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		BoardID     string
+		Bytes       int64
+		Attachments int64
+	}
+	var rows []row
+	if err := db.Raw("SELECT board_id, bytes, attachments FROM board_storage_rows ORDER BY board_id").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0] != (row{"b1", 20, 3}) || rows[1] != (row{"b2", 7, 1}) {
+		t.Fatalf("rows = %+v", rows)
+	}
+}
+
+// TestLegacyBoardOwners 最早版本建的库：0000 补上缺的列，0029 把没有所有者的看板交给最早注册的用户
+// 还没有用户时跳过 0029，有用户之后再执行
+func TestLegacyBoardOwners(t *testing.T) {
+	db := openTestDB(t)
+
+	for _, sql := range []string{
+		"CREATE TABLE `user_rows` (`id` text,`email` text,`password_hash` text,`created_at` datetime,PRIMARY KEY (`id`))",
+		"CREATE TABLE `board_rows` (`id` text,`title` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`))",
+		"INSERT INTO board_rows (id, title) VALUES ('b1', 'Old'), ('b2', 'Older')",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	applied, warnings, err := Up(db, 0)
+	if err != nil || len(warnings) != 1 || len(applied) != len(all)-1 {
+		t.Fatalf("up without users = %v, %v, %v", applied, warnings, err)
+	}
+
+	for _, sql := range []string{
+		"INSERT INTO user_rows (id, email, created_at) VALUES ('late', 'b@example.com', '2025-02-01'), ('first', 'a@example.com', '2025-01-01')",
+		"INSERT INTO board_rows (id, title, slug, owner_id) VALUES ('b3', 'New', 'b3', 'late')",
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if applied, warnings, err := Up(db, 0); err != nil || len(warnings) != 0 || len(applied) != 1 {
+		t.Fatalf("up with users = %v, %v, %v", applied, warnings, err)
+	}
+
+	type row struct {
+		ID      string
+		OwnerID string
+		Slug    string
+		State   string
+	}
+	var rows []row
+	if err := db.Raw("SELECT id, owner_id, slug, state FROM board_rows ORDER BY id").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []row{{"b1", "first", "b1", "active"}, {"b2", "first", "b2", "active"}, {"b3", "late", "b3", "active"}}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Fatalf("rows = %+v, want %+v", rows, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// tableDef 一张表的建表语句
+// 语句在发布之后就固定下来：改表结构要加新的迁移，不能回头改这里
+type tableDef struct {
+	Name    string
+	Columns string
+}
+
+// sqliteTables SQLite 里的所有表
+// 和原来 AutoMigrate 按 row 结构体生成的表结构一致，老数据库上 CREATE TABLE IF NOT EXISTS 什么也不做
+var sqliteTables = []tableDef{
+	{"user_rows", "`id` text,`email` text,`password_hash` text,`role` text NOT NULL DEFAULT 'user',`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"board_rows", "`id` text,`owner_id` text,`title` text,`slug` text,`state` text DEFAULT 'active',`state_reason` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"},
+	{"board_member_rows", "`board_id` text,`user_id` text,`role` text,`created_at` datetime,PRIMARY KEY (`board_id`,`user_id`)"},
+	{"list_rows", "`id` text,`board_id` text,`title` text,`position` integer,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"},
+	{"card_rows", "`id` text,`board_id` text,`list_id` text,`title` text,`description` text,`position` integer,`due_date` datetime,`reminder` numeric,`reminded_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"},
+	{"checklist_rows", "`id` text,`card_id` text,`title` text,`position` integer,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"},
+	{"checklist_item_rows", "`id` text,`checklist_id` text,`text` text,`done` numeric,`position` integer,`completed_at` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"},
+	{"attachment_rows", "`id` text,`card_id` text,`filename` text,`content_type` text,`size` integer,`sha256` text,`created_by` text,`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"attachment_blob_rows", "`hash` text,`size` integer,`refs` integer,`created_at` datetime,PRIMARY KEY (`hash`)"},
+	{"embed_token_rows", "`id` text,`board_id` text,`created_by` text,`expires_at` datetime,`revoked_at` datetime,`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"refresh_token_rows", "`id` text,`user_id` text,`family_id` text,`token_hash` text,`expires_at` datetime,`revoked_at` datetime,`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"password_reset_rows", "`id` text,`user_id` text,`token_hash` text,`expires_at` datetime,`used_at` datetime,`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"webhook_rows", "`id` text,`board_id` text,`url` text,`events` text,`secret` text,`created_by` text,`last_delivery_at` datetime,`last_status` integer,`last_error` text,`created_at` datetime,PRIMARY KEY (`id`)"},
+	{"activity_rows", "`seq` integer PRIMARY KEY AUTOINCREMENT,`id` text,`board_id` text,`actor_id` text,`action` text,`target_id` text,`before` text,`after` text,`created_at` datetime"},
+}
+
+// attachmentUploadTable 附件分块上传表，0011 新增，只在 SQLite 里
+var attachmentUploadTable = tableDef{"attachment_upload_rows", "`id` text,`card_id` text,`filename` text,`content_type` text,`size` integer,`created_by` text,`expires_at` datetime,`created_at` datetime,PRIMARY KEY (`id`)"}
+
+// boardStorageTable 看板附件占用空间的计数器表，0012 新增，只在 SQLite 里
+var boardStorageTable = tableDef{"board_storage_rows", "`board_id` text,`bytes` integer NOT NULL DEFAULT 0,`attachments` integer NOT NULL DEFAULT 0,PRIMARY KEY (`board_id`)"}
+
+// idempotencyKeyTable 幂等键表，0014 新增，只在 SQLite 里
+var idempotencyKeyTable = tableDef{"idempotency_key_rows", "`user_id` text,`key` text,`fingerprint` text,`status` integer NOT NULL DEFAULT 0,`headers` text,`body` blob,`expires_at` datetime,`created_at` datetime,PRIMARY KEY (`user_id`,`key`)"}
+
+// boardSnapshotTable 看板每日快照表，0016 新增，只在 SQLite 里
+var boardSnapshotTable = tableDef{"board_snapshot_rows", "`board_id` text,`day` text,`cards` integer,`overdue` integer,`members` integer,`lists` text,`taken_at` datetime,PRIMARY KEY (`board_id`,`day`)"}
+
+// loginAttemptTable、anomalyTable 登录尝试记录和异常检测结果，0018 新增，只在 SQLite 里
+var (
+	loginAttemptTable = tableDef{"login_attempt_rows", "`id` integer PRIMARY KEY AUTOINCREMENT,`email` text,`ip` text,`success` numeric,`created_at` datetime"}
+	anomalyTable      = tableDef{"anomaly_rows", "`seq` integer PRIMARY KEY AUTOINCREMENT,`id` text,`kind` text,`board_id` text,`subject` text,`count` integer,`threshold` integer,`window_start` datetime,`window_end` datetime,`created_at` datetime"}
+)
+
+// cardTemplateTable 卡片模板表，0022 新增，只在 SQLite 里
+var cardTemplateTable = tableDef{"card_template_rows", "`id` text,`board_id` text,`name` text,`title` text,`description` text,`labels` text,`checklist` text,`created_by` text,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`id`)"}
+
+// userIdentityTable 第三方登录身份表，0024 新增，只在 SQLite 里（用户在 MySQL 里时也一样，和刷新令牌表相同）
+var userIdentityTable = tableDef{"user_identity_rows", "`id` text,`user_id` text,`provider` text,`subject` text,`email` text,`created_at` datetime,PRIMARY KEY (`id`)"}
+
+// apiKeyTable 个人 API 密钥表，0025 新增，只在 SQLite 里
+var apiKeyTable = tableDef{"api_key_rows", "`id` text,`user_id` text,`name` text,`prefix` text,`scopes` text,`key_hash` text,`expires_at` datetime,`last_used_at` datetime,`revoked_at` datetime,`created_at` datetime,PRIMARY KEY (`id`)"}
+
+// healthCheckTable、incidentTable 健康探测记录和状态页事故公告，0026 新增，只在 SQLite 里
+var (
+	healthCheckTable = tableDef{"health_check_rows", "`id` integer PRIMARY KEY AUTOINCREMENT,`component` text,`ok` numeric,`latency_ms` integer,`checked_at` datetime"}
+	incidentTable    = tableDef{"incident_rows", "`id` text,`message` text,`severity` text,`created_by` text,`created_at` datetime,`resolved_at` datetime,PRIMARY KEY (`id`)"}
+)
+
+// cardLinkTable 卡片镜像链接表，0030 新增，只在 SQLite 里
+var cardLinkTable = tableDef{"card_link_rows", "`id` text,`source_card_id` text,`mirror_card_id` text,`created_by` text,`created_at` datetime,PRIMARY KEY (`id`)"}
+
+// mysqlTables MySQL 里的表，目前只有用户和看板
+// 要建索引的列用 varchar(191)：utf8mb4 下 InnoDB 索引前缀最多 767 字节
+var mysqlTables = []tableDef{
+	{"user_rows", "`id` varchar(191),`email` varchar(191),`password_hash` longtext,`role` varchar(16) NOT NULL DEFAULT 'user',`created_at` datetime(3) NULL,PRIMARY KEY (`id`)"},
+	{"board_rows", "`id` varchar(191),`owner_id` varchar(64),`title` longtext,`slug` varchar(191),`state` varchar(16) DEFAULT 'active',`state_reason` longtext,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`)"},
+}
+
+// tablesFor 按数据库类型返回要建的表
+func tablesFor(db *gorm.DB) []tableDef {
+	if isMySQL(db) {
+		return mysqlTables
+	}
+	return sqliteTables
+}
+
+// createTables 0000 迁移：建表
+// 这个迁移之前表由各个仓储的 AutoMigrate 创建，老数据库上表都已经在了，这里什么也不改
+func createTables(db *gorm.DB) error {
+	for _, t := range tablesFor(db) {
+		if err := createTable(db, t); err != nil {
+			return err
+		}
+	}
+	if err := addLegacyColumns(db); err != nil {
+		return err
+	}
+
+	// 旧版本创建的看板没有 slug，先用 ID 回填，保证唯一
+	// 必须在 0001 创建唯一索引之前完成，否则多个空 slug 会违反唯一约束
+	return db.Exec("UPDATE board_rows SET slug = id WHERE slug IS NULL OR slug = ''").Error
+}
+
+// legacyColumns 最早的 AutoMigrate 版本建的表里没有、后来才加到 row 结构体里的列
+// 这样的老库（例如仓库里的 kanban.db）表已经在了，CREATE TABLE IF NOT EXISTS 不会补列
+var legacyColumns = []struct{ Table, Column, Type string }{
+	{"user_rows", "role", "text NOT NULL DEFAULT 'user'"},
+	{"board_rows", "owner_id", "text"},
+	{"board_rows", "slug", "text"},
+	{"board_rows", "state", "text DEFAULT 'active'"},
+	{"board_rows", "state_reason", "text"},
+}
+
+// addLegacyColumns 给老的 SQLite 库补上 legacyColumns 里缺的列，已经有的什么也不做
+// 补上的 owner_id 是空的，由 0029 迁移回填；MySQL 的表都是迁移建的，不会缺列
+func addLegacyColumns(db *gorm.DB) error {
+	if isMySQL(db) {
+		return nil
+	}
+	m := db.Migrator()
+	for _, c := range legacyColumns {
+		if m.HasColumn(c.Table, c.Column) {
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", c.Table, c.Column, c.Type)).Error; err != nil {
+			return fmt.Errorf("add column %s.%s: %w", c.Table, c.Column, err)
+		}
+	}
+	return nil
+}
+
+// dropTables 0000 迁移的回滚：删除所有表，数据也一起删掉
+func dropTables(db *gorm.DB) error {
+	tables := tablesFor(db)
+	for i := len(tables) - 1; i >= 0; i-- {
+		if err := dropTable(db, tables[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTable 建一张表，已经存在时什么也不做
+func createTable(db *gorm.DB, t tableDef) error {
+	if err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (%s)", t.Name, t.Columns)).Error; err != nil {
+		return fmt.Errorf("create table %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// dropTable 删除一张表和里面的数据
+func dropTable(db *gorm.DB, t tableDef) error {
+	if err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", t.Name)).Error; err != nil {
+		return fmt.Errorf("drop table %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// isMySQL 是否是 MySQL 数据库，其它情况都按 SQLite 处理
+func isMySQL(db *gorm.DB) bool {
+	return db.Dialector.Name() == "mysql"
+}
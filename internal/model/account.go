@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AccountDeletion 注销账号的结果
+type AccountDeletion struct {
+	// DeletedAt 注销的时间
+	DeletedAt time.Time `json:"deletedAt"`
+
+	// PurgeAt 宽限期结束的时间，在这之前可以撤销；之后看板被删除或转给成员，用户被删除
+	PurgeAt time.Time `json:"purgeAt"`
+
+	// TransferBoards 是否把看板转给成员
+	TransferBoards bool `json:"transferBoards"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (d AccountDeletion) MarshalJSON() ([]byte, error) {
+	type plain AccountDeletion
+	return json.Marshal(struct {
+		plain
+		DeletedAt Timestamp `json:"deletedAt"`
+		PurgeAt   Timestamp `json:"purgeAt"`
+	}{plain(d), Timestamp(d.DeletedAt), Timestamp(d.PurgeAt)})
+}
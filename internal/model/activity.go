@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Activity 看板动态（操作记录）中的一条
+// 看板、列表、卡片、成员的每次修改记一条：谁、做了什么、什么时候、改之前和改之后的内容
+type Activity struct {
+	// ID 记录的唯一标识
+	ID string `json:"id"`
+
+	// Seq 写入顺序，只增不减，分页游标用它（见 service.ActivityPage）
+	// 同一毫秒内的多条记录按创建时间排不出先后，所以不用 CreatedAt 分页
+	Seq int64 `json:"-"`
+
+	// BoardID 发生在哪个看板上
+	BoardID string `json:"boardId"`
+
+	// ActorID 操作人的用户 ID，管理员删除看板这类没有具体用户的操作为空
+	ActorID string `json:"actorId,omitempty"`
+
+	// Action 做了什么，和实时推送的事件类型相同，例如 card.updated
+	Action string `json:"action"`
+
+	// TargetID 被操作对象的 ID（看板、列表、卡片的 ID，成员操作是成员的用户 ID）
+	TargetID string `json:"targetId"`
+
+	// Before、After 操作前后对象的 JSON，创建时 Before 为 null，删除时 After 为 null
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+
+	// CreatedAt 操作时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (a Activity) MarshalJSON() ([]byte, error) {
+	type plain Activity
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(a), Timestamp(a.CreatedAt)})
+}
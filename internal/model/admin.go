@@ -0,0 +1,33 @@
+package model
+
+// DayCount 某一天（UTC，格式见 DayLayout）的数量，用于按天统计
+type DayCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// AdminStats 运维接口的汇总统计
+type AdminStats struct {
+	// Users、DisabledUsers 用户总数（包括停用的）和其中停用的用户数
+	Users         int `json:"users"`
+	DisabledUsers int `json:"disabledUsers"`
+
+	// Boards 看板总数
+	Boards int `json:"boards"`
+
+	// Signups 最近几天每天的注册数，从旧到新，没有人注册的日子也列出来，数量是 0
+	Signups []DayCount `json:"signups"`
+}
+
+// BoardOverview 管理员查看任意看板时看到的概况
+type BoardOverview struct {
+	Board Board `json:"board"`
+
+	// Owner 看板所有者，用户已经不存在时为 nil
+	Owner *User `json:"owner"`
+
+	// Members 成员数（不含所有者），Lists、Cards 列表数和卡片数
+	Members int `json:"members"`
+	Lists   int `json:"lists"`
+	Cards   int `json:"cards"`
+}
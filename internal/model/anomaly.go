@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 异常的类别
+const (
+	// AnomalyActivitySpike 看板在一个时间窗口里的操作次数超过阈值
+	AnomalyActivitySpike = "activity_spike"
+
+	// AnomalyMassDeletion 同一个人在一个看板上一个时间窗口里删除的东西（列表、卡片、清单、附件）超过阈值
+	AnomalyMassDeletion = "mass_deletion"
+
+	// AnomalyLoginFailures 同一个邮箱或者同一个 IP 在一个时间窗口里登录失败的次数超过阈值
+	AnomalyLoginFailures = "login_failures"
+)
+
+// Anomaly 异常检测发现的一条异常，给管理员看
+type Anomaly struct {
+	ID string `json:"id"`
+
+	// Kind 类别，见 AnomalyActivitySpike 等
+	Kind string `json:"kind"`
+
+	// BoardID 发生在哪个看板上，登录异常为空
+	BoardID string `json:"boardId,omitempty"`
+
+	// Subject 是谁：大量删除是操作人的用户 ID，登录异常是 "email:<邮箱>" 或 "ip:<IP>"，操作激增为空
+	Subject string `json:"subject,omitempty"`
+
+	// Count 窗口内的次数，Threshold 当时的阈值
+	Count     int `json:"count"`
+	Threshold int `json:"threshold"`
+
+	// WindowStart、WindowEnd 统计的时间窗口 [WindowStart, WindowEnd)
+	// 同一个窗口里同一类别、同一个看板和 Subject 只记一条
+	WindowStart time.Time `json:"windowStart"`
+	WindowEnd   time.Time `json:"windowEnd"`
+
+	// CreatedAt 发现的时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (a Anomaly) MarshalJSON() ([]byte, error) {
+	type plain Anomaly
+	return json.Marshal(struct {
+		plain
+		WindowStart Timestamp `json:"windowStart"`
+		WindowEnd   Timestamp `json:"windowEnd"`
+		CreatedAt   Timestamp `json:"createdAt"`
+	}{plain(a), Timestamp(a.WindowStart), Timestamp(a.WindowEnd), Timestamp(a.CreatedAt)})
+}
+
+// ActivityCount 一个人在一个看板上一段时间内的操作次数，异常检测使用
+type ActivityCount struct {
+	BoardID string
+	ActorID string
+
+	// Total 所有操作的次数
+	Total int
+
+	// Deletes 其中删除操作（*.deleted）的次数
+	Deletes int
+}
+
+// LoginAttempt 一次登录尝试，只在服务端内部使用，异常检测统计登录失败
+type LoginAttempt struct {
+	// Email 登录时填的邮箱（规范化之后），不一定是已注册的用户
+	Email string
+
+	// IP 客户端 IP，拿不到时为空
+	IP string
+
+	// Success 是否登录成功
+	Success bool
+
+	CreatedAt time.Time
+}
+
+// LoginFailureCount 一个邮箱或者一个 IP 一段时间内登录失败的次数
+type LoginFailureCount struct {
+	// Key 统计的对象，"email:<邮箱>" 或 "ip:<IP>"
+	Key string
+
+	Failures int
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// API 密钥的权限范围
+const (
+	// APIKeyScopeRead 只能调用 GET / HEAD 接口
+	APIKeyScopeRead = "read"
+
+	// APIKeyScopeWrite 可以调用所有接口（包括读），和登录后的用户一样
+	APIKeyScopeWrite = "write"
+)
+
+// APIKey 个人 API 密钥，给脚本、CI 这类非浏览器的集成使用
+// 密钥代表创建它的用户，能访问的看板和用户一样，能做什么由 Scopes 限制；
+// 管理员接口、账号相关的接口（改密码、注销、管理 API 密钥）不接受 API 密钥
+// 和刷新令牌一样，数据库里只保存密钥的 SHA-256 哈希，密钥本身只在创建时返回一次
+type APIKey struct {
+	// ID 记录的唯一标识
+	ID string `json:"id"`
+
+	// UserID 创建密钥的用户
+	UserID string `json:"userId"`
+
+	// Name 用户起的名字，例如 "CI"，方便认出是哪个密钥
+	Name string `json:"name"`
+
+	// Prefix 密钥开头的几个字符，列表里用来和手上的密钥对照
+	Prefix string `json:"prefix"`
+
+	// Scopes 权限范围，APIKeyScopeRead 或 APIKeyScopeWrite
+	Scopes []string `json:"scopes"`
+
+	// KeyHash 密钥的 SHA-256 哈希（十六进制）
+	KeyHash string `json:"-"`
+
+	// ExpiresAt 过期时间，nil 表示不过期
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// LastUsedAt 最近一次使用的时间，nil 表示还没用过；为了少写数据库，精确到分钟左右
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
+	// RevokedAt 吊销时间，nil 表示没有吊销；吊销的密钥不再出现在列表里
+	RevokedAt *time.Time `json:"-"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Allows 密钥是否允许这个 HTTP 方法：只读密钥只能 GET / HEAD
+func (k APIKey) Allows(method string) bool {
+	for _, s := range k.Scopes {
+		if s == APIKeyScopeWrite {
+			return true
+		}
+		if s == APIKeyScopeRead && (method == "GET" || method == "HEAD") {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type plain APIKey
+	return json.Marshal(struct {
+		plain
+		ExpiresAt  *Timestamp `json:"expiresAt,omitempty"`
+		LastUsedAt *Timestamp `json:"lastUsedAt,omitempty"`
+		CreatedAt  Timestamp  `json:"createdAt"`
+	}{plain(k), TimestampPtr(k.ExpiresAt), TimestampPtr(k.LastUsedAt), Timestamp(k.CreatedAt)})
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 附件文件的存储类别，见 Attachment.StorageClass
+const (
+	// StorageClassStandard 文件在普通存储里，可以直接下载
+	StorageClassStandard = "standard"
+
+	// StorageClassCold 文件已经移到冷存储（更便宜的目录 / 存储桶），下载时先恢复
+	StorageClassCold = "cold"
+
+	// StorageClassRestoring 文件正在从冷存储恢复，恢复完成后变回 standard
+	StorageClassRestoring = "restoring"
+)
+
+// Attachment 卡片的附件
+// 文件内容按 SHA-256 只保存一份（见 internal/blob），多个附件上传了同样的文件时指向同一个 blob，
+// 删除附件只减少 blob 的引用计数，没有附件再引用时才删除文件
+type Attachment struct {
+	// ID 附件的唯一标识
+	ID string `json:"id"`
+
+	// CardID 附件所属的卡片 ID
+	// 和清单一样不保存看板和列表 ID，卡片移动时附件跟着卡片走
+	CardID string `json:"cardId"`
+
+	// Filename 上传时的文件名，下载时原样放在 Content-Disposition 里
+	Filename string `json:"filename"`
+
+	// ContentType 文件类型，上传时没有给出就按内容猜测
+	ContentType string `json:"contentType"`
+
+	// Size 文件大小（字节）
+	Size int64 `json:"size"`
+
+	// SHA256 文件内容的 SHA-256（十六进制），也是 blob 的 key
+	// 客户端可以用它校验下载的文件，或者判断两个附件是不是同一个文件
+	SHA256 string `json:"sha256"`
+
+	// StorageClass 文件现在在哪里：standard、cold、restoring
+	// 和 SHA256 一样是 blob 的属性，同一个文件的附件存储类别总是相同
+	StorageClass string `json:"storageClass"`
+
+	// CreatedBy 上传者的用户 ID
+	CreatedBy string `json:"createdBy"`
+
+	// CreatedAt 上传时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (a Attachment) MarshalJSON() ([]byte, error) {
+	type plain Attachment
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(a), Timestamp(a.CreatedAt)})
+}
+
+// ResumableUpload 分块上传（断点续传）中的附件
+// 客户端先声明文件名和大小，再分多次把内容传上来，断开之后从 Offset 接着传；
+// 传满 Size 字节之后变成一个普通的 Attachment，这条记录随之删除
+type ResumableUpload struct {
+	// ID 上传的唯一标识
+	ID string `json:"id"`
+
+	// CardID 附件将要挂到的卡片 ID
+	CardID string `json:"cardId"`
+
+	// Filename、ContentType 和一次性上传一样，ContentType 为空时传完再按内容猜测
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// Size 文件的总字节数，开始上传时声明
+	Size int64 `json:"size"`
+
+	// Offset 已经收到的字节数，下一块从这里开始
+	// 不保存在仓储里，以存储里实际写入的字节数为准
+	Offset int64 `json:"offset"`
+
+	// CreatedBy 开始上传的用户 ID，只有这个用户可以继续上传
+	CreatedBy string `json:"createdBy"`
+
+	// ExpiresAt 过期时间，每收到一块顺延；过期之后已经传上来的内容被删除
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// CreatedAt 开始上传的时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (u ResumableUpload) MarshalJSON() ([]byte, error) {
+	type plain ResumableUpload
+	return json.Marshal(struct {
+		plain
+		ExpiresAt Timestamp `json:"expiresAt"`
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(u), Timestamp(u.ExpiresAt), Timestamp(u.CreatedAt)})
+}
+
+// StorageUsage 一组附件占用的空间
+// 按附件累加：同一个文件挂在两张卡片上算两次，和去重之后实际占用的磁盘空间不同
+type StorageUsage struct {
+	// Bytes 附件大小之和（字节）
+	Bytes int64 `json:"bytes"`
+
+	// Attachments 附件个数
+	Attachments int64 `json:"attachments"`
+}
+
+// BoardStorage 看板的附件占用空间和配额
+type BoardStorage struct {
+	// BoardID 看板 ID
+	BoardID string `json:"boardId"`
+
+	StorageUsage
+
+	// Quota 每个看板最多能放多少字节的附件，0 表示不限制
+	Quota int64 `json:"quota"`
+}
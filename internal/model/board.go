@@ -1,7 +1,22 @@
 // Package model 定义看板应用的数据模型
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// 看板状态，只有管理员可以修改（见 service.BoardService.AdminSetBoardState）
+const (
+	// BoardStateActive 正常使用
+	BoardStateActive = "active"
+
+	// BoardStateReadOnly 只读：成员还能查看，但不能修改看板、列表、卡片和成员，例如欠费、归档
+	BoardStateReadOnly = "read_only"
+
+	// BoardStateSuspended 停用：所有人（包括所有者）都不能访问看板内容，例如违规内容等待处理
+	BoardStateSuspended = "suspended"
+)
 
 // Board 看板结构体，代表一个看板（Kanban Board）
 // 每个看板有标题、创建时间和更新时间
@@ -9,9 +24,36 @@ type Board struct {
 	// ID 看板的唯一标识符，使用 UUID 格式
 	ID string `json:"id"`
 
+	// OwnerID 看板所有者的用户 ID
+	// 看板只对所有者可见，其他用户无法查看、修改或删除
+	OwnerID string `json:"ownerId"`
+
 	// Title 看板的标题，例如："我的待办事项"、"项目A任务板"
 	Title string `json:"title"`
 
+	// Slug 看板的短链接标识，由标题生成，全局唯一且只包含 URL 安全字符
+	// 例如标题 "Project Alpha" 对应的 slug 为 "project-alpha"
+	// 可以用 GET /boards/by-slug/:slug 通过 slug 访问看板，链接更易读
+	Slug string `json:"slug"`
+
+	// State 看板状态：active、read_only、suspended
+	State string `json:"state"`
+
+	// StateReason 管理员修改状态时填写的原因，客户端可以显示给成员；正常状态下一般为空
+	StateReason string `json:"stateReason,omitempty"`
+
+	// Version 版本号，创建时是 1，看板每修改一次（标题、slug、状态）加一
+	// GET 返回的 ETag 就是它；更新看板时带上读到的版本，别人先改过时更新会失败，不会悄悄覆盖别人的修改
+	Version int64 `json:"version"`
+
+	// Progress 看板上所有卡片的检查清单合在一起的完成进度
+	// 不存储在看板表里，只有获取单个看板时由服务层从进度缓存里填上（见 service.ProgressRollups），其它地方为 nil
+	Progress *ChecklistProgress `json:"progress,omitempty"`
+
+	// Lists 和看板一起创建的列表
+	// 只有创建看板时带了列表（默认列表或者请求里传的）才填，方便客户端不用再查一次列表，其它地方为 nil
+	Lists []List `json:"lists,omitempty"`
+
 	// CreatedAt 看板的创建时间
 	// 创建时设置一次，之后不再修改
 	CreatedAt time.Time `json:"createdAt"`
@@ -20,3 +62,13 @@ type Board struct {
 	// 每次修改看板信息时都要更新这个字段
 	UpdatedAt time.Time `json:"updatedAt"`
 }
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (b Board) MarshalJSON() ([]byte, error) {
+	type plain Board
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+		UpdatedAt Timestamp `json:"updatedAt"`
+	}{plain(b), Timestamp(b.CreatedAt), Timestamp(b.UpdatedAt)})
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 看板成员的角色
+// owner 就是看板的创建者（Board.OwnerID），不单独保存成员记录
+const (
+	// RoleOwner 所有者：可以做任何操作，包括删除看板和管理成员
+	RoleOwner = "owner"
+
+	// RoleEditor 编辑者：可以查看和修改看板、列表、卡片
+	RoleEditor = "editor"
+
+	// RoleViewer 只读成员：只能查看
+	RoleViewer = "viewer"
+)
+
+// BoardMember 看板成员
+// 看板所有者邀请其他用户加入看板，实现多人协作
+type BoardMember struct {
+	// BoardID 看板 ID
+	BoardID string `json:"boardId"`
+
+	// UserID 成员的用户 ID
+	UserID string `json:"userId"`
+
+	// Email 成员的邮箱，方便客户端展示
+	// 不保存在成员表里，由服务层查询用户后填充
+	Email string `json:"email,omitempty"`
+
+	// Role 角色：owner / editor / viewer
+	Role string `json:"role"`
+
+	// CreatedAt 加入时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (b BoardMember) MarshalJSON() ([]byte, error) {
+	type plain BoardMember
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(b), Timestamp(b.CreatedAt)})
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Card 卡片结构体，代表看板上的一个任务
+// 卡片放在列表中，同一列表内按 Position 从上到下排列
+type Card struct {
+	// ID 卡片的唯一标识符
+	ID string `json:"id"`
+
+	// BoardID 卡片所属的看板 ID
+	// 虽然可以通过列表推算出来，但冗余保存一份，按看板查询卡片时更方便
+	BoardID string `json:"boardId"`
+
+	// ListID 卡片所在的列表 ID
+	ListID string `json:"listId"`
+
+	// Title 卡片标题，例如："修复登录页面的样式问题"
+	Title string `json:"title"`
+
+	// Description 卡片的详细描述，可以为空
+	Description string `json:"description"`
+
+	// Labels 卡片的标签，例如 ["bug", "前端"]，按添加的顺序排列，不区分大小写地去重
+	// 标签是自由填写的文字，没有单独的标签表；没有标签时输出空数组
+	Labels []string `json:"labels"`
+
+	// Position 卡片在列表中的位置，从 0 开始，数字越小越靠上
+	Position int `json:"position"`
+
+	// DueDate 截止日期，nil 表示没有设置
+	// `json:"dueDate"` 没有 omitempty，未设置时输出 null，客户端更容易处理
+	DueDate *time.Time `json:"dueDate"`
+
+	// Reminder 截止日期快到时是否提醒（见 service.Reminders），默认不提醒
+	Reminder bool `json:"reminder"`
+
+	// RemindedAt 已经发出提醒的时间，nil 表示还没提醒过
+	// 修改截止日期后清空，按新的截止日期重新提醒
+	RemindedAt *time.Time `json:"remindedAt"`
+
+	// Sample 是不是创建看板时生成的示例卡片（POST /boards 带 "sample": true）
+	// 示例卡片可以一次清除（DELETE /boards/:id/sample），用户修改过也算示例；普通卡片不输出这个字段
+	Sample bool `json:"sample,omitempty"`
+
+	// Checklists 卡片所有检查清单合在一起的完成进度，没有清单时各项都是 0
+	// 不存储在卡片表里，由服务层返回卡片时填上：看板在进度缓存里时直接读缓存，否则查询计算（见 service.ProgressRollups）
+	Checklists ChecklistProgress `json:"checklists"`
+
+	// CreatedAt 卡片的创建时间
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt 卡片的最后更新时间
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+// 外层的同名字段覆盖 plain 里的 time.Time 字段，其它字段照常输出
+func (c Card) MarshalJSON() ([]byte, error) {
+	type plain Card
+	labels := c.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return json.Marshal(struct {
+		plain
+		Labels     []string   `json:"labels"`
+		DueDate    *Timestamp `json:"dueDate"`
+		RemindedAt *Timestamp `json:"remindedAt"`
+		CreatedAt  Timestamp  `json:"createdAt"`
+		UpdatedAt  Timestamp  `json:"updatedAt"`
+	}{plain(c), labels, TimestampPtr(c.DueDate), TimestampPtr(c.RemindedAt), Timestamp(c.CreatedAt), Timestamp(c.UpdatedAt)})
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CardLink 卡片和它在另一个看板上的镜像之间的链接
+// 镜像是一张普通的卡片，创建时复制原卡片的标题；之后任何一边的标题变了，
+// 或者被移到了另一个列表（状态变了），另一边都跟着改（见 service.MirrorSync）
+type CardLink struct {
+	// ID 链接的唯一标识
+	ID string `json:"id"`
+
+	// SourceCardID 原卡片，MirrorCardID 镜像卡片
+	// 一张原卡片可以有多个镜像，镜像不能再有镜像
+	SourceCardID string `json:"sourceCardId"`
+	MirrorCardID string `json:"mirrorCardId"`
+
+	// SourceBoardID、SourceListID、MirrorBoardID、MirrorListID 两张卡片现在所在的看板和列表
+	// 卡片可以被移动，所以不存储，由服务层返回链接时按卡片当前的位置填上
+	SourceBoardID string `json:"sourceBoardId"`
+	SourceListID  string `json:"sourceListId"`
+	MirrorBoardID string `json:"mirrorBoardId"`
+	MirrorListID  string `json:"mirrorListId"`
+
+	// CreatedBy 创建镜像的用户 ID
+	CreatedBy string `json:"createdBy"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Other 链接另一头的卡片 ID
+func (l CardLink) Other(cardID string) string {
+	if cardID == l.SourceCardID {
+		return l.MirrorCardID
+	}
+	return l.SourceCardID
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (l CardLink) MarshalJSON() ([]byte, error) {
+	type plain CardLink
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(l), Timestamp(l.CreatedAt)})
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CardTemplate 卡片模板，看板上预先定义好的卡片内容
+// 从模板创建卡片时（POST .../cards?template=:tid），标题、描述、标签和检查清单都按模板填好，
+// 例如 "Bug 报告" 模板带着复现步骤的描述骨架、bug 标签和一个 "修复流程" 清单
+type CardTemplate struct {
+	// ID 模板的唯一标识
+	ID string `json:"id"`
+
+	// BoardID 模板属于哪个看板，只能在这个看板上使用
+	BoardID string `json:"boardId"`
+
+	// Name 模板名称，给人在菜单里挑选用，例如："Bug 报告"
+	Name string `json:"name"`
+
+	// Title 卡片标题的模板，可以带占位符（见 service.renderTemplateTitle）：
+	// {{title}} 创建时传的标题，{{date}} 创建当天的日期（UTC，2026-10-16）
+	// 例如 "[Bug] {{title}}"；为空时直接使用创建时传的标题
+	Title string `json:"title"`
+
+	// Description 描述的骨架，原样填进卡片
+	Description string `json:"description"`
+
+	// Labels 默认标签，创建时传的标签追加在后面
+	Labels []string `json:"labels"`
+
+	// Checklist 创建卡片时一起创建的检查清单，nil 表示不创建
+	Checklist *TemplateChecklist `json:"checklist"`
+
+	// CreatedBy 创建者的用户 ID
+	CreatedBy string `json:"createdBy"`
+
+	// CreatedAt、UpdatedAt 创建和最后修改的时间
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TemplateChecklist 模板里的检查清单
+type TemplateChecklist struct {
+	// Title 清单标题
+	Title string `json:"title"`
+
+	// Items 条目内容，按顺序添加，都是未完成
+	Items []string `json:"items"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go；没有标签时输出空数组
+func (t CardTemplate) MarshalJSON() ([]byte, error) {
+	type plain CardTemplate
+	labels := t.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return json.Marshal(struct {
+		plain
+		Labels    []string  `json:"labels"`
+		CreatedAt Timestamp `json:"createdAt"`
+		UpdatedAt Timestamp `json:"updatedAt"`
+	}{plain(t), labels, Timestamp(t.CreatedAt), Timestamp(t.UpdatedAt)})
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Checklist 检查清单，挂在卡片下面，把一个任务拆成几个可以逐个勾选的小步骤
+// 一张卡片可以有多个清单，按 Position 从上到下排列
+type Checklist struct {
+	// ID 清单的唯一标识符
+	ID string `json:"id"`
+
+	// CardID 清单所属的卡片 ID
+	// 不保存看板和列表 ID：卡片可以移动到别的列表、别的看板，清单跟着卡片走
+	CardID string `json:"cardId"`
+
+	// Title 清单标题，例如："上线前检查"
+	Title string `json:"title"`
+
+	// Position 清单在卡片中的位置，从 0 开始，同一卡片内连续
+	Position int `json:"position"`
+
+	// Items 清单中的条目，按 Position 排序
+	Items []ChecklistItem `json:"items"`
+
+	// Progress 这个清单的完成进度，由 Items 计算得出
+	Progress ChecklistProgress `json:"progress"`
+
+	// CreatedAt 清单的创建时间
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt 清单的最后更新时间（重命名、移动位置会更新，条目的修改不会）
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (c Checklist) MarshalJSON() ([]byte, error) {
+	type plain Checklist
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+		UpdatedAt Timestamp `json:"updatedAt"`
+	}{plain(c), Timestamp(c.CreatedAt), Timestamp(c.UpdatedAt)})
+}
+
+// ChecklistItem 清单中的一个条目
+type ChecklistItem struct {
+	// ID 条目的唯一标识符
+	ID string `json:"id"`
+
+	// ChecklistID 条目所属的清单 ID
+	ChecklistID string `json:"checklistId"`
+
+	// Text 条目内容
+	Text string `json:"text"`
+
+	// Done 是否已经完成
+	Done bool `json:"done"`
+
+	// Position 条目在清单中的位置，从 0 开始，同一清单内连续
+	Position int `json:"position"`
+
+	// CompletedAt 勾选完成的时间，没有完成时为 nil；取消勾选会清空
+	CompletedAt *time.Time `json:"completedAt"`
+
+	// CreatedAt 条目的创建时间
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt 条目的最后更新时间
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (i ChecklistItem) MarshalJSON() ([]byte, error) {
+	type plain ChecklistItem
+	return json.Marshal(struct {
+		plain
+		CompletedAt *Timestamp `json:"completedAt"`
+		CreatedAt   Timestamp  `json:"createdAt"`
+		UpdatedAt   Timestamp  `json:"updatedAt"`
+	}{plain(i), TimestampPtr(i.CompletedAt), Timestamp(i.CreatedAt), Timestamp(i.UpdatedAt)})
+}
+
+// ChecklistProgress 清单的完成进度
+// 单个清单上是这个清单的进度，卡片上是卡片所有清单合在一起的进度
+type ChecklistProgress struct {
+	// Done、Total 已完成的条目数和条目总数
+	Done  int `json:"done"`
+	Total int `json:"total"`
+
+	// Percent 完成百分比（0-100，向下取整），没有条目时为 0
+	// 向下取整保证只有全部完成时才显示 100
+	Percent int `json:"percent"`
+}
+
+// NewChecklistProgress 按已完成数和总数计算进度
+func NewChecklistProgress(done, total int) ChecklistProgress {
+	p := ChecklistProgress{Done: done, Total: total}
+	if total > 0 {
+		p.Percent = done * 100 / total
+	}
+	return p
+}
+
+// Add 合并两个进度，卡片汇总多个清单时使用
+func (p ChecklistProgress) Add(o ChecklistProgress) ChecklistProgress {
+	return NewChecklistProgress(p.Done+o.Done, p.Total+o.Total)
+}
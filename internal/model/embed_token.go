@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EmbedToken 看板嵌入令牌
+// 用于把看板以只读方式嵌入到 Wiki、Notion 等第三方页面中
+// 令牌本身是签名的 JWT，这里保存的是它的服务端记录，用于单独吊销
+type EmbedToken struct {
+	// ID 令牌记录的唯一标识，同时写入 JWT 的 jti 字段
+	ID string `json:"id"`
+
+	// BoardID 令牌可以访问的看板 ID
+	BoardID string `json:"boardId"`
+
+	// CreatedBy 签发令牌的用户 ID
+	// 解析令牌时以这个用户的身份读取看板，看板不再属于该用户时令牌自动失效
+	CreatedBy string `json:"createdBy"`
+
+	// ExpiresAt 令牌过期时间，过期后嵌入页面将无法访问
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// RevokedAt 令牌被吊销的时间，nil 表示未吊销
+	// 使用指针类型是为了区分"没有值"和"零值时间"
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+
+	// CreatedAt 令牌创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (e EmbedToken) MarshalJSON() ([]byte, error) {
+	type plain EmbedToken
+	return json.Marshal(struct {
+		plain
+		ExpiresAt Timestamp  `json:"expiresAt"`
+		RevokedAt *Timestamp `json:"revokedAt,omitempty"`
+		CreatedAt Timestamp  `json:"createdAt"`
+	}{plain(e), Timestamp(e.ExpiresAt), TimestampPtr(e.RevokedAt), Timestamp(e.CreatedAt)})
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// IdempotencyRecord 一个幂等键和它第一次请求的响应
+// 客户端带着 Idempotency-Key 重发同一个请求时，直接返回这里保存的响应，不再执行第二次
+// 只在服务端内部使用，不会输出给客户端
+type IdempotencyRecord struct {
+	// UserID、Key 一起确定一条记录：幂等键只在同一个用户内有效
+	UserID string
+	Key    string
+
+	// Fingerprint 第一次请求的方法、路径和请求体的哈希
+	// 同一个键换了请求内容说明客户端用错了，不能返回别的请求的响应
+	Fingerprint string
+
+	// Status 响应状态码，0 表示第一次请求还在处理
+	Status int
+
+	// Headers 需要原样重放的响应头（Content-Type、Location 等）
+	Headers map[string]string
+
+	// Body 响应体
+	Body []byte
+
+	// ExpiresAt 过期时间，过期之后同一个键当作新的请求处理
+	ExpiresAt time.Time
+
+	// CreatedAt 第一次请求的时间
+	CreatedAt time.Time
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// List 列表结构体，代表看板中的一列（例如："待办"、"进行中"、"已完成"）
+// 一个看板包含多个列表，列表按 Position 从左到右排列
+type List struct {
+	// ID 列表的唯一标识符
+	ID string `json:"id"`
+
+	// BoardID 列表所属的看板 ID
+	BoardID string `json:"boardId"`
+
+	// Title 列表标题
+	Title string `json:"title"`
+
+	// Position 列表在看板中的位置，从 0 开始，数字越小越靠左
+	// 同一个看板内的列表位置总是连续的：0, 1, 2, ...
+	Position int `json:"position"`
+
+	// Progress 列表中所有卡片的检查清单合在一起的完成进度
+	// 不存储在列表表里，列表接口返回时由服务层从进度缓存里填上（见 service.ProgressRollups），其它地方为 nil
+	Progress *ChecklistProgress `json:"progress,omitempty"`
+
+	// CreatedAt 列表的创建时间
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt 列表的最后更新时间（重命名、移动位置都会更新）
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (l List) MarshalJSON() ([]byte, error) {
+	type plain List
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+		UpdatedAt Timestamp `json:"updatedAt"`
+	}{plain(l), Timestamp(l.CreatedAt), Timestamp(l.UpdatedAt)})
+}
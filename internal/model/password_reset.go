@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PasswordResetToken 密码重置令牌的服务端记录
+// 用户忘记密码时，服务端生成一个随机令牌通过邮件发给用户，用户凭令牌设置新密码
+// 和刷新令牌一样，数据库里只保存令牌的 SHA-256 哈希
+type PasswordResetToken struct {
+	// ID 记录的唯一标识
+	ID string `json:"id"`
+
+	// UserID 要重置密码的用户
+	UserID string `json:"userId"`
+
+	// TokenHash 令牌字符串的 SHA-256 哈希（十六进制）
+	TokenHash string `json:"-"`
+
+	// ExpiresAt 过期时间，重置令牌有效期很短（例如 30 分钟）
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// UsedAt 使用时间，nil 表示还没用过
+	// 重置令牌只能用一次
+	UsedAt *time.Time `json:"usedAt,omitempty"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (p PasswordResetToken) MarshalJSON() ([]byte, error) {
+	type plain PasswordResetToken
+	return json.Marshal(struct {
+		plain
+		ExpiresAt Timestamp  `json:"expiresAt"`
+		UsedAt    *Timestamp `json:"usedAt,omitempty"`
+		CreatedAt Timestamp  `json:"createdAt"`
+	}{plain(p), Timestamp(p.ExpiresAt), TimestampPtr(p.UsedAt), Timestamp(p.CreatedAt)})
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RefreshToken 刷新令牌的服务端记录
+// 访问令牌（JWT）过期后，客户端用刷新令牌换一对新的令牌，不需要重新输入密码
+// 数据库里只保存令牌的 SHA-256 哈希，数据库泄露也拿不到可用的令牌
+type RefreshToken struct {
+	// ID 记录的唯一标识
+	ID string `json:"id"`
+
+	// UserID 令牌所属用户
+	UserID string `json:"userId"`
+
+	// FamilyID 令牌家族 ID
+	// 同一次登录轮换出来的所有刷新令牌属于同一个家族
+	// 发现旧令牌被重复使用时，整个家族一起吊销（说明令牌可能已经泄露）
+	FamilyID string `json:"familyId"`
+
+	// TokenHash 令牌字符串的 SHA-256 哈希（十六进制）
+	TokenHash string `json:"-"`
+
+	// ExpiresAt 过期时间
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// RevokedAt 吊销时间，nil 表示仍然有效
+	// 令牌被轮换（换成新令牌）或者用户登出时都会被吊销
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (r RefreshToken) MarshalJSON() ([]byte, error) {
+	type plain RefreshToken
+	return json.Marshal(struct {
+		plain
+		ExpiresAt Timestamp  `json:"expiresAt"`
+		RevokedAt *Timestamp `json:"revokedAt,omitempty"`
+		CreatedAt Timestamp  `json:"createdAt"`
+	}{plain(r), Timestamp(r.ExpiresAt), TimestampPtr(r.RevokedAt), Timestamp(r.CreatedAt)})
+}
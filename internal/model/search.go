@@ -0,0 +1,35 @@
+package model
+
+// 搜索结果的类型
+const (
+	// SearchKindBoard 匹配到看板标题
+	SearchKindBoard = "board"
+
+	// SearchKindCard 匹配到卡片标题或描述
+	SearchKindCard = "card"
+)
+
+// SearchHighlight 搜索结果中一处需要高亮的位置
+// Start、End 是字段内的字符偏移（按 Unicode 码点计算，不是字节），区间左闭右开
+type SearchHighlight struct {
+	// Field 所在字段：title 或 description
+	Field string `json:"field"`
+
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchResult 一条搜索结果
+// 客户端根据 Kind 决定如何展示和跳转：看板结果只有 BoardID，卡片结果还带 ListID
+type SearchResult struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	BoardID string `json:"boardId"`
+	ListID  string `json:"listId,omitempty"`
+
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+
+	// Highlights 按字段、位置排序，重叠的区间已合并
+	Highlights []SearchHighlight `json:"highlights"`
+}
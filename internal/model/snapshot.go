@@ -0,0 +1,51 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DayLayout 快照日期的格式，按 UTC 计算的日期，例如 2026-10-16
+const DayLayout = "2006-01-02"
+
+// BoardSnapshot 看板某一天的统计快照，用于画长期的趋势图
+// 每天由定时任务记录一次（见 service.BoardSnapshots），查询趋势时直接读快照，不用翻看板动态
+type BoardSnapshot struct {
+	// BoardID 看板 ID
+	BoardID string `json:"boardId"`
+
+	// Day 日期（UTC），格式见 DayLayout；同一个看板同一天只有一条，当天再记录会覆盖
+	Day string `json:"day"`
+
+	// Cards 看板上的卡片数
+	Cards int `json:"cards"`
+
+	// Overdue 记录时截止日期已经过了的卡片数
+	Overdue int `json:"overdue"`
+
+	// Members 成员数，包括所有者
+	Members int `json:"members"`
+
+	// Lists 每个列表的卡片数，按列表的位置排序；没有卡片的列表也在里面
+	Lists []ListSnapshot `json:"lists"`
+
+	// TakenAt 记录时间
+	TakenAt time.Time `json:"takenAt"`
+}
+
+// ListSnapshot 快照里一个列表的统计
+type ListSnapshot struct {
+	ListID  string `json:"listId"`
+	Title   string `json:"title"`
+	Cards   int    `json:"cards"`
+	Overdue int    `json:"overdue"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (s BoardSnapshot) MarshalJSON() ([]byte, error) {
+	type plain BoardSnapshot
+	return json.Marshal(struct {
+		plain
+		TakenAt Timestamp `json:"takenAt"`
+	}{plain(s), Timestamp(s.TakenAt)})
+}
@@ -0,0 +1,16 @@
+package model
+
+// ListCardCount 一个列表的卡片统计，用于看板指标
+type ListCardCount struct {
+	// BoardID 列表所属的看板
+	BoardID string `json:"boardId"`
+
+	// ListID 列表 ID
+	ListID string `json:"listId"`
+
+	// Cards 列表中的卡片数，也就是这一列的在制品（WIP）数量
+	Cards int `json:"cards"`
+
+	// Overdue 截止日期已经过了的卡片数
+	Overdue int `json:"overdue"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 组件和整体的状态
+const (
+	// StatusOperational 最近一次探测正常
+	StatusOperational = "operational"
+
+	// StatusDegraded 整体状态：有组件最近一次探测失败
+	StatusDegraded = "degraded"
+
+	// StatusDown 组件状态：最近一次探测失败
+	StatusDown = "down"
+
+	// StatusUnknown 还没有探测过，或者最近的探测结果已经过时（探测停了）
+	StatusUnknown = "unknown"
+)
+
+// 事故公告的严重程度
+const (
+	IncidentMinor       = "minor"
+	IncidentMajor       = "major"
+	IncidentMaintenance = "maintenance"
+)
+
+// HealthCheck 一轮健康探测里一个组件的结果
+// 同一轮的各个组件 CheckedAt 相同
+type HealthCheck struct {
+	Component string `json:"component"`
+	OK        bool   `json:"ok"`
+
+	// LatencyMs 探测花了多久（毫秒），存储就是 ping 的耗时
+	LatencyMs int64 `json:"latencyMs"`
+
+	// Value 组件的读数，例如队列里排队的数量；没有读数的组件（存储）为 nil
+	Value *int64 `json:"value,omitempty"`
+
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (h HealthCheck) MarshalJSON() ([]byte, error) {
+	type plain HealthCheck
+	return json.Marshal(struct {
+		plain
+		CheckedAt Timestamp `json:"checkedAt"`
+	}{plain(h), Timestamp(h.CheckedAt)})
+}
+
+// HealthCheckCount 一个组件一段时间内的探测次数和成功次数，计算可用率用
+type HealthCheckCount struct {
+	Component string
+	Total     int
+	OK        int
+}
+
+// Incident 状态页上的事故公告，管理员发布和解除
+type Incident struct {
+	ID string `json:"id"`
+
+	// Message 公告内容，原样显示给用户
+	Message string `json:"message"`
+
+	// Severity 严重程度，见 IncidentMinor 等
+	Severity string `json:"severity"`
+
+	// CreatedBy 发布公告的管理员的用户 ID，不出现在公开的状态页上
+	CreatedBy string `json:"-"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ResolvedAt 解除时间，nil 表示公告还在显示
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (i Incident) MarshalJSON() ([]byte, error) {
+	type plain Incident
+	return json.Marshal(struct {
+		plain
+		CreatedAt  Timestamp  `json:"createdAt"`
+		ResolvedAt *Timestamp `json:"resolvedAt,omitempty"`
+	}{plain(i), Timestamp(i.CreatedAt), TimestampPtr(i.ResolvedAt)})
+}
+
+// ComponentStatus 状态页上一个组件的状态
+type ComponentStatus struct {
+	Name string `json:"name"`
+
+	// Status StatusOperational、StatusDown 或 StatusUnknown
+	Status string `json:"status"`
+
+	// Uptime 最近 24 小时、7 天、30 天的可用率（百分比，两位小数），键是 "24h"、"7d"、"30d"
+	// 这段时间里没有探测记录时为 null
+	Uptime map[string]*float64 `json:"uptime"`
+
+	// SLO 可用率目标和错误预算
+	SLO SLO `json:"slo"`
+}
+
+// SLO 一个组件在滚动窗口里的可用率目标和错误预算
+// 错误预算是窗口里允许失败的探测次数：探测了 N 次、目标 99.9% 时允许失败 N × 0.1% 次
+type SLO struct {
+	// Target 可用率目标（百分比），例如 99.9
+	Target float64 `json:"target"`
+
+	// Window 滚动窗口，例如 "30d"
+	Window string `json:"window"`
+
+	// Availability 窗口里的可用率（百分比，两位小数），没有探测记录时为 null
+	Availability *float64 `json:"availability"`
+
+	// ErrorBudgetRemaining 还剩多少错误预算（百分比，两位小数）：100 是一次都没失败，0 是刚好用完，
+	// 负数是已经超出、没达到目标；没有探测记录时为 null
+	ErrorBudgetRemaining *float64 `json:"errorBudgetRemaining"`
+}
+
+// ServiceStatus 公开状态页的内容
+type ServiceStatus struct {
+	// Status 整体状态：StatusOperational、StatusDegraded 或 StatusUnknown
+	Status string `json:"status"`
+
+	Components []ComponentStatus `json:"components"`
+
+	// Incident 正在显示的事故公告，没有时为 null
+	Incident *Incident `json:"incident"`
+
+	// CheckedAt 最近一轮探测的时间，还没有探测过时为 null
+	CheckedAt *time.Time `json:"checkedAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (s ServiceStatus) MarshalJSON() ([]byte, error) {
+	type plain ServiceStatus
+	return json.Marshal(struct {
+		plain
+		CheckedAt *Timestamp `json:"checkedAt"`
+	}{plain(s), TimestampPtr(s.CheckedAt)})
+}
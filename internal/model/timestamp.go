@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TimeLayout 所有响应里的时间格式：RFC3339，UTC，固定三位毫秒，例如 2026-10-16T08:00:00.000Z
+//
+// time.Time 默认的 JSON 格式带着它自己的时区和去掉末尾 0 的纳秒：
+// 同一个卡片从内存仓储读出来是 +08:00，从 SQLite 读出来可能是 Z，小数位数还时有时无，
+// 客户端没法直接按字符串比较和排序。统一成 UTC 和固定宽度之后，字符串顺序就是时间顺序
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Timestamp 输出到 JSON 的时间
+// 模型里的时间字段仍然是 time.Time，方便比较和计算；只在序列化时（各模型的 MarshalJSON）换成 Timestamp
+type Timestamp time.Time
+
+// MarshalJSON 按 TimeLayout 输出，零值输出 null
+// 零值不是真实的时间（例如还没投递过的 webhook），输出成 0001-01-01T00:00:00Z 容易被客户端当成一个日期显示
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	tt := time.Time(t)
+	if tt.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(tt.UTC().Format(TimeLayout))
+}
+
+// TimestampPtr 可选的时间：nil 还是 nil，配合 omitempty 不输出，没有 omitempty 时输出 null
+func TimestampPtr(t *time.Time) *Timestamp {
+	if t == nil {
+		return nil
+	}
+	ts := Timestamp(*t)
+	return &ts
+}
@@ -0,0 +1,107 @@
+package model_test
+
+import (
+	"encoding/json"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"testing"
+	"time"
+)
+
+// TestTimestampJSON 零值、nil 和真实时间经过各个 MarshalJSON 之后的输出
+// 零值输出 null 而不是 0001-01-01T00:00:00Z，真实时间统一成 UTC 和三位毫秒
+func TestTimestampJSON(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := time.Date(2026, 10, 16, 16, 0, 0, 123456789, shanghai)
+	due := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"zero", model.Timestamp(time.Time{}), `null`},
+		{"real", model.Timestamp(at), `"2026-10-16T08:00:00.123Z"`},
+		{"nil pointer", model.TimestampPtr(nil), `null`},
+		{"pointer", model.TimestampPtr(&due), `"2026-10-17T09:30:00.000Z"`},
+		{"nil pointer with omitempty", struct {
+			At *model.Timestamp `json:"at,omitempty"`
+		}{model.TimestampPtr(nil)}, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTimestampJSONFields 模型和事件里的时间字段，只看时间字段本身，其它字段的顺序和内容不管
+func TestTimestampJSONFields(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := time.Date(2026, 10, 16, 16, 0, 0, 123456789, shanghai)
+	due := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    any
+		want map[string]string
+	}{
+		{"card zero and nil", model.Card{ID: "c1"}, map[string]string{
+			"dueDate": `null`, "remindedAt": `null`, "createdAt": `null`, "updatedAt": `null`,
+		}},
+		{"card real", model.Card{ID: "c1", DueDate: &due, CreatedAt: at, UpdatedAt: at}, map[string]string{
+			"dueDate": `"2026-10-17T09:30:00.000Z"`, "remindedAt": `null`,
+			"createdAt": `"2026-10-16T08:00:00.123Z"`, "updatedAt": `"2026-10-16T08:00:00.123Z"`,
+		}},
+		{"event zero time", events.Event{Type: events.CardCreated, BoardID: "b1"}, map[string]string{
+			"time": `null`, "data": `null`,
+		}},
+		{"event real time", events.Event{Type: events.CardCreated, BoardID: "b1", Time: at}, map[string]string{
+			"time": `"2026-10-16T08:00:00.123Z"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			for k, want := range tt.want {
+				if string(got[k]) != want {
+					t.Errorf("%s = %s, want %s", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+// TestEventDataTimestamps 事件里的对象（Data）同样按 TimeLayout 输出，Timestamp 是通过各模型的 MarshalJSON 生效的
+func TestEventDataTimestamps(t *testing.T) {
+	due := time.Date(2026, 10, 17, 17, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	data, err := json.Marshal(events.Event{Type: events.CardUpdated, BoardID: "b1", Data: model.Card{ID: "c1", DueDate: &due}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"2026-10-17T09:30:00.000Z"`; string(got.Data["dueDate"]) != want {
+		t.Fatalf("data.dueDate = %s, want %s", got.Data["dueDate"], want)
+	}
+	if string(got.Data["createdAt"]) != `null` {
+		t.Fatalf("data.createdAt = %s, want null", got.Data["createdAt"])
+	}
+}
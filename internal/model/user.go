@@ -2,7 +2,21 @@
 // 这一层定义了我们要操作的数据结构，不包含任何业务逻辑
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// 用户的系统角色
+// 注意和看板成员的角色（RoleOwner / RoleEditor / RoleViewer）区分：
+// 系统角色作用于整个应用，看板角色只作用于某个看板
+const (
+	// UserRoleUser 普通用户（默认）
+	UserRoleUser = "user"
+
+	// UserRoleAdmin 管理员：可以查看所有用户、修改用户角色、删除任意看板
+	UserRoleAdmin = "admin"
+)
 
 // User 用户结构体，代表系统中的一个用户
 // 在 Go 中，结构体（struct）类似于其他语言中的类（class）
@@ -20,8 +34,39 @@ type User struct {
 	// `json:"-"` 这个特殊标签表示：在序列化为 JSON 时忽略这个字段，保护用户密码安全
 	PasswordHash string `json:"-"`
 
+	// Role 系统角色：user 或 admin
+	// 登录时写进 JWT，AuthRequired 中间件解析后供 RequireRole 检查
+	Role string `json:"role"`
+
 	// CreatedAt 用户创建时间
 	// time.Time 是 Go 内置的时间类型
 	// `json:"createdAt"` 表示 JSON 中使用驼峰命名
 	CreatedAt time.Time `json:"createdAt"`
+
+	// DisabledAt 被管理员停用的时间，没停用时为 nil
+	// 停用的用户不能登录，也不能用刷新令牌换新令牌
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+
+	// DeletedAt 用户自己注销账号的时间，没注销时为 nil
+	// 注销后有一段宽限期可以撤销，过了宽限期由定时任务处理看板、删除用户（见 service.AccountDeleter）
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+
+	// TransferBoards 注销时选择把看板转给成员而不是删除，只在 DeletedAt 不为 nil 时有意义
+	TransferBoards bool `json:"-"`
+
+	// EmailVerifiedAt 确认邮箱属于这个用户的时间，没确认过时为 nil
+	// 第三方登录用提供方验证过的邮箱创建账号、通过邮件重置过密码时记下；修改邮箱后清空
+	// 第三方登录只会按邮箱关联确认过邮箱的账号（见 service.OAuthService）
+	EmailVerifiedAt *time.Time `json:"-"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return json.Marshal(struct {
+		plain
+		CreatedAt  Timestamp  `json:"createdAt"`
+		DisabledAt *Timestamp `json:"disabledAt,omitempty"`
+		DeletedAt  *Timestamp `json:"deletedAt,omitempty"`
+	}{plain(u), Timestamp(u.CreatedAt), TimestampPtr(u.DisabledAt), TimestampPtr(u.DeletedAt)})
 }
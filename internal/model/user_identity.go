@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// UserIdentity 用户关联的第三方登录身份（Google、GitHub 等）
+// 同一个提供方的同一个身份只能关联一个用户，一个用户可以关联多个提供方
+type UserIdentity struct {
+	// ID 记录的唯一标识
+	ID string `json:"id"`
+
+	// UserID 关联的用户
+	UserID string `json:"userId"`
+
+	// Provider 提供方的名字，例如 google、github
+	Provider string `json:"provider"`
+
+	// Subject 用户在提供方的唯一 ID
+	Subject string `json:"subject"`
+
+	// Email 关联时提供方返回的邮箱，只用于展示；之后用户在提供方改了邮箱，这里不会跟着变
+	Email string `json:"email"`
+
+	// CreatedAt 关联时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (i UserIdentity) MarshalJSON() ([]byte, error) {
+	type plain UserIdentity
+	return json.Marshal(struct {
+		plain
+		CreatedAt Timestamp `json:"createdAt"`
+	}{plain(i), Timestamp(i.CreatedAt)})
+}
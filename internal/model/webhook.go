@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook 看板的出站通知
+// 看板上发生修改时，服务端把事件 POST 到 URL，外部系统（聊天机器人、CI、同步脚本）不用轮询就能知道
+type Webhook struct {
+	// ID webhook 的唯一标识
+	ID string `json:"id"`
+
+	// BoardID 监听哪个看板
+	BoardID string `json:"boardId"`
+
+	// URL 接收事件的地址，http 或 https
+	URL string `json:"url"`
+
+	// Events 只投递这些类型的事件（例如 card.created），为空表示所有事件
+	Events []string `json:"events"`
+
+	// Secret 签名密钥，投递时用它计算 X-Kanban-Signature
+	// 只在创建时返回一次，之后的响应里不出现
+	// HMAC 签名需要原始密钥，所以不能像刷新令牌那样只存哈希
+	Secret string `json:"-"`
+
+	// CreatedBy 创建者的用户 ID
+	CreatedBy string `json:"createdBy"`
+
+	// LastDeliveryAt、LastStatus、LastError 最近一次投递的结果，方便排查对方收不到的问题
+	// LastStatus 是对方返回的 HTTP 状态码，连接失败时为 0
+	LastDeliveryAt *time.Time `json:"lastDeliveryAt,omitempty"`
+	LastStatus     int        `json:"lastStatus,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+
+	// CreatedAt 创建时间
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Wants 是否要投递这种类型的事件
+func (w Webhook) Wants(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, t := range w.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON 时间字段按 TimeLayout 输出（UTC），见 timestamp.go
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type plain Webhook
+	return json.Marshal(struct {
+		plain
+		LastDeliveryAt *Timestamp `json:"lastDeliveryAt,omitempty"`
+		CreatedAt      Timestamp  `json:"createdAt"`
+	}{plain(w), TimestampPtr(w.LastDeliveryAt), Timestamp(w.CreatedAt)})
+}
@@ -0,0 +1,179 @@
+// Package oauth 第三方登录（OAuth 2.0 授权码流程）
+//
+// 流程：
+//  1. 把浏览器重定向到提供方的授权页（Provider.AuthCodeURL），带上随机的 state
+//  2. 用户同意后提供方带着 code 和 state 重定向回我们的回调地址
+//  3. 回调里用 code 换访问令牌，再用访问令牌读取用户的 ID 和邮箱（Provider.Exchange）
+//
+// 只用标准库实现，不依赖 golang.org/x/oauth2；业务代码只依赖 Provider 接口
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Identity 提供方返回的用户身份
+type Identity struct {
+	// Subject 用户在提供方的唯一 ID（Google 的 sub、GitHub 的数字 ID），不会变
+	Subject string
+
+	// Email 用户的邮箱，已经转成小写
+	Email string
+
+	// EmailVerified 提供方是否验证过这个邮箱，只有验证过的邮箱才能用来关联已有账号
+	EmailVerified bool
+}
+
+// Provider 一个第三方登录提供方
+type Provider interface {
+	// Name 提供方的名字，出现在路由里：/auth/oauth/:provider
+	Name() string
+
+	// AuthCodeURL 授权页地址，state 原样带回回调地址
+	AuthCodeURL(state string) string
+
+	// Exchange 用回调收到的 code 换访问令牌，再读取用户身份
+	Exchange(ctx context.Context, code string) (Identity, error)
+}
+
+// Config 一个提供方的配置
+type Config struct {
+	// ClientID、ClientSecret 在提供方的开发者后台注册应用时拿到
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL 回调地址，必须和注册应用时填的一致
+	RedirectURL string
+
+	// AuthURL、TokenURL、UserURL 提供方的接口地址，为空时用默认值；测试时指向本地的假服务器
+	AuthURL  string
+	TokenURL string
+	UserURL  string
+}
+
+// FromEnv 从环境变量读取配置，返回启用了的提供方（按名字索引），都没配置时返回空 map
+// - OAUTH_REDIRECT_BASE: 本服务对外的地址（默认 http://localhost:8080），回调地址是 {OAUTH_REDIRECT_BASE}/api/v1/auth/oauth/{provider}/callback
+// - OAUTH_GOOGLE_CLIENT_ID / OAUTH_GOOGLE_CLIENT_SECRET: 设置了 ID 就启用 Google 登录
+// - OAUTH_GITHUB_CLIENT_ID / OAUTH_GITHUB_CLIENT_SECRET: 设置了 ID 就启用 GitHub 登录
+func FromEnv() (map[string]Provider, error) {
+	base := strings.TrimRight(os.Getenv("OAUTH_REDIRECT_BASE"), "/")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	providers := map[string]Provider{}
+	for _, p := range []struct {
+		name string
+		env  string
+		new  func(Config) Provider
+	}{
+		{"google", "GOOGLE", NewGoogle},
+		{"github", "GITHUB", NewGitHub},
+	} {
+		cfg := Config{
+			ClientID:     os.Getenv("OAUTH_" + p.env + "_CLIENT_ID"),
+			ClientSecret: os.Getenv("OAUTH_" + p.env + "_CLIENT_SECRET"),
+			RedirectURL:  base + "/api/v1/auth/oauth/" + p.name + "/callback",
+		}
+		if cfg.ClientID == "" {
+			continue
+		}
+		if cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("OAUTH_%s_CLIENT_SECRET is required", p.env)
+		}
+		providers[p.name] = p.new(cfg)
+	}
+	return providers, nil
+}
+
+// client 访问提供方接口用的 HTTP 客户端
+// 回调请求在等这些调用，提供方卡住时不能一直等下去
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// maxResponseBytes 提供方响应体最多读多少字节
+const maxResponseBytes = 1 << 20
+
+// authCodeURL 拼授权页地址
+func authCodeURL(authURL string, cfg Config, scope, state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {cfg.ClientID},
+		"redirect_uri":  {cfg.RedirectURL},
+		"scope":         {scope},
+		"state":         {state},
+	}
+	return authURL + "?" + q.Encode()
+}
+
+// exchangeCode 用授权码换访问令牌
+// 请求体是表单，要求返回 JSON（GitHub 默认返回表单格式，要加 Accept 头）
+func exchangeCode(ctx context.Context, tokenURL string, cfg Config, code string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	// 授权码无效时 Google 返回 400，GitHub 返回 200 加 error 字段，两种都按错误处理
+	if err := do(req, &body); err != nil && body.Error == "" {
+		return "", err
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token exchange: %s %s", body.Error, body.Description)
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("token exchange: no access token in response")
+	}
+	return body.AccessToken, nil
+}
+
+// getJSON 带着访问令牌 GET 一个接口，把 JSON 响应解析到 out
+func getJSON(ctx context.Context, u, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return do(req, out)
+}
+
+// do 发送请求并解析 JSON 响应；非 2xx 也尽量解析（错误信息在响应体里），同时返回错误
+func do(req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(data, out)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: status %d", req.Method, req.URL.Host+req.URL.Path, resp.StatusCode)
+	}
+	return decodeErr
+}
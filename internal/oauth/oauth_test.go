@@ -0,0 +1,64 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestGitHubExchange 用授权码换令牌，读用户 ID 和主邮箱；无效的授权码返回错误
+func TestGitHubExchange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_secret") != "secret" || r.Form.Get("redirect_uri") != "http://app.test/callback" {
+			t.Errorf("token request form = %v", r.Form)
+		}
+		// 和 GitHub 一样，授权码无效时也返回 200
+		if r.Form.Get("code") != "good" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at"})
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": 42, "email": null}`))
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"email": "work@example.com", "primary": false, "verified": true},
+			{"email": "Me@Example.com", "primary": true, "verified": true}]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := NewGitHub(Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.test/callback",
+		AuthURL:      srv.URL + "/authorize",
+		TokenURL:     srv.URL + "/token",
+		UserURL:      srv.URL + "/user",
+	})
+	u, err := url.Parse(p.AuthCodeURL("st"))
+	if err != nil || u.Query().Get("state") != "st" || u.Query().Get("client_id") != "id" {
+		t.Fatalf("auth url = %v, %v", u, err)
+	}
+
+	id, err := p.Exchange(context.Background(), "good")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != (Identity{Subject: "42", Email: "me@example.com", EmailVerified: true}) {
+		t.Fatalf("identity = %+v", id)
+	}
+	if _, err := p.Exchange(context.Background(), "bad"); err == nil {
+		t.Fatal("exchange with bad code succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ========== Google ==========
+
+// google 用 OpenID Connect 的 userinfo 接口读取身份
+type google struct {
+	cfg Config
+}
+
+// NewGoogle 创建 Google 登录提供方
+func NewGoogle(cfg Config) Provider {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://oauth2.googleapis.com/token"
+	}
+	if cfg.UserURL == "" {
+		cfg.UserURL = "https://openidconnect.googleapis.com/v1/userinfo"
+	}
+	return &google{cfg: cfg}
+}
+
+func (g *google) Name() string { return "google" }
+
+func (g *google) AuthCodeURL(state string) string {
+	return authCodeURL(g.cfg.AuthURL, g.cfg, "openid email", state)
+}
+
+func (g *google) Exchange(ctx context.Context, code string) (Identity, error) {
+	token, err := exchangeCode(ctx, g.cfg.TokenURL, g.cfg, code)
+	if err != nil {
+		return Identity{}, err
+	}
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := getJSON(ctx, g.cfg.UserURL, token, &info); err != nil {
+		return Identity{}, err
+	}
+	if info.Sub == "" {
+		return Identity{}, errors.New("google: no subject in userinfo")
+	}
+	return Identity{Subject: info.Sub, Email: strings.ToLower(info.Email), EmailVerified: info.EmailVerified}, nil
+}
+
+// ========== GitHub ==========
+
+// github 用户资料里的 email 是用户选择公开的邮箱，可能为空，也不说明是否验证过，
+// 所以另外读 /user/emails，取主邮箱和它的验证状态
+type github struct {
+	cfg Config
+}
+
+// NewGitHub 创建 GitHub 登录提供方
+// UserURL 是 /user 接口，邮箱列表在它下面的 /emails
+func NewGitHub(cfg Config) Provider {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = "https://github.com/login/oauth/authorize"
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = "https://github.com/login/oauth/access_token"
+	}
+	if cfg.UserURL == "" {
+		cfg.UserURL = "https://api.github.com/user"
+	}
+	return &github{cfg: cfg}
+}
+
+func (g *github) Name() string { return "github" }
+
+func (g *github) AuthCodeURL(state string) string {
+	return authCodeURL(g.cfg.AuthURL, g.cfg, "user:email", state)
+}
+
+func (g *github) Exchange(ctx context.Context, code string) (Identity, error) {
+	token, err := exchangeCode(ctx, g.cfg.TokenURL, g.cfg, code)
+	if err != nil {
+		return Identity{}, err
+	}
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	if err := getJSON(ctx, g.cfg.UserURL, token, &user); err != nil {
+		return Identity{}, err
+	}
+	if user.ID == 0 {
+		return Identity{}, errors.New("github: no id in user")
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, g.cfg.UserURL+"/emails", token, &emails); err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Subject: strconv.FormatInt(user.ID, 10)}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email, id.EmailVerified = strings.ToLower(e.Email), e.Verified
+		}
+	}
+	return id, nil
+}
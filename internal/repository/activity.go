@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActivityRepository 看板动态仓储接口
+// 记录只追加，不修改；看板被删除时整个看板的记录一起删除
+type ActivityRepository interface {
+	// Create 追加一条记录，ID、Seq 和创建时间由仓储生成
+	Create(ctx context.Context, a model.Activity) (model.Activity, error)
+
+	// ListByBoard 按 Seq 从新到旧列出看板的记录，最多 limit 条
+	// beforeSeq > 0 时只返回 Seq 小于它的记录（下一页）
+	ListByBoard(ctx context.Context, boardID string, beforeSeq int64, limit int) ([]model.Activity, error)
+
+	// DeleteByBoard 删除看板的所有记录
+	DeleteByBoard(ctx context.Context, boardID string) error
+
+	// CountByActor 按看板和操作人统计 [from, to) 之间的操作次数和其中的删除次数，异常检测使用
+	CountByActor(ctx context.Context, from, to time.Time) ([]model.ActivityCount, error)
+}
+
+// memActivityRepo 看板动态仓储的内存实现
+// 记录按 Seq 递增的顺序追加在切片末尾，倒着扫描就是从新到旧
+type memActivityRepo struct {
+	mu      sync.RWMutex
+	seq     int64
+	entries []model.Activity
+}
+
+// NewMemActivityRepo 创建一个新的内存看板动态仓储
+func NewMemActivityRepo() ActivityRepository {
+	return &memActivityRepo{}
+}
+
+// Create 追加记录
+func (r *memActivityRepo) Create(ctx context.Context, a model.Activity) (model.Activity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	a.ID = generateID()
+	a.Seq = r.seq
+	a.CreatedAt = time.Now()
+	r.entries = append(r.entries, a)
+	return a, nil
+}
+
+// ListByBoard 列出看板的记录
+func (r *memActivityRepo) ListByBoard(ctx context.Context, boardID string, beforeSeq int64, limit int) ([]model.Activity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Activity, 0)
+	for i := len(r.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		a := r.entries[i]
+		if a.BoardID != boardID || (beforeSeq > 0 && a.Seq >= beforeSeq) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// DeleteByBoard 删除看板的所有记录
+func (r *memActivityRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.entries[:0]
+	for _, a := range r.entries {
+		if a.BoardID != boardID {
+			kept = append(kept, a)
+		}
+	}
+	r.entries = kept
+	return nil
+}
+
+// CountByActor 按看板和操作人统计
+func (r *memActivityRepo) CountByActor(ctx context.Context, from, to time.Time) ([]model.ActivityCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := make(map[[2]string]int)
+	var out []model.ActivityCount
+	for _, a := range r.entries {
+		if a.CreatedAt.Before(from) || !a.CreatedAt.Before(to) {
+			continue
+		}
+		key := [2]string{a.BoardID, a.ActorID}
+		i, ok := idx[key]
+		if !ok {
+			i = len(out)
+			idx[key] = i
+			out = append(out, model.ActivityCount{BoardID: a.BoardID, ActorID: a.ActorID})
+		}
+		out[i].Total++
+		if strings.HasSuffix(a.Action, ".deleted") {
+			out[i].Deletes++
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteActivityRepo 是 ActivityRepository 的 SQLite 实现
+type sqliteActivityRepo struct {
+	db *gorm.DB
+}
+
+// activityRow 看板动态表结构
+// (board_id, seq) 上的索引见 internal/migrations
+type activityRow struct {
+	// Seq 自增主键，就是写入顺序
+	Seq      int64 `gorm:"primaryKey;autoIncrement"`
+	ID       string
+	BoardID  string
+	ActorID  string
+	Action   string
+	TargetID string
+
+	// Before、After 原样保存 JSON 文本，空串表示 null
+	Before string
+	After  string
+
+	CreatedAt time.Time
+}
+
+// NewSQLiteActivityRepo 创建一个新的 SQLite 看板动态仓储
+func NewSQLiteActivityRepo(db *gorm.DB) (ActivityRepository, error) {
+	return &sqliteActivityRepo{db: db}, nil
+}
+
+func (r *sqliteActivityRepo) toModel(row activityRow) model.Activity {
+	return model.Activity{
+		ID:        row.ID,
+		Seq:       row.Seq,
+		BoardID:   row.BoardID,
+		ActorID:   row.ActorID,
+		Action:    row.Action,
+		TargetID:  row.TargetID,
+		Before:    rawJSON(row.Before),
+		After:     rawJSON(row.After),
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// rawJSON 把保存的 JSON 文本还原成 json.RawMessage，空串还原成 nil（输出 null）
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+func (r *sqliteActivityRepo) Create(ctx context.Context, a model.Activity) (model.Activity, error) {
+	row := activityRow{
+		ID:        generateID(),
+		BoardID:   a.BoardID,
+		ActorID:   a.ActorID,
+		Action:    a.Action,
+		TargetID:  a.TargetID,
+		Before:    string(a.Before),
+		After:     string(a.After),
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return model.Activity{}, err
+	}
+	return r.toModel(row), nil
+}
+
+func (r *sqliteActivityRepo) ListByBoard(ctx context.Context, boardID string, beforeSeq int64, limit int) ([]model.Activity, error) {
+	q := r.db.WithContext(ctx).Where("board_id = ?", boardID)
+	if beforeSeq > 0 {
+		q = q.Where("seq < ?", beforeSeq)
+	}
+	var rows []activityRow
+	if err := q.Order("seq desc").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Activity, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, r.toModel(row))
+	}
+	return out, nil
+}
+
+func (r *sqliteActivityRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Where("board_id = ?", boardID).Delete(&activityRow{}).Error
+}
+
+// CountByActor 相当于 SQL:
+//
+//	SELECT board_id, actor_id, COUNT(*), SUM(action LIKE '%.deleted') FROM activity_rows
+//	WHERE created_at >= ? AND created_at < ? GROUP BY board_id, actor_id
+//
+// created_at 上的索引见 internal/migrations
+func (r *sqliteActivityRepo) CountByActor(ctx context.Context, from, to time.Time) ([]model.ActivityCount, error) {
+	var out []model.ActivityCount
+	err := r.db.WithContext(ctx).Model(&activityRow{}).
+		Select("board_id, actor_id, COUNT(*) AS total, SUM(CASE WHEN action LIKE '%.deleted' THEN 1 ELSE 0 END) AS deletes").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("board_id, actor_id").Scan(&out).Error
+	return out, err
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteActivityRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// AnomalyRepository 异常检测结果仓储接口
+type AnomalyRepository interface {
+	// Create 保存一条异常，ID 和 CreatedAt 由仓储生成
+	// 同一个窗口里同一类别、同一个看板和 Subject 已经有一条时不保存，返回 false：
+	// 多个实例同时检测，或者重启后重新检测同一个窗口，都只记一次
+	Create(ctx context.Context, a model.Anomaly) (model.Anomaly, bool, error)
+
+	// List 从新到旧列出最近的 limit 条异常
+	List(ctx context.Context, limit int) ([]model.Anomaly, error)
+}
+
+// memAnomalyRepo 异常检测结果仓储的内存实现，按写入顺序追加
+type memAnomalyRepo struct {
+	mu        sync.RWMutex
+	anomalies []model.Anomaly
+}
+
+// NewMemAnomalyRepo 创建一个新的内存异常检测结果仓储
+func NewMemAnomalyRepo() AnomalyRepository {
+	return &memAnomalyRepo{}
+}
+
+func (r *memAnomalyRepo) Create(ctx context.Context, a model.Anomaly) (model.Anomaly, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cur := range r.anomalies {
+		if cur.Kind == a.Kind && cur.BoardID == a.BoardID && cur.Subject == a.Subject && cur.WindowStart.Equal(a.WindowStart) {
+			return cur, false, nil
+		}
+	}
+	a.ID = generateID()
+	a.CreatedAt = time.Now()
+	r.anomalies = append(r.anomalies, a)
+	return a, true, nil
+}
+
+func (r *memAnomalyRepo) List(ctx context.Context, limit int) ([]model.Anomaly, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Anomaly, 0)
+	for i := len(r.anomalies) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, r.anomalies[i])
+	}
+	return out, nil
+}
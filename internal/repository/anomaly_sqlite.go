@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteAnomalyRepo 是 AnomalyRepository 的 SQLite 实现
+type sqliteAnomalyRepo struct {
+	db *gorm.DB
+}
+
+// anomalyRow 异常表结构
+// (kind, board_id, subject, window_start) 上的唯一索引负责去重，见 internal/migrations
+type anomalyRow struct {
+	Seq         int64 `gorm:"primaryKey;autoIncrement"`
+	ID          string
+	Kind        string
+	BoardID     string
+	Subject     string
+	Count       int
+	Threshold   int
+	WindowStart time.Time
+	WindowEnd   time.Time
+	CreatedAt   time.Time
+}
+
+// NewSQLiteAnomalyRepo 创建一个新的 SQLite 异常检测结果仓储
+func NewSQLiteAnomalyRepo(db *gorm.DB) (AnomalyRepository, error) {
+	return &sqliteAnomalyRepo{db: db}, nil
+}
+
+func (r *sqliteAnomalyRepo) toModel(row anomalyRow) model.Anomaly {
+	return model.Anomaly{
+		ID:          row.ID,
+		Kind:        row.Kind,
+		BoardID:     row.BoardID,
+		Subject:     row.Subject,
+		Count:       row.Count,
+		Threshold:   row.Threshold,
+		WindowStart: row.WindowStart,
+		WindowEnd:   row.WindowEnd,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+// Create 相当于 SQL: INSERT ... ON CONFLICT DO NOTHING，没有插入说明别人已经记过了
+func (r *sqliteAnomalyRepo) Create(ctx context.Context, a model.Anomaly) (model.Anomaly, bool, error) {
+	row := anomalyRow{
+		ID:          generateID(),
+		Kind:        a.Kind,
+		BoardID:     a.BoardID,
+		Subject:     a.Subject,
+		Count:       a.Count,
+		Threshold:   a.Threshold,
+		WindowStart: a.WindowStart,
+		WindowEnd:   a.WindowEnd,
+		CreatedAt:   time.Now(),
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if res.Error != nil {
+		return model.Anomaly{}, false, res.Error
+	}
+	return r.toModel(row), res.RowsAffected > 0, nil
+}
+
+func (r *sqliteAnomalyRepo) List(ctx context.Context, limit int) ([]model.Anomaly, error) {
+	var rows []anomalyRow
+	if err := r.db.WithContext(ctx).Order("seq desc").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Anomaly, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, r.toModel(row))
+	}
+	return out, nil
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteAnomalyRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// APIKeyRepository API 密钥仓储接口
+// 只保存密钥的哈希，不保存密钥本身
+type APIKeyRepository interface {
+	// Create 保存一个新密钥，ID 和创建时间由仓储生成
+	Create(ctx context.Context, k model.APIKey) (model.APIKey, error)
+
+	// GetByHash 通过密钥哈希查询，吊销了的也能查到（调用方检查 RevokedAt）
+	GetByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+
+	// ListByUser 列出用户没有吊销的密钥，新的在前
+	ListByUser(ctx context.Context, userID string) ([]model.APIKey, error)
+
+	// Revoke 吊销用户的某个密钥，不存在、不属于这个用户或者已经吊销时返回 ErrNotFound
+	Revoke(ctx context.Context, userID, id string) error
+
+	// Touch 记下密钥最近一次使用的时间
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// memAPIKeyRepo API 密钥仓储的内存实现
+type memAPIKeyRepo struct {
+	mu   sync.RWMutex
+	keys map[string]model.APIKey
+}
+
+// NewMemAPIKeyRepo 创建一个新的内存 API 密钥仓储
+func NewMemAPIKeyRepo() APIKeyRepository {
+	return &memAPIKeyRepo{keys: make(map[string]model.APIKey)}
+}
+
+func (r *memAPIKeyRepo) Create(ctx context.Context, k model.APIKey) (model.APIKey, error) {
+	k.ID = generateID()
+	k.CreatedAt = time.Now()
+	k.Scopes = append([]string(nil), k.Scopes...)
+
+	r.mu.Lock()
+	r.keys[k.ID] = k
+	r.mu.Unlock()
+	return k, nil
+}
+
+func (r *memAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			return k, nil
+		}
+	}
+	return model.APIKey{}, ErrNotFound
+}
+
+func (r *memAPIKeyRepo) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := []model.APIKey{}
+	for _, k := range r.keys {
+		if k.UserID == userID && k.RevokedAt == nil {
+			out = append(out, k)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memAPIKeyRepo) Revoke(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.keys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	r.keys[id] = k
+	return nil
+}
+
+func (r *memAPIKeyRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if k, ok := r.keys[id]; ok {
+		k.LastUsedAt = &at
+		r.keys[id] = k
+	}
+	return nil
+}
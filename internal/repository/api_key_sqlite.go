@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"strings"
+	"time"
+)
+
+// sqliteAPIKeyRepo 是 APIKeyRepository 的 SQLite 实现
+type sqliteAPIKeyRepo struct {
+	db *gorm.DB
+}
+
+// apiKeyRow API 密钥表结构
+// key_hash 有唯一索引，user_id 有普通索引（见 internal/migrations）
+type apiKeyRow struct {
+	ID     string `gorm:"primaryKey"`
+	UserID string
+	Name   string
+	Prefix string
+
+	// Scopes 和卡片标签一样用逗号连接保存
+	Scopes string
+
+	KeyHash    string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// NewSQLiteAPIKeyRepo 创建一个新的 SQLite API 密钥仓储
+func NewSQLiteAPIKeyRepo(db *gorm.DB) (APIKeyRepository, error) {
+	return &sqliteAPIKeyRepo{db: db}, nil
+}
+
+func (r *sqliteAPIKeyRepo) toModel(row apiKeyRow) model.APIKey {
+	return model.APIKey{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		Scopes:     splitLabels(row.Scopes),
+		KeyHash:    row.KeyHash,
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		RevokedAt:  row.RevokedAt,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+func (r *sqliteAPIKeyRepo) Create(ctx context.Context, k model.APIKey) (model.APIKey, error) {
+	rw := apiKeyRow{
+		ID:        generateID(),
+		UserID:    k.UserID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    strings.Join(k.Scopes, ","),
+		KeyHash:   k.KeyHash,
+		ExpiresAt: k.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.APIKey{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	var rw apiKeyRow
+	if err := r.db.WithContext(ctx).First(&rw, "key_hash = ?", keyHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.APIKey{}, ErrNotFound
+		}
+		return model.APIKey{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteAPIKeyRepo) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	var rows []apiKeyRow
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.APIKey, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Revoke 吊销密钥
+// 条件里带上 user_id 和 revoked_at IS NULL，用受影响行数判断密钥是否存在、是否属于这个用户
+func (r *sqliteAPIKeyRepo) Revoke(ctx context.Context, userID, id string) error {
+	res := r.db.WithContext(ctx).Model(&apiKeyRow{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteAPIKeyRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&apiKeyRow{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteAPIKeyRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
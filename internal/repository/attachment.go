@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AttachmentRepository 附件仓储接口
+// 同时维护 blob 的引用计数：每条附件记录引用一个 blob（按 SHA256），
+// 创建、删除附件时在同一个事务里增减计数，计数降到 0 的 blob 由 UnreferencedBlobs 列出来，交给调用方删除文件
+type AttachmentRepository interface {
+	// Create 保存附件记录，并把 a.SHA256 对应的 blob 引用计数加一（blob 记录不存在时创建）
+	// ID 和创建时间由仓储生成
+	Create(ctx context.Context, a model.Attachment) (model.Attachment, error)
+
+	// Get 获取卡片的某个附件
+	Get(ctx context.Context, cardID, id string) (model.Attachment, error)
+
+	// ListByCard 列出卡片的所有附件，先上传的在前
+	ListByCard(ctx context.Context, cardID string) ([]model.Attachment, error)
+
+	// Delete 删除卡片的某个附件并减少 blob 的引用计数，不存在时返回 ErrNotFound
+	Delete(ctx context.Context, cardID, id string) error
+
+	// DeleteByCards 删除这些卡片的所有附件，删除卡片、列表、看板时使用
+	DeleteByCards(ctx context.Context, cardIDs []string) error
+
+	// UsageByCards 这些卡片的附件个数和大小之和，重算看板占用空间、跨看板移动卡片时使用
+	UsageByCards(ctx context.Context, cardIDs []string) (model.StorageUsage, error)
+
+	// UnreferencedBlobs 引用计数已经降到 0 的 blob
+	UnreferencedBlobs(ctx context.Context) ([]string, error)
+
+	// DeleteBlob 删除 blob 记录，只在引用计数仍然是 0 时删除，返回是否删除了
+	// 调用方删除了记录之后才能删除文件
+	DeleteBlob(ctx context.Context, hash string) (bool, error)
+
+	// ArchivableBlobs 可以移到冷存储的 blob：只被 cardIDs 这些卡片上的附件引用、现在在普通存储里，
+	// 并且 before 之后没有改变过存储类别（刚恢复回来的文件不会马上又被移走）
+	ArchivableBlobs(ctx context.Context, cardIDs []string, before time.Time) ([]string, error)
+
+	// SetBlobClass 把 blob 的存储类别从 from 改成 to，返回是否改了；现在不是 from 时什么也不做
+	SetBlobClass(ctx context.Context, hash, from, to string) (bool, error)
+
+	// CreateUpload 保存一个分块上传，ID 和创建时间由仓储生成
+	// 已经收到的内容在 blob 存储里，这里只有元数据，Offset 不保存
+	CreateUpload(ctx context.Context, u model.ResumableUpload) (model.ResumableUpload, error)
+
+	// GetUpload 获取卡片的某个分块上传
+	GetUpload(ctx context.Context, cardID, id string) (model.ResumableUpload, error)
+
+	// TouchUpload 把分块上传的过期时间改成 expiresAt，不存在时返回 ErrNotFound
+	TouchUpload(ctx context.Context, id string, expiresAt time.Time) error
+
+	// DeleteUpload 删除分块上传的记录，不存在时不报错
+	DeleteUpload(ctx context.Context, id string) error
+
+	// ExpiredUploads 过期时间早于 now 的分块上传 ID
+	ExpiredUploads(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// memAttachmentRepo 附件仓储的内存实现
+type memAttachmentRepo struct {
+	mu          sync.RWMutex
+	attachments map[string]model.Attachment
+	refs        map[string]int // blob 哈希 -> 引用计数
+	classes     map[string]memBlobClass
+	uploads     map[string]model.ResumableUpload
+}
+
+// memBlobClass blob 的存储类别和最后一次改变的时间（从来没变过时是创建时间）
+type memBlobClass struct {
+	class     string
+	changedAt time.Time
+}
+
+// NewMemAttachmentRepo 创建一个新的内存附件仓储
+func NewMemAttachmentRepo() AttachmentRepository {
+	return &memAttachmentRepo{
+		attachments: make(map[string]model.Attachment),
+		refs:        make(map[string]int),
+		classes:     make(map[string]memBlobClass),
+		uploads:     make(map[string]model.ResumableUpload),
+	}
+}
+
+// Create 保存附件
+func (r *memAttachmentRepo) Create(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	a.ID = generateID()
+	a.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.classes[a.SHA256]; !ok {
+		r.classes[a.SHA256] = memBlobClass{class: model.StorageClassStandard, changedAt: a.CreatedAt}
+	}
+	r.attachments[a.ID] = a
+	r.refs[a.SHA256]++
+	return r.withClass(a), nil
+}
+
+// withClass 填上附件的存储类别，调用方持有锁
+func (r *memAttachmentRepo) withClass(a model.Attachment) model.Attachment {
+	a.StorageClass = r.classes[a.SHA256].class
+	return a
+}
+
+// Get 获取附件
+func (r *memAttachmentRepo) Get(ctx context.Context, cardID, id string) (model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.attachments[id]
+	if !ok || a.CardID != cardID {
+		return model.Attachment{}, ErrNotFound
+	}
+	return r.withClass(a), nil
+}
+
+// ListByCard 列出卡片的附件
+func (r *memAttachmentRepo) ListByCard(ctx context.Context, cardID string) ([]model.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Attachment, 0)
+	for _, a := range r.attachments {
+		if a.CardID == cardID {
+			out = append(out, r.withClass(a))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// Delete 删除附件
+func (r *memAttachmentRepo) Delete(ctx context.Context, cardID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.attachments[id]
+	if !ok || a.CardID != cardID {
+		return ErrNotFound
+	}
+	r.remove(a)
+	return nil
+}
+
+// DeleteByCards 删除这些卡片的所有附件
+func (r *memAttachmentRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	set := make(map[string]struct{}, len(cardIDs))
+	for _, id := range cardIDs {
+		set[id] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.attachments {
+		if _, ok := set[a.CardID]; ok {
+			r.remove(a)
+		}
+	}
+	return nil
+}
+
+// UsageByCards 这些卡片的附件个数和大小之和
+func (r *memAttachmentRepo) UsageByCards(ctx context.Context, cardIDs []string) (model.StorageUsage, error) {
+	set := make(map[string]struct{}, len(cardIDs))
+	for _, id := range cardIDs {
+		set[id] = struct{}{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var u model.StorageUsage
+	for _, a := range r.attachments {
+		if _, ok := set[a.CardID]; ok {
+			u.Bytes += a.Size
+			u.Attachments++
+		}
+	}
+	return u, nil
+}
+
+// remove 删除附件并减少引用计数，调用方持有写锁
+// 计数降到 0 时保留在 refs 里，等 DeleteBlob 删除
+func (r *memAttachmentRepo) remove(a model.Attachment) {
+	delete(r.attachments, a.ID)
+	r.refs[a.SHA256]--
+}
+
+// UnreferencedBlobs 引用计数为 0 的 blob
+func (r *memAttachmentRepo) UnreferencedBlobs(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]string, 0)
+	for hash, n := range r.refs {
+		if n <= 0 {
+			out = append(out, hash)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// DeleteBlob 删除引用计数为 0 的 blob 记录
+func (r *memAttachmentRepo) DeleteBlob(ctx context.Context, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.refs[hash]
+	if !ok || n > 0 {
+		return false, nil
+	}
+	delete(r.refs, hash)
+	delete(r.classes, hash)
+	return true, nil
+}
+
+// ArchivableBlobs 数出这些卡片上每个 blob 被引用了几次，等于引用计数的就是只被这些卡片引用的
+func (r *memAttachmentRepo) ArchivableBlobs(ctx context.Context, cardIDs []string, before time.Time) ([]string, error) {
+	set := make(map[string]struct{}, len(cardIDs))
+	for _, id := range cardIDs {
+		set[id] = struct{}{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, a := range r.attachments {
+		if _, ok := set[a.CardID]; ok {
+			counts[a.SHA256]++
+		}
+	}
+	out := make([]string, 0)
+	for hash, n := range counts {
+		c := r.classes[hash]
+		if n == r.refs[hash] && c.class == model.StorageClassStandard && c.changedAt.Before(before) {
+			out = append(out, hash)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// SetBlobClass 修改 blob 的存储类别
+func (r *memAttachmentRepo) SetBlobClass(ctx context.Context, hash, from, to string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.classes[hash]
+	if !ok || c.class != from {
+		return false, nil
+	}
+	r.classes[hash] = memBlobClass{class: to, changedAt: time.Now()}
+	return true, nil
+}
+
+// CreateUpload 保存分块上传
+func (r *memAttachmentRepo) CreateUpload(ctx context.Context, u model.ResumableUpload) (model.ResumableUpload, error) {
+	u.ID = generateID()
+	u.Offset = 0
+	u.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	r.uploads[u.ID] = u
+	r.mu.Unlock()
+	return u, nil
+}
+
+// GetUpload 获取分块上传
+func (r *memAttachmentRepo) GetUpload(ctx context.Context, cardID, id string) (model.ResumableUpload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.uploads[id]
+	if !ok || u.CardID != cardID {
+		return model.ResumableUpload{}, ErrNotFound
+	}
+	return u, nil
+}
+
+// TouchUpload 顺延过期时间
+func (r *memAttachmentRepo) TouchUpload(ctx context.Context, id string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.uploads[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.ExpiresAt = expiresAt
+	r.uploads[id] = u
+	return nil
+}
+
+// DeleteUpload 删除分块上传
+func (r *memAttachmentRepo) DeleteUpload(ctx context.Context, id string) error {
+	r.mu.Lock()
+	delete(r.uploads, id)
+	r.mu.Unlock()
+	return nil
+}
+
+// ExpiredUploads 过期的分块上传
+func (r *memAttachmentRepo) ExpiredUploads(ctx context.Context, now time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]string, 0)
+	for id, u := range r.uploads {
+		if u.ExpiresAt.Before(now) {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
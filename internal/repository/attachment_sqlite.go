@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteAttachmentRepo 是 AttachmentRepository 的 SQLite 实现
+type sqliteAttachmentRepo struct {
+	db *gorm.DB
+}
+
+// attachmentRow 附件表结构
+// card_id 上的索引见 internal/migrations
+type attachmentRow struct {
+	ID          string `gorm:"primaryKey"`
+	CardID      string
+	Filename    string
+	ContentType string
+	Size        int64
+	SHA256      string `gorm:"column:sha256"`
+	CreatedBy   string
+	CreatedAt   time.Time
+
+	// StorageClass 不是附件表的列，查询时从 blob 表 JOIN 过来，只读
+	StorageClass string `gorm:"->"`
+}
+
+// attachmentBlobRow blob 表结构，一个 blob 一行
+// Refs 是引用这个 blob 的附件数，和附件记录在同一个事务里增减
+// StorageClass 是文件现在所在的存储，ClassChangedAt 是它最后一次改变的时间（从来没变过时为空）
+type attachmentBlobRow struct {
+	Hash           string `gorm:"primaryKey"`
+	Size           int64
+	Refs           int
+	StorageClass   string
+	ClassChangedAt *time.Time
+	CreatedAt      time.Time
+}
+
+// withStorageClass 查询附件时带上 blob 的存储类别
+func withStorageClass(db *gorm.DB) *gorm.DB {
+	return db.Model(&attachmentRow{}).Select("attachment_rows.*, attachment_blob_rows.storage_class").
+		Joins("LEFT JOIN attachment_blob_rows ON attachment_blob_rows.hash = attachment_rows.sha256")
+}
+
+// attachmentUploadRow 分块上传表结构
+// 已经收到的字节数以 blob 存储里的文件为准，不在表里保存；expires_at 上的索引见 internal/migrations
+type attachmentUploadRow struct {
+	ID          string `gorm:"primaryKey"`
+	CardID      string
+	Filename    string
+	ContentType string
+	Size        int64
+	CreatedBy   string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+// NewSQLiteAttachmentRepo 创建一个新的 SQLite 附件仓储
+func NewSQLiteAttachmentRepo(db *gorm.DB) (AttachmentRepository, error) {
+	return &sqliteAttachmentRepo{db: db}, nil
+}
+
+func (r *sqliteAttachmentRepo) toModel(row attachmentRow) model.Attachment {
+	return model.Attachment{
+		ID:           row.ID,
+		CardID:       row.CardID,
+		Filename:     row.Filename,
+		ContentType:  row.ContentType,
+		Size:         row.Size,
+		SHA256:       row.SHA256,
+		StorageClass: row.StorageClass,
+		CreatedBy:    row.CreatedBy,
+		CreatedAt:    row.CreatedAt,
+	}
+}
+
+// Create 保存附件记录并增加 blob 的引用计数
+// 返回的存储类别是 blob 现在的类别：同样的文件已经在冷存储里时是 cold
+func (r *sqliteAttachmentRepo) Create(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	rw := attachmentRow{
+		ID:          generateID(),
+		CardID:      a.CardID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		SHA256:      a.SHA256,
+		CreatedBy:   a.CreatedBy,
+		CreatedAt:   time.Now(),
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 相当于 SQL: INSERT INTO attachment_blob_rows ... ON CONFLICT(hash) DO UPDATE SET refs = refs + 1
+		blob := attachmentBlobRow{Hash: a.SHA256, Size: a.Size, Refs: 1, StorageClass: model.StorageClassStandard, CreatedAt: rw.CreatedAt}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]any{"refs": gorm.Expr("refs + 1")}),
+		}).Create(&blob).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&attachmentBlobRow{}).Where("hash = ?", a.SHA256).Select("storage_class").Scan(&rw.StorageClass).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rw).Error
+	})
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteAttachmentRepo) Get(ctx context.Context, cardID, id string) (model.Attachment, error) {
+	var row attachmentRow
+	err := withStorageClass(r.db.WithContext(ctx)).Where("id = ? AND card_id = ?", id, cardID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Attachment{}, ErrNotFound
+	}
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	return r.toModel(row), nil
+}
+
+func (r *sqliteAttachmentRepo) ListByCard(ctx context.Context, cardID string) ([]model.Attachment, error) {
+	var rows []attachmentRow
+	err := withStorageClass(r.db.WithContext(ctx)).Where("card_id = ?", cardID).
+		Order("attachment_rows.created_at, attachment_rows.id").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.Attachment, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Delete 删除附件并减少 blob 的引用计数
+// 条件里带上 card_id，不能通过别的卡片的路径删掉这个附件
+func (r *sqliteAttachmentRepo) Delete(ctx context.Context, cardID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row attachmentRow
+		err := tx.Where("id = ? AND card_id = ?", id, cardID).First(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&row).Error; err != nil {
+			return err
+		}
+		return releaseBlob(tx, row.SHA256, 1)
+	})
+}
+
+// DeleteByCards 删除这些卡片的所有附件，按 blob 分组减少引用计数
+func (r *sqliteAttachmentRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	if len(cardIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 相当于 SQL: SELECT sha256, COUNT(*) AS n FROM attachment_rows WHERE card_id IN ? GROUP BY sha256
+		var groups []struct {
+			SHA256 string `gorm:"column:sha256"`
+			N      int
+		}
+		if err := tx.Model(&attachmentRow{}).Select("sha256, COUNT(*) AS n").
+			Where("card_id IN ?", cardIDs).Group("sha256").Scan(&groups).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("card_id IN ?", cardIDs).Delete(&attachmentRow{}).Error; err != nil {
+			return err
+		}
+		for _, g := range groups {
+			if err := releaseBlob(tx, g.SHA256, g.N); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// releaseBlob 把 blob 的引用计数减少 n，在调用方的事务里执行
+func releaseBlob(tx *gorm.DB, hash string, n int) error {
+	return tx.Model(&attachmentBlobRow{}).Where("hash = ?", hash).
+		UpdateColumn("refs", gorm.Expr("refs - ?", n)).Error
+}
+
+// UsageByCards 相当于 SQL: SELECT COALESCE(SUM(size), 0), COUNT(*) FROM attachment_rows WHERE card_id IN ?
+func (r *sqliteAttachmentRepo) UsageByCards(ctx context.Context, cardIDs []string) (model.StorageUsage, error) {
+	var u model.StorageUsage
+	if len(cardIDs) == 0 {
+		return u, nil
+	}
+	err := r.db.WithContext(ctx).Model(&attachmentRow{}).Select("COALESCE(SUM(size), 0) AS bytes, COUNT(*) AS attachments").
+		Where("card_id IN ?", cardIDs).Scan(&u).Error
+	return u, err
+}
+
+func (r *sqliteAttachmentRepo) UnreferencedBlobs(ctx context.Context) ([]string, error) {
+	var hashes []string
+	err := r.db.WithContext(ctx).Model(&attachmentBlobRow{}).Where("refs <= 0").Order("hash").Pluck("hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// DeleteBlob 条件里再检查一次 refs，列出来之后又有人上传了同样的文件时不会删掉
+func (r *sqliteAttachmentRepo) DeleteBlob(ctx context.Context, hash string) (bool, error) {
+	res := r.db.WithContext(ctx).Where("hash = ? AND refs <= 0", hash).Delete(&attachmentBlobRow{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ArchivableBlobs 相当于 SQL:
+//
+//	SELECT b.hash FROM attachment_blob_rows b
+//	JOIN (SELECT sha256, COUNT(*) AS n FROM attachment_rows WHERE card_id IN ? GROUP BY sha256) a ON a.sha256 = b.hash
+//	WHERE a.n = b.refs AND b.storage_class = 'standard' AND COALESCE(b.class_changed_at, b.created_at) < ?
+//
+// 这些卡片上引用 blob 的附件数等于引用计数，说明没有别的附件引用它
+func (r *sqliteAttachmentRepo) ArchivableBlobs(ctx context.Context, cardIDs []string, before time.Time) ([]string, error) {
+	hashes := make([]string, 0)
+	if len(cardIDs) == 0 {
+		return hashes, nil
+	}
+	refs := r.db.Model(&attachmentRow{}).Select("sha256, COUNT(*) AS n").Where("card_id IN ?", cardIDs).Group("sha256")
+	err := r.db.WithContext(ctx).Table("attachment_blob_rows AS b").
+		Joins("JOIN (?) AS a ON a.sha256 = b.hash", refs).
+		Where("a.n = b.refs AND b.storage_class = ? AND COALESCE(b.class_changed_at, b.created_at) < ?", model.StorageClassStandard, before).
+		Order("b.hash").Pluck("b.hash", &hashes).Error
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// SetBlobClass 条件里带上原来的类别，两个实例同时移动同一个 blob 时只有一个能改成功
+func (r *sqliteAttachmentRepo) SetBlobClass(ctx context.Context, hash, from, to string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&attachmentBlobRow{}).Where("hash = ? AND storage_class = ?", hash, from).
+		Updates(map[string]any{"storage_class": to, "class_changed_at": time.Now()})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// CreateUpload 保存分块上传
+func (r *sqliteAttachmentRepo) CreateUpload(ctx context.Context, u model.ResumableUpload) (model.ResumableUpload, error) {
+	rw := attachmentUploadRow{
+		ID:          generateID(),
+		CardID:      u.CardID,
+		Filename:    u.Filename,
+		ContentType: u.ContentType,
+		Size:        u.Size,
+		CreatedBy:   u.CreatedBy,
+		ExpiresAt:   u.ExpiresAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.ResumableUpload{}, err
+	}
+	return r.uploadToModel(rw), nil
+}
+
+func (r *sqliteAttachmentRepo) uploadToModel(row attachmentUploadRow) model.ResumableUpload {
+	return model.ResumableUpload{
+		ID:          row.ID,
+		CardID:      row.CardID,
+		Filename:    row.Filename,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		CreatedBy:   row.CreatedBy,
+		ExpiresAt:   row.ExpiresAt,
+		CreatedAt:   row.CreatedAt,
+	}
+}
+
+func (r *sqliteAttachmentRepo) GetUpload(ctx context.Context, cardID, id string) (model.ResumableUpload, error) {
+	var row attachmentUploadRow
+	err := r.db.WithContext(ctx).Where("id = ? AND card_id = ?", id, cardID).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.ResumableUpload{}, ErrNotFound
+	}
+	if err != nil {
+		return model.ResumableUpload{}, err
+	}
+	return r.uploadToModel(row), nil
+}
+
+func (r *sqliteAttachmentRepo) TouchUpload(ctx context.Context, id string, expiresAt time.Time) error {
+	res := r.db.WithContext(ctx).Model(&attachmentUploadRow{}).Where("id = ?", id).UpdateColumn("expires_at", expiresAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteAttachmentRepo) DeleteUpload(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&attachmentUploadRow{}).Error
+}
+
+func (r *sqliteAttachmentRepo) ExpiredUploads(ctx context.Context, now time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&attachmentUploadRow{}).Where("expires_at < ?", now).Order("id").Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteAttachmentRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"kanban_api/internal/model"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -10,23 +13,86 @@ import (
 // ErrNotFound 当查询的资源不存在时返回的错误
 var ErrNotFound = errors.New("not found")
 
+// ErrSlugExists 当 slug 已被其他看板占用时返回的错误
+var ErrSlugExists = errors.New("slug already exists")
+
+// ErrVersionConflict 更新时看板的版本和调用方读到的不一样，说明别人已经修改过
+var ErrVersionConflict = errors.New("version conflict")
+
 // BoardRepository 看板仓储接口
 // 定义了对看板数据的 CRUD（增删改查）操作
+// 所有读写操作都以 ownerID 为范围：查不到其他用户的看板，
+// 访问别人的看板和访问不存在的看板一样返回 ErrNotFound
+//
+// 所有方法的第一个参数都是 context.Context（Go 的惯例是命名为 ctx 并放在第一位）
+// 请求被取消或超时时，正在执行的数据库查询会随之中止
 type BoardRepository interface {
-	// List 列出所有看板
-	List() ([]model.Board, error)
+	// List 列出某个用户的所有看板
+	List(ctx context.Context, ownerID string) ([]model.Board, error)
+
+	// Get 获取用户的单个看板
+	Get(ctx context.Context, ownerID, id string) (model.Board, error)
+
+	// GetBySlug 通过 slug 获取用户的单个看板
+	GetBySlug(ctx context.Context, ownerID, slug string) (model.Board, error)
+
+	// SlugExists 检查 slug 是否已被任意看板占用
+	// slug 是全局唯一的，所以这里不按用户过滤
+	SlugExists(ctx context.Context, slug string) (bool, error)
+
+	// Create 为用户创建新看板
+	Create(ctx context.Context, ownerID, title, slug string) (model.Board, error)
+
+	// Update 更新用户的看板信息，只修改 ch 里不为 nil 的字段，版本号加一
+	// version 是调用方读到的版本：看板当前的版本不是它时不修改，返回 ErrVersionConflict；
+	// 传 0 表示不检查版本
+	Update(ctx context.Context, ownerID, id string, ch BoardChanges, version int64) (model.Board, error)
+
+	// Delete 删除用户的看板
+	Delete(ctx context.Context, ownerID, id string) error
+
+	// ListByIDs 按 ID 批量查询看板，不按用户过滤
+	// 用于读取别人分享给当前用户的看板，调用方必须先通过 MemberRepository 确认成员身份
+	ListByIDs(ctx context.Context, ids []string) ([]model.Board, error)
+
+	// ListIDs 所有看板的 ID，不按用户过滤，定时统计所有看板时使用
+	ListIDs(ctx context.Context) ([]string, error)
+
+	// Search 不按用户过滤，按创建时间从新到旧分页列出符合条件的看板，同时返回符合条件的总数；只给运维接口使用
+	Search(ctx context.Context, f BoardFilter) ([]model.Board, int, error)
+
+	// Count 看板总数，不按用户过滤
+	Count(ctx context.Context) (int, error)
 
-	// Get 获取单个看板
-	Get(id string) (model.Board, error)
+	// SetState 修改看板状态和原因，版本号加一；不按用户过滤，只给管理员接口使用
+	SetState(ctx context.Context, id, state, reason string) (model.Board, error)
 
-	// Create 创建新看板
-	Create(title string) (model.Board, error)
+	// SetOwner 把 ownerID 的看板转给 newOwnerID，版本号加一
+	// 看板不存在或者已经不属于 ownerID 时返回 ErrNotFound
+	SetOwner(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error)
 
-	// Update 更新看板信息
-	Update(id, title string) (model.Board, error)
+	// Ping 检查存储是否可用，就绪检查使用
+	Ping(ctx context.Context) error
+}
+
+// BoardChanges 更新看板时要修改的字段，nil 表示不修改
+// 整体更新（PUT）两个字段都传；部分更新（PATCH）只传请求里出现的字段
+type BoardChanges struct {
+	Title *string
+	Slug  *string
+}
 
-	// Delete 删除看板
-	Delete(id string) error
+// BoardFilter 运维接口查找看板的条件，零值表示不限制
+type BoardFilter struct {
+	// OwnerID 只列出这个用户的看板
+	OwnerID string
+
+	// Title 标题包含这个字符串，不区分大小写（只对 ASCII 字母）
+	Title string
+
+	// Limit、Offset 分页，Limit <= 0 表示不限制
+	Limit  int
+	Offset int
 }
 
 // memBoardRepo 看板仓储的内存实现
@@ -43,8 +109,8 @@ func NewMemBoardRepo() BoardRepository {
 	}
 }
 
-// List 列出所有看板
-func (r *memBoardRepo) List() ([]model.Board, error) {
+// List 列出某个用户的所有看板
+func (r *memBoardRepo) List(ctx context.Context, ownerID string) ([]model.Board, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -59,6 +125,10 @@ func (r *memBoardRepo) List() ([]model.Board, error) {
 	// for key, value := range map 会遍历所有键值对
 	// 这里用 _ 忽略 key（看板ID），只关心 value（看板对象）
 	for _, b := range r.boards {
+		// 只返回属于该用户的看板
+		if b.OwnerID != ownerID {
+			continue
+		}
 		// append 向切片追加元素
 		out = append(out, b)
 	}
@@ -66,13 +136,13 @@ func (r *memBoardRepo) List() ([]model.Board, error) {
 	return out, nil
 }
 
-// Get 根据 ID 获取单个看板
-func (r *memBoardRepo) Get(id string) (model.Board, error) {
+// Get 根据 ID 获取用户的单个看板
+func (r *memBoardRepo) Get(ctx context.Context, ownerID, id string) (model.Board, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	b, ok := r.boards[id]
-	if !ok {
+	if !ok || b.OwnerID != ownerID {
 		// 注意：这里原代码返回 nil 作为错误可能是个 bug
 		// 应该返回 ErrNotFound 才对
 		return model.Board{}, ErrNotFound
@@ -80,40 +150,95 @@ func (r *memBoardRepo) Get(id string) (model.Board, error) {
 	return b, nil
 }
 
+// GetBySlug 根据 slug 获取用户的单个看板
+// 内存实现直接遍历查找，看板数量不多时足够快
+func (r *memBoardRepo) GetBySlug(ctx context.Context, ownerID, slug string) (model.Board, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, b := range r.boards {
+		if b.Slug == slug && b.OwnerID == ownerID {
+			return b, nil
+		}
+	}
+	return model.Board{}, ErrNotFound
+}
+
+// SlugExists 检查 slug 是否已被任意看板占用
+func (r *memBoardRepo) SlugExists(ctx context.Context, slug string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.slugTaken(slug, ""), nil
+}
+
+// slugTaken 检查 slug 是否已被其他看板占用
+// 调用方必须已经持有锁
+func (r *memBoardRepo) slugTaken(slug, exceptID string) bool {
+	for _, b := range r.boards {
+		if b.Slug == slug && b.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
 // Create 创建新看板
-func (r *memBoardRepo) Create(title string) (model.Board, error) {
+func (r *memBoardRepo) Create(ctx context.Context, ownerID, title, slug string) (model.Board, error) {
 	// 获取当前时间，创建时间和更新时间都设置为当前时间
 	now := time.Now()
 
 	// 构建看板对象
 	b := model.Board{
 		ID:        generateID(), // 生成唯一 ID
+		OwnerID:   ownerID,
 		Title:     title,
+		Slug:      slug,
+		State:     model.BoardStateActive,
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
 	// 写操作需要获取写锁
 	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// slug 必须唯一，与数据库中的唯一索引保持一致
+	if r.slugTaken(slug, "") {
+		return model.Board{}, ErrSlugExists
+	}
 	r.boards[b.ID] = b
-	r.mu.Unlock()
 
 	return b, nil
 }
 
 // Update 更新看板信息
-func (r *memBoardRepo) Update(id, title string) (model.Board, error) {
+func (r *memBoardRepo) Update(ctx context.Context, ownerID, id string, ch BoardChanges, version int64) (model.Board, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// 先检查看板是否存在
+	// 先检查看板是否存在，并且属于该用户
 	b, ok := r.boards[id]
-	if !ok {
+	if !ok || b.OwnerID != ownerID {
 		return model.Board{}, ErrNotFound
 	}
+	// 检查版本和修改在同一把锁里，中间不会有别的修改插进来
+	if version != 0 && b.Version != version {
+		return model.Board{}, ErrVersionConflict
+	}
+	if ch.Slug != nil && r.slugTaken(*ch.Slug, id) {
+		return model.Board{}, ErrSlugExists
+	}
 
-	// 更新标题和更新时间
-	b.Title = title
+	// 更新传了的字段、版本号和更新时间
+	if ch.Title != nil {
+		b.Title = *ch.Title
+	}
+	if ch.Slug != nil {
+		b.Slug = *ch.Slug
+	}
+	b.Version++
 	b.UpdatedAt = time.Now()
 
 	// 注意：在 Go 中，从 map 取出的是值的副本
@@ -123,13 +248,46 @@ func (r *memBoardRepo) Update(id, title string) (model.Board, error) {
 	return b, nil
 }
 
+// SetState 修改看板状态
+func (r *memBoardRepo) SetState(ctx context.Context, id, state, reason string) (model.Board, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.boards[id]
+	if !ok {
+		return model.Board{}, ErrNotFound
+	}
+	b.State = state
+	b.StateReason = reason
+	b.Version++
+	b.UpdatedAt = time.Now()
+	r.boards[id] = b
+	return b, nil
+}
+
+// SetOwner 转让看板
+func (r *memBoardRepo) SetOwner(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.boards[id]
+	if !ok || b.OwnerID != ownerID {
+		return model.Board{}, ErrNotFound
+	}
+	b.OwnerID = newOwnerID
+	b.Version++
+	b.UpdatedAt = time.Now()
+	r.boards[id] = b
+	return b, nil
+}
+
 // Delete 删除看板
-func (r *memBoardRepo) Delete(id string) error {
+func (r *memBoardRepo) Delete(ctx context.Context, ownerID, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// 先检查是否存在
-	if _, ok := r.boards[id]; !ok {
+	// 先检查是否存在，并且属于该用户
+	if b, ok := r.boards[id]; !ok || b.OwnerID != ownerID {
 		return ErrNotFound
 	}
 
@@ -138,3 +296,62 @@ func (r *memBoardRepo) Delete(id string) error {
 
 	return nil
 }
+
+// ListByIDs 按 ID 批量查询看板
+func (r *memBoardRepo) ListByIDs(ctx context.Context, ids []string) ([]model.Board, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Board, 0, len(ids))
+	for _, id := range ids {
+		if b, ok := r.boards[id]; ok {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// ListIDs 所有看板的 ID，按 ID 排序
+func (r *memBoardRepo) ListIDs(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.boards))
+	for id := range r.boards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Search 按条件分页列出所有用户的看板
+func (r *memBoardRepo) Search(ctx context.Context, f BoardFilter) ([]model.Board, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	title := strings.ToLower(f.Title)
+	var out []model.Board
+	for _, b := range r.boards {
+		if f.OwnerID != "" && b.OwnerID != f.OwnerID {
+			continue
+		}
+		if title != "" && !strings.Contains(strings.ToLower(b.Title), title) {
+			continue
+		}
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return page(out, f.Limit, f.Offset), len(out), nil
+}
+
+// Count 看板总数
+func (r *memBoardRepo) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.boards), nil
+}
+
+// Ping 内存存储总是可用
+func (r *memBoardRepo) Ping(ctx context.Context) error {
+	return nil
+}
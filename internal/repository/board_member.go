@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemberRepository 看板成员仓储接口
+// 只保存被邀请的成员（editor / viewer），所有者由看板的 OwnerID 表示
+type MemberRepository interface {
+	// Upsert 添加成员；已经是成员时只修改角色
+	Upsert(ctx context.Context, boardID, userID, role string) (model.BoardMember, error)
+
+	// Get 查询用户在看板中的成员记录，不是成员时返回 ErrNotFound
+	Get(ctx context.Context, boardID, userID string) (model.BoardMember, error)
+
+	// ListByBoard 按加入时间列出看板的所有成员
+	ListByBoard(ctx context.Context, boardID string) ([]model.BoardMember, error)
+
+	// ListByUser 列出用户加入的所有看板的成员记录
+	ListByUser(ctx context.Context, userID string) ([]model.BoardMember, error)
+
+	// Remove 移除成员，不是成员时返回 ErrNotFound
+	Remove(ctx context.Context, boardID, userID string) error
+
+	// DeleteByBoard 删除看板的所有成员，删除看板时使用
+	DeleteByBoard(ctx context.Context, boardID string) error
+}
+
+// memMemberRepo 成员仓储的内存实现
+type memMemberRepo struct {
+	mu      sync.RWMutex
+	members map[[2]string]model.BoardMember // key 是 {boardID, userID}
+}
+
+// NewMemMemberRepo 创建一个新的内存成员仓储
+func NewMemMemberRepo() MemberRepository {
+	return &memMemberRepo{
+		members: make(map[[2]string]model.BoardMember),
+	}
+}
+
+func (r *memMemberRepo) Upsert(ctx context.Context, boardID, userID, role string) (model.BoardMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{boardID, userID}
+	m, ok := r.members[key]
+	if !ok {
+		m = model.BoardMember{BoardID: boardID, UserID: userID, CreatedAt: time.Now()}
+	}
+	m.Role = role
+	r.members[key] = m
+	return m, nil
+}
+
+func (r *memMemberRepo) Get(ctx context.Context, boardID, userID string) (model.BoardMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.members[[2]string{boardID, userID}]
+	if !ok {
+		return model.BoardMember{}, ErrNotFound
+	}
+	return m, nil
+}
+
+func (r *memMemberRepo) ListByBoard(ctx context.Context, boardID string) ([]model.BoardMember, error) {
+	return r.filter(func(m model.BoardMember) bool { return m.BoardID == boardID }), nil
+}
+
+func (r *memMemberRepo) ListByUser(ctx context.Context, userID string) ([]model.BoardMember, error) {
+	return r.filter(func(m model.BoardMember) bool { return m.UserID == userID }), nil
+}
+
+// filter 返回满足条件的成员，按加入时间排序
+func (r *memMemberRepo) filter(keep func(model.BoardMember) bool) []model.BoardMember {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.BoardMember, 0)
+	for _, m := range r.members {
+		if keep(m) {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (r *memMemberRepo) Remove(ctx context.Context, boardID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{boardID, userID}
+	if _, ok := r.members[key]; !ok {
+		return ErrNotFound
+	}
+	delete(r.members, key)
+	return nil
+}
+
+func (r *memMemberRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, m := range r.members {
+		if m.BoardID == boardID {
+			delete(r.members, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteMemberRepo 是 MemberRepository 的 SQLite 实现
+type sqliteMemberRepo struct {
+	db *gorm.DB
+}
+
+// boardMemberRow 看板成员表结构
+// (board_id, user_id) 是联合主键，同一个用户在一个看板里只有一条记录
+// user_id 上的索引见 internal/migrations，用于列出"我加入的看板"
+type boardMemberRow struct {
+	BoardID   string `gorm:"primaryKey"`
+	UserID    string `gorm:"primaryKey"`
+	Role      string
+	CreatedAt time.Time
+}
+
+// NewSQLiteMemberRepo 创建一个新的 SQLite 成员仓储
+func NewSQLiteMemberRepo(db *gorm.DB) (MemberRepository, error) {
+	return &sqliteMemberRepo{db: db}, nil
+}
+
+func (r *sqliteMemberRepo) toModel(row boardMemberRow) model.BoardMember {
+	return model.BoardMember{
+		BoardID:   row.BoardID,
+		UserID:    row.UserID,
+		Role:      row.Role,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// Upsert 添加成员或修改角色
+// INSERT ... ON CONFLICT DO UPDATE 一条语句完成，不会因为并发邀请同一个人而报主键冲突
+func (r *sqliteMemberRepo) Upsert(ctx context.Context, boardID, userID, role string) (model.BoardMember, error) {
+	rw := boardMemberRow{BoardID: boardID, UserID: userID, Role: role, CreatedAt: time.Now()}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "board_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&rw).Error
+	if err != nil {
+		return model.BoardMember{}, err
+	}
+	// 已经存在时 CreatedAt 不会被更新，重新读一次拿到真实的加入时间
+	return r.Get(ctx, boardID, userID)
+}
+
+func (r *sqliteMemberRepo) Get(ctx context.Context, boardID, userID string) (model.BoardMember, error) {
+	var rw boardMemberRow
+	if err := r.db.WithContext(ctx).First(&rw, "board_id = ? AND user_id = ?", boardID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.BoardMember{}, ErrNotFound
+		}
+		return model.BoardMember{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteMemberRepo) ListByBoard(ctx context.Context, boardID string) ([]model.BoardMember, error) {
+	return r.list(ctx, "board_id = ?", boardID)
+}
+
+func (r *sqliteMemberRepo) ListByUser(ctx context.Context, userID string) ([]model.BoardMember, error) {
+	return r.list(ctx, "user_id = ?", userID)
+}
+
+// list 按条件查询成员，按加入时间排序
+func (r *sqliteMemberRepo) list(ctx context.Context, query string, arg string) ([]model.BoardMember, error) {
+	var rows []boardMemberRow
+	if err := r.db.WithContext(ctx).Where(query, arg).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.BoardMember, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteMemberRepo) Remove(ctx context.Context, boardID, userID string) error {
+	res := r.db.WithContext(ctx).Delete(&boardMemberRow{}, "board_id = ? AND user_id = ?", boardID, userID)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteMemberRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Delete(&boardMemberRow{}, "board_id = ?", boardID).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteMemberRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
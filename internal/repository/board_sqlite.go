@@ -2,10 +2,12 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"kanban_api/internal/model"
+	"strings"
 	"time"
 )
 
@@ -20,16 +22,32 @@ type sqliteBoardRepo struct {
 
 // boardRow 数据库表结构
 // 这个结构体对应数据库中的一张表
-// GORM 会自动根据这个结构体创建表（auto migration）
+// 表结构由 internal/migrations 中的迁移创建，改字段时要加一个新的迁移
 type boardRow struct {
 	// ID 主键
 	// `gorm:"primaryKey"` 是 GORM 的标签，表示这是主键
 	ID string `gorm:"primaryKey"`
 
+	// OwnerID 看板所有者的用户 ID
+	// 按用户查询看板时走 idx_board_rows_owner_id 索引（见 internal/migrations）
+	// MySQL 里是带长度的 varchar，才能建索引
+	OwnerID string `gorm:"size:64"`
+
 	// Title 看板标题
 	// 没有标签时，GORM 会自动将字段名转为蛇形命名（title）
 	Title string
 
+	// Slug 看板的短链接标识
+	// 唯一索引 idx_board_rows_slug 由 internal/migrations 创建
+	Slug string `gorm:"size:191"`
+
+	// State 看板状态，加这一列之前的看板由默认值填成 active
+	State       string `gorm:"size:16;default:active"`
+	StateReason string
+
+	// Version 版本号，0015 迁移新增，已有的看板由默认值填成 1
+	Version int64 `gorm:"default:1"`
+
 	// CreatedAt 创建时间
 	// GORM 会自动识别 CreatedAt 字段，在插入时自动设置
 	CreatedAt time.Time
@@ -40,23 +58,17 @@ type boardRow struct {
 }
 
 // NewSQLiteBoardRepo 创建一个新的 SQLite 看板仓储
-// 参数 path 是数据库文件路径，例如："file:kanban.db?cache=shared&_fk=1"
+// 参数 db 是 NewDB 打开的数据库连接，所有 SQLite 仓储共用
 // 返回 BoardRepository 接口，使用者不需要知道底层是 SQLite
-func NewSQLiteBoardRepo(path string) (BoardRepository, error) {
-	// gorm.Open 打开数据库连接
-	// sqlite.Open(path) 指定使用 SQLite 驱动
-	// &gorm.Config{} 是 GORM 的配置选项（这里使用默认配置）
-	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
-	if err != nil {
-		// 如果连接失败，返回错误
-		return nil, err
-	}
+func NewSQLiteBoardRepo(db *gorm.DB) (BoardRepository, error) {
+	return newGormBoardRepo(db)
+}
 
-	// AutoMigrate 自动迁移数据库表结构
-	// 它会根据 boardRow 结构体自动创建表
-	// 如果表已存在，会根据结构体更新表结构（增加新字段等）
-	// 注意：传入的是指针 &boardRow{}
-	if err := db.AutoMigrate(&boardRow{}); err != nil {
+// newGormBoardRepo 在已经打开的数据库上返回看板仓储，表要先由迁移建好
+// 这里只用到 GORM 的通用 API，SQLite 和 MySQL 共用这一份实现
+func newGormBoardRepo(db *gorm.DB) (BoardRepository, error) {
+	// 注册多租户检查：之后对 board_rows 的查询都必须带 owner_id 条件
+	if err := registerTenantGuard(db); err != nil {
 		return nil, err
 	}
 
@@ -72,23 +84,30 @@ func NewSQLiteBoardRepo(path string) (BoardRepository, error) {
 // 这种分层设计让各层职责更清晰
 func (r *sqliteBoardRepo) toModel(row boardRow) model.Board {
 	return model.Board{
-		ID:        row.ID,
-		Title:     row.Title,
-		CreatedAt: row.CreatedAt,
-		UpdatedAt: row.UpdatedAt,
+		ID:          row.ID,
+		OwnerID:     row.OwnerID,
+		Title:       row.Title,
+		Slug:        row.Slug,
+		State:       row.State,
+		StateReason: row.StateReason,
+		Version:     row.Version,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
 	}
 }
 
-// List 查询所有看板
-func (r *sqliteBoardRepo) List() ([]model.Board, error) {
+// List 查询某个用户的所有看板
+func (r *sqliteBoardRepo) List(ctx context.Context, ownerID string) ([]model.Board, error) {
 	// 声明一个切片来接收查询结果
 	var rows []boardRow
 
 	// GORM 链式调用：
+	// WithContext(ctx): 把请求的 context 传给数据库驱动，请求取消时查询也会中止
+	// Where("owner_id=?", ownerID): 只查询该用户的看板
 	// Order("created_at desc"): 按创建时间降序排序（最新的在前）
 	// Find(&rows): 查询所有记录，结果存入 rows
 	// .Error: 获取错误（GORM 用这种方式返回错误）
-	if err := r.db.Order("created_at desc").Find(&rows).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("owner_id=?", ownerID).Order("created_at desc").Find(&rows).Error; err != nil {
 		return nil, err
 	}
 
@@ -101,15 +120,15 @@ func (r *sqliteBoardRepo) List() ([]model.Board, error) {
 	return out, nil
 }
 
-// Get 根据 ID 查询单个看板
-func (r *sqliteBoardRepo) Get(id string) (model.Board, error) {
+// Get 根据 ID 查询用户的单个看板
+func (r *sqliteBoardRepo) Get(ctx context.Context, ownerID, id string) (model.Board, error) {
 	var rw boardRow
 
 	// First 查询第一条匹配的记录
-	// "id=?" 是 SQL 条件，? 是占位符
-	// id 是占位符的值，GORM 会自动防止 SQL 注入
-	// 相当于 SQL: SELECT * FROM board_rows WHERE id=? LIMIT 1
-	if err := r.db.First(&rw, "id=?", id).Error; err != nil {
+	// "id=? AND owner_id=?" 是 SQL 条件，? 是占位符
+	// id、ownerID 是占位符的值，GORM 会自动防止 SQL 注入
+	// 相当于 SQL: SELECT * FROM board_rows WHERE id=? AND owner_id=? LIMIT 1
+	if err := r.db.WithContext(ctx).First(&rw, "id=? AND owner_id=?", id, ownerID).Error; err != nil {
 		// errors.Is 判断错误类型（Go 1.13+ 的标准错误处理方式）
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// 如果记录不存在，返回我们自定义的 ErrNotFound
@@ -122,21 +141,115 @@ func (r *sqliteBoardRepo) Get(id string) (model.Board, error) {
 	// 将数据库行转换为业务模型
 	return r.toModel(rw), nil
 }
+
+// GetBySlug 根据 slug 查询用户的单个看板
+func (r *sqliteBoardRepo) GetBySlug(ctx context.Context, ownerID, slug string) (model.Board, error) {
+	var rw boardRow
+	if err := r.db.WithContext(ctx).First(&rw, "slug=? AND owner_id=?", slug, ownerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Board{}, ErrNotFound
+		}
+		return model.Board{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// SlugExists 检查 slug 是否已被任意看板占用
+func (r *sqliteBoardRepo) SlugExists(ctx context.Context, slug string) (bool, error) {
+	var n int64
+	// slug 在所有用户之间唯一，这里必须跨用户查询，所以跳过多租户检查
+	if err := withoutTenantGuard(r.db.WithContext(ctx)).Model(&boardRow{}).Where("slug=?", slug).Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListByIDs 按 ID 批量查询看板
+// 共享看板的所有者是别人，没有 owner_id 条件，所以跳过多租户检查；
+// 成员身份由服务层先通过成员表确认
+func (r *sqliteBoardRepo) ListByIDs(ctx context.Context, ids []string) ([]model.Board, error) {
+	if len(ids) == 0 {
+		return []model.Board{}, nil
+	}
+	var rows []boardRow
+	if err := withoutTenantGuard(r.db.WithContext(ctx)).Where("id IN ?", ids).Order("created_at desc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Board, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// ListIDs 所有看板的 ID，定时任务统计所有看板，同样跳过多租户检查
+func (r *sqliteBoardRepo) ListIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := withoutTenantGuard(r.db.WithContext(ctx)).Model(&boardRow{}).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Search 运维接口列出所有用户的看板，同样跳过多租户检查
+func (r *sqliteBoardRepo) Search(ctx context.Context, f BoardFilter) ([]model.Board, int, error) {
+	filter := func() *gorm.DB {
+		q := withoutTenantGuard(r.db.WithContext(ctx)).Model(&boardRow{})
+		if f.OwnerID != "" {
+			q = q.Where("owner_id = ?", f.OwnerID)
+		}
+		if f.Title != "" {
+			q = q.Where("LOWER(title) LIKE ? ESCAPE '!'", containsPattern(strings.ToLower(f.Title)))
+		}
+		return q
+	}
+	var total int64
+	if err := filter().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	q := filter().Order("created_at desc").Order("id").Offset(f.Offset)
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var rows []boardRow
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]model.Board, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, int(total), nil
+}
+
+// Count 看板总数，跳过多租户检查
+func (r *sqliteBoardRepo) Count(ctx context.Context) (int, error) {
+	var n int64
+	err := withoutTenantGuard(r.db.WithContext(ctx)).Model(&boardRow{}).Count(&n).Error
+	return int(n), err
+}
+
 // Create 创建新看板
-func (r *sqliteBoardRepo) Create(title string) (model.Board, error) {
+func (r *sqliteBoardRepo) Create(ctx context.Context, ownerID, title, slug string) (model.Board, error) {
 	now := time.Now()
 
 	// 构建数据库行对象
 	rw := boardRow{
 		ID:        generateID(), // 生成唯一 ID
+		OwnerID:   ownerID,
 		Title:     title,
+		Slug:      slug,
+		State:     model.BoardStateActive,
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
 	// Create 插入一条新记录
-	// 相当于 SQL: INSERT INTO board_rows (id, title, created_at, updated_at) VALUES (?, ?, ?, ?)
-	if err := r.db.Create(&rw).Error; err != nil {
+	// 相当于 SQL: INSERT INTO board_rows (id, owner_id, title, slug, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		// 并发创建时，两个请求可能算出同一个 slug，由唯一索引兜底
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.Board{}, ErrSlugExists
+		}
 		return model.Board{}, err
 	}
 
@@ -144,38 +257,94 @@ func (r *sqliteBoardRepo) Create(title string) (model.Board, error) {
 	return r.toModel(rw), nil
 }
 
-// Update 更新看板信息
-func (r *sqliteBoardRepo) Update(id, title string) (model.Board, error) {
+// Update 更新看板信息，只写 ch 里传了的列
+func (r *sqliteBoardRepo) Update(ctx context.Context, ownerID, id string, ch BoardChanges, version int64) (model.Board, error) {
 	var rw boardRow
 
-	// 先查询记录是否存在
-	if err := r.db.First(&rw, "id=?", id).Error; err != nil {
+	// 先查询记录是否存在，并且属于该用户
+	if err := r.db.WithContext(ctx).First(&rw, "id=? AND owner_id=?", id, ownerID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return model.Board{}, ErrNotFound
 		}
 		return model.Board{}, err
 	}
+	if version == 0 {
+		version = rw.Version
+	} else if rw.Version != version {
+		return model.Board{}, ErrVersionConflict
+	}
 
-	// 修改字段
-	rw.Title = title
-	rw.UpdatedAt = time.Now()
+	// 相当于 SQL: UPDATE board_rows SET [title=?,] [slug=?,] version=?, updated_at=? WHERE owner_id=? AND id=? AND version=?
+	// 检查版本和修改是同一条语句：查询之后别人先改了，这里一行也不会更新，
+	// 所以没有写的列还是上面读到的值
+	// 带上 owner_id 条件，满足多租户检查（见 tenant_guard.go）
+	now := time.Now()
+	updates := map[string]interface{}{"version": version + 1, "updated_at": now}
+	if ch.Title != nil {
+		updates["title"] = *ch.Title
+		rw.Title = *ch.Title
+	}
+	if ch.Slug != nil {
+		updates["slug"] = *ch.Slug
+		rw.Slug = *ch.Slug
+	}
+	res := r.db.WithContext(ctx).Model(&boardRow{}).Where("owner_id=? AND id=? AND version=?", ownerID, id, version).Updates(updates)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrDuplicatedKey) {
+			return model.Board{}, ErrSlugExists
+		}
+		return model.Board{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.Board{}, ErrVersionConflict
+	}
 
-	// Save 更新记录
-	// 相当于 SQL: UPDATE board_rows SET title=?, updated_at=? WHERE id=?
-	// Save 会更新所有字段，即使字段值没变
-	if err := r.db.Save(&rw).Error; err != nil {
-		return model.Board{}, err
+	rw.Version = version + 1
+	rw.UpdatedAt = now
+	return r.toModel(rw), nil
+}
+
+// SetState 修改看板状态
+// 管理员接口按 ID 修改任意用户的看板，没有 owner_id 条件，所以跳过多租户检查
+func (r *sqliteBoardRepo) SetState(ctx context.Context, id, state, reason string) (model.Board, error) {
+	db := withoutTenantGuard(r.db.WithContext(ctx))
+	res := db.Model(&boardRow{}).Where("id=?", id).
+		Updates(map[string]interface{}{"state": state, "state_reason": reason, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+	if res.Error != nil {
+		return model.Board{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.Board{}, ErrNotFound
 	}
 
+	var rw boardRow
+	if err := db.First(&rw, "id=?", id).Error; err != nil {
+		return model.Board{}, err
+	}
 	return r.toModel(rw), nil
 }
 
+// SetOwner 转让看板
+// 条件里带着原来的所有者：同一个看板被并发转让时只有一次成功，也满足多租户检查
+func (r *sqliteBoardRepo) SetOwner(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	db := r.db.WithContext(ctx)
+	res := db.Model(&boardRow{}).Where("owner_id=? AND id=?", ownerID, id).
+		Updates(map[string]interface{}{"owner_id": newOwnerID, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+	if res.Error != nil {
+		return model.Board{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.Board{}, ErrNotFound
+	}
+	return r.Get(ctx, newOwnerID, id)
+}
+
 // Delete 删除看板
-func (r *sqliteBoardRepo) Delete(id string) error {
+func (r *sqliteBoardRepo) Delete(ctx context.Context, ownerID, id string) error {
 	// Delete 删除记录
-	// 相当于 SQL: DELETE FROM board_rows WHERE id=?
+	// 相当于 SQL: DELETE FROM board_rows WHERE id=? AND owner_id=?
 	// 第一个参数 &boardRow{} 用于指定表名（GORM 会根据类型推断）
-	res := r.db.Delete(&boardRow{}, "id=?", id)
+	res := r.db.WithContext(ctx).Delete(&boardRow{}, "id=? AND owner_id=?", id, ownerID)
 
 	// 检查是否有错误
 	if res.Error != nil {
@@ -183,10 +352,24 @@ func (r *sqliteBoardRepo) Delete(id string) error {
 	}
 
 	// RowsAffected 返回受影响的行数
-	// 如果为 0，说明没有找到要删除的记录
+	// 如果为 0，说明没有找到要删除的记录（或者看板不属于该用户）
 	if res.RowsAffected == 0 {
 		return ErrNotFound
 	}
 
 	return nil
 }
+
+// Ping 向数据库发一次 ping，连接池里没有可用连接时会新建一个
+func (r *sqliteBoardRepo) Ping(ctx context.Context) error {
+	db, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteBoardRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,412 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CardRepository 卡片仓储接口
+// 和列表一样，卡片的位置由仓储层维护：新建时追加到列表末尾，删除后重新编号
+type CardRepository interface {
+	// ListByList 按位置顺序列出列表中的所有卡片
+	ListByList(ctx context.Context, listID string) ([]model.Card, error)
+
+	// Get 获取列表中的单张卡片
+	Get(ctx context.Context, listID, id string) (model.Card, error)
+
+	// GetByID 按 ID 获取卡片，不知道卡片在哪个列表时使用（例如同步镜像卡片）
+	// 不检查卡片属于哪个看板，调用方负责确认有没有权限
+	GetByID(ctx context.Context, id string) (model.Card, error)
+
+	// Create 在列表末尾创建卡片
+	// 使用 c 中的 BoardID、ListID、Title、Description、Labels、DueDate、Reminder、Sample，其余字段由仓储生成
+	Create(ctx context.Context, c model.Card) (model.Card, error)
+
+	// Update 更新列表中的卡片，只修改 ch 里传了的字段（标题、描述、截止日期、是否提醒、标签）
+	// 截止日期变了时清空 RemindedAt，按新的截止日期重新提醒
+	Update(ctx context.Context, listID, id string, ch CardChanges) (model.Card, error)
+
+	// Move 把卡片移动到目标列表的指定位置（目标列表可以就是原列表）
+	// toBoardID 是目标列表所在的看板，跨看板移动时卡片的 BoardID 一起更新
+	// 原列表和目标列表都会重新编号，返回移动后目标列表的全部卡片
+	// 位置超出范围时放到末尾
+	Move(ctx context.Context, fromListID, id, toBoardID, toListID string, position int) ([]model.Card, error)
+
+	// Delete 删除卡片，后面的卡片依次前移
+	Delete(ctx context.Context, listID, id string) error
+
+	// DeleteByList 删除列表中的所有卡片，删除列表时使用
+	DeleteByList(ctx context.Context, listID string) error
+
+	// DeleteByBoard 删除看板中的所有卡片，删除看板时使用
+	DeleteByBoard(ctx context.Context, boardID string) error
+
+	// CountByBoard 统计看板中的卡片数量，删除看板前评估影响时使用
+	CountByBoard(ctx context.Context, boardID string) (int, error)
+
+	// ListDue 按截止日期从早到晚列出 boardIDs 中截止日期在 [from, to) 之间的卡片
+	// from 为零值表示没有下限，包括所有已经过期的卡片
+	ListDue(ctx context.Context, boardIDs []string, from, to time.Time) ([]model.Card, error)
+
+	// ListReminders 所有看板中需要提醒的卡片：打开了提醒、还没提醒过、截止日期早于 before
+	ListReminders(ctx context.Context, before time.Time) ([]model.Card, error)
+
+	// MarkReminded 记录卡片在 at 提醒过
+	// 只在还没提醒过时写入并返回 true：多个实例同时检查时只有一个能拿到这张卡片，不会重复提醒
+	MarkReminded(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// CountByList 按列表统计所有看板的卡片数和过期卡片数（截止日期早于 now），看板指标使用
+	// 没有卡片的列表不会出现在结果中
+	CountByList(ctx context.Context, now time.Time) ([]model.ListCardCount, error)
+
+	// Ping 检查存储是否可用，就绪检查使用
+	Ping(ctx context.Context) error
+}
+
+// moveCard 计算移动卡片后两个列表的新顺序，内存实现和 SQLite 实现共用
+// source 和 target 必须已经按位置排好序；同一列表内移动时两者传同一个切片
+// 返回重新编号后的原列表和目标列表；卡片不在 source 中时 ok 为 false
+func moveCard(source, target []model.Card, id, toBoardID, toListID string, position int) (src, dst []model.Card, ok bool) {
+	from := -1
+	for i, c := range source {
+		if c.ID == id {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return nil, nil, false
+	}
+
+	moving := source[from]
+	src = append(append([]model.Card{}, source[:from]...), source[from+1:]...)
+
+	sameList := moving.ListID == toListID
+	if sameList {
+		dst = src
+	} else {
+		dst = append([]model.Card{}, target...)
+	}
+
+	if position > len(dst) {
+		position = len(dst)
+	}
+	moving.ListID = toListID
+	moving.BoardID = toBoardID
+	out := make([]model.Card, 0, len(dst)+1)
+	out = append(out, dst[:position]...)
+	out = append(out, moving)
+	out = append(out, dst[position:]...)
+	dst = out
+
+	for i := range dst {
+		dst[i].Position = i
+	}
+	if sameList {
+		return dst, dst, true
+	}
+	for i := range src {
+		src[i].Position = i
+	}
+	return src, dst, true
+}
+
+// CardChanges 更新卡片时要修改的字段，nil 表示不修改
+// 整体替换（PUT）所有字段都传，没有截止日期时 ClearDueDate 为 true；部分更新（PATCH）只传请求里出现的字段
+type CardChanges struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Reminder    *bool
+	Labels      *[]string
+
+	// ClearDueDate 清空截止日期，DueDate 为 nil 时才有意义
+	ClearDueDate bool
+}
+
+// dueDate 修改后的截止日期，第二个返回值表示截止日期有没有变
+func (ch CardChanges) dueDate(cur *time.Time) (*time.Time, bool) {
+	next := cur
+	switch {
+	case ch.DueDate != nil:
+		next = ch.DueDate
+	case ch.ClearDueDate:
+		next = nil
+	}
+	return next, !sameDueDate(cur, next)
+}
+
+// sameDueDate 两个截止日期是否相同，都没有设置也算相同
+func sameDueDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// sortByDueDate 按截止日期从早到晚排序，截止日期相同时按 ID 排，保证顺序稳定
+func sortByDueDate(cards []model.Card) {
+	sort.Slice(cards, func(i, j int) bool {
+		if !cards[i].DueDate.Equal(*cards[j].DueDate) {
+			return cards[i].DueDate.Before(*cards[j].DueDate)
+		}
+		return cards[i].ID < cards[j].ID
+	})
+}
+
+// memCardRepo 卡片仓储的内存实现
+type memCardRepo struct {
+	mu    sync.RWMutex
+	cards map[string]model.Card // key 是卡片 ID
+}
+
+// NewMemCardRepo 创建一个新的内存卡片仓储
+func NewMemCardRepo() CardRepository {
+	return &memCardRepo{
+		cards: make(map[string]model.Card),
+	}
+}
+
+// byList 返回列表中的所有卡片，按位置排序
+// 调用方必须已经持有锁
+func (r *memCardRepo) byList(listID string) []model.Card {
+	out := make([]model.Card, 0)
+	for _, c := range r.cards {
+		if c.ListID == listID {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Position < out[j].Position
+	})
+	return out
+}
+
+func (r *memCardRepo) ListByList(ctx context.Context, listID string) ([]model.Card, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byList(listID), nil
+}
+
+func (r *memCardRepo) Get(ctx context.Context, listID, id string) (model.Card, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.cards[id]
+	if !ok || c.ListID != listID {
+		return model.Card{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *memCardRepo) GetByID(ctx context.Context, id string) (model.Card, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.cards[id]
+	if !ok {
+		return model.Card{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *memCardRepo) Create(ctx context.Context, c model.Card) (model.Card, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	c.ID = generateID()
+	c.Position = len(r.byList(c.ListID)) // 追加到末尾
+	c.RemindedAt = nil
+	c.Labels = append([]string(nil), c.Labels...)
+	c.CreatedAt = now
+	c.UpdatedAt = now
+
+	r.cards[c.ID] = c
+	return c, nil
+}
+
+func (r *memCardRepo) Update(ctx context.Context, listID, id string, ch CardChanges) (model.Card, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.cards[id]
+	if !ok || cur.ListID != listID {
+		return model.Card{}, ErrNotFound
+	}
+
+	// 只修改传了的字段，位置和所属列表保持不变
+	if ch.Title != nil {
+		cur.Title = *ch.Title
+	}
+	if ch.Description != nil {
+		cur.Description = *ch.Description
+	}
+	if due, changed := ch.dueDate(cur.DueDate); changed {
+		cur.DueDate = due
+		cur.RemindedAt = nil
+	}
+	if ch.Reminder != nil {
+		cur.Reminder = *ch.Reminder
+	}
+	if ch.Labels != nil {
+		cur.Labels = append([]string(nil), (*ch.Labels)...)
+	}
+	cur.UpdatedAt = time.Now()
+
+	r.cards[id] = cur
+	return cur, nil
+}
+
+func (r *memCardRepo) Move(ctx context.Context, fromListID, id, toBoardID, toListID string, position int) ([]model.Card, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	src, dst, ok := moveCard(r.byList(fromListID), r.byList(toListID), id, toBoardID, toListID, position)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	for _, c := range src {
+		r.cards[c.ID] = c
+	}
+	for i, c := range dst {
+		if c.ID == id {
+			dst[i].UpdatedAt = now
+			c = dst[i]
+		}
+		r.cards[c.ID] = c
+	}
+	return dst, nil
+}
+
+func (r *memCardRepo) Delete(ctx context.Context, listID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.cards[id]
+	if !ok || c.ListID != listID {
+		return ErrNotFound
+	}
+	delete(r.cards, id)
+
+	for i, rest := range r.byList(listID) {
+		rest.Position = i
+		r.cards[rest.ID] = rest
+	}
+	return nil
+}
+
+func (r *memCardRepo) DeleteByList(ctx context.Context, listID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.cards {
+		if c.ListID == listID {
+			delete(r.cards, id)
+		}
+	}
+	return nil
+}
+
+func (r *memCardRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.cards {
+		if c.BoardID == boardID {
+			delete(r.cards, id)
+		}
+	}
+	return nil
+}
+
+func (r *memCardRepo) CountByBoard(ctx context.Context, boardID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, c := range r.cards {
+		if c.BoardID == boardID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memCardRepo) ListDue(ctx context.Context, boardIDs []string, from, to time.Time) ([]model.Card, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	boards := make(map[string]bool, len(boardIDs))
+	for _, id := range boardIDs {
+		boards[id] = true
+	}
+	out := make([]model.Card, 0)
+	for _, c := range r.cards {
+		if c.DueDate == nil || !boards[c.BoardID] || c.DueDate.Before(from) || !c.DueDate.Before(to) {
+			continue
+		}
+		out = append(out, c)
+	}
+	sortByDueDate(out)
+	return out, nil
+}
+
+func (r *memCardRepo) ListReminders(ctx context.Context, before time.Time) ([]model.Card, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Card, 0)
+	for _, c := range r.cards {
+		if c.Reminder && c.RemindedAt == nil && c.DueDate != nil && c.DueDate.Before(before) {
+			out = append(out, c)
+		}
+	}
+	sortByDueDate(out)
+	return out, nil
+}
+
+func (r *memCardRepo) MarkReminded(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.cards[id]
+	if !ok || c.RemindedAt != nil {
+		return false, nil
+	}
+	c.RemindedAt = &at
+	r.cards[id] = c
+	return true, nil
+}
+
+func (r *memCardRepo) CountByList(ctx context.Context, now time.Time) ([]model.ListCardCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := make(map[string]int) // 列表 ID -> 在 out 中的下标
+	var out []model.ListCardCount
+	for _, c := range r.cards {
+		i, ok := idx[c.ListID]
+		if !ok {
+			i = len(out)
+			idx[c.ListID] = i
+			out = append(out, model.ListCardCount{BoardID: c.BoardID, ListID: c.ListID})
+		}
+		out[i].Cards++
+		if c.DueDate != nil && c.DueDate.Before(now) {
+			out[i].Overdue++
+		}
+	}
+	return out, nil
+}
+
+// Ping 内存存储总是可用
+func (r *memCardRepo) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CardLinkRepository 卡片镜像链接仓储接口
+// 只保存两张卡片的 ID，卡片在哪个看板、哪个列表由服务层按卡片查
+type CardLinkRepository interface {
+	// Create 保存一个链接，ID 和时间由仓储生成
+	Create(ctx context.Context, sourceCardID, mirrorCardID, createdBy string) (model.CardLink, error)
+
+	// Get 按 ID 获取链接
+	Get(ctx context.Context, id string) (model.CardLink, error)
+
+	// ListByCard 卡片作为原卡片或者镜像的所有链接，按创建时间排序
+	ListByCard(ctx context.Context, cardID string) ([]model.CardLink, error)
+
+	// Delete 删除一个链接，两张卡片都保留，不存在时返回 ErrNotFound
+	Delete(ctx context.Context, id string) error
+
+	// DeleteByCard 删除卡片作为原卡片或者镜像的所有链接，删除卡片之后使用
+	DeleteByCard(ctx context.Context, cardID string) error
+}
+
+// memCardLinkRepo 卡片镜像链接仓储的内存实现
+type memCardLinkRepo struct {
+	mu    sync.RWMutex
+	links map[string]model.CardLink // key 是链接 ID
+}
+
+// NewMemCardLinkRepo 创建一个新的内存卡片镜像链接仓储
+func NewMemCardLinkRepo() CardLinkRepository {
+	return &memCardLinkRepo{links: make(map[string]model.CardLink)}
+}
+
+func (r *memCardLinkRepo) Create(ctx context.Context, sourceCardID, mirrorCardID, createdBy string) (model.CardLink, error) {
+	l := model.CardLink{
+		ID:           generateID(),
+		SourceCardID: sourceCardID,
+		MirrorCardID: mirrorCardID,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+
+	r.mu.Lock()
+	r.links[l.ID] = l
+	r.mu.Unlock()
+
+	return l, nil
+}
+
+func (r *memCardLinkRepo) Get(ctx context.Context, id string) (model.CardLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.links[id]
+	if !ok {
+		return model.CardLink{}, ErrNotFound
+	}
+	return l, nil
+}
+
+func (r *memCardLinkRepo) ListByCard(ctx context.Context, cardID string) ([]model.CardLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.CardLink, 0)
+	for _, l := range r.links {
+		if l.SourceCardID == cardID || l.MirrorCardID == cardID {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *memCardLinkRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.links[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.links, id)
+	return nil
+}
+
+func (r *memCardLinkRepo) DeleteByCard(ctx context.Context, cardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, l := range r.links {
+		if l.SourceCardID == cardID || l.MirrorCardID == cardID {
+			delete(r.links, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteCardLinkRepo 是 CardLinkRepository 的 SQLite 实现
+type sqliteCardLinkRepo struct {
+	db *gorm.DB
+}
+
+// cardLinkRow 卡片镜像链接表结构
+// source_card_id 和 mirror_card_id 上的索引见 internal/migrations
+type cardLinkRow struct {
+	ID           string `gorm:"primaryKey"`
+	SourceCardID string
+	MirrorCardID string
+	CreatedBy    string
+	CreatedAt    time.Time
+}
+
+// NewSQLiteCardLinkRepo 创建一个新的 SQLite 卡片镜像链接仓储
+func NewSQLiteCardLinkRepo(db *gorm.DB) (CardLinkRepository, error) {
+	return &sqliteCardLinkRepo{db: db}, nil
+}
+
+func (r *sqliteCardLinkRepo) toModel(row cardLinkRow) model.CardLink {
+	return model.CardLink{
+		ID:           row.ID,
+		SourceCardID: row.SourceCardID,
+		MirrorCardID: row.MirrorCardID,
+		CreatedBy:    row.CreatedBy,
+		CreatedAt:    row.CreatedAt,
+	}
+}
+
+func (r *sqliteCardLinkRepo) Create(ctx context.Context, sourceCardID, mirrorCardID, createdBy string) (model.CardLink, error) {
+	rw := cardLinkRow{
+		ID:           generateID(),
+		SourceCardID: sourceCardID,
+		MirrorCardID: mirrorCardID,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.CardLink{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteCardLinkRepo) Get(ctx context.Context, id string) (model.CardLink, error) {
+	var rw cardLinkRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.CardLink{}, ErrNotFound
+		}
+		return model.CardLink{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteCardLinkRepo) ListByCard(ctx context.Context, cardID string) ([]model.CardLink, error) {
+	var rows []cardLinkRow
+	if err := r.db.WithContext(ctx).Where("source_card_id = ? OR mirror_card_id = ?", cardID, cardID).
+		Order("created_at asc, id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.CardLink, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteCardLinkRepo) Delete(ctx context.Context, id string) error {
+	res := r.db.WithContext(ctx).Where("id = ?", id).Delete(&cardLinkRow{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteCardLinkRepo) DeleteByCard(ctx context.Context, cardID string) error {
+	return r.db.WithContext(ctx).Where("source_card_id = ? OR mirror_card_id = ?", cardID, cardID).Delete(&cardLinkRow{}).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteCardLinkRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"strings"
+	"time"
+)
+
+// sqliteCardRepo 是 CardRepository 的 SQLite 实现
+type sqliteCardRepo struct {
+	db *gorm.DB
+}
+
+// cardRow 卡片表结构
+type cardRow struct {
+	ID      string `gorm:"primaryKey"`
+	BoardID string
+
+	// ListID 和 Position 有联合索引 idx_card_list_position（见 internal/migrations）
+	// 列表内按位置排序时直接走索引
+	ListID      string
+	Title       string
+	Description string
+	Position    int
+
+	// Labels 标签用逗号连接保存，服务层保证标签里没有逗号
+	Labels string
+
+	// DueDate 使用指针，数据库中对应可以为 NULL 的列
+	DueDate *time.Time
+
+	// Reminder、RemindedAt 截止日期提醒，(reminder, reminded_at) 上的索引见 internal/migrations
+	Reminder   bool
+	RemindedAt *time.Time
+
+	// Sample 示例卡片，清除示例内容时按看板逐个列表查出来删除，不单独建索引
+	Sample bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// splitLabels 把 labels 列拆回标签，空字符串是没有标签
+func splitLabels(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// NewSQLiteCardRepo 创建一个新的 SQLite 卡片仓储
+func NewSQLiteCardRepo(db *gorm.DB) (CardRepository, error) {
+	return &sqliteCardRepo{db: db}, nil
+}
+
+func (r *sqliteCardRepo) toModel(row cardRow) model.Card {
+	return model.Card{
+		ID:          row.ID,
+		BoardID:     row.BoardID,
+		ListID:      row.ListID,
+		Title:       row.Title,
+		Description: row.Description,
+		Labels:      splitLabels(row.Labels),
+		Position:    row.Position,
+		DueDate:     row.DueDate,
+		Reminder:    row.Reminder,
+		RemindedAt:  row.RemindedAt,
+		Sample:      row.Sample,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+func (r *sqliteCardRepo) ListByList(ctx context.Context, listID string) ([]model.Card, error) {
+	var rows []cardRow
+	if err := r.db.WithContext(ctx).Where("list_id = ?", listID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Card, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteCardRepo) Get(ctx context.Context, listID, id string) (model.Card, error) {
+	var rw cardRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ? AND list_id = ?", id, listID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Card{}, ErrNotFound
+		}
+		return model.Card{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteCardRepo) GetByID(ctx context.Context, id string) (model.Card, error) {
+	var rw cardRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Card{}, ErrNotFound
+		}
+		return model.Card{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// Create 在列表末尾创建卡片
+// 计算位置和插入放在同一个事务里
+func (r *sqliteCardRepo) Create(ctx context.Context, c model.Card) (model.Card, error) {
+	now := time.Now()
+	rw := cardRow{
+		ID:          generateID(),
+		BoardID:     c.BoardID,
+		ListID:      c.ListID,
+		Title:       c.Title,
+		Description: c.Description,
+		Labels:      strings.Join(c.Labels, ","),
+		DueDate:     c.DueDate,
+		Reminder:    c.Reminder,
+		Sample:      c.Sample,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&cardRow{}).Where("list_id = ?", c.ListID).Count(&n).Error; err != nil {
+			return err
+		}
+		rw.Position = int(n)
+		return tx.Create(&rw).Error
+	})
+	if err != nil {
+		return model.Card{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// Update 相当于 SQL: UPDATE card_rows SET <传了的列>, updated_at=? WHERE id=? AND list_id=?
+// 没传的列不写，两个请求同时修改不同的字段不会互相覆盖
+// 截止日期要和原来的比较才知道要不要清空 reminded_at，所以读和写放在一个事务里
+func (r *sqliteCardRepo) Update(ctx context.Context, listID, id string, ch CardChanges) (model.Card, error) {
+	var rw cardRow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rw, "id = ? AND list_id = ?", id, listID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		rw.UpdatedAt = time.Now()
+		updates := map[string]interface{}{"updated_at": rw.UpdatedAt}
+		if ch.Title != nil {
+			rw.Title = *ch.Title
+			updates["title"] = rw.Title
+		}
+		if ch.Description != nil {
+			rw.Description = *ch.Description
+			updates["description"] = rw.Description
+		}
+		if due, changed := ch.dueDate(rw.DueDate); changed {
+			rw.DueDate, rw.RemindedAt = due, nil
+			updates["due_date"] = due
+			updates["reminded_at"] = nil
+		}
+		if ch.Reminder != nil {
+			rw.Reminder = *ch.Reminder
+			updates["reminder"] = rw.Reminder
+		}
+		if ch.Labels != nil {
+			rw.Labels = strings.Join(*ch.Labels, ",")
+			updates["labels"] = rw.Labels
+		}
+		return tx.Model(&cardRow{}).Where("id = ? AND list_id = ?", id, listID).Updates(updates).Error
+	})
+	if err != nil {
+		return model.Card{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// byList 在事务中读取列表的所有卡片，按位置排序
+func (r *sqliteCardRepo) byList(tx *gorm.DB, listID string) ([]model.Card, error) {
+	var rows []cardRow
+	if err := tx.Where("list_id = ?", listID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Card, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Move 把卡片移动到目标列表的指定位置
+// 读取、重新编号和写回都在一个事务里，拖拽时不会出现位置重复或断档
+func (r *sqliteCardRepo) Move(ctx context.Context, fromListID, id, toBoardID, toListID string, position int) ([]model.Card, error) {
+	var out []model.Card
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		source, err := r.byList(tx, fromListID)
+		if err != nil {
+			return err
+		}
+		target := source
+		if toListID != fromListID {
+			if target, err = r.byList(tx, toListID); err != nil {
+				return err
+			}
+		}
+
+		src, dst, ok := moveCard(source, target, id, toBoardID, toListID, position)
+		if !ok {
+			return ErrNotFound
+		}
+
+		// 记录移动前的位置、列表和看板，只更新真正变化的卡片
+		before := make(map[string]model.Card, len(source)+len(target))
+		for _, c := range source {
+			before[c.ID] = c
+		}
+		for _, c := range target {
+			before[c.ID] = c
+		}
+
+		now := time.Now()
+		save := func(cards []model.Card) error {
+			for i, c := range cards {
+				old := before[c.ID]
+				if old.Position == c.Position && old.ListID == c.ListID && old.BoardID == c.BoardID {
+					continue
+				}
+				cards[i].UpdatedAt = now
+				if err := tx.Model(&cardRow{}).Where("id = ?", c.ID).
+					Updates(map[string]interface{}{"board_id": c.BoardID, "list_id": c.ListID, "position": c.Position, "updated_at": now}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if toListID != fromListID {
+			if err := save(src); err != nil {
+				return err
+			}
+		}
+		if err := save(dst); err != nil {
+			return err
+		}
+		out = dst
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Delete 删除卡片，并把后面的卡片依次前移
+func (r *sqliteCardRepo) Delete(ctx context.Context, listID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rw cardRow
+		if err := tx.First(&rw, "id = ? AND list_id = ?", id, listID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&cardRow{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&cardRow{}).
+			Where("list_id = ? AND position > ?", listID, rw.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+func (r *sqliteCardRepo) DeleteByList(ctx context.Context, listID string) error {
+	return r.db.WithContext(ctx).Delete(&cardRow{}, "list_id = ?", listID).Error
+}
+
+func (r *sqliteCardRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Delete(&cardRow{}, "board_id = ?", boardID).Error
+}
+
+func (r *sqliteCardRepo) CountByBoard(ctx context.Context, boardID string) (int, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&cardRow{}).Where("board_id = ?", boardID).Count(&n).Error; err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// ListDue 按截止日期列出卡片
+// 和 CountByList 一样用 julianday 比较，due_date 里可能带着不同的时区
+func (r *sqliteCardRepo) ListDue(ctx context.Context, boardIDs []string, from, to time.Time) ([]model.Card, error) {
+	if len(boardIDs) == 0 {
+		return []model.Card{}, nil
+	}
+	q := r.db.WithContext(ctx).
+		Where("board_id IN ? AND due_date IS NOT NULL AND julianday(due_date) < julianday(?)", boardIDs, to.UTC())
+	if !from.IsZero() {
+		q = q.Where("julianday(due_date) >= julianday(?)", from.UTC())
+	}
+	var rows []cardRow
+	if err := q.Order("julianday(due_date) asc, id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Card, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteCardRepo) ListReminders(ctx context.Context, before time.Time) ([]model.Card, error) {
+	var rows []cardRow
+	err := r.db.WithContext(ctx).
+		Where("reminder = ? AND reminded_at IS NULL AND due_date IS NOT NULL AND julianday(due_date) < julianday(?)", true, before.UTC()).
+		Order("julianday(due_date) asc, id asc").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.Card, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// MarkReminded 条件更新：reminded_at 还是 NULL 才写入，影响的行数说明是不是这次拿到的
+// 用 UpdateColumn，不修改 updated_at：发出提醒不算修改卡片
+func (r *sqliteCardRepo) MarkReminded(ctx context.Context, id string, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&cardRow{}).
+		Where("id = ? AND reminded_at IS NULL", id).
+		UpdateColumn("reminded_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// CountByList 一条 GROUP BY 查询统计所有列表，不把卡片读出来
+// 同一个列表的卡片 board_id 都相同，所以按 (board_id, list_id) 分组和按 list_id 分组结果一样
+//
+// due_date 按文本保存，带着用户提交时的时区（例如 2026-01-01 09:00:00+08:00），
+// 直接按字符串比较会差几个小时，用 julianday 换算成同一个时间轴再比较
+func (r *sqliteCardRepo) CountByList(ctx context.Context, now time.Time) ([]model.ListCardCount, error) {
+	var out []model.ListCardCount
+	err := r.db.WithContext(ctx).Model(&cardRow{}).
+		Select("board_id, list_id, COUNT(*) AS cards, SUM(CASE WHEN julianday(due_date) < julianday(?) THEN 1 ELSE 0 END) AS overdue", now.UTC()).
+		Group("board_id, list_id").
+		Scan(&out).Error
+	return out, err
+}
+
+// Ping 向数据库发一次 ping，连接池里没有可用连接时会新建一个
+func (r *sqliteCardRepo) Ping(ctx context.Context) error {
+	db, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteCardRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
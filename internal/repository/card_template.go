@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CardTemplateRepository 卡片模板仓储接口
+type CardTemplateRepository interface {
+	// ListByBoard 列出看板的所有模板，按名称排序
+	ListByBoard(ctx context.Context, boardID string) ([]model.CardTemplate, error)
+
+	// Get 获取看板的某个模板，不存在或者不属于这个看板时返回 ErrNotFound
+	Get(ctx context.Context, boardID, id string) (model.CardTemplate, error)
+
+	// Create 保存一个新模板，ID 和时间由仓储生成
+	Create(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error)
+
+	// Update 按 t.BoardID 和 t.ID 整体替换模板的内容（名称、标题、描述、标签、清单），不存在时返回 ErrNotFound
+	Update(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error)
+
+	// Delete 删除看板的某个模板，不存在或者不属于这个看板时返回 ErrNotFound
+	Delete(ctx context.Context, boardID, id string) error
+
+	// DeleteByBoard 删除看板的所有模板，删除看板时使用
+	DeleteByBoard(ctx context.Context, boardID string) error
+}
+
+// memCardTemplateRepo 卡片模板仓储的内存实现
+type memCardTemplateRepo struct {
+	mu        sync.RWMutex
+	templates map[string]model.CardTemplate
+}
+
+// NewMemCardTemplateRepo 创建一个新的内存卡片模板仓储
+func NewMemCardTemplateRepo() CardTemplateRepository {
+	return &memCardTemplateRepo{templates: make(map[string]model.CardTemplate)}
+}
+
+// cloneTemplate 复制模板里的切片，调用方之后修改自己的切片不会影响仓储里的数据
+func cloneTemplate(t model.CardTemplate) model.CardTemplate {
+	t.Labels = append([]string(nil), t.Labels...)
+	if t.Checklist != nil {
+		cl := *t.Checklist
+		cl.Items = append([]string(nil), cl.Items...)
+		t.Checklist = &cl
+	}
+	return t
+}
+
+func (r *memCardTemplateRepo) ListByBoard(ctx context.Context, boardID string) ([]model.CardTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.CardTemplate, 0)
+	for _, t := range r.templates {
+		if t.BoardID == boardID {
+			out = append(out, cloneTemplate(t))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *memCardTemplateRepo) Get(ctx context.Context, boardID, id string) (model.CardTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.templates[id]
+	if !ok || t.BoardID != boardID {
+		return model.CardTemplate{}, ErrNotFound
+	}
+	return cloneTemplate(t), nil
+}
+
+func (r *memCardTemplateRepo) Create(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	t = cloneTemplate(t)
+	t.ID = generateID()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+
+	r.mu.Lock()
+	r.templates[t.ID] = t
+	r.mu.Unlock()
+	return cloneTemplate(t), nil
+}
+
+func (r *memCardTemplateRepo) Update(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.templates[t.ID]
+	if !ok || cur.BoardID != t.BoardID {
+		return model.CardTemplate{}, ErrNotFound
+	}
+	t = cloneTemplate(t)
+	t.CreatedBy, t.CreatedAt = cur.CreatedBy, cur.CreatedAt
+	t.UpdatedAt = time.Now()
+	r.templates[t.ID] = t
+	return cloneTemplate(t), nil
+}
+
+func (r *memCardTemplateRepo) Delete(ctx context.Context, boardID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.templates[id]
+	if !ok || t.BoardID != boardID {
+		return ErrNotFound
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+func (r *memCardTemplateRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.templates {
+		if t.BoardID == boardID {
+			delete(r.templates, id)
+		}
+	}
+	return nil
+}
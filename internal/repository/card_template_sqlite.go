@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"strings"
+	"time"
+)
+
+// sqliteCardTemplateRepo 是 CardTemplateRepository 的 SQLite 实现
+type sqliteCardTemplateRepo struct {
+	db *gorm.DB
+}
+
+// cardTemplateRow 卡片模板表结构
+// board_id 上的索引见 internal/migrations
+type cardTemplateRow struct {
+	ID          string `gorm:"primaryKey"`
+	BoardID     string
+	Name        string
+	Title       string
+	Description string
+
+	// Labels 和卡片一样用逗号连接保存
+	Labels string
+
+	// Checklist 清单序列化成 JSON 保存（条目里可能有逗号），空串表示没有清单
+	Checklist string
+
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewSQLiteCardTemplateRepo 创建一个新的 SQLite 卡片模板仓储
+func NewSQLiteCardTemplateRepo(db *gorm.DB) (CardTemplateRepository, error) {
+	return &sqliteCardTemplateRepo{db: db}, nil
+}
+
+func (r *sqliteCardTemplateRepo) toModel(row cardTemplateRow) (model.CardTemplate, error) {
+	t := model.CardTemplate{
+		ID:          row.ID,
+		BoardID:     row.BoardID,
+		Name:        row.Name,
+		Title:       row.Title,
+		Description: row.Description,
+		Labels:      splitLabels(row.Labels),
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	if row.Checklist != "" {
+		t.Checklist = &model.TemplateChecklist{}
+		if err := json.Unmarshal([]byte(row.Checklist), t.Checklist); err != nil {
+			return model.CardTemplate{}, err
+		}
+	}
+	return t, nil
+}
+
+// fromModel 把模板的内容转成表里的列，ID 和时间由调用方填
+func (r *sqliteCardTemplateRepo) fromModel(t model.CardTemplate) (cardTemplateRow, error) {
+	rw := cardTemplateRow{
+		BoardID:     t.BoardID,
+		Name:        t.Name,
+		Title:       t.Title,
+		Description: t.Description,
+		Labels:      strings.Join(t.Labels, ","),
+		CreatedBy:   t.CreatedBy,
+	}
+	if t.Checklist != nil {
+		b, err := json.Marshal(t.Checklist)
+		if err != nil {
+			return cardTemplateRow{}, err
+		}
+		rw.Checklist = string(b)
+	}
+	return rw, nil
+}
+
+func (r *sqliteCardTemplateRepo) ListByBoard(ctx context.Context, boardID string) ([]model.CardTemplate, error) {
+	var rows []cardTemplateRow
+	if err := r.db.WithContext(ctx).Where("board_id = ?", boardID).Order("name asc, id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.CardTemplate, 0, len(rows))
+	for _, rw := range rows {
+		t, err := r.toModel(rw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (r *sqliteCardTemplateRepo) Get(ctx context.Context, boardID, id string) (model.CardTemplate, error) {
+	var rw cardTemplateRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ? AND board_id = ?", id, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.CardTemplate{}, ErrNotFound
+		}
+		return model.CardTemplate{}, err
+	}
+	return r.toModel(rw)
+}
+
+func (r *sqliteCardTemplateRepo) Create(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	rw, err := r.fromModel(t)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	rw.ID = generateID()
+	rw.CreatedAt = time.Now()
+	rw.UpdatedAt = rw.CreatedAt
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.CardTemplate{}, err
+	}
+	return r.toModel(rw)
+}
+
+// Update 条件里带上 board_id，不能通过别的看板的路径改掉这个模板
+func (r *sqliteCardTemplateRepo) Update(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	rw, err := r.fromModel(t)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	res := r.db.WithContext(ctx).Model(&cardTemplateRow{}).Where("id = ? AND board_id = ?", t.ID, t.BoardID).Updates(map[string]any{
+		"name":        rw.Name,
+		"title":       rw.Title,
+		"description": rw.Description,
+		"labels":      rw.Labels,
+		"checklist":   rw.Checklist,
+		"updated_at":  time.Now(),
+	})
+	if res.Error != nil {
+		return model.CardTemplate{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.CardTemplate{}, ErrNotFound
+	}
+	return r.Get(ctx, t.BoardID, t.ID)
+}
+
+func (r *sqliteCardTemplateRepo) Delete(ctx context.Context, boardID, id string) error {
+	res := r.db.WithContext(ctx).Where("id = ? AND board_id = ?", id, boardID).Delete(&cardTemplateRow{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteCardTemplateRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Where("board_id = ?", boardID).Delete(&cardTemplateRow{}).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteCardTemplateRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChecklistRepository 检查清单仓储接口，清单和清单中的条目都由它管理
+// 和列表、卡片一样，位置由仓储层维护：新建时追加到末尾，移动和删除后重新编号
+// 返回的清单都带着按位置排好序的条目和计算好的进度（Checklist.Progress）
+type ChecklistRepository interface {
+	// ListByCard 按位置顺序列出卡片的所有清单
+	ListByCard(ctx context.Context, cardID string) ([]model.Checklist, error)
+
+	// Get 获取卡片中的单个清单
+	Get(ctx context.Context, cardID, id string) (model.Checklist, error)
+
+	// Create 在卡片末尾创建清单
+	Create(ctx context.Context, cardID, title string) (model.Checklist, error)
+
+	// Rename 修改清单标题
+	Rename(ctx context.Context, cardID, id, title string) (model.Checklist, error)
+
+	// Move 把清单移动到指定位置，返回移动后卡片的全部清单
+	// position 超出范围时移动到最后
+	Move(ctx context.Context, cardID, id string, position int) ([]model.Checklist, error)
+
+	// Delete 删除清单和其中的条目，后面的清单依次前移
+	Delete(ctx context.Context, cardID, id string) error
+
+	// DeleteByCards 删除这些卡片的所有清单和条目，删除卡片、列表、看板时使用
+	DeleteByCards(ctx context.Context, cardIDs []string) error
+
+	// AddItem 在清单末尾添加条目
+	AddItem(ctx context.Context, checklistID, text string) (model.ChecklistItem, error)
+
+	// UpdateItem 修改条目的内容和完成状态，通过 item.ChecklistID 和 item.ID 定位
+	// 从未完成变成完成时记下 CompletedAt，取消完成时清空
+	UpdateItem(ctx context.Context, item model.ChecklistItem) (model.ChecklistItem, error)
+
+	// MoveItem 把条目移动到清单中的指定位置，返回移动后清单的全部条目
+	// position 超出范围时移动到最后
+	MoveItem(ctx context.Context, checklistID, id string, position int) ([]model.ChecklistItem, error)
+
+	// DeleteItem 删除条目，后面的条目依次前移
+	DeleteItem(ctx context.Context, checklistID, id string) error
+
+	// ProgressByCards 按卡片汇总所有清单的进度，列出卡片时一次查出整个列表的进度
+	// 没有条目的卡片不在结果中
+	ProgressByCards(ctx context.Context, cardIDs []string) (map[string]model.ChecklistProgress, error)
+
+	// ProgressByChecklists 和 ProgressByCards 一样，但是按清单分开：卡片 ID -> 清单 ID -> 进度
+	// 进度缓存（见 service.ProgressRollups）加载看板时使用，之后单个清单变了只替换这一个清单的进度
+	// 没有条目的清单不在结果中
+	ProgressByChecklists(ctx context.Context, cardIDs []string) (map[string]map[string]model.ChecklistProgress, error)
+}
+
+// moveTo 把 items[from] 移动到 position（超出范围时移到最后），返回新的顺序，不修改 items
+// 清单和条目的移动共用这段逻辑，重新编号由调用方完成
+func moveTo[T any](items []T, from, position int) []T {
+	if position >= len(items) {
+		position = len(items) - 1
+	}
+	moving := items[from]
+	rest := append(append([]T{}, items[:from]...), items[from+1:]...)
+	out := make([]T, 0, len(items))
+	out = append(out, rest[:position]...)
+	out = append(out, moving)
+	return append(out, rest[position:]...)
+}
+
+// itemsProgress 按条目计算清单的进度
+func itemsProgress(items []model.ChecklistItem) model.ChecklistProgress {
+	done := 0
+	for _, it := range items {
+		if it.Done {
+			done++
+		}
+	}
+	return model.NewChecklistProgress(done, len(items))
+}
+
+// setCompletedAt 按完成状态的变化维护 CompletedAt
+// 一直是完成状态时保留原来的完成时间，只改内容不会刷新它
+func setCompletedAt(before, after *model.ChecklistItem, now time.Time) {
+	switch {
+	case !after.Done:
+		after.CompletedAt = nil
+	case !before.Done:
+		after.CompletedAt = &now
+	default:
+		after.CompletedAt = before.CompletedAt
+	}
+}
+
+// memChecklistRepo 检查清单仓储的内存实现
+// 清单和条目分开保存，读取时再把条目装进清单，和 SQLite 实现的两张表对应
+type memChecklistRepo struct {
+	mu         sync.RWMutex
+	checklists map[string]model.Checklist     // key 是清单 ID，Items 字段不使用
+	items      map[string]model.ChecklistItem // key 是条目 ID
+}
+
+// NewMemChecklistRepo 创建一个新的内存检查清单仓储
+func NewMemChecklistRepo() ChecklistRepository {
+	return &memChecklistRepo{
+		checklists: make(map[string]model.Checklist),
+		items:      make(map[string]model.ChecklistItem),
+	}
+}
+
+// byCard 返回卡片的所有清单（不带条目），按位置排序
+// 调用方必须已经持有锁
+func (r *memChecklistRepo) byCard(cardID string) []model.Checklist {
+	out := make([]model.Checklist, 0)
+	for _, c := range r.checklists {
+		if c.CardID == cardID {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out
+}
+
+// itemsOf 返回清单的所有条目，按位置排序
+// 调用方必须已经持有锁
+func (r *memChecklistRepo) itemsOf(checklistID string) []model.ChecklistItem {
+	out := make([]model.ChecklistItem, 0)
+	for _, it := range r.items {
+		if it.ChecklistID == checklistID {
+			out = append(out, it)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out
+}
+
+// withItems 装上条目并计算进度
+// 调用方必须已经持有锁
+func (r *memChecklistRepo) withItems(c model.Checklist) model.Checklist {
+	c.Items = r.itemsOf(c.ID)
+	c.Progress = itemsProgress(c.Items)
+	return c
+}
+
+func (r *memChecklistRepo) ListByCard(ctx context.Context, cardID string) ([]model.Checklist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := r.byCard(cardID)
+	for i := range out {
+		out[i] = r.withItems(out[i])
+	}
+	return out, nil
+}
+
+func (r *memChecklistRepo) Get(ctx context.Context, cardID, id string) (model.Checklist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.checklists[id]
+	if !ok || c.CardID != cardID {
+		return model.Checklist{}, ErrNotFound
+	}
+	return r.withItems(c), nil
+}
+
+func (r *memChecklistRepo) Create(ctx context.Context, cardID, title string) (model.Checklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	c := model.Checklist{
+		ID:        generateID(),
+		CardID:    cardID,
+		Title:     title,
+		Position:  len(r.byCard(cardID)), // 追加到末尾
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.checklists[c.ID] = c
+	return r.withItems(c), nil
+}
+
+func (r *memChecklistRepo) Rename(ctx context.Context, cardID, id, title string) (model.Checklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.checklists[id]
+	if !ok || c.CardID != cardID {
+		return model.Checklist{}, ErrNotFound
+	}
+	c.Title = title
+	c.UpdatedAt = time.Now()
+	r.checklists[id] = c
+	return r.withItems(c), nil
+}
+
+func (r *memChecklistRepo) Move(ctx context.Context, cardID, id string, position int) ([]model.Checklist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := r.byCard(cardID)
+	from := -1
+	for i, c := range all {
+		if c.ID == id {
+			from = i
+		}
+	}
+	if from < 0 {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	out := moveTo(all, from, position)
+	for i := range out {
+		if out[i].Position != i {
+			out[i].Position = i
+			out[i].UpdatedAt = now
+		}
+		r.checklists[out[i].ID] = out[i]
+		out[i] = r.withItems(out[i])
+	}
+	return out, nil
+}
+
+func (r *memChecklistRepo) Delete(ctx context.Context, cardID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.checklists[id]
+	if !ok || c.CardID != cardID {
+		return ErrNotFound
+	}
+	r.deleteChecklist(id)
+
+	for i, rest := range r.byCard(cardID) {
+		rest.Position = i
+		r.checklists[rest.ID] = rest
+	}
+	return nil
+}
+
+// deleteChecklist 删除清单和它的条目
+// 调用方必须已经持有锁
+func (r *memChecklistRepo) deleteChecklist(id string) {
+	delete(r.checklists, id)
+	for itemID, it := range r.items {
+		if it.ChecklistID == id {
+			delete(r.items, itemID)
+		}
+	}
+}
+
+func (r *memChecklistRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cardID := range cardIDs {
+		for _, c := range r.byCard(cardID) {
+			r.deleteChecklist(c.ID)
+		}
+	}
+	return nil
+}
+
+func (r *memChecklistRepo) AddItem(ctx context.Context, checklistID, text string) (model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.checklists[checklistID]; !ok {
+		return model.ChecklistItem{}, ErrNotFound
+	}
+	now := time.Now()
+	it := model.ChecklistItem{
+		ID:          generateID(),
+		ChecklistID: checklistID,
+		Text:        text,
+		Position:    len(r.itemsOf(checklistID)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.items[it.ID] = it
+	return it, nil
+}
+
+func (r *memChecklistRepo) UpdateItem(ctx context.Context, item model.ChecklistItem) (model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before, ok := r.items[item.ID]
+	if !ok || before.ChecklistID != item.ChecklistID {
+		return model.ChecklistItem{}, ErrNotFound
+	}
+	now := time.Now()
+	after := before
+	after.Text = item.Text
+	after.Done = item.Done
+	after.UpdatedAt = now
+	setCompletedAt(&before, &after, now)
+	r.items[item.ID] = after
+	return after, nil
+}
+
+func (r *memChecklistRepo) MoveItem(ctx context.Context, checklistID, id string, position int) ([]model.ChecklistItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := r.itemsOf(checklistID)
+	from := -1
+	for i, it := range all {
+		if it.ID == id {
+			from = i
+		}
+	}
+	if from < 0 {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	out := moveTo(all, from, position)
+	for i := range out {
+		if out[i].Position != i {
+			out[i].Position = i
+			out[i].UpdatedAt = now
+		}
+		r.items[out[i].ID] = out[i]
+	}
+	return out, nil
+}
+
+func (r *memChecklistRepo) DeleteItem(ctx context.Context, checklistID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	it, ok := r.items[id]
+	if !ok || it.ChecklistID != checklistID {
+		return ErrNotFound
+	}
+	delete(r.items, id)
+
+	for i, rest := range r.itemsOf(checklistID) {
+		rest.Position = i
+		r.items[rest.ID] = rest
+	}
+	return nil
+}
+
+func (r *memChecklistRepo) ProgressByCards(ctx context.Context, cardIDs []string) (map[string]model.ChecklistProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]model.ChecklistProgress)
+	for _, cardID := range cardIDs {
+		var p model.ChecklistProgress
+		for _, c := range r.byCard(cardID) {
+			p = p.Add(itemsProgress(r.itemsOf(c.ID)))
+		}
+		if p.Total > 0 {
+			out[cardID] = p
+		}
+	}
+	return out, nil
+}
+
+func (r *memChecklistRepo) ProgressByChecklists(ctx context.Context, cardIDs []string) (map[string]map[string]model.ChecklistProgress, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]map[string]model.ChecklistProgress)
+	for _, cardID := range cardIDs {
+		for _, c := range r.byCard(cardID) {
+			p := itemsProgress(r.itemsOf(c.ID))
+			if p.Total == 0 {
+				continue
+			}
+			if out[cardID] == nil {
+				out[cardID] = make(map[string]model.ChecklistProgress)
+			}
+			out[cardID][c.ID] = p
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteChecklistRepo 是 ChecklistRepository 的 SQLite 实现
+type sqliteChecklistRepo struct {
+	db *gorm.DB
+}
+
+// checklistRow 清单表结构
+// (card_id, position) 上的索引 idx_checklist_card_position 见 internal/migrations
+type checklistRow struct {
+	ID        string `gorm:"primaryKey"`
+	CardID    string
+	Title     string
+	Position  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// checklistItemRow 清单条目表结构
+// (checklist_id, position) 上的索引 idx_checklist_item_position 见 internal/migrations
+type checklistItemRow struct {
+	ID          string `gorm:"primaryKey"`
+	ChecklistID string
+	Text        string
+	Done        bool
+	Position    int
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewSQLiteChecklistRepo 创建一个新的 SQLite 检查清单仓储
+func NewSQLiteChecklistRepo(db *gorm.DB) (ChecklistRepository, error) {
+	return &sqliteChecklistRepo{db: db}, nil
+}
+
+func (r *sqliteChecklistRepo) toModel(row checklistRow) model.Checklist {
+	return model.Checklist{
+		ID:        row.ID,
+		CardID:    row.CardID,
+		Title:     row.Title,
+		Position:  row.Position,
+		Items:     []model.ChecklistItem{},
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+func (r *sqliteChecklistRepo) itemToModel(row checklistItemRow) model.ChecklistItem {
+	return model.ChecklistItem{
+		ID:          row.ID,
+		ChecklistID: row.ChecklistID,
+		Text:        row.Text,
+		Done:        row.Done,
+		Position:    row.Position,
+		CompletedAt: row.CompletedAt,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// byCard 按位置顺序查询卡片的所有清单，带上条目
+// 条目用一次 IN 查询取出，不是每个清单查一次
+func (r *sqliteChecklistRepo) byCard(tx *gorm.DB, cardID string) ([]model.Checklist, error) {
+	var rows []checklistRow
+	if err := tx.Where("card_id = ?", cardID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Checklist, 0, len(rows))
+	ids := make([]string, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+		ids = append(ids, rw.ID)
+	}
+	if err := r.fillItems(tx, out, ids); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fillItems 查出清单的条目，装进对应的清单并计算进度
+func (r *sqliteChecklistRepo) fillItems(tx *gorm.DB, lists []model.Checklist, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var rows []checklistItemRow
+	if err := tx.Where("checklist_id IN ?", ids).Order("position asc").Find(&rows).Error; err != nil {
+		return err
+	}
+	items := make(map[string][]model.ChecklistItem, len(ids))
+	for _, rw := range rows {
+		items[rw.ChecklistID] = append(items[rw.ChecklistID], r.itemToModel(rw))
+	}
+	for i := range lists {
+		if its, ok := items[lists[i].ID]; ok {
+			lists[i].Items = its
+		}
+		lists[i].Progress = itemsProgress(lists[i].Items)
+	}
+	return nil
+}
+
+// itemsOf 按位置顺序查询清单的所有条目
+func (r *sqliteChecklistRepo) itemsOf(tx *gorm.DB, checklistID string) ([]model.ChecklistItem, error) {
+	var rows []checklistItemRow
+	if err := tx.Where("checklist_id = ?", checklistID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.ChecklistItem, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.itemToModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteChecklistRepo) ListByCard(ctx context.Context, cardID string) ([]model.Checklist, error) {
+	return r.byCard(r.db.WithContext(ctx), cardID)
+}
+
+func (r *sqliteChecklistRepo) Get(ctx context.Context, cardID, id string) (model.Checklist, error) {
+	db := r.db.WithContext(ctx)
+	var rw checklistRow
+	if err := db.First(&rw, "id = ? AND card_id = ?", id, cardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Checklist{}, ErrNotFound
+		}
+		return model.Checklist{}, err
+	}
+	out := []model.Checklist{r.toModel(rw)}
+	if err := r.fillItems(db, out, []string{rw.ID}); err != nil {
+		return model.Checklist{}, err
+	}
+	return out[0], nil
+}
+
+// Create 在卡片末尾创建清单
+// 计算位置和插入放在同一个事务里
+func (r *sqliteChecklistRepo) Create(ctx context.Context, cardID, title string) (model.Checklist, error) {
+	now := time.Now()
+	rw := checklistRow{
+		ID:        generateID(),
+		CardID:    cardID,
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&checklistRow{}).Where("card_id = ?", cardID).Count(&n).Error; err != nil {
+			return err
+		}
+		rw.Position = int(n)
+		return tx.Create(&rw).Error
+	})
+	if err != nil {
+		return model.Checklist{}, err
+	}
+	c := r.toModel(rw)
+	c.Progress = itemsProgress(c.Items)
+	return c, nil
+}
+
+func (r *sqliteChecklistRepo) Rename(ctx context.Context, cardID, id, title string) (model.Checklist, error) {
+	res := r.db.WithContext(ctx).Model(&checklistRow{}).Where("id = ? AND card_id = ?", id, cardID).
+		Updates(map[string]any{"title": title, "updated_at": time.Now()})
+	if res.Error != nil {
+		return model.Checklist{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.Checklist{}, ErrNotFound
+	}
+	return r.Get(ctx, cardID, id)
+}
+
+// Move 把清单移动到指定位置
+// 整张卡片的清单重新编号，在一个事务里完成，只更新位置真正变化的清单
+func (r *sqliteChecklistRepo) Move(ctx context.Context, cardID, id string, position int) ([]model.Checklist, error) {
+	var out []model.Checklist
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		all, err := r.byCard(tx, cardID)
+		if err != nil {
+			return err
+		}
+		from := -1
+		for i, c := range all {
+			if c.ID == id {
+				from = i
+			}
+		}
+		if from < 0 {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		out = moveTo(all, from, position)
+		for i := range out {
+			if out[i].Position == i {
+				continue
+			}
+			out[i].Position = i
+			out[i].UpdatedAt = now
+			if err := tx.Model(&checklistRow{}).Where("id = ?", out[i].ID).
+				Updates(map[string]any{"position": i, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Delete 删除清单和其中的条目，并把后面的清单依次前移
+func (r *sqliteChecklistRepo) Delete(ctx context.Context, cardID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rw checklistRow
+		if err := tx.First(&rw, "id = ? AND card_id = ?", id, cardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&checklistItemRow{}, "checklist_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&checklistRow{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&checklistRow{}).
+			Where("card_id = ? AND position > ?", cardID, rw.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+func (r *sqliteChecklistRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	if len(cardIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 相当于 SQL: DELETE FROM checklist_item_rows WHERE checklist_id IN (SELECT id FROM checklist_rows WHERE card_id IN ?)
+		ids := tx.Model(&checklistRow{}).Select("id").Where("card_id IN ?", cardIDs)
+		if err := tx.Where("checklist_id IN (?)", ids).Delete(&checklistItemRow{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("card_id IN ?", cardIDs).Delete(&checklistRow{}).Error
+	})
+}
+
+// AddItem 在清单末尾添加条目
+func (r *sqliteChecklistRepo) AddItem(ctx context.Context, checklistID, text string) (model.ChecklistItem, error) {
+	now := time.Now()
+	rw := checklistItemRow{
+		ID:          generateID(),
+		ChecklistID: checklistID,
+		Text:        text,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&checklistRow{}).Where("id = ?", checklistID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Model(&checklistItemRow{}).Where("checklist_id = ?", checklistID).Count(&n).Error; err != nil {
+			return err
+		}
+		rw.Position = int(n)
+		return tx.Create(&rw).Error
+	})
+	if err != nil {
+		return model.ChecklistItem{}, err
+	}
+	return r.itemToModel(rw), nil
+}
+
+// UpdateItem 修改条目
+// 读出原来的完成状态和修改放在同一个事务里，CompletedAt 才能按状态变化正确维护
+func (r *sqliteChecklistRepo) UpdateItem(ctx context.Context, item model.ChecklistItem) (model.ChecklistItem, error) {
+	var after model.ChecklistItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rw checklistItemRow
+		if err := tx.First(&rw, "id = ? AND checklist_id = ?", item.ID, item.ChecklistID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		before := r.itemToModel(rw)
+		now := time.Now()
+		after = before
+		after.Text = item.Text
+		after.Done = item.Done
+		after.UpdatedAt = now
+		setCompletedAt(&before, &after, now)
+		return tx.Model(&checklistItemRow{}).Where("id = ?", item.ID).Updates(map[string]any{
+			"text":         after.Text,
+			"done":         after.Done,
+			"completed_at": after.CompletedAt,
+			"updated_at":   now,
+		}).Error
+	})
+	if err != nil {
+		return model.ChecklistItem{}, err
+	}
+	return after, nil
+}
+
+// MoveItem 把条目移动到指定位置，做法和 Move 相同
+func (r *sqliteChecklistRepo) MoveItem(ctx context.Context, checklistID, id string, position int) ([]model.ChecklistItem, error) {
+	var out []model.ChecklistItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		all, err := r.itemsOf(tx, checklistID)
+		if err != nil {
+			return err
+		}
+		from := -1
+		for i, it := range all {
+			if it.ID == id {
+				from = i
+			}
+		}
+		if from < 0 {
+			return ErrNotFound
+		}
+
+		now := time.Now()
+		out = moveTo(all, from, position)
+		for i := range out {
+			if out[i].Position == i {
+				continue
+			}
+			out[i].Position = i
+			out[i].UpdatedAt = now
+			if err := tx.Model(&checklistItemRow{}).Where("id = ?", out[i].ID).
+				Updates(map[string]any{"position": i, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteItem 删除条目，并把后面的条目依次前移
+func (r *sqliteChecklistRepo) DeleteItem(ctx context.Context, checklistID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rw checklistItemRow
+		if err := tx.First(&rw, "id = ? AND checklist_id = ?", id, checklistID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&checklistItemRow{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&checklistItemRow{}).
+			Where("checklist_id = ? AND position > ?", checklistID, rw.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+// ProgressByCards 一条 GROUP BY 查询汇总所有卡片的进度，不把条目读出来
+func (r *sqliteChecklistRepo) ProgressByCards(ctx context.Context, cardIDs []string) (map[string]model.ChecklistProgress, error) {
+	out := make(map[string]model.ChecklistProgress)
+	if len(cardIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		CardID string
+		Done   int
+		Total  int
+	}
+	err := r.db.WithContext(ctx).Table("checklist_item_rows").
+		Select("checklist_rows.card_id AS card_id, SUM(CASE WHEN checklist_item_rows.done THEN 1 ELSE 0 END) AS done, COUNT(*) AS total").
+		Joins("JOIN checklist_rows ON checklist_rows.id = checklist_item_rows.checklist_id").
+		Where("checklist_rows.card_id IN ?", cardIDs).
+		Group("checklist_rows.card_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, rw := range rows {
+		out[rw.CardID] = model.NewChecklistProgress(rw.Done, rw.Total)
+	}
+	return out, nil
+}
+
+// ProgressByChecklists 和 ProgressByCards 是同一条查询，按清单分组
+func (r *sqliteChecklistRepo) ProgressByChecklists(ctx context.Context, cardIDs []string) (map[string]map[string]model.ChecklistProgress, error) {
+	out := make(map[string]map[string]model.ChecklistProgress)
+	if len(cardIDs) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		CardID      string
+		ChecklistID string
+		Done        int
+		Total       int
+	}
+	err := r.db.WithContext(ctx).Table("checklist_item_rows").
+		Select("checklist_rows.card_id AS card_id, checklist_rows.id AS checklist_id, SUM(CASE WHEN checklist_item_rows.done THEN 1 ELSE 0 END) AS done, COUNT(*) AS total").
+		Joins("JOIN checklist_rows ON checklist_rows.id = checklist_item_rows.checklist_id").
+		Where("checklist_rows.card_id IN ?", cardIDs).
+		Group("checklist_rows.card_id, checklist_rows.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, rw := range rows {
+		if out[rw.CardID] == nil {
+			out[rw.CardID] = make(map[string]model.ChecklistProgress)
+		}
+		out[rw.CardID][rw.ChecklistID] = model.NewChecklistProgress(rw.Done, rw.Total)
+	}
+	return out, nil
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteChecklistRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
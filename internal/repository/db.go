@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"os"
+	"time"
+)
+
+// DefaultSQLiteDSN 默认的 SQLite 连接字符串
+// - file:kanban.db: 数据库文件路径
+// - cache=shared: 启用共享缓存，多个连接可以共享缓存
+// - _fk=1: 启用外键约束
+const DefaultSQLiteDSN = "file:kanban.db?cache=shared&_fk=1"
+
+// PoolConfig 数据库连接池配置，SQLite 和 MySQL 共用
+type PoolConfig struct {
+	// MaxOpenConns 最大打开连接数，0 表示不限制
+	MaxOpenConns int
+
+	// MaxIdleConns 最大空闲连接数
+	MaxIdleConns int
+
+	// ConnMaxLifetime 连接最长存活时间，0 表示不限制
+	// MySQL 上应该比服务端的 wait_timeout 短，避免用到已经被服务端关闭的连接
+	ConnMaxLifetime time.Duration
+
+	// ConnMaxIdleTime 连接最长空闲时间，0 表示不限制
+	ConnMaxIdleTime time.Duration
+}
+
+// poolFromEnv 从 <prefix>_MAX_OPEN_CONNS、<prefix>_MAX_IDLE_CONNS、<prefix>_CONN_MAX_LIFETIME、
+// <prefix>_CONN_MAX_IDLE_TIME 读取连接池配置，没有设置的保留 def 里的值
+func poolFromEnv(prefix string, def PoolConfig) (PoolConfig, error) {
+	p := def
+	if err := envInt(prefix+"_MAX_OPEN_CONNS", &p.MaxOpenConns); err != nil {
+		return p, err
+	}
+	if err := envInt(prefix+"_MAX_IDLE_CONNS", &p.MaxIdleConns); err != nil {
+		return p, err
+	}
+	if err := envDuration(prefix+"_CONN_MAX_LIFETIME", &p.ConnMaxLifetime); err != nil {
+		return p, err
+	}
+	if err := envDuration(prefix+"_CONN_MAX_IDLE_TIME", &p.ConnMaxIdleTime); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+// apply 把连接池配置设置到 db 底层的 *sql.DB 上
+func (p PoolConfig) apply(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(p.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(p.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(p.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	return nil
+}
+
+// SQLiteConfig SQLite 连接和连接池配置
+type SQLiteConfig struct {
+	// DSN 连接字符串，默认 DefaultSQLiteDSN
+	DSN string
+
+	PoolConfig
+}
+
+// SQLiteConfigFromEnv 从环境变量读取 SQLite 配置
+// - SQLITE_DSN: 连接字符串，默认 DefaultSQLiteDSN
+// - SQLITE_MAX_OPEN_CONNS: 最大打开连接数，默认 10
+// - SQLITE_MAX_IDLE_CONNS: 最大空闲连接数，默认 10（连接不用关了又开，每次新开连接都要重新读一遍表结构）
+// - SQLITE_CONN_MAX_LIFETIME: 连接最长存活时间，默认不限制
+// - SQLITE_CONN_MAX_IDLE_TIME: 连接最长空闲时间，默认不限制
+func SQLiteConfigFromEnv() (SQLiteConfig, error) {
+	cfg := SQLiteConfig{DSN: os.Getenv("SQLITE_DSN")}
+	if cfg.DSN == "" {
+		cfg.DSN = DefaultSQLiteDSN
+	}
+	pool, err := poolFromEnv("SQLITE", PoolConfig{MaxOpenConns: 10, MaxIdleConns: 10})
+	cfg.PoolConfig = pool
+	return cfg, err
+}
+
+// NewDB 打开 SQLite 数据库并设置连接池
+// 返回的 *gorm.DB 由所有 SQLite 仓储共用：整个进程只有一个连接池，连接数上限对所有仓储一起生效
+// 表和索引由 internal/migrations 创建，迁移也在这个连接上执行
+func NewDB(dsn string, pool PoolConfig) (*gorm.DB, error) {
+	// TranslateError 让 GORM 把驱动的原始错误翻译成通用错误
+	// 例如违反唯一索引时返回 gorm.ErrDuplicatedKey，用户和看板仓储靠它识别邮箱、slug 重复
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true}, gormTracing{}, gormLogging{})
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.apply(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EmbedTokenRepository 嵌入令牌仓储接口
+// 只保存令牌的元数据（看板、过期时间、吊销状态），不保存令牌字符串本身
+type EmbedTokenRepository interface {
+	// Create 为看板创建一条令牌记录
+	Create(ctx context.Context, boardID, createdBy string, expiresAt time.Time) (model.EmbedToken, error)
+
+	// Get 获取单条令牌记录
+	Get(ctx context.Context, id string) (model.EmbedToken, error)
+
+	// ListByBoard 列出某个看板的所有令牌记录
+	ListByBoard(ctx context.Context, boardID string) ([]model.EmbedToken, error)
+
+	// Revoke 吊销令牌，吊销后该令牌不能再访问嵌入页面
+	Revoke(ctx context.Context, id string) (model.EmbedToken, error)
+}
+
+// memEmbedTokenRepo 嵌入令牌仓储的内存实现
+type memEmbedTokenRepo struct {
+	mu     sync.RWMutex
+	tokens map[string]model.EmbedToken
+}
+
+// NewMemEmbedTokenRepo 创建一个新的内存嵌入令牌仓储
+func NewMemEmbedTokenRepo() EmbedTokenRepository {
+	return &memEmbedTokenRepo{
+		tokens: make(map[string]model.EmbedToken),
+	}
+}
+
+// Create 创建令牌记录
+func (r *memEmbedTokenRepo) Create(ctx context.Context, boardID, createdBy string, expiresAt time.Time) (model.EmbedToken, error) {
+	t := model.EmbedToken{
+		ID:        generateID(),
+		BoardID:   boardID,
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	r.tokens[t.ID] = t
+	r.mu.Unlock()
+
+	return t, nil
+}
+
+// Get 获取单条令牌记录
+func (r *memEmbedTokenRepo) Get(ctx context.Context, id string) (model.EmbedToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tokens[id]
+	if !ok {
+		return model.EmbedToken{}, ErrNotFound
+	}
+	return t, nil
+}
+
+// ListByBoard 列出某个看板的所有令牌，最新创建的在前
+func (r *memEmbedTokenRepo) ListByBoard(ctx context.Context, boardID string) ([]model.EmbedToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.EmbedToken, 0)
+	for _, t := range r.tokens {
+		if t.BoardID == boardID {
+			out = append(out, t)
+		}
+	}
+
+	// map 的遍历顺序是随机的，排序后结果才稳定
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// Revoke 吊销令牌
+// 重复吊销是安全的，保留第一次吊销的时间
+func (r *memEmbedTokenRepo) Revoke(ctx context.Context, id string) (model.EmbedToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok {
+		return model.EmbedToken{}, ErrNotFound
+	}
+	if t.RevokedAt == nil {
+		now := time.Now()
+		t.RevokedAt = &now
+		r.tokens[id] = t
+	}
+	return t, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteEmbedTokenRepo 是 EmbedTokenRepository 的 SQLite 实现
+type sqliteEmbedTokenRepo struct {
+	db *gorm.DB
+}
+
+// embedTokenRow 嵌入令牌表结构
+type embedTokenRow struct {
+	ID string `gorm:"primaryKey"`
+
+	// BoardID 加索引，按看板列出令牌时不需要全表扫描
+	BoardID   string
+	CreatedBy string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewSQLiteEmbedTokenRepo 创建一个新的 SQLite 嵌入令牌仓储
+func NewSQLiteEmbedTokenRepo(db *gorm.DB) (EmbedTokenRepository, error) {
+	return &sqliteEmbedTokenRepo{db: db}, nil
+}
+
+func (r *sqliteEmbedTokenRepo) toModel(row embedTokenRow) model.EmbedToken {
+	return model.EmbedToken{
+		ID:        row.ID,
+		BoardID:   row.BoardID,
+		CreatedBy: row.CreatedBy,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func (r *sqliteEmbedTokenRepo) Create(ctx context.Context, boardID, createdBy string, expiresAt time.Time) (model.EmbedToken, error) {
+	rw := embedTokenRow{
+		ID:        generateID(),
+		BoardID:   boardID,
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.EmbedToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteEmbedTokenRepo) Get(ctx context.Context, id string) (model.EmbedToken, error) {
+	var rw embedTokenRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.EmbedToken{}, ErrNotFound
+		}
+		return model.EmbedToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteEmbedTokenRepo) ListByBoard(ctx context.Context, boardID string) ([]model.EmbedToken, error) {
+	var rows []embedTokenRow
+	if err := r.db.WithContext(ctx).Where("board_id = ?", boardID).Order("created_at desc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.EmbedToken, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Revoke 吊销令牌
+// 只更新 revoked_at 为空的记录，重复吊销时保留第一次的时间
+func (r *sqliteEmbedTokenRepo) Revoke(ctx context.Context, id string) (model.EmbedToken, error) {
+	now := time.Now()
+	if err := r.db.WithContext(ctx).Model(&embedTokenRow{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now).Error; err != nil {
+		return model.EmbedToken{}, err
+	}
+	return r.Get(ctx, id)
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteEmbedTokenRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// Pinger 可以检查底层存储是否可用的仓储
+// UserRepository、BoardRepository、CardRepository 都实现了它，供就绪检查（/readyz）使用
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
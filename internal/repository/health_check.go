@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthCheckRepository 健康探测记录仓储接口，公开状态页按它计算可用率
+type HealthCheckRepository interface {
+	// Save 保存一轮探测的结果
+	Save(ctx context.Context, checks []model.HealthCheck) error
+
+	// Latest 最近一轮探测的结果，按组件名排序；还没有探测过时返回空切片
+	Latest(ctx context.Context) ([]model.HealthCheck, error)
+
+	// Count 统计 since 之后每个组件的探测次数和成功次数，按组件名排序
+	Count(ctx context.Context, since time.Time) ([]model.HealthCheckCount, error)
+
+	// List 按条件查询探测记录，从新到旧，最多 f.Limit 条
+	List(ctx context.Context, f HealthCheckFilter) ([]model.HealthCheck, error)
+
+	// DeleteBefore 删除 before 之前的记录，返回删除了几条
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// HealthCheckFilter 查询探测记录的条件，零值的字段不限制
+type HealthCheckFilter struct {
+	// Component 只查这个组件
+	Component string
+
+	// From、To 时间范围 [From, To)
+	From time.Time
+	To   time.Time
+
+	// FailedOnly 只查失败的探测
+	FailedOnly bool
+
+	// Limit 最多返回几条，必须大于 0
+	Limit int
+}
+
+// match 记录是否符合条件（不看 Limit）
+func (f HealthCheckFilter) match(c model.HealthCheck) bool {
+	switch {
+	case f.Component != "" && c.Component != f.Component:
+		return false
+	case !f.From.IsZero() && c.CheckedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !c.CheckedAt.Before(f.To):
+		return false
+	case f.FailedOnly && c.OK:
+		return false
+	}
+	return true
+}
+
+// memHealthCheckRepo 健康探测记录仓储的内存实现
+type memHealthCheckRepo struct {
+	mu     sync.RWMutex
+	checks []model.HealthCheck
+}
+
+// NewMemHealthCheckRepo 创建一个新的内存健康探测记录仓储
+func NewMemHealthCheckRepo() HealthCheckRepository {
+	return &memHealthCheckRepo{}
+}
+
+func (r *memHealthCheckRepo) Save(ctx context.Context, checks []model.HealthCheck) error {
+	r.mu.Lock()
+	r.checks = append(r.checks, checks...)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memHealthCheckRepo) Latest(ctx context.Context) ([]model.HealthCheck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var last time.Time
+	for _, c := range r.checks {
+		if c.CheckedAt.After(last) {
+			last = c.CheckedAt
+		}
+	}
+	out := []model.HealthCheck{}
+	for _, c := range r.checks {
+		if c.CheckedAt.Equal(last) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Component < out[j].Component })
+	return out, nil
+}
+
+func (r *memHealthCheckRepo) Count(ctx context.Context, since time.Time) ([]model.HealthCheckCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]*model.HealthCheckCount)
+	for _, c := range r.checks {
+		if c.CheckedAt.Before(since) {
+			continue
+		}
+		n, ok := counts[c.Component]
+		if !ok {
+			n = &model.HealthCheckCount{Component: c.Component}
+			counts[c.Component] = n
+		}
+		n.Total++
+		if c.OK {
+			n.OK++
+		}
+	}
+	out := make([]model.HealthCheckCount, 0, len(counts))
+	for _, n := range counts {
+		out = append(out, *n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Component < out[j].Component })
+	return out, nil
+}
+
+func (r *memHealthCheckRepo) List(ctx context.Context, f HealthCheckFilter) ([]model.HealthCheck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := []model.HealthCheck{}
+	for i := len(r.checks) - 1; i >= 0 && len(out) < f.Limit; i-- {
+		if f.match(r.checks[i]) {
+			out = append(out, r.checks[i])
+		}
+	}
+	return out, nil
+}
+
+func (r *memHealthCheckRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.checks[:0]
+	for _, c := range r.checks {
+		if !c.CheckedAt.Before(before) {
+			kept = append(kept, c)
+		}
+	}
+	n := len(r.checks) - len(kept)
+	r.checks = kept
+	return n, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteHealthCheckRepo 是 HealthCheckRepository 的 SQLite 实现
+type sqliteHealthCheckRepo struct {
+	db *gorm.DB
+}
+
+// healthCheckRow 健康探测记录表结构，checked_at 和 (component, checked_at) 上的索引见 internal/migrations
+type healthCheckRow struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement"`
+	Component string
+	OK        bool `gorm:"column:ok"`
+	LatencyMs int64
+	Value     *int64
+	CheckedAt time.Time
+}
+
+// NewSQLiteHealthCheckRepo 创建一个新的 SQLite 健康探测记录仓储
+func NewSQLiteHealthCheckRepo(db *gorm.DB) (HealthCheckRepository, error) {
+	return &sqliteHealthCheckRepo{db: db}, nil
+}
+
+func (r *sqliteHealthCheckRepo) Save(ctx context.Context, checks []model.HealthCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	rows := make([]healthCheckRow, 0, len(checks))
+	for _, c := range checks {
+		rows = append(rows, healthCheckRow{Component: c.Component, OK: c.OK, LatencyMs: c.LatencyMs, Value: c.Value, CheckedAt: c.CheckedAt})
+	}
+	return r.db.WithContext(ctx).Create(&rows).Error
+}
+
+// Latest 相当于 SQL: SELECT * FROM health_check_rows WHERE checked_at = (SELECT MAX(checked_at) FROM health_check_rows) ORDER BY component
+func (r *sqliteHealthCheckRepo) Latest(ctx context.Context) ([]model.HealthCheck, error) {
+	var rows []healthCheckRow
+	if err := r.db.WithContext(ctx).
+		Where("checked_at = (?)", r.db.Model(&healthCheckRow{}).Select("MAX(checked_at)")).
+		Order("component").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return r.toModels(rows), nil
+}
+
+func (r *sqliteHealthCheckRepo) toModels(rows []healthCheckRow) []model.HealthCheck {
+	out := make([]model.HealthCheck, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, model.HealthCheck{Component: rw.Component, OK: rw.OK, LatencyMs: rw.LatencyMs, Value: rw.Value, CheckedAt: rw.CheckedAt})
+	}
+	return out
+}
+
+// Count 相当于 SQL: SELECT component, COUNT(*), SUM(ok) FROM health_check_rows WHERE checked_at >= ? GROUP BY component
+func (r *sqliteHealthCheckRepo) Count(ctx context.Context, since time.Time) ([]model.HealthCheckCount, error) {
+	var out []model.HealthCheckCount
+	err := r.db.WithContext(ctx).Model(&healthCheckRow{}).
+		Select("component, COUNT(*) AS total, COALESCE(SUM(ok), 0) AS ok").
+		Where("checked_at >= ?", since).
+		Group("component").Order("component").Scan(&out).Error
+	return out, err
+}
+
+// List 按 id 倒序就是按时间从新到旧（同一轮的记录按组件名插入），比按 checked_at 排序省一次排序
+func (r *sqliteHealthCheckRepo) List(ctx context.Context, f HealthCheckFilter) ([]model.HealthCheck, error) {
+	q := r.db.WithContext(ctx).Model(&healthCheckRow{})
+	if f.Component != "" {
+		q = q.Where("component = ?", f.Component)
+	}
+	if !f.From.IsZero() {
+		q = q.Where("checked_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("checked_at < ?", f.To)
+	}
+	if f.FailedOnly {
+		q = q.Where("NOT ok")
+	}
+	var rows []healthCheckRow
+	if err := q.Order("id DESC").Limit(f.Limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return r.toModels(rows), nil
+}
+
+func (r *sqliteHealthCheckRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	res := r.db.WithContext(ctx).Where("checked_at < ?", before).Delete(&healthCheckRow{})
+	return int(res.RowsAffected), res.Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteHealthCheckRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
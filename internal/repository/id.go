@@ -2,13 +2,55 @@
 // 这一层处理所有与数据存储相关的操作（数据库、内存等）
 package repository
 
-import "github.com/google/uuid"
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/google/uuid"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
 
-// uuidNew 生成一个新的 UUID（通用唯一识别码）
-// UUID 是一个 128 位的随机数，格式类似：550e8400-e29b-41d4-a716-446655440000
-// 用于生成全局唯一的 ID，避免数据冲突
-func uuidNew() string {
-	return uuid.NewString()
+// IDGenerator ID 生成器接口
+// 所有仓储创建新记录时都通过它生成主键
+// ID 始终是字符串，所以切换策略后，已有的 UUID 数据仍然可以正常查询
+type IDGenerator interface {
+	// NewID 生成一个新的唯一 ID
+	NewID() string
+}
+
+// ID 生成策略名称，对应环境变量 ID_STRATEGY 的取值
+const (
+	IDStrategyUUID  = "uuid"
+	IDStrategyULID  = "ulid"
+	IDStrategyKSUID = "ksuid"
+)
+
+// NewIDGenerator 根据策略名称创建 ID 生成器
+// 空字符串表示默认的 UUID，保持与旧版本一致
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "", IDStrategyUUID:
+		return uuidGenerator{}, nil
+	case IDStrategyULID:
+		return &ulidGenerator{}, nil
+	case IDStrategyKSUID:
+		return ksuidGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown id strategy %q", strategy)
+	}
+}
+
+// idGen 当前使用的 ID 生成器，默认是 UUID
+// 所有仓储共用一个生成器，启动时通过 SetIDGenerator 设置一次
+var idGen IDGenerator = uuidGenerator{}
+
+// SetIDGenerator 设置全局的 ID 生成器
+// 应该在创建任何仓储之前调用（通常在 main 中）
+func SetIDGenerator(g IDGenerator) {
+	idGen = g
 }
 
 // generateID 是一个辅助函数，用于生成唯一 ID
@@ -16,5 +58,96 @@ func uuidNew() string {
 // 1. 如果以后想换其他 ID 生成方式，只需修改这一处
 // 2. 代码更清晰，表明这是在生成 ID
 func generateID() string {
-	return uuidNew()
+	return idGen.NewID()
+}
+
+// uuidGenerator 生成随机的 UUIDv4
+// UUID 是一个 128 位的随机数，格式类似：550e8400-e29b-41d4-a716-446655440000
+// 缺点是完全随机，插入数据库时索引的局部性较差
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	return uuid.NewString()
+}
+
+// crockford ULID 使用的 Crockford Base32 字母表
+// 去掉了容易混淆的 I、L、O、U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator 生成 ULID（Universally Unique Lexicographically Sortable Identifier）
+// 结构：48 位毫秒时间戳 + 80 位随机数，编码为 26 个字符，例如：01ARZ3NDEKTSV4RRFFQ69G5FAV
+// ULID 按字符串排序就是按时间排序，新记录总是追加在索引末尾，分页也更方便
+type ulidGenerator struct {
+	// mu 保护下面两个字段，保证同一毫秒内生成的 ID 依然单调递增
+	mu      sync.Mutex
+	lastMs  uint64
+	lastRnd [10]byte
+}
+
+func (g *ulidGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms == g.lastMs {
+		// 同一毫秒内：随机部分加 1，而不是重新生成，保证有序
+		for i := len(g.lastRnd) - 1; i >= 0; i-- {
+			g.lastRnd[i]++
+			if g.lastRnd[i] != 0 {
+				break
+			}
+		}
+	} else {
+		g.lastMs = ms
+		if _, err := rand.Read(g.lastRnd[:]); err != nil {
+			panic(err)
+		}
+	}
+
+	// 拼出 16 字节：前 6 字节时间戳（大端序），后 10 字节随机数
+	var raw [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(raw[:6], ts[2:])
+	copy(raw[6:], g.lastRnd[:])
+
+	// 128 位按每 5 位一个字符编码，最高位补 2 个 0，共 26 个字符
+	n := new(big.Int).SetBytes(raw[:])
+	out := make([]byte, 26)
+	mask := big.NewInt(31)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(out)
+}
+
+// ksuidEpoch KSUID 的时间起点（2014-05-13），用 32 位秒数可以表示到 2150 年左右
+const ksuidEpoch = 1400000000
+
+// base62 KSUID 使用的 Base62 字母表，排序与 ASCII 顺序一致
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ksuidGenerator 生成 KSUID（K-Sortable Unique IDentifier）
+// 结构：32 位秒级时间戳 + 128 位随机数，编码为 27 个字符
+// 同样可以按时间排序，随机部分更长，适合对碰撞更敏感的场景
+type ksuidGenerator struct{}
+
+func (ksuidGenerator) NewID() string {
+	var raw [20]byte
+	binary.BigEndian.PutUint32(raw[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	if _, err := rand.Read(raw[4:]); err != nil {
+		panic(err)
+	}
+
+	// 定长 27 位 Base62 编码，不足的高位补 '0'，保证字符串排序正确
+	n := new(big.Int).SetBytes(raw[:])
+	out := make([]byte, 27)
+	base := big.NewInt(62)
+	rem := new(big.Int)
+	for i := 26; i >= 0; i-- {
+		n.DivMod(n, base, rem)
+		out[i] = base62[rem.Int64()]
+	}
+	return string(out)
 }
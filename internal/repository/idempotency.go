@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// IdempotencyRepository 幂等键仓储接口，见 middleware.Idempotency
+// 第一次请求先用 Reserve 占住键，处理完用 Complete 保存响应；处理失败时 Release，客户端可以用同一个键重试
+type IdempotencyRepository interface {
+	// Reserve 占用 rec.UserID、rec.Key：不存在或者已经过期时保存 rec（Status 为 0）并返回 true；
+	// 已经有没过期的记录时不修改，返回已有的记录和 false
+	Reserve(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error)
+
+	// Complete 保存第一次请求的响应，之后 Reserve 返回的记录里带着它
+	Complete(ctx context.Context, userID, key string, status int, headers map[string]string, body []byte) error
+
+	// Release 删除记录，不存在时不报错
+	Release(ctx context.Context, userID, key string) error
+
+	// DeleteExpired 删除过期时间早于 now 的记录，返回删除了几条
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// memIdempotencyRepo 幂等键仓储的内存实现
+type memIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]model.IdempotencyRecord // key 是 userID + "\x00" + 幂等键
+}
+
+// NewMemIdempotencyRepo 创建一个新的内存幂等键仓储
+func NewMemIdempotencyRepo() IdempotencyRepository {
+	return &memIdempotencyRepo{records: make(map[string]model.IdempotencyRecord)}
+}
+
+func idempotencyMapKey(userID, key string) string {
+	return userID + "\x00" + key
+}
+
+// Reserve 占用幂等键
+func (r *memIdempotencyRepo) Reserve(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyMapKey(rec.UserID, rec.Key)
+	if old, ok := r.records[k]; ok && old.ExpiresAt.After(rec.CreatedAt) {
+		return old, false, nil
+	}
+	rec.Status, rec.Headers, rec.Body = 0, nil, nil
+	r.records[k] = rec
+	return rec, true, nil
+}
+
+// Complete 保存响应
+func (r *memIdempotencyRepo) Complete(ctx context.Context, userID, key string, status int, headers map[string]string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyMapKey(userID, key)
+	rec, ok := r.records[k]
+	if !ok {
+		return ErrNotFound
+	}
+	rec.Status, rec.Headers, rec.Body = status, headers, body
+	r.records[k] = rec
+	return nil
+}
+
+// Release 删除记录
+func (r *memIdempotencyRepo) Release(ctx context.Context, userID, key string) error {
+	r.mu.Lock()
+	delete(r.records, idempotencyMapKey(userID, key))
+	r.mu.Unlock()
+	return nil
+}
+
+// DeleteExpired 删除过期的记录
+func (r *memIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for k, rec := range r.records {
+		if rec.ExpiresAt.Before(now) {
+			delete(r.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
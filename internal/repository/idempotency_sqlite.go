@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteIdempotencyRepo 是 IdempotencyRepository 的 SQLite 实现
+type sqliteIdempotencyRepo struct {
+	db *gorm.DB
+}
+
+// idempotencyKeyRow 幂等键表结构，主键是 (user_id, key)
+// 响应头保存成 JSON 文本
+type idempotencyKeyRow struct {
+	UserID      string `gorm:"primaryKey"`
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string
+	Status      int
+	Headers     string
+	Body        []byte
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+// NewSQLiteIdempotencyRepo 创建一个新的 SQLite 幂等键仓储
+func NewSQLiteIdempotencyRepo(db *gorm.DB) (IdempotencyRepository, error) {
+	return &sqliteIdempotencyRepo{db: db}, nil
+}
+
+func (r *sqliteIdempotencyRepo) toModel(row idempotencyKeyRow) model.IdempotencyRecord {
+	rec := model.IdempotencyRecord{
+		UserID:      row.UserID,
+		Key:         row.Key,
+		Fingerprint: row.Fingerprint,
+		Status:      row.Status,
+		Body:        row.Body,
+		ExpiresAt:   row.ExpiresAt,
+		CreatedAt:   row.CreatedAt,
+	}
+	if row.Headers != "" {
+		_ = json.Unmarshal([]byte(row.Headers), &rec.Headers)
+	}
+	return rec
+}
+
+// Reserve 在一个事务里：先删掉同一个键的过期记录，再 INSERT ... ON CONFLICT DO NOTHING
+// 插入成功说明占到了；没插入说明别的请求已经占用，读出它的记录返回
+func (r *sqliteIdempotencyRepo) Reserve(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	var got model.IdempotencyRecord
+	reserved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND key = ? AND expires_at <= ?", rec.UserID, rec.Key, rec.CreatedAt).
+			Delete(&idempotencyKeyRow{}).Error; err != nil {
+			return err
+		}
+		row := idempotencyKeyRow{
+			UserID:      rec.UserID,
+			Key:         rec.Key,
+			Fingerprint: rec.Fingerprint,
+			ExpiresAt:   rec.ExpiresAt,
+			CreatedAt:   rec.CreatedAt,
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			got, reserved = r.toModel(row), true
+			return nil
+		}
+		var existing idempotencyKeyRow
+		if err := tx.Where("user_id = ? AND key = ?", rec.UserID, rec.Key).First(&existing).Error; err != nil {
+			return err
+		}
+		got = r.toModel(existing)
+		return nil
+	})
+	if err != nil {
+		return model.IdempotencyRecord{}, false, err
+	}
+	return got, reserved, nil
+}
+
+func (r *sqliteIdempotencyRepo) Complete(ctx context.Context, userID, key string, status int, headers map[string]string, body []byte) error {
+	h, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	res := r.db.WithContext(ctx).Model(&idempotencyKeyRow{}).Where("user_id = ? AND key = ?", userID, key).
+		Updates(map[string]any{"status": status, "headers": string(h), "body": body})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteIdempotencyRepo) Release(ctx context.Context, userID, key string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).Delete(&idempotencyKeyRow{}).Error
+}
+
+func (r *sqliteIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	res := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&idempotencyKeyRow{})
+	return int(res.RowsAffected), res.Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteIdempotencyRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
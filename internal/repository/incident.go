@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// IncidentRepository 状态页事故公告仓储接口
+// 同一时间最多一条公告在显示，解除了的公告保留下来当作记录
+type IncidentRepository interface {
+	// Current 正在显示的公告，没有时返回 ErrNotFound
+	Current(ctx context.Context) (model.Incident, error)
+
+	// Open 发布一条新公告，ID 由仓储生成；正在显示的公告在 inc.CreatedAt 解除，被新公告替换
+	Open(ctx context.Context, inc model.Incident) (model.Incident, error)
+
+	// Resolve 在 at 解除正在显示的公告并返回它，没有时返回 ErrNotFound
+	Resolve(ctx context.Context, at time.Time) (model.Incident, error)
+}
+
+// memIncidentRepo 事故公告仓储的内存实现
+type memIncidentRepo struct {
+	mu        sync.Mutex
+	incidents []model.Incident
+}
+
+// NewMemIncidentRepo 创建一个新的内存事故公告仓储
+func NewMemIncidentRepo() IncidentRepository {
+	return &memIncidentRepo{}
+}
+
+// current 正在显示的公告的下标，没有时返回 -1，调用方持有锁
+func (r *memIncidentRepo) current() int {
+	for i := len(r.incidents) - 1; i >= 0; i-- {
+		if r.incidents[i].ResolvedAt == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *memIncidentRepo) Current(ctx context.Context) (model.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.current()
+	if i < 0 {
+		return model.Incident{}, ErrNotFound
+	}
+	return r.incidents[i], nil
+}
+
+func (r *memIncidentRepo) Open(ctx context.Context, inc model.Incident) (model.Incident, error) {
+	inc.ID = generateID()
+	inc.ResolvedAt = nil
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.current(); i >= 0 {
+		at := inc.CreatedAt
+		r.incidents[i].ResolvedAt = &at
+	}
+	r.incidents = append(r.incidents, inc)
+	return inc, nil
+}
+
+func (r *memIncidentRepo) Resolve(ctx context.Context, at time.Time) (model.Incident, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.current()
+	if i < 0 {
+		return model.Incident{}, ErrNotFound
+	}
+	r.incidents[i].ResolvedAt = &at
+	return r.incidents[i], nil
+}
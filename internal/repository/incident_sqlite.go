@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteIncidentRepo 是 IncidentRepository 的 SQLite 实现
+type sqliteIncidentRepo struct {
+	db *gorm.DB
+}
+
+// incidentRow 事故公告表结构
+// 表很小（每次发布一行），查正在显示的公告不需要索引
+type incidentRow struct {
+	ID         string `gorm:"primaryKey"`
+	Message    string
+	Severity   string
+	CreatedBy  string
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+// NewSQLiteIncidentRepo 创建一个新的 SQLite 事故公告仓储
+func NewSQLiteIncidentRepo(db *gorm.DB) (IncidentRepository, error) {
+	return &sqliteIncidentRepo{db: db}, nil
+}
+
+func (r *sqliteIncidentRepo) toModel(row incidentRow) model.Incident {
+	return model.Incident{
+		ID:         row.ID,
+		Message:    row.Message,
+		Severity:   row.Severity,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt,
+		ResolvedAt: row.ResolvedAt,
+	}
+}
+
+// current 查正在显示的公告，db 可以是事务
+func (r *sqliteIncidentRepo) current(db *gorm.DB) (incidentRow, error) {
+	var row incidentRow
+	err := db.Where("resolved_at IS NULL").Order("created_at DESC").Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return incidentRow{}, ErrNotFound
+	}
+	return row, err
+}
+
+func (r *sqliteIncidentRepo) Current(ctx context.Context) (model.Incident, error) {
+	row, err := r.current(r.db.WithContext(ctx))
+	if err != nil {
+		return model.Incident{}, err
+	}
+	return r.toModel(row), nil
+}
+
+// Open 在一个事务里解除旧公告、插入新公告
+func (r *sqliteIncidentRepo) Open(ctx context.Context, inc model.Incident) (model.Incident, error) {
+	row := incidentRow{
+		ID:        generateID(),
+		Message:   inc.Message,
+		Severity:  inc.Severity,
+		CreatedBy: inc.CreatedBy,
+		CreatedAt: inc.CreatedAt,
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&incidentRow{}).Where("resolved_at IS NULL").Update("resolved_at", inc.CreatedAt).Error; err != nil {
+			return err
+		}
+		return tx.Create(&row).Error
+	})
+	if err != nil {
+		return model.Incident{}, err
+	}
+	return r.toModel(row), nil
+}
+
+func (r *sqliteIncidentRepo) Resolve(ctx context.Context, at time.Time) (model.Incident, error) {
+	var row incidentRow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if row, err = r.current(tx); err != nil {
+			return err
+		}
+		row.ResolvedAt = &at
+		return tx.Model(&incidentRow{}).Where("resolved_at IS NULL").Update("resolved_at", at).Error
+	})
+	if err != nil {
+		return model.Incident{}, err
+	}
+	return r.toModel(row), nil
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteIncidentRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,1228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets 耗时直方图的桶边界（单位：秒）
+// 覆盖从 0.5 毫秒到 1 秒，SQLite 的正常查询大多落在前几个桶里
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// methodStats 单个仓储方法的统计数据
+type methodStats struct {
+	calls   uint64
+	errors  uint64
+	total   time.Duration
+	max     time.Duration
+	buckets []uint64 // 与 latencyBuckets 一一对应，累计计数（<= 边界）
+}
+
+// MethodStats 仓储方法统计的快照，用于 JSON 输出
+type MethodStats struct {
+	Repo      string  `json:"repo"`
+	Method    string  `json:"method"`
+	Calls     uint64  `json:"calls"`
+	Errors    uint64  `json:"errors"`
+	TotalMs   float64 `json:"totalMs"`
+	AvgMs     float64 `json:"avgMs"`
+	MaxMs     float64 `json:"maxMs"`
+	SlowCalls uint64  `json:"slowCalls"` // 超过 100ms 的调用次数
+}
+
+// QueryMetrics 收集仓储层的调用次数、错误次数和耗时
+// 通过 Instrument* 系列装饰器包装仓储，业务代码不需要任何改动
+type QueryMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*methodStats // key 是 "repo.method"
+	order   []string                // 记录 key 的插入顺序，输出更稳定
+
+	// pools 数据库连接池，key 是连接池名
+	// 同一个连接池只记录一次：SQLite 仓储共用 NewDB 打开的连接池，由 AddPool 记成 "sqlite"
+	pools map[string]*sql.DB
+}
+
+// NewQueryMetrics 创建一个新的统计收集器
+func NewQueryMetrics() *QueryMetrics {
+	return &QueryMetrics{
+		methods: make(map[string]*methodStats),
+		pools:   make(map[string]*sql.DB),
+	}
+}
+
+// observe 记录一次调用
+func (m *QueryMetrics) observe(repo, method string, d time.Duration, err error) {
+	key := repo + "." + method
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.methods[key]
+	if !ok {
+		st = &methodStats{buckets: make([]uint64, len(latencyBuckets))}
+		m.methods[key] = st
+		m.order = append(m.order, key)
+	}
+
+	st.calls++
+	// ErrNotFound 是正常的业务结果，不算作数据库错误
+	if err != nil && err != ErrNotFound {
+		st.errors++
+	}
+	st.total += d
+	if d > st.max {
+		st.max = d
+	}
+	sec := d.Seconds()
+	for i, b := range latencyBuckets {
+		if sec <= b {
+			st.buckets[i]++
+		}
+	}
+}
+
+// pooled 由 SQLite 仓储实现，用于取出底层连接池
+type pooled interface {
+	sqlDB() (*sql.DB, error)
+}
+
+// addPool 如果仓储有底层连接池，并且这个连接池还没有记录过，就按仓储名记录下来
+func (m *QueryMetrics) addPool(repo string, r interface{}) {
+	p, ok := r.(pooled)
+	if !ok {
+		return
+	}
+	db, err := p.sqlDB()
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.pools {
+		if existing == db {
+			return
+		}
+	}
+	m.pools[repo] = db
+}
+
+// AddPool 按名字记录一个共享的连接池，例如 AddPool("sqlite", db)
+// 之前按仓储名记录的同一个连接池会被替换掉，所以先包装仓储、后调用 AddPool 也不会重复
+func (m *QueryMetrics) AddPool(name string, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, existing := range m.pools {
+		if existing == sqlDB {
+			delete(m.pools, k)
+		}
+	}
+	m.pools[name] = sqlDB
+}
+
+// Methods 返回所有方法的统计快照，按总耗时从高到低排序
+// 排在前面的就是最"热"或最慢的数据访问
+func (m *QueryMetrics) Methods() []MethodStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 100ms 对应的桶下标，用来计算慢调用次数
+	slowIdx := sort.SearchFloat64s(latencyBuckets, 0.1)
+
+	out := make([]MethodStats, 0, len(m.order))
+	for _, key := range m.order {
+		st := m.methods[key]
+		repo, method := splitKey(key)
+		ms := MethodStats{
+			Repo:      repo,
+			Method:    method,
+			Calls:     st.calls,
+			Errors:    st.errors,
+			TotalMs:   float64(st.total) / float64(time.Millisecond),
+			MaxMs:     float64(st.max) / float64(time.Millisecond),
+			SlowCalls: st.calls - st.buckets[slowIdx],
+		}
+		if st.calls > 0 {
+			ms.AvgMs = ms.TotalMs / float64(st.calls)
+		}
+		out = append(out, ms)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].TotalMs > out[j].TotalMs
+	})
+	return out
+}
+
+// Pools 返回各连接池的 sql.DBStats
+func (m *QueryMetrics) Pools() map[string]sql.DBStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]sql.DBStats, len(m.pools))
+	for name, db := range m.pools {
+		out[name] = db.Stats()
+	}
+	return out
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出所有指标
+// 格式说明：https://prometheus.io/docs/instrumenting/exposition_formats/
+func (m *QueryMetrics) WritePrometheus(w io.Writer) {
+	m.mu.Lock()
+	keys := append([]string(nil), m.order...)
+	snap := make(map[string]methodStats, len(keys))
+	for _, k := range keys {
+		st := *m.methods[k]
+		st.buckets = append([]uint64(nil), st.buckets...)
+		snap[k] = st
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP kanban_repo_calls_total Total repository method calls.")
+	fmt.Fprintln(w, "# TYPE kanban_repo_calls_total counter")
+	for _, k := range keys {
+		repo, method := splitKey(k)
+		st := snap[k]
+		fmt.Fprintf(w, "kanban_repo_calls_total{repo=%q,method=%q,status=\"ok\"} %d\n", repo, method, st.calls-st.errors)
+		fmt.Fprintf(w, "kanban_repo_calls_total{repo=%q,method=%q,status=\"error\"} %d\n", repo, method, st.errors)
+	}
+
+	fmt.Fprintln(w, "# HELP kanban_repo_call_duration_seconds Repository method latency.")
+	fmt.Fprintln(w, "# TYPE kanban_repo_call_duration_seconds histogram")
+	for _, k := range keys {
+		repo, method := splitKey(k)
+		st := snap[k]
+		for i, b := range latencyBuckets {
+			fmt.Fprintf(w, "kanban_repo_call_duration_seconds_bucket{repo=%q,method=%q,le=\"%g\"} %d\n", repo, method, b, st.buckets[i])
+		}
+		fmt.Fprintf(w, "kanban_repo_call_duration_seconds_bucket{repo=%q,method=%q,le=\"+Inf\"} %d\n", repo, method, st.calls)
+		fmt.Fprintf(w, "kanban_repo_call_duration_seconds_sum{repo=%q,method=%q} %g\n", repo, method, st.total.Seconds())
+		fmt.Fprintf(w, "kanban_repo_call_duration_seconds_count{repo=%q,method=%q} %d\n", repo, method, st.calls)
+	}
+
+	pools := m.Pools()
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gauges := []struct {
+		name, help string
+		value      func(sql.DBStats) float64
+	}{
+		{"kanban_db_open_connections", "Open connections in the pool.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+		{"kanban_db_in_use_connections", "Connections currently in use.", func(s sql.DBStats) float64 { return float64(s.InUse) }},
+		{"kanban_db_idle_connections", "Idle connections.", func(s sql.DBStats) float64 { return float64(s.Idle) }},
+		{"kanban_db_wait_count_total", "Total connections waited for.", func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+		{"kanban_db_wait_duration_seconds_total", "Total time blocked waiting for a connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s{pool=%q} %g\n", g.name, name, g.value(pools[name]))
+		}
+	}
+}
+
+// splitKey 把 "repo.method" 拆成两部分
+func splitKey(key string) (string, string) {
+	for i := 0; i < len(key); i++ {
+		if key[i] == '.' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return key, ""
+}
+
+// timed 执行 fn 并记录耗时，是所有装饰器方法的公共部分
+// 使用泛型，任意返回类型的仓储方法都可以复用
+func timed[T any](m *QueryMetrics, repo, method string, fn func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := fn()
+	m.observe(repo, method, time.Since(start), err)
+	return v, err
+}
+
+// timedErr 与 timed 相同，用于只返回 error 的方法
+func timedErr(m *QueryMetrics, repo, method string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	m.observe(repo, method, time.Since(start), err)
+	return err
+}
+
+// ========== 用户仓储装饰器 ==========
+
+type instrumentedUserRepo struct {
+	next UserRepository
+	m    *QueryMetrics
+}
+
+// InstrumentUserRepo 用统计装饰器包装用户仓储
+func InstrumentUserRepo(next UserRepository, m *QueryMetrics) UserRepository {
+	m.addPool("users", next)
+	return &instrumentedUserRepo{next: next, m: m}
+}
+
+// Ping 不计入统计：探针每隔几秒调用一次，会淹没真正的业务调用
+func (r *instrumentedUserRepo) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedUserRepo) Create(ctx context.Context, email, passwordHash string) (model.User, error) {
+	return timed(r.m, "users", "Create", func() (model.User, error) { return r.next.Create(ctx, email, passwordHash) })
+}
+
+func (r *instrumentedUserRepo) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	return timed(r.m, "users", "GetByEmail", func() (model.User, error) { return r.next.GetByEmail(ctx, email) })
+}
+
+func (r *instrumentedUserRepo) GetByID(ctx context.Context, id string) (model.User, error) {
+	return timed(r.m, "users", "GetByID", func() (model.User, error) { return r.next.GetByID(ctx, id) })
+}
+
+func (r *instrumentedUserRepo) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	return timedErr(r.m, "users", "UpdatePassword", func() error { return r.next.UpdatePassword(ctx, id, passwordHash) })
+}
+
+func (r *instrumentedUserRepo) SetRole(ctx context.Context, id, role string) (model.User, error) {
+	return timed(r.m, "users", "SetRole", func() (model.User, error) { return r.next.SetRole(ctx, id, role) })
+}
+
+func (r *instrumentedUserRepo) List(ctx context.Context) ([]model.User, error) {
+	return timed(r.m, "users", "List", func() ([]model.User, error) { return r.next.List(ctx) })
+}
+
+func (r *instrumentedUserRepo) UpdateEmail(ctx context.Context, id, email string) (model.User, error) {
+	return timed(r.m, "users", "UpdateEmail", func() (model.User, error) { return r.next.UpdateEmail(ctx, id, email) })
+}
+
+func (r *instrumentedUserRepo) Search(ctx context.Context, f UserFilter) ([]model.User, int, error) {
+	start := time.Now()
+	users, total, err := r.next.Search(ctx, f)
+	r.m.observe("users", "Search", time.Since(start), err)
+	return users, total, err
+}
+
+func (r *instrumentedUserRepo) SetEmailVerified(ctx context.Context, id string, at time.Time) error {
+	return timedErr(r.m, "users", "SetEmailVerified", func() error { return r.next.SetEmailVerified(ctx, id, at) })
+}
+
+func (r *instrumentedUserRepo) SetDisabled(ctx context.Context, id string, at *time.Time) (model.User, error) {
+	return timed(r.m, "users", "SetDisabled", func() (model.User, error) { return r.next.SetDisabled(ctx, id, at) })
+}
+
+func (r *instrumentedUserRepo) Count(ctx context.Context) (total, disabled int, err error) {
+	start := time.Now()
+	total, disabled, err = r.next.Count(ctx)
+	r.m.observe("users", "Count", time.Since(start), err)
+	return total, disabled, err
+}
+
+func (r *instrumentedUserRepo) SetDeleted(ctx context.Context, id string, at *time.Time, transferBoards bool) (model.User, error) {
+	return timed(r.m, "users", "SetDeleted", func() (model.User, error) { return r.next.SetDeleted(ctx, id, at, transferBoards) })
+}
+
+func (r *instrumentedUserRepo) ListDeleted(ctx context.Context, before time.Time) ([]model.User, error) {
+	return timed(r.m, "users", "ListDeleted", func() ([]model.User, error) { return r.next.ListDeleted(ctx, before) })
+}
+
+func (r *instrumentedUserRepo) Delete(ctx context.Context, id string, before time.Time) (bool, error) {
+	return timed(r.m, "users", "Delete", func() (bool, error) { return r.next.Delete(ctx, id, before) })
+}
+
+func (r *instrumentedUserRepo) CountSignups(ctx context.Context, from, to time.Time) ([]model.DayCount, error) {
+	return timed(r.m, "users", "CountSignups", func() ([]model.DayCount, error) { return r.next.CountSignups(ctx, from, to) })
+}
+
+// ========== 看板仓储装饰器 ==========
+
+type instrumentedBoardRepo struct {
+	next BoardRepository
+	m    *QueryMetrics
+}
+
+// InstrumentBoardRepo 用统计装饰器包装看板仓储
+func InstrumentBoardRepo(next BoardRepository, m *QueryMetrics) BoardRepository {
+	m.addPool("boards", next)
+	return &instrumentedBoardRepo{next: next, m: m}
+}
+
+// Ping 不计入统计：探针每隔几秒调用一次，会淹没真正的业务调用
+func (r *instrumentedBoardRepo) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedBoardRepo) List(ctx context.Context, ownerID string) ([]model.Board, error) {
+	return timed(r.m, "boards", "List", func() ([]model.Board, error) { return r.next.List(ctx, ownerID) })
+}
+
+func (r *instrumentedBoardRepo) Get(ctx context.Context, ownerID, id string) (model.Board, error) {
+	return timed(r.m, "boards", "Get", func() (model.Board, error) { return r.next.Get(ctx, ownerID, id) })
+}
+
+func (r *instrumentedBoardRepo) GetBySlug(ctx context.Context, ownerID, slug string) (model.Board, error) {
+	return timed(r.m, "boards", "GetBySlug", func() (model.Board, error) { return r.next.GetBySlug(ctx, ownerID, slug) })
+}
+
+func (r *instrumentedBoardRepo) ListByIDs(ctx context.Context, ids []string) ([]model.Board, error) {
+	return timed(r.m, "boards", "ListByIDs", func() ([]model.Board, error) { return r.next.ListByIDs(ctx, ids) })
+}
+
+func (r *instrumentedBoardRepo) ListIDs(ctx context.Context) ([]string, error) {
+	return timed(r.m, "boards", "ListIDs", func() ([]string, error) { return r.next.ListIDs(ctx) })
+}
+
+func (r *instrumentedBoardRepo) Search(ctx context.Context, f BoardFilter) ([]model.Board, int, error) {
+	start := time.Now()
+	boards, total, err := r.next.Search(ctx, f)
+	r.m.observe("boards", "Search", time.Since(start), err)
+	return boards, total, err
+}
+
+func (r *instrumentedBoardRepo) Count(ctx context.Context) (int, error) {
+	return timed(r.m, "boards", "Count", func() (int, error) { return r.next.Count(ctx) })
+}
+
+func (r *instrumentedBoardRepo) SetOwner(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	return timed(r.m, "boards", "SetOwner", func() (model.Board, error) { return r.next.SetOwner(ctx, ownerID, id, newOwnerID) })
+}
+
+func (r *instrumentedBoardRepo) SetState(ctx context.Context, id, state, reason string) (model.Board, error) {
+	return timed(r.m, "boards", "SetState", func() (model.Board, error) { return r.next.SetState(ctx, id, state, reason) })
+}
+
+func (r *instrumentedBoardRepo) SlugExists(ctx context.Context, slug string) (bool, error) {
+	return timed(r.m, "boards", "SlugExists", func() (bool, error) { return r.next.SlugExists(ctx, slug) })
+}
+
+func (r *instrumentedBoardRepo) Create(ctx context.Context, ownerID, title, slug string) (model.Board, error) {
+	return timed(r.m, "boards", "Create", func() (model.Board, error) { return r.next.Create(ctx, ownerID, title, slug) })
+}
+
+func (r *instrumentedBoardRepo) Update(ctx context.Context, ownerID, id string, ch BoardChanges, version int64) (model.Board, error) {
+	return timed(r.m, "boards", "Update", func() (model.Board, error) { return r.next.Update(ctx, ownerID, id, ch, version) })
+}
+
+func (r *instrumentedBoardRepo) Delete(ctx context.Context, ownerID, id string) error {
+	return timedErr(r.m, "boards", "Delete", func() error { return r.next.Delete(ctx, ownerID, id) })
+}
+
+// ========== 列表仓储装饰器 ==========
+
+type instrumentedListRepo struct {
+	next ListRepository
+	m    *QueryMetrics
+}
+
+// InstrumentListRepo 用统计装饰器包装列表仓储
+func InstrumentListRepo(next ListRepository, m *QueryMetrics) ListRepository {
+	m.addPool("lists", next)
+	return &instrumentedListRepo{next: next, m: m}
+}
+
+func (r *instrumentedListRepo) ListByBoard(ctx context.Context, boardID string) ([]model.List, error) {
+	return timed(r.m, "lists", "ListByBoard", func() ([]model.List, error) { return r.next.ListByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedListRepo) Get(ctx context.Context, boardID, id string) (model.List, error) {
+	return timed(r.m, "lists", "Get", func() (model.List, error) { return r.next.Get(ctx, boardID, id) })
+}
+
+func (r *instrumentedListRepo) Create(ctx context.Context, boardID, title string) (model.List, error) {
+	return timed(r.m, "lists", "Create", func() (model.List, error) { return r.next.Create(ctx, boardID, title) })
+}
+
+func (r *instrumentedListRepo) Rename(ctx context.Context, boardID, id, title string) (model.List, error) {
+	return timed(r.m, "lists", "Rename", func() (model.List, error) { return r.next.Rename(ctx, boardID, id, title) })
+}
+
+func (r *instrumentedListRepo) Move(ctx context.Context, boardID, id string, position int) ([]model.List, error) {
+	return timed(r.m, "lists", "Move", func() ([]model.List, error) { return r.next.Move(ctx, boardID, id, position) })
+}
+
+func (r *instrumentedListRepo) Delete(ctx context.Context, boardID, id string) error {
+	return timedErr(r.m, "lists", "Delete", func() error { return r.next.Delete(ctx, boardID, id) })
+}
+
+func (r *instrumentedListRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "lists", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+// ========== 卡片仓储装饰器 ==========
+
+type instrumentedCardRepo struct {
+	next CardRepository
+	m    *QueryMetrics
+}
+
+// InstrumentCardRepo 用统计装饰器包装卡片仓储
+func InstrumentCardRepo(next CardRepository, m *QueryMetrics) CardRepository {
+	m.addPool("cards", next)
+	return &instrumentedCardRepo{next: next, m: m}
+}
+
+// Ping 不计入统计：探针每隔几秒调用一次，会淹没真正的业务调用
+func (r *instrumentedCardRepo) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *instrumentedCardRepo) ListByList(ctx context.Context, listID string) ([]model.Card, error) {
+	return timed(r.m, "cards", "ListByList", func() ([]model.Card, error) { return r.next.ListByList(ctx, listID) })
+}
+
+func (r *instrumentedCardRepo) Get(ctx context.Context, listID, id string) (model.Card, error) {
+	return timed(r.m, "cards", "Get", func() (model.Card, error) { return r.next.Get(ctx, listID, id) })
+}
+
+func (r *instrumentedCardRepo) GetByID(ctx context.Context, id string) (model.Card, error) {
+	return timed(r.m, "cards", "GetByID", func() (model.Card, error) { return r.next.GetByID(ctx, id) })
+}
+
+func (r *instrumentedCardRepo) Create(ctx context.Context, c model.Card) (model.Card, error) {
+	return timed(r.m, "cards", "Create", func() (model.Card, error) { return r.next.Create(ctx, c) })
+}
+
+func (r *instrumentedCardRepo) Update(ctx context.Context, listID, id string, ch CardChanges) (model.Card, error) {
+	return timed(r.m, "cards", "Update", func() (model.Card, error) { return r.next.Update(ctx, listID, id, ch) })
+}
+
+func (r *instrumentedCardRepo) Move(ctx context.Context, fromListID, id, toBoardID, toListID string, position int) ([]model.Card, error) {
+	return timed(r.m, "cards", "Move", func() ([]model.Card, error) {
+		return r.next.Move(ctx, fromListID, id, toBoardID, toListID, position)
+	})
+}
+
+func (r *instrumentedCardRepo) Delete(ctx context.Context, listID, id string) error {
+	return timedErr(r.m, "cards", "Delete", func() error { return r.next.Delete(ctx, listID, id) })
+}
+
+func (r *instrumentedCardRepo) DeleteByList(ctx context.Context, listID string) error {
+	return timedErr(r.m, "cards", "DeleteByList", func() error { return r.next.DeleteByList(ctx, listID) })
+}
+
+func (r *instrumentedCardRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "cards", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedCardRepo) CountByBoard(ctx context.Context, boardID string) (int, error) {
+	return timed(r.m, "cards", "CountByBoard", func() (int, error) { return r.next.CountByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedCardRepo) ListDue(ctx context.Context, boardIDs []string, from, to time.Time) ([]model.Card, error) {
+	return timed(r.m, "cards", "ListDue", func() ([]model.Card, error) { return r.next.ListDue(ctx, boardIDs, from, to) })
+}
+
+func (r *instrumentedCardRepo) ListReminders(ctx context.Context, before time.Time) ([]model.Card, error) {
+	return timed(r.m, "cards", "ListReminders", func() ([]model.Card, error) { return r.next.ListReminders(ctx, before) })
+}
+
+func (r *instrumentedCardRepo) MarkReminded(ctx context.Context, id string, at time.Time) (bool, error) {
+	return timed(r.m, "cards", "MarkReminded", func() (bool, error) { return r.next.MarkReminded(ctx, id, at) })
+}
+
+func (r *instrumentedCardRepo) CountByList(ctx context.Context, now time.Time) ([]model.ListCardCount, error) {
+	return timed(r.m, "cards", "CountByList", func() ([]model.ListCardCount, error) { return r.next.CountByList(ctx, now) })
+}
+
+// ========== 嵌入令牌仓储装饰器 ==========
+
+type instrumentedEmbedTokenRepo struct {
+	next EmbedTokenRepository
+	m    *QueryMetrics
+}
+
+// InstrumentEmbedTokenRepo 用统计装饰器包装嵌入令牌仓储
+func InstrumentEmbedTokenRepo(next EmbedTokenRepository, m *QueryMetrics) EmbedTokenRepository {
+	m.addPool("embed_tokens", next)
+	return &instrumentedEmbedTokenRepo{next: next, m: m}
+}
+
+func (r *instrumentedEmbedTokenRepo) Create(ctx context.Context, boardID, createdBy string, expiresAt time.Time) (model.EmbedToken, error) {
+	return timed(r.m, "embed_tokens", "Create", func() (model.EmbedToken, error) { return r.next.Create(ctx, boardID, createdBy, expiresAt) })
+}
+
+func (r *instrumentedEmbedTokenRepo) Get(ctx context.Context, id string) (model.EmbedToken, error) {
+	return timed(r.m, "embed_tokens", "Get", func() (model.EmbedToken, error) { return r.next.Get(ctx, id) })
+}
+
+func (r *instrumentedEmbedTokenRepo) ListByBoard(ctx context.Context, boardID string) ([]model.EmbedToken, error) {
+	return timed(r.m, "embed_tokens", "ListByBoard", func() ([]model.EmbedToken, error) { return r.next.ListByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedEmbedTokenRepo) Revoke(ctx context.Context, id string) (model.EmbedToken, error) {
+	return timed(r.m, "embed_tokens", "Revoke", func() (model.EmbedToken, error) { return r.next.Revoke(ctx, id) })
+}
+
+// ========== 刷新令牌仓储装饰器 ==========
+
+type instrumentedRefreshTokenRepo struct {
+	next RefreshTokenRepository
+	m    *QueryMetrics
+}
+
+// InstrumentRefreshTokenRepo 用统计装饰器包装刷新令牌仓储
+func InstrumentRefreshTokenRepo(next RefreshTokenRepository, m *QueryMetrics) RefreshTokenRepository {
+	m.addPool("refresh_tokens", next)
+	return &instrumentedRefreshTokenRepo{next: next, m: m}
+}
+
+func (r *instrumentedRefreshTokenRepo) Create(ctx context.Context, userID, familyID, tokenHash string, expiresAt time.Time) (model.RefreshToken, error) {
+	return timed(r.m, "refresh_tokens", "Create", func() (model.RefreshToken, error) {
+		return r.next.Create(ctx, userID, familyID, tokenHash, expiresAt)
+	})
+}
+
+func (r *instrumentedRefreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	return timed(r.m, "refresh_tokens", "GetByHash", func() (model.RefreshToken, error) { return r.next.GetByHash(ctx, tokenHash) })
+}
+
+func (r *instrumentedRefreshTokenRepo) Revoke(ctx context.Context, id string) (bool, error) {
+	return timed(r.m, "refresh_tokens", "Revoke", func() (bool, error) { return r.next.Revoke(ctx, id) })
+}
+
+func (r *instrumentedRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	return timedErr(r.m, "refresh_tokens", "RevokeFamily", func() error { return r.next.RevokeFamily(ctx, familyID) })
+}
+
+func (r *instrumentedRefreshTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	return timedErr(r.m, "refresh_tokens", "RevokeUser", func() error { return r.next.RevokeUser(ctx, userID) })
+}
+
+// ========== 密码重置令牌仓储装饰器 ==========
+
+type instrumentedPasswordResetRepo struct {
+	next PasswordResetRepository
+	m    *QueryMetrics
+}
+
+// InstrumentPasswordResetRepo 用统计装饰器包装密码重置令牌仓储
+func InstrumentPasswordResetRepo(next PasswordResetRepository, m *QueryMetrics) PasswordResetRepository {
+	m.addPool("password_resets", next)
+	return &instrumentedPasswordResetRepo{next: next, m: m}
+}
+
+func (r *instrumentedPasswordResetRepo) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (model.PasswordResetToken, error) {
+	return timed(r.m, "password_resets", "Create", func() (model.PasswordResetToken, error) {
+		return r.next.Create(ctx, userID, tokenHash, expiresAt)
+	})
+}
+
+func (r *instrumentedPasswordResetRepo) GetByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	return timed(r.m, "password_resets", "GetByHash", func() (model.PasswordResetToken, error) { return r.next.GetByHash(ctx, tokenHash) })
+}
+
+func (r *instrumentedPasswordResetRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	return timed(r.m, "password_resets", "MarkUsed", func() (bool, error) { return r.next.MarkUsed(ctx, id) })
+}
+
+func (r *instrumentedPasswordResetRepo) RevokeUser(ctx context.Context, userID string) error {
+	return timedErr(r.m, "password_resets", "RevokeUser", func() error { return r.next.RevokeUser(ctx, userID) })
+}
+
+// ========== 看板成员仓储装饰器 ==========
+
+type instrumentedMemberRepo struct {
+	next MemberRepository
+	m    *QueryMetrics
+}
+
+// InstrumentMemberRepo 用统计装饰器包装看板成员仓储
+func InstrumentMemberRepo(next MemberRepository, m *QueryMetrics) MemberRepository {
+	m.addPool("members", next)
+	return &instrumentedMemberRepo{next: next, m: m}
+}
+
+func (r *instrumentedMemberRepo) Upsert(ctx context.Context, boardID, userID, role string) (model.BoardMember, error) {
+	return timed(r.m, "members", "Upsert", func() (model.BoardMember, error) { return r.next.Upsert(ctx, boardID, userID, role) })
+}
+
+func (r *instrumentedMemberRepo) Get(ctx context.Context, boardID, userID string) (model.BoardMember, error) {
+	return timed(r.m, "members", "Get", func() (model.BoardMember, error) { return r.next.Get(ctx, boardID, userID) })
+}
+
+func (r *instrumentedMemberRepo) ListByBoard(ctx context.Context, boardID string) ([]model.BoardMember, error) {
+	return timed(r.m, "members", "ListByBoard", func() ([]model.BoardMember, error) { return r.next.ListByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedMemberRepo) ListByUser(ctx context.Context, userID string) ([]model.BoardMember, error) {
+	return timed(r.m, "members", "ListByUser", func() ([]model.BoardMember, error) { return r.next.ListByUser(ctx, userID) })
+}
+
+func (r *instrumentedMemberRepo) Remove(ctx context.Context, boardID, userID string) error {
+	return timedErr(r.m, "members", "Remove", func() error { return r.next.Remove(ctx, boardID, userID) })
+}
+
+func (r *instrumentedMemberRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "members", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+// ========== 搜索仓储装饰器 ==========
+
+type instrumentedSearchRepo struct {
+	next SearchRepository
+	m    *QueryMetrics
+}
+
+// InstrumentSearchRepo 用统计装饰器包装搜索仓储
+func InstrumentSearchRepo(next SearchRepository, m *QueryMetrics) SearchRepository {
+	m.addPool("search", next)
+	return &instrumentedSearchRepo{next: next, m: m}
+}
+
+func (r *instrumentedSearchRepo) Search(ctx context.Context, boardIDs, terms []string, limit int) ([]model.SearchResult, error) {
+	return timed(r.m, "search", "Search", func() ([]model.SearchResult, error) { return r.next.Search(ctx, boardIDs, terms, limit) })
+}
+
+// ========== webhook 仓储装饰器 ==========
+
+type instrumentedWebhookRepo struct {
+	next WebhookRepository
+	m    *QueryMetrics
+}
+
+// InstrumentWebhookRepo 用统计装饰器包装 webhook 仓储
+func InstrumentWebhookRepo(next WebhookRepository, m *QueryMetrics) WebhookRepository {
+	m.addPool("webhooks", next)
+	return &instrumentedWebhookRepo{next: next, m: m}
+}
+
+func (r *instrumentedWebhookRepo) Create(ctx context.Context, w model.Webhook) (model.Webhook, error) {
+	return timed(r.m, "webhooks", "Create", func() (model.Webhook, error) { return r.next.Create(ctx, w) })
+}
+
+func (r *instrumentedWebhookRepo) ListByBoard(ctx context.Context, boardID string) ([]model.Webhook, error) {
+	return timed(r.m, "webhooks", "ListByBoard", func() ([]model.Webhook, error) { return r.next.ListByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedWebhookRepo) Delete(ctx context.Context, boardID, id string) error {
+	return timedErr(r.m, "webhooks", "Delete", func() error { return r.next.Delete(ctx, boardID, id) })
+}
+
+func (r *instrumentedWebhookRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "webhooks", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedWebhookRepo) RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error {
+	return timedErr(r.m, "webhooks", "RecordDelivery", func() error { return r.next.RecordDelivery(ctx, id, at, status, errMsg) })
+}
+
+// ========== 卡片模板仓储装饰器 ==========
+
+type instrumentedCardTemplateRepo struct {
+	next CardTemplateRepository
+	m    *QueryMetrics
+}
+
+// InstrumentCardTemplateRepo 用统计装饰器包装卡片模板仓储
+func InstrumentCardTemplateRepo(next CardTemplateRepository, m *QueryMetrics) CardTemplateRepository {
+	m.addPool("card_templates", next)
+	return &instrumentedCardTemplateRepo{next: next, m: m}
+}
+
+func (r *instrumentedCardTemplateRepo) ListByBoard(ctx context.Context, boardID string) ([]model.CardTemplate, error) {
+	return timed(r.m, "card_templates", "ListByBoard", func() ([]model.CardTemplate, error) { return r.next.ListByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedCardTemplateRepo) Get(ctx context.Context, boardID, id string) (model.CardTemplate, error) {
+	return timed(r.m, "card_templates", "Get", func() (model.CardTemplate, error) { return r.next.Get(ctx, boardID, id) })
+}
+
+func (r *instrumentedCardTemplateRepo) Create(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	return timed(r.m, "card_templates", "Create", func() (model.CardTemplate, error) { return r.next.Create(ctx, t) })
+}
+
+func (r *instrumentedCardTemplateRepo) Update(ctx context.Context, t model.CardTemplate) (model.CardTemplate, error) {
+	return timed(r.m, "card_templates", "Update", func() (model.CardTemplate, error) { return r.next.Update(ctx, t) })
+}
+
+func (r *instrumentedCardTemplateRepo) Delete(ctx context.Context, boardID, id string) error {
+	return timedErr(r.m, "card_templates", "Delete", func() error { return r.next.Delete(ctx, boardID, id) })
+}
+
+func (r *instrumentedCardTemplateRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "card_templates", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+// ========== 卡片镜像链接仓储装饰器 ==========
+
+type instrumentedCardLinkRepo struct {
+	next CardLinkRepository
+	m    *QueryMetrics
+}
+
+// InstrumentCardLinkRepo 用统计装饰器包装卡片镜像链接仓储
+func InstrumentCardLinkRepo(next CardLinkRepository, m *QueryMetrics) CardLinkRepository {
+	m.addPool("card_links", next)
+	return &instrumentedCardLinkRepo{next: next, m: m}
+}
+
+func (r *instrumentedCardLinkRepo) Create(ctx context.Context, sourceCardID, mirrorCardID, createdBy string) (model.CardLink, error) {
+	return timed(r.m, "card_links", "Create", func() (model.CardLink, error) {
+		return r.next.Create(ctx, sourceCardID, mirrorCardID, createdBy)
+	})
+}
+
+func (r *instrumentedCardLinkRepo) Get(ctx context.Context, id string) (model.CardLink, error) {
+	return timed(r.m, "card_links", "Get", func() (model.CardLink, error) { return r.next.Get(ctx, id) })
+}
+
+func (r *instrumentedCardLinkRepo) ListByCard(ctx context.Context, cardID string) ([]model.CardLink, error) {
+	return timed(r.m, "card_links", "ListByCard", func() ([]model.CardLink, error) { return r.next.ListByCard(ctx, cardID) })
+}
+
+func (r *instrumentedCardLinkRepo) Delete(ctx context.Context, id string) error {
+	return timedErr(r.m, "card_links", "Delete", func() error { return r.next.Delete(ctx, id) })
+}
+
+func (r *instrumentedCardLinkRepo) DeleteByCard(ctx context.Context, cardID string) error {
+	return timedErr(r.m, "card_links", "DeleteByCard", func() error { return r.next.DeleteByCard(ctx, cardID) })
+}
+
+// ========== 第三方登录身份仓储装饰器 ==========
+
+type instrumentedUserIdentityRepo struct {
+	next UserIdentityRepository
+	m    *QueryMetrics
+}
+
+// InstrumentUserIdentityRepo 用统计装饰器包装第三方登录身份仓储
+func InstrumentUserIdentityRepo(next UserIdentityRepository, m *QueryMetrics) UserIdentityRepository {
+	m.addPool("user_identities", next)
+	return &instrumentedUserIdentityRepo{next: next, m: m}
+}
+
+func (r *instrumentedUserIdentityRepo) Get(ctx context.Context, provider, subject string) (model.UserIdentity, error) {
+	return timed(r.m, "user_identities", "Get", func() (model.UserIdentity, error) { return r.next.Get(ctx, provider, subject) })
+}
+
+func (r *instrumentedUserIdentityRepo) Create(ctx context.Context, userID, provider, subject, email string) (model.UserIdentity, error) {
+	return timed(r.m, "user_identities", "Create", func() (model.UserIdentity, error) {
+		return r.next.Create(ctx, userID, provider, subject, email)
+	})
+}
+
+func (r *instrumentedUserIdentityRepo) Delete(ctx context.Context, id string) error {
+	return timedErr(r.m, "user_identities", "Delete", func() error { return r.next.Delete(ctx, id) })
+}
+
+// ========== API 密钥仓储装饰器 ==========
+
+type instrumentedAPIKeyRepo struct {
+	next APIKeyRepository
+	m    *QueryMetrics
+}
+
+// InstrumentAPIKeyRepo 用统计装饰器包装 API 密钥仓储
+func InstrumentAPIKeyRepo(next APIKeyRepository, m *QueryMetrics) APIKeyRepository {
+	m.addPool("api_keys", next)
+	return &instrumentedAPIKeyRepo{next: next, m: m}
+}
+
+func (r *instrumentedAPIKeyRepo) Create(ctx context.Context, k model.APIKey) (model.APIKey, error) {
+	return timed(r.m, "api_keys", "Create", func() (model.APIKey, error) { return r.next.Create(ctx, k) })
+}
+
+func (r *instrumentedAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	return timed(r.m, "api_keys", "GetByHash", func() (model.APIKey, error) { return r.next.GetByHash(ctx, keyHash) })
+}
+
+func (r *instrumentedAPIKeyRepo) ListByUser(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return timed(r.m, "api_keys", "ListByUser", func() ([]model.APIKey, error) { return r.next.ListByUser(ctx, userID) })
+}
+
+func (r *instrumentedAPIKeyRepo) Revoke(ctx context.Context, userID, id string) error {
+	return timedErr(r.m, "api_keys", "Revoke", func() error { return r.next.Revoke(ctx, userID, id) })
+}
+
+func (r *instrumentedAPIKeyRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	return timedErr(r.m, "api_keys", "Touch", func() error { return r.next.Touch(ctx, id, at) })
+}
+
+// ========== 看板动态仓储装饰器 ==========
+
+type instrumentedActivityRepo struct {
+	next ActivityRepository
+	m    *QueryMetrics
+}
+
+// InstrumentActivityRepo 用统计装饰器包装看板动态仓储
+func InstrumentActivityRepo(next ActivityRepository, m *QueryMetrics) ActivityRepository {
+	m.addPool("activities", next)
+	return &instrumentedActivityRepo{next: next, m: m}
+}
+
+func (r *instrumentedActivityRepo) Create(ctx context.Context, a model.Activity) (model.Activity, error) {
+	return timed(r.m, "activities", "Create", func() (model.Activity, error) { return r.next.Create(ctx, a) })
+}
+
+func (r *instrumentedActivityRepo) ListByBoard(ctx context.Context, boardID string, beforeSeq int64, limit int) ([]model.Activity, error) {
+	return timed(r.m, "activities", "ListByBoard", func() ([]model.Activity, error) {
+		return r.next.ListByBoard(ctx, boardID, beforeSeq, limit)
+	})
+}
+
+func (r *instrumentedActivityRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "activities", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+func (r *instrumentedActivityRepo) CountByActor(ctx context.Context, from, to time.Time) ([]model.ActivityCount, error) {
+	return timed(r.m, "activities", "CountByActor", func() ([]model.ActivityCount, error) { return r.next.CountByActor(ctx, from, to) })
+}
+
+// ========== 检查清单仓储装饰器 ==========
+
+type instrumentedChecklistRepo struct {
+	next ChecklistRepository
+	m    *QueryMetrics
+}
+
+// InstrumentChecklistRepo 用统计装饰器包装检查清单仓储
+func InstrumentChecklistRepo(next ChecklistRepository, m *QueryMetrics) ChecklistRepository {
+	m.addPool("checklists", next)
+	return &instrumentedChecklistRepo{next: next, m: m}
+}
+
+func (r *instrumentedChecklistRepo) ListByCard(ctx context.Context, cardID string) ([]model.Checklist, error) {
+	return timed(r.m, "checklists", "ListByCard", func() ([]model.Checklist, error) { return r.next.ListByCard(ctx, cardID) })
+}
+
+func (r *instrumentedChecklistRepo) Get(ctx context.Context, cardID, id string) (model.Checklist, error) {
+	return timed(r.m, "checklists", "Get", func() (model.Checklist, error) { return r.next.Get(ctx, cardID, id) })
+}
+
+func (r *instrumentedChecklistRepo) Create(ctx context.Context, cardID, title string) (model.Checklist, error) {
+	return timed(r.m, "checklists", "Create", func() (model.Checklist, error) { return r.next.Create(ctx, cardID, title) })
+}
+
+func (r *instrumentedChecklistRepo) Rename(ctx context.Context, cardID, id, title string) (model.Checklist, error) {
+	return timed(r.m, "checklists", "Rename", func() (model.Checklist, error) { return r.next.Rename(ctx, cardID, id, title) })
+}
+
+func (r *instrumentedChecklistRepo) Move(ctx context.Context, cardID, id string, position int) ([]model.Checklist, error) {
+	return timed(r.m, "checklists", "Move", func() ([]model.Checklist, error) { return r.next.Move(ctx, cardID, id, position) })
+}
+
+func (r *instrumentedChecklistRepo) Delete(ctx context.Context, cardID, id string) error {
+	return timedErr(r.m, "checklists", "Delete", func() error { return r.next.Delete(ctx, cardID, id) })
+}
+
+func (r *instrumentedChecklistRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	return timedErr(r.m, "checklists", "DeleteByCards", func() error { return r.next.DeleteByCards(ctx, cardIDs) })
+}
+
+func (r *instrumentedChecklistRepo) AddItem(ctx context.Context, checklistID, text string) (model.ChecklistItem, error) {
+	return timed(r.m, "checklists", "AddItem", func() (model.ChecklistItem, error) { return r.next.AddItem(ctx, checklistID, text) })
+}
+
+func (r *instrumentedChecklistRepo) UpdateItem(ctx context.Context, item model.ChecklistItem) (model.ChecklistItem, error) {
+	return timed(r.m, "checklists", "UpdateItem", func() (model.ChecklistItem, error) { return r.next.UpdateItem(ctx, item) })
+}
+
+func (r *instrumentedChecklistRepo) MoveItem(ctx context.Context, checklistID, id string, position int) ([]model.ChecklistItem, error) {
+	return timed(r.m, "checklists", "MoveItem", func() ([]model.ChecklistItem, error) {
+		return r.next.MoveItem(ctx, checklistID, id, position)
+	})
+}
+
+func (r *instrumentedChecklistRepo) DeleteItem(ctx context.Context, checklistID, id string) error {
+	return timedErr(r.m, "checklists", "DeleteItem", func() error { return r.next.DeleteItem(ctx, checklistID, id) })
+}
+
+func (r *instrumentedChecklistRepo) ProgressByCards(ctx context.Context, cardIDs []string) (map[string]model.ChecklistProgress, error) {
+	return timed(r.m, "checklists", "ProgressByCards", func() (map[string]model.ChecklistProgress, error) {
+		return r.next.ProgressByCards(ctx, cardIDs)
+	})
+}
+
+func (r *instrumentedChecklistRepo) ProgressByChecklists(ctx context.Context, cardIDs []string) (map[string]map[string]model.ChecklistProgress, error) {
+	return timed(r.m, "checklists", "ProgressByChecklists", func() (map[string]map[string]model.ChecklistProgress, error) {
+		return r.next.ProgressByChecklists(ctx, cardIDs)
+	})
+}
+
+// ========== 附件仓储装饰器 ==========
+
+type instrumentedAttachmentRepo struct {
+	next AttachmentRepository
+	m    *QueryMetrics
+}
+
+// InstrumentAttachmentRepo 用统计装饰器包装附件仓储
+func InstrumentAttachmentRepo(next AttachmentRepository, m *QueryMetrics) AttachmentRepository {
+	m.addPool("attachments", next)
+	return &instrumentedAttachmentRepo{next: next, m: m}
+}
+
+func (r *instrumentedAttachmentRepo) Create(ctx context.Context, a model.Attachment) (model.Attachment, error) {
+	return timed(r.m, "attachments", "Create", func() (model.Attachment, error) { return r.next.Create(ctx, a) })
+}
+
+func (r *instrumentedAttachmentRepo) Get(ctx context.Context, cardID, id string) (model.Attachment, error) {
+	return timed(r.m, "attachments", "Get", func() (model.Attachment, error) { return r.next.Get(ctx, cardID, id) })
+}
+
+func (r *instrumentedAttachmentRepo) ListByCard(ctx context.Context, cardID string) ([]model.Attachment, error) {
+	return timed(r.m, "attachments", "ListByCard", func() ([]model.Attachment, error) { return r.next.ListByCard(ctx, cardID) })
+}
+
+func (r *instrumentedAttachmentRepo) Delete(ctx context.Context, cardID, id string) error {
+	return timedErr(r.m, "attachments", "Delete", func() error { return r.next.Delete(ctx, cardID, id) })
+}
+
+func (r *instrumentedAttachmentRepo) DeleteByCards(ctx context.Context, cardIDs []string) error {
+	return timedErr(r.m, "attachments", "DeleteByCards", func() error { return r.next.DeleteByCards(ctx, cardIDs) })
+}
+
+func (r *instrumentedAttachmentRepo) UsageByCards(ctx context.Context, cardIDs []string) (model.StorageUsage, error) {
+	return timed(r.m, "attachments", "UsageByCards", func() (model.StorageUsage, error) { return r.next.UsageByCards(ctx, cardIDs) })
+}
+
+func (r *instrumentedAttachmentRepo) UnreferencedBlobs(ctx context.Context) ([]string, error) {
+	return timed(r.m, "attachments", "UnreferencedBlobs", func() ([]string, error) { return r.next.UnreferencedBlobs(ctx) })
+}
+
+func (r *instrumentedAttachmentRepo) DeleteBlob(ctx context.Context, hash string) (bool, error) {
+	return timed(r.m, "attachments", "DeleteBlob", func() (bool, error) { return r.next.DeleteBlob(ctx, hash) })
+}
+
+func (r *instrumentedAttachmentRepo) ArchivableBlobs(ctx context.Context, cardIDs []string, before time.Time) ([]string, error) {
+	return timed(r.m, "attachments", "ArchivableBlobs", func() ([]string, error) { return r.next.ArchivableBlobs(ctx, cardIDs, before) })
+}
+
+func (r *instrumentedAttachmentRepo) SetBlobClass(ctx context.Context, hash, from, to string) (bool, error) {
+	return timed(r.m, "attachments", "SetBlobClass", func() (bool, error) { return r.next.SetBlobClass(ctx, hash, from, to) })
+}
+
+func (r *instrumentedAttachmentRepo) CreateUpload(ctx context.Context, u model.ResumableUpload) (model.ResumableUpload, error) {
+	return timed(r.m, "attachments", "CreateUpload", func() (model.ResumableUpload, error) { return r.next.CreateUpload(ctx, u) })
+}
+
+func (r *instrumentedAttachmentRepo) GetUpload(ctx context.Context, cardID, id string) (model.ResumableUpload, error) {
+	return timed(r.m, "attachments", "GetUpload", func() (model.ResumableUpload, error) { return r.next.GetUpload(ctx, cardID, id) })
+}
+
+func (r *instrumentedAttachmentRepo) TouchUpload(ctx context.Context, id string, expiresAt time.Time) error {
+	return timedErr(r.m, "attachments", "TouchUpload", func() error { return r.next.TouchUpload(ctx, id, expiresAt) })
+}
+
+func (r *instrumentedAttachmentRepo) DeleteUpload(ctx context.Context, id string) error {
+	return timedErr(r.m, "attachments", "DeleteUpload", func() error { return r.next.DeleteUpload(ctx, id) })
+}
+
+func (r *instrumentedAttachmentRepo) ExpiredUploads(ctx context.Context, now time.Time) ([]string, error) {
+	return timed(r.m, "attachments", "ExpiredUploads", func() ([]string, error) { return r.next.ExpiredUploads(ctx, now) })
+}
+
+// ========== 看板占用空间计数器仓储装饰器 ==========
+
+type instrumentedStorageRepo struct {
+	next StorageRepository
+	m    *QueryMetrics
+}
+
+// InstrumentStorageRepo 用统计装饰器包装计数器仓储
+func InstrumentStorageRepo(next StorageRepository, m *QueryMetrics) StorageRepository {
+	m.addPool("storage", next)
+	return &instrumentedStorageRepo{next: next, m: m}
+}
+
+func (r *instrumentedStorageRepo) Get(ctx context.Context, boardID string) (model.StorageUsage, error) {
+	return timed(r.m, "storage", "Get", func() (model.StorageUsage, error) { return r.next.Get(ctx, boardID) })
+}
+
+func (r *instrumentedStorageRepo) Add(ctx context.Context, boardID string, delta model.StorageUsage) error {
+	return timedErr(r.m, "storage", "Add", func() error { return r.next.Add(ctx, boardID, delta) })
+}
+
+func (r *instrumentedStorageRepo) Set(ctx context.Context, boardID string, u model.StorageUsage) error {
+	return timedErr(r.m, "storage", "Set", func() error { return r.next.Set(ctx, boardID, u) })
+}
+
+func (r *instrumentedStorageRepo) Delete(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "storage", "Delete", func() error { return r.next.Delete(ctx, boardID) })
+}
+
+func (r *instrumentedStorageRepo) ListBoardIDs(ctx context.Context) ([]string, error) {
+	return timed(r.m, "storage", "ListBoardIDs", func() ([]string, error) { return r.next.ListBoardIDs(ctx) })
+}
+
+// ========== 幂等键仓储装饰器 ==========
+
+type instrumentedIdempotencyRepo struct {
+	next IdempotencyRepository
+	m    *QueryMetrics
+}
+
+// InstrumentIdempotencyRepo 用统计装饰器包装幂等键仓储
+func InstrumentIdempotencyRepo(next IdempotencyRepository, m *QueryMetrics) IdempotencyRepository {
+	m.addPool("idempotency", next)
+	return &instrumentedIdempotencyRepo{next: next, m: m}
+}
+
+func (r *instrumentedIdempotencyRepo) Reserve(ctx context.Context, rec model.IdempotencyRecord) (model.IdempotencyRecord, bool, error) {
+	start := time.Now()
+	got, ok, err := r.next.Reserve(ctx, rec)
+	r.m.observe("idempotency", "Reserve", time.Since(start), err)
+	return got, ok, err
+}
+
+func (r *instrumentedIdempotencyRepo) Complete(ctx context.Context, userID, key string, status int, headers map[string]string, body []byte) error {
+	return timedErr(r.m, "idempotency", "Complete", func() error { return r.next.Complete(ctx, userID, key, status, headers, body) })
+}
+
+func (r *instrumentedIdempotencyRepo) Release(ctx context.Context, userID, key string) error {
+	return timedErr(r.m, "idempotency", "Release", func() error { return r.next.Release(ctx, userID, key) })
+}
+
+func (r *instrumentedIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return timed(r.m, "idempotency", "DeleteExpired", func() (int, error) { return r.next.DeleteExpired(ctx, now) })
+}
+
+// ========== 看板每日快照仓储装饰器 ==========
+
+type instrumentedSnapshotRepo struct {
+	next SnapshotRepository
+	m    *QueryMetrics
+}
+
+// InstrumentSnapshotRepo 用统计装饰器包装快照仓储
+func InstrumentSnapshotRepo(next SnapshotRepository, m *QueryMetrics) SnapshotRepository {
+	m.addPool("snapshots", next)
+	return &instrumentedSnapshotRepo{next: next, m: m}
+}
+
+func (r *instrumentedSnapshotRepo) Save(ctx context.Context, snapshots []model.BoardSnapshot) error {
+	return timedErr(r.m, "snapshots", "Save", func() error { return r.next.Save(ctx, snapshots) })
+}
+
+func (r *instrumentedSnapshotRepo) ListByBoard(ctx context.Context, boardID, from, to string) ([]model.BoardSnapshot, error) {
+	return timed(r.m, "snapshots", "ListByBoard", func() ([]model.BoardSnapshot, error) { return r.next.ListByBoard(ctx, boardID, from, to) })
+}
+
+func (r *instrumentedSnapshotRepo) BoardIDs(ctx context.Context) ([]string, error) {
+	return timed(r.m, "snapshots", "BoardIDs", func() ([]string, error) { return r.next.BoardIDs(ctx) })
+}
+
+func (r *instrumentedSnapshotRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return timedErr(r.m, "snapshots", "DeleteByBoard", func() error { return r.next.DeleteByBoard(ctx, boardID) })
+}
+
+// ========== 登录尝试记录仓储装饰器 ==========
+
+type instrumentedLoginAttemptRepo struct {
+	next LoginAttemptRepository
+	m    *QueryMetrics
+}
+
+// InstrumentLoginAttemptRepo 用统计装饰器包装登录尝试记录仓储
+func InstrumentLoginAttemptRepo(next LoginAttemptRepository, m *QueryMetrics) LoginAttemptRepository {
+	m.addPool("login_attempts", next)
+	return &instrumentedLoginAttemptRepo{next: next, m: m}
+}
+
+func (r *instrumentedLoginAttemptRepo) Record(ctx context.Context, a model.LoginAttempt) error {
+	return timedErr(r.m, "login_attempts", "Record", func() error { return r.next.Record(ctx, a) })
+}
+
+func (r *instrumentedLoginAttemptRepo) CountFailures(ctx context.Context, from, to time.Time) ([]model.LoginFailureCount, error) {
+	return timed(r.m, "login_attempts", "CountFailures", func() ([]model.LoginFailureCount, error) { return r.next.CountFailures(ctx, from, to) })
+}
+
+func (r *instrumentedLoginAttemptRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	return timed(r.m, "login_attempts", "DeleteBefore", func() (int, error) { return r.next.DeleteBefore(ctx, before) })
+}
+
+// ========== 异常检测结果仓储装饰器 ==========
+
+type instrumentedAnomalyRepo struct {
+	next AnomalyRepository
+	m    *QueryMetrics
+}
+
+// InstrumentAnomalyRepo 用统计装饰器包装异常检测结果仓储
+func InstrumentAnomalyRepo(next AnomalyRepository, m *QueryMetrics) AnomalyRepository {
+	m.addPool("anomalies", next)
+	return &instrumentedAnomalyRepo{next: next, m: m}
+}
+
+func (r *instrumentedAnomalyRepo) Create(ctx context.Context, a model.Anomaly) (model.Anomaly, bool, error) {
+	start := time.Now()
+	got, ok, err := r.next.Create(ctx, a)
+	r.m.observe("anomalies", "Create", time.Since(start), err)
+	return got, ok, err
+}
+
+func (r *instrumentedAnomalyRepo) List(ctx context.Context, limit int) ([]model.Anomaly, error) {
+	return timed(r.m, "anomalies", "List", func() ([]model.Anomaly, error) { return r.next.List(ctx, limit) })
+}
+
+// ========== 健康探测记录仓储装饰器 ==========
+
+type instrumentedHealthCheckRepo struct {
+	next HealthCheckRepository
+	m    *QueryMetrics
+}
+
+// InstrumentHealthCheckRepo 用统计装饰器包装健康探测记录仓储
+func InstrumentHealthCheckRepo(next HealthCheckRepository, m *QueryMetrics) HealthCheckRepository {
+	m.addPool("health_checks", next)
+	return &instrumentedHealthCheckRepo{next: next, m: m}
+}
+
+func (r *instrumentedHealthCheckRepo) Save(ctx context.Context, checks []model.HealthCheck) error {
+	return timedErr(r.m, "health_checks", "Save", func() error { return r.next.Save(ctx, checks) })
+}
+
+func (r *instrumentedHealthCheckRepo) Latest(ctx context.Context) ([]model.HealthCheck, error) {
+	return timed(r.m, "health_checks", "Latest", func() ([]model.HealthCheck, error) { return r.next.Latest(ctx) })
+}
+
+func (r *instrumentedHealthCheckRepo) Count(ctx context.Context, since time.Time) ([]model.HealthCheckCount, error) {
+	return timed(r.m, "health_checks", "Count", func() ([]model.HealthCheckCount, error) { return r.next.Count(ctx, since) })
+}
+
+func (r *instrumentedHealthCheckRepo) List(ctx context.Context, f HealthCheckFilter) ([]model.HealthCheck, error) {
+	return timed(r.m, "health_checks", "List", func() ([]model.HealthCheck, error) { return r.next.List(ctx, f) })
+}
+
+func (r *instrumentedHealthCheckRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	return timed(r.m, "health_checks", "DeleteBefore", func() (int, error) { return r.next.DeleteBefore(ctx, before) })
+}
+
+// ========== 事故公告仓储装饰器 ==========
+
+type instrumentedIncidentRepo struct {
+	next IncidentRepository
+	m    *QueryMetrics
+}
+
+// InstrumentIncidentRepo 用统计装饰器包装事故公告仓储
+func InstrumentIncidentRepo(next IncidentRepository, m *QueryMetrics) IncidentRepository {
+	m.addPool("incidents", next)
+	return &instrumentedIncidentRepo{next: next, m: m}
+}
+
+func (r *instrumentedIncidentRepo) Current(ctx context.Context) (model.Incident, error) {
+	return timed(r.m, "incidents", "Current", func() (model.Incident, error) { return r.next.Current(ctx) })
+}
+
+func (r *instrumentedIncidentRepo) Open(ctx context.Context, inc model.Incident) (model.Incident, error) {
+	return timed(r.m, "incidents", "Open", func() (model.Incident, error) { return r.next.Open(ctx, inc) })
+}
+
+func (r *instrumentedIncidentRepo) Resolve(ctx context.Context, at time.Time) (model.Incident, error) {
+	return timed(r.m, "incidents", "Resolve", func() (model.Incident, error) { return r.next.Resolve(ctx, at) })
+}
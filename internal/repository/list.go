@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ListRepository 列表仓储接口
+// 列表的位置（Position）由仓储层统一维护：
+// 新建时追加到末尾，移动和删除后重新编号，保证同一看板内位置连续
+type ListRepository interface {
+	// ListByBoard 按位置顺序列出看板的所有列表
+	ListByBoard(ctx context.Context, boardID string) ([]model.List, error)
+
+	// Get 获取看板中的单个列表
+	Get(ctx context.Context, boardID, id string) (model.List, error)
+
+	// Create 在看板末尾创建新列表
+	Create(ctx context.Context, boardID, title string) (model.List, error)
+
+	// Rename 修改列表标题
+	Rename(ctx context.Context, boardID, id, title string) (model.List, error)
+
+	// Move 把列表移动到指定位置，返回移动后看板的全部列表
+	// position 超出范围时移动到最后
+	Move(ctx context.Context, boardID, id string, position int) ([]model.List, error)
+
+	// Delete 删除列表，后面的列表依次前移
+	Delete(ctx context.Context, boardID, id string) error
+
+	// DeleteByBoard 删除看板的所有列表，删除看板时使用
+	DeleteByBoard(ctx context.Context, boardID string) error
+}
+
+// reorderLists 把 id 对应的列表移动到 position，并重新编号
+// lists 必须已经按位置排好序；返回新的顺序，以及是否找到了该列表
+// 内存实现和 SQLite 实现共用这段逻辑
+func reorderLists(lists []model.List, id string, position int) ([]model.List, bool) {
+	from := -1
+	for i, l := range lists {
+		if l.ID == id {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return nil, false
+	}
+
+	if position >= len(lists) {
+		position = len(lists) - 1
+	}
+
+	// 先取出要移动的列表，再插入到目标位置
+	moving := lists[from]
+	rest := append(append([]model.List{}, lists[:from]...), lists[from+1:]...)
+	out := make([]model.List, 0, len(lists))
+	out = append(out, rest[:position]...)
+	out = append(out, moving)
+	out = append(out, rest[position:]...)
+
+	for i := range out {
+		out[i].Position = i
+	}
+	return out, true
+}
+
+// memListRepo 列表仓储的内存实现
+type memListRepo struct {
+	mu    sync.RWMutex
+	lists map[string]model.List // key 是列表 ID
+}
+
+// NewMemListRepo 创建一个新的内存列表仓储
+func NewMemListRepo() ListRepository {
+	return &memListRepo{
+		lists: make(map[string]model.List),
+	}
+}
+
+// byBoard 返回看板的所有列表，按位置排序
+// 调用方必须已经持有锁
+func (r *memListRepo) byBoard(boardID string) []model.List {
+	out := make([]model.List, 0)
+	for _, l := range r.lists {
+		if l.BoardID == boardID {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Position < out[j].Position
+	})
+	return out
+}
+
+// ListByBoard 按位置顺序列出看板的所有列表
+func (r *memListRepo) ListByBoard(ctx context.Context, boardID string) ([]model.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byBoard(boardID), nil
+}
+
+// Get 获取看板中的单个列表
+func (r *memListRepo) Get(ctx context.Context, boardID, id string) (model.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.lists[id]
+	if !ok || l.BoardID != boardID {
+		return model.List{}, ErrNotFound
+	}
+	return l, nil
+}
+
+// Create 在看板末尾创建新列表
+func (r *memListRepo) Create(ctx context.Context, boardID, title string) (model.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	l := model.List{
+		ID:        generateID(),
+		BoardID:   boardID,
+		Title:     title,
+		Position:  len(r.byBoard(boardID)), // 追加到末尾
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.lists[l.ID] = l
+	return l, nil
+}
+
+// Rename 修改列表标题
+func (r *memListRepo) Rename(ctx context.Context, boardID, id, title string) (model.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.lists[id]
+	if !ok || l.BoardID != boardID {
+		return model.List{}, ErrNotFound
+	}
+	l.Title = title
+	l.UpdatedAt = time.Now()
+	r.lists[id] = l
+	return l, nil
+}
+
+// Move 把列表移动到指定位置
+func (r *memListRepo) Move(ctx context.Context, boardID, id string, position int) ([]model.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out, ok := reorderLists(r.byBoard(boardID), id, position)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	for i, l := range out {
+		// 位置发生变化的列表才刷新更新时间
+		if r.lists[l.ID].Position != l.Position {
+			out[i].UpdatedAt = now
+		}
+		r.lists[l.ID] = out[i]
+	}
+	return out, nil
+}
+
+// Delete 删除列表，并把后面的列表依次前移
+func (r *memListRepo) Delete(ctx context.Context, boardID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.lists[id]
+	if !ok || l.BoardID != boardID {
+		return ErrNotFound
+	}
+	delete(r.lists, id)
+
+	for i, rest := range r.byBoard(boardID) {
+		rest.Position = i
+		r.lists[rest.ID] = rest
+	}
+	return nil
+}
+
+// DeleteByBoard 删除看板的所有列表
+func (r *memListRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, l := range r.lists {
+		// 在 range 遍历 map 时删除元素是安全的
+		if l.BoardID == boardID {
+			delete(r.lists, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteListRepo 是 ListRepository 的 SQLite 实现
+type sqliteListRepo struct {
+	db *gorm.DB
+}
+
+// listRow 列表表结构
+type listRow struct {
+	ID string `gorm:"primaryKey"`
+
+	// BoardID 和 Position 有联合索引 idx_list_board_position（见 internal/migrations）
+	// 按看板查询并按位置排序时可以直接走索引
+	BoardID   string
+	Title     string
+	Position  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewSQLiteListRepo 创建一个新的 SQLite 列表仓储
+func NewSQLiteListRepo(db *gorm.DB) (ListRepository, error) {
+	return &sqliteListRepo{db: db}, nil
+}
+
+func (r *sqliteListRepo) toModel(row listRow) model.List {
+	return model.List{
+		ID:        row.ID,
+		BoardID:   row.BoardID,
+		Title:     row.Title,
+		Position:  row.Position,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+}
+
+// byBoard 按位置顺序查询看板的所有列表
+// 传入 tx 是为了在事务中复用
+func (r *sqliteListRepo) byBoard(tx *gorm.DB, boardID string) ([]model.List, error) {
+	var rows []listRow
+	if err := tx.Where("board_id = ?", boardID).Order("position asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.List, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteListRepo) ListByBoard(ctx context.Context, boardID string) ([]model.List, error) {
+	return r.byBoard(r.db.WithContext(ctx), boardID)
+}
+
+func (r *sqliteListRepo) Get(ctx context.Context, boardID, id string) (model.List, error) {
+	var rw listRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ? AND board_id = ?", id, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.List{}, ErrNotFound
+		}
+		return model.List{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// Create 在看板末尾创建新列表
+// 计算位置和插入放在同一个事务里
+func (r *sqliteListRepo) Create(ctx context.Context, boardID, title string) (model.List, error) {
+	now := time.Now()
+	rw := listRow{
+		ID:        generateID(),
+		BoardID:   boardID,
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&listRow{}).Where("board_id = ?", boardID).Count(&n).Error; err != nil {
+			return err
+		}
+		rw.Position = int(n)
+		return tx.Create(&rw).Error
+	})
+	if err != nil {
+		return model.List{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteListRepo) Rename(ctx context.Context, boardID, id, title string) (model.List, error) {
+	var rw listRow
+	if err := r.db.WithContext(ctx).First(&rw, "id = ? AND board_id = ?", id, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.List{}, ErrNotFound
+		}
+		return model.List{}, err
+	}
+	rw.Title = title
+	rw.UpdatedAt = time.Now()
+	if err := r.db.WithContext(ctx).Save(&rw).Error; err != nil {
+		return model.List{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// Move 把列表移动到指定位置
+// 整个看板的列表重新编号，在一个事务里完成，不会出现位置重复或断档
+func (r *sqliteListRepo) Move(ctx context.Context, boardID, id string, position int) ([]model.List, error) {
+	var out []model.List
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lists, err := r.byBoard(tx, boardID)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		out, ok = reorderLists(lists, id, position)
+		if !ok {
+			return ErrNotFound
+		}
+
+		// 记录移动前的位置，只更新位置真正变化的列表
+		before := make(map[string]int, len(lists))
+		for _, l := range lists {
+			before[l.ID] = l.Position
+		}
+
+		now := time.Now()
+		for i, l := range out {
+			if before[l.ID] == l.Position {
+				continue
+			}
+			out[i].UpdatedAt = now
+			if err := tx.Model(&listRow{}).Where("id = ?", l.ID).
+				Updates(map[string]interface{}{"position": l.Position, "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Delete 删除列表，并把后面的列表依次前移
+func (r *sqliteListRepo) Delete(ctx context.Context, boardID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rw listRow
+		if err := tx.First(&rw, "id = ? AND board_id = ?", id, boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&listRow{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		// 相当于 SQL: UPDATE list_rows SET position = position - 1 WHERE board_id = ? AND position > ?
+		return tx.Model(&listRow{}).
+			Where("board_id = ? AND position > ?", boardID, rw.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+func (r *sqliteListRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Delete(&listRow{}, "board_id = ?", boardID).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteListRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
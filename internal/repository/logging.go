@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"kanban_api/internal/logging"
+	"log/slog"
+	"time"
+)
+
+// slowSQLThreshold 超过这个耗时的 SQL 按 warn 级别记录
+const slowSQLThreshold = 200 * time.Millisecond
+
+// gormLogging 传给 gorm.Open 的额外选项：把 GORM 自己的日志接到 slog 上
+// GORM 默认的 logger 直接往标准输出打彩色文本，不受 LOG_FORMAT、LOG_LEVEL 控制，也带不上请求 ID
+type gormLogging struct{}
+
+// Apply 实现 gorm.Option，替换配置里的 logger
+func (gormLogging) Apply(cfg *gorm.Config) error {
+	cfg.Logger = gormSlog{}
+	return nil
+}
+
+// AfterInitialize 实现 gorm.Option，不需要额外处理
+func (gormLogging) AfterInitialize(*gorm.DB) error { return nil }
+
+// gormSlog 实现 GORM 的 logger.Interface
+// 用 logging.FromContext 取 logger：db.WithContext(ctx) 执行的查询会带上请求 ID 和用户 ID
+//
+// 级别的对应关系：
+//   - SQL 出错：error
+//   - 慢查询：warn
+//   - 其它每一条 SQL：debug，LOG_LEVEL=debug 时才能看到；
+//     打开运行时开关 VerboseSQL（管理员接口 PUT /admin/log-level）后改为 info，不用把整个服务调到 debug
+type gormSlog struct{}
+
+// LogMode 级别统一由 slog 的 LOG_LEVEL 控制，忽略 GORM 的设置
+func (l gormSlog) LogMode(gormlogger.LogLevel) gormlogger.Interface { return l }
+
+func (gormSlog) Info(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+}
+
+func (gormSlog) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+}
+
+func (gormSlog) Error(ctx context.Context, msg string, args ...interface{}) {
+	logging.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter：日志里的 SQL 只保留 ? 占位符，不拼参数值
+// 参数里有密码哈希、令牌哈希、用户输入的内容，不应该出现在日志里；和链路追踪的 span 一样只记录 SQL 文本
+func (gormSlog) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Trace 每条 SQL 执行完后调用
+func (gormSlog) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	logger := logging.FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	level := slog.LevelDebug
+	if logging.Current().VerboseSQL {
+		level = slog.LevelInfo
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		// 查不到记录是正常情况，仓储会转换成 not found 错误返回，不算 SQL 出错
+		level = slog.LevelError
+	case elapsed > slowSQLThreshold:
+		level = slog.LevelWarn
+	}
+	// fc 要拼出完整的 SQL 文本，级别没开启时就不调用了
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "elapsed_ms", float64(elapsed.Microseconds()) / 1000}
+	if level == slog.LevelError {
+		attrs = append(attrs, "err", err)
+	}
+	logger.Log(ctx, level, "sql", attrs...)
+}
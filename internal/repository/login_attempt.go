@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LoginAttemptRepository 登录尝试记录仓储接口，异常检测统计登录失败用
+type LoginAttemptRepository interface {
+	// Record 记一次登录尝试，CreatedAt 为零值时用当前时间
+	Record(ctx context.Context, a model.LoginAttempt) error
+
+	// CountFailures 统计 [from, to) 之间每个邮箱、每个 IP 登录失败的次数，见 model.LoginFailureCount
+	// 只返回有失败的邮箱和 IP，按 Key 排序
+	CountFailures(ctx context.Context, from, to time.Time) ([]model.LoginFailureCount, error)
+
+	// DeleteBefore 删除 before 之前的记录，返回删除了几条
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// memLoginAttemptRepo 登录尝试记录仓储的内存实现
+type memLoginAttemptRepo struct {
+	mu       sync.Mutex
+	attempts []model.LoginAttempt
+}
+
+// NewMemLoginAttemptRepo 创建一个新的内存登录尝试记录仓储
+func NewMemLoginAttemptRepo() LoginAttemptRepository {
+	return &memLoginAttemptRepo{}
+}
+
+func (r *memLoginAttemptRepo) Record(ctx context.Context, a model.LoginAttempt) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	r.mu.Lock()
+	r.attempts = append(r.attempts, a)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memLoginAttemptRepo) CountFailures(ctx context.Context, from, to time.Time) ([]model.LoginFailureCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, a := range r.attempts {
+		if a.Success || a.CreatedAt.Before(from) || !a.CreatedAt.Before(to) {
+			continue
+		}
+		counts["email:"+a.Email]++
+		if a.IP != "" {
+			counts["ip:"+a.IP]++
+		}
+	}
+	out := make([]model.LoginFailureCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, model.LoginFailureCount{Key: k, Failures: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (r *memLoginAttemptRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.attempts[:0]
+	for _, a := range r.attempts {
+		if !a.CreatedAt.Before(before) {
+			kept = append(kept, a)
+		}
+	}
+	n := len(r.attempts) - len(kept)
+	r.attempts = kept
+	return n, nil
+}
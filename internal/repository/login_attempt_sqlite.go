@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteLoginAttemptRepo 是 LoginAttemptRepository 的 SQLite 实现
+type sqliteLoginAttemptRepo struct {
+	db *gorm.DB
+}
+
+// loginAttemptRow 登录尝试表结构，created_at 上的索引见 internal/migrations
+type loginAttemptRow struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement"`
+	Email     string
+	IP        string `gorm:"column:ip"`
+	Success   bool
+	CreatedAt time.Time
+}
+
+// NewSQLiteLoginAttemptRepo 创建一个新的 SQLite 登录尝试记录仓储
+func NewSQLiteLoginAttemptRepo(db *gorm.DB) (LoginAttemptRepository, error) {
+	return &sqliteLoginAttemptRepo{db: db}, nil
+}
+
+func (r *sqliteLoginAttemptRepo) Record(ctx context.Context, a model.LoginAttempt) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return r.db.WithContext(ctx).Create(&loginAttemptRow{Email: a.Email, IP: a.IP, Success: a.Success, CreatedAt: a.CreatedAt}).Error
+}
+
+// CountFailures 相当于 SQL:
+//
+//	SELECT 'email:' || email AS key, COUNT(*) FROM login_attempt_rows WHERE NOT success AND created_at >= ? AND created_at < ? GROUP BY email
+//	UNION ALL
+//	SELECT 'ip:' || ip, COUNT(*) ... AND ip <> '' GROUP BY ip
+func (r *sqliteLoginAttemptRepo) CountFailures(ctx context.Context, from, to time.Time) ([]model.LoginFailureCount, error) {
+	var out []model.LoginFailureCount
+	err := r.db.WithContext(ctx).Raw(
+		"SELECT `key`, failures FROM ("+
+			"SELECT 'email:' || email AS `key`, COUNT(*) AS failures FROM login_attempt_rows WHERE NOT success AND created_at >= ? AND created_at < ? GROUP BY email "+
+			"UNION ALL "+
+			"SELECT 'ip:' || ip, COUNT(*) FROM login_attempt_rows WHERE NOT success AND created_at >= ? AND created_at < ? AND ip <> '' GROUP BY ip"+
+			") ORDER BY `key`",
+		from, to, from, to).Scan(&out).Error
+	return out, err
+}
+
+func (r *sqliteLoginAttemptRepo) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	res := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&loginAttemptRow{})
+	return int(res.RowsAffected), res.Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteLoginAttemptRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
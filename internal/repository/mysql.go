@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MySQLConfig MySQL 连接和连接池配置
+type MySQLConfig struct {
+	// DSN 连接字符串，例如：
+	// "kanban:secret@tcp(127.0.0.1:3306)/kanban?charset=utf8mb4&parseTime=True&loc=Local"
+	// parseTime=True 必须带上，否则 DATETIME 列无法扫描到 time.Time
+	DSN string
+
+	PoolConfig
+}
+
+// MySQLConfigFromEnv 从环境变量读取 MySQL 配置
+// - MYSQL_DSN: 连接字符串（必填）
+// - MYSQL_MAX_OPEN_CONNS: 最大打开连接数，默认 25
+// - MYSQL_MAX_IDLE_CONNS: 最大空闲连接数，默认 10
+// - MYSQL_CONN_MAX_LIFETIME: 连接最长存活时间，默认 5m
+// - MYSQL_CONN_MAX_IDLE_TIME: 连接最长空闲时间，默认 1m
+func MySQLConfigFromEnv() (MySQLConfig, error) {
+	cfg := MySQLConfig{DSN: os.Getenv("MYSQL_DSN")}
+	if cfg.DSN == "" {
+		return cfg, errors.New("MYSQL_DSN is required")
+	}
+	pool, err := poolFromEnv("MYSQL", PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 5 * time.Minute,
+		ConnMaxIdleTime: time.Minute,
+	})
+	cfg.PoolConfig = pool
+	return cfg, err
+}
+
+// envInt 读取整数环境变量，没有设置时保留默认值
+func envInt(key string, dst *int) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return errors.New("invalid " + key + ": " + v)
+	}
+	*dst = n
+	return nil
+}
+
+// envDuration 读取时长环境变量（例如 "5m"、"30s"），没有设置时保留默认值
+func envDuration(key string, dst *time.Duration) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return errors.New("invalid " + key + ": " + v)
+	}
+	*dst = d
+	return nil
+}
+
+// OpenMySQL 打开 MySQL 连接并设置连接池参数
+// 返回的 *gorm.DB 由用户仓储和看板仓储共用，这样连接池配置对它们同时生效
+func OpenMySQL(cfg MySQLConfig) (*gorm.DB, error) {
+	// TranslateError 与 NewDB 一致，违反唯一索引时返回 gorm.ErrDuplicatedKey
+	db, err := gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{TranslateError: true}, gormTracing{}, gormLogging{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.apply(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewMySQLUserRepo 创建 MySQL 用户仓储
+func NewMySQLUserRepo(db *gorm.DB) (UserRepository, error) {
+	return newGormUserRepo(db)
+}
+
+// NewMySQLBoardRepo 创建 MySQL 看板仓储
+func NewMySQLBoardRepo(db *gorm.DB) (BoardRepository, error) {
+	return newGormBoardRepo(db)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// PasswordResetRepository 密码重置令牌仓储接口
+// 只保存令牌的哈希，不保存令牌明文
+type PasswordResetRepository interface {
+	// Create 保存一条重置令牌记录
+	Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (model.PasswordResetToken, error)
+
+	// GetByHash 通过令牌哈希查询记录
+	GetByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, error)
+
+	// MarkUsed 把令牌标记为已使用
+	// 只有令牌原来未使用时返回 true；同一个令牌并发提交两次，只有一次能拿到 true
+	MarkUsed(ctx context.Context, id string) (bool, error)
+
+	// RevokeUser 把用户所有还没用过的令牌标记为已使用（修改邮箱后发到旧邮箱的令牌作废）
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// memPasswordResetRepo 重置令牌仓储的内存实现
+type memPasswordResetRepo struct {
+	mu     sync.Mutex
+	tokens map[string]model.PasswordResetToken // key 是记录 ID
+}
+
+// NewMemPasswordResetRepo 创建一个新的内存重置令牌仓储
+func NewMemPasswordResetRepo() PasswordResetRepository {
+	return &memPasswordResetRepo{
+		tokens: make(map[string]model.PasswordResetToken),
+	}
+}
+
+func (r *memPasswordResetRepo) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (model.PasswordResetToken, error) {
+	t := model.PasswordResetToken{
+		ID:        generateID(),
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	r.tokens[t.ID] = t
+	r.mu.Unlock()
+
+	return t, nil
+}
+
+func (r *memPasswordResetRepo) GetByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return model.PasswordResetToken{}, ErrNotFound
+}
+
+func (r *memPasswordResetRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	r.tokens[id] = t
+	return true, nil
+}
+
+func (r *memPasswordResetRepo) RevokeUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.tokens {
+		if t.UserID == userID && t.UsedAt == nil {
+			t.UsedAt = &now
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqlitePasswordResetRepo 是 PasswordResetRepository 的 SQLite 实现
+type sqlitePasswordResetRepo struct {
+	db *gorm.DB
+}
+
+// passwordResetRow 重置令牌表结构
+// token_hash 有唯一索引（见 internal/migrations）
+type passwordResetRow struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewSQLitePasswordResetRepo 创建一个新的 SQLite 重置令牌仓储
+func NewSQLitePasswordResetRepo(db *gorm.DB) (PasswordResetRepository, error) {
+	return &sqlitePasswordResetRepo{db: db}, nil
+}
+
+func (r *sqlitePasswordResetRepo) toModel(row passwordResetRow) model.PasswordResetToken {
+	return model.PasswordResetToken{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		TokenHash: row.TokenHash,
+		ExpiresAt: row.ExpiresAt,
+		UsedAt:    row.UsedAt,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func (r *sqlitePasswordResetRepo) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (model.PasswordResetToken, error) {
+	rw := passwordResetRow{
+		ID:        generateID(),
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.PasswordResetToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqlitePasswordResetRepo) GetByHash(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	var rw passwordResetRow
+	if err := r.db.WithContext(ctx).First(&rw, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.PasswordResetToken{}, ErrNotFound
+		}
+		return model.PasswordResetToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// MarkUsed 标记令牌已使用
+// 和刷新令牌的 Revoke 一样，用 used_at IS NULL 条件加受影响行数保证只能用一次
+func (r *sqlitePasswordResetRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&passwordResetRow{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeUser 把用户所有还没用过的令牌标记为已使用
+func (r *sqlitePasswordResetRepo) RevokeUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&passwordResetRow{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqlitePasswordResetRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
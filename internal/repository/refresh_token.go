@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// RefreshTokenRepository 刷新令牌仓储接口
+// 只保存令牌的哈希，不保存令牌明文
+type RefreshTokenRepository interface {
+	// Create 保存一条刷新令牌记录
+	// familyID 为空时开启新家族，以新记录自己的 ID 作为家族 ID
+	Create(ctx context.Context, userID, familyID, tokenHash string, expiresAt time.Time) (model.RefreshToken, error)
+
+	// GetByHash 通过令牌哈希查询记录
+	GetByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error)
+
+	// Revoke 吊销一条令牌
+	// 只有令牌原来有效时返回 true；两个请求同时用同一个令牌刷新时，只有一个能拿到 true
+	Revoke(ctx context.Context, id string) (bool, error)
+
+	// RevokeFamily 吊销整个家族中还有效的令牌
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser 吊销用户所有还有效的令牌（所有设备上的登录都失效）
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// memRefreshTokenRepo 刷新令牌仓储的内存实现
+type memRefreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]model.RefreshToken // key 是记录 ID
+}
+
+// NewMemRefreshTokenRepo 创建一个新的内存刷新令牌仓储
+func NewMemRefreshTokenRepo() RefreshTokenRepository {
+	return &memRefreshTokenRepo{
+		tokens: make(map[string]model.RefreshToken),
+	}
+}
+
+func (r *memRefreshTokenRepo) Create(ctx context.Context, userID, familyID, tokenHash string, expiresAt time.Time) (model.RefreshToken, error) {
+	id := generateID()
+	if familyID == "" {
+		familyID = id
+	}
+	t := model.RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	r.tokens[t.ID] = t
+	r.mu.Unlock()
+
+	return t, nil
+}
+
+func (r *memRefreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return model.RefreshToken{}, ErrNotFound
+}
+
+func (r *memRefreshTokenRepo) Revoke(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RevokedAt = &now
+	r.tokens[id] = t
+	return true, nil
+}
+
+func (r *memRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
+
+func (r *memRefreshTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			r.tokens[id] = t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteRefreshTokenRepo 是 RefreshTokenRepository 的 SQLite 实现
+type sqliteRefreshTokenRepo struct {
+	db *gorm.DB
+}
+
+// refreshTokenRow 刷新令牌表结构
+// token_hash 有唯一索引，family_id 有普通索引（见 internal/migrations）
+type refreshTokenRow struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewSQLiteRefreshTokenRepo 创建一个新的 SQLite 刷新令牌仓储
+func NewSQLiteRefreshTokenRepo(db *gorm.DB) (RefreshTokenRepository, error) {
+	return &sqliteRefreshTokenRepo{db: db}, nil
+}
+
+func (r *sqliteRefreshTokenRepo) toModel(row refreshTokenRow) model.RefreshToken {
+	return model.RefreshToken{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		FamilyID:  row.FamilyID,
+		TokenHash: row.TokenHash,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func (r *sqliteRefreshTokenRepo) Create(ctx context.Context, userID, familyID, tokenHash string, expiresAt time.Time) (model.RefreshToken, error) {
+	id := generateID()
+	if familyID == "" {
+		familyID = id
+	}
+	rw := refreshTokenRow{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.RefreshToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteRefreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (model.RefreshToken, error) {
+	var rw refreshTokenRow
+	if err := r.db.WithContext(ctx).First(&rw, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.RefreshToken{}, ErrNotFound
+		}
+		return model.RefreshToken{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+// Revoke 吊销令牌
+// 条件里带上 revoked_at IS NULL，用受影响行数判断是不是这次吊销的，
+// 这样并发刷新时不会有两个请求同时换到新令牌
+func (r *sqliteRefreshTokenRepo) Revoke(ctx context.Context, id string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&refreshTokenRow{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *sqliteRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&refreshTokenRow{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *sqliteRefreshTokenRepo) RevokeUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&refreshTokenRow{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteRefreshTokenRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
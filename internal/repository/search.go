@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"strings"
+)
+
+// SearchRepository 全文搜索仓储接口
+// 只负责"找到哪些看板和卡片"，访问控制和高亮位置由服务层处理
+type SearchRepository interface {
+	// Search 在 boardIDs 范围内查找看板和卡片
+	// 每个关键词都必须出现在标题或描述中（AND），不区分大小写
+	// 看板排在卡片前面，最多返回 limit 条
+	Search(ctx context.Context, boardIDs, terms []string, limit int) ([]model.SearchResult, error)
+}
+
+// memSearchRepo 朴素的搜索实现：通过其它仓储把数据读出来逐条比较
+// 不需要额外的索引，任何存储后端都能用，但每次搜索都要读出所有可见看板的全部卡片，
+// 只适合数据量很小的场景，或者 SQLite 没有编译 FTS5 时作为后备
+type memSearchRepo struct {
+	boards BoardRepository
+	lists  ListRepository
+	cards  CardRepository
+}
+
+// NewMemSearchRepo 创建朴素的搜索仓储
+func NewMemSearchRepo(boards BoardRepository, lists ListRepository, cards CardRepository) SearchRepository {
+	return &memSearchRepo{boards: boards, lists: lists, cards: cards}
+}
+
+// Search 逐个看板、逐个列表扫描
+func (r *memSearchRepo) Search(ctx context.Context, boardIDs, terms []string, limit int) ([]model.SearchResult, error) {
+	if len(boardIDs) == 0 || len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	boards, err := r.boards.ListByIDs(ctx, boardIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []model.SearchResult
+	for _, b := range boards {
+		if containsAll(b.Title, terms) {
+			out = append(out, model.SearchResult{Kind: model.SearchKindBoard, ID: b.ID, BoardID: b.ID, Title: b.Title})
+		}
+	}
+
+	var cards []model.SearchResult
+	for _, b := range boards {
+		lists, err := r.lists.ListByBoard(ctx, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lists {
+			cs, err := r.cards.ListByList(ctx, l.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range cs {
+				if containsAll(c.Title+"\n"+c.Description, terms) {
+					cards = append(cards, model.SearchResult{
+						Kind: model.SearchKindCard, ID: c.ID, BoardID: c.BoardID, ListID: c.ListID,
+						Title: c.Title, Description: c.Description,
+					})
+				}
+			}
+		}
+	}
+
+	out = append(out, cards...)
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// containsAll 不区分大小写地检查 s 是否包含所有关键词
+func containsAll(s string, terms []string) bool {
+	s = strings.ToLower(s)
+	for _, t := range terms {
+		if !strings.Contains(s, strings.ToLower(t)) {
+			return false
+		}
+	}
+	return true
+}
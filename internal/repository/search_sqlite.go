@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrFTS5Unavailable 当前编译的 SQLite 没有启用 FTS5
+// mattn/go-sqlite3 默认不带 FTS5，需要用 go build -tags sqlite_fts5 编译
+var ErrFTS5Unavailable = errors.New("sqlite FTS5 is not compiled in (build with -tags sqlite_fts5)")
+
+// sqliteSearchRepo 基于 SQLite FTS5 的全文搜索
+//
+// board_fts、card_fts 是"外部内容"（external content）FTS5 表：
+// 索引本身不保存原文，原文仍在 board_rows、card_rows 中，通过 rowid 关联。
+// 原表上的触发器在插入、修改标题/描述、删除时同步更新索引，仓储层其它代码不需要关心搜索。
+//
+// 分词器使用 trigram：按三个字符一组建索引，支持任意子串匹配，
+// 中文不需要分词也能搜到；代价是少于三个字符的关键词没法走索引，只能用 LIKE 扫描
+type sqliteSearchRepo struct {
+	db *gorm.DB
+}
+
+// searchSchema 建立 FTS 表和同步触发器，全部是幂等的
+// 卡片只在标题和描述变化时更新索引，移动卡片（改 position、list_id）不会触发
+var searchSchema = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS board_fts USING fts5(title, content='board_rows', content_rowid='rowid', tokenize='trigram')`,
+	`CREATE VIRTUAL TABLE IF NOT EXISTS card_fts USING fts5(title, description, content='card_rows', content_rowid='rowid', tokenize='trigram')`,
+
+	`CREATE TRIGGER IF NOT EXISTS board_fts_ai AFTER INSERT ON board_rows BEGIN
+		INSERT INTO board_fts(rowid, title) VALUES (new.rowid, new.title);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS board_fts_ad AFTER DELETE ON board_rows BEGIN
+		INSERT INTO board_fts(board_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS board_fts_au AFTER UPDATE OF title ON board_rows BEGIN
+		INSERT INTO board_fts(board_fts, rowid, title) VALUES ('delete', old.rowid, old.title);
+		INSERT INTO board_fts(rowid, title) VALUES (new.rowid, new.title);
+	END`,
+
+	`CREATE TRIGGER IF NOT EXISTS card_fts_ai AFTER INSERT ON card_rows BEGIN
+		INSERT INTO card_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS card_fts_ad AFTER DELETE ON card_rows BEGIN
+		INSERT INTO card_fts(card_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS card_fts_au AFTER UPDATE OF title, description ON card_rows BEGIN
+		INSERT INTO card_fts(card_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+		INSERT INTO card_fts(rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END`,
+
+	// 每次启动重建一次索引：
+	// 1. 第一次建表时把已有数据导入索引
+	// 2. board_rows、card_rows 的主键是字符串，rowid 是隐式的，VACUUM 可能会重新编号，重建后重新对齐
+	`INSERT INTO board_fts(board_fts) VALUES ('rebuild')`,
+	`INSERT INTO card_fts(card_fts) VALUES ('rebuild')`,
+}
+
+// searchTriggers 同步触发器的名字，FTS5 不可用时要删掉它们
+var searchTriggers = []string{"board_fts_ai", "board_fts_ad", "board_fts_au", "card_fts_ai", "card_fts_ad", "card_fts_au"}
+
+// NewSQLiteSearchRepo 创建基于 FTS5 的搜索仓储
+// 必须在迁移建好表之后调用，触发器建在 board_rows、card_rows 上
+// SQLite 没有编译 FTS5 时返回 ErrFTS5Unavailable，调用方可以改用 NewMemSearchRepo
+func NewSQLiteSearchRepo(db *gorm.DB) (SearchRepository, error) {
+	var fts5 bool
+	if err := db.Raw(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5).Error; err != nil {
+		return nil, err
+	}
+	if !fts5 {
+		// 之前用带 FTS5 的版本运行过的话，数据库里还留着触发器；
+		// 触发器引用的 FTS5 表在这个版本里打不开，会导致所有看板、卡片写入失败，必须删掉
+		for _, name := range searchTriggers {
+			if err := db.Exec(`DROP TRIGGER IF EXISTS ` + name).Error; err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrFTS5Unavailable
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range searchSchema {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteSearchRepo{db: db}, nil
+}
+
+// searchRow 搜索查询的结果行，看板查询时 list_id、description 为空
+type searchRow struct {
+	ID          string
+	BoardID     string
+	ListID      string
+	Title       string
+	Description string
+}
+
+// Search 先查看板再查卡片
+func (r *sqliteSearchRepo) Search(ctx context.Context, boardIDs, terms []string, limit int) ([]model.SearchResult, error) {
+	if len(boardIDs) == 0 || len(terms) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	match, short := splitTerms(terms)
+
+	var boards []searchRow
+	q, args := buildSearchSQL(
+		`SELECT b.id AS id, b.id AS board_id, b.title AS title FROM board_rows b`,
+		"board_fts", "b", "b.id", []string{"b.title"}, "b.title",
+		boardIDs, match, short, limit,
+	)
+	if err := r.db.WithContext(ctx).Raw(q, args...).Scan(&boards).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]model.SearchResult, 0, len(boards))
+	for _, b := range boards {
+		out = append(out, model.SearchResult{Kind: model.SearchKindBoard, ID: b.ID, BoardID: b.BoardID, Title: b.Title})
+	}
+	if len(out) >= limit {
+		return out, nil
+	}
+
+	var cards []searchRow
+	q, args = buildSearchSQL(
+		`SELECT c.id, c.board_id, c.list_id, c.title, c.description FROM card_rows c`,
+		"card_fts", "c", "c.board_id", []string{"c.title", "c.description"}, "c.updated_at DESC",
+		boardIDs, match, short, limit-len(out),
+	)
+	if err := r.db.WithContext(ctx).Raw(q, args...).Scan(&cards).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range cards {
+		out = append(out, model.SearchResult{
+			Kind: model.SearchKindCard, ID: c.ID, BoardID: c.BoardID, ListID: c.ListID,
+			Title: c.Title, Description: c.Description,
+		})
+	}
+	return out, nil
+}
+
+// splitTerms 把关键词分成两类：
+// 至少三个字符的交给 FTS5 MATCH（trigram 索引），更短的只能用 LIKE
+func splitTerms(terms []string) (match, short []string) {
+	for _, t := range terms {
+		if utf8.RuneCountInString(t) >= 3 {
+			match = append(match, t)
+		} else {
+			short = append(short, t)
+		}
+	}
+	return match, short
+}
+
+// buildSearchSQL 拼出一条搜索 SQL
+// 有可以走索引的关键词时 JOIN FTS 表并按相关度（rank）排序，否则按 fallbackOrder 排序
+// scopeCol 是限定看板范围的列：看板表用自己的 id，卡片表用 board_id
+func buildSearchSQL(base, fts, alias, scopeCol string, columns []string, fallbackOrder string, boardIDs, match, short []string, limit int) (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+
+	sb.WriteString(base)
+	if len(match) > 0 {
+		sb.WriteString(` JOIN ` + fts + ` ON ` + fts + `.rowid = ` + alias + `.rowid`)
+	}
+	sb.WriteString(` WHERE ` + scopeCol + ` IN ?`)
+	args = append(args, boardIDs)
+
+	if len(match) > 0 {
+		sb.WriteString(` AND ` + fts + ` MATCH ?`)
+		args = append(args, ftsQuery(match))
+	}
+	for _, t := range short {
+		like := "%" + escapeLike(t) + "%"
+		conds := make([]string, 0, len(columns))
+		for _, col := range columns {
+			conds = append(conds, col+` LIKE ? ESCAPE '\'`)
+			args = append(args, like)
+		}
+		sb.WriteString(` AND (` + strings.Join(conds, " OR ") + `)`)
+	}
+
+	if len(match) > 0 {
+		sb.WriteString(` ORDER BY ` + fts + `.rank`)
+	} else {
+		sb.WriteString(` ORDER BY ` + fallbackOrder)
+	}
+	sb.WriteString(` LIMIT ?`)
+	args = append(args, limit)
+	return sb.String(), args
+}
+
+// ftsQuery 把关键词拼成 FTS5 查询表达式
+// 每个关键词用双引号包起来当作短语，避免用户输入里的 AND、OR、*、: 等被当成 FTS5 语法；
+// 短语之间用空格分隔，FTS5 中表示 AND
+func ftsQuery(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(t, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// escapeLike 转义 LIKE 的通配符，让 % 和 _ 按字面匹配
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteSearchRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+)
+
+// SnapshotRepository 看板每日快照仓储接口
+// 日期都是 model.DayLayout 格式的字符串，按字符串比较就是按日期比较
+type SnapshotRepository interface {
+	// Save 保存快照，同一个看板同一天已经有快照时覆盖
+	Save(ctx context.Context, snapshots []model.BoardSnapshot) error
+
+	// ListByBoard 看板在 [from, to] 之间（包括两端）的快照，按日期从早到晚
+	ListByBoard(ctx context.Context, boardID, from, to string) ([]model.BoardSnapshot, error)
+
+	// BoardIDs 有快照的所有看板 ID
+	BoardIDs(ctx context.Context) ([]string, error)
+
+	// DeleteByBoard 删除看板的所有快照
+	DeleteByBoard(ctx context.Context, boardID string) error
+}
+
+// memSnapshotRepo 快照仓储的内存实现
+type memSnapshotRepo struct {
+	mu        sync.RWMutex
+	snapshots map[string]map[string]model.BoardSnapshot // 看板 ID -> 日期 -> 快照
+}
+
+// NewMemSnapshotRepo 创建一个新的内存快照仓储
+func NewMemSnapshotRepo() SnapshotRepository {
+	return &memSnapshotRepo{snapshots: make(map[string]map[string]model.BoardSnapshot)}
+}
+
+func (r *memSnapshotRepo) Save(ctx context.Context, snapshots []model.BoardSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range snapshots {
+		days, ok := r.snapshots[s.BoardID]
+		if !ok {
+			days = make(map[string]model.BoardSnapshot)
+			r.snapshots[s.BoardID] = days
+		}
+		days[s.Day] = s
+	}
+	return nil
+}
+
+func (r *memSnapshotRepo) ListByBoard(ctx context.Context, boardID, from, to string) ([]model.BoardSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := []model.BoardSnapshot{}
+	for day, s := range r.snapshots[boardID] {
+		if day >= from && day <= to {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out, nil
+}
+
+func (r *memSnapshotRepo) BoardIDs(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.snapshots))
+	for id := range r.snapshots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (r *memSnapshotRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	delete(r.snapshots, boardID)
+	r.mu.Unlock()
+	return nil
+}
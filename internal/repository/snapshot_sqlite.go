@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteSnapshotRepo 是 SnapshotRepository 的 SQLite 实现
+type sqliteSnapshotRepo struct {
+	db *gorm.DB
+}
+
+// boardSnapshotRow 快照表结构，主键是 (board_id, day)，按看板查一段日期直接走主键
+// 每个列表的统计保存成 JSON 文本
+type boardSnapshotRow struct {
+	BoardID string `gorm:"primaryKey"`
+	Day     string `gorm:"primaryKey"`
+	Cards   int
+	Overdue int
+	Members int
+	Lists   string
+	TakenAt time.Time
+}
+
+// NewSQLiteSnapshotRepo 创建一个新的 SQLite 快照仓储
+func NewSQLiteSnapshotRepo(db *gorm.DB) (SnapshotRepository, error) {
+	return &sqliteSnapshotRepo{db: db}, nil
+}
+
+func (r *sqliteSnapshotRepo) toModel(row boardSnapshotRow) model.BoardSnapshot {
+	s := model.BoardSnapshot{
+		BoardID: row.BoardID,
+		Day:     row.Day,
+		Cards:   row.Cards,
+		Overdue: row.Overdue,
+		Members: row.Members,
+		TakenAt: row.TakenAt,
+	}
+	if err := json.Unmarshal([]byte(row.Lists), &s.Lists); err != nil || s.Lists == nil {
+		s.Lists = []model.ListSnapshot{}
+	}
+	return s
+}
+
+// Save 相当于 SQL: INSERT ... ON CONFLICT(board_id, day) DO UPDATE SET ...，一次插入所有看板
+func (r *sqliteSnapshotRepo) Save(ctx context.Context, snapshots []model.BoardSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	rows := make([]boardSnapshotRow, 0, len(snapshots))
+	for _, s := range snapshots {
+		lists, err := json.Marshal(s.Lists)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, boardSnapshotRow{
+			BoardID: s.BoardID,
+			Day:     s.Day,
+			Cards:   s.Cards,
+			Overdue: s.Overdue,
+			Members: s.Members,
+			Lists:   string(lists),
+			TakenAt: s.TakenAt,
+		})
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "board_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cards", "overdue", "members", "lists", "taken_at"}),
+	}).CreateInBatches(&rows, 100).Error
+}
+
+func (r *sqliteSnapshotRepo) ListByBoard(ctx context.Context, boardID, from, to string) ([]model.BoardSnapshot, error) {
+	var rows []boardSnapshotRow
+	if err := r.db.WithContext(ctx).Where("board_id = ? AND day >= ? AND day <= ?", boardID, from, to).
+		Order("day").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.BoardSnapshot, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+func (r *sqliteSnapshotRepo) BoardIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&boardSnapshotRow{}).Distinct("board_id").Order("board_id").Pluck("board_id", &ids).Error
+	return ids, err
+}
+
+func (r *sqliteSnapshotRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Where("board_id = ?", boardID).Delete(&boardSnapshotRow{}).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteSnapshotRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
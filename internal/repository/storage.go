@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+)
+
+// StorageRepository 看板附件占用空间的计数器仓储接口
+// 上传、删除附件时由 Service 层增减，和附件记录不在同一个事务里，
+// 偏差由定时重算（service.StorageCounters.Reconcile）修正
+type StorageRepository interface {
+	// Get 看板的计数，还没有记录时返回 0
+	Get(ctx context.Context, boardID string) (model.StorageUsage, error)
+
+	// Add 在看板的计数上加上 delta（可以是负数），没有记录时先创建
+	Add(ctx context.Context, boardID string, delta model.StorageUsage) error
+
+	// Set 把看板的计数改成 u，重算时使用
+	Set(ctx context.Context, boardID string, u model.StorageUsage) error
+
+	// Delete 删除看板的计数，不存在时不报错
+	Delete(ctx context.Context, boardID string) error
+
+	// ListBoardIDs 有计数记录的所有看板
+	ListBoardIDs(ctx context.Context) ([]string, error)
+}
+
+// memStorageRepo 计数器仓储的内存实现
+type memStorageRepo struct {
+	mu     sync.RWMutex
+	usages map[string]model.StorageUsage
+}
+
+// NewMemStorageRepo 创建一个新的内存计数器仓储
+func NewMemStorageRepo() StorageRepository {
+	return &memStorageRepo{usages: make(map[string]model.StorageUsage)}
+}
+
+func (r *memStorageRepo) Get(ctx context.Context, boardID string) (model.StorageUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.usages[boardID], nil
+}
+
+func (r *memStorageRepo) Add(ctx context.Context, boardID string, delta model.StorageUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.usages[boardID]
+	u.Bytes += delta.Bytes
+	u.Attachments += delta.Attachments
+	r.usages[boardID] = u
+	return nil
+}
+
+func (r *memStorageRepo) Set(ctx context.Context, boardID string, u model.StorageUsage) error {
+	r.mu.Lock()
+	r.usages[boardID] = u
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memStorageRepo) Delete(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	delete(r.usages, boardID)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memStorageRepo) ListBoardIDs(ctx context.Context) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.usages))
+	for id := range r.usages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"kanban_api/internal/model"
+)
+
+// sqliteStorageRepo 是 StorageRepository 的 SQLite 实现
+type sqliteStorageRepo struct {
+	db *gorm.DB
+}
+
+// boardStorageRow 计数器表结构，一个看板一行
+type boardStorageRow struct {
+	BoardID     string `gorm:"primaryKey"`
+	Bytes       int64
+	Attachments int64
+}
+
+// NewSQLiteStorageRepo 创建一个新的 SQLite 计数器仓储
+func NewSQLiteStorageRepo(db *gorm.DB) (StorageRepository, error) {
+	return &sqliteStorageRepo{db: db}, nil
+}
+
+func (r *sqliteStorageRepo) Get(ctx context.Context, boardID string) (model.StorageUsage, error) {
+	var rows []boardStorageRow
+	if err := r.db.WithContext(ctx).Where("board_id = ?", boardID).Limit(1).Find(&rows).Error; err != nil {
+		return model.StorageUsage{}, err
+	}
+	if len(rows) == 0 {
+		return model.StorageUsage{}, nil
+	}
+	return model.StorageUsage{Bytes: rows[0].Bytes, Attachments: rows[0].Attachments}, nil
+}
+
+// Add 相当于 SQL: INSERT ... ON CONFLICT(board_id) DO UPDATE SET bytes = bytes + ?, attachments = attachments + ?
+// 加法在数据库里做，并发的上传不会互相覆盖
+func (r *sqliteStorageRepo) Add(ctx context.Context, boardID string, delta model.StorageUsage) error {
+	row := boardStorageRow{BoardID: boardID, Bytes: delta.Bytes, Attachments: delta.Attachments}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "board_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"bytes":       gorm.Expr("bytes + ?", delta.Bytes),
+			"attachments": gorm.Expr("attachments + ?", delta.Attachments),
+		}),
+	}).Create(&row).Error
+}
+
+func (r *sqliteStorageRepo) Set(ctx context.Context, boardID string, u model.StorageUsage) error {
+	row := boardStorageRow{BoardID: boardID, Bytes: u.Bytes, Attachments: u.Attachments}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "board_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"bytes", "attachments"}),
+	}).Create(&row).Error
+}
+
+func (r *sqliteStorageRepo) Delete(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Where("board_id = ?", boardID).Delete(&boardStorageRow{}).Error
+}
+
+func (r *sqliteStorageRepo) ListBoardIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&boardStorageRow{}).Order("board_id").Pluck("board_id", &ids).Error
+	return ids, err
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteStorageRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strings"
+)
+
+// ErrMissingTenantScope 查询多租户表时没有带上所有者条件
+// 出现这个错误说明代码有 bug：如果放行，就可能把别人的数据返回给当前用户
+var ErrMissingTenantScope = errors.New("query on tenant-scoped table without owner predicate")
+
+// tenantScopedTables 需要按所有者隔离的表，值是所有者列名
+// 列表和卡片没有所有者列，它们通过 Service 层先校验看板归属来间接隔离
+var tenantScopedTables = map[string]string{
+	"board_rows": "owner_id",
+}
+
+// tenantGuardSkip 跳过检查的标记，见 withoutTenantGuard
+const tenantGuardSkip = "tenant_guard:skip"
+
+// withoutTenantGuard 明确声明这次查询不需要按所有者过滤
+// 只用于确实需要跨用户的场景，例如检查 slug 是否全局唯一
+// 使用时请写注释说明为什么可以跨用户
+func withoutTenantGuard(db *gorm.DB) *gorm.DB {
+	return db.Set(tenantGuardSkip, true)
+}
+
+// registerTenantGuard 在数据库连接上注册多租户检查回调
+// 查询、更新、删除多租户表时，WHERE 条件里必须包含所有者列；插入时所有者列不能为空
+// 不满足时直接返回 ErrMissingTenantScope，而不是执行 SQL
+//
+// 注意：db.Raw / db.Exec 执行的原生 SQL 不经过这些检查
+func registerTenantGuard(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("tenant_guard:query", checkTenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant_guard:row", checkTenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant_guard:update", checkTenantWhere); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant_guard:delete", checkTenantWhere); err != nil {
+		return err
+	}
+	return cb.Create().Before("gorm:create").Register("tenant_guard:create", checkTenantCreate)
+}
+
+// tenantColumn 返回当前语句需要检查的所有者列
+// 不是多租户表、已经出错或者明确跳过时返回 false
+func tenantColumn(db *gorm.DB) (string, bool) {
+	if db.Error != nil {
+		return "", false
+	}
+	col, ok := tenantScopedTables[db.Statement.Table]
+	if !ok {
+		return "", false
+	}
+	if skip, _ := db.Get(tenantGuardSkip); skip == true {
+		return "", false
+	}
+	return col, true
+}
+
+// checkTenantWhere 检查 WHERE 条件中是否包含所有者列
+func checkTenantWhere(db *gorm.DB) {
+	col, ok := tenantColumn(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+		// 把 WHERE 条件单独生成一遍 SQL，再检查其中是否出现所有者列
+		// 这样不管条件是字符串、map 还是结构体形式都能识别
+		tmp := &gorm.Statement{DB: db, Table: stmt.Table, Schema: stmt.Schema, Clauses: map[string]clause.Clause{}}
+		where.Expression.Build(tmp)
+		if strings.Contains(tmp.SQL.String(), col) {
+			return
+		}
+	}
+	db.AddError(fmt.Errorf("%w: %s", ErrMissingTenantScope, stmt.Table))
+}
+
+// checkTenantCreate 检查插入的记录是否设置了所有者
+func checkTenantCreate(db *gorm.DB) {
+	col, ok := tenantColumn(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	if stmt.Schema == nil {
+		return
+	}
+	field := stmt.Schema.LookUpField(col)
+	if field == nil {
+		return
+	}
+
+	// 单条插入时 ReflectValue 是结构体，批量插入时是切片
+	rv := stmt.ReflectValue
+	switch rv.Kind() {
+	case reflect.Struct:
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			db.AddError(fmt.Errorf("%w: %s", ErrMissingTenantScope, stmt.Table))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if _, zero := field.ValueOf(stmt.Context, reflect.Indirect(rv.Index(i))); zero {
+				db.AddError(fmt.Errorf("%w: %s", ErrMissingTenantScope, stmt.Table))
+				return
+			}
+		}
+	}
+}
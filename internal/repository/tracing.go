@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormTracer 数据库层使用的 tracer
+var gormTracer = otel.Tracer("kanban_api/internal/repository")
+
+// gormSpanKey 执行中的 span 存在 gorm 语句实例上的 key
+const gormSpanKey = "otel:span"
+
+// gormTracing 传给 gorm.Open 的额外选项：连接建立后注册链路追踪回调，
+// 每条 SQL 语句生成一个 span，挂在调用方 ctx 里的 span（通常是服务方法的 span）下面
+//
+// 只有用 db.WithContext(ctx) 执行的查询才能接到链路上，这也是仓储方法都要接收 ctx 的原因之一
+// 没有配置 OTLP 导出时全局 TracerProvider 是 noop，回调的开销可以忽略
+type gormTracing struct{}
+
+// Apply 实现 gorm.Option，不修改配置
+func (gormTracing) Apply(*gorm.Config) error { return nil }
+
+// AfterInitialize 实现 gorm.Option，在连接建立后注册回调
+// 和 tenant_guard.go 一样，在 GORM 内置的执行步骤前后各挂一个回调
+func (gormTracing) AfterInitialize(db *gorm.DB) error {
+	cb := db.Callback()
+	steps := []struct {
+		name   string
+		before func(string) error
+		after  func(string) error
+	}{
+		{"query",
+			func(n string) error { return cb.Query().Before("gorm:query").Register(n, startGormSpan("query")) },
+			func(n string) error { return cb.Query().After("gorm:query").Register(n, endGormSpan) }},
+		{"row",
+			func(n string) error { return cb.Row().Before("gorm:row").Register(n, startGormSpan("row")) },
+			func(n string) error { return cb.Row().After("gorm:row").Register(n, endGormSpan) }},
+		{"raw",
+			func(n string) error { return cb.Raw().Before("gorm:raw").Register(n, startGormSpan("raw")) },
+			func(n string) error { return cb.Raw().After("gorm:raw").Register(n, endGormSpan) }},
+		{"create",
+			func(n string) error { return cb.Create().Before("gorm:create").Register(n, startGormSpan("create")) },
+			func(n string) error { return cb.Create().After("gorm:create").Register(n, endGormSpan) }},
+		{"update",
+			func(n string) error { return cb.Update().Before("gorm:update").Register(n, startGormSpan("update")) },
+			func(n string) error { return cb.Update().After("gorm:update").Register(n, endGormSpan) }},
+		{"delete",
+			func(n string) error { return cb.Delete().Before("gorm:delete").Register(n, startGormSpan("delete")) },
+			func(n string) error { return cb.Delete().After("gorm:delete").Register(n, endGormSpan) }},
+	}
+	for _, s := range steps {
+		if err := s.before("tracing:before_" + s.name); err != nil {
+			return err
+		}
+		if err := s.after("tracing:after_" + s.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startGormSpan 在执行 SQL 之前开始一个 span
+func startGormSpan(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// span 名带上表名，例如 "gorm.query card_rows"；原生 SQL（db.Raw / db.Exec）没有表名
+		name := "gorm." + op
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := gormTracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", db.Dialector.Name()),
+				attribute.String("db.operation", op),
+				attribute.String("db.collection.name", db.Statement.Table),
+			),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// endGormSpan 在执行 SQL 之后结束 span
+// 只记录带 ? 占位符的 SQL，不记录参数：参数里有密码哈希、令牌哈希等敏感数据，不能发到追踪后端
+func endGormSpan(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.query.text", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	// 查不到记录是正常的业务结果，不算数据库错误
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -2,8 +2,11 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"kanban_api/internal/model"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,15 +25,92 @@ type UserRepository interface {
 	// Create 创建新用户
 	// 参数：email(邮箱), passwordHash(密码哈希值)
 	// 返回：创建的用户对象, 错误信息
-	Create(email, passwordHash string) (model.User, error)
+	Create(ctx context.Context, email, passwordHash string) (model.User, error)
 
 	// GetByEmail 通过邮箱查询用户
 	// 用于登录时验证用户
-	GetByEmail(email string) (model.User, error)
+	GetByEmail(ctx context.Context, email string) (model.User, error)
 
 	// GetByID 通过 ID 查询用户
 	// 用于鉴权后获取用户信息
-	GetByID(id string) (model.User, error)
+	GetByID(ctx context.Context, id string) (model.User, error)
+
+	// UpdatePassword 更新用户的密码哈希
+	// 用于重置密码，用户不存在时返回 ErrNotFound
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+
+	// UpdateEmail 修改用户的邮箱
+	// 邮箱已被其他用户占用时返回 ErrUserExists，用户不存在时返回 ErrNotFound
+	UpdateEmail(ctx context.Context, id, email string) (model.User, error)
+
+	// SetEmailVerified 记下用户确认邮箱的时间，用户不存在时返回 ErrNotFound
+	// UpdateEmail 会清空这个时间，新邮箱要重新确认
+	SetEmailVerified(ctx context.Context, id string, at time.Time) error
+
+	// SetRole 修改用户的系统角色，用户不存在时返回 ErrNotFound
+	SetRole(ctx context.Context, id, role string) (model.User, error)
+
+	// List 按注册时间列出所有用户，管理后台使用
+	List(ctx context.Context) ([]model.User, error)
+
+	// Search 按注册时间分页列出符合条件的用户，同时返回符合条件的总数，运维接口使用
+	Search(ctx context.Context, f UserFilter) ([]model.User, int, error)
+
+	// SetDisabled 停用（at 不为 nil，停用时间）或恢复（at 为 nil）用户，用户不存在时返回 ErrNotFound
+	SetDisabled(ctx context.Context, id string, at *time.Time) (model.User, error)
+
+	// Count 用户总数（包括停用的）和其中停用的用户数
+	Count(ctx context.Context) (total, disabled int, err error)
+
+	// SetDeleted 标记用户自己注销了账号（at 不为 nil，注销时间）或撤销注销（at 为 nil），用户不存在时返回 ErrNotFound
+	// transferBoards 记下注销时的选择，撤销时忽略
+	SetDeleted(ctx context.Context, id string, at *time.Time, transferBoards bool) (model.User, error)
+
+	// ListDeleted 注销时间早于 before 的用户，按注销时间排序，宽限期过后的清理任务使用
+	ListDeleted(ctx context.Context, before time.Time) ([]model.User, error)
+
+	// Delete 删除注销时间早于 before 的用户，返回是否删除了
+	// 用户不存在、没有注销或者还在宽限期里（例如刚撤销了注销）时不删除，返回 false
+	Delete(ctx context.Context, id string, before time.Time) (bool, error)
+
+	// CountSignups 按天（UTC）统计 [from, to) 之间的注册数，从旧到新，没有人注册的日子不返回
+	CountSignups(ctx context.Context, from, to time.Time) ([]model.DayCount, error)
+
+	// Ping 检查存储是否可用，就绪检查使用
+	Ping(ctx context.Context) error
+}
+
+// UserFilter 查找用户的条件，零值表示不限制
+type UserFilter struct {
+	// Email 邮箱包含这个字符串（邮箱保存时已经转成小写，调用方也要传小写）
+	Email string
+
+	// Disabled 不为 nil 时只列出停用（true）或没停用（false）的用户
+	Disabled *bool
+
+	// Limit、Offset 分页，Limit <= 0 表示不限制
+	Limit  int
+	Offset int
+}
+
+// match 用户是否符合条件，内存实现使用
+func (f UserFilter) match(u model.User) bool {
+	if f.Email != "" && !strings.Contains(u.Email, f.Email) {
+		return false
+	}
+	return f.Disabled == nil || *f.Disabled == (u.DisabledAt != nil)
+}
+
+// page 按 Limit、Offset 截取一页
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
 }
 
 // memUserRepo 是 UserRepository 接口的内存实现
@@ -68,7 +148,7 @@ func NewMemUserRepo() UserRepository {
 // Create 实现 UserRepository 接口的 Create 方法
 // (r *memUserRepo) 是接收者（receiver），表示这个方法属于 memUserRepo 类型
 // 类似于其他语言中的 this 或 self
-func (r *memUserRepo) Create(email, password string) (model.User, error) {
+func (r *memUserRepo) Create(ctx context.Context, email, password string) (model.User, error) {
 	// Lock() 获取写锁，确保同一时刻只有一个 goroutine 可以修改数据
 	r.mu.Lock()
 
@@ -92,6 +172,7 @@ func (r *memUserRepo) Create(email, password string) (model.User, error) {
 		Email:        email,        // 保存邮箱
 		PasswordHash: password,     // 保存密码哈希（不是明文！）
 		CreatedAt:    time.Now(),   // 记录创建时间
+		Role:         model.UserRoleUser,
 	}
 
 	// 保存到主存储
@@ -104,7 +185,7 @@ func (r *memUserRepo) Create(email, password string) (model.User, error) {
 }
 
 // GetByEmail 通过邮箱查询用户
-func (r *memUserRepo) GetByEmail(email string) (model.User, error) {
+func (r *memUserRepo) GetByEmail(ctx context.Context, email string) (model.User, error) {
 	// RLock() 获取读锁
 	// 读锁的特点：多个 goroutine 可以同时持有读锁
 	// 但如果有写锁，读锁会等待
@@ -125,7 +206,7 @@ func (r *memUserRepo) GetByEmail(email string) (model.User, error) {
 }
 
 // GetByID 通过用户 ID 查询用户
-func (r *memUserRepo) GetByID(id string) (model.User, error) {
+func (r *memUserRepo) GetByID(ctx context.Context, id string) (model.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -137,3 +218,188 @@ func (r *memUserRepo) GetByID(id string) (model.User, error) {
 
 	return u, nil
 }
+
+// UpdatePassword 更新用户的密码哈希
+func (r *memUserRepo) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	// 修改数据要用写锁
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	r.users[id] = u
+	return nil
+}
+
+// UpdateEmail 修改用户的邮箱，同时维护邮箱索引
+func (r *memUserRepo) UpdateEmail(ctx context.Context, id, email string) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+	if other, ok := r.emailIdx[email]; ok && other != id {
+		return model.User{}, ErrUserExists
+	}
+
+	// 删除旧邮箱的索引，再建立新邮箱的索引
+	delete(r.emailIdx, u.Email)
+	r.emailIdx[email] = id
+	if u.Email != email {
+		u.Email, u.EmailVerifiedAt = email, nil
+	}
+	r.users[id] = u
+	return u, nil
+}
+
+// SetEmailVerified 记下确认邮箱的时间
+func (r *memUserRepo) SetEmailVerified(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.EmailVerifiedAt = &at
+	r.users[id] = u
+	return nil
+}
+
+// SetRole 修改用户的系统角色
+func (r *memUserRepo) SetRole(ctx context.Context, id, role string) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+	u.Role = role
+	r.users[id] = u
+	return u, nil
+}
+
+// List 列出所有用户
+func (r *memUserRepo) List(ctx context.Context) ([]model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.User, 0, len(r.users))
+	for _, u := range r.users {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Search 按条件分页列出用户
+func (r *memUserRepo) Search(ctx context.Context, f UserFilter) ([]model.User, int, error) {
+	all, _ := r.List(ctx)
+	var out []model.User
+	for _, u := range all {
+		if f.match(u) {
+			out = append(out, u)
+		}
+	}
+	return page(out, f.Limit, f.Offset), len(out), nil
+}
+
+// SetDisabled 停用或恢复用户
+func (r *memUserRepo) SetDisabled(ctx context.Context, id string, at *time.Time) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+	u.DisabledAt = at
+	r.users[id] = u
+	return u, nil
+}
+
+// SetDeleted 标记注销或撤销注销
+func (r *memUserRepo) SetDeleted(ctx context.Context, id string, at *time.Time, transferBoards bool) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+	u.DeletedAt, u.TransferBoards = at, at != nil && transferBoards
+	r.users[id] = u
+	return u, nil
+}
+
+// ListDeleted 列出宽限期已过的用户
+func (r *memUserRepo) ListDeleted(ctx context.Context, before time.Time) ([]model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []model.User
+	for _, u := range r.users {
+		if u.DeletedAt != nil && u.DeletedAt.Before(before) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt.Before(*out[j].DeletedAt) })
+	return out, nil
+}
+
+// Delete 删除宽限期已过的用户
+func (r *memUserRepo) Delete(ctx context.Context, id string, before time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt == nil || !u.DeletedAt.Before(before) {
+		return false, nil
+	}
+	delete(r.users, id)
+	delete(r.emailIdx, u.Email)
+	return true, nil
+}
+
+// Count 统计用户数
+func (r *memUserRepo) Count(ctx context.Context) (total, disabled int, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.DisabledAt != nil {
+			disabled++
+		}
+	}
+	return len(r.users), disabled, nil
+}
+
+// CountSignups 按天统计注册数
+func (r *memUserRepo) CountSignups(ctx context.Context, from, to time.Time) ([]model.DayCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, u := range r.users {
+		if !u.CreatedAt.Before(from) && u.CreatedAt.Before(to) {
+			counts[u.CreatedAt.UTC().Format(model.DayLayout)]++
+		}
+	}
+	out := make([]model.DayCount, 0, len(counts))
+	for day, n := range counts {
+		out = append(out, model.DayCount{Day: day, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day < out[j].Day })
+	return out, nil
+}
+
+// Ping 内存存储总是可用
+func (r *memUserRepo) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"sync"
+	"time"
+)
+
+// ErrIdentityLinked 第三方身份已经关联了用户
+var ErrIdentityLinked = errors.New("identity already linked")
+
+// UserIdentityRepository 第三方登录身份仓储接口
+// 和刷新令牌一样总是保存在 SQLite 里，用户在 MySQL 里时也一样
+type UserIdentityRepository interface {
+	// Get 按提供方和提供方的用户 ID 查询，没有关联时返回 ErrNotFound
+	Get(ctx context.Context, provider, subject string) (model.UserIdentity, error)
+
+	// Create 把第三方身份关联到用户，这个身份已经关联过时返回 ErrIdentityLinked
+	Create(ctx context.Context, userID, provider, subject, email string) (model.UserIdentity, error)
+
+	// Delete 删除一条关联，关联的用户已经不存在时使用
+	Delete(ctx context.Context, id string) error
+}
+
+// memUserIdentityRepo 第三方登录身份仓储的内存实现
+type memUserIdentityRepo struct {
+	mu         sync.Mutex
+	identities map[string]model.UserIdentity // key 是 provider + "\x00" + subject
+}
+
+// NewMemUserIdentityRepo 创建一个新的内存第三方登录身份仓储
+func NewMemUserIdentityRepo() UserIdentityRepository {
+	return &memUserIdentityRepo{identities: make(map[string]model.UserIdentity)}
+}
+
+func (r *memUserIdentityRepo) Get(ctx context.Context, provider, subject string) (model.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.identities[provider+"\x00"+subject]
+	if !ok {
+		return model.UserIdentity{}, ErrNotFound
+	}
+	return i, nil
+}
+
+func (r *memUserIdentityRepo) Create(ctx context.Context, userID, provider, subject, email string) (model.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := provider + "\x00" + subject
+	if _, ok := r.identities[key]; ok {
+		return model.UserIdentity{}, ErrIdentityLinked
+	}
+	i := model.UserIdentity{
+		ID:        generateID(),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+	r.identities[key] = i
+	return i, nil
+}
+
+func (r *memUserIdentityRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, i := range r.identities {
+		if i.ID == id {
+			delete(r.identities, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sqliteUserIdentityRepo 是 UserIdentityRepository 的 SQLite 实现
+type sqliteUserIdentityRepo struct {
+	db *gorm.DB
+}
+
+// userIdentityRow 第三方登录身份表结构
+// (provider, subject) 有唯一索引（见 internal/migrations）
+type userIdentityRow struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// NewSQLiteUserIdentityRepo 创建一个新的 SQLite 第三方登录身份仓储
+func NewSQLiteUserIdentityRepo(db *gorm.DB) (UserIdentityRepository, error) {
+	return &sqliteUserIdentityRepo{db: db}, nil
+}
+
+func (r *sqliteUserIdentityRepo) toModel(row userIdentityRow) model.UserIdentity {
+	return model.UserIdentity{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Provider:  row.Provider,
+		Subject:   row.Subject,
+		Email:     row.Email,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+func (r *sqliteUserIdentityRepo) Get(ctx context.Context, provider, subject string) (model.UserIdentity, error) {
+	var rw userIdentityRow
+	if err := r.db.WithContext(ctx).First(&rw, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.UserIdentity{}, ErrNotFound
+		}
+		return model.UserIdentity{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteUserIdentityRepo) Create(ctx context.Context, userID, provider, subject, email string) (model.UserIdentity, error) {
+	rw := userIdentityRow{
+		ID:        generateID(),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		// 同一个身份同时登录两次时，由唯一索引 idx_user_identity_rows_subject 兜底
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.UserIdentity{}, ErrIdentityLinked
+		}
+		return model.UserIdentity{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteUserIdentityRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&userIdentityRow{}, "id = ?", id).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteUserIdentityRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"kanban_api/internal/model"
+	"strings"
 	"time"
 )
 
@@ -13,60 +15,125 @@ type sqliteUserRep struct {
 }
 
 type userRow struct {
-	ID           string `gorm:"primary_key"`
-	Email        string
+	ID string `gorm:"primary_key"`
+	// Email 有唯一索引 idx_user_rows_email（见 internal/migrations）
+	// MySQL 里是带长度的 varchar，才能建索引
+	Email        string `gorm:"size:191"`
 	PasswordHash string
-	CreatedAt    time.Time
+	// Role 系统角色；给老数据库加这一列时，已有用户会填上默认值 user
+	Role      string `gorm:"size:16;not null;default:user"`
+	CreatedAt time.Time
+	// DisabledAt 停用时间，0019 迁移新增
+	DisabledAt *time.Time
+	// DeletedAt、TransferBoards 用户自己注销的时间和选择，0020 迁移新增
+	DeletedAt      *time.Time
+	TransferBoards bool `gorm:"not null;default:false"`
+	// EmailVerifiedAt 确认邮箱的时间，0028 迁移新增
+	EmailVerifiedAt *time.Time
 }
 
-func NewSQLiteUserRepo(path string) (UserRepository, error) {
-	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
-	if err != nil {
-		return nil, err
-	}
-	if err = db.AutoMigrate(&userRow{}); err != nil {
-		return nil, err
-	}
+// NewSQLiteUserRepo 创建 SQLite 用户仓储
+// 邮箱重复时能识别出 gorm.ErrDuplicatedKey，靠的是 NewDB 打开连接时设置的 TranslateError
+func NewSQLiteUserRepo(db *gorm.DB) (UserRepository, error) {
+	return newGormUserRepo(db)
+}
+
+// newGormUserRepo 在已经打开的数据库上返回用户仓储，表要先由迁移建好
+// SQLite 和 MySQL 共用这一份实现
+func newGormUserRepo(db *gorm.DB) (UserRepository, error) {
 	return &sqliteUserRep{db: db}, nil
 }
 
 func (r *sqliteUserRep) toModel(row userRow) model.User {
 	return model.User{
-		ID:           row.ID,
-		Email:        row.Email,
-		PasswordHash: row.PasswordHash,
-		CreatedAt:    row.CreatedAt,
+		ID:              row.ID,
+		Email:           row.Email,
+		PasswordHash:    row.PasswordHash,
+		Role:            row.Role,
+		CreatedAt:       row.CreatedAt,
+		DisabledAt:      row.DisabledAt,
+		DeletedAt:       row.DeletedAt,
+		TransferBoards:  row.TransferBoards,
+		EmailVerifiedAt: row.EmailVerifiedAt,
 	}
 }
 
-func (r *sqliteUserRep) Create(email, passwordHash string) (model.User, error) {
+func (r *sqliteUserRep) Create(ctx context.Context, email, passwordHash string) (model.User, error) {
 	now := time.Now()
 	rw := userRow{
 		ID:           generateID(),
 		Email:        email,
 		PasswordHash: passwordHash,
+		Role:         model.UserRoleUser,
 		CreatedAt:    now,
 	}
-	if err := r.db.Create(&rw).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		// 同一个邮箱同时注册两次时，由唯一索引 idx_user_rows_email 兜底
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return model.User{}, ErrUserExists
+		}
+		return model.User{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteUserRep) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	var rw userRow
+	if err := r.db.WithContext(ctx).First(&rw, "email = ?", email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.User{}, ErrNotFound
+		}
 		return model.User{}, err
 	}
 	return r.toModel(rw), nil
 }
 
-func (r *sqliteUserRep) GetByEmail(email string) (model.User, error) {
+func (r *sqliteUserRep) GetByID(ctx context.Context, id string) (model.User, error) {
 	var rw userRow
-	if err := r.db.First(&rw, "email = ?", email).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&rw, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return model.User{}, ErrNotFound
 		}
-		return model.User{}, nil
+		return model.User{}, err
 	}
 	return r.toModel(rw), nil
 }
 
-func (r *sqliteUserRep) GetByID(id string) (model.User, error) {
+func (r *sqliteUserRep) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	res := r.db.WithContext(ctx).Model(&userRow{}).Where("id = ?", id).Update("password_hash", passwordHash)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateEmail 修改邮箱
+// 老数据库里邮箱唯一索引可能没建成功（见迁移 0002），所以不能只靠数据库报错，
+// 在事务里先检查新邮箱有没有被别人占用
+func (r *sqliteUserRep) UpdateEmail(ctx context.Context, id, email string) (model.User, error) {
 	var rw userRow
-	if err := r.db.First(&rw, "id = ?", id).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&rw, "id = ?", id).Error; err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&userRow{}).Where("email = ? AND id <> ?", email, id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrUserExists
+		}
+		if rw.Email == email {
+			return nil
+		}
+		// 新邮箱还没确认过
+		rw.Email, rw.EmailVerifiedAt = email, nil
+		return tx.Model(&rw).Updates(map[string]any{"email": email, "email_verified_at": nil}).Error
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return model.User{}, ErrNotFound
 		}
@@ -74,3 +141,158 @@ func (r *sqliteUserRep) GetByID(id string) (model.User, error) {
 	}
 	return r.toModel(rw), nil
 }
+
+// SetEmailVerified 记下确认邮箱的时间
+func (r *sqliteUserRep) SetEmailVerified(ctx context.Context, id string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&userRow{}).Where("id = ?", id).Update("email_verified_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteUserRep) SetRole(ctx context.Context, id, role string) (model.User, error) {
+	res := r.db.WithContext(ctx).Model(&userRow{}).Where("id = ?", id).Update("role", role)
+	if res.Error != nil {
+		return model.User{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.User{}, ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *sqliteUserRep) List(ctx context.Context) ([]model.User, error) {
+	var rows []userRow
+	if err := r.db.WithContext(ctx).Order("created_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.User, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// filter 按条件拼 WHERE，Search 的查询和计数共用
+func (r *sqliteUserRep) filter(db *gorm.DB, f UserFilter) *gorm.DB {
+	q := db.Model(&userRow{})
+	if f.Email != "" {
+		q = q.Where("email LIKE ? ESCAPE '!'", containsPattern(f.Email))
+	}
+	if f.Disabled != nil {
+		if *f.Disabled {
+			q = q.Where("disabled_at IS NOT NULL")
+		} else {
+			q = q.Where("disabled_at IS NULL")
+		}
+	}
+	return q
+}
+
+func (r *sqliteUserRep) Search(ctx context.Context, f UserFilter) ([]model.User, int, error) {
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := r.filter(db, f).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	q := r.filter(db, f).Order("created_at").Order("id").Offset(f.Offset)
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	var rows []userRow
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	out := make([]model.User, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, int(total), nil
+}
+
+func (r *sqliteUserRep) SetDisabled(ctx context.Context, id string, at *time.Time) (model.User, error) {
+	res := r.db.WithContext(ctx).Model(&userRow{}).Where("id = ?", id).Update("disabled_at", at)
+	if res.Error != nil {
+		return model.User{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.User{}, ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *sqliteUserRep) SetDeleted(ctx context.Context, id string, at *time.Time, transferBoards bool) (model.User, error) {
+	res := r.db.WithContext(ctx).Model(&userRow{}).Where("id = ?", id).
+		Updates(map[string]any{"deleted_at": at, "transfer_boards": at != nil && transferBoards})
+	if res.Error != nil {
+		return model.User{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.User{}, ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *sqliteUserRep) ListDeleted(ctx context.Context, before time.Time) ([]model.User, error) {
+	var rows []userRow
+	if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.User, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Delete 条件写在同一条 DELETE 里：清理任务查出用户之后用户又撤销了注销，这里不会误删
+func (r *sqliteUserRep) Delete(ctx context.Context, id string, before time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Where("id = ? AND deleted_at < ?", id, before).Delete(&userRow{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *sqliteUserRep) Count(ctx context.Context) (total, disabled int, err error) {
+	var res struct {
+		Total    int
+		Disabled int
+	}
+	err = r.db.WithContext(ctx).Model(&userRow{}).
+		Select("COUNT(*) AS total, COUNT(disabled_at) AS disabled").Scan(&res).Error
+	return res.Total, res.Disabled, err
+}
+
+// CountSignups 按 created_at 的日期分组
+// SQLite 里的时间带着时区后缀，DATE() 会先换算成 UTC；MySQL 的 datetime 不带时区，按 DSN 里 loc 的时区分组，
+// loc 不是 UTC 时日期边界和其它按天统计的接口差几个小时
+func (r *sqliteUserRep) CountSignups(ctx context.Context, from, to time.Time) ([]model.DayCount, error) {
+	var out []model.DayCount
+	err := r.db.WithContext(ctx).Model(&userRow{}).
+		Select("DATE(created_at) AS day, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("DATE(created_at)").Order("day").Scan(&out).Error
+	return out, err
+}
+
+// Ping 向数据库发一次 ping，连接池里没有可用连接时会新建一个
+func (r *sqliteUserRep) Ping(ctx context.Context) error {
+	db, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteUserRep) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
+
+// containsPattern 把 s 转成“包含 s”的 LIKE 模式，配合 ESCAPE '!' 使用
+// 用 ! 而不是反斜杠做转义符：反斜杠在 MySQL 的字符串字面量里本身就要转义，同一条 SQL 没法两边通用
+func containsPattern(s string) string {
+	return "%" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s) + "%"
+}
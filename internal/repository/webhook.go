@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WebhookRepository webhook 仓储接口
+type WebhookRepository interface {
+	// Create 保存一个新的 webhook，ID 和创建时间由仓储生成
+	Create(ctx context.Context, w model.Webhook) (model.Webhook, error)
+
+	// ListByBoard 列出看板的所有 webhook，最新创建的在前
+	ListByBoard(ctx context.Context, boardID string) ([]model.Webhook, error)
+
+	// Delete 删除看板的某个 webhook，不存在或者不属于这个看板时返回 ErrNotFound
+	Delete(ctx context.Context, boardID, id string) error
+
+	// DeleteByBoard 删除看板的所有 webhook，看板被删除时调用
+	DeleteByBoard(ctx context.Context, boardID string) error
+
+	// RecordDelivery 记录最近一次投递的结果，webhook 已经被删除时什么也不做
+	RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error
+}
+
+// memWebhookRepo webhook 仓储的内存实现
+type memWebhookRepo struct {
+	mu       sync.RWMutex
+	webhooks map[string]model.Webhook
+}
+
+// NewMemWebhookRepo 创建一个新的内存 webhook 仓储
+func NewMemWebhookRepo() WebhookRepository {
+	return &memWebhookRepo{webhooks: make(map[string]model.Webhook)}
+}
+
+// Create 保存 webhook
+func (r *memWebhookRepo) Create(ctx context.Context, w model.Webhook) (model.Webhook, error) {
+	w.ID = generateID()
+	w.CreatedAt = time.Now()
+	// 复制一份，调用方之后修改自己的切片不会影响仓储里的数据
+	w.Events = append([]string{}, w.Events...)
+
+	r.mu.Lock()
+	r.webhooks[w.ID] = w
+	r.mu.Unlock()
+	return w, nil
+}
+
+// ListByBoard 列出看板的所有 webhook
+func (r *memWebhookRepo) ListByBoard(ctx context.Context, boardID string) ([]model.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]model.Webhook, 0)
+	for _, w := range r.webhooks {
+		if w.BoardID == boardID {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// Delete 删除 webhook
+func (r *memWebhookRepo) Delete(ctx context.Context, boardID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.webhooks[id]
+	if !ok || w.BoardID != boardID {
+		return ErrNotFound
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+// DeleteByBoard 删除看板的所有 webhook
+func (r *memWebhookRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, w := range r.webhooks {
+		if w.BoardID == boardID {
+			delete(r.webhooks, id)
+		}
+	}
+	return nil
+}
+
+// RecordDelivery 记录投递结果
+func (r *memWebhookRepo) RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.webhooks[id]
+	if !ok {
+		return nil
+	}
+	w.LastDeliveryAt, w.LastStatus, w.LastError = &at, status, errMsg
+	r.webhooks[id] = w
+	return nil
+}
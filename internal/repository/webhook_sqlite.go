@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"kanban_api/internal/model"
+	"strings"
+	"time"
+)
+
+// sqliteWebhookRepo 是 WebhookRepository 的 SQLite 实现
+type sqliteWebhookRepo struct {
+	db *gorm.DB
+}
+
+// webhookRow webhook 表结构
+// board_id 上的索引见 internal/migrations
+type webhookRow struct {
+	ID      string `gorm:"primaryKey"`
+	BoardID string
+	URL     string
+
+	// Events 用逗号拼接保存，事件类型里没有逗号；空串表示所有事件
+	Events string
+
+	Secret         string
+	CreatedBy      string
+	LastDeliveryAt *time.Time
+	LastStatus     int
+	LastError      string
+	CreatedAt      time.Time
+}
+
+// NewSQLiteWebhookRepo 创建一个新的 SQLite webhook 仓储
+func NewSQLiteWebhookRepo(db *gorm.DB) (WebhookRepository, error) {
+	return &sqliteWebhookRepo{db: db}, nil
+}
+
+func (r *sqliteWebhookRepo) toModel(row webhookRow) model.Webhook {
+	events := []string{}
+	if row.Events != "" {
+		events = strings.Split(row.Events, ",")
+	}
+	return model.Webhook{
+		ID:             row.ID,
+		BoardID:        row.BoardID,
+		URL:            row.URL,
+		Events:         events,
+		Secret:         row.Secret,
+		CreatedBy:      row.CreatedBy,
+		LastDeliveryAt: row.LastDeliveryAt,
+		LastStatus:     row.LastStatus,
+		LastError:      row.LastError,
+		CreatedAt:      row.CreatedAt,
+	}
+}
+
+func (r *sqliteWebhookRepo) Create(ctx context.Context, w model.Webhook) (model.Webhook, error) {
+	rw := webhookRow{
+		ID:        generateID(),
+		BoardID:   w.BoardID,
+		URL:       w.URL,
+		Events:    strings.Join(w.Events, ","),
+		Secret:    w.Secret,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	if err := r.db.WithContext(ctx).Create(&rw).Error; err != nil {
+		return model.Webhook{}, err
+	}
+	return r.toModel(rw), nil
+}
+
+func (r *sqliteWebhookRepo) ListByBoard(ctx context.Context, boardID string) ([]model.Webhook, error) {
+	var rows []webhookRow
+	if err := r.db.WithContext(ctx).Where("board_id = ?", boardID).Order("created_at desc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]model.Webhook, 0, len(rows))
+	for _, rw := range rows {
+		out = append(out, r.toModel(rw))
+	}
+	return out, nil
+}
+
+// Delete 删除 webhook
+// 条件里带上 board_id，不能通过别的看板的路径删掉这个 webhook
+func (r *sqliteWebhookRepo) Delete(ctx context.Context, boardID, id string) error {
+	res := r.db.WithContext(ctx).Where("id = ? AND board_id = ?", id, boardID).Delete(&webhookRow{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqliteWebhookRepo) DeleteByBoard(ctx context.Context, boardID string) error {
+	return r.db.WithContext(ctx).Where("board_id = ?", boardID).Delete(&webhookRow{}).Error
+}
+
+func (r *sqliteWebhookRepo) RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error {
+	return r.db.WithContext(ctx).Model(&webhookRow{}).Where("id = ?", id).Updates(map[string]any{
+		"last_delivery_at": at,
+		"last_status":      status,
+		"last_error":       errMsg,
+	}).Error
+}
+
+// sqlDB 返回底层的 *sql.DB，供 QueryMetrics 读取连接池状态
+func (r *sqliteWebhookRepo) sqlDB() (*sql.DB, error) {
+	return r.db.DB()
+}
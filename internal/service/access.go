@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+)
+
+// roleRank 角色的权限高低，数字越大权限越多
+var roleRank = map[string]int{
+	model.RoleViewer: 1,
+	model.RoleEditor: 2,
+	model.RoleOwner:  3,
+}
+
+// boardAccess 看板访问检查，看板、列表、卡片服务共用
+// 用户对看板的角色：
+// - 看板所有者：owner
+// - 被邀请的成员：成员记录里的 editor / viewer
+// - 其他人：看不到看板（ErrNotFound）
+type boardAccess struct {
+	boards  repository.BoardRepository
+	members repository.MemberRepository
+}
+
+// check 确认用户对看板至少有 need 角色，并且看板状态允许这个操作（见 checkState），返回看板和用户的实际角色
+func (a boardAccess) check(ctx context.Context, userID, boardID, need string) (model.Board, string, error) {
+	// 先按所有者查询，自己的看板最常见，一次查询就能确定
+	b, err := a.boards.Get(ctx, userID, boardID)
+	if err == nil {
+		if err := checkState(b, need); err != nil {
+			return model.Board{}, "", err
+		}
+		return b, model.RoleOwner, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return model.Board{}, "", err
+	}
+
+	// 不是所有者，再看是不是成员
+	m, err := a.members.Get(ctx, boardID, userID)
+	if err != nil {
+		return model.Board{}, "", err
+	}
+	if roleRank[m.Role] < roleRank[need] {
+		return model.Board{}, "", ErrForbidden
+	}
+
+	boards, err := a.boards.ListByIDs(ctx, []string{boardID})
+	if err != nil {
+		return model.Board{}, "", err
+	}
+	if len(boards) == 0 {
+		// 成员记录还在但看板已经删除
+		return model.Board{}, "", repository.ErrNotFound
+	}
+	if err := checkState(boards[0], need); err != nil {
+		return model.Board{}, "", err
+	}
+	return boards[0], m.Role, nil
+}
+
+// checkState 看板状态对操作的限制
+// - 停用的看板：任何操作都不行
+// - 只读的看板：只能做 viewer 角色就能做的事（查看），需要 editor、owner 角色的修改操作都不行
+//
+// 看板、列表、卡片、成员、webhook 服务都经过 check，所以状态只需要在这里检查一次
+func checkState(b model.Board, need string) error {
+	switch b.State {
+	case model.BoardStateSuspended:
+		return ErrBoardSuspended
+	case model.BoardStateReadOnly:
+		if need != model.RoleViewer {
+			return ErrBoardReadOnly
+		}
+	}
+	return nil
+}
+
+// visibleBoardIDs 用户能看到内容的所有看板 ID：自己的看板加上作为成员加入的看板，不包括停用的看板
+// 搜索、即将到期的卡片这些跨看板的查询用它，停用看板里的内容不会从这些地方漏出去
+func (a boardAccess) visibleBoardIDs(ctx context.Context, userID string) ([]string, error) {
+	own, err := a.boards.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := a.members.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sharedIDs := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		sharedIDs = append(sharedIDs, m.BoardID)
+	}
+	shared, err := a.boards.ListByIDs(ctx, sharedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(own)+len(shared))
+	for _, b := range append(own, shared...) {
+		if b.State != model.BoardStateSuspended {
+			ids = append(ids, b.ID)
+		}
+	}
+	return ids, nil
+}
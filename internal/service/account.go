@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrRestoreExpired 注销的宽限期已经过了，不能再撤销
+var ErrRestoreExpired = newError(ErrForbidden, "account deletion can no longer be undone")
+
+// AccountService 用户注销账号和撤销注销
+type AccountService interface {
+	// DeleteAccount 验证密码后注销当前用户的账号，密码不对返回 ErrWrongPassword
+	// 账号先标记为注销、吊销所有刷新令牌，数据在宽限期过后才处理：
+	// transferBoards 为 true 时把看板转给最早加入的编辑者，没有编辑者的看板和 false 时一样删除
+	// 已经注销过时不修改，返回第一次注销的结果
+	DeleteAccount(ctx context.Context, userID, password string, transferBoards bool) (model.AccountDeletion, error)
+
+	// RestoreAccount 宽限期内撤销注销，凭邮箱和密码调用（注销后不能登录），成功后和登录一样颁发令牌
+	// 邮箱或密码不对返回和登录相同的错误；宽限期已过返回 ErrRestoreExpired；账号没有注销时直接登录
+	RestoreAccount(ctx context.Context, email, password string) (model.User, TokenPair, error)
+}
+
+// AccountDeleter 注销账号和宽限期过后的清理
+//
+// 注销分两步：DeleteAccount 只标记用户（user_rows.deleted_at）、吊销刷新令牌，什么数据都不删，撤销时清掉标记就行；
+// 宽限期过后 Purge 处理用户的看板、退出别人的看板，最后删除用户
+//
+// 用户和看板可能在 MySQL 里，列表、卡片总在 SQLite 里，没法放进一个事务。
+// Purge 的每一步在各自的存储里是原子的（转让看板是一条带原所有者条件的 UPDATE，删除看板见 BoardService.AdminDeleteBoard），
+// 并且可以重复执行：中途失败时用户还在，下一轮清理从剩下的看板接着做；用户记录最后删除，删除时再检查一次宽限期
+type AccountDeleter struct {
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	boards        repository.BoardRepository
+	members       repository.MemberRepository
+
+	// boardSvc 转让和删除看板走看板服务，和正常操作一样记看板动态、推送事件、投递 webhook
+	boardSvc BoardService
+
+	// auth 撤销注销之后用它登录、颁发令牌
+	auth AuthService
+
+	// grace 注销之后多久内可以撤销
+	grace time.Duration
+}
+
+// NewAccountDeleter 创建注销账号实例
+// boardSvc、auth 应该是包装好装饰器的服务
+func NewAccountDeleter(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, boards repository.BoardRepository, members repository.MemberRepository, boardSvc BoardService, auth AuthService, grace time.Duration) *AccountDeleter {
+	return &AccountDeleter{users: users, refreshTokens: refreshTokens, boards: boards, members: members, boardSvc: boardSvc, auth: auth, grace: grace}
+}
+
+// DeleteAccount 注销账号
+// 刷新令牌在这里吊销；访问令牌不用单独处理，AuthRequired 通过 CheckSession 看到 DeletedAt 就拒绝
+func (d *AccountDeleter) DeleteAccount(ctx context.Context, userID, password string, transferBoards bool) (model.AccountDeletion, error) {
+	u, err := d.users.GetByID(ctx, userID)
+	if err != nil {
+		return model.AccountDeletion{}, err
+	}
+	// 和修改密码一样，已经登录也要验证密码，拿到令牌的人不能直接注销账号
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return model.AccountDeletion{}, ErrWrongPassword
+	}
+	if u.DeletedAt == nil {
+		now := time.Now()
+		if u, err = d.users.SetDeleted(ctx, userID, &now, transferBoards); err != nil {
+			return model.AccountDeletion{}, err
+		}
+		logging.FromContext(ctx).Info("account deleted", "transfer_boards", transferBoards)
+	}
+	// 已经注销过也再吊销一次，上一次可能在这一步失败了
+	if err := d.refreshTokens.RevokeUser(ctx, userID); err != nil {
+		return model.AccountDeletion{}, err
+	}
+	return model.AccountDeletion{DeletedAt: *u.DeletedAt, PurgeAt: u.DeletedAt.Add(d.grace), TransferBoards: u.TransferBoards}, nil
+}
+
+// RestoreAccount 撤销注销
+func (d *AccountDeleter) RestoreAccount(ctx context.Context, email, password string) (model.User, TokenPair, error) {
+	email = strings.TrimSpace(strings.ToLower(email))
+	u, err := d.users.GetByEmail(ctx, email)
+	if err != nil {
+		return model.User{}, TokenPair{}, errInvalidCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return model.User{}, TokenPair{}, errInvalidCredentials
+	}
+	if u.DeletedAt != nil {
+		if !time.Now().Before(u.DeletedAt.Add(d.grace)) {
+			return model.User{}, TokenPair{}, ErrRestoreExpired
+		}
+		if _, err := d.users.SetDeleted(ctx, u.ID, nil, false); err != nil {
+			return model.User{}, TokenPair{}, err
+		}
+		logging.FromContext(ctx).Info("account restored", "user_id", u.ID)
+	}
+	return d.auth.Login(ctx, email, password)
+}
+
+// Purge 清理注销时间早于 now 减去宽限期的用户，返回删除了几个用户
+// 单个用户清理失败只记日志，接着清理其他用户，下一轮再重试
+func (d *AccountDeleter) Purge(ctx context.Context, now time.Time) (int, error) {
+	before := now.Add(-d.grace)
+	users, err := d.users.ListDeleted(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, u := range users {
+		deleted, err := d.purge(ctx, u, before)
+		if err != nil {
+			logging.FromContext(ctx).Warn("purge deleted account", "user_id", u.ID, "err", err)
+			continue
+		}
+		if deleted {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// purge 处理一个用户的看板和成员身份，最后删除用户
+func (d *AccountDeleter) purge(ctx context.Context, u model.User, before time.Time) (bool, error) {
+	boards, err := d.boards.List(ctx, u.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range boards {
+		if u.TransferBoards {
+			to, err := d.successor(ctx, b.ID)
+			if err != nil {
+				return false, err
+			}
+			if to != "" {
+				if _, err := d.boardSvc.TransferBoard(ctx, u.ID, b.ID, to); err != nil {
+					return false, err
+				}
+				continue
+			}
+		}
+		if err := d.boardSvc.AdminDeleteBoard(ctx, b.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return false, err
+		}
+	}
+
+	memberships, err := d.members.ListByUser(ctx, u.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range memberships {
+		if err := d.members.Remove(ctx, m.BoardID, u.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return false, err
+		}
+	}
+	if err := d.refreshTokens.RevokeUser(ctx, u.ID); err != nil {
+		return false, err
+	}
+	return d.users.Delete(ctx, u.ID, before)
+}
+
+// successor 看板转给谁：最早加入的编辑者，没有编辑者时返回空
+// 只读成员不算：原所有者没给过他们修改的权限，不该因为注销就成了所有者
+// 自己也在注销宽限期里或者被停用的编辑者也跳过：转给他们的看板没人能用，过些天还会跟着再被删掉
+func (d *AccountDeleter) successor(ctx context.Context, boardID string) (string, error) {
+	members, err := d.members.ListByBoard(ctx, boardID)
+	if err != nil {
+		return "", err
+	}
+	sort.SliceStable(members, func(i, j int) bool { return members[i].CreatedAt.Before(members[j].CreatedAt) })
+	for _, m := range members {
+		if m.Role != model.RoleEditor {
+			continue
+		}
+		u, err := d.users.GetByID(ctx, m.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if u.DeletedAt == nil && u.DisabledAt == nil {
+			return u.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// Run 立即清理一次，之后每隔 interval 清理一次，直到 ctx 被取消
+func (d *AccountDeleter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := d.Purge(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warn("purge deleted accounts", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("purged deleted accounts", "count", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
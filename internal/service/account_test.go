@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestAccountDeletion 注销后不能登录，宽限期内可以撤销；宽限期过后有编辑者的看板转给最早加入的编辑者
+// （跳过同样在注销或者被停用的编辑者），只有只读成员的看板被删除，用户退出别人的看板并被删除
+func TestAccountDeletion(t *testing.T) {
+	ctx := context.Background()
+	users, refreshTokens := repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo()
+	boards, members := repository.NewMemBoardRepo(), repository.NewMemMemberRepo()
+	lists, cards, attachments := repository.NewMemListRepo(), repository.NewMemCardRepo(), repository.NewMemAttachmentRepo()
+	storage := NewStorageCounters(repository.NewMemStorageRepo(), attachments, lists, cards, 0)
+	boardSvc := NewBoardService(boards, lists, cards, repository.NewMemChecklistRepo(), attachments, repository.NewMemCardTemplateRepo(), storage, members, []byte("secret"), 20, nil)
+	auth := NewAuthService(users, refreshTokens, repository.NewMemPasswordResetRepo(), jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+	grace := 24 * time.Hour
+	accounts := NewAccountDeleter(users, refreshTokens, boards, members, boardSvc, auth, grace)
+
+	u, pair, err := auth.Register(ctx, "leaving@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := auth.Register(ctx, "other@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := boards.Create(ctx, u.ID, "Shared", "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := boards.Create(ctx, u.ID, "Private", "private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := boards.Create(ctx, other.ID, "Theirs", "theirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := func(name string) string {
+		m, err := users.Create(ctx, name+"@example.com", "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m.ID
+	}
+	viewer, leaving, disabled, editor := member("viewer"), member("also-leaving"), member("disabled"), member("editor")
+	// also-leaving 晚一个小时注销，下面清理的时候还在宽限期里
+	now, later := time.Now(), time.Now().Add(time.Hour)
+	if _, err := users.SetDeleted(ctx, leaving, &later, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.SetDisabled(ctx, disabled, &now); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []struct{ board, user, role string }{
+		{shared.ID, viewer, model.RoleViewer},
+		{shared.ID, leaving, model.RoleEditor},
+		{shared.ID, disabled, model.RoleEditor},
+		{shared.ID, editor, model.RoleEditor},
+		{private.ID, viewer, model.RoleViewer},
+		{theirs.ID, u.ID, model.RoleEditor},
+	} {
+		if _, err := members.Upsert(ctx, m.board, m.user, m.role); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // 加入时间决定转给谁
+	}
+
+	if _, err := accounts.DeleteAccount(ctx, u.ID, "wrong-password", true); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("delete with wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	d, err := accounts.DeleteAccount(ctx, u.ID, "password123", false)
+	if err != nil || d.PurgeAt.Sub(d.DeletedAt) != grace {
+		t.Fatalf("delete = %+v, %v", d, err)
+	}
+	if _, _, err := auth.Login(ctx, "leaving@example.com", "password123"); !errors.Is(err, ErrAccountDeleted) {
+		t.Fatalf("login after delete: err = %v, want ErrAccountDeleted", err)
+	}
+	if _, err := auth.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after delete: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := auth.CheckSession(ctx, u.ID); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("session after delete: err = %v, want ErrSessionRevoked", err)
+	}
+
+	// 撤销之后可以正常登录，换个选择再注销一次
+	if _, _, err := accounts.RestoreAccount(ctx, "Leaving@example.com", "password123"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := accounts.DeleteAccount(ctx, u.ID, "password123", true); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := accounts.Purge(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("purge within grace period = %d, %v, want 0", n, err)
+	}
+
+	if n, err := accounts.Purge(ctx, time.Now().Add(grace+time.Minute)); err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v, want 1", n, err)
+	}
+	if _, err := users.GetByID(ctx, u.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("user after purge: err = %v, want ErrNotFound", err)
+	}
+	got, err := boards.ListByIDs(ctx, []string{shared.ID, private.ID})
+	if err != nil || len(got) != 1 || got[0].ID != shared.ID || got[0].OwnerID != editor {
+		t.Fatalf("boards after purge = %+v, %v, want only the shared board owned by the active editor", got, err)
+	}
+	if _, err := members.Get(ctx, shared.ID, editor); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("new owner still a member: err = %v", err)
+	}
+	if _, err := members.Get(ctx, theirs.ID, u.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("membership on other board after purge: err = %v", err)
+	}
+}
@@ -0,0 +1,675 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"kanban_api/internal/events"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strconv"
+	"time"
+)
+
+// 看板动态每页的条数
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+// ActivityPage 一页看板动态
+type ActivityPage struct {
+	Items []model.Activity `json:"items"`
+
+	// NextCursor 下一页的游标，作为 cursor 参数传回来；没有更多记录时为空
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ActivityService 看板动态服务接口
+// 记录由下面的装饰器在修改成功后写入，这里只负责查询
+type ActivityService interface {
+	// ListActivities 从新到旧列出看板的动态，看板的所有成员（包括只读成员）都可以查看
+	// cursor 为空表示第一页；limit <= 0 时使用默认值
+	ListActivities(ctx context.Context, userID, boardID, cursor string, limit int) (ActivityPage, error)
+}
+
+// activityService 看板动态服务的具体实现
+type activityService struct {
+	activities repository.ActivityRepository
+	access     boardAccess
+}
+
+// NewActivityService 创建看板动态服务实例
+func NewActivityService(activities repository.ActivityRepository, boards repository.BoardRepository, members repository.MemberRepository) ActivityService {
+	return &activityService{activities: activities, access: boardAccess{boards: boards, members: members}}
+}
+
+// ListActivities 列出看板动态
+// 游标是上一页最后一条记录的 Seq，按 Seq 分页不会因为翻页期间有新记录而重复或漏掉
+func (s *activityService) ListActivities(ctx context.Context, userID, boardID, cursor string, limit int) (ActivityPage, error) {
+	var before int64
+	if cursor != "" {
+		n, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || n <= 0 {
+			return ActivityPage{}, invalidInput("invalid cursor")
+		}
+		before = n
+	}
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return ActivityPage{}, err
+	}
+
+	// 多查一条，用来判断还有没有下一页
+	items, err := s.activities.ListByBoard(ctx, boardID, before, limit+1)
+	if err != nil {
+		return ActivityPage{}, err
+	}
+	page := ActivityPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = strconv.FormatInt(page.Items[limit-1].Seq, 10)
+	}
+	return page, nil
+}
+
+// ========== 记录看板动态的装饰器 ==========
+//
+// 和 events.go 的事件发布装饰器一样实现同样的接口，修改成功之后写一条记录
+// 修改之前先通过下一层的查询方法取出对象原来的内容作为 Before，所以每次修改会多一次查询
+// 修改已经成功了，写记录失败只打印日志，不把错误返回给客户端
+
+// activityRecorder 各个装饰器共用的写记录方法
+type activityRecorder struct {
+	activities repository.ActivityRepository
+}
+
+// record 写一条记录，before、after 为 nil 时保存为 null
+func (r activityRecorder) record(ctx context.Context, boardID, actorID, action, targetID string, before, after any) {
+	a := model.Activity{BoardID: boardID, ActorID: actorID, Action: action, TargetID: targetID}
+	var err error
+	if a.Before, err = marshalState(before); err == nil {
+		a.After, err = marshalState(after)
+	}
+	if err == nil {
+		_, err = r.activities.Create(ctx, a)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("record activity", "board_id", boardID, "action", action, "err", err)
+	}
+}
+
+// marshalState 对象内容转成 JSON，nil 保持为 nil
+func marshalState(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// ========== 看板服务装饰器 ==========
+
+type recordingBoardService struct {
+	BoardService
+	rec    activityRecorder
+	boards repository.BoardRepository
+}
+
+// RecordBoardService 用看板动态装饰器包装看板服务
+// 看板被删除后动态也没人能看了，删除看板时一起删掉
+// boards 用来在管理员修改状态之前取出原来的看板：管理员不一定是成员，不能通过 GetBoard 查询
+func RecordBoardService(next BoardService, activities repository.ActivityRepository, boards repository.BoardRepository) BoardService {
+	return &recordingBoardService{BoardService: next, rec: activityRecorder{activities: activities}, boards: boards}
+}
+
+func (s *recordingBoardService) CreateBoard(ctx context.Context, userID string, in BoardInput) (model.Board, error) {
+	b, err := s.BoardService.CreateBoard(ctx, userID, in)
+	if err == nil {
+		s.rec.record(ctx, b.ID, userID, events.BoardCreated, b.ID, nil, b)
+	}
+	return b, err
+}
+
+// ImportBoard 和新建看板一样只记一条，导入的内容不逐个记录
+func (s *recordingBoardService) ImportBoard(ctx context.Context, userID string, in BoardImport) (ImportResult, error) {
+	res, err := s.BoardService.ImportBoard(ctx, userID, in)
+	if err == nil {
+		s.rec.record(ctx, res.Board.ID, userID, events.BoardCreated, res.Board.ID, nil, res.Board)
+	}
+	return res, err
+}
+
+// ClearSample 每张删除的示例卡片记一条 card.deleted，和逐张删除一样
+func (s *recordingBoardService) ClearSample(ctx context.Context, userID, boardID string) ([]model.Card, error) {
+	cards, err := s.BoardService.ClearSample(ctx, userID, boardID)
+	for _, c := range cards {
+		s.rec.record(ctx, boardID, userID, events.CardDeleted, c.ID, c, nil)
+	}
+	return cards, err
+}
+
+func (s *recordingBoardService) UpdateBoard(ctx context.Context, userID, id, title, slug string, version int64) (model.Board, error) {
+	before, berr := s.BoardService.GetBoard(ctx, userID, id)
+	b, err := s.BoardService.UpdateBoard(ctx, userID, id, title, slug, version)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, id, userID, events.BoardUpdated, id, before, b)
+	}
+	return b, err
+}
+
+func (s *recordingBoardService) PatchBoard(ctx context.Context, userID, id string, p BoardPatch, version int64) (model.Board, error) {
+	before, berr := s.BoardService.GetBoard(ctx, userID, id)
+	b, err := s.BoardService.PatchBoard(ctx, userID, id, p, version)
+	// 一个字段都没传时什么都没改，不记录
+	if err == nil && berr == nil && (p.Title != nil || p.Slug != nil) {
+		s.rec.record(ctx, id, userID, events.BoardUpdated, id, before, b)
+	}
+	return b, err
+}
+
+func (s *recordingBoardService) DeleteBoard(ctx context.Context, userID, id, confirmToken string) (*DeleteConfirmation, error) {
+	confirm, err := s.BoardService.DeleteBoard(ctx, userID, id, confirmToken)
+	if err == nil && confirm == nil {
+		s.deleteActivities(ctx, id)
+	}
+	return confirm, err
+}
+
+func (s *recordingBoardService) AdminDeleteBoard(ctx context.Context, id string) error {
+	err := s.BoardService.AdminDeleteBoard(ctx, id)
+	if err == nil {
+		s.deleteActivities(ctx, id)
+	}
+	return err
+}
+
+// AdminSetBoardState 记成管理员对看板的修改（board.updated），Before、After 里能看出状态和原因的变化
+func (s *recordingBoardService) AdminSetBoardState(ctx context.Context, adminID, id, state, reason string) (model.Board, error) {
+	var before any
+	if boards, err := s.boards.ListByIDs(ctx, []string{id}); err == nil && len(boards) == 1 {
+		before = boards[0]
+	}
+	b, err := s.BoardService.AdminSetBoardState(ctx, adminID, id, state, reason)
+	if err == nil {
+		s.rec.record(ctx, id, adminID, events.BoardUpdated, id, before, b)
+	}
+	return b, err
+}
+
+// TransferBoard 记成原所有者对看板的修改（board.updated），Before、After 里能看出所有者的变化
+func (s *recordingBoardService) TransferBoard(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	var before any
+	if boards, err := s.boards.ListByIDs(ctx, []string{id}); err == nil && len(boards) == 1 {
+		before = boards[0]
+	}
+	b, err := s.BoardService.TransferBoard(ctx, ownerID, id, newOwnerID)
+	if err == nil {
+		s.rec.record(ctx, id, ownerID, events.BoardUpdated, id, before, b)
+	}
+	return b, err
+}
+
+func (s *recordingBoardService) deleteActivities(ctx context.Context, boardID string) {
+	if err := s.rec.activities.DeleteByBoard(ctx, boardID); err != nil {
+		logging.FromContext(ctx).Warn("delete activities of deleted board", "board_id", boardID, "err", err)
+	}
+}
+
+// ========== 列表服务装饰器 ==========
+
+type recordingListService struct {
+	ListService
+	rec activityRecorder
+}
+
+// RecordListService 用看板动态装饰器包装列表服务
+func RecordListService(next ListService, activities repository.ActivityRepository) ListService {
+	return &recordingListService{ListService: next, rec: activityRecorder{activities: activities}}
+}
+
+// findList 修改之前的列表内容，ListService 没有查询单个列表的方法，从整个看板的列表里找
+func (s *recordingListService) findList(ctx context.Context, userID, boardID, listID string) (model.List, bool) {
+	lists, err := s.ListService.ListLists(ctx, userID, boardID)
+	if err != nil {
+		return model.List{}, false
+	}
+	for _, l := range lists {
+		if l.ID == listID {
+			return l, true
+		}
+	}
+	return model.List{}, false
+}
+
+func (s *recordingListService) CreateList(ctx context.Context, userID, boardID, title string) (model.List, error) {
+	l, err := s.ListService.CreateList(ctx, userID, boardID, title)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.ListCreated, l.ID, nil, l)
+	}
+	return l, err
+}
+
+func (s *recordingListService) RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error) {
+	before, ok := s.findList(ctx, userID, boardID, listID)
+	l, err := s.ListService.RenameList(ctx, userID, boardID, listID, title)
+	if err == nil && ok {
+		s.rec.record(ctx, boardID, userID, events.ListUpdated, listID, before, l)
+	}
+	return l, err
+}
+
+func (s *recordingListService) MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error) {
+	before, ok := s.findList(ctx, userID, boardID, listID)
+	lists, err := s.ListService.MoveList(ctx, userID, boardID, listID, position)
+	if err == nil && ok {
+		for _, l := range lists {
+			if l.ID == listID {
+				s.rec.record(ctx, boardID, userID, events.ListMoved, listID, before, l)
+				break
+			}
+		}
+	}
+	return lists, err
+}
+
+func (s *recordingListService) DeleteList(ctx context.Context, userID, boardID, listID string) error {
+	before, ok := s.findList(ctx, userID, boardID, listID)
+	err := s.ListService.DeleteList(ctx, userID, boardID, listID)
+	if err == nil && ok {
+		s.rec.record(ctx, boardID, userID, events.ListDeleted, listID, before, nil)
+	}
+	return err
+}
+
+// ========== 卡片服务装饰器 ==========
+
+type recordingCardService struct {
+	CardService
+	rec activityRecorder
+}
+
+// RecordCardService 用看板动态装饰器包装卡片服务
+func RecordCardService(next CardService, activities repository.ActivityRepository) CardService {
+	return &recordingCardService{CardService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingCardService) CreateCard(ctx context.Context, userID, boardID, listID string, in CardInput) (model.Card, error) {
+	c, err := s.CardService.CreateCard(ctx, userID, boardID, listID, in)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.CardCreated, c.ID, nil, c)
+	}
+	return c, err
+}
+
+func (s *recordingCardService) CreateCardFromTemplate(ctx context.Context, userID, boardID, listID, templateID string, in CardInput) (model.Card, error) {
+	c, err := s.CardService.CreateCardFromTemplate(ctx, userID, boardID, listID, templateID, in)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.CardCreated, c.ID, nil, c)
+	}
+	return c, err
+}
+
+func (s *recordingCardService) ReplaceCard(ctx context.Context, userID, boardID, listID, cardID string, in CardInput) (model.Card, error) {
+	before, berr := s.CardService.GetCard(ctx, userID, boardID, listID, cardID)
+	c, err := s.CardService.ReplaceCard(ctx, userID, boardID, listID, cardID, in)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.CardUpdated, cardID, before, c)
+	}
+	return c, err
+}
+
+func (s *recordingCardService) PatchCard(ctx context.Context, userID, boardID, listID, cardID string, p CardPatch) (model.Card, error) {
+	before, berr := s.CardService.GetCard(ctx, userID, boardID, listID, cardID)
+	c, err := s.CardService.PatchCard(ctx, userID, boardID, listID, cardID, p)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.CardUpdated, cardID, before, c)
+	}
+	return c, err
+}
+
+// MoveCard 跨看板移动时原看板和目标看板各记一条，两边的成员都能看到卡片去了哪里
+func (s *recordingCardService) MoveCard(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string, position int) ([]model.Card, error) {
+	before, berr := s.CardService.GetCard(ctx, userID, boardID, listID, cardID)
+	cards, err := s.CardService.MoveCard(ctx, userID, boardID, listID, cardID, toBoardID, toListID, position)
+	if err != nil || berr != nil {
+		return cards, err
+	}
+	if toBoardID == "" {
+		toBoardID = boardID
+	}
+	for _, c := range cards {
+		if c.ID != cardID {
+			continue
+		}
+		s.rec.record(ctx, toBoardID, userID, events.CardMoved, cardID, before, c)
+		if toBoardID != boardID {
+			s.rec.record(ctx, boardID, userID, events.CardMoved, cardID, before, c)
+		}
+		break
+	}
+	return cards, nil
+}
+
+func (s *recordingCardService) DeleteCard(ctx context.Context, userID, boardID, listID, cardID string) error {
+	before, berr := s.CardService.GetCard(ctx, userID, boardID, listID, cardID)
+	err := s.CardService.DeleteCard(ctx, userID, boardID, listID, cardID)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.CardDeleted, cardID, before, nil)
+	}
+	return err
+}
+
+// ========== 看板成员服务装饰器 ==========
+
+type recordingMemberService struct {
+	MemberService
+	rec activityRecorder
+}
+
+// RecordMemberService 用看板动态装饰器包装看板成员服务
+func RecordMemberService(next MemberService, activities repository.ActivityRepository) MemberService {
+	return &recordingMemberService{MemberService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingMemberService) AddMember(ctx context.Context, userID, boardID, email, role string) (model.BoardMember, error) {
+	m, err := s.MemberService.AddMember(ctx, userID, boardID, email, role)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.MemberAdded, m.UserID, nil, m)
+	}
+	return m, err
+}
+
+func (s *recordingMemberService) RemoveMember(ctx context.Context, userID, boardID, memberID string) error {
+	var before any
+	if members, err := s.MemberService.ListMembers(ctx, userID, boardID); err == nil {
+		for _, m := range members {
+			if m.UserID == memberID {
+				before = m
+				break
+			}
+		}
+	}
+	err := s.MemberService.RemoveMember(ctx, userID, boardID, memberID)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.MemberRemoved, memberID, before, nil)
+	}
+	return err
+}
+
+// ========== 检查清单服务装饰器 ==========
+
+type recordingChecklistService struct {
+	ChecklistService
+	rec activityRecorder
+}
+
+// RecordChecklistService 用看板动态装饰器包装检查清单服务
+// 条目的修改记成清单的修改（checklist.updated），Before、After 是修改前后的整个清单
+func RecordChecklistService(next ChecklistService, activities repository.ActivityRepository) ChecklistService {
+	return &recordingChecklistService{ChecklistService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingChecklistService) CreateChecklist(ctx context.Context, userID, boardID, listID, cardID, title string) (model.Checklist, error) {
+	c, err := s.ChecklistService.CreateChecklist(ctx, userID, boardID, listID, cardID, title)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistCreated, c.ID, nil, c)
+	}
+	return c, err
+}
+
+func (s *recordingChecklistService) RenameChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, title string) (model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	c, err := s.ChecklistService.RenameChecklist(ctx, userID, boardID, listID, cardID, checklistID, title)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistUpdated, checklistID, before, c)
+	}
+	return c, err
+}
+
+func (s *recordingChecklistService) MoveChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string, position int) ([]model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	lists, err := s.ChecklistService.MoveChecklist(ctx, userID, boardID, listID, cardID, checklistID, position)
+	if err == nil && berr == nil {
+		for _, c := range lists {
+			if c.ID == checklistID {
+				s.rec.record(ctx, boardID, userID, events.ChecklistMoved, checklistID, before, c)
+				break
+			}
+		}
+	}
+	return lists, err
+}
+
+func (s *recordingChecklistService) DeleteChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) error {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	err := s.ChecklistService.DeleteChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistDeleted, checklistID, before, nil)
+	}
+	return err
+}
+
+func (s *recordingChecklistService) AddItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, text string) (model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	c, err := s.ChecklistService.AddItem(ctx, userID, boardID, listID, cardID, checklistID, text)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistUpdated, checklistID, before, c)
+	}
+	return c, err
+}
+
+func (s *recordingChecklistService) UpdateItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, p ChecklistItemPatch) (model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	c, err := s.ChecklistService.UpdateItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, p)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistUpdated, checklistID, before, c)
+	}
+	return c, err
+}
+
+func (s *recordingChecklistService) MoveItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, position int) (model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	c, err := s.ChecklistService.MoveItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, position)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistUpdated, checklistID, before, c)
+	}
+	return c, err
+}
+
+func (s *recordingChecklistService) DeleteItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string) (model.Checklist, error) {
+	before, berr := s.ChecklistService.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	c, err := s.ChecklistService.DeleteItem(ctx, userID, boardID, listID, cardID, checklistID, itemID)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.ChecklistUpdated, checklistID, before, c)
+	}
+	return c, err
+}
+
+// ========== webhook 服务装饰器 ==========
+
+type recordingWebhookService struct {
+	WebhookService
+	rec activityRecorder
+}
+
+// RecordWebhookService 用看板动态装饰器包装 webhook 服务
+// 记录里的 webhook 不带签名密钥（model.Webhook.Secret 不输出到 JSON）
+func RecordWebhookService(next WebhookService, activities repository.ActivityRepository) WebhookService {
+	return &recordingWebhookService{WebhookService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingWebhookService) CreateWebhook(ctx context.Context, userID, boardID, rawURL string, types []string) (model.Webhook, string, error) {
+	w, secret, err := s.WebhookService.CreateWebhook(ctx, userID, boardID, rawURL, types)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.WebhookCreated, w.ID, nil, w)
+	}
+	return w, secret, err
+}
+
+func (s *recordingWebhookService) DeleteWebhook(ctx context.Context, userID, boardID, webhookID string) error {
+	var before any
+	if hooks, err := s.WebhookService.ListWebhooks(ctx, userID, boardID); err == nil {
+		for _, w := range hooks {
+			if w.ID == webhookID {
+				before = w
+				break
+			}
+		}
+	}
+	err := s.WebhookService.DeleteWebhook(ctx, userID, boardID, webhookID)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.WebhookDeleted, webhookID, before, nil)
+	}
+	return err
+}
+
+// ========== 卡片镜像服务装饰器 ==========
+
+type recordingCardLinkService struct {
+	CardLinkService
+	rec activityRecorder
+}
+
+// RecordCardLinkService 用看板动态装饰器包装卡片镜像服务
+// 创建镜像在目标看板上记一条 card.created；之后 MirrorSync 同步的修改不记录
+func RecordCardLinkService(next CardLinkService, activities repository.ActivityRepository) CardLinkService {
+	return &recordingCardLinkService{CardLinkService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingCardLinkService) CreateMirror(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string) (model.Card, error) {
+	c, err := s.CardLinkService.CreateMirror(ctx, userID, boardID, listID, cardID, toBoardID, toListID)
+	if err == nil {
+		s.rec.record(ctx, toBoardID, userID, events.CardCreated, c.ID, nil, c)
+	}
+	return c, err
+}
+
+// ========== 卡片模板服务装饰器 ==========
+
+type recordingCardTemplateService struct {
+	CardTemplateService
+	rec activityRecorder
+}
+
+// RecordCardTemplateService 用看板动态装饰器包装卡片模板服务
+func RecordCardTemplateService(next CardTemplateService, activities repository.ActivityRepository) CardTemplateService {
+	return &recordingCardTemplateService{CardTemplateService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingCardTemplateService) CreateTemplate(ctx context.Context, userID, boardID string, in CardTemplateInput) (model.CardTemplate, error) {
+	t, err := s.CardTemplateService.CreateTemplate(ctx, userID, boardID, in)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.CardTemplateCreated, t.ID, nil, t)
+	}
+	return t, err
+}
+
+func (s *recordingCardTemplateService) ReplaceTemplate(ctx context.Context, userID, boardID, templateID string, in CardTemplateInput) (model.CardTemplate, error) {
+	before, berr := s.CardTemplateService.GetTemplate(ctx, userID, boardID, templateID)
+	t, err := s.CardTemplateService.ReplaceTemplate(ctx, userID, boardID, templateID, in)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.CardTemplateUpdated, templateID, before, t)
+	}
+	return t, err
+}
+
+func (s *recordingCardTemplateService) DeleteTemplate(ctx context.Context, userID, boardID, templateID string) error {
+	before, berr := s.CardTemplateService.GetTemplate(ctx, userID, boardID, templateID)
+	err := s.CardTemplateService.DeleteTemplate(ctx, userID, boardID, templateID)
+	if err == nil && berr == nil {
+		s.rec.record(ctx, boardID, userID, events.CardTemplateDeleted, templateID, before, nil)
+	}
+	return err
+}
+
+// ========== 嵌入令牌服务装饰器 ==========
+
+type recordingEmbedService struct {
+	EmbedService
+	rec activityRecorder
+}
+
+// RecordEmbedService 用看板动态装饰器包装看板嵌入服务
+// 只记令牌的服务端记录，签名后的令牌字符串不写进动态
+func RecordEmbedService(next EmbedService, activities repository.ActivityRepository) EmbedService {
+	return &recordingEmbedService{EmbedService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingEmbedService) CreateToken(ctx context.Context, userID, boardID string, ttl time.Duration) (model.EmbedToken, string, error) {
+	t, signed, err := s.EmbedService.CreateToken(ctx, userID, boardID, ttl)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.EmbedTokenCreated, t.ID, nil, t)
+	}
+	return t, signed, err
+}
+
+func (s *recordingEmbedService) RevokeToken(ctx context.Context, userID, boardID, tokenID string) (model.EmbedToken, error) {
+	var before any
+	if tokens, err := s.EmbedService.ListTokens(ctx, userID, boardID); err == nil {
+		for _, t := range tokens {
+			if t.ID == tokenID {
+				before = t
+				break
+			}
+		}
+	}
+	t, err := s.EmbedService.RevokeToken(ctx, userID, boardID, tokenID)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.EmbedTokenRevoked, tokenID, before, t)
+	}
+	return t, err
+}
+
+// ========== 附件服务装饰器 ==========
+
+type recordingAttachmentService struct {
+	AttachmentService
+	rec activityRecorder
+}
+
+// RecordAttachmentService 用看板动态装饰器包装附件服务
+// 记录里只有附件的元数据（文件名、大小、哈希），不包括文件内容
+func RecordAttachmentService(next AttachmentService, activities repository.ActivityRepository) AttachmentService {
+	return &recordingAttachmentService{AttachmentService: next, rec: activityRecorder{activities: activities}}
+}
+
+func (s *recordingAttachmentService) UploadAttachment(ctx context.Context, userID, boardID, listID, cardID string, in AttachmentUpload) (model.Attachment, error) {
+	a, err := s.AttachmentService.UploadAttachment(ctx, userID, boardID, listID, cardID, in)
+	if err == nil {
+		s.rec.record(ctx, boardID, userID, events.AttachmentCreated, a.ID, nil, a)
+	}
+	return a, err
+}
+
+func (s *recordingAttachmentService) AppendUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string, offset int64, body io.Reader) (model.ResumableUpload, *model.Attachment, error) {
+	u, a, err := s.AttachmentService.AppendUpload(ctx, userID, boardID, listID, cardID, uploadID, offset, body)
+	if err == nil && a != nil {
+		s.rec.record(ctx, boardID, userID, events.AttachmentCreated, a.ID, nil, *a)
+	}
+	return u, a, err
+}
+
+// DeleteAttachment 附件服务没有单独查询一个附件的方法，从卡片的附件列表里找出删除前的内容
+func (s *recordingAttachmentService) DeleteAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) error {
+	var before *model.Attachment
+	if items, err := s.AttachmentService.ListAttachments(ctx, userID, boardID, listID, cardID); err == nil {
+		for i := range items {
+			if items[i].ID == attachmentID {
+				before = &items[i]
+				break
+			}
+		}
+	}
+	err := s.AttachmentService.DeleteAttachment(ctx, userID, boardID, listID, cardID, attachmentID)
+	if err == nil && before != nil {
+		s.rec.record(ctx, boardID, userID, events.AttachmentDeleted, attachmentID, before, nil)
+	}
+	return err
+}
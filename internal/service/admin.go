@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+	"time"
+)
+
+// 运维接口的分页和统计范围
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 100
+	defaultStatsDays     = 30
+	maxStatsDays         = 366
+)
+
+// UserQuery 运维接口查找用户的条件，零值表示不限制
+type UserQuery struct {
+	// Email 邮箱包含这个字符串，不区分大小写
+	Email string
+
+	// Disabled 不为 nil 时只列出停用（true）或没停用（false）的用户
+	Disabled *bool
+
+	// Limit 每页条数，<= 0 时使用默认值；Offset 跳过前面多少条
+	Limit  int
+	Offset int
+}
+
+// UserPage 一页用户
+type UserPage struct {
+	Items []model.User `json:"items"`
+
+	// Total 符合条件的用户总数，不只是这一页
+	Total int `json:"total"`
+}
+
+// BoardQuery 运维接口查找看板的条件，零值表示不限制
+type BoardQuery struct {
+	// OwnerID 只列出这个用户的看板
+	OwnerID string
+
+	// Title 标题包含这个字符串，不区分大小写
+	Title string
+
+	// Limit 每页条数，<= 0 时使用默认值；Offset 跳过前面多少条
+	Limit  int
+	Offset int
+}
+
+// BoardPage 一页看板
+type BoardPage struct {
+	Items []model.Board `json:"items"`
+
+	// Total 符合条件的看板总数，不只是这一页
+	Total int `json:"total"`
+}
+
+// AdminService 运维接口（/admin/v1）的业务逻辑
+// 所有方法都不检查所有者和成员身份，权限检查由路由上的 RequireRole("admin") 负责；
+// 删除任意看板复用 BoardService.AdminDeleteBoard
+type AdminService interface {
+	// SearchUsers 按注册时间分页查找所有用户
+	SearchUsers(ctx context.Context, q UserQuery) (UserPage, error)
+
+	// SetUserDisabled 停用或恢复用户，adminID 是操作的管理员，不能停用自己
+	// 停用后用户不能再登录，所有刷新令牌立即吊销；已经签发的访问令牌要等过期才失效
+	SetUserDisabled(ctx context.Context, adminID, userID string, disabled bool) (model.User, error)
+
+	// SearchBoards 按创建时间从新到旧分页查找所有用户的看板
+	SearchBoards(ctx context.Context, q BoardQuery) (BoardPage, error)
+
+	// GetBoard 查看任意看板的概况：看板本身、所有者和列表、卡片、成员的数量
+	GetBoard(ctx context.Context, id string) (model.BoardOverview, error)
+
+	// Stats 用户数、看板数和截至 now 最近 days 天（UTC，包括今天）每天的注册数；days <= 0 时使用默认值
+	Stats(ctx context.Context, days int, now time.Time) (model.AdminStats, error)
+}
+
+// adminService 运维接口的具体实现
+type adminService struct {
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	boards        repository.BoardRepository
+	lists         repository.ListRepository
+	cards         repository.CardRepository
+	members       repository.MemberRepository
+}
+
+// NewAdminService 创建运维接口服务实例
+func NewAdminService(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, boards repository.BoardRepository, lists repository.ListRepository, cards repository.CardRepository, members repository.MemberRepository) AdminService {
+	return &adminService{users: users, refreshTokens: refreshTokens, boards: boards, lists: lists, cards: cards, members: members}
+}
+
+// pageSize 检查分页参数，limit <= 0 时使用默认值
+func pageSize(limit, offset int) (int, error) {
+	if offset < 0 {
+		return 0, invalidInput("offset must not be negative")
+	}
+	if limit <= 0 {
+		return defaultAdminPageSize, nil
+	}
+	return min(limit, maxAdminPageSize), nil
+}
+
+// SearchUsers 查找用户
+func (s *adminService) SearchUsers(ctx context.Context, q UserQuery) (UserPage, error) {
+	limit, err := pageSize(q.Limit, q.Offset)
+	if err != nil {
+		return UserPage{}, err
+	}
+	users, total, err := s.users.Search(ctx, repository.UserFilter{
+		// 邮箱保存时转成了小写，查询条件也转成小写
+		Email:    strings.TrimSpace(strings.ToLower(q.Email)),
+		Disabled: q.Disabled,
+		Limit:    limit,
+		Offset:   q.Offset,
+	})
+	if err != nil {
+		return UserPage{}, err
+	}
+	return UserPage{Items: users, Total: total}, nil
+}
+
+// SetUserDisabled 停用或恢复用户
+// 已经是目标状态时不修改，停用时间保留第一次停用的时间
+func (s *adminService) SetUserDisabled(ctx context.Context, adminID, userID string, disabled bool) (model.User, error) {
+	if disabled && userID == adminID {
+		return model.User{}, invalidInput("cannot disable your own account")
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	if (u.DisabledAt != nil) == disabled {
+		return u, nil
+	}
+
+	var at *time.Time
+	if disabled {
+		now := time.Now()
+		at = &now
+	}
+	u, err = s.users.SetDisabled(ctx, userID, at)
+	if err != nil {
+		return model.User{}, err
+	}
+	// 刷新令牌全部吊销，所有设备上的登录最多在访问令牌过期后失效；
+	// 即使这一步失败，Refresh 也会拒绝停用用户的令牌
+	if disabled {
+		if err := s.refreshTokens.RevokeUser(ctx, userID); err != nil {
+			return model.User{}, err
+		}
+	}
+	logging.FromContext(ctx).Warn("user disabled changed", "target_user_id", userID, "disabled", disabled)
+	return u, nil
+}
+
+// SearchBoards 查找看板
+func (s *adminService) SearchBoards(ctx context.Context, q BoardQuery) (BoardPage, error) {
+	limit, err := pageSize(q.Limit, q.Offset)
+	if err != nil {
+		return BoardPage{}, err
+	}
+	boards, total, err := s.boards.Search(ctx, repository.BoardFilter{OwnerID: q.OwnerID, Title: q.Title, Limit: limit, Offset: q.Offset})
+	if err != nil {
+		return BoardPage{}, err
+	}
+	return BoardPage{Items: boards, Total: total}, nil
+}
+
+// GetBoard 查看看板概况
+func (s *adminService) GetBoard(ctx context.Context, id string) (model.BoardOverview, error) {
+	boards, err := s.boards.ListByIDs(ctx, []string{id})
+	if err != nil {
+		return model.BoardOverview{}, err
+	}
+	if len(boards) == 0 {
+		return model.BoardOverview{}, repository.ErrNotFound
+	}
+	ov := model.BoardOverview{Board: boards[0]}
+
+	owner, err := s.users.GetByID(ctx, ov.Board.OwnerID)
+	switch {
+	case err == nil:
+		ov.Owner = &owner
+	case !errors.Is(err, repository.ErrNotFound):
+		return model.BoardOverview{}, err
+	}
+	members, err := s.members.ListByBoard(ctx, id)
+	if err != nil {
+		return model.BoardOverview{}, err
+	}
+	lists, err := s.lists.ListByBoard(ctx, id)
+	if err != nil {
+		return model.BoardOverview{}, err
+	}
+	cards, err := s.cards.CountByBoard(ctx, id)
+	if err != nil {
+		return model.BoardOverview{}, err
+	}
+	ov.Members, ov.Lists, ov.Cards = len(members), len(lists), cards
+	return ov, nil
+}
+
+// Stats 汇总统计
+func (s *adminService) Stats(ctx context.Context, days int, now time.Time) (model.AdminStats, error) {
+	if days <= 0 {
+		days = defaultStatsDays
+	}
+	if days > maxStatsDays {
+		return model.AdminStats{}, invalidInput("days must be at most 366")
+	}
+	var st model.AdminStats
+	var err error
+	if st.Users, st.DisabledUsers, err = s.users.Count(ctx); err != nil {
+		return model.AdminStats{}, err
+	}
+	if st.Boards, err = s.boards.Count(ctx); err != nil {
+		return model.AdminStats{}, err
+	}
+
+	// 从 days-1 天前的 0 点（UTC）到明天 0 点
+	end := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -days)
+	counts, err := s.users.CountSignups(ctx, start, end)
+	if err != nil {
+		return model.AdminStats{}, err
+	}
+	byDay := make(map[string]int, len(counts))
+	for _, c := range counts {
+		byDay[c.Day] = c.Count
+	}
+	st.Signups = make([]model.DayCount, 0, days)
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		day := d.Format(model.DayLayout)
+		st.Signups = append(st.Signups, model.DayCount{Day: day, Count: byDay[day]})
+	}
+	return st, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestSetUserDisabled 停用的用户不能登录，之前的刷新令牌和访问令牌也不能再用；恢复之后可以重新登录
+// 管理员不能停用自己
+func TestSetUserDisabled(t *testing.T) {
+	ctx := context.Background()
+	users, refreshTokens := repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo()
+	auth := NewAuthService(users, refreshTokens, repository.NewMemPasswordResetRepo(), jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+	admin := NewAdminService(users, refreshTokens, repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo(), repository.NewMemMemberRepo())
+
+	u, pair, err := auth.Register(ctx, "user@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.SetUserDisabled(ctx, u.ID, u.ID, true); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("disable yourself: err = %v, want ErrInvalidInput", err)
+	}
+
+	disabled, err := admin.SetUserDisabled(ctx, "admin", u.ID, true)
+	if err != nil || disabled.DisabledAt == nil {
+		t.Fatalf("disable = %+v, %v", disabled, err)
+	}
+	if _, _, err := auth.Login(ctx, "user@example.com", "password123"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("login while disabled: err = %v, want ErrAccountDisabled", err)
+	}
+	// 密码错误时仍然是普通的登录失败，不透露账号被停用了
+	if _, _, err := auth.Login(ctx, "user@example.com", "wrong-password"); !errors.Is(err, errInvalidCredentials) {
+		t.Fatalf("wrong password while disabled: err = %v", err)
+	}
+	if _, err := auth.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh while disabled: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := auth.CheckSession(ctx, u.ID); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("session while disabled: err = %v, want ErrSessionRevoked", err)
+	}
+	page, err := admin.SearchUsers(ctx, UserQuery{Email: "USER@", Disabled: &[]bool{true}[0]})
+	if err != nil || page.Total != 1 || page.Items[0].ID != u.ID {
+		t.Fatalf("search disabled users = %+v, %v", page, err)
+	}
+
+	if _, err := admin.SetUserDisabled(ctx, "admin", u.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := auth.Login(ctx, "user@example.com", "password123"); err != nil {
+		t.Fatalf("login after enable: %v", err)
+	}
+	if _, err := auth.CheckSession(ctx, u.ID); err != nil {
+		t.Fatalf("session after enable: %v", err)
+	}
+	if _, err := auth.CheckSession(ctx, "no-such-user"); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("session of a missing user: err = %v, want ErrSessionRevoked", err)
+	}
+}
+
+// TestCheckSessionRole 角色按数据库里当前的值返回，不管访问令牌是什么时候签发的
+func TestCheckSessionRole(t *testing.T) {
+	ctx := context.Background()
+	users, refreshTokens := repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo()
+	auth := NewAuthService(users, refreshTokens, repository.NewMemPasswordResetRepo(), jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+
+	u, _, err := auth.Register(ctx, "admin@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.SetRole(ctx, u.ID, model.UserRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := auth.CheckSession(ctx, u.ID); err != nil || got.Role != model.UserRoleAdmin {
+		t.Fatalf("session = %+v, %v, want admin", got, err)
+	}
+	if _, err := auth.SetRole(ctx, u.ID, model.UserRoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := auth.CheckSession(ctx, u.ID); err != nil || got.Role != model.UserRoleUser {
+		t.Fatalf("session after demotion = %+v, %v, want user", got, err)
+	}
+}
+
+// TestAdminStats 注册数按天列出最近 days 天，没有人注册的日子是 0
+func TestAdminStats(t *testing.T) {
+	ctx := context.Background()
+	users, boards := repository.NewMemUserRepo(), repository.NewMemBoardRepo()
+	admin := NewAdminService(users, repository.NewMemRefreshTokenRepo(), boards, repository.NewMemListRepo(), repository.NewMemCardRepo(), repository.NewMemMemberRepo())
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := users.Create(ctx, email, "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, _ := users.GetByEmail(ctx, "a@example.com")
+	if _, err := boards.Create(ctx, u.ID, "Roadmap", "roadmap"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	st, err := admin.Stats(ctx, 3, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := now.UTC().Format(model.DayLayout)
+	if st.Users != 2 || st.DisabledUsers != 0 || st.Boards != 1 || len(st.Signups) != 3 {
+		t.Fatalf("stats = %+v", st)
+	}
+	if last := st.Signups[2]; last.Day != today || last.Count != 2 || st.Signups[0].Count != 0 {
+		t.Fatalf("signups = %+v, want 2 today", st.Signups)
+	}
+	if _, err := admin.Stats(ctx, 400, now); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("400 days: err = %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kanban_api/internal/events"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/mail"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+	"time"
+)
+
+// 管理员查看异常列表的条数
+const (
+	defaultAnomalyLimit = 50
+	maxAnomalyLimit     = 500
+)
+
+// AnomalyThresholds 异常检测的阈值，都是一个时间窗口内的次数，0 表示不检测这一类
+type AnomalyThresholds struct {
+	// ActivitySpike 一个看板上所有人的操作次数
+	ActivitySpike int
+
+	// MassDeletion 一个人在一个看板上删除的次数（列表、卡片、清单、附件）
+	MassDeletion int
+
+	// LoginFailures 一个邮箱或者一个 IP 登录失败的次数
+	LoginFailures int
+}
+
+// AnomalyService 异常检测结果的查询接口（管理员接口）
+type AnomalyService interface {
+	// ListAnomalies 从新到旧列出最近发现的异常；limit <= 0 时使用默认值
+	ListAnomalies(ctx context.Context, limit int) ([]model.Anomaly, error)
+}
+
+// AnomalyDetector 简单的异常检测
+// 把时间切成固定长度的窗口，每个窗口结束后统计一次，次数超过阈值就算异常：
+// - 看板操作激增：看板动态里一个看板的记录数
+// - 大量删除：看板动态里一个人在一个看板上的 *.deleted 记录数
+// - 登录失败：同一个邮箱、同一个 IP 的失败次数（见 RecordLoginAttempts）
+//
+// 发现的异常保存下来给管理员查看，打一条 warn 日志，给所有管理员发邮件；
+// 看板上的异常还在看板动态里记一条 anomaly.detected，和看板的其它操作记录放在一起
+//
+// 窗口和墙上时间对齐（例如窗口是 10 分钟时就是 00:00-00:10、00:10-00:20……），
+// 同一个窗口检测多次（多个实例、重启）只记一次，见 AnomalyRepository.Create
+type AnomalyDetector struct {
+	activities repository.ActivityRepository
+	logins     repository.LoginAttemptRepository
+	anomalies  repository.AnomalyRepository
+	users      repository.UserRepository
+	sender     mail.Sender
+	rec        activityRecorder
+
+	window     time.Duration
+	thresholds AnomalyThresholds
+}
+
+// NewAnomalyDetector 创建异常检测实例，window 是统计窗口的长度
+func NewAnomalyDetector(activities repository.ActivityRepository, logins repository.LoginAttemptRepository, anomalies repository.AnomalyRepository, users repository.UserRepository, sender mail.Sender, window time.Duration, thresholds AnomalyThresholds) *AnomalyDetector {
+	return &AnomalyDetector{
+		activities: activities,
+		logins:     logins,
+		anomalies:  anomalies,
+		users:      users,
+		sender:     sender,
+		rec:        activityRecorder{activities: activities},
+		window:     window,
+		thresholds: thresholds,
+	}
+}
+
+// ListAnomalies 列出最近的异常
+func (d *AnomalyDetector) ListAnomalies(ctx context.Context, limit int) ([]model.Anomaly, error) {
+	if limit <= 0 {
+		limit = defaultAnomalyLimit
+	}
+	if limit > maxAnomalyLimit {
+		limit = maxAnomalyLimit
+	}
+	return d.anomalies.List(ctx, limit)
+}
+
+// Detect 检测 now 之前最后一个完整的窗口，返回这一次新发现的异常（之前检测过、已经记下的不算）
+func (d *AnomalyDetector) Detect(ctx context.Context, now time.Time) ([]model.Anomaly, error) {
+	end := now.Truncate(d.window)
+	start := end.Add(-d.window)
+	var found []model.Anomaly
+
+	counts, err := d.activities.CountByActor(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	perBoard := make(map[string]int)
+	var boards []string
+	for _, c := range counts {
+		if _, ok := perBoard[c.BoardID]; !ok {
+			boards = append(boards, c.BoardID)
+		}
+		perBoard[c.BoardID] += c.Total
+		if over(c.Deletes, d.thresholds.MassDeletion) {
+			found = append(found, model.Anomaly{Kind: model.AnomalyMassDeletion, BoardID: c.BoardID, Subject: c.ActorID, Count: c.Deletes, Threshold: d.thresholds.MassDeletion})
+		}
+	}
+	for _, id := range boards {
+		if over(perBoard[id], d.thresholds.ActivitySpike) {
+			found = append(found, model.Anomaly{Kind: model.AnomalyActivitySpike, BoardID: id, Count: perBoard[id], Threshold: d.thresholds.ActivitySpike})
+		}
+	}
+
+	failures, err := d.logins.CountFailures(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range failures {
+		if over(f.Failures, d.thresholds.LoginFailures) {
+			found = append(found, model.Anomaly{Kind: model.AnomalyLoginFailures, Subject: f.Key, Count: f.Failures, Threshold: d.thresholds.LoginFailures})
+		}
+	}
+
+	var created []model.Anomaly
+	for _, a := range found {
+		a.WindowStart, a.WindowEnd = start, end
+		a, ok, err := d.anomalies.Create(ctx, a)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, a)
+			d.flag(ctx, a)
+		}
+	}
+	if len(created) > 0 {
+		d.notify(ctx, created)
+	}
+	return created, nil
+}
+
+// over 次数是否达到阈值，阈值为 0 表示不检测
+func over(n, threshold int) bool {
+	return threshold > 0 && n >= threshold
+}
+
+// flag 打日志，看板上的异常在看板动态里记一条
+func (d *AnomalyDetector) flag(ctx context.Context, a model.Anomaly) {
+	logging.FromContext(ctx).Warn("anomaly detected", "kind", a.Kind, "board_id", a.BoardID, "subject", a.Subject, "count", a.Count, "threshold", a.Threshold)
+	if a.BoardID != "" {
+		d.rec.record(ctx, a.BoardID, "", events.AnomalyDetected, a.ID, nil, a)
+	}
+}
+
+// notify 给所有管理员发一封邮件，列出这一轮发现的异常
+// 发送失败只记日志，异常已经保存，管理员在管理接口里也能看到
+func (d *AnomalyDetector) notify(ctx context.Context, found []model.Anomaly) {
+	users, err := d.users.List(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("list admins for anomaly alert", "err", err)
+		return
+	}
+	var body strings.Builder
+	body.WriteString("The anomaly detector found:\n\n")
+	for _, a := range found {
+		fmt.Fprintf(&body, "- %s: %d in %s - %s (threshold %d)", a.Kind, a.Count,
+			a.WindowStart.UTC().Format(time.RFC3339), a.WindowEnd.UTC().Format(time.RFC3339), a.Threshold)
+		if a.BoardID != "" {
+			fmt.Fprintf(&body, ", board %s", a.BoardID)
+		}
+		if a.Subject != "" {
+			fmt.Fprintf(&body, ", %s", a.Subject)
+		}
+		body.WriteString("\n")
+	}
+	body.WriteString("\nDetails: GET /api/v1/admin/anomalies\n")
+
+	subject := fmt.Sprintf("[kanban] %d anomalies detected", len(found))
+	if len(found) == 1 {
+		subject = "[kanban] anomaly detected: " + found[0].Kind
+	}
+	for _, u := range users {
+		if u.Role != model.UserRoleAdmin {
+			continue
+		}
+		if err := d.sender.Send(ctx, mail.Message{To: u.Email, Subject: subject, Body: body.String()}); err != nil {
+			logging.FromContext(ctx).Warn("send anomaly alert", "to", u.ID, "err", err)
+		}
+	}
+}
+
+// Run 立即检测上一个窗口，之后每个窗口结束时检测一次，直到 ctx 被取消
+// 顺带删除一天以前的登录尝试记录
+func (d *AnomalyDetector) Run(ctx context.Context) {
+	for {
+		now := time.Now()
+		if _, err := d.Detect(ctx, now); err != nil {
+			logging.FromContext(ctx).Warn("detect anomalies", "err", err)
+		}
+		if _, err := d.logins.DeleteBefore(ctx, now.Add(-24*time.Hour-d.window)); err != nil {
+			logging.FromContext(ctx).Warn("purge login attempts", "err", err)
+		}
+		// 窗口结束后稍等一下再统计，让窗口最后时刻的写入落盘
+		timer := time.NewTimer(time.Until(now.Truncate(d.window).Add(d.window + time.Second)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// ========== 登录尝试记录装饰器 ==========
+
+// clientIPKey 请求 ctx 里客户端 IP 的 key
+type clientIPKey struct{}
+
+// WithClientIP 把客户端 IP 放进 ctx，登录尝试记录用它按 IP 统计失败次数
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// clientIP 取出 WithClientIP 放进去的 IP，没有时为空
+func clientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+type recordingAuthService struct {
+	AuthService
+	attempts repository.LoginAttemptRepository
+}
+
+// RecordLoginAttempts 用登录尝试记录装饰器包装认证服务，每次登录记下邮箱、IP 和成败，给异常检测统计
+// 只记密码错误、用户不存在这类失败；数据库出错之类的失败和用户无关，不记
+func RecordLoginAttempts(next AuthService, attempts repository.LoginAttemptRepository) AuthService {
+	return &recordingAuthService{AuthService: next, attempts: attempts}
+}
+
+func (s *recordingAuthService) Login(ctx context.Context, email, password string) (model.User, TokenPair, error) {
+	u, pair, err := s.AuthService.Login(ctx, email, password)
+	if err == nil || err == errInvalidCredentials {
+		a := model.LoginAttempt{Email: strings.TrimSpace(strings.ToLower(email)), IP: clientIP(ctx), Success: err == nil}
+		if rerr := s.attempts.Record(ctx, a); rerr != nil {
+			logging.FromContext(ctx).Warn("record login attempt", "err", rerr)
+		}
+	}
+	return u, pair, err
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/events"
+	"kanban_api/internal/mail"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender 把邮件记下来，不真正发送
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg mail.Message) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, msg)
+	s.mu.Unlock()
+	return nil
+}
+
+// TestAnomalyDetector 超过阈值的大量删除、操作激增和登录失败各记一条，
+// 看板上的异常记进看板动态，管理员收到一封邮件；同一个窗口再检测不重复记录
+func TestAnomalyDetector(t *testing.T) {
+	ctx := context.Background()
+	activities := repository.NewMemActivityRepo()
+	logins := repository.NewMemLoginAttemptRepo()
+	anomalies := repository.NewMemAnomalyRepo()
+	users := repository.NewMemUserRepo()
+	sender := &fakeSender{}
+	admin, err := users.Create(ctx, "admin@example.com", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.SetRole(ctx, admin.ID, model.UserRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Create(ctx, "user@example.com", "hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewAnomalyDetector(activities, logins, anomalies, users, sender, time.Hour, AnomalyThresholds{ActivitySpike: 5, MassDeletion: 3, LoginFailures: 3})
+	// b1 上 u1 删了 3 张卡片，u2 改了 2 次：删除和总数都到了阈值；b2 只有一次操作
+	for _, a := range []model.Activity{
+		{BoardID: "b1", ActorID: "u1", Action: events.CardDeleted},
+		{BoardID: "b1", ActorID: "u1", Action: events.CardDeleted},
+		{BoardID: "b1", ActorID: "u1", Action: events.CardDeleted},
+		{BoardID: "b1", ActorID: "u2", Action: events.CardUpdated},
+		{BoardID: "b1", ActorID: "u2", Action: events.CardDeleted},
+		{BoardID: "b2", ActorID: "u1", Action: events.CardDeleted},
+	} {
+		if _, err := activities.Create(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 同一个 IP 试了三个邮箱：按 IP 到了阈值，按邮箱都没有
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if err := logins.Record(ctx, model.LoginAttempt{Email: email, IP: "203.0.113.9"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 当前时刻所在的窗口结束之后检测
+	later := time.Now().Add(time.Hour)
+	found, err := d.Detect(ctx, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]model.Anomaly{}
+	for _, a := range found {
+		kinds[a.Kind] = a
+	}
+	if len(found) != 3 {
+		t.Fatalf("found %+v, want 3 anomalies", found)
+	}
+	if a := kinds[model.AnomalyMassDeletion]; a.BoardID != "b1" || a.Subject != "u1" || a.Count != 3 {
+		t.Fatalf("mass deletion = %+v", a)
+	}
+	if a := kinds[model.AnomalyActivitySpike]; a.BoardID != "b1" || a.Count != 5 {
+		t.Fatalf("activity spike = %+v", a)
+	}
+	if a := kinds[model.AnomalyLoginFailures]; a.Subject != "ip:203.0.113.9" || a.Count != 3 {
+		t.Fatalf("login failures = %+v", a)
+	}
+
+	flagged, err := activities.ListByBoard(ctx, "b1", 0, 10)
+	if err != nil || flagged[0].Action != events.AnomalyDetected || flagged[1].Action != events.AnomalyDetected {
+		t.Fatalf("board activities = %+v, %v, want anomaly.detected on top", flagged, err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "admin@example.com" {
+		t.Fatalf("sent = %+v, want one mail to the admin", sender.sent)
+	}
+
+	// 同一个窗口再检测一次（另一个实例、重启）不重复记录、不重复发邮件
+	if again, err := d.Detect(ctx, later); err != nil || len(again) != 0 {
+		t.Fatalf("detect again = %+v, %v, want nothing new", again, err)
+	}
+	if list, err := d.ListAnomalies(ctx, 0); err != nil || len(list) != 3 || len(sender.sent) != 1 {
+		t.Fatalf("list = %d, %v, sent %d", len(list), err, len(sender.sent))
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix 密钥的固定前缀，在日志、代码仓库里泄露时一眼能认出来
+	apiKeyPrefix = "kb_"
+
+	// maxAPIKeys 每个用户最多有多少个没吊销的密钥
+	maxAPIKeys = 20
+
+	// maxAPIKeyTTL 密钥的有效期最长一年，不设置有效期时不过期
+	maxAPIKeyTTL = 365 * 24 * time.Hour
+
+	// maxAPIKeyName 密钥名字的最大长度（字符数）
+	maxAPIKeyName = 100
+
+	// apiKeyTouchInterval 最近使用时间最多隔这么久写一次，不是每个请求都写数据库
+	apiKeyTouchInterval = time.Minute
+)
+
+// ErrInvalidAPIKey API 密钥不存在、已吊销、已过期，或者所属的用户不能登录
+// 几种情况返回同一个错误，不泄露密钥的状态
+var ErrInvalidAPIKey = newError(ErrUnauthorized, "invalid api key")
+
+// APIKeyInput 创建 API 密钥的参数
+type APIKeyInput struct {
+	// Name 密钥的名字，必填
+	Name string
+
+	// Scopes 权限范围，model.APIKeyScopeRead / model.APIKeyScopeWrite，至少一个
+	Scopes []string
+
+	// TTL 有效期，0 表示不过期
+	TTL time.Duration
+}
+
+// APIKeyService 个人 API 密钥服务接口
+type APIKeyService interface {
+	// CreateKey 创建密钥，返回密钥记录和密钥本身；密钥只在这里返回一次，之后谁也拿不到
+	CreateKey(ctx context.Context, userID string, in APIKeyInput) (model.APIKey, string, error)
+
+	// ListKeys 列出用户没有吊销的密钥（不包含密钥本身），新的在前
+	ListKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+
+	// RevokeKey 吊销用户的某个密钥，立即生效
+	RevokeKey(ctx context.Context, userID, id string) error
+
+	// Authenticate 校验请求带的密钥，返回密钥记录和它代表的用户，认证中间件使用
+	// 每次都查所属的用户：用户被停用、注销后密钥马上失效，不像访问令牌要等过期
+	Authenticate(ctx context.Context, key string) (model.APIKey, model.User, error)
+}
+
+// apiKeyService API 密钥服务的具体实现
+type apiKeyService struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+}
+
+// NewAPIKeyService 创建 API 密钥服务实例
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository) APIKeyService {
+	return &apiKeyService{keys: keys, users: users}
+}
+
+// CreateKey 生成密钥，只保存哈希
+func (s *apiKeyService) CreateKey(ctx context.Context, userID string, in APIKeyInput) (model.APIKey, string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" || len([]rune(name)) > maxAPIKeyName {
+		return model.APIKey{}, "", invalidInput("name must be 1-100 characters")
+	}
+	scopes, err := cleanScopes(in.Scopes)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	if in.TTL < 0 || in.TTL > maxAPIKeyTTL {
+		return model.APIKey{}, "", invalidInput("ttl out of range")
+	}
+
+	existing, err := s.keys.ListByUser(ctx, userID)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	if len(existing) >= maxAPIKeys {
+		return model.APIKey{}, "", newError(ErrConflict, "too many api keys, revoke an unused one first")
+	}
+
+	random, err := newOpaqueToken()
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	key := apiKeyPrefix + random
+	k := model.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  key[:len(apiKeyPrefix)+6],
+		Scopes:  scopes,
+		KeyHash: hashToken(key),
+	}
+	if in.TTL > 0 {
+		expires := time.Now().Add(in.TTL)
+		k.ExpiresAt = &expires
+	}
+	k, err = s.keys.Create(ctx, k)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	return k, key, nil
+}
+
+// cleanScopes 检查权限范围并去重
+func cleanScopes(in []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, s := range in {
+		if s != model.APIKeyScopeRead && s != model.APIKeyScopeWrite {
+			return nil, invalidInput("scope must be read or write")
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, invalidInput("at least one scope required")
+	}
+	return out, nil
+}
+
+// ListKeys 列出密钥
+func (s *apiKeyService) ListKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return s.keys.ListByUser(ctx, userID)
+}
+
+// RevokeKey 吊销密钥，不是自己的密钥和不存在一样返回 ErrNotFound
+func (s *apiKeyService) RevokeKey(ctx context.Context, userID, id string) error {
+	return s.keys.Revoke(ctx, userID, id)
+}
+
+// Authenticate 校验密钥
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (model.APIKey, model.User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return model.APIKey{}, model.User{}, ErrInvalidAPIKey
+	}
+	k, err := s.keys.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.APIKey{}, model.User{}, ErrInvalidAPIKey
+		}
+		return model.APIKey{}, model.User{}, err
+	}
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && now.After(*k.ExpiresAt)) {
+		return model.APIKey{}, model.User{}, ErrInvalidAPIKey
+	}
+
+	u, err := s.users.GetByID(ctx, k.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.APIKey{}, model.User{}, ErrInvalidAPIKey
+		}
+		return model.APIKey{}, model.User{}, err
+	}
+	if u.DisabledAt != nil || u.DeletedAt != nil {
+		return model.APIKey{}, model.User{}, ErrInvalidAPIKey
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		// 写失败不影响这次请求
+		if err := s.keys.Touch(ctx, k.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("touch api key", "key_id", k.ID, "err", err)
+		}
+		k.LastUsedAt = &now
+	}
+	return k, u, nil
+}
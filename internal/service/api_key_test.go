@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAPIKeyAuthenticate 创建的密钥能认证出所属的用户；吊销、过期的密钥和停用用户的密钥都不能用
+func TestAPIKeyAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	users, keys := repository.NewMemUserRepo(), repository.NewMemAPIKeyRepo()
+	svc := NewAPIKeyService(keys, users)
+	u, err := users.Create(ctx, "a@example.com", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, secret, err := svc.CreateKey(ctx, u.ID, APIKeyInput{Name: " ci ", Scopes: []string{"read", "read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, apiKeyPrefix) || !strings.HasPrefix(secret, k.Prefix) || k.Name != "ci" || len(k.Scopes) != 1 {
+		t.Fatalf("create = %+v, %q", k, secret)
+	}
+	if k.KeyHash == secret || k.ExpiresAt != nil {
+		t.Fatalf("key hash %q, expires %v", k.KeyHash, k.ExpiresAt)
+	}
+
+	got, gotUser, err := svc.Authenticate(ctx, secret)
+	if err != nil || got.ID != k.ID || gotUser.ID != u.ID || got.LastUsedAt == nil {
+		t.Fatalf("authenticate = %+v, %+v, %v", got, gotUser, err)
+	}
+	if !got.Allows("GET") || got.Allows("POST") {
+		t.Fatalf("read key allows GET %v, POST %v", got.Allows("GET"), got.Allows("POST"))
+	}
+	for _, bad := range []string{"", "kb_nope", strings.TrimPrefix(secret, apiKeyPrefix)} {
+		if _, _, err := svc.Authenticate(ctx, bad); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Fatalf("authenticate %q: %v, want ErrInvalidAPIKey", bad, err)
+		}
+	}
+
+	// 过期的密钥
+	expired := time.Now().Add(-time.Minute)
+	if _, err := keys.Create(ctx, model.APIKey{UserID: u.ID, Name: "old", Scopes: []string{"write"}, KeyHash: hashToken("kb_expired"), ExpiresAt: &expired}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Authenticate(ctx, "kb_expired"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("expired key: %v, want ErrInvalidAPIKey", err)
+	}
+
+	// 用户停用后密钥马上失效，恢复后又能用
+	now := time.Now()
+	if _, err := users.SetDisabled(ctx, u.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("disabled user: %v, want ErrInvalidAPIKey", err)
+	}
+	if _, err := users.SetDisabled(ctx, u.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只能吊销自己的密钥
+	if err := svc.RevokeKey(ctx, "someone-else", k.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("revoke other user's key: %v, want ErrNotFound", err)
+	}
+	if err := svc.RevokeKey(ctx, u.ID, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("revoked key: %v, want ErrInvalidAPIKey", err)
+	}
+	list, err := svc.ListKeys(ctx, u.ID)
+	if err != nil || len(list) != 1 || list[0].Name != "old" {
+		t.Fatalf("list after revoke = %+v, %v", list, err)
+	}
+}
+
+// TestAPIKeyCreateValidation 名字、权限范围、有效期要合法，每个用户的密钥数量有上限
+func TestAPIKeyCreateValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAPIKeyService(repository.NewMemAPIKeyRepo(), repository.NewMemUserRepo())
+
+	for _, in := range []APIKeyInput{
+		{Name: "", Scopes: []string{"read"}},
+		{Name: "x", Scopes: nil},
+		{Name: "x", Scopes: []string{"admin"}},
+		{Name: "x", Scopes: []string{"read"}, TTL: 2 * maxAPIKeyTTL},
+	} {
+		if _, _, err := svc.CreateKey(ctx, "u1", in); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("create %+v: %v, want ErrInvalidInput", in, err)
+		}
+	}
+
+	k, _, err := svc.CreateKey(ctx, "u1", APIKeyInput{Name: "x", Scopes: []string{"write"}, TTL: time.Hour})
+	if err != nil || k.ExpiresAt == nil || !k.Allows("DELETE") {
+		t.Fatalf("create with ttl = %+v, %v", k, err)
+	}
+	for i := 1; i < maxAPIKeys; i++ {
+		if _, _, err := svc.CreateKey(ctx, "u1", APIKeyInput{Name: "x", Scopes: []string{"read"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := svc.CreateKey(ctx, "u1", APIKeyInput{Name: "x", Scopes: []string{"read"}}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("create over limit: %v, want ErrConflict", err)
+	}
+}
@@ -0,0 +1,642 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"kanban_api/internal/blob"
+	"kanban_api/internal/imagemeta"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAttachmentFilename 附件文件名最多多少个字符
+const maxAttachmentFilename = 255
+
+// AttachmentUpload 上传附件时的输入
+type AttachmentUpload struct {
+	// Filename 文件名，只保留最后一段（去掉客户端带上来的目录）
+	Filename string
+
+	// ContentType 客户端给出的文件类型，为空或者是 application/octet-stream 时按内容猜测
+	ContentType string
+
+	// Body 文件内容，读到 EOF 为止
+	Body io.Reader
+}
+
+// UploadStart 开始分块上传时的输入
+type UploadStart struct {
+	// Filename、ContentType 和 AttachmentUpload 一样
+	Filename    string
+	ContentType string
+
+	// Size 文件的总字节数
+	Size int64
+}
+
+// AttachmentService 卡片附件服务接口
+// 附件挂在卡片下面，和检查清单一样逐级校验 看板 -> 列表 -> 卡片 的归属关系
+// 文件内容按 SHA-256 去重保存，见 AttachmentBlobs；每个看板的附件总大小受配额限制，见 StorageCounters
+// 上传（包括开始分块上传）超过看板的配额时返回 ErrQuotaExceeded
+type AttachmentService interface {
+	// ListAttachments 列出卡片的所有附件
+	ListAttachments(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Attachment, error)
+
+	// UploadAttachment 给卡片添加附件
+	// 文件超过大小限制时返回 ErrTooLarge
+	UploadAttachment(ctx context.Context, userID, boardID, listID, cardID string, in AttachmentUpload) (model.Attachment, error)
+
+	// OpenAttachment 打开附件读取内容，调用方负责 Close
+	// 文件在冷存储里时开始恢复，返回 ErrRestoring 和存储类别为 restoring 的附件记录，恢复完成之前一直这样
+	OpenAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) (model.Attachment, io.ReadSeekCloser, error)
+
+	// DeleteAttachment 删除附件
+	// 文件不会马上删除，没有附件再引用它之后由 AttachmentBlobs.Collect 删除
+	DeleteAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) error
+
+	// StartUpload 开始一个分块上传（断点续传），之后用 AppendUpload 一块一块地传
+	// 声明的大小超过限制时返回 ErrTooLarge
+	StartUpload(ctx context.Context, userID, boardID, listID, cardID string, in UploadStart) (model.ResumableUpload, error)
+
+	// GetUpload 查询分块上传，Offset 是已经收到的字节数，连接断开之后从这里接着传
+	// 只有开始上传的用户能看到
+	GetUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) (model.ResumableUpload, error)
+
+	// AppendUpload 从 offset 开始追加一块内容
+	// offset 和已经收到的字节数不一致时返回 ErrConflict，返回的上传记录里带着正确的 Offset
+	// 收满声明的大小之后创建附件并返回（不为 nil），分块上传随之结束
+	AppendUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string, offset int64, body io.Reader) (model.ResumableUpload, *model.Attachment, error)
+
+	// CancelUpload 放弃分块上传，删除已经收到的内容
+	CancelUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) error
+
+	// BoardStorage 看板的附件占用了多少空间、配额是多少，需要 viewer 角色
+	BoardStorage(ctx context.Context, userID, boardID string) (model.BoardStorage, error)
+}
+
+// attachmentService 附件服务的具体实现
+type attachmentService struct {
+	attachments repository.AttachmentRepository
+	blobs       *AttachmentBlobs
+	storage     *StorageCounters
+	cards       repository.CardRepository
+	lists       repository.ListRepository
+	access      boardAccess
+
+	// maxSize 单个附件最大字节数
+	maxSize int64
+
+	// uploadTTL 分块上传多久没有收到新的内容就过期
+	uploadTTL time.Duration
+
+	// stripMetadata 保存图片附件之前是否去掉 EXIF、GPS 等元数据，见 imagemeta
+	stripMetadata bool
+
+	// appending 正在追加内容的分块上传 ID，同一个上传同时只能有一个请求在写
+	appending sync.Map
+}
+
+// NewAttachmentService 创建附件服务实例
+func NewAttachmentService(attachments repository.AttachmentRepository, blobs *AttachmentBlobs, storage *StorageCounters, cards repository.CardRepository, lists repository.ListRepository, boards repository.BoardRepository, members repository.MemberRepository, maxSize int64, uploadTTL time.Duration, stripMetadata bool) AttachmentService {
+	return &attachmentService{
+		attachments:   attachments,
+		blobs:         blobs,
+		storage:       storage,
+		cards:         cards,
+		lists:         lists,
+		access:        boardAccess{boards: boards, members: members},
+		maxSize:       maxSize,
+		uploadTTL:     uploadTTL,
+		stripMetadata: stripMetadata,
+	}
+}
+
+// checkCard 确认当前用户对看板至少有 need 角色，并且卡片在这个看板的这个列表里
+func (s *attachmentService) checkCard(ctx context.Context, userID, boardID, listID, cardID, need string) error {
+	if _, _, err := s.access.check(ctx, userID, boardID, need); err != nil {
+		return err
+	}
+	if _, err := s.lists.Get(ctx, boardID, listID); err != nil {
+		return err
+	}
+	_, err := s.cards.Get(ctx, listID, cardID)
+	return err
+}
+
+func (s *attachmentService) ListAttachments(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Attachment, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.attachments.ListByCard(ctx, cardID)
+}
+
+// UploadAttachment 先检查权限再读文件内容，没有权限的请求不会写临时文件
+func (s *attachmentService) UploadAttachment(ctx context.Context, userID, boardID, listID, cardID string, in AttachmentUpload) (model.Attachment, error) {
+	name, err := cleanFilename(in.Filename)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.Attachment{}, err
+	}
+	// 配额已经用完时不用读文件，保存之前再按实际大小检查一次
+	if err := s.storage.check(ctx, boardID, 1); err != nil {
+		return model.Attachment{}, err
+	}
+
+	staged, err := s.blobs.store.Stage(in.Body, s.maxSize)
+	if errors.Is(err, blob.ErrTooLarge) {
+		return model.Attachment{}, newError(ErrTooLarge, fmt.Sprintf("attachment larger than %d bytes", s.maxSize))
+	}
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	return s.save(ctx, boardID, staged, model.Attachment{
+		CardID:      cardID,
+		Filename:    name,
+		ContentType: detectContentType(in.ContentType, staged.Head),
+		CreatedBy:   userID,
+	})
+}
+
+// save 保存暂存的内容并创建附件记录，Size 和 SHA256 按最终保存的内容填写，看板的占用空间跟着增加
+// 开启了 stripMetadata 时图片先去掉元数据，改写过的内容重新暂存，原来的临时文件丢掉
+func (s *attachmentService) save(ctx context.Context, boardID string, staged *blob.Staged, a model.Attachment) (model.Attachment, error) {
+	mediaType, _, _ := mime.ParseMediaType(a.ContentType)
+	if s.stripMetadata && imagemeta.Supported(mediaType) {
+		stripped, err := stripStaged(s.blobs.store, staged, mediaType)
+		if err != nil {
+			staged.Discard()
+			return model.Attachment{}, err
+		}
+		staged = stripped
+	}
+	if err := s.storage.check(ctx, boardID, staged.Size); err != nil {
+		staged.Discard()
+		return model.Attachment{}, err
+	}
+	a.Size = staged.Size
+	a.SHA256 = staged.Hash
+	a, err := s.blobs.commit(ctx, staged, a)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	s.storage.add(ctx, boardID, model.StorageUsage{Bytes: a.Size, Attachments: 1})
+	return a, nil
+}
+
+// stripStaged 去掉暂存图片的元数据，没有可去的时候原样返回 staged
+// 内容和文件类型对不上（比如声称是 JPEG 其实不是）时拒绝上传，不能把没处理过的图片存下来
+func stripStaged(store blob.Store, staged *blob.Staged, contentType string) (*blob.Staged, error) {
+	f, err := staged.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	out, changed, err := imagemeta.Strip(data, contentType)
+	if errors.Is(err, imagemeta.ErrInvalid) {
+		return nil, invalidInput(fmt.Sprintf("attachment is not a valid %s", contentType))
+	}
+	if err != nil || !changed {
+		return staged, err
+	}
+	// 去掉元数据只会让文件变小；按方向重新编码的 JPEG 可能变大，不再按大小限制拒绝
+	stripped, err := store.Stage(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		return nil, err
+	}
+	staged.Discard()
+	return stripped, nil
+}
+
+func (s *attachmentService) OpenAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) (model.Attachment, io.ReadSeekCloser, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleViewer); err != nil {
+		return model.Attachment{}, nil, err
+	}
+	a, err := s.attachments.Get(ctx, cardID, attachmentID)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	f, err := s.blobs.open(ctx, a)
+	if errors.Is(err, ErrRestoring) {
+		a.StorageClass = model.StorageClassRestoring
+		return a, nil, err
+	}
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	return a, f, nil
+}
+
+func (s *attachmentService) DeleteAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) error {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return err
+	}
+	a, err := s.attachments.Get(ctx, cardID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.attachments.Delete(ctx, cardID, attachmentID); err != nil {
+		return err
+	}
+	s.storage.sub(ctx, boardID, model.StorageUsage{Bytes: a.Size, Attachments: 1})
+	return nil
+}
+
+func (s *attachmentService) StartUpload(ctx context.Context, userID, boardID, listID, cardID string, in UploadStart) (model.ResumableUpload, error) {
+	name, err := cleanFilename(in.Filename)
+	if err != nil {
+		return model.ResumableUpload{}, err
+	}
+	if in.Size <= 0 {
+		return model.ResumableUpload{}, invalidInput("size must be positive")
+	}
+	if in.Size > s.maxSize {
+		return model.ResumableUpload{}, newError(ErrTooLarge, fmt.Sprintf("attachment larger than %d bytes", s.maxSize))
+	}
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.ResumableUpload{}, err
+	}
+	// 开始时按声明的大小检查配额，传完保存之前再检查一次（中间可能有别的上传占了空间）
+	if err := s.storage.check(ctx, boardID, in.Size); err != nil {
+		return model.ResumableUpload{}, err
+	}
+	return s.attachments.CreateUpload(ctx, model.ResumableUpload{
+		CardID:      cardID,
+		Filename:    name,
+		ContentType: cleanContentType(in.ContentType),
+		Size:        in.Size,
+		CreatedBy:   userID,
+		ExpiresAt:   time.Now().Add(s.uploadTTL),
+	})
+}
+
+// getUpload 检查权限并取出分块上传，填上已经收到的字节数
+// 别人开始的上传按不存在处理
+func (s *attachmentService) getUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) (model.ResumableUpload, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.ResumableUpload{}, err
+	}
+	u, err := s.attachments.GetUpload(ctx, cardID, uploadID)
+	if err != nil {
+		return model.ResumableUpload{}, err
+	}
+	if u.CreatedBy != userID {
+		return model.ResumableUpload{}, ErrNotFound
+	}
+	if u.Offset, err = s.blobs.store.PartialSize(u.ID); err != nil {
+		return model.ResumableUpload{}, err
+	}
+	return u, nil
+}
+
+func (s *attachmentService) GetUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) (model.ResumableUpload, error) {
+	return s.getUpload(ctx, userID, boardID, listID, cardID, uploadID)
+}
+
+// AppendUpload 客户端断开时已经写入的部分保留下来，过期时间照样顺延，客户端查询 Offset 之后接着传
+func (s *attachmentService) AppendUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string, offset int64, body io.Reader) (model.ResumableUpload, *model.Attachment, error) {
+	u, err := s.getUpload(ctx, userID, boardID, listID, cardID, uploadID)
+	if err != nil {
+		return model.ResumableUpload{}, nil, err
+	}
+	if _, busy := s.appending.LoadOrStore(u.ID, struct{}{}); busy {
+		return u, nil, newError(ErrConflict, "another request is writing to this upload")
+	}
+	defer s.appending.Delete(u.ID)
+
+	n, err := s.blobs.store.Append(u.ID, offset, body, u.Size)
+	u.Offset = n
+	switch {
+	case errors.Is(err, blob.ErrOffsetMismatch):
+		return u, nil, newError(ErrConflict, fmt.Sprintf("upload offset is %d, not %d", n, offset))
+	case errors.Is(err, blob.ErrTooLarge):
+		return u, nil, newError(ErrTooLarge, fmt.Sprintf("chunk goes past the declared size of %d bytes", u.Size))
+	}
+	if n > offset {
+		u.ExpiresAt = time.Now().Add(s.uploadTTL)
+		if terr := s.attachments.TouchUpload(ctx, u.ID, u.ExpiresAt); terr != nil && err == nil {
+			err = terr
+		}
+	}
+	if err != nil || n < u.Size {
+		return u, nil, err
+	}
+
+	// 收满了：和一次性上传一样保存文件、创建附件
+	staged, err := s.blobs.store.Seal(u.ID)
+	if err != nil {
+		return u, nil, err
+	}
+	a, err := s.save(ctx, boardID, staged, model.Attachment{
+		CardID:      cardID,
+		Filename:    u.Filename,
+		ContentType: detectContentType(u.ContentType, staged.Head),
+		CreatedBy:   userID,
+	})
+	if err != nil {
+		return u, nil, err
+	}
+	// 附件已经建好，这里失败只会留下一条没有内容的上传记录，过期后被清理
+	if err := s.attachments.DeleteUpload(ctx, u.ID); err != nil {
+		logging.FromContext(ctx).Warn("delete finished upload", "upload_id", u.ID, "err", err)
+	}
+	return u, &a, nil
+}
+
+func (s *attachmentService) CancelUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) error {
+	u, err := s.getUpload(ctx, userID, boardID, listID, cardID, uploadID)
+	if err != nil {
+		return err
+	}
+	if err := s.attachments.DeleteUpload(ctx, u.ID); err != nil {
+		return err
+	}
+	return s.blobs.store.DeletePartial(u.ID)
+}
+
+func (s *attachmentService) BoardStorage(ctx context.Context, userID, boardID string) (model.BoardStorage, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return model.BoardStorage{}, err
+	}
+	return s.storage.Usage(ctx, boardID)
+}
+
+// detectContentType 客户端给出的文件类型可用时直接用，否则按内容开头猜测
+func detectContentType(given string, head []byte) string {
+	if ct := cleanContentType(given); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(head)
+}
+
+// cleanFilename 只保留文件名的最后一段，去掉首尾空白并检查长度
+// Windows 客户端可能带上 C:\Users\... 这样的路径，先把反斜杠换成斜杠
+func cleanFilename(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "", invalidInput("filename required")
+	}
+	if len([]rune(name)) > maxAttachmentFilename {
+		return "", invalidInput("filename too long")
+	}
+	return name, nil
+}
+
+// cleanContentType 客户端给出的文件类型，格式不对或者等于没说（application/octet-stream）时返回空串
+func cleanContentType(ct string) string {
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// ========== blob 的保存和回收 ==========
+
+// AttachmentBlobs 附件文件的保存和回收
+//
+// 附件记录和 blob 的引用计数在仓储里一起变化，文件本身在 blob.Store 里：
+//   - 上传：文件写好之后再创建附件记录（引用计数加一）
+//   - 删除附件：只减少引用计数，不碰文件
+//   - Collect：删除引用计数为 0 的 blob 记录和文件
+//
+// 不常用的文件可以移到冷存储（另一个 blob.Store，例如更便宜的目录或存储桶），
+// blob 记录里的存储类别说明文件现在在哪里，见 AttachmentArchiver；读取冷存储里的文件时先恢复回普通存储
+//
+// 上传、Collect 和在两个存储之间移动文件要互斥：否则 Collect 判断 blob 没人引用之后、删文件之前，
+// 刚好有人上传了同样的文件，新的附件记录就会指向一个被删掉的文件
+// 锁只在进程内有效，多个实例共用一个存储目录时只能让其中一个实例运行 Collect 和 AttachmentArchiver
+type AttachmentBlobs struct {
+	mu          sync.Mutex
+	attachments repository.AttachmentRepository
+	store       blob.Store
+
+	// cold 冷存储
+	cold blob.Store
+
+	// restoring 这个进程里正在恢复的 blob，同一个 blob 只起一个恢复
+	restoring sync.Map
+}
+
+// NewAttachmentBlobs 创建附件文件的保存和回收实例，store 是普通存储，cold 是冷存储
+func NewAttachmentBlobs(attachments repository.AttachmentRepository, store, cold blob.Store) *AttachmentBlobs {
+	return &AttachmentBlobs{attachments: attachments, store: store, cold: cold}
+}
+
+// commit 保存暂存的文件并创建附件记录
+// 同样的内容已经移到了冷存储时，这次 Commit 在普通存储里重新写了一份，直接改回 standard
+func (b *AttachmentBlobs) commit(ctx context.Context, staged *blob.Staged, a model.Attachment) (model.Attachment, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.store.Commit(staged); err != nil {
+		return model.Attachment{}, err
+	}
+	// 这里失败的话文件留在存储里但没有记录，以后上传同样的内容时会直接复用
+	a, err := b.attachments.Create(ctx, a)
+	if err != nil || a.StorageClass != model.StorageClassCold {
+		return a, err
+	}
+	if ok, err := b.attachments.SetBlobClass(ctx, a.SHA256, model.StorageClassCold, model.StorageClassStandard); err != nil || !ok {
+		// 改不了也没关系：下载时按冷存储处理，恢复一次就好
+		return a, nil
+	}
+	if err := b.cold.Delete(a.SHA256); err != nil {
+		logging.FromContext(ctx).Warn("delete cold blob", "sha256", a.SHA256, "err", err)
+	}
+	a.StorageClass = model.StorageClassStandard
+	return a, nil
+}
+
+// open 打开附件的文件
+// 文件在冷存储里（或者正在恢复）时开始恢复，返回 ErrRestoring
+func (b *AttachmentBlobs) open(ctx context.Context, a model.Attachment) (io.ReadSeekCloser, error) {
+	if a.StorageClass == model.StorageClassCold || a.StorageClass == model.StorageClassRestoring {
+		b.restore(ctx, a.SHA256)
+		return nil, newError(ErrRestoring, "attachment is being restored from cold storage, retry later")
+	}
+	f, err := b.store.Open(a.SHA256)
+	if err != nil {
+		// 记录还在、文件却没了：存储目录被人动过，按内部错误处理
+		return nil, fmt.Errorf("open blob %s: %w", a.SHA256, err)
+	}
+	return f, nil
+}
+
+// restore 在后台把 blob 从冷存储恢复到普通存储，这个进程里已经在恢复时什么也不做
+// 状态是 restoring 但没有在恢复（比如恢复到一半时服务重启了），下次访问时重新开始
+func (b *AttachmentBlobs) restore(ctx context.Context, hash string) {
+	if _, busy := b.restoring.LoadOrStore(hash, struct{}{}); busy {
+		return
+	}
+	// 请求结束之后恢复还要继续，不能跟着请求的 ctx 一起取消
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer b.restoring.Delete(hash)
+		if err := b.Restore(ctx, hash); err != nil {
+			logging.FromContext(ctx).Warn("restore attachment blob", "sha256", hash, "err", err)
+		}
+	}()
+}
+
+// Restore 把 blob 从冷存储复制回普通存储，改回 standard 之后删除冷存储里的文件
+// 先把状态改成 restoring，这样列出附件时能看到正在恢复；已经恢复过了时什么也不做
+func (b *AttachmentBlobs) Restore(ctx context.Context, hash string) error {
+	started, err := b.attachments.SetBlobClass(ctx, hash, model.StorageClassCold, model.StorageClassRestoring)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := copyBlob(b.cold, b.store, hash); err != nil {
+		if !started && errors.Is(err, blob.ErrNotFound) {
+			// 状态不是 cold，冷存储里也没有：别的请求已经恢复完了
+			return nil
+		}
+		return err
+	}
+	ok, err := b.attachments.SetBlobClass(ctx, hash, model.StorageClassRestoring, model.StorageClassStandard)
+	if err != nil || !ok {
+		return err
+	}
+	if err := b.cold.Delete(hash); err != nil {
+		logging.FromContext(ctx).Warn("delete cold blob", "sha256", hash, "err", err)
+	}
+	return nil
+}
+
+// archive 把 blob 从普通存储移到冷存储，返回是否移动了
+// 先复制再改存储类别，最后删除普通存储里的文件；中途失败时文件还在原来的地方
+func (b *AttachmentBlobs) archive(ctx context.Context, hash string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := copyBlob(b.store, b.cold, hash); err != nil {
+		return false, err
+	}
+	ok, err := b.attachments.SetBlobClass(ctx, hash, model.StorageClassStandard, model.StorageClassCold)
+	if err != nil || !ok {
+		// 列出来之后 blob 被删掉或者已经被移走了，复制过去的这份不要了
+		if derr := b.cold.Delete(hash); derr != nil {
+			logging.FromContext(ctx).Warn("delete cold blob", "sha256", hash, "err", derr)
+		}
+		return false, err
+	}
+	if err := b.store.Delete(hash); err != nil {
+		logging.FromContext(ctx).Warn("delete blob", "sha256", hash, "err", err)
+	}
+	return true, nil
+}
+
+// copyBlob 把 blob 从一个存储复制到另一个存储，复制完按内容校验哈希
+func copyBlob(from, to blob.Store, hash string) error {
+	f, err := from.Open(hash)
+	if err != nil {
+		return fmt.Errorf("open blob %s: %w", hash, err)
+	}
+	defer f.Close()
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	staged, err := to.Stage(f, size)
+	if err != nil {
+		return err
+	}
+	if staged.Hash != hash {
+		staged.Discard()
+		return fmt.Errorf("blob %s: content hash is %s", hash, staged.Hash)
+	}
+	return to.Commit(staged)
+}
+
+// ExpireUploads 删除过期的分块上传和已经收到的内容，返回删除了几个
+func (b *AttachmentBlobs) ExpireUploads(ctx context.Context, now time.Time) (int, error) {
+	ids, err := b.attachments.ExpiredUploads(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := b.attachments.DeleteUpload(ctx, id); err != nil {
+			return i, err
+		}
+		if err := b.store.DeletePartial(id); err != nil {
+			logging.FromContext(ctx).Warn("delete expired upload", "upload_id", id, "err", err)
+		}
+	}
+	return len(ids), nil
+}
+
+// Collect 删除没有附件再引用的 blob，返回删除了几个
+func (b *AttachmentBlobs) Collect(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hashes, err := b.attachments.UnreferencedBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, h := range hashes {
+		ok, err := b.attachments.DeleteBlob(ctx, h)
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			continue
+		}
+		// 记录已经删了，文件删除失败只会留下一个没人引用的文件，不影响使用
+		// 不知道文件在哪个存储里，两边都删
+		for _, store := range []blob.Store{b.store, b.cold} {
+			if err := store.Delete(h); err != nil {
+				logging.FromContext(ctx).Warn("delete blob", "sha256", h, "err", err)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Run 立即回收一次，之后每隔 interval 回收一次，直到 ctx 被取消
+// 每次先清理过期的分块上传，再回收没人引用的 blob
+func (b *AttachmentBlobs) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := b.ExpireUploads(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warn("expire attachment uploads", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("expired attachment uploads", "count", n)
+		}
+		n, err := b.Collect(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warn("collect attachment blobs", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("collected attachment blobs", "count", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"time"
+)
+
+// AttachmentArchiver 把归档看板上的附件文件移到冷存储
+//
+// 归档的看板是被管理员设为只读（model.BoardStateReadOnly）的看板：只读之后看板不能再修改，
+// 它的 UpdatedAt 就是设为只读的时间，只读超过 after 的看板上的文件才会移走
+//
+// 文件按内容去重，同一个文件可能还挂在别的看板上，只有所有引用它的附件都在这些归档看板上时才移动；
+// 刚从冷存储恢复回来的文件也要再过 after 才会重新移走，避免有人在看的文件来回搬
+//
+// 移走之后附件记录不变，只是存储类别变成 cold；下载时自动恢复，见 AttachmentBlobs
+type AttachmentArchiver struct {
+	blobs    *AttachmentBlobs
+	counters *StorageCounters
+	boards   repository.BoardRepository
+
+	// after 看板只读多久之后移动文件
+	after time.Duration
+}
+
+// NewAttachmentArchiver 创建冷存储归档实例
+// 有附件的看板都有占用空间的计数，要检查哪些看板由 counters 列出
+func NewAttachmentArchiver(blobs *AttachmentBlobs, counters *StorageCounters, boards repository.BoardRepository, after time.Duration) *AttachmentArchiver {
+	return &AttachmentArchiver{blobs: blobs, counters: counters, boards: boards, after: after}
+}
+
+// Archive 把归档看板上的文件移到冷存储，返回移动了几个文件
+// 单个文件移动失败只记日志，接着移动其它文件
+func (a *AttachmentArchiver) Archive(ctx context.Context, now time.Time) (int, error) {
+	ids, err := a.counters.storage.ListBoardIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	boards, err := a.boards.ListByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.Add(-a.after)
+	var cards []string
+	for _, b := range boards {
+		if b.State != model.BoardStateReadOnly || !b.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		ids, _, err := a.counters.boardCards(ctx, b.ID)
+		if err != nil {
+			return 0, err
+		}
+		cards = append(cards, ids...)
+	}
+	if len(cards) == 0 {
+		return 0, nil
+	}
+
+	hashes, err := a.blobs.attachments.ArchivableBlobs(ctx, cards, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, h := range hashes {
+		moved, err := a.blobs.archive(ctx, h)
+		if err != nil {
+			logging.FromContext(ctx).Warn("archive attachment blob", "sha256", h, "err", err)
+			continue
+		}
+		if moved {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Run 立即检查一次，之后每隔 interval 检查一次，直到 ctx 被取消
+func (a *AttachmentArchiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := a.Archive(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warn("archive attachments", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("archived attachments to cold storage", "count", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
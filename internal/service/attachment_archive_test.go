@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"kanban_api/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAttachmentColdStorage 只读超过一段时间的看板上的文件移到冷存储，还挂在别的看板上的不动；
+// 下载冷存储里的附件先返回 ErrRestoring，恢复完成后可以正常下载
+func TestAttachmentColdStorage(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+	archiver := NewAttachmentArchiver(f.blobs, f.storage, f.boards, time.Hour)
+
+	// 另一个正常使用的看板，和归档看板共用一个文件
+	other, err := f.boards.Create(ctx, "u1", "Other", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherList, err := f.lists.Create(ctx, other.ID, "L")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCard, err := f.cardRepo.Create(ctx, model.Card{BoardID: other.ID, ListID: otherList.ID, Title: "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	only := f.upload(t, f.card1, "only.txt", "only on the archived board")
+	f.upload(t, f.card2, "shared.txt", "shared")
+	if _, err := f.svc.UploadAttachment(ctx, "u1", other.ID, otherList.ID, otherCard.ID, AttachmentUpload{Filename: "shared.txt", Body: strings.NewReader("shared")}); err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(2 * time.Hour)
+	if n, err := archiver.Archive(ctx, later); err != nil || n != 0 {
+		t.Fatalf("archive active board = %d, %v, want 0", n, err)
+	}
+	if _, err := f.boards.SetState(ctx, f.board.ID, model.BoardStateReadOnly, "archived"); err != nil {
+		t.Fatal(err)
+	}
+	// 刚设为只读，还没到时间
+	if n, err := archiver.Archive(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("archive too early = %d, %v, want 0", n, err)
+	}
+	if n, err := archiver.Archive(ctx, later); err != nil || n != 1 {
+		t.Fatalf("archive = %d, %v, want 1", n, err)
+	}
+	if hot, cold := f.blobFiles(t), countBlobFiles(t, f.coldDir); hot != 1 || cold != 1 {
+		t.Fatalf("hot %d, cold %d blob files, want 1 and 1", hot, cold)
+	}
+	list, err := f.svc.ListAttachments(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID)
+	if err != nil || len(list) != 1 || list[0].StorageClass != model.StorageClassCold {
+		t.Fatalf("list = %+v, %v, want one cold attachment", list, err)
+	}
+
+	// 第一次下载开始恢复
+	a, _, err := f.svc.OpenAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, only.ID)
+	if !errors.Is(err, ErrRestoring) || a.StorageClass != model.StorageClassRestoring {
+		t.Fatalf("open cold attachment: %+v, err = %v, want ErrRestoring", a, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, r, err := f.svc.OpenAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, only.ID)
+		if err == nil {
+			data, _ := io.ReadAll(r)
+			r.Close()
+			if string(data) != "only on the archived board" {
+				t.Fatalf("restored content = %q", data)
+			}
+			break
+		}
+		if !errors.Is(err, ErrRestoring) || time.Now().After(deadline) {
+			t.Fatalf("open while restoring: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if hot, cold := f.blobFiles(t), countBlobFiles(t, f.coldDir); hot != 2 || cold != 0 {
+		t.Fatalf("after restore: hot %d, cold %d blob files, want 2 and 0", hot, cold)
+	}
+	a, err = f.attachments.Get(ctx, f.card1.ID, only.ID)
+	if err != nil || a.StorageClass != model.StorageClassStandard {
+		t.Fatalf("after restore: %+v, %v", a, err)
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"kanban_api/internal/blob"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// attachmentFixture 一个看板、一个列表、两张卡片，附件文件保存在临时目录
+type attachmentFixture struct {
+	svc         AttachmentService
+	blobs       *AttachmentBlobs
+	storage     *StorageCounters
+	attachments repository.AttachmentRepository
+	cards       CardService
+	boards      repository.BoardRepository
+	lists       repository.ListRepository
+	cardRepo    repository.CardRepository
+	dir         string
+	coldDir     string
+	board       model.Board
+	list        model.List
+	card1       model.Card
+	card2       model.Card
+}
+
+func newAttachmentFixture(t *testing.T) attachmentFixture {
+	t.Helper()
+	ctx := context.Background()
+	f := attachmentFixture{dir: t.TempDir(), coldDir: t.TempDir(), attachments: repository.NewMemAttachmentRepo()}
+	boards, lists, cards := repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo()
+	checklists, members := repository.NewMemChecklistRepo(), repository.NewMemMemberRepo()
+
+	store, err := blob.NewDirStore(f.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cold, err := blob.NewDirStore(f.coldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.blobs = NewAttachmentBlobs(f.attachments, store, cold)
+	f.storage = NewStorageCounters(repository.NewMemStorageRepo(), f.attachments, lists, cards, 0)
+	f.svc = NewAttachmentService(f.attachments, f.blobs, f.storage, cards, lists, boards, members, 1024, time.Hour, true)
+	f.cards = NewCardService(cards, lists, checklists, f.attachments, f.storage, repository.NewMemCardTemplateRepo(), boards, members)
+	f.boards, f.lists, f.cardRepo = boards, lists, cards
+
+	if f.board, err = boards.Create(ctx, "u1", "B", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if f.list, err = lists.Create(ctx, f.board.ID, "L"); err != nil {
+		t.Fatal(err)
+	}
+	if f.card1, err = cards.Create(ctx, model.Card{BoardID: f.board.ID, ListID: f.list.ID, Title: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if f.card2, err = cards.Create(ctx, model.Card{BoardID: f.board.ID, ListID: f.list.ID, Title: "two"}); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f attachmentFixture) upload(t *testing.T, card model.Card, name, content string) model.Attachment {
+	t.Helper()
+	a, err := f.svc.UploadAttachment(context.Background(), "u1", f.board.ID, f.list.ID, card.ID, AttachmentUpload{Filename: name, Body: strings.NewReader(content)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// blobFiles 存储目录里的 blob 文件数（不算临时文件）
+func (f attachmentFixture) blobFiles(t *testing.T) int {
+	t.Helper()
+	return countBlobFiles(t, f.dir)
+}
+
+// countBlobFiles 目录里的 blob 文件数
+func countBlobFiles(t *testing.T, dir string) int {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "??", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+// TestAttachmentDedup 同样的内容只保存一份，最后一个引用删除之后文件才被回收
+func TestAttachmentDedup(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+
+	a1 := f.upload(t, f.card1, "report.txt", "same content")
+	a2 := f.upload(t, f.card2, `C:\tmp\copy.txt`, "same content")
+	other := f.upload(t, f.card2, "other.txt", "different")
+
+	if a1.SHA256 != a2.SHA256 || a1.ID == a2.ID {
+		t.Fatalf("a1 = %+v, a2 = %+v", a1, a2)
+	}
+	if a2.Filename != "copy.txt" {
+		t.Fatalf("filename = %q, want copy.txt", a2.Filename)
+	}
+	if a1.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("content type = %q", a1.ContentType)
+	}
+	if n := f.blobFiles(t); n != 2 {
+		t.Fatalf("%d blob files, want 2", n)
+	}
+
+	// 删掉一个引用，文件还被 a2 引用，不能回收
+	if err := f.svc.DeleteAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, a1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := f.blobs.Collect(ctx); err != nil || n != 0 {
+		t.Fatalf("collect = %d, %v, want 0", n, err)
+	}
+	_, r, err := f.svc.OpenAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID, a2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if string(got) != "same content" {
+		t.Fatalf("content = %q", got)
+	}
+
+	// 删除卡片会连带删除它的附件，两个 blob 都没人引用了
+	if err := f.cards.DeleteCard(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := f.blobs.Collect(ctx); err != nil || n != 2 {
+		t.Fatalf("collect = %d, %v, want 2", n, err)
+	}
+	if n := f.blobFiles(t); n != 0 {
+		t.Fatalf("%d blob files left", n)
+	}
+	if _, err := f.attachments.Get(ctx, f.card2.ID, other.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("attachment of deleted card: err = %v", err)
+	}
+}
+
+// TestAttachmentTooLarge 超过大小限制返回 ErrTooLarge，不留下任何文件和记录
+func TestAttachmentTooLarge(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+
+	_, err := f.svc.UploadAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, AttachmentUpload{Filename: "big.bin", Body: strings.NewReader(strings.Repeat("x", 1025))})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("err = %v, want ErrTooLarge", err)
+	}
+	if n := f.blobFiles(t); n != 0 {
+		t.Fatalf("%d blob files", n)
+	}
+	tmp, _ := os.ReadDir(filepath.Join(f.dir, "tmp"))
+	if len(tmp) != 0 {
+		t.Fatalf("%d temp files left", len(tmp))
+	}
+	items, _ := f.svc.ListAttachments(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID)
+	if len(items) != 0 {
+		t.Fatalf("%d attachments", len(items))
+	}
+}
+
+// TestAttachmentStripMetadata 图片保存前去掉元数据，Size 和 SHA256 对应去掉之后的内容；
+// 声称是图片但内容不对的上传被拒绝
+func TestAttachmentStripMetadata(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	plain := buf.Bytes()
+	// IHDR 后面插一个 tEXt 块（8 字节签名 + 25 字节 IHDR）
+	text := []byte{0, 0, 0, 8, 't', 'E', 'X', 't', 'G', 'P', 'S', 0, '1', ',', '2', '3', 0, 0, 0, 0}
+	tagged := append(append(append([]byte{}, plain[:33]...), text...), plain[33:]...)
+
+	a := f.upload(t, f.card1, "photo.png", string(tagged))
+	sum := sha256.Sum256(plain)
+	if a.ContentType != "image/png" || a.Size != int64(len(plain)) || a.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("attachment = %+v", a)
+	}
+	_, r, err := f.svc.OpenAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(got, plain) {
+		t.Fatal("stored content still has metadata")
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(f.dir, "tmp")); len(tmp) != 0 {
+		t.Fatalf("%d temp files left", len(tmp))
+	}
+
+	_, err = f.svc.UploadAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, AttachmentUpload{Filename: "fake.jpg", ContentType: "image/jpeg", Body: strings.NewReader("not a jpeg")})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("err = %v, want ErrInvalidInput", err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(f.dir, "tmp")); len(tmp) != 0 {
+		t.Fatalf("%d temp files left after rejected upload", len(tmp))
+	}
+}
+
+// TestResumableUpload 分块上传：offset 不对时返回冲突和正确的位置，传满之后变成普通附件
+func TestResumableUpload(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+	content := "hello, resumable world"
+
+	u, err := f.svc.StartUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, UploadStart{Filename: "notes.txt", Size: int64(len(content))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.StartUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, UploadStart{Filename: "big.bin", Size: 1025}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("declared size over limit: err = %v, want ErrTooLarge", err)
+	}
+
+	u, a, err := f.svc.AppendUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID, 0, strings.NewReader(content[:5]))
+	if err != nil || a != nil || u.Offset != 5 {
+		t.Fatalf("first chunk: offset %d, attachment %v, err %v", u.Offset, a, err)
+	}
+
+	// 客户端以为第一块没传上去，从头重传：冲突，并告诉它已经收到了 5 字节
+	u, _, err = f.svc.AppendUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID, 0, strings.NewReader(content))
+	if !errors.Is(err, ErrConflict) || u.Offset != 5 {
+		t.Fatalf("wrong offset: offset %d, err %v", u.Offset, err)
+	}
+	// 别人看不到这个上传
+	if _, err := f.svc.GetUpload(ctx, "u2", f.board.ID, f.list.ID, f.card1.ID, u.ID); err == nil {
+		t.Fatal("another user can see the upload")
+	}
+	// 超过声明的大小：这一块作废，进度不变
+	u, _, err = f.svc.AppendUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID, 5, strings.NewReader(content[5:]+"extra"))
+	if !errors.Is(err, ErrTooLarge) || u.Offset != 5 {
+		t.Fatalf("chunk past size: offset %d, err %v", u.Offset, err)
+	}
+
+	_, a, err = f.svc.AppendUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID, 5, strings.NewReader(content[5:]))
+	if err != nil || a == nil {
+		t.Fatalf("last chunk: attachment %v, err %v", a, err)
+	}
+	if a.Filename != "notes.txt" || a.Size != int64(len(content)) || a.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("attachment = %+v", a)
+	}
+	// 和一次性上传的同样内容共用一个 blob
+	if b := f.upload(t, f.card2, "copy.txt", content); b.SHA256 != a.SHA256 || f.blobFiles(t) != 1 {
+		t.Fatalf("sha256 %s vs %s, %d blob files", b.SHA256, a.SHA256, f.blobFiles(t))
+	}
+	if _, err := f.svc.GetUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("finished upload still exists: err = %v", err)
+	}
+}
+
+// TestResumableUploadExpiry 过期的分块上传连同已经收到的内容一起删除
+func TestResumableUploadExpiry(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+
+	u, err := f.svc.StartUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, UploadStart{Filename: "a.bin", Size: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := f.svc.AppendUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID, 0, strings.NewReader("partial")); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := f.blobs.ExpireUploads(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("expire now = %d, %v, want 0", n, err)
+	}
+	if n, err := f.blobs.ExpireUploads(ctx, time.Now().Add(2*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("expire later = %d, %v, want 1", n, err)
+	}
+	if _, err := f.svc.GetUpload(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, u.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expired upload: err = %v", err)
+	}
+	partial, _ := os.ReadDir(filepath.Join(f.dir, "partial"))
+	if len(partial) != 0 {
+		t.Fatalf("%d partial files left", len(partial))
+	}
+}
@@ -4,9 +4,16 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5" // JWT（JSON Web Token）库，用于生成和验证令牌
 	"golang.org/x/crypto/bcrypt"   // bcrypt 加密库，用于密码哈希
+	"kanban_api/internal/jwtkeys"
 	"kanban_api/internal/model"
 	"kanban_api/internal/repository"
 	"os"
@@ -14,16 +21,76 @@ import (
 	"time"
 )
 
+// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+var ErrInvalidRefreshToken = newError(ErrUnauthorized, "invalid refresh token")
+
+// ErrWrongPassword 修改密码时当前密码不正确
+var ErrWrongPassword = newError(ErrForbidden, "current password is incorrect")
+
+// ErrAccountDisabled 账号已被管理员停用
+var ErrAccountDisabled = newError(ErrForbidden, "account disabled")
+
+// ErrAccountDeleted 用户自己注销了账号，宽限期内可以用 AccountService.RestoreAccount 撤销
+var ErrAccountDeleted = newError(ErrForbidden, "account scheduled for deletion")
+
+// ErrSessionRevoked 访问令牌还没过期，但所属的用户已经被停用、注销或者删除
+// 和令牌无效一样是 401，客户端重新登录时会看到具体原因（ErrAccountDisabled 等）
+var ErrSessionRevoked = newError(ErrUnauthorized, "session revoked")
+
+// errInvalidCredentials 登录失败
+// 邮箱不存在和密码错误返回同一个错误，不泄露邮箱是否注册过
+var errInvalidCredentials = newError(ErrUnauthorized, "invalid credentials")
+
+// TokenPair 登录后颁发的一对令牌
+type TokenPair struct {
+	// AccessToken 访问令牌（JWT），放在 Authorization 头中访问接口，有效期较短
+	AccessToken string
+
+	// RefreshToken 刷新令牌，只用来换新的令牌，有效期较长
+	// 是一串随机字符，不是 JWT，服务端通过哈希查表校验
+	RefreshToken string
+}
+
 // AuthService 认证服务接口
 // 负责用户注册、登录、JWT 令牌生成等认证相关的业务逻辑
 type AuthService interface {
 	// Register 用户注册
-	// 返回：用户对象、JWT令牌、错误
-	Register(email, password string) (model.User, string, error)
+	// 返回：用户对象、令牌对、错误
+	Register(ctx context.Context, email, password string) (model.User, TokenPair, error)
 
 	// Login 用户登录
-	// 返回：用户对象、JWT令牌、错误
-	Login(email, password string) (model.User, string, error)
+	// 返回：用户对象、令牌对、错误
+	Login(ctx context.Context, email, password string) (model.User, TokenPair, error)
+
+	// Refresh 用刷新令牌换一对新令牌
+	// 旧的刷新令牌会被吊销（轮换），每个刷新令牌只能用一次
+	Refresh(ctx context.Context, refreshToken string) (TokenPair, error)
+
+	// Logout 吊销刷新令牌所在的整个家族（即这次登录的会话）
+	// 令牌无效时也返回 nil，登出操作是幂等的
+	Logout(ctx context.Context, refreshToken string) error
+
+	// Me 获取当前登录用户的资料
+	Me(ctx context.Context, userID string) (model.User, error)
+
+	// CheckSession 认证中间件验证访问令牌之后调用，返回令牌所属用户当前的资料
+	// 用户被停用、注销或者删除时返回 ErrSessionRevoked：访问令牌不用等过期就马上失效
+	CheckSession(ctx context.Context, userID string) (model.User, error)
+
+	// UpdateEmail 修改当前登录用户的邮箱，需要当前密码
+	UpdateEmail(ctx context.Context, userID, email, currentPassword string) (model.User, error)
+
+	// ChangePassword 验证当前密码后修改密码
+	// 成功后用户所有的刷新令牌都会被吊销（其它设备需要重新登录），
+	// 并为当前设备颁发一对新令牌
+	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) (TokenPair, error)
+
+	// ListUsers 列出所有用户（管理员接口）
+	ListUsers(ctx context.Context) ([]model.User, error)
+
+	// SetRole 修改用户的系统角色（管理员接口）
+	// 角色也写在访问令牌里，但认证中间件按 CheckSession 查到的当前角色检查权限，修改后马上生效
+	SetRole(ctx context.Context, userID, role string) (model.User, error)
 }
 
 // authService 认证服务的具体实现
@@ -32,28 +99,39 @@ type authService struct {
 	// users 用户仓储，用于访问用户数据
 	users repository.UserRepository
 
-	// jwtSecret JWT 签名密钥
-	// 用于生成和验证 JWT 令牌的安全性
+	// refreshTokens 刷新令牌仓储
+	refreshTokens repository.RefreshTokenRepository
+
+	// resets 密码重置令牌仓储，修改邮箱时作废发到旧邮箱的令牌
+	resets repository.PasswordResetRepository
+
+	// keys JWT 签名密钥（HS256 的共享密钥，或者 RS256 / EdDSA 的私钥），见 jwtkeys 包
 	// 必须保密！泄露会导致他人可以伪造令牌
-	jwtSecret []byte
+	keys *jwtkeys.Set
 
 	// tokenTTL JWT 令牌的有效期（Time To Live）
 	// 例如 24*time.Hour 表示令牌 24 小时后过期
 	tokenTTL time.Duration
+
+	// refreshTTL 刷新令牌的有效期，例如 30 天
+	refreshTTL time.Duration
 }
 
 // NewAuthService 创建认证服务实例
 // 这是构造函数，返回接口类型
-func NewAuthService(users repository.UserRepository, jwtSecret []byte, tokenTTL time.Duration) AuthService {
+func NewAuthService(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, resets repository.PasswordResetRepository, keys *jwtkeys.Set, tokenTTL, refreshTTL time.Duration) AuthService {
 	return &authService{
-		users:     users,
-		jwtSecret: jwtSecret,
-		tokenTTL:  tokenTTL,
+		users:         users,
+		refreshTokens: refreshTokens,
+		resets:        resets,
+		keys:          keys,
+		tokenTTL:      tokenTTL,
+		refreshTTL:    refreshTTL,
 	}
 }
 
 // Register 实现用户注册逻辑
-func (s *authService) Register(email, password string) (model.User, string, error) {
+func (s *authService) Register(ctx context.Context, email, password string) (model.User, TokenPair, error) {
 	// 数据清理和标准化
 	// TrimSpace: 去除首尾空格，防止 "user@example.com " 和 "user@example.com" 被当作不同邮箱
 	// ToLower: 转为小写，确保邮箱不区分大小写（User@Example.com 和 user@example.com 是同一个）
@@ -61,7 +139,12 @@ func (s *authService) Register(email, password string) (model.User, string, erro
 
 	// 数据验证：邮箱和密码不能为空
 	if email == "" || password == "" {
-		return model.User{}, "", errors.New("email and password required")
+		return model.User{}, TokenPair{}, invalidInput("email and password required")
+	}
+	// 密码强度和修改密码、重置密码用同一套规则（见 validatePassword）
+	// HTTP 层按字符数检查过长度，但一个中文字符占 3 个字节，bcrypt 超过 72 字节直接报错，这里按字节再检查一次
+	if err := validatePassword(password); err != nil {
+		return model.User{}, TokenPair{}, err
 	}
 
 	// 验证邮箱是否注册过
@@ -76,34 +159,35 @@ func (s *authService) Register(email, password string) (model.User, string, erro
 	// 3. 慢速算法：故意设计得很慢，防止暴力破解
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return model.User{}, "", err
+		return model.User{}, TokenPair{}, err
 	}
 
 	// 调用仓储层创建用户
 	// 注意：存储的是哈希值 string(hash)，不是明文密码！
-	u, err := s.users.Create(email, string(hash))
+	u, err := s.users.Create(ctx, email, string(hash))
 	if err != nil {
-		return model.User{}, "", err
+		return model.User{}, TokenPair{}, conflict(err)
 	}
 
-	// 注册成功后，立即颁发 JWT 令牌
+	// 注册成功后，立即颁发令牌
 	// 这样用户注册后就自动登录了，提供更好的用户体验
-	tok, err := s.issueToken(u)
-	return u, tok, err
+	// familyID 传空字符串：每次登录开启一个新的令牌家族
+	pair, err := s.issueTokens(ctx, u, "")
+	return u, pair, err
 }
 
 // Login 实现用户登录逻辑
-func (s *authService) Login(email, password string) (model.User, string, error) {
+func (s *authService) Login(ctx context.Context, email, password string) (model.User, TokenPair, error) {
 	// 同样对邮箱进行标准化处理
 	email = strings.TrimSpace(strings.ToLower(email))
 
 	// 根据邮箱查询用户
-	u, err := s.users.GetByEmail(email)
+	u, err := s.users.GetByEmail(ctx, email)
 	if err != nil {
 		// 注意：不管是用户不存在还是其他错误，都返回相同的错误信息
 		// 这是安全最佳实践：不要泄露"用户是否存在"的信息
 		// 否则攻击者可以枚举有效的邮箱地址
-		return model.User{}, "", errors.New("invalid credentials")
+		return model.User{}, TokenPair{}, errInvalidCredentials
 	}
 
 	// bcrypt.CompareHashAndPassword 验证密码
@@ -112,12 +196,224 @@ func (s *authService) Login(email, password string) (model.User, string, error)
 	// 如果密码正确返回 nil，否则返回错误
 	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
 		// 密码错误，返回相同的错误信息（同样是安全考虑）
-		return model.User{}, "", errors.New("invalid credentials")
+		return model.User{}, TokenPair{}, errInvalidCredentials
+	}
+
+	// 停用、注销的账号放在密码检查之后：只有知道密码的人才能知道账号的状态
+	if u.DeletedAt != nil {
+		return model.User{}, TokenPair{}, ErrAccountDeleted
+	}
+	if u.DisabledAt != nil {
+		return model.User{}, TokenPair{}, ErrAccountDisabled
+	}
+
+	// 验证通过，颁发令牌
+	pair, err := s.issueTokens(ctx, u, "")
+	return u, pair, err
+}
+
+// Refresh 用刷新令牌换一对新令牌
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	rec, err := s.refreshTokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return TokenPair{}, ErrInvalidRefreshToken
+		}
+		return TokenPair{}, err
+	}
+
+	// 已经被吊销的令牌又被拿来用，说明它可能被别人偷走了：
+	// 合法用户和攻击者手里各有一个令牌，我们分不清谁是谁，
+	// 所以把整个家族都吊销，双方都需要重新登录
+	if rec.RevokedAt != nil {
+		if err := s.refreshTokens.RevokeFamily(ctx, rec.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if time.Now().After(rec.ExpiresAt) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	// 先吊销旧令牌再颁发新令牌
+	// Revoke 返回 false 说明并发请求已经用掉了这个令牌，同样按重复使用处理
+	ok, err := s.refreshTokens.Revoke(ctx, rec.ID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if !ok {
+		if err := s.refreshTokens.RevokeFamily(ctx, rec.FamilyID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	u, err := s.users.GetByID(ctx, rec.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return TokenPair{}, ErrInvalidRefreshToken
+		}
+		return TokenPair{}, err
+	}
+	// 停用、注销时已经吊销了所有刷新令牌，这里再挡一次，防止吊销失败或者和刷新同时发生
+	if u.DisabledAt != nil || u.DeletedAt != nil {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	return s.issueTokens(ctx, u, rec.FamilyID)
+}
+
+// Logout 吊销刷新令牌所在的家族
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	rec, err := s.refreshTokens.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.refreshTokens.RevokeFamily(ctx, rec.FamilyID)
+}
+
+// Me 获取当前用户
+// userID 来自 AuthRequired 中间件解析出的 JWT，用户可能在令牌签发后被删除，所以仍然要查库
+func (s *authService) Me(ctx context.Context, userID string) (model.User, error) {
+	return s.users.GetByID(ctx, userID)
+}
+
+// CheckSession 检查访问令牌所属的用户还能不能用
+// 每个请求查一次库，和 API 密钥的认证一样：停用、注销、降级马上生效，不用等访问令牌过期
+func (s *authService) CheckSession(ctx context.Context, userID string) (model.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return model.User{}, ErrSessionRevoked
 	}
+	if err != nil {
+		return model.User{}, err
+	}
+	if u.DisabledAt != nil || u.DeletedAt != nil {
+		return model.User{}, ErrSessionRevoked
+	}
+	return u, nil
+}
 
-	// 验证通过，颁发 JWT 令牌
-	tok, err := s.issueToken(u)
-	return u, tok, err
+// UpdateEmail 修改邮箱
+// 注意：已经签发的访问令牌里的 email 声明不会跟着变，它只用于展示，鉴权只看 sub（用户 ID）
+func (s *authService) UpdateEmail(ctx context.Context, userID, email, currentPassword string) (model.User, error) {
+	// 和注册时一样标准化邮箱，否则换个大小写就能绕过重复检查
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return model.User{}, invalidInput("email required")
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	// 和修改密码一样要验证当前密码：拿到访问令牌的人把邮箱改成自己的，
+	// 再走忘记密码就能把账号整个拿走
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(currentPassword)) != nil {
+		return model.User{}, ErrWrongPassword
+	}
+	if email == u.Email {
+		return u, nil
+	}
+	if u, err = s.users.UpdateEmail(ctx, userID, email); err != nil {
+		return model.User{}, conflict(err)
+	}
+
+	// 发到旧邮箱的重置令牌作废，所有设备上的登录也都失效
+	if err := s.resets.RevokeUser(ctx, userID); err != nil {
+		return model.User{}, err
+	}
+	if err := s.refreshTokens.RevokeUser(ctx, userID); err != nil {
+		return model.User{}, err
+	}
+	return u, nil
+}
+
+// ChangePassword 修改密码
+func (s *authService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) (TokenPair, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// 即使已经登录也要验证当前密码：
+	// 访问令牌可能被偷，或者用户离开时没锁屏，不能让拿到令牌的人直接改掉密码
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(oldPassword)) != nil {
+		return TokenPair{}, ErrWrongPassword
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return TokenPair{}, err
+	}
+	if oldPassword == newPassword {
+		return TokenPair{}, invalidInput("new password must differ from the current one")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if err := s.users.UpdatePassword(ctx, u.ID, string(hash)); err != nil {
+		return TokenPair{}, err
+	}
+
+	// 旧密码登录得到的刷新令牌全部作废，再给当前设备开一个新的令牌家族
+	if err := s.refreshTokens.RevokeUser(ctx, u.ID); err != nil {
+		return TokenPair{}, err
+	}
+	return s.issueTokens(ctx, u, "")
+}
+
+// ListUsers 列出所有用户
+func (s *authService) ListUsers(ctx context.Context) ([]model.User, error) {
+	return s.users.List(ctx)
+}
+
+// SetRole 修改系统角色
+// 不需要吊销刷新令牌：Refresh 每次都会重新查库拿到最新的角色，
+// 所以降级最多在一个访问令牌有效期（tokenTTL）之后生效
+func (s *authService) SetRole(ctx context.Context, userID, role string) (model.User, error) {
+	if role != model.UserRoleUser && role != model.UserRoleAdmin {
+		return model.User{}, invalidInput(fmt.Sprintf("invalid role %q", role))
+	}
+	return s.users.SetRole(ctx, userID, role)
+}
+
+// issueTokens 颁发访问令牌和刷新令牌
+// familyID 是刷新令牌所属的家族，登录时传空字符串新建，刷新时沿用
+func (s *authService) issueTokens(ctx context.Context, u model.User, familyID string) (TokenPair, error) {
+	access, err := s.issueToken(u)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, err := newOpaqueToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// 数据库里只存哈希
+	if _, err := s.refreshTokens.Create(ctx, u.ID, familyID, hashToken(refresh), time.Now().Add(s.refreshTTL)); err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// newOpaqueToken 生成一个不透明的随机令牌（刷新令牌、密码重置令牌都用它）
+// 32 字节随机数，用 crypto/rand 生成，不可预测；base64url 编码后可以直接放进 URL
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 计算令牌的 SHA-256 哈希（十六进制），数据库里只保存这个值
+// 令牌本身已经是高强度随机数，用 SHA-256 就够了，不需要 bcrypt 这样的慢哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // customClaims JWT 令牌中存储的自定义声明（Claims）
@@ -127,6 +423,9 @@ type customClaims struct {
 	// Email 用户邮箱（自定义字段）
 	Email string `json:"email"`
 
+	// Role 用户的系统角色，RequireRole 中间件据此判断能否访问管理员接口
+	Role string `json:"role,omitempty"`
+
 	// jwt.RegisteredClaims 嵌入标准声明
 	// Go 的嵌入（embedding）特性：customClaims 自动拥有 RegisteredClaims 的所有字段
 	// RegisteredClaims 包含：
@@ -145,6 +444,7 @@ func (s *authService) issueToken(u model.User) (string, error) {
 	// 构建 JWT Claims（声明）
 	claims := customClaims{
 		Email: u.Email, // 自定义字段：存储用户邮箱
+		Role:  u.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			// Subject（主题）：通常存储用户 ID
 			// 后续请求时可以从 JWT 中提取用户 ID，知道是哪个用户在访问
@@ -162,15 +462,10 @@ func (s *authService) issueToken(u model.User) (string, error) {
 		},
 	}
 
-	// jwt.NewWithClaims 创建令牌
-	// jwt.SigningMethodHS256: 使用 HMAC-SHA256 算法签名
-	// HS256 是对称加密：签名和验证使用同一个密钥
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// SignedString 生成最终的 JWT 字符串
-	// 使用密钥对令牌进行签名，确保令牌不被篡改
+	// 默认用 HS256（HMAC-SHA256）签名：对称加密，签名和验证使用同一个密钥
+	// 配置了私钥时用 RS256 / EdDSA：私钥签名、公钥验证，其它服务拿 JWKS 里的公钥就能验证
 	// 返回的字符串格式：header.payload.signature（三部分用 . 分隔）
-	return token.SignedString(s.jwtSecret)
+	return s.keys.Sign(claims)
 }
 
 // MustJWTSecret 获取 JWT 密钥
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestRegisterPasswordPolicy 注册和修改密码、重置密码用同一套密码强度规则
+func TestRegisterPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	auth := NewAuthService(repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo(), repository.NewMemPasswordResetRepo(), jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+
+	for _, pw := range []string{"password", "12345678", "abc123", string(make([]byte, 73))} {
+		if _, _, err := auth.Register(ctx, "weak@example.com", pw); !errors.Is(err, ErrWeakPassword) {
+			t.Fatalf("register with %q: err = %v, want ErrWeakPassword", pw, err)
+		}
+	}
+	if _, _, err := auth.Register(ctx, "weak@example.com", "password1"); err != nil {
+		t.Fatalf("register with a valid password: %v", err)
+	}
+}
+
+// TestUpdateEmail 修改邮箱要验证当前密码；改成功后新邮箱算没确认过，发到旧邮箱的重置令牌和所有刷新令牌都作废
+func TestUpdateEmail(t *testing.T) {
+	ctx := context.Background()
+	users, refreshTokens, resets := repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo(), repository.NewMemPasswordResetRepo()
+	auth := NewAuthService(users, refreshTokens, resets, jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+
+	u, pair, err := auth.Register(ctx, "old@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.SetEmailVerified(ctx, u.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := auth.Register(ctx, "taken@example.com", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	reset, err := resets.Create(ctx, u.ID, "hash", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.UpdateEmail(ctx, u.ID, "attacker@example.com", "wrong-password"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("update with wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if _, err := auth.UpdateEmail(ctx, u.ID, "taken@example.com", "password123"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("update to a taken email: err = %v, want ErrConflict", err)
+	}
+	if _, err := auth.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("refresh after failed updates: %v", err)
+	}
+
+	got, err := auth.UpdateEmail(ctx, u.ID, " New@Example.com", "password123")
+	if err != nil || got.Email != "new@example.com" || got.EmailVerifiedAt != nil {
+		t.Fatalf("update = %+v, %v, want the new email unverified", got, err)
+	}
+	if rec, err := resets.GetByHash(ctx, reset.TokenHash); err != nil || rec.UsedAt == nil {
+		t.Fatalf("reset token after email change = %+v, %v, want used", rec, err)
+	}
+	if _, err := auth.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after email change: err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, _, err := auth.Login(ctx, "new@example.com", "password123"); err != nil {
+		t.Fatalf("login with the new email: %v", err)
+	}
+}
@@ -2,85 +2,512 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"kanban_api/internal/model"
 	"kanban_api/internal/repository"
 	"strings"
+	"time"
 )
 
+// maxSlugLen slug 的最大长度，太长的链接不方便分享
+const maxSlugLen = 60
+
+// maxInitialLists 创建看板时最多一起创建多少个列表
+const maxInitialLists = 20
+
+// BoardInput 创建看板时的输入
+type BoardInput struct {
+	Title string
+
+	// Lists 和看板一起创建的列表标题，按顺序排列；为空时看 DefaultLists
+	Lists []string
+
+	// DefaultLists 为 true 且没有传 Lists 时，创建服务端配置的默认列表（BOARD_DEFAULT_LISTS）
+	DefaultLists bool
+
+	// Sample 在列表里生成一组示例卡片（见 board_sample.go），没有指定列表时同时创建示例的列表
+	Sample bool
+}
+
 // BoardService 看板服务接口
 // 定义看板相关的业务操作
+// 每个方法的第一个参数 userID 是当前登录用户的 ID（来自认证中间件），
+// 用户能看到自己的看板和别人分享给自己的看板，能做哪些操作取决于角色（见 model.RoleOwner 等）
 type BoardService interface {
-	// ListBoards 列出所有看板
-	ListBoards() ([]model.Board, error)
+	// ListBoards 列出用户的所有看板，包括别人分享给用户的看板
+	ListBoards(ctx context.Context, userID string) ([]model.Board, error)
+
+	// GetBoard 获取用户的单个看板
+	GetBoard(ctx context.Context, userID, id string) (model.Board, error)
+
+	// GetBoardBySlug 通过 slug 获取用户自己的单个看板
+	GetBoardBySlug(ctx context.Context, userID, slug string) (model.Board, error)
+
+	// CreateBoard 为用户创建新看板，按 in.Lists / in.DefaultLists 一起创建列表（填在返回的 Board.Lists 里）
+	// 列表创建失败时删掉刚创建的看板，不留下只有一半列表的看板
+	CreateBoard(ctx context.Context, userID string, in BoardInput) (model.Board, error)
+
+	// ImportBoard 为用户创建看板并导入其中的列表、卡片和检查清单，见 board_import.go
+	// 数据有问题时返回 ErrInvalidInput，什么都不创建
+	ImportBoard(ctx context.Context, userID string, in BoardImport) (ImportResult, error)
+
+	// ClearSample 删除看板上创建时生成的示例卡片（model.Card.Sample），需要 editor 或 owner 角色
+	// 返回删除了的卡片；中途出错时返回已经删除了的部分和错误
+	ClearSample(ctx context.Context, userID, boardID string) ([]model.Card, error)
+
+	// UpdateBoard 更新看板，需要 editor 或 owner 角色
+	// slug 为空时保留原来的 slug
+	// version 是客户端读到的看板版本，看板已经被别人修改过时返回 ErrConflict（Cause 是 ErrVersionConflict）；
+	// 传 0 表示不检查版本
+	UpdateBoard(ctx context.Context, userID, id, title, slug string, version int64) (model.Board, error)
 
-	// GetBoard 获取单个看板
-	GetBoard(id string) (model.Board, error)
+	// PatchBoard 部分更新看板，只修改 p 里不为 nil 的字段，权限和 version 同 UpdateBoard
+	// 一个字段都没传时不修改，直接返回看板（版本不对时同样返回 ErrConflict）
+	PatchBoard(ctx context.Context, userID, id string, p BoardPatch, version int64) (model.Board, error)
 
-	// CreateBoard 创建新看板
-	CreateBoard(title string) (model.Board, error)
+	// DeleteBoard 删除看板，只有所有者可以删除
+	// 卡片数量超过阈值的看板需要两步删除：
+	// confirmToken 为空时不删除，返回确认令牌和影响范围；带上确认令牌再调用一次才真正删除
+	// 返回 nil 表示已经删除
+	DeleteBoard(ctx context.Context, userID, id, confirmToken string) (*DeleteConfirmation, error)
 
-	// UpdateBoard 更新看板
-	UpdateBoard(id, title string) (model.Board, error)
+	// AdminDeleteBoard 管理员删除任意看板，不检查所有者，也不需要二次确认
+	// 权限检查由路由上的 RequireRole("admin") 负责
+	AdminDeleteBoard(ctx context.Context, id string) error
 
-	// DeleteBoard 删除看板
-	DeleteBoard(id string) error
+	// AdminSetBoardState 管理员修改任意看板的状态（model.BoardStateActive 等），reason 是给用户看的原因，可以为空
+	// adminID 是操作的管理员，记在看板动态里
+	// 只读、停用的看板上的操作会返回 ErrBoardReadOnly、ErrBoardSuspended，见 checkState
+	AdminSetBoardState(ctx context.Context, adminID, id, state, reason string) (model.Board, error)
+
+	// TransferBoard 把 ownerID 的看板转给 newOwnerID，newOwnerID 必须是看板成员
+	// 不检查调用方的权限，只给注销账号的清理任务使用（见 AccountDeleter）；原所有者之后不能再访问看板
+	TransferBoard(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error)
+}
+
+// BoardPatch 部分更新看板时的输入，字段为 nil 表示不修改
+type BoardPatch struct {
+	// Title 新标题，不能是空的
+	Title *string
+
+	// Slug 新 slug；传空串表示按（修改后的）标题重新生成，和创建看板时一样，被占用时自动加后缀
+	Slug *string
 }
 
 // boardService 看板服务的具体实现
 type boardService struct {
 	// repo 看板仓储，用于数据访问
 	repo repository.BoardRepository
+
+	// lists、cards、checklists、attachments 列表、卡片、检查清单和附件仓储，删除看板时一并删除它们
+	lists       repository.ListRepository
+	cards       repository.CardRepository
+	checklists  repository.ChecklistRepository
+	attachments repository.AttachmentRepository
+
+	// templates 卡片模板仓储，删除看板时一并删除
+	templates repository.CardTemplateRepository
+
+	// storage 附件占用空间的计数器，删除看板时一并删除
+	storage *StorageCounters
+
+	// members 成员仓储，access 用它判断用户对看板的角色
+	members repository.MemberRepository
+	access  boardAccess
+
+	// confirmKey 删除确认令牌的签名密钥，由 JWT 密钥派生
+	confirmKey []byte
+
+	// confirmThreshold 卡片数量超过这个值的看板，删除时需要二次确认
+	confirmThreshold int
+
+	// defaultLists 创建看板时选了默认列表（BoardInput.DefaultLists）时创建的列表标题
+	defaultLists []string
 }
 
 // NewBoardService 创建看板服务实例
-func NewBoardService(repo repository.BoardRepository) BoardService {
-	return &boardService{repo: repo}
+// confirmThreshold：卡片数量超过它的看板删除时需要确认，见 DeleteBoard
+// defaultLists：创建看板时选了默认列表时创建的列表标题，为空时选了也不创建
+func NewBoardService(repo repository.BoardRepository, lists repository.ListRepository, cards repository.CardRepository, checklists repository.ChecklistRepository, attachments repository.AttachmentRepository, templates repository.CardTemplateRepository, storage *StorageCounters, members repository.MemberRepository, jwtSecret []byte, confirmThreshold int, defaultLists []string) BoardService {
+	return &boardService{
+		repo:             repo,
+		lists:            lists,
+		cards:            cards,
+		checklists:       checklists,
+		attachments:      attachments,
+		templates:        templates,
+		storage:          storage,
+		members:          members,
+		access:           boardAccess{boards: repo, members: members},
+		confirmKey:       deriveKey("board-delete:", jwtSecret),
+		confirmThreshold: confirmThreshold,
+		defaultLists:     defaultLists,
+	}
 }
 
-// ListBoards 列出所有看板
-// 这个方法比较简单，直接调用仓储层
-func (s *boardService) ListBoards() ([]model.Board, error) {
-	return s.repo.List()
+// ListBoards 列出用户的所有看板
+// 先列出自己的看板，后面接着别人分享给自己的看板
+func (s *boardService) ListBoards(ctx context.Context, userID string) ([]model.Board, error) {
+	own, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.members.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return own, nil
+	}
+	ids := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		ids = append(ids, m.BoardID)
+	}
+	// 一次查询取出所有共享看板，避免每个看板查一次
+	shared, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return append(own, shared...), nil
 }
 
-// GetBoard 获取单个看板
-// 同样直接调用仓储层
-func (s *boardService) GetBoard(id string) (model.Board, error) {
-	return s.repo.Get(id)
+// GetBoard 获取用户的单个看板
+// 所有者和成员（包括只读成员）都可以查看
+func (s *boardService) GetBoard(ctx context.Context, userID, id string) (model.Board, error) {
+	b, _, err := s.access.check(ctx, userID, id, model.RoleViewer)
+	return b, err
+}
+
+// GetBoardBySlug 通过 slug 获取用户的单个看板
+func (s *boardService) GetBoardBySlug(ctx context.Context, userID, slug string) (model.Board, error) {
+	b, err := s.repo.GetBySlug(ctx, userID, strings.ToLower(slug))
+	if err != nil {
+		return model.Board{}, err
+	}
+	return b, checkState(b, model.RoleViewer)
 }
 
 // CreateBoard 创建新看板
 // Service 层负责业务验证
-func (s *boardService) CreateBoard(title string) (model.Board, error) {
+// 看板和列表可能保存在不同的数据库里（见 DB_DRIVER），没法放进一个事务，
+// 所以和导入看板一样先检查列表标题，创建列表失败时把刚创建的看板删掉
+func (s *boardService) CreateBoard(ctx context.Context, userID string, in BoardInput) (model.Board, error) {
 	// 清理标题：去除首尾空格
-	title = strings.TrimSpace(title)
+	title := strings.TrimSpace(in.Title)
 
 	// 业务规则验证：标题不能为空
 	// 这是 Service 层的职责：确保数据符合业务规则
 	if title == "" {
-		return model.Board{}, errors.New("title required")
+		return model.Board{}, invalidInput("title required")
+	}
+	lists, err := s.initialLists(in)
+	if err != nil {
+		return model.Board{}, err
+	}
+
+	// 根据标题生成唯一的 slug
+	slug, err := s.uniqueSlug(ctx, slugify(title))
+	if err != nil {
+		return model.Board{}, err
 	}
 
 	// 验证通过，调用仓储层创建
-	return s.repo.Create(title)
+	// 两个请求同时生成了同一个 slug 时，后写入的一个会撞上唯一约束
+	b, err := s.repo.Create(ctx, userID, title, slug)
+	if err != nil {
+		return model.Board{}, conflict(err)
+	}
+	for _, t := range lists {
+		l, err := s.lists.Create(ctx, b.ID, t)
+		if err != nil {
+			_ = s.deleteCascade(ctx, userID, b.ID)
+			return model.Board{}, err
+		}
+		b.Lists = append(b.Lists, l)
+	}
+	if in.Sample {
+		if err := s.addSample(ctx, b, time.Now()); err != nil {
+			_ = s.deleteCascade(ctx, userID, b.ID)
+			return model.Board{}, err
+		}
+	}
+	return b, nil
+}
+
+// initialLists 创建看板时要一起创建的列表标题：传了 Lists 用传的，否则选了默认列表时用配置的，
+// 都没有又要示例内容时用示例的列表
+func (s *boardService) initialLists(in BoardInput) ([]string, error) {
+	titles := in.Lists
+	if len(titles) == 0 && in.DefaultLists {
+		titles = s.defaultLists
+	}
+	if len(titles) == 0 && in.Sample {
+		titles = sampleContent.Lists
+	}
+	if len(titles) > maxInitialLists {
+		return nil, invalidInput(fmt.Sprintf("at most %d lists", maxInitialLists))
+	}
+	out := make([]string, 0, len(titles))
+	for i, t := range titles {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, invalidInput(fmt.Sprintf("lists[%d]: title required", i))
+		}
+		out = append(out, t)
+	}
+	return out, nil
 }
 
 // UpdateBoard 更新看板
-func (s *boardService) UpdateBoard(id, title string) (model.Board, error) {
+// 修改标题不会自动修改 slug，避免已经分享出去的链接失效
+func (s *boardService) UpdateBoard(ctx context.Context, userID, id, title, slug string, version int64) (model.Board, error) {
 	// 同样进行数据清理和验证
 	title = strings.TrimSpace(title)
 	if title == "" {
-		return model.Board{}, errors.New("title required")
+		return model.Board{}, invalidInput("title required")
 	}
 
-	return s.repo.Update(id, title)
+	b, _, err := s.access.check(ctx, userID, id, model.RoleEditor)
+	if err != nil {
+		return model.Board{}, err
+	}
+
+	// 没有传 slug 时保留原值；传了就规范化后使用
+	// 用户手动指定的 slug 不自动加后缀，冲突时直接报错，让用户自己决定
+	if strings.TrimSpace(slug) == "" {
+		slug = b.Slug
+	} else {
+		slug = slugify(slug)
+		if slug == "" {
+			return model.Board{}, invalidInput("invalid slug")
+		}
+	}
+
+	// 编辑者修改的是别人的看板，仓储层按所有者过滤，所以传看板的 OwnerID
+	// 版本在仓储层检查：检查和修改是同一条 UPDATE，这里先比较的话两个请求可能同时通过
+	b, err = s.repo.Update(ctx, b.OwnerID, id, repository.BoardChanges{Title: &title, Slug: &slug}, version)
+	return b, conflict(err)
+}
+
+// PatchBoard 部分更新看板
+func (s *boardService) PatchBoard(ctx context.Context, userID, id string, p BoardPatch, version int64) (model.Board, error) {
+	var ch repository.BoardChanges
+	if p.Title != nil {
+		title := strings.TrimSpace(*p.Title)
+		if title == "" {
+			return model.Board{}, invalidInput("title required")
+		}
+		ch.Title = &title
+	}
+	// 和 UpdateBoard 一样，手动指定的 slug 冲突时直接报错
+	if p.Slug != nil && strings.TrimSpace(*p.Slug) != "" {
+		slug := slugify(*p.Slug)
+		if slug == "" {
+			return model.Board{}, invalidInput("invalid slug")
+		}
+		ch.Slug = &slug
+	}
+
+	b, _, err := s.access.check(ctx, userID, id, model.RoleEditor)
+	if err != nil {
+		return model.Board{}, err
+	}
+
+	if p.Slug != nil && ch.Slug == nil {
+		title := b.Title
+		if ch.Title != nil {
+			title = *ch.Title
+		}
+		// 重新生成的和现在的一样时保留，否则 uniqueSlug 会认为它被（自己）占用了，加上后缀
+		slug := slugify(title)
+		if slug == "" {
+			slug = "board"
+		}
+		if slug != b.Slug {
+			if slug, err = s.uniqueSlug(ctx, slug); err != nil {
+				return model.Board{}, err
+			}
+		}
+		ch.Slug = &slug
+	}
+
+	if ch.Title == nil && ch.Slug == nil {
+		if version != 0 && version != b.Version {
+			return model.Board{}, conflict(repository.ErrVersionConflict)
+		}
+		return b, nil
+	}
+	b, err = s.repo.Update(ctx, b.OwnerID, id, ch, version)
+	return b, conflict(err)
 }
 
 // DeleteBoard 删除看板
-func (s *boardService) DeleteBoard(id string) error {
-	// 直接调用仓储层删除
-	// 如果需要更复杂的业务逻辑（例如：删除看板前要先删除所有任务），
-	// 就在这里添加
-	return s.repo.Delete(id)
+func (s *boardService) DeleteBoard(ctx context.Context, userID, id, confirmToken string) (*DeleteConfirmation, error) {
+	// 先确认用户是看板所有者，不能给别人的看板签发确认令牌
+	// 成员能看到看板但不能删除，返回 ErrForbidden
+	// 带着确认令牌时也要再查一次：令牌签发之后看板可能被管理员设成只读或停用
+	if _, _, err := s.access.check(ctx, userID, id, model.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	// 带了确认令牌就校验令牌；否则看卡片数量决定要不要先确认
+	if confirmToken != "" {
+		if err := s.checkDeleteToken(confirmToken, userID, id); err != nil {
+			return nil, err
+		}
+	} else {
+		cards, err := s.cards.CountByBoard(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if cards > s.confirmThreshold {
+			lists, err := s.lists.ListByBoard(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return s.newDeleteConfirmation(userID, id, len(lists), cards)
+		}
+	}
+
+	return nil, s.deleteCascade(ctx, userID, id)
+}
+
+// AdminDeleteBoard 管理员删除看板
+func (s *boardService) AdminDeleteBoard(ctx context.Context, id string) error {
+	// 仓储层的 Delete 按所有者过滤，所以先不分用户查出看板拿到 OwnerID
+	boards, err := s.repo.ListByIDs(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if len(boards) == 0 {
+		return repository.ErrNotFound
+	}
+	return s.deleteCascade(ctx, boards[0].OwnerID, id)
+}
+
+// AdminSetBoardState 管理员修改看板状态
+// 恢复成 active 时清掉原因，避免旧的原因继续显示
+func (s *boardService) AdminSetBoardState(ctx context.Context, adminID, id, state, reason string) (model.Board, error) {
+	switch state {
+	case model.BoardStateActive:
+		reason = ""
+	case model.BoardStateReadOnly, model.BoardStateSuspended:
+		reason = strings.TrimSpace(reason)
+	default:
+		return model.Board{}, invalidInput("invalid state")
+	}
+	return s.repo.SetState(ctx, id, state, reason)
+}
+
+// TransferBoard 转让看板
+// 先改所有者再删除新所有者的成员记录：中间失败时新所有者同时是所有者和成员，按所有者处理，不影响使用；
+// 反过来的话中间失败会让新所有者暂时失去访问权限
+func (s *boardService) TransferBoard(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	if _, err := s.members.Get(ctx, id, newOwnerID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.Board{}, invalidInput("new owner must be a member of the board")
+		}
+		return model.Board{}, err
+	}
+	b, err := s.repo.SetOwner(ctx, ownerID, id, newOwnerID)
+	if err != nil {
+		return model.Board{}, err
+	}
+	if err := s.members.Remove(ctx, id, newOwnerID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return model.Board{}, err
+	}
+	return b, nil
+}
+
+// deleteCascade 删除看板及其下的卡片、清单、附件、成员和列表
+func (s *boardService) deleteCascade(ctx context.Context, ownerID, id string) error {
+	// 清单和附件只记着卡片 ID，先逐个列表查出卡片 ID
+	lists, err := s.lists.ListByBoard(ctx, id)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, l := range lists {
+		cards, err := s.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, cardIDs(cards)...)
+	}
+
+	// 先删除看板本身（仓储层会校验看板属于该用户）
+	if err := s.repo.Delete(ctx, ownerID, id); err != nil {
+		return err
+	}
+
+	// 再删除看板下的所有卡片和列表
+	// 如果需要更复杂的业务逻辑，也在这里添加
+	if err := s.cards.DeleteByBoard(ctx, id); err != nil {
+		return err
+	}
+	if err := s.checklists.DeleteByCards(ctx, ids); err != nil {
+		return err
+	}
+	if err := s.attachments.DeleteByCards(ctx, ids); err != nil {
+		return err
+	}
+	s.storage.remove(ctx, id)
+	if err := s.templates.DeleteByBoard(ctx, id); err != nil {
+		return err
+	}
+	if err := s.members.DeleteByBoard(ctx, id); err != nil {
+		return err
+	}
+	return s.lists.DeleteByBoard(ctx, id)
+}
+
+// slugify 将任意字符串转换为 URL 安全的 slug
+// 规则：字母转小写，字母和数字保留，其它字符统一替换成 "-"，
+// 连续的 "-" 合并为一个，并去掉首尾的 "-"
+// 例如："Project Alpha!" -> "project-alpha"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		// 非 ASCII 字母数字（包括中文）都当作分隔符处理
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	out := strings.Trim(b.String(), "-")
+	if len(out) > maxSlugLen {
+		out = strings.Trim(out[:maxSlugLen], "-")
+	}
+	return out
+}
+
+// uniqueSlug 在 base 的基础上生成一个未被占用的 slug
+// 如果 base 已存在，依次尝试 base-2、base-3……
+// 标题全是中文等情况会得到空的 base，这时使用 "board" 兜底
+func (s *boardService) uniqueSlug(ctx context.Context, base string) (string, error) {
+	if base == "" {
+		base = "board"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := s.repo.SlugExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
 }
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"crypto/sha256"
+	"github.com/golang-jwt/jwt/v5"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// deleteConfirmAudience 删除确认令牌的 aud，防止和其它令牌混用
+const deleteConfirmAudience = "board-delete"
+
+// deleteConfirmTTL 删除确认令牌的有效期
+// 足够用户看清影响范围再点确认，又不会长到被拿去误用
+const deleteConfirmTTL = 5 * time.Minute
+
+// ErrInvalidConfirmToken 删除确认令牌无效、过期，或者不是签发给这个看板的
+var ErrInvalidConfirmToken = invalidInput("invalid or expired confirm token")
+
+// DeleteConfirmation 删除大看板时第一步的返回结果
+// 客户端应该把影响范围展示给用户，用户确认后带上 Token 再发一次删除请求
+type DeleteConfirmation struct {
+	// Token 确认令牌
+	Token string `json:"confirmToken"`
+
+	// ExpiresAt 确认令牌的过期时间
+	ExpiresAt model.Timestamp `json:"expiresAt"`
+
+	// Lists、Cards 删除看板会连带删除的列表和卡片数量
+	Lists int `json:"lists"`
+	Cards int `json:"cards"`
+}
+
+// deleteClaims 删除确认令牌的声明
+// Subject 是看板 ID，UserID 是发起删除的用户，两者都要和第二次请求一致
+type deleteClaims struct {
+	UserID string `json:"uid"`
+	jwt.RegisteredClaims
+}
+
+// deriveKey 从 JWT 密钥派生出某一用途专用的签名密钥
+// 不同用途的令牌用不同的密钥签名，一种令牌就不能被当作另一种使用
+func deriveKey(purpose string, secret []byte) []byte {
+	sum := sha256.Sum256(append([]byte(purpose), secret...))
+	return sum[:]
+}
+
+// newDeleteConfirmation 签发删除确认令牌
+// 令牌是无状态的：服务端不保存，靠签名和过期时间校验
+// 看板删除之后同一个令牌自然就没用了，不需要单独记录"已使用"
+func (s *boardService) newDeleteConfirmation(userID, boardID string, lists, cards int) (*DeleteConfirmation, error) {
+	now := time.Now()
+	exp := now.Add(deleteConfirmTTL)
+	claims := deleteClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   boardID,
+			Audience:  jwt.ClaimStrings{deleteConfirmAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Issuer:    "kanban_api",
+		},
+	}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.confirmKey)
+	if err != nil {
+		return nil, err
+	}
+	return &DeleteConfirmation{Token: tok, ExpiresAt: model.Timestamp(exp), Lists: lists, Cards: cards}, nil
+}
+
+// checkDeleteToken 校验删除确认令牌是否签发给这个用户的这个看板
+func (s *boardService) checkDeleteToken(token, userID, boardID string) error {
+	var claims deleteClaims
+	tok, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.confirmKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(deleteConfirmAudience),
+	)
+	if err != nil || !tok.Valid {
+		return ErrInvalidConfirmToken
+	}
+	if claims.UserID != userID || claims.Subject != boardID {
+		return ErrInvalidConfirmToken
+	}
+	return nil
+}
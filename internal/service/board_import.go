@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kanban_api/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 一次导入的数量上限，防止一个请求写入过多数据
+const (
+	maxImportLists = 500
+	maxImportCards = 10000
+	maxImportItems = 50000
+)
+
+// BoardImport 要导入的看板，和来源格式无关
+// 各种格式（BoardExport、TrelloBoard）先转换成它，ID 的对应关系在转换时已经处理掉，
+// 这里只剩按顺序排好的嵌套结构
+type BoardImport struct {
+	Title string
+	Lists []ImportList
+}
+
+// ImportList 要导入的列表，Cards 按位置排序
+type ImportList struct {
+	Title string
+	Cards []ImportCard
+}
+
+// ImportCard 要导入的卡片，Checklists 按位置排序
+type ImportCard struct {
+	Title       string
+	Description string
+	DueDate     *time.Time
+	Reminder    bool
+	Labels      []string
+	Checklists  []ImportChecklist
+}
+
+// ImportChecklist 要导入的检查清单，Items 按位置排序
+type ImportChecklist struct {
+	Title string
+	Items []ImportChecklistItem
+}
+
+// ImportChecklistItem 要导入的清单条目
+type ImportChecklistItem struct {
+	Text string
+	Done bool
+}
+
+// ImportResult 导入结果：新看板和导入的数量
+type ImportResult struct {
+	Board      model.Board `json:"board"`
+	Lists      int         `json:"lists"`
+	Cards      int         `json:"cards"`
+	Checklists int         `json:"checklists"`
+}
+
+// BoardExport 本服务的看板导出格式
+// 就是各个查询接口返回的对象放在一起，客户端把 GET 看板、列表、卡片、清单的结果拼起来就是一份导出：
+//
+//	{"board": {...}, "lists": [...], "cards": [...], "checklists": [...]}
+//
+// 卡片通过 listId、清单通过 cardId 引用导出里的列表和卡片，导入时换成新的 ID
+// position 只用来排序，其它只读字段（createdAt、进度、提醒时间等）会被忽略
+type BoardExport struct {
+	Board      model.Board       `json:"board"`
+	Lists      []model.List      `json:"lists"`
+	Cards      []model.Card      `json:"cards"`
+	Checklists []model.Checklist `json:"checklists"`
+}
+
+// BoardImport 把导出转换成要导入的看板
+// 引用了不存在的列表、卡片，或者 ID 重复时返回 ErrInvalidInput，错误里带着出错的位置
+func (e BoardExport) BoardImport() (BoardImport, error) {
+	lists := append([]model.List{}, e.Lists...)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Position < lists[j].Position })
+	cards := append([]model.Card{}, e.Cards...)
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].Position < cards[j].Position })
+	checklists := append([]model.Checklist{}, e.Checklists...)
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Position < checklists[j].Position })
+
+	// 旧 ID -> 在结果中的下标
+	listAt := make(map[string]int, len(lists))
+	out := BoardImport{Title: e.Board.Title, Lists: make([]ImportList, 0, len(lists))}
+	for i, l := range lists {
+		if _, dup := listAt[l.ID]; dup || l.ID == "" {
+			return BoardImport{}, invalidInput(fmt.Sprintf("lists[%d].id missing or duplicated", i))
+		}
+		listAt[l.ID] = len(out.Lists)
+		out.Lists = append(out.Lists, ImportList{Title: l.Title})
+	}
+
+	type cardRef struct{ list, card int }
+	cardAt := make(map[string]cardRef, len(cards))
+	for i, c := range cards {
+		li, ok := listAt[c.ListID]
+		if !ok {
+			return BoardImport{}, invalidInput(fmt.Sprintf("cards[%d].listId: unknown list", i))
+		}
+		if _, dup := cardAt[c.ID]; dup || c.ID == "" {
+			return BoardImport{}, invalidInput(fmt.Sprintf("cards[%d].id missing or duplicated", i))
+		}
+		labels, err := cleanLabels(c.Labels)
+		if err != nil {
+			return BoardImport{}, invalidInput(fmt.Sprintf("cards[%d].labels: %v", i, err))
+		}
+		cardAt[c.ID] = cardRef{li, len(out.Lists[li].Cards)}
+		out.Lists[li].Cards = append(out.Lists[li].Cards, ImportCard{
+			Title:       c.Title,
+			Description: c.Description,
+			DueDate:     c.DueDate,
+			Reminder:    c.Reminder,
+			Labels:      labels,
+		})
+	}
+
+	for i, cl := range checklists {
+		ref, ok := cardAt[cl.CardID]
+		if !ok {
+			return BoardImport{}, invalidInput(fmt.Sprintf("checklists[%d].cardId: unknown card", i))
+		}
+		items := append([]model.ChecklistItem{}, cl.Items...)
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Position < items[j].Position })
+		ic := ImportChecklist{Title: cl.Title, Items: make([]ImportChecklistItem, 0, len(items))}
+		for _, it := range items {
+			ic.Items = append(ic.Items, ImportChecklistItem{Text: it.Text, Done: it.Done})
+		}
+		card := &out.Lists[ref.list].Cards[ref.card]
+		card.Checklists = append(card.Checklists, ic)
+	}
+	return out, nil
+}
+
+// TrelloBoard Trello 看板导出（看板菜单 -> 打印、导出和共享 -> 导出为 JSON）中用到的部分
+// 已归档（closed）的列表和卡片不导入；标签、成员、附件、评论这些本服务没有对应功能的内容会被忽略
+type TrelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string  `json:"id"`
+		Name   string  `json:"name"`
+		Closed bool    `json:"closed"`
+		Pos    float64 `json:"pos"`
+	} `json:"lists"`
+	Cards []struct {
+		ID     string     `json:"id"`
+		IDList string     `json:"idList"`
+		Name   string     `json:"name"`
+		Desc   string     `json:"desc"`
+		Closed bool       `json:"closed"`
+		Pos    float64    `json:"pos"`
+		Due    *time.Time `json:"due"`
+
+		// DueReminder 到期前多少分钟提醒，-1 或者没有表示不提醒
+		DueReminder *int `json:"dueReminder"`
+	} `json:"cards"`
+	Checklists []struct {
+		IDCard     string  `json:"idCard"`
+		Name       string  `json:"name"`
+		Pos        float64 `json:"pos"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"` // complete 或 incomplete
+			Pos   float64 `json:"pos"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+// BoardImport 把 Trello 导出转换成要导入的看板
+// Trello 的 pos 是浮点数，只用来排序
+func (t TrelloBoard) BoardImport() (BoardImport, error) {
+	lists := append(t.Lists[:0:0], t.Lists...)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+	cards := append(t.Cards[:0:0], t.Cards...)
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].Pos < cards[j].Pos })
+	checklists := append(t.Checklists[:0:0], t.Checklists...)
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+
+	listAt := make(map[string]int, len(lists))
+	out := BoardImport{Title: t.Name, Lists: make([]ImportList, 0, len(lists))}
+	for _, l := range lists {
+		if l.Closed {
+			continue
+		}
+		listAt[l.ID] = len(out.Lists)
+		out.Lists = append(out.Lists, ImportList{Title: l.Name})
+	}
+
+	type cardRef struct{ list, card int }
+	cardAt := make(map[string]cardRef, len(cards))
+	for _, c := range cards {
+		// 卡片在已归档的列表里时一起跳过
+		li, ok := listAt[c.IDList]
+		if c.Closed || !ok {
+			continue
+		}
+		cardAt[c.ID] = cardRef{li, len(out.Lists[li].Cards)}
+		out.Lists[li].Cards = append(out.Lists[li].Cards, ImportCard{
+			Title:       c.Name,
+			Description: c.Desc,
+			DueDate:     c.Due,
+			Reminder:    c.Due != nil && c.DueReminder != nil && *c.DueReminder >= 0,
+		})
+	}
+
+	for _, cl := range checklists {
+		ref, ok := cardAt[cl.IDCard]
+		if !ok {
+			continue
+		}
+		items := append(cl.CheckItems[:0:0], cl.CheckItems...)
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+		ic := ImportChecklist{Title: cl.Name, Items: make([]ImportChecklistItem, 0, len(items))}
+		for _, it := range items {
+			ic.Items = append(ic.Items, ImportChecklistItem{Text: it.Name, Done: it.State == "complete"})
+		}
+		card := &out.Lists[ref.list].Cards[ref.card]
+		card.Checklists = append(card.Checklists, ic)
+	}
+	return out, nil
+}
+
+// cleanImport 规范化并检查要导入的看板，错误里带着出错的位置，例如 lists[0].cards[3].title
+// 在写入任何数据之前完成，数据有问题时什么都不创建
+func cleanImport(in BoardImport) (BoardImport, error) {
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Title == "" {
+		return in, invalidInput("title required")
+	}
+	if len(in.Lists) > maxImportLists {
+		return in, invalidInput(fmt.Sprintf("at most %d lists", maxImportLists))
+	}
+
+	cards, items := 0, 0
+	for i := range in.Lists {
+		l := &in.Lists[i]
+		at := fmt.Sprintf("lists[%d]", i)
+		if l.Title = strings.TrimSpace(l.Title); l.Title == "" {
+			return in, invalidInput(at + ".title required")
+		}
+		cards += len(l.Cards)
+		for j := range l.Cards {
+			c := &l.Cards[j]
+			cat := fmt.Sprintf("%s.cards[%d]", at, j)
+			if c.Title = strings.TrimSpace(c.Title); c.Title == "" {
+				return in, invalidInput(cat + ".title required")
+			}
+			for k := range c.Checklists {
+				cl := &c.Checklists[k]
+				clat := fmt.Sprintf("%s.checklists[%d]", cat, k)
+				var err error
+				if cl.Title, err = cleanChecklistTitle(cl.Title); err != nil {
+					return in, invalidInput(clat + "." + err.Error())
+				}
+				items += len(cl.Items)
+				for m := range cl.Items {
+					if cl.Items[m].Text, err = cleanItemText(cl.Items[m].Text); err != nil {
+						return in, invalidInput(fmt.Sprintf("%s.items[%d].%s", clat, m, err.Error()))
+					}
+				}
+			}
+		}
+	}
+	if cards > maxImportCards {
+		return in, invalidInput(fmt.Sprintf("at most %d cards", maxImportCards))
+	}
+	if items > maxImportItems {
+		return in, invalidInput(fmt.Sprintf("at most %d checklist items", maxImportItems))
+	}
+	return in, nil
+}
+
+// ImportBoard 导入看板
+// 看板、列表、卡片可能保存在不同的数据库里（见 DB_DRIVER），没法放进一个事务，
+// 所以先把数据全部检查一遍，写入时任何一步失败都把已经创建的看板连同其中的内容删掉，不留下导入了一半的看板
+func (s *boardService) ImportBoard(ctx context.Context, userID string, in BoardImport) (ImportResult, error) {
+	in, err := cleanImport(in)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	b, err := s.CreateBoard(ctx, userID, BoardInput{Title: in.Title})
+	if err != nil {
+		return ImportResult{}, err
+	}
+	res := ImportResult{Board: b}
+	if err := s.importContent(ctx, b.ID, in, &res); err != nil {
+		_ = s.deleteCascade(ctx, userID, b.ID)
+		return ImportResult{}, err
+	}
+	return res, nil
+}
+
+// importContent 按顺序创建列表、卡片、清单和条目
+// 仓储层新建时都追加到末尾，按顺序创建出来的位置就是导入数据里的顺序
+func (s *boardService) importContent(ctx context.Context, boardID string, in BoardImport, res *ImportResult) error {
+	for _, il := range in.Lists {
+		l, err := s.lists.Create(ctx, boardID, il.Title)
+		if err != nil {
+			return err
+		}
+		res.Lists++
+
+		for _, ic := range il.Cards {
+			c, err := s.cards.Create(ctx, model.Card{
+				BoardID:     boardID,
+				ListID:      l.ID,
+				Title:       ic.Title,
+				Description: ic.Description,
+				DueDate:     ic.DueDate,
+				Reminder:    ic.Reminder,
+				Labels:      ic.Labels,
+			})
+			if err != nil {
+				return err
+			}
+			res.Cards++
+
+			for _, icl := range ic.Checklists {
+				cl, err := s.checklists.Create(ctx, c.ID, icl.Title)
+				if err != nil {
+					return err
+				}
+				res.Checklists++
+
+				for _, it := range icl.Items {
+					item, err := s.checklists.AddItem(ctx, cl.ID, it.Text)
+					if err != nil {
+						return err
+					}
+					if it.Done {
+						item.Done = true
+						if _, err := s.checklists.UpdateItem(ctx, item); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kanban_api/internal/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// export 按 BoardExport 的说明把各个查询的结果拼成一份导出，再经过一次 JSON，和客户端拿到的一样
+func (f boardFixture) export(t *testing.T, b model.Board) BoardExport {
+	t.Helper()
+	ctx := context.Background()
+	e := BoardExport{Board: b}
+	lists, err := f.lists.ListByBoard(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Lists = lists
+	for _, l := range lists {
+		cards, err := f.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Cards = append(e.Cards, cards...)
+		for _, c := range cards {
+			cls, err := f.checklists.ListByCard(ctx, c.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.Checklists = append(e.Checklists, cls...)
+		}
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out BoardExport
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// seed 准备一个有顺序、截止日期、提醒、清单和完成状态的看板
+func (f boardFixture) seed(t *testing.T, userID string) model.Board {
+	t.Helper()
+	ctx := context.Background()
+	b, err := f.svc.CreateBoard(ctx, userID, BoardInput{Title: "Roadmap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2026, 11, 1, 9, 30, 0, 0, time.UTC)
+	for _, lt := range []string{"Todo", "Doing", "Done"} {
+		l, err := f.lists.Create(ctx, b.ID, lt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, ct := range []string{lt + " A", lt + " B"} {
+			card := model.Card{BoardID: b.ID, ListID: l.ID, Title: ct, Description: "desc of " + ct}
+			if i == 0 {
+				card.DueDate, card.Reminder = &due, true
+			}
+			c, err := f.cards.Create(ctx, card)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cl, err := f.checklists.Create(ctx, c.ID, "Steps")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j, text := range []string{"one", "two", "three"} {
+				item, err := f.checklists.AddItem(ctx, cl.ID, text)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if j == 1 {
+					item.Done = true
+					if _, err := f.checklists.UpdateItem(ctx, item); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+		}
+	}
+	return b
+}
+
+// TestBoardExportImportRoundTrip 导出 -> 导入 -> 再导出，除了 ID 和时间戳之外内容完全一样
+func TestBoardExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newBoardFixture()
+	first := src.export(t, src.seed(t, "u1"))
+
+	in, err := first.BoardImport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 导入到另一个实例，模拟迁移到别的部署
+	dst := newBoardFixture()
+	res, err := dst.svc.ImportBoard(ctx, "u2", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lists != 3 || res.Cards != 6 || res.Checklists != 6 {
+		t.Fatalf("counts = %d lists, %d cards, %d checklists", res.Lists, res.Cards, res.Checklists)
+	}
+	if res.Board.OwnerID != "u2" {
+		t.Fatalf("owner = %q, want u2", res.Board.OwnerID)
+	}
+
+	second := dst.export(t, res.Board)
+	for _, l := range second.Lists {
+		for _, old := range first.Lists {
+			if l.ID == old.ID {
+				t.Fatalf("list id %s was not remapped", l.ID)
+			}
+		}
+	}
+
+	// BoardImport 本身就是去掉 ID、按位置排好序的内容，直接比较
+	got, err := second.BoardImport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, in) {
+		t.Fatalf("round trip changed content:\n got %+v\nwant %+v", got, in)
+	}
+}
+
+// TestBoardExportImportInvalid 结构有问题时返回 ErrInvalidInput，而且什么都不创建
+func TestBoardExportImportInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		e    BoardExport
+	}{
+		{"unknown list", BoardExport{
+			Board: model.Board{Title: "B"},
+			Lists: []model.List{{ID: "l1", Title: "L"}},
+			Cards: []model.Card{{ID: "c1", ListID: "l2", Title: "C"}},
+		}},
+		{"duplicated list", BoardExport{
+			Board: model.Board{Title: "B"},
+			Lists: []model.List{{ID: "l1", Title: "L"}, {ID: "l1", Title: "M"}},
+		}},
+		{"unknown card", BoardExport{
+			Board:      model.Board{Title: "B"},
+			Lists:      []model.List{{ID: "l1", Title: "L"}},
+			Checklists: []model.Checklist{{ID: "k1", CardID: "c9", Title: "K"}},
+		}},
+		{"empty card title", BoardExport{
+			Board: model.Board{Title: "B"},
+			Lists: []model.List{{ID: "l1", Title: "L"}},
+			Cards: []model.Card{{ID: "c1", ListID: "l1", Title: "  "}},
+		}},
+		{"missing title", BoardExport{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBoardFixture()
+			in, err := tt.e.BoardImport()
+			if err == nil {
+				_, err = f.svc.ImportBoard(context.Background(), "u1", in)
+			}
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("err = %v, want ErrInvalidInput", err)
+			}
+			boards, _ := f.svc.ListBoards(context.Background(), "u1")
+			if len(boards) != 0 {
+				t.Fatalf("created %d boards", len(boards))
+			}
+		})
+	}
+}
+
+// TestTrelloBoardImport Trello 导出跳过已归档的内容，按 pos 排序
+func TestTrelloBoardImport(t *testing.T) {
+	const data = `{
+		"name": "Trello board",
+		"lists": [
+			{"id": "L2", "name": "Second", "pos": 200},
+			{"id": "L1", "name": "First", "pos": 100},
+			{"id": "L3", "name": "Old", "pos": 300, "closed": true}
+		],
+		"cards": [
+			{"id": "C2", "idList": "L1", "name": "b", "pos": 2, "due": "2026-11-01T09:30:00.000Z", "dueReminder": 60},
+			{"id": "C1", "idList": "L1", "name": "a", "desc": "first", "pos": 1},
+			{"id": "C3", "idList": "L1", "name": "archived", "pos": 3, "closed": true},
+			{"id": "C4", "idList": "L3", "name": "in closed list", "pos": 1}
+		],
+		"checklists": [
+			{"idCard": "C1", "name": "Todo", "pos": 1, "checkItems": [
+				{"name": "y", "state": "complete", "pos": 2},
+				{"name": "x", "state": "incomplete", "pos": 1}
+			]},
+			{"idCard": "C3", "name": "gone", "pos": 1}
+		],
+		"labels": [{"id": "x"}]
+	}`
+	var tb TrelloBoard
+	if err := json.Unmarshal([]byte(data), &tb); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tb.BoardImport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2026, 11, 1, 9, 30, 0, 0, time.UTC)
+	want := BoardImport{Title: "Trello board", Lists: []ImportList{
+		{Title: "First", Cards: []ImportCard{
+			{Title: "a", Description: "first", Checklists: []ImportChecklist{{Title: "Todo", Items: []ImportChecklistItem{
+				{Text: "x"}, {Text: "y", Done: true},
+			}}}},
+			{Title: "b", DueDate: &due, Reminder: true},
+		}},
+		{Title: "Second"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"kanban_api/internal/statsd"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BoardMetrics 定期把每个看板的卡片统计作为 gauge 发给 StatsD
+// 给不用 Prometheus、而是用 StatsD / Datadog 的部署使用
+type BoardMetrics interface {
+	// Report 统计一次所有看板并发送
+	Report(ctx context.Context) error
+
+	// Run 立即上报一次，之后每隔 interval 上报一次，直到 ctx 被取消
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// gauge 一个已经发送过的 gauge（名字加标签确定一条时间序列）
+type gauge struct {
+	name string
+	tags []string
+}
+
+// boardMetrics 看板指标上报的具体实现
+// 发送的指标（标签 board_id，列表的指标还有 list_id）：
+// - board.cards.open: 看板上的卡片数（卡片目前没有"已完成"状态，所有卡片都算）
+// - board.cards.overdue: 截止日期已过的卡片数
+// - list.cards.wip: 每个列表中的卡片数
+// - board.checklists.percent、list.checklists.percent: 检查清单的完成百分比，来自进度缓存（见 ProgressRollups）
+type boardMetrics struct {
+	cards   repository.CardRepository
+	rollups *ProgressRollups
+	client  *statsd.Client
+
+	mu sync.Mutex
+	// sent 上一次发送过的时间序列
+	// gauge 在 agent 那边会一直保持最后一次的值：卡片被删光、看板被删除后统计里就没有它了，
+	// 如果不管，图表上会一直停在删除前的数字，所以要给消失的序列补发一次 0
+	sent map[string]gauge
+}
+
+// NewBoardMetrics 创建看板指标上报实例
+// 第一次上报会把所有有卡片的看板加载进进度缓存，之后按事件增量更新
+func NewBoardMetrics(cards repository.CardRepository, rollups *ProgressRollups, client *statsd.Client) BoardMetrics {
+	return &boardMetrics{cards: cards, rollups: rollups, client: client, sent: make(map[string]gauge)}
+}
+
+// Report 统计并发送
+func (m *boardMetrics) Report(ctx context.Context) error {
+	counts, err := m.cards.CountByList(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	type rollup struct {
+		total model.ChecklistProgress
+		lists map[string]model.ChecklistProgress
+	}
+	progress := make(map[string]rollup)
+	for _, c := range counts {
+		if _, ok := progress[c.BoardID]; ok {
+			continue
+		}
+		total, lists, err := m.rollups.Board(ctx, c.BoardID)
+		if err != nil {
+			return err
+		}
+		progress[c.BoardID] = rollup{total: total, lists: lists}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur := make(map[string]gauge)
+	values := make(map[string]float64)
+	set := func(name string, v int, tags ...string) {
+		key := name + "|" + strings.Join(tags, ",")
+		cur[key] = gauge{name: name, tags: tags}
+		values[key] += float64(v)
+	}
+	for _, c := range counts {
+		board := statsd.Tag("board_id", c.BoardID)
+		set("board.cards.open", c.Cards, board)
+		set("board.cards.overdue", c.Overdue, board)
+		set("list.cards.wip", c.Cards, board, statsd.Tag("list_id", c.ListID))
+		set("list.checklists.percent", progress[c.BoardID].lists[c.ListID].Percent, board, statsd.Tag("list_id", c.ListID))
+	}
+	// 一个看板有多个列表，board.checklists.percent 不能在上面按列表累加
+	for id, p := range progress {
+		set("board.checklists.percent", p.total.Percent, statsd.Tag("board_id", id))
+	}
+
+	for key, g := range cur {
+		m.client.Gauge(g.name, values[key], g.tags...)
+	}
+	for key, g := range m.sent {
+		if _, ok := cur[key]; !ok {
+			m.client.Gauge(g.name, 0, g.tags...)
+		}
+	}
+	m.sent = cur
+	return nil
+}
+
+// Run 定时上报
+// 单次失败（例如数据库暂时不可用）只打日志，下一轮继续
+func (m *boardMetrics) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Report(ctx); err != nil {
+			logging.FromContext(ctx).Warn("report board metrics", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package service 新看板的示例内容
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// sampleBoardJSON 示例内容，改示例只改这个文件
+//
+//go:embed sample_board.json
+var sampleBoardJSON []byte
+
+// sampleBoard 示例内容的格式
+type sampleBoard struct {
+	// Lists 没有指定列表时和看板一起创建的列表
+	Lists []string `json:"lists"`
+
+	Cards []sampleCard `json:"cards"`
+}
+
+// sampleCard 一张示例卡片
+type sampleCard struct {
+	// List 放在第几个列表（从 0 开始）；看板的列表没有这么多时放在最后一个
+	List        int      `json:"list"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+
+	// DueInDays 截止日期是创建之后的第几天，负数是已经过期，0 表示没有截止日期
+	DueInDays int  `json:"dueInDays"`
+	Reminder  bool `json:"reminder"`
+
+	Checklist *struct {
+		Title string `json:"title"`
+		Items []struct {
+			Text string `json:"text"`
+			Done bool   `json:"done"`
+		} `json:"items"`
+	} `json:"checklist"`
+}
+
+// loadSampleBoard 解析内嵌的示例内容；文件是随代码一起发布的，格式不对是代码的问题
+func loadSampleBoard() sampleBoard {
+	var sb sampleBoard
+	if err := json.Unmarshal(sampleBoardJSON, &sb); err != nil {
+		panic("service: invalid sample_board.json: " + err.Error())
+	}
+	return sb
+}
+
+// sampleContent 示例内容，启动时解析一次
+var sampleContent = loadSampleBoard()
+
+// addSample 在看板的列表里创建示例卡片，都标记为 Sample
+func (s *boardService) addSample(ctx context.Context, b model.Board, now time.Time) error {
+	if len(b.Lists) == 0 {
+		return nil
+	}
+	for _, sc := range sampleContent.Cards {
+		l := b.Lists[min(sc.List, len(b.Lists)-1)]
+		c := model.Card{
+			BoardID:     b.ID,
+			ListID:      l.ID,
+			Title:       sc.Title,
+			Description: sc.Description,
+			Labels:      sc.Labels,
+			Reminder:    sc.Reminder && sc.DueInDays != 0,
+			Sample:      true,
+		}
+		if sc.DueInDays != 0 {
+			due := now.UTC().Truncate(time.Hour).AddDate(0, 0, sc.DueInDays)
+			c.DueDate = &due
+		}
+		c, err := s.cards.Create(ctx, c)
+		if err != nil {
+			return err
+		}
+		if sc.Checklist == nil {
+			continue
+		}
+		cl, err := s.checklists.Create(ctx, c.ID, sc.Checklist.Title)
+		if err != nil {
+			return err
+		}
+		for _, it := range sc.Checklist.Items {
+			item, err := s.checklists.AddItem(ctx, cl.ID, it.Text)
+			if err != nil {
+				return err
+			}
+			if it.Done {
+				item.Done = true
+				if _, err := s.checklists.UpdateItem(ctx, item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ClearSample 删除看板上的所有示例卡片和它们的清单、附件
+// 和删除卡片一样按卡片逐张删除，返回删除了的卡片
+func (s *boardService) ClearSample(ctx context.Context, userID, boardID string) ([]model.Card, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	lists, err := s.lists.ListByBoard(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make([]model.Card, 0)
+	for _, l := range lists {
+		cards, err := s.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			return deleted, err
+		}
+		for _, c := range cards {
+			if !c.Sample {
+				continue
+			}
+			if err := s.cards.Delete(ctx, l.ID, c.ID); err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, c)
+			if err := s.checklists.DeleteByCards(ctx, []string{c.ID}); err != nil {
+				return deleted, err
+			}
+			usage := s.storage.usageOf(ctx, []string{c.ID})
+			if err := s.attachments.DeleteByCards(ctx, []string{c.ID}); err != nil {
+				return deleted, err
+			}
+			s.storage.sub(ctx, boardID, usage)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"testing"
+)
+
+// TestUpdateBoardVersion 带着读到的版本更新：版本对得上才修改，修改后版本加一；
+// 用过时的版本更新返回 ErrConflict，Cause 是 ErrVersionConflict，看板不变
+func TestUpdateBoardVersion(t *testing.T) {
+	ctx := context.Background()
+	svc := newBoardFixture().svc
+	b, err := svc.CreateBoard(ctx, "u1", BoardInput{Title: "Board"})
+	if err != nil || b.Version != 1 {
+		t.Fatalf("create = %+v, %v, want version 1", b, err)
+	}
+
+	updated, err := svc.UpdateBoard(ctx, "u1", b.ID, "First", "", b.Version)
+	if err != nil || updated.Version != 2 || updated.Title != "First" {
+		t.Fatalf("update = %+v, %v, want version 2", updated, err)
+	}
+	// 另一个客户端还拿着版本 1
+	_, err = svc.UpdateBoard(ctx, "u1", b.ID, "Second", "", b.Version)
+	if !errors.Is(err, ErrConflict) || !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("stale update err = %v, want a version conflict", err)
+	}
+	got, err := svc.GetBoard(ctx, "u1", b.ID)
+	if err != nil || got.Title != "First" || got.Version != 2 {
+		t.Fatalf("after stale update: %+v, %v", got, err)
+	}
+
+	// 0 表示不检查版本
+	if got, err = svc.UpdateBoard(ctx, "u1", b.ID, "Third", "", 0); err != nil || got.Version != 3 {
+		t.Fatalf("unconditional update = %+v, %v", got, err)
+	}
+}
+
+// TestPatchBoard 只修改传了的字段：没传 slug 保持不变，传空串按标题重新生成；
+// 标题传空串是错误；什么都没传时版本不变
+func TestPatchBoard(t *testing.T) {
+	ctx := context.Background()
+	svc := newBoardFixture().svc
+	b, err := svc.CreateBoard(ctx, "u1", BoardInput{Title: "Roadmap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := func(s string) *string { return &s }
+
+	got, err := svc.PatchBoard(ctx, "u1", b.ID, BoardPatch{Title: str("Q3 Plan")}, b.Version)
+	if err != nil || got.Title != "Q3 Plan" || got.Slug != "roadmap" || got.Version != 2 {
+		t.Fatalf("patch title = %+v, %v, want slug kept", got, err)
+	}
+	if got, err = svc.PatchBoard(ctx, "u1", b.ID, BoardPatch{Slug: str("")}, 0); err != nil || got.Slug != "q3-plan" || got.Title != "Q3 Plan" {
+		t.Fatalf("reset slug = %+v, %v, want slug from title", got, err)
+	}
+	if _, err := svc.PatchBoard(ctx, "u1", b.ID, BoardPatch{Title: str(" ")}, 0); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("empty title err = %v, want ErrInvalidInput", err)
+	}
+	if same, err := svc.PatchBoard(ctx, "u1", b.ID, BoardPatch{}, got.Version); err != nil || same.Version != got.Version {
+		t.Fatalf("empty patch = %+v, %v, want version %d", same, err, got.Version)
+	}
+	if _, err := svc.PatchBoard(ctx, "u1", b.ID, BoardPatch{}, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("empty patch with stale version err = %v, want a version conflict", err)
+	}
+}
+
+// TestCreateBoardLists 选了默认列表时创建配置的列表，传了列表时用传的；列表标题有问题时什么都不创建
+func TestCreateBoardLists(t *testing.T) {
+	ctx := context.Background()
+	f := newBoardFixture()
+
+	b, err := f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "Default", DefaultLists: true})
+	if err != nil || len(b.Lists) != 3 || b.Lists[0].Title != "Todo" || b.Lists[2].Position != 2 {
+		t.Fatalf("create with default lists = %+v, %v, want Todo, Doing, Done", b.Lists, err)
+	}
+	if got, _ := f.lists.ListByBoard(ctx, b.ID); len(got) != 3 {
+		t.Fatalf("stored lists = %+v, want 3", got)
+	}
+
+	b, err = f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "Custom", Lists: []string{" Backlog ", "Sprint"}, DefaultLists: true})
+	if err != nil || len(b.Lists) != 2 || b.Lists[0].Title != "Backlog" {
+		t.Fatalf("create with lists = %+v, %v, want Backlog, Sprint", b.Lists, err)
+	}
+
+	if b, err = f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "Empty"}); err != nil || b.Lists != nil {
+		t.Fatalf("create without lists = %+v, %v, want no lists", b.Lists, err)
+	}
+
+	if _, err := f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "Bad", Lists: []string{"ok", " "}}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("blank list title err = %v, want ErrInvalidInput", err)
+	}
+	boards, err := f.svc.ListBoards(ctx, "u1")
+	if err != nil || len(boards) != 3 {
+		t.Fatalf("boards = %d, %v, want 3 (nothing created for the bad request)", len(boards), err)
+	}
+}
+
+// TestBoardSample 示例内容按内嵌的文件生成，清除时只删示例卡片和它们的清单，自己创建的卡片留着
+func TestBoardSample(t *testing.T) {
+	ctx := context.Background()
+	f := newBoardFixture()
+
+	b, err := f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "Tutorial", Sample: true})
+	if err != nil || len(b.Lists) != len(sampleContent.Lists) {
+		t.Fatalf("create = %+v, %v, want the sample lists", b.Lists, err)
+	}
+	var sample, withChecklist int
+	for _, l := range b.Lists {
+		cards, err := f.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range cards {
+			if !c.Sample {
+				t.Fatalf("card %q is not marked as sample", c.Title)
+			}
+			sample++
+			if cls, _ := f.checklists.ListByCard(ctx, c.ID); len(cls) > 0 {
+				withChecklist++
+			}
+		}
+	}
+	if sample != len(sampleContent.Cards) || withChecklist == 0 {
+		t.Fatalf("%d sample cards, %d with checklists, want %d cards and some checklists", sample, withChecklist, len(sampleContent.Cards))
+	}
+
+	// 用户指定的列表比示例少时，多出来的卡片放在最后一个列表
+	one, err := f.svc.CreateBoard(ctx, "u1", BoardInput{Title: "One list", Lists: []string{"Only"}, Sample: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cards, _ := f.cards.ListByList(ctx, one.Lists[0].ID); len(cards) != len(sampleContent.Cards) {
+		t.Fatalf("one list board has %d cards, want all %d", len(cards), len(sampleContent.Cards))
+	}
+
+	mine, err := f.cards.Create(ctx, model.Card{BoardID: b.ID, ListID: b.Lists[0].ID, Title: "mine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.svc.ClearSample(ctx, "u2", b.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("clear by a stranger: err = %v, want ErrNotFound", err)
+	}
+	deleted, err := f.svc.ClearSample(ctx, "u1", b.ID)
+	if err != nil || len(deleted) != sample {
+		t.Fatalf("clear = %d, %v, want %d", len(deleted), err, sample)
+	}
+	cards, err := f.cards.ListByList(ctx, b.Lists[0].ID)
+	if err != nil || len(cards) != 1 || cards[0].ID != mine.ID || cards[0].Position != 0 {
+		t.Fatalf("after clear: %+v, %v, want only my card at position 0", cards, err)
+	}
+	for _, c := range deleted {
+		if cls, _ := f.checklists.ListByCard(ctx, c.ID); len(cls) != 0 {
+			t.Fatalf("checklists of %q were not deleted", c.Title)
+		}
+	}
+	if again, err := f.svc.ClearSample(ctx, "u1", b.ID); err != nil || len(again) != 0 {
+		t.Fatalf("second clear = %d, %v, want 0", len(again), err)
+	}
+}
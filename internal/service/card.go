@@ -0,0 +1,436 @@
+// Package service 卡片业务逻辑层
+package service
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCardTitle 卡片标题最长多少个字符，和 HTTP 层的校验一致
+	// 从模板创建时标题是渲染出来的，在这里再检查一次
+	maxCardTitle = 200
+
+	// maxCardLabels 每张卡片最多多少个标签
+	maxCardLabels = 10
+
+	// maxLabelLen 单个标签最长多少个字符
+	maxLabelLen = 50
+)
+
+// CardInput 创建或整体替换卡片时的输入
+type CardInput struct {
+	Title       string
+	Description string
+	DueDate     *time.Time // nil 表示没有截止日期
+	Reminder    bool       // 截止日期快到时是否提醒
+	Labels      []string   // 标签，见 cleanLabels
+}
+
+// CardPatch 部分更新卡片时的输入
+// 字段为 nil 表示不修改该字段；字段和 repository.CardChanges 一一对应，校验之后直接转换过去
+type CardPatch struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Reminder    *bool
+	Labels      *[]string
+
+	// ClearDueDate 清空截止日期（PATCH 里传了 "dueDate": null），DueDate 为 nil 时才有意义
+	ClearDueDate bool
+}
+
+// UpcomingCards 快到期和已经过期的卡片
+type UpcomingCards struct {
+	// Overdue 已经过了截止日期的卡片，最早过期的在前
+	Overdue []model.Card `json:"overdue"`
+
+	// DueSoon 截止日期还没到、但在查询的时间范围之内的卡片，最早到期的在前
+	DueSoon []model.Card `json:"dueSoon"`
+}
+
+// CardService 卡片服务接口
+// 卡片的路径是 看板 -> 列表 -> 卡片，每个操作都会逐级校验归属关系
+type CardService interface {
+	// ListCards 按位置顺序列出列表中的所有卡片
+	ListCards(ctx context.Context, userID, boardID, listID string) ([]model.Card, error)
+
+	// GetCard 获取单张卡片
+	GetCard(ctx context.Context, userID, boardID, listID, cardID string) (model.Card, error)
+
+	// CreateCard 在列表末尾创建卡片
+	CreateCard(ctx context.Context, userID, boardID, listID string, in CardInput) (model.Card, error)
+
+	// CreateCardFromTemplate 按看板的卡片模板在列表末尾创建卡片，模板里有清单时一起创建
+	// in.Title 填进模板标题的 {{title}}（模板没有标题时直接作为标题），in.Description 不为空时代替模板的描述，
+	// in.Labels 追加在模板的标签后面；模板不存在或者不属于这个看板时返回 ErrNotFound
+	CreateCardFromTemplate(ctx context.Context, userID, boardID, listID, templateID string, in CardInput) (model.Card, error)
+
+	// ReplaceCard 整体替换卡片内容（PUT 语义，未传的字段会被清空）
+	ReplaceCard(ctx context.Context, userID, boardID, listID, cardID string, in CardInput) (model.Card, error)
+
+	// PatchCard 部分更新卡片（PATCH 语义，只修改传入的字段）
+	PatchCard(ctx context.Context, userID, boardID, listID, cardID string, p CardPatch) (model.Card, error)
+
+	// MoveCard 把卡片移动到目标列表的指定位置，返回移动后目标列表的全部卡片
+	// toBoardID 为空表示在同一看板内移动；跨看板移动时用户在两个看板上都需要 editor 角色
+	// toListID 为空表示在原列表内调整顺序（跨看板移动时必须指定）
+	// 跨看板移动时卡片的附件计入目标看板，超过目标看板的附件配额时返回 ErrQuotaExceeded
+	MoveCard(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string, position int) ([]model.Card, error)
+
+	// DeleteCard 删除卡片
+	DeleteCard(ctx context.Context, userID, boardID, listID, cardID string) error
+
+	// UpcomingCards 在用户能看到的所有看板中，列出截止日期在接下来 within 之内的卡片
+	// overdue 为 true 时同时列出已经过期的卡片，否则 Overdue 为空
+	UpcomingCards(ctx context.Context, userID string, within time.Duration, overdue bool) (UpcomingCards, error)
+}
+
+// cardService 卡片服务的具体实现
+type cardService struct {
+	cards  repository.CardRepository
+	lists  repository.ListRepository
+	access boardAccess
+
+	// checklists 检查清单仓储：返回卡片时填上清单进度（Card.Checklists），删除卡片时一并删除清单
+	checklists repository.ChecklistRepository
+
+	// attachments 附件仓储，删除卡片时一并删除附件
+	attachments repository.AttachmentRepository
+
+	// storage 附件占用空间的计数器：删除卡片、把卡片移到别的看板时跟着改
+	storage *StorageCounters
+
+	// templates 卡片模板仓储，从模板创建卡片时读取模板
+	templates repository.CardTemplateRepository
+}
+
+// NewCardService 创建卡片服务实例
+func NewCardService(cards repository.CardRepository, lists repository.ListRepository, checklists repository.ChecklistRepository, attachments repository.AttachmentRepository, storage *StorageCounters, templates repository.CardTemplateRepository, boards repository.BoardRepository, members repository.MemberRepository) CardService {
+	return &cardService{cards: cards, lists: lists, checklists: checklists, attachments: attachments, storage: storage, templates: templates, access: boardAccess{boards: boards, members: members}}
+}
+
+// cleanLabels 去掉标签首尾的空白，跳过空标签，不区分大小写地去重（保留第一次出现的写法）
+// 标签用逗号连接保存，所以不能包含逗号
+func cleanLabels(labels []string) ([]string, error) {
+	out := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if strings.Contains(l, ",") {
+			return nil, invalidInput("label must not contain a comma")
+		}
+		if len([]rune(l)) > maxLabelLen {
+			return nil, invalidInput("label too long")
+		}
+		key := strings.ToLower(l)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, l)
+	}
+	if len(out) > maxCardLabels {
+		return nil, invalidInput("too many labels")
+	}
+	return out, nil
+}
+
+// withProgress 给卡片填上清单进度，一次查询查出所有卡片的进度
+func (s *cardService) withProgress(ctx context.Context, cards []model.Card, err error) ([]model.Card, error) {
+	if err != nil || len(cards) == 0 {
+		return cards, err
+	}
+	ids := make([]string, 0, len(cards))
+	for _, c := range cards {
+		ids = append(ids, c.ID)
+	}
+	progress, err := s.checklists.ProgressByCards(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cards {
+		cards[i].Checklists = progress[cards[i].ID]
+	}
+	return cards, nil
+}
+
+// oneWithProgress 单张卡片的 withProgress
+func (s *cardService) oneWithProgress(ctx context.Context, c model.Card, err error) (model.Card, error) {
+	if err != nil {
+		return model.Card{}, err
+	}
+	cards, err := s.withProgress(ctx, []model.Card{c}, nil)
+	if err != nil {
+		return model.Card{}, err
+	}
+	return cards[0], nil
+}
+
+// checkList 确认当前用户对看板至少有 need 角色，并且列表属于该看板
+func (s *cardService) checkList(ctx context.Context, userID, boardID, listID, need string) error {
+	if _, _, err := s.access.check(ctx, userID, boardID, need); err != nil {
+		return err
+	}
+	_, err := s.lists.Get(ctx, boardID, listID)
+	return err
+}
+
+// ListCards 列出列表中的所有卡片
+func (s *cardService) ListCards(ctx context.Context, userID, boardID, listID string) ([]model.Card, error) {
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	items, err := s.cards.ListByList(ctx, listID)
+	return s.withProgress(ctx, items, err)
+}
+
+// GetCard 获取单张卡片
+func (s *cardService) GetCard(ctx context.Context, userID, boardID, listID, cardID string) (model.Card, error) {
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleViewer); err != nil {
+		return model.Card{}, err
+	}
+	c, err := s.cards.Get(ctx, listID, cardID)
+	return s.oneWithProgress(ctx, c, err)
+}
+
+// CreateCard 创建卡片
+func (s *cardService) CreateCard(ctx context.Context, userID, boardID, listID string, in CardInput) (model.Card, error) {
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Title == "" {
+		return model.Card{}, invalidInput("title required")
+	}
+	labels, err := cleanLabels(in.Labels)
+	if err != nil {
+		return model.Card{}, err
+	}
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return model.Card{}, err
+	}
+
+	return s.cards.Create(ctx, model.Card{
+		BoardID:     boardID,
+		ListID:      listID,
+		Title:       in.Title,
+		Description: in.Description,
+		Labels:      labels,
+		DueDate:     in.DueDate,
+		Reminder:    in.Reminder,
+	})
+}
+
+// CreateCardFromTemplate 从模板创建卡片
+// 先创建卡片再创建清单：清单创建失败时卡片已经在了，和用户先建卡片再加清单的结果一样，不回滚
+func (s *cardService) CreateCardFromTemplate(ctx context.Context, userID, boardID, listID, templateID string, in CardInput) (model.Card, error) {
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return model.Card{}, err
+	}
+	t, err := s.templates.Get(ctx, boardID, templateID)
+	if err != nil {
+		return model.Card{}, err
+	}
+
+	title := renderTemplateTitle(t.Title, strings.TrimSpace(in.Title), time.Now())
+	if title == "" {
+		return model.Card{}, invalidInput("title required")
+	}
+	if len([]rune(title)) > maxCardTitle {
+		return model.Card{}, invalidInput("title too long")
+	}
+	if in.Description == "" {
+		in.Description = t.Description
+	}
+	labels, err := cleanLabels(append(append([]string(nil), t.Labels...), in.Labels...))
+	if err != nil {
+		return model.Card{}, err
+	}
+
+	c, err := s.cards.Create(ctx, model.Card{
+		BoardID:     boardID,
+		ListID:      listID,
+		Title:       title,
+		Description: in.Description,
+		Labels:      labels,
+		DueDate:     in.DueDate,
+		Reminder:    in.Reminder,
+	})
+	if err != nil || t.Checklist == nil {
+		return c, err
+	}
+	cl, err := s.checklists.Create(ctx, c.ID, t.Checklist.Title)
+	if err != nil {
+		return model.Card{}, err
+	}
+	for _, text := range t.Checklist.Items {
+		if _, err := s.checklists.AddItem(ctx, cl.ID, text); err != nil {
+			return model.Card{}, err
+		}
+	}
+	return s.oneWithProgress(ctx, c, nil)
+}
+
+// renderTemplateTitle 替换模板标题里的占位符：{{title}} 换成 title，{{date}} 换成 now 的 UTC 日期
+// 模板没有标题时直接返回 title；结果去掉首尾空白，例如 "[Bug] {{title}}" 在 title 为空时得到 "[Bug]"
+func renderTemplateTitle(pattern, title string, now time.Time) string {
+	if pattern == "" {
+		return title
+	}
+	r := strings.NewReplacer("{{title}}", title, "{{date}}", now.UTC().Format("2006-01-02"))
+	return strings.TrimSpace(r.Replace(pattern))
+}
+
+// ReplaceCard 整体替换卡片内容
+func (s *cardService) ReplaceCard(ctx context.Context, userID, boardID, listID, cardID string, in CardInput) (model.Card, error) {
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Title == "" {
+		return model.Card{}, invalidInput("title required")
+	}
+	labels, err := cleanLabels(in.Labels)
+	if err != nil {
+		return model.Card{}, err
+	}
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return model.Card{}, err
+	}
+
+	c, err := s.cards.Update(ctx, listID, cardID, repository.CardChanges{
+		Title:        &in.Title,
+		Description:  &in.Description,
+		DueDate:      in.DueDate,
+		ClearDueDate: in.DueDate == nil,
+		Reminder:     &in.Reminder,
+		Labels:       &labels,
+	})
+	return s.oneWithProgress(ctx, c, err)
+}
+
+// PatchCard 部分更新卡片
+// 仓储只写传入的字段，不用先读出整张卡片再写回，同时修改不同字段的请求不会互相覆盖
+func (s *cardService) PatchCard(ctx context.Context, userID, boardID, listID, cardID string, p CardPatch) (model.Card, error) {
+	if p.Title != nil {
+		title := strings.TrimSpace(*p.Title)
+		if title == "" {
+			return model.Card{}, invalidInput("title required")
+		}
+		p.Title = &title
+	}
+	if p.Labels != nil {
+		labels, err := cleanLabels(*p.Labels)
+		if err != nil {
+			return model.Card{}, err
+		}
+		p.Labels = &labels
+	}
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return model.Card{}, err
+	}
+
+	c, err := s.cards.Update(ctx, listID, cardID, repository.CardChanges(p))
+	return s.oneWithProgress(ctx, c, err)
+}
+
+// MoveCard 移动卡片
+// 目标列表必须属于目标看板
+func (s *cardService) MoveCard(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string, position int) ([]model.Card, error) {
+	if position < 0 {
+		return nil, invalidInput("invalid position")
+	}
+	if toBoardID == "" {
+		toBoardID = boardID
+	}
+	if toListID == "" {
+		if toBoardID != boardID {
+			return nil, invalidInput("listId required when moving to another board")
+		}
+		toListID = listID
+	}
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	var usage model.StorageUsage
+	if toBoardID != boardID {
+		// 跨看板移动相当于从原看板删除、在目标看板创建，两边都要有修改权限
+		if err := s.checkList(ctx, userID, toBoardID, toListID, model.RoleEditor); err != nil {
+			return nil, err
+		}
+		// 附件跟着卡片走，占用的空间也要从原看板转到目标看板，目标看板放不下时不能移动
+		var err error
+		if usage, err = s.attachments.UsageByCards(ctx, []string{cardID}); err != nil {
+			return nil, err
+		}
+		if err := s.storage.check(ctx, toBoardID, usage.Bytes); err != nil {
+			return nil, err
+		}
+	} else if toListID != listID {
+		if _, err := s.lists.Get(ctx, boardID, toListID); err != nil {
+			return nil, err
+		}
+	}
+	cards, err := s.cards.Move(ctx, listID, cardID, toBoardID, toListID, position)
+	if err == nil && toBoardID != boardID {
+		s.storage.sub(ctx, boardID, usage)
+		s.storage.add(ctx, toBoardID, usage)
+	}
+	return s.withProgress(ctx, cards, err)
+}
+
+// DeleteCard 删除卡片和它的检查清单、附件
+func (s *cardService) DeleteCard(ctx context.Context, userID, boardID, listID, cardID string) error {
+	if err := s.checkList(ctx, userID, boardID, listID, model.RoleEditor); err != nil {
+		return err
+	}
+	if err := s.cards.Delete(ctx, listID, cardID); err != nil {
+		return err
+	}
+	if err := s.checklists.DeleteByCards(ctx, []string{cardID}); err != nil {
+		return err
+	}
+	usage := s.storage.usageOf(ctx, []string{cardID})
+	if err := s.attachments.DeleteByCards(ctx, []string{cardID}); err != nil {
+		return err
+	}
+	s.storage.sub(ctx, boardID, usage)
+	return nil
+}
+
+// UpcomingCards 快到期和已经过期的卡片
+// 一次查询取出 [过期下限, now+within) 的卡片，再按 now 分成两组
+func (s *cardService) UpcomingCards(ctx context.Context, userID string, within time.Duration, overdue bool) (UpcomingCards, error) {
+	if within <= 0 {
+		return UpcomingCards{}, invalidInput("invalid time range")
+	}
+	ids, err := s.access.visibleBoardIDs(ctx, userID)
+	if err != nil {
+		return UpcomingCards{}, err
+	}
+
+	now := time.Now()
+	from := now
+	if overdue {
+		from = time.Time{}
+	}
+	cards, err := s.cards.ListDue(ctx, ids, from, now.Add(within))
+	if err != nil {
+		return UpcomingCards{}, err
+	}
+
+	if cards, err = s.withProgress(ctx, cards, nil); err != nil {
+		return UpcomingCards{}, err
+	}
+
+	out := UpcomingCards{Overdue: []model.Card{}, DueSoon: []model.Card{}}
+	for _, c := range cards {
+		if c.DueDate.Before(now) {
+			out.Overdue = append(out.Overdue, c)
+		} else {
+			out.DueSoon = append(out.DueSoon, c)
+		}
+	}
+	return out, nil
+}
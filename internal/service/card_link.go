@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+)
+
+// maxMirrorsPerCard 一张卡片最多有多少个镜像
+// 每次修改都要同步到所有镜像，数量不设上限的话一次修改就会引发大量写入
+const maxMirrorsPerCard = 10
+
+// CardLinkService 卡片镜像服务接口
+// 镜像是另一个看板上的一张普通卡片，和原卡片之间有一个链接；标题和状态（所在列表）的同步见 MirrorSync
+// 同步是双向的，所以创建镜像需要在两个看板上都有 editor 或 owner 角色
+type CardLinkService interface {
+	// CreateMirror 在另一个看板的列表末尾为卡片创建镜像，返回镜像卡片
+	CreateMirror(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string) (model.Card, error)
+
+	// ListLinks 列出卡片的所有链接（卡片是原卡片或者镜像），只返回用户能看到另一头的链接
+	ListLinks(ctx context.Context, userID, boardID, listID, cardID string) ([]model.CardLink, error)
+
+	// DeleteLink 删除卡片的某个链接，两张卡片都保留，之后不再同步
+	DeleteLink(ctx context.Context, userID, boardID, listID, cardID, linkID string) error
+}
+
+// cardLinkService 卡片镜像服务的具体实现
+type cardLinkService struct {
+	links  repository.CardLinkRepository
+	cards  repository.CardRepository
+	lists  repository.ListRepository
+	access boardAccess
+}
+
+// NewCardLinkService 创建卡片镜像服务实例
+func NewCardLinkService(links repository.CardLinkRepository, cards repository.CardRepository, lists repository.ListRepository, boards repository.BoardRepository, members repository.MemberRepository) CardLinkService {
+	return &cardLinkService{links: links, cards: cards, lists: lists, access: boardAccess{boards: boards, members: members}}
+}
+
+// checkCard 确认当前用户对看板至少有 need 角色，并且卡片在看板的这个列表里
+func (s *cardLinkService) checkCard(ctx context.Context, userID, boardID, listID, cardID, need string) (model.Card, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, need); err != nil {
+		return model.Card{}, err
+	}
+	if _, err := s.lists.Get(ctx, boardID, listID); err != nil {
+		return model.Card{}, err
+	}
+	return s.cards.Get(ctx, listID, cardID)
+}
+
+// CreateMirror 创建镜像
+// 镜像只复制标题；镜像不能再有镜像，要在别的看板上再放一份，从原卡片创建
+func (s *cardLinkService) CreateMirror(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string) (model.Card, error) {
+	if toBoardID == "" || toListID == "" {
+		return model.Card{}, invalidInput("boardId and listId required")
+	}
+	if toBoardID == boardID {
+		return model.Card{}, invalidInput("mirror must be on another board")
+	}
+	c, err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor)
+	if err != nil {
+		return model.Card{}, err
+	}
+	if _, _, err := s.access.check(ctx, userID, toBoardID, model.RoleEditor); err != nil {
+		return model.Card{}, err
+	}
+	if _, err := s.lists.Get(ctx, toBoardID, toListID); err != nil {
+		return model.Card{}, err
+	}
+
+	existing, err := s.links.ListByCard(ctx, cardID)
+	if err != nil {
+		return model.Card{}, err
+	}
+	mirrors := 0
+	for _, l := range existing {
+		if l.MirrorCardID == cardID {
+			return model.Card{}, invalidInput("card is a mirror, create mirrors from the source card")
+		}
+		mirrors++
+	}
+	if mirrors >= maxMirrorsPerCard {
+		return model.Card{}, newError(ErrConflict, "too many mirrors of this card")
+	}
+
+	// 先创建卡片再创建链接：链接创建失败时留下一张普通卡片，和用户手动复制的结果一样，不回滚
+	mirror, err := s.cards.Create(ctx, model.Card{BoardID: toBoardID, ListID: toListID, Title: c.Title})
+	if err != nil {
+		return model.Card{}, err
+	}
+	if _, err := s.links.Create(ctx, cardID, mirror.ID, userID); err != nil {
+		return model.Card{}, err
+	}
+	return mirror, nil
+}
+
+// ListLinks 列出卡片的链接，按两张卡片现在的位置填上看板和列表
+// 另一头的卡片已经被删除（例如随列表、看板一起删除，这时不会有 card.deleted 事件）时顺便删掉链接
+func (s *cardLinkService) ListLinks(ctx context.Context, userID, boardID, listID, cardID string) ([]model.CardLink, error) {
+	c, err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.links.ListByCard(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.CardLink, 0, len(links))
+	for _, l := range links {
+		other, err := s.cards.GetByID(ctx, l.Other(cardID))
+		if errors.Is(err, repository.ErrNotFound) {
+			if err := s.links.Delete(ctx, l.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 看不到的看板不暴露卡片在哪里，链接也不返回
+		if _, _, err := s.access.check(ctx, userID, other.BoardID, model.RoleViewer); err != nil {
+			if isAccessDenied(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		src, mirror := c, other
+		if l.MirrorCardID == cardID {
+			src, mirror = other, c
+		}
+		l.SourceBoardID, l.SourceListID = src.BoardID, src.ListID
+		l.MirrorBoardID, l.MirrorListID = mirror.BoardID, mirror.ListID
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// DeleteLink 删除链接，链接必须是这张卡片的
+func (s *cardLinkService) DeleteLink(ctx context.Context, userID, boardID, listID, cardID, linkID string) error {
+	if _, err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return err
+	}
+	l, err := s.links.Get(ctx, linkID)
+	if err != nil {
+		return err
+	}
+	if l.SourceCardID != cardID && l.MirrorCardID != cardID {
+		return repository.ErrNotFound
+	}
+	return s.links.Delete(ctx, linkID)
+}
+
+// isAccessDenied 看板访问检查的拒绝（看不到、角色不够、看板停用），不是内部错误
+func isAccessDenied(err error) bool {
+	return errors.Is(err, repository.ErrNotFound) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrBoardSuspended)
+}
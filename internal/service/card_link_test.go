@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+)
+
+// TestCardMirrors 创建镜像之后标题、状态双向同步，删除卡片后链接一起删除
+func TestCardMirrors(t *testing.T) {
+	ctx := context.Background()
+	boards, lists, cards := repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo()
+	members, links, attachments := repository.NewMemMemberRepo(), repository.NewMemCardLinkRepo(), repository.NewMemAttachmentRepo()
+	storage := NewStorageCounters(repository.NewMemStorageRepo(), attachments, lists, cards, 0)
+
+	bus := events.NewBus()
+	m := NewMirrorSync(links, cards, lists, boards, bus)
+	bus.Listen(m.Handle)
+	cardSvc := PublishCardService(NewCardService(cards, lists, repository.NewMemChecklistRepo(), attachments, storage, repository.NewMemCardTemplateRepo(), boards, members), bus)
+	linkSvc := PublishCardLinkService(NewCardLinkService(links, cards, lists, boards, members), bus)
+
+	// drain 像 Run 一样处理排队的事件，直到同步引起的事件也处理完
+	drain := func() {
+		t.Helper()
+		for len(m.queue) > 0 {
+			if err := m.handle(ctx, <-m.queue); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	a, _ := boards.Create(ctx, "u1", "Team", "team")
+	b, _ := boards.Create(ctx, "u1", "Personal", "personal")
+	aTodo, _ := lists.Create(ctx, a.ID, "Todo")
+	aDone, _ := lists.Create(ctx, a.ID, "Done")
+	bInbox, _ := lists.Create(ctx, b.ID, "Inbox")
+	bDone, _ := lists.Create(ctx, b.ID, "done")
+
+	src, err := cardSvc.CreateCard(ctx, "u1", a.ID, aTodo.ID, CardInput{Title: "ship v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror, err := linkSvc.CreateMirror(ctx, "u1", a.ID, aTodo.ID, src.ID, b.ID, bInbox.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mirror.BoardID != b.ID || mirror.ListID != bInbox.ID || mirror.Title != "ship v2" {
+		t.Fatalf("mirror = %+v", mirror)
+	}
+	got, err := linkSvc.ListLinks(ctx, "u1", b.ID, bInbox.ID, mirror.ID)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("ListLinks = %+v, %v", got, err)
+	}
+	if l := got[0]; l.SourceCardID != src.ID || l.SourceBoardID != a.ID || l.SourceListID != aTodo.ID || l.MirrorListID != bInbox.ID {
+		t.Fatalf("link = %+v", l)
+	}
+	drain()
+
+	// 原卡片改标题，镜像跟着改
+	title := "ship v2.1"
+	if _, err := cardSvc.PatchCard(ctx, "u1", a.ID, aTodo.ID, src.ID, CardPatch{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if c, _ := cards.GetByID(ctx, mirror.ID); c.Title != title {
+		t.Fatalf("mirror title = %q, want %q", c.Title, title)
+	}
+
+	// 镜像改标题，原卡片跟着改
+	title = "ship v3"
+	if _, err := cardSvc.PatchCard(ctx, "u1", b.ID, bInbox.ID, mirror.ID, CardPatch{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if c, _ := cards.GetByID(ctx, src.ID); c.Title != title {
+		t.Fatalf("source title = %q, want %q", c.Title, title)
+	}
+
+	// 原卡片移到 Done，镜像移到自己看板上的 done 列表（不区分大小写）
+	if _, err := cardSvc.MoveCard(ctx, "u1", a.ID, aTodo.ID, src.ID, "", aDone.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if c, _ := cards.GetByID(ctx, mirror.ID); c.ListID != bDone.ID {
+		t.Fatalf("mirror list = %s, want %s", c.ListID, bDone.ID)
+	}
+
+	// 镜像移到原卡片看板上没有同名列表的 Inbox，原卡片不动
+	if _, err := cardSvc.MoveCard(ctx, "u1", b.ID, bDone.ID, mirror.ID, "", bInbox.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if c, _ := cards.GetByID(ctx, src.ID); c.ListID != aDone.ID {
+		t.Fatalf("source list = %s, want %s", c.ListID, aDone.ID)
+	}
+
+	// 只读的看板不同步
+	if _, err := boards.SetState(ctx, b.ID, model.BoardStateReadOnly, "archived"); err != nil {
+		t.Fatal(err)
+	}
+	title = "ship v4"
+	if _, err := cardSvc.PatchCard(ctx, "u1", a.ID, aDone.ID, src.ID, CardPatch{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if c, _ := cards.GetByID(ctx, mirror.ID); c.Title != "ship v3" {
+		t.Fatalf("read-only mirror title = %q, want unchanged", c.Title)
+	}
+	if _, err := boards.SetState(ctx, b.ID, model.BoardStateActive, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 删除原卡片，链接一起删除，镜像保留
+	if err := cardSvc.DeleteCard(ctx, "u1", a.ID, aDone.ID, src.ID); err != nil {
+		t.Fatal(err)
+	}
+	drain()
+	if got, _ := links.ListByCard(ctx, mirror.ID); len(got) != 0 {
+		t.Fatalf("links after delete = %+v", got)
+	}
+	if _, err := cards.GetByID(ctx, mirror.ID); err != nil {
+		t.Fatalf("mirror after delete: %v", err)
+	}
+}
+
+// TestCreateMirrorRules 需要两个看板的修改权限，不能放在同一个看板上，镜像不能再有镜像
+func TestCreateMirrorRules(t *testing.T) {
+	ctx := context.Background()
+	boards, lists, cards := repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo()
+	members, links := repository.NewMemMemberRepo(), repository.NewMemCardLinkRepo()
+	svc := NewCardLinkService(links, cards, lists, boards, members)
+
+	a, _ := boards.Create(ctx, "u1", "A", "a")
+	b, _ := boards.Create(ctx, "u2", "B", "b")
+	c, _ := boards.Create(ctx, "u1", "C", "c")
+	aTodo, _ := lists.Create(ctx, a.ID, "Todo")
+	aDoing, _ := lists.Create(ctx, a.ID, "Doing")
+	bTodo, _ := lists.Create(ctx, b.ID, "Todo")
+	cTodo, _ := lists.Create(ctx, c.ID, "Todo")
+	src, _ := cards.Create(ctx, model.Card{BoardID: a.ID, ListID: aTodo.ID, Title: "task"})
+
+	// 在目标看板上只读：不能创建
+	if _, err := members.Upsert(ctx, b.ID, "u1", model.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateMirror(ctx, "u1", a.ID, aTodo.ID, src.ID, b.ID, bTodo.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("viewer on target: err = %v, want ErrForbidden", err)
+	}
+
+	if _, err := svc.CreateMirror(ctx, "u1", a.ID, aTodo.ID, src.ID, a.ID, aDoing.ID); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("same board: err = %v, want ErrInvalidInput", err)
+	}
+
+	mirror, err := svc.CreateMirror(ctx, "u1", a.ID, aTodo.ID, src.ID, c.ID, cTodo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Upsert(ctx, b.ID, "u1", model.RoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateMirror(ctx, "u1", c.ID, cTodo.ID, mirror.ID, b.ID, bTodo.ID); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("mirror of mirror: err = %v, want ErrInvalidInput", err)
+	}
+
+	// 只能看到原卡片看板的用户看不到镜像所在的看板，链接不返回
+	if _, err := members.Upsert(ctx, a.ID, "u3", model.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := svc.ListLinks(ctx, "u3", a.ID, aTodo.ID, src.ID); err != nil || len(got) != 0 {
+		t.Fatalf("ListLinks for u3 = %+v, %v", got, err)
+	}
+
+	// 链接必须属于路径上的卡片
+	other, _ := cards.Create(ctx, model.Card{BoardID: a.ID, ListID: aTodo.ID, Title: "other"})
+	got, _ := svc.ListLinks(ctx, "u1", a.ID, aTodo.ID, src.ID)
+	if err := svc.DeleteLink(ctx, "u1", a.ID, aTodo.ID, other.ID, got[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("delete via other card: err = %v, want ErrNotFound", err)
+	}
+	if err := svc.DeleteLink(ctx, "u1", c.ID, cTodo.ID, mirror.ID, got[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := links.ListByCard(ctx, src.ID); len(got) != 0 {
+		t.Fatalf("links after DeleteLink = %+v", got)
+	}
+}
@@ -0,0 +1,151 @@
+// Package service 卡片模板业务逻辑
+package service
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+)
+
+const (
+	// maxTemplatesPerBoard 每个看板最多多少个卡片模板
+	maxTemplatesPerBoard = 50
+
+	// maxTemplateName 模板名称最长多少个字符
+	maxTemplateName = 100
+
+	// maxTemplateItems 模板清单最多多少个条目
+	maxTemplateItems = 50
+)
+
+// CardTemplateInput 创建或整体替换卡片模板时的输入
+type CardTemplateInput struct {
+	Name        string
+	Title       string // 标题模板，可以带 {{title}}、{{date}}，见 model.CardTemplate.Title
+	Description string
+	Labels      []string
+	Checklist   *model.TemplateChecklist // nil 表示不带清单
+}
+
+// CardTemplateService 卡片模板服务接口
+// 看板成员都可以查看模板，editor 以上可以管理；从模板创建卡片见 CardService.CreateCardFromTemplate
+type CardTemplateService interface {
+	// ListTemplates 列出看板的所有卡片模板，按名称排序
+	ListTemplates(ctx context.Context, userID, boardID string) ([]model.CardTemplate, error)
+
+	// GetTemplate 获取看板的某个卡片模板
+	GetTemplate(ctx context.Context, userID, boardID, templateID string) (model.CardTemplate, error)
+
+	// CreateTemplate 为看板创建卡片模板，超过每个看板的上限时返回 ErrConflict
+	CreateTemplate(ctx context.Context, userID, boardID string, in CardTemplateInput) (model.CardTemplate, error)
+
+	// ReplaceTemplate 整体替换模板内容（PUT 语义，未传的字段会被清空）
+	ReplaceTemplate(ctx context.Context, userID, boardID, templateID string, in CardTemplateInput) (model.CardTemplate, error)
+
+	// DeleteTemplate 删除看板的某个卡片模板，已经从模板创建的卡片不受影响
+	DeleteTemplate(ctx context.Context, userID, boardID, templateID string) error
+}
+
+// cardTemplateService 卡片模板服务的具体实现
+type cardTemplateService struct {
+	templates repository.CardTemplateRepository
+	access    boardAccess
+}
+
+// NewCardTemplateService 创建卡片模板服务实例
+func NewCardTemplateService(templates repository.CardTemplateRepository, boards repository.BoardRepository, members repository.MemberRepository) CardTemplateService {
+	return &cardTemplateService{templates: templates, access: boardAccess{boards: boards, members: members}}
+}
+
+// cleanTemplate 校验输入并转成模板，标签和清单的规则和卡片、清单相同
+func cleanTemplate(in CardTemplateInput) (model.CardTemplate, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return model.CardTemplate{}, invalidInput("name required")
+	}
+	if len([]rune(name)) > maxTemplateName {
+		return model.CardTemplate{}, invalidInput("name too long")
+	}
+	labels, err := cleanLabels(in.Labels)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	t := model.CardTemplate{
+		Name:        name,
+		Title:       strings.TrimSpace(in.Title),
+		Description: in.Description,
+		Labels:      labels,
+	}
+	if in.Checklist != nil {
+		title, err := cleanChecklistTitle(in.Checklist.Title)
+		if err != nil {
+			return model.CardTemplate{}, err
+		}
+		if len(in.Checklist.Items) > maxTemplateItems {
+			return model.CardTemplate{}, invalidInput("too many checklist items")
+		}
+		items := make([]string, 0, len(in.Checklist.Items))
+		for _, text := range in.Checklist.Items {
+			text, err := cleanItemText(text)
+			if err != nil {
+				return model.CardTemplate{}, err
+			}
+			items = append(items, text)
+		}
+		t.Checklist = &model.TemplateChecklist{Title: title, Items: items}
+	}
+	return t, nil
+}
+
+func (s *cardTemplateService) ListTemplates(ctx context.Context, userID, boardID string) ([]model.CardTemplate, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.templates.ListByBoard(ctx, boardID)
+}
+
+func (s *cardTemplateService) GetTemplate(ctx context.Context, userID, boardID, templateID string) (model.CardTemplate, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return model.CardTemplate{}, err
+	}
+	return s.templates.Get(ctx, boardID, templateID)
+}
+
+func (s *cardTemplateService) CreateTemplate(ctx context.Context, userID, boardID string, in CardTemplateInput) (model.CardTemplate, error) {
+	t, err := cleanTemplate(in)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return model.CardTemplate{}, err
+	}
+	existing, err := s.templates.ListByBoard(ctx, boardID)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	if len(existing) >= maxTemplatesPerBoard {
+		return model.CardTemplate{}, newError(ErrConflict, "too many card templates on this board")
+	}
+	t.BoardID, t.CreatedBy = boardID, userID
+	return s.templates.Create(ctx, t)
+}
+
+func (s *cardTemplateService) ReplaceTemplate(ctx context.Context, userID, boardID, templateID string, in CardTemplateInput) (model.CardTemplate, error) {
+	t, err := cleanTemplate(in)
+	if err != nil {
+		return model.CardTemplate{}, err
+	}
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return model.CardTemplate{}, err
+	}
+	t.ID, t.BoardID = templateID, boardID
+	return s.templates.Update(ctx, t)
+}
+
+func (s *cardTemplateService) DeleteTemplate(ctx context.Context, userID, boardID, templateID string) error {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return err
+	}
+	return s.templates.Delete(ctx, boardID, templateID)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestCardTemplates 编辑可以管理模板、只读成员只能查看；从模板创建的卡片带着渲染好的标题、合并后的标签和清单
+func TestCardTemplates(t *testing.T) {
+	ctx := context.Background()
+	boards, lists, cards := repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo()
+	checklists, members, attachments := repository.NewMemChecklistRepo(), repository.NewMemMemberRepo(), repository.NewMemAttachmentRepo()
+	templates := repository.NewMemCardTemplateRepo()
+	storage := NewStorageCounters(repository.NewMemStorageRepo(), attachments, lists, cards, 0)
+	svc := NewCardTemplateService(templates, boards, members)
+	cardSvc := NewCardService(cards, lists, checklists, attachments, storage, templates, boards, members)
+
+	b, _ := boards.Create(ctx, "u1", "A", "a")
+	other, _ := boards.Create(ctx, "u1", "B", "b")
+	todo, _ := lists.Create(ctx, b.ID, "Todo")
+	if _, err := members.Upsert(ctx, b.ID, "u2", model.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	in := CardTemplateInput{
+		Name:        " Bug 报告 ",
+		Title:       "[Bug] {{title}} {{date}}",
+		Description: "复现步骤：\n",
+		Labels:      []string{"bug", " Bug ", ""},
+		Checklist:   &model.TemplateChecklist{Title: "修复流程", Items: []string{"复现", " 修复 ", "回归测试"}},
+	}
+	if _, err := svc.CreateTemplate(ctx, "u2", b.ID, in); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("viewer create: err = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.CreateTemplate(ctx, "u1", b.ID, CardTemplateInput{Name: "x", Labels: []string{"a,b"}}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("label with comma: err = %v, want ErrInvalidInput", err)
+	}
+	tpl, err := svc.CreateTemplate(ctx, "u1", b.ID, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpl.Name != "Bug 报告" || len(tpl.Labels) != 1 || tpl.Checklist.Items[1] != "修复" {
+		t.Fatalf("template = %+v, want cleaned name, labels and items", tpl)
+	}
+	if got, err := svc.ListTemplates(ctx, "u2", b.ID); err != nil || len(got) != 1 {
+		t.Fatalf("viewer list = %+v, %v, want one template", got, err)
+	}
+
+	// 模板只能在自己的看板上用
+	otherList, _ := lists.Create(ctx, other.ID, "L")
+	if _, err := cardSvc.CreateCardFromTemplate(ctx, "u1", other.ID, otherList.ID, tpl.ID, CardInput{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("template from another board: err = %v, want ErrNotFound", err)
+	}
+
+	c, err := cardSvc.CreateCardFromTemplate(ctx, "u1", b.ID, todo.ID, tpl.ID, CardInput{Title: "登录页白屏", Labels: []string{"BUG", "前端"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTitle := "[Bug] 登录页白屏 " + time.Now().UTC().Format("2006-01-02")
+	if c.Title != wantTitle || c.Description != in.Description {
+		t.Fatalf("card = %q / %q, want %q and the template description", c.Title, c.Description, wantTitle)
+	}
+	if len(c.Labels) != 2 || c.Labels[0] != "bug" || c.Labels[1] != "前端" {
+		t.Fatalf("labels = %v, want [bug 前端]", c.Labels)
+	}
+	if c.Checklists != model.NewChecklistProgress(0, 3) {
+		t.Fatalf("checklist progress = %+v, want 0/3", c.Checklists)
+	}
+
+	// 修改模板不影响已经创建的卡片；去掉清单之后创建的卡片不带清单
+	in.Checklist = nil
+	in.Title = ""
+	if _, err := svc.ReplaceTemplate(ctx, "u1", b.ID, tpl.ID, in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cardSvc.CreateCardFromTemplate(ctx, "u1", b.ID, todo.ID, tpl.ID, CardInput{}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("no title at all: err = %v, want ErrInvalidInput", err)
+	}
+	plain, err := cardSvc.CreateCardFromTemplate(ctx, "u1", b.ID, todo.ID, tpl.ID, CardInput{Title: "second"})
+	if err != nil || plain.Title != "second" || plain.Checklists.Total != 0 {
+		t.Fatalf("card = %+v, %v, want title second without checklist", plain, err)
+	}
+	if got, _ := checklists.ListByCard(ctx, c.ID); len(got) != 1 || len(got[0].Items) != 3 {
+		t.Fatalf("first card checklists = %+v, want one with 3 items", got)
+	}
+
+	if err := svc.DeleteTemplate(ctx, "u1", b.ID, tpl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetTemplate(ctx, "u1", b.ID, tpl.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("after delete: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package service 检查清单业务逻辑层
+package service
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+)
+
+// 检查清单的长度限制
+const (
+	maxChecklistTitle = 200
+	maxChecklistItem  = 500
+)
+
+// ChecklistItemPatch 修改清单条目时的输入，字段为 nil 表示不修改
+// 勾选、取消勾选只传 Done 即可
+type ChecklistItemPatch struct {
+	Text *string
+	Done *bool
+}
+
+// ChecklistService 检查清单服务接口
+// 清单的路径是 看板 -> 列表 -> 卡片 -> 清单，和卡片一样逐级校验归属关系
+// 查看需要 viewer 角色，其它操作需要 editor 角色
+//
+// 修改条目的方法都返回修改后的整个清单，客户端可以直接用里面的 Progress 刷新进度条
+type ChecklistService interface {
+	// ListChecklists 按位置顺序列出卡片的所有清单（带条目）
+	ListChecklists(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Checklist, error)
+
+	// GetChecklist 获取单个清单
+	GetChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) (model.Checklist, error)
+
+	// CreateChecklist 在卡片末尾创建清单
+	CreateChecklist(ctx context.Context, userID, boardID, listID, cardID, title string) (model.Checklist, error)
+
+	// RenameChecklist 修改清单标题
+	RenameChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, title string) (model.Checklist, error)
+
+	// MoveChecklist 把清单移动到卡片中的指定位置，返回移动后卡片的全部清单
+	MoveChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string, position int) ([]model.Checklist, error)
+
+	// DeleteChecklist 删除清单和其中的条目
+	DeleteChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) error
+
+	// AddItem 在清单末尾添加条目
+	AddItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, text string) (model.Checklist, error)
+
+	// UpdateItem 修改条目内容或者勾选、取消勾选
+	UpdateItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, p ChecklistItemPatch) (model.Checklist, error)
+
+	// MoveItem 把条目移动到清单中的指定位置
+	MoveItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, position int) (model.Checklist, error)
+
+	// DeleteItem 删除条目
+	DeleteItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string) (model.Checklist, error)
+}
+
+// checklistService 检查清单服务的具体实现
+type checklistService struct {
+	checklists repository.ChecklistRepository
+	cards      repository.CardRepository
+	lists      repository.ListRepository
+	access     boardAccess
+}
+
+// NewChecklistService 创建检查清单服务实例
+func NewChecklistService(checklists repository.ChecklistRepository, cards repository.CardRepository, lists repository.ListRepository, boards repository.BoardRepository, members repository.MemberRepository) ChecklistService {
+	return &checklistService{checklists: checklists, cards: cards, lists: lists, access: boardAccess{boards: boards, members: members}}
+}
+
+// checkCard 确认当前用户对看板至少有 need 角色，并且卡片在这个看板的这个列表里
+func (s *checklistService) checkCard(ctx context.Context, userID, boardID, listID, cardID, need string) error {
+	if _, _, err := s.access.check(ctx, userID, boardID, need); err != nil {
+		return err
+	}
+	if _, err := s.lists.Get(ctx, boardID, listID); err != nil {
+		return err
+	}
+	_, err := s.cards.Get(ctx, listID, cardID)
+	return err
+}
+
+// checkChecklist 在 checkCard 的基础上确认清单属于这张卡片
+// 条目的操作都经过它：条目只按清单 ID 定位，不能通过别的卡片的路径改到这个清单的条目
+func (s *checklistService) checkChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, need string) error {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, need); err != nil {
+		return err
+	}
+	_, err := s.checklists.Get(ctx, cardID, checklistID)
+	return err
+}
+
+// cleanChecklistTitle 去掉首尾空白并检查长度
+func cleanChecklistTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", invalidInput("title required")
+	}
+	if len([]rune(title)) > maxChecklistTitle {
+		return "", invalidInput("title too long")
+	}
+	return title, nil
+}
+
+// cleanItemText 去掉首尾空白并检查长度
+func cleanItemText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", invalidInput("text required")
+	}
+	if len([]rune(text)) > maxChecklistItem {
+		return "", invalidInput("text too long")
+	}
+	return text, nil
+}
+
+func (s *checklistService) ListChecklists(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Checklist, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.checklists.ListByCard(ctx, cardID)
+}
+
+func (s *checklistService) GetChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) (model.Checklist, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleViewer); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Get(ctx, cardID, checklistID)
+}
+
+func (s *checklistService) CreateChecklist(ctx context.Context, userID, boardID, listID, cardID, title string) (model.Checklist, error) {
+	title, err := cleanChecklistTitle(title)
+	if err != nil {
+		return model.Checklist{}, err
+	}
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Create(ctx, cardID, title)
+}
+
+func (s *checklistService) RenameChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, title string) (model.Checklist, error) {
+	title, err := cleanChecklistTitle(title)
+	if err != nil {
+		return model.Checklist{}, err
+	}
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Rename(ctx, cardID, checklistID, title)
+}
+
+func (s *checklistService) MoveChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string, position int) ([]model.Checklist, error) {
+	if position < 0 {
+		return nil, invalidInput("invalid position")
+	}
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	return s.checklists.Move(ctx, cardID, checklistID, position)
+}
+
+func (s *checklistService) DeleteChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) error {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return err
+	}
+	return s.checklists.Delete(ctx, cardID, checklistID)
+}
+
+func (s *checklistService) AddItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, text string) (model.Checklist, error) {
+	text, err := cleanItemText(text)
+	if err != nil {
+		return model.Checklist{}, err
+	}
+	if err := s.checkChecklist(ctx, userID, boardID, listID, cardID, checklistID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	if _, err := s.checklists.AddItem(ctx, checklistID, text); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Get(ctx, cardID, checklistID)
+}
+
+// UpdateItem 修改条目
+// 先从清单里找出条目当前的内容，把传入的字段合并进去再写回，和 PatchCard 的做法一样
+func (s *checklistService) UpdateItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, p ChecklistItemPatch) (model.Checklist, error) {
+	if err := s.checkCard(ctx, userID, boardID, listID, cardID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	c, err := s.checklists.Get(ctx, cardID, checklistID)
+	if err != nil {
+		return model.Checklist{}, err
+	}
+	var item *model.ChecklistItem
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			item = &c.Items[i]
+		}
+	}
+	if item == nil {
+		return model.Checklist{}, ErrNotFound
+	}
+
+	if p.Text != nil {
+		if item.Text, err = cleanItemText(*p.Text); err != nil {
+			return model.Checklist{}, err
+		}
+	}
+	if p.Done != nil {
+		item.Done = *p.Done
+	}
+	if _, err := s.checklists.UpdateItem(ctx, *item); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Get(ctx, cardID, checklistID)
+}
+
+func (s *checklistService) MoveItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, position int) (model.Checklist, error) {
+	if position < 0 {
+		return model.Checklist{}, invalidInput("invalid position")
+	}
+	if err := s.checkChecklist(ctx, userID, boardID, listID, cardID, checklistID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	if _, err := s.checklists.MoveItem(ctx, checklistID, itemID, position); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Get(ctx, cardID, checklistID)
+}
+
+func (s *checklistService) DeleteItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string) (model.Checklist, error) {
+	if err := s.checkChecklist(ctx, userID, boardID, listID, cardID, checklistID, model.RoleEditor); err != nil {
+		return model.Checklist{}, err
+	}
+	if err := s.checklists.DeleteItem(ctx, checklistID, itemID); err != nil {
+		return model.Checklist{}, err
+	}
+	return s.checklists.Get(ctx, cardID, checklistID)
+}
@@ -0,0 +1,231 @@
+// Package service 看板嵌入业务逻辑
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"time"
+)
+
+const (
+	// defaultEmbedTTL 嵌入令牌的默认有效期
+	defaultEmbedTTL = time.Hour
+
+	// maxEmbedTTL 嵌入令牌允许的最长有效期
+	// 嵌入链接会被贴到第三方页面上，有效期不宜过长
+	maxEmbedTTL = 30 * 24 * time.Hour
+
+	// embedAudience 写入 JWT aud 字段，标识这是一个嵌入令牌
+	embedAudience = "board-embed"
+)
+
+// ErrInvalidEmbedToken 嵌入令牌无效（签名错误、过期、被吊销等）
+// 所有失败原因都返回同一个错误，不向外部泄露具体细节
+var ErrInvalidEmbedToken = newError(ErrUnauthorized, "invalid embed token")
+
+// EmbedService 看板嵌入服务接口
+// 负责签发、吊销嵌入令牌，以及通过令牌只读地访问看板
+// 管理令牌的方法都要求 userID 是看板的所有者
+type EmbedService interface {
+	// CreateToken 为看板签发一个嵌入令牌
+	// ttl 为 0 时使用默认有效期
+	// 返回：令牌记录、签名后的令牌字符串、错误
+	CreateToken(ctx context.Context, userID, boardID string, ttl time.Duration) (model.EmbedToken, string, error)
+
+	// ListTokens 列出看板的所有嵌入令牌记录
+	ListTokens(ctx context.Context, userID, boardID string) ([]model.EmbedToken, error)
+
+	// RevokeToken 吊销看板的某个嵌入令牌
+	RevokeToken(ctx context.Context, userID, boardID, tokenID string) (model.EmbedToken, error)
+
+	// ResolveBoard 校验嵌入令牌并返回它可以访问的看板
+	ResolveBoard(ctx context.Context, token string) (model.Board, error)
+
+	// Preview 校验嵌入令牌，返回分享链接的预览内容（看板，或者 cardID 指定的看板中的卡片）
+	// 聊天软件展开链接时使用（oEmbed、OpenGraph），卡片不在令牌的看板里返回 ErrNotFound
+	Preview(ctx context.Context, token, cardID string) (LinkPreview, error)
+}
+
+// LinkPreview 分享链接的预览内容
+// Card 为 nil 时是看板的预览
+type LinkPreview struct {
+	Board model.Board
+	Card  *model.Card
+
+	// Status 卡片所在列表的标题，看板用列表表示状态（待办、进行中、已完成）
+	Status string
+
+	// ListCount、CardCount 看板的列表数和卡片数，只有看板预览填写
+	ListCount int
+	CardCount int
+
+	// ExpiresAt 嵌入令牌的过期时间，预览的缓存时间不能超过它
+	ExpiresAt time.Time
+}
+
+// embedService 嵌入服务的具体实现
+type embedService struct {
+	tokens repository.EmbedTokenRepository
+	boards repository.BoardRepository
+	lists  repository.ListRepository
+	cards  repository.CardRepository
+
+	// signingKey 嵌入令牌的签名密钥
+	// 由 JWT 密钥派生而来，与登录令牌的密钥不同，
+	// 这样嵌入令牌就不能被当作登录令牌通过 AuthRequired 中间件
+	signingKey []byte
+}
+
+// NewEmbedService 创建嵌入服务实例
+func NewEmbedService(tokens repository.EmbedTokenRepository, boards repository.BoardRepository, lists repository.ListRepository, cards repository.CardRepository, jwtSecret []byte) EmbedService {
+	return &embedService{
+		tokens:     tokens,
+		boards:     boards,
+		lists:      lists,
+		cards:      cards,
+		signingKey: deriveKey("board-embed:", jwtSecret),
+	}
+}
+
+// CreateToken 签发嵌入令牌
+func (s *embedService) CreateToken(ctx context.Context, userID, boardID string, ttl time.Duration) (model.EmbedToken, string, error) {
+	if ttl == 0 {
+		ttl = defaultEmbedTTL
+	}
+	if ttl < 0 || ttl > maxEmbedTTL {
+		return model.EmbedToken{}, "", invalidInput("ttl out of range")
+	}
+
+	// 只能为自己的看板签发令牌
+	if _, err := s.boards.Get(ctx, userID, boardID); err != nil {
+		return model.EmbedToken{}, "", err
+	}
+
+	// 先保存服务端记录，再用记录 ID 作为 jti 签名
+	// 吊销时只需要标记这条记录，令牌字符串本身不用保存
+	rec, err := s.tokens.Create(ctx, boardID, userID, time.Now().Add(ttl))
+	if err != nil {
+		return model.EmbedToken{}, "", err
+	}
+
+	claims := jwt.RegisteredClaims{
+		ID:        rec.ID,
+		Subject:   boardID,
+		Audience:  jwt.ClaimStrings{embedAudience},
+		IssuedAt:  jwt.NewNumericDate(rec.CreatedAt),
+		ExpiresAt: jwt.NewNumericDate(rec.ExpiresAt),
+		Issuer:    "kanban_api",
+	}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey)
+	if err != nil {
+		return model.EmbedToken{}, "", err
+	}
+	return rec, tok, nil
+}
+
+// ListTokens 列出看板的所有嵌入令牌记录
+func (s *embedService) ListTokens(ctx context.Context, userID, boardID string) ([]model.EmbedToken, error) {
+	if _, err := s.boards.Get(ctx, userID, boardID); err != nil {
+		return nil, err
+	}
+	return s.tokens.ListByBoard(ctx, boardID)
+}
+
+// RevokeToken 吊销嵌入令牌
+// 令牌必须属于路径中的看板，防止通过别的看板吊销令牌
+func (s *embedService) RevokeToken(ctx context.Context, userID, boardID, tokenID string) (model.EmbedToken, error) {
+	if _, err := s.boards.Get(ctx, userID, boardID); err != nil {
+		return model.EmbedToken{}, err
+	}
+
+	rec, err := s.tokens.Get(ctx, tokenID)
+	if err != nil {
+		return model.EmbedToken{}, err
+	}
+	if rec.BoardID != boardID {
+		return model.EmbedToken{}, repository.ErrNotFound
+	}
+	return s.tokens.Revoke(ctx, tokenID)
+}
+
+// ResolveBoard 校验嵌入令牌并返回看板
+func (s *embedService) ResolveBoard(ctx context.Context, token string) (model.Board, error) {
+	_, b, err := s.verify(ctx, token)
+	return b, err
+}
+
+// Preview 分享链接的预览内容
+func (s *embedService) Preview(ctx context.Context, token, cardID string) (LinkPreview, error) {
+	rec, b, err := s.verify(ctx, token)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	p := LinkPreview{Board: b, ExpiresAt: rec.ExpiresAt}
+
+	if cardID == "" {
+		lists, err := s.lists.ListByBoard(ctx, b.ID)
+		if err != nil {
+			return LinkPreview{}, err
+		}
+		p.ListCount = len(lists)
+		if p.CardCount, err = s.cards.CountByBoard(ctx, b.ID); err != nil {
+			return LinkPreview{}, err
+		}
+		return p, nil
+	}
+
+	c, err := findCard(ctx, s.lists, s.cards, b.ID, "", cardID)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	l, err := s.lists.Get(ctx, b.ID, c.ListID)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	p.Card = &c
+	p.Status = l.Title
+	return p, nil
+}
+
+// verify 校验嵌入令牌，返回令牌记录和看板
+// 校验步骤：
+// 1. 签名和过期时间（由 JWT 库完成）
+// 2. aud 必须是嵌入令牌
+// 3. 服务端记录存在、未吊销，且看板 ID 与令牌一致
+func (s *embedService) verify(ctx context.Context, token string) (model.EmbedToken, model.Board, error) {
+	var claims jwt.RegisteredClaims
+	tok, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.signingKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(embedAudience),
+	)
+	if err != nil || !tok.Valid {
+		return model.EmbedToken{}, model.Board{}, ErrInvalidEmbedToken
+	}
+
+	rec, err := s.tokens.Get(ctx, claims.ID)
+	if err != nil {
+		return model.EmbedToken{}, model.Board{}, ErrInvalidEmbedToken
+	}
+	if rec.RevokedAt != nil || rec.BoardID != claims.Subject {
+		return model.EmbedToken{}, model.Board{}, ErrInvalidEmbedToken
+	}
+
+	// 看板已经删除和令牌无效返回同一个错误，不泄露看板是否存在
+	b, err := s.boards.Get(ctx, rec.CreatedBy, rec.BoardID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return model.EmbedToken{}, model.Board{}, ErrInvalidEmbedToken
+	}
+	if err != nil {
+		return model.EmbedToken{}, model.Board{}, err
+	}
+	// 只读看板照常嵌入（嵌入本来就是只读的），停用的看板不再对外展示
+	if b.State == model.BoardStateSuspended {
+		return model.EmbedToken{}, model.Board{}, ErrBoardSuspended
+	}
+	return rec, b, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"kanban_api/internal/repository"
+)
+
+// 错误类别
+// Service 层返回的每个错误都属于下面某一类（或者是数据库故障之类的内部错误，不属于任何一类），
+// HTTP 层用 errors.Is 判断类别决定状态码和错误码，不再靠比较错误文字
+var (
+	// ErrInvalidInput 请求参数不合法，例如标题为空、位置越界
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrUnauthorized 身份认证失败，例如密码错误、刷新令牌无效
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden 用户能看到看板，但角色不够执行这个操作（例如只读成员修改卡片）
+	// 完全看不到的看板仍然返回 ErrNotFound，不暴露看板是否存在
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound 资源不存在，或者用户看不到
+	// 直接复用仓储层的错误，仓储层返回的 ErrNotFound 原样往上传就行
+	ErrNotFound = repository.ErrNotFound
+
+	// ErrConflict 和已有数据冲突，例如邮箱已注册、slug 已被占用、看板已经被别人修改过
+	ErrConflict = errors.New("conflict")
+
+	// ErrVersionConflict 更新看板时版本对不上，不是单独的类别，而是 ErrConflict 的 Cause
+	// HTTP 层按客户端是用 If-Match 还是请求体里的 version 带的版本，分别返回 412 和 409
+	ErrVersionConflict = repository.ErrVersionConflict
+
+	// ErrTooLarge 上传的内容超过了大小限制，例如附件
+	ErrTooLarge = errors.New("too large")
+
+	// ErrQuotaExceeded 看板的附件超过了存储配额
+	// 和 ErrTooLarge 分开：文件本身没有超过大小限制，删掉一些旧附件之后就能上传
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrRestoring 附件的文件在冷存储里，已经开始恢复，稍后再试
+	// 不是出错，只是还拿不到内容：HTTP 层返回 202 和 Retry-After，客户端等一会儿重新请求
+	ErrRestoring = errors.New("restoring")
+
+	// ErrBoardReadOnly 看板处于只读状态（model.BoardStateReadOnly），只能查看不能修改
+	// 和 ErrForbidden 分开：角色够了也不行，客户端应该提示看板被锁定，而不是提示去找所有者要权限
+	ErrBoardReadOnly = errors.New("board is read-only")
+
+	// ErrBoardSuspended 看板已被管理员停用（model.BoardStateSuspended），谁都不能访问
+	ErrBoardSuspended = errors.New("board is suspended")
+)
+
+// Error 带类别的业务错误
+// Message 是可以直接展示给客户端的说明；Cause 是底层原因（可以为空），
+// 例如 ErrConflict 的 Cause 是仓储层的 ErrUserExists，调用方仍然能用 errors.Is 判断
+type Error struct {
+	Kind    error
+	Message string
+	Cause   error
+}
+
+// Error 实现 error 接口，只返回 Message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap 让 errors.Is 既能匹配类别，也能匹配底层原因
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// newError 创建一个 kind 类别的错误
+func newError(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// invalidInput 参数校验失败
+func invalidInput(message string) *Error {
+	return newError(ErrInvalidInput, message)
+}
+
+// conflict 把仓储层的唯一约束错误（ErrUserExists、ErrSlugExists）和版本冲突标成 ErrConflict
+// 其它错误原样返回
+func conflict(err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return &Error{Kind: ErrConflict, Message: "board has been modified, reload and try again", Cause: err}
+	}
+	if errors.Is(err, repository.ErrUserExists) || errors.Is(err, repository.ErrSlugExists) {
+		return &Error{Kind: ErrConflict, Message: err.Error(), Cause: err}
+	}
+	return err
+}
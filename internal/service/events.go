@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"io"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+)
+
+// 服务层的事件发布装饰器
+// 和 traced.go 的链路追踪装饰器一样实现同样的接口：调用下一层，成功之后往事件总线上发布一个事件，
+// 订阅了这个看板的 WebSocket 客户端就能实时看到修改（见 http/ws_handler.go）
+// 只有修改操作发布事件，查询操作直接转发
+//
+// 事件在修改完成之后才发布，失败的修改不会发布；
+// 总线不会阻塞（见 events.Bus.Publish），发布不影响接口的响应时间
+
+// IDRef 删除事件的 Data，只有被删除对象的 ID
+type IDRef struct {
+	ID     string `json:"id"`
+	ListID string `json:"listId,omitempty"`
+	CardID string `json:"cardId,omitempty"`
+}
+
+// MemberRef member.removed 事件的 Data：被移出看板的用户
+type MemberRef struct {
+	UserID string `json:"userId"`
+}
+
+// ListsMoved list.moved 事件的 Data：被移动的列表和移动后看板的全部列表
+type ListsMoved struct {
+	ListID string       `json:"listId"`
+	Lists  []model.List `json:"lists"`
+}
+
+// ChecklistsMoved checklist.moved 事件的 Data：被移动的清单和移动后卡片的全部清单
+type ChecklistsMoved struct {
+	ChecklistID string            `json:"checklistId"`
+	CardID      string            `json:"cardId"`
+	Checklists  []model.Checklist `json:"checklists"`
+}
+
+// CardMoved card.moved 事件的 Data
+// 跨看板移动时原看板和目标看板各收到一个事件，原看板的客户端把卡片从 FromListID 里去掉即可
+type CardMoved struct {
+	CardID      string `json:"cardId"`
+	FromBoardID string `json:"fromBoardId"`
+	FromListID  string `json:"fromListId"`
+	ToBoardID   string `json:"toBoardId"`
+	ToListID    string `json:"toListId"`
+
+	// Cards 移动后目标列表的全部卡片，只发给目标看板
+	// 原看板的订阅方不一定能看到目标看板，发给原看板的事件里没有这一项
+	Cards []model.Card `json:"cards,omitempty"`
+
+	// Card 被移动的卡片，只在发给原看板的事件里有
+	Card *model.Card `json:"card,omitempty"`
+}
+
+// ========== 看板服务装饰器 ==========
+
+type publishingBoardService struct {
+	BoardService
+	bus *events.Bus
+}
+
+// PublishBoardService 用事件发布装饰器包装看板服务
+func PublishBoardService(next BoardService, bus *events.Bus) BoardService {
+	return &publishingBoardService{BoardService: next, bus: bus}
+}
+
+// CreateBoard 一起创建的列表在 Board.Lists 里，不再逐个发布 list.created
+func (s *publishingBoardService) CreateBoard(ctx context.Context, userID string, in BoardInput) (model.Board, error) {
+	b, err := s.BoardService.CreateBoard(ctx, userID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardCreated, BoardID: b.ID, ActorID: userID, Data: b})
+	}
+	return b, err
+}
+
+// ImportBoard 导入的看板对订阅方来说就是新建的看板，其中的列表、卡片不再逐个发布事件
+func (s *publishingBoardService) ImportBoard(ctx context.Context, userID string, in BoardImport) (ImportResult, error) {
+	res, err := s.BoardService.ImportBoard(ctx, userID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardCreated, BoardID: res.Board.ID, ActorID: userID, Data: res.Board})
+	}
+	return res, err
+}
+
+// ClearSample 每张删除的示例卡片发布一个 card.deleted；中途出错时已经删除的卡片也要发布，订阅方和进度缓存才不会留着它们
+func (s *publishingBoardService) ClearSample(ctx context.Context, userID, boardID string) ([]model.Card, error) {
+	cards, err := s.BoardService.ClearSample(ctx, userID, boardID)
+	for _, c := range cards {
+		s.bus.Publish(events.Event{Type: events.CardDeleted, BoardID: boardID, ActorID: userID, Data: IDRef{ID: c.ID, ListID: c.ListID}})
+	}
+	return cards, err
+}
+
+func (s *publishingBoardService) UpdateBoard(ctx context.Context, userID, id, title, slug string, version int64) (model.Board, error) {
+	b, err := s.BoardService.UpdateBoard(ctx, userID, id, title, slug, version)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardUpdated, BoardID: id, ActorID: userID, Data: b})
+	}
+	return b, err
+}
+
+func (s *publishingBoardService) PatchBoard(ctx context.Context, userID, id string, p BoardPatch, version int64) (model.Board, error) {
+	b, err := s.BoardService.PatchBoard(ctx, userID, id, p, version)
+	if err == nil && (p.Title != nil || p.Slug != nil) {
+		s.bus.Publish(events.Event{Type: events.BoardUpdated, BoardID: id, ActorID: userID, Data: b})
+	}
+	return b, err
+}
+
+func (s *publishingBoardService) DeleteBoard(ctx context.Context, userID, id, confirmToken string) (*DeleteConfirmation, error) {
+	confirm, err := s.BoardService.DeleteBoard(ctx, userID, id, confirmToken)
+	// 返回确认令牌说明还没有真正删除
+	if err == nil && confirm == nil {
+		s.bus.Publish(events.Event{Type: events.BoardDeleted, BoardID: id, ActorID: userID, Data: IDRef{ID: id}})
+	}
+	return confirm, err
+}
+
+func (s *publishingBoardService) AdminDeleteBoard(ctx context.Context, id string) error {
+	err := s.BoardService.AdminDeleteBoard(ctx, id)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardDeleted, BoardID: id, Data: IDRef{ID: id}})
+	}
+	return err
+}
+
+// AdminSetBoardState 状态变化也是看板更新，客户端收到后刷新看板、按新状态禁用编辑
+func (s *publishingBoardService) AdminSetBoardState(ctx context.Context, adminID, id, state, reason string) (model.Board, error) {
+	b, err := s.BoardService.AdminSetBoardState(ctx, adminID, id, state, reason)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardUpdated, BoardID: id, ActorID: adminID, Data: b})
+	}
+	return b, err
+}
+
+// TransferBoard 所有者变了也是看板更新
+func (s *publishingBoardService) TransferBoard(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	b, err := s.BoardService.TransferBoard(ctx, ownerID, id, newOwnerID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.BoardUpdated, BoardID: id, ActorID: ownerID, Data: b})
+	}
+	return b, err
+}
+
+// ========== 列表服务装饰器 ==========
+
+type publishingListService struct {
+	ListService
+	bus *events.Bus
+}
+
+// PublishListService 用事件发布装饰器包装列表服务
+func PublishListService(next ListService, bus *events.Bus) ListService {
+	return &publishingListService{ListService: next, bus: bus}
+}
+
+func (s *publishingListService) CreateList(ctx context.Context, userID, boardID, title string) (model.List, error) {
+	l, err := s.ListService.CreateList(ctx, userID, boardID, title)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ListCreated, BoardID: boardID, ActorID: userID, Data: l})
+	}
+	return l, err
+}
+
+func (s *publishingListService) RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error) {
+	l, err := s.ListService.RenameList(ctx, userID, boardID, listID, title)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ListUpdated, BoardID: boardID, ActorID: userID, Data: l})
+	}
+	return l, err
+}
+
+func (s *publishingListService) MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error) {
+	lists, err := s.ListService.MoveList(ctx, userID, boardID, listID, position)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ListMoved, BoardID: boardID, ActorID: userID, Data: ListsMoved{ListID: listID, Lists: lists}})
+	}
+	return lists, err
+}
+
+func (s *publishingListService) DeleteList(ctx context.Context, userID, boardID, listID string) error {
+	err := s.ListService.DeleteList(ctx, userID, boardID, listID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ListDeleted, BoardID: boardID, ActorID: userID, Data: IDRef{ID: listID}})
+	}
+	return err
+}
+
+// ========== 卡片服务装饰器 ==========
+
+type publishingCardService struct {
+	CardService
+	bus *events.Bus
+}
+
+// PublishCardService 用事件发布装饰器包装卡片服务
+func PublishCardService(next CardService, bus *events.Bus) CardService {
+	return &publishingCardService{CardService: next, bus: bus}
+}
+
+func (s *publishingCardService) CreateCard(ctx context.Context, userID, boardID, listID string, in CardInput) (model.Card, error) {
+	c, err := s.CardService.CreateCard(ctx, userID, boardID, listID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardCreated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingCardService) CreateCardFromTemplate(ctx context.Context, userID, boardID, listID, templateID string, in CardInput) (model.Card, error) {
+	c, err := s.CardService.CreateCardFromTemplate(ctx, userID, boardID, listID, templateID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardCreated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingCardService) ReplaceCard(ctx context.Context, userID, boardID, listID, cardID string, in CardInput) (model.Card, error) {
+	c, err := s.CardService.ReplaceCard(ctx, userID, boardID, listID, cardID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingCardService) PatchCard(ctx context.Context, userID, boardID, listID, cardID string, p CardPatch) (model.Card, error) {
+	c, err := s.CardService.PatchCard(ctx, userID, boardID, listID, cardID, p)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingCardService) MoveCard(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string, position int) ([]model.Card, error) {
+	cards, err := s.CardService.MoveCard(ctx, userID, boardID, listID, cardID, toBoardID, toListID, position)
+	if err != nil {
+		return cards, err
+	}
+	// 和 MoveCard 本身一样补上省略的目标
+	if toBoardID == "" {
+		toBoardID = boardID
+	}
+	if toListID == "" {
+		toListID = listID
+	}
+	data := CardMoved{CardID: cardID, FromBoardID: boardID, FromListID: listID, ToBoardID: toBoardID, ToListID: toListID}
+	if toBoardID != boardID {
+		// 原看板只收到 ID 和被移动的卡片，目标列表里的其它卡片不能发给原看板的订阅方和 webhook
+		src := data
+		for i := range cards {
+			if cards[i].ID == cardID {
+				src.Card = &cards[i]
+				break
+			}
+		}
+		s.bus.Publish(events.Event{Type: events.CardMoved, BoardID: boardID, ActorID: userID, Data: src})
+	}
+	data.Cards = cards
+	s.bus.Publish(events.Event{Type: events.CardMoved, BoardID: toBoardID, ActorID: userID, Data: data})
+	return cards, nil
+}
+
+func (s *publishingCardService) DeleteCard(ctx context.Context, userID, boardID, listID, cardID string) error {
+	err := s.CardService.DeleteCard(ctx, userID, boardID, listID, cardID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardDeleted, BoardID: boardID, ActorID: userID, Data: IDRef{ID: cardID, ListID: listID}})
+	}
+	return err
+}
+
+// ========== 卡片镜像服务装饰器 ==========
+
+type publishingCardLinkService struct {
+	CardLinkService
+	bus *events.Bus
+}
+
+// PublishCardLinkService 用事件发布装饰器包装卡片镜像服务
+// 创建镜像就是在目标看板上创建一张卡片，目标看板收到 card.created；链接本身的变化不发布事件
+func PublishCardLinkService(next CardLinkService, bus *events.Bus) CardLinkService {
+	return &publishingCardLinkService{CardLinkService: next, bus: bus}
+}
+
+func (s *publishingCardLinkService) CreateMirror(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string) (model.Card, error) {
+	c, err := s.CardLinkService.CreateMirror(ctx, userID, boardID, listID, cardID, toBoardID, toListID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.CardCreated, BoardID: toBoardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+// ========== 看板成员服务装饰器 ==========
+
+type publishingMemberService struct {
+	MemberService
+	bus *events.Bus
+}
+
+// PublishMemberService 用事件发布装饰器包装看板成员服务
+// 除了让客户端刷新成员列表，WebSocket 连接也靠 member.removed 及时取消被移出的用户对这个看板的订阅
+func PublishMemberService(next MemberService, bus *events.Bus) MemberService {
+	return &publishingMemberService{MemberService: next, bus: bus}
+}
+
+func (s *publishingMemberService) AddMember(ctx context.Context, userID, boardID, email, role string) (model.BoardMember, error) {
+	m, err := s.MemberService.AddMember(ctx, userID, boardID, email, role)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.MemberAdded, BoardID: boardID, ActorID: userID, Data: m})
+	}
+	return m, err
+}
+
+func (s *publishingMemberService) RemoveMember(ctx context.Context, userID, boardID, memberID string) error {
+	err := s.MemberService.RemoveMember(ctx, userID, boardID, memberID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.MemberRemoved, BoardID: boardID, ActorID: userID, Data: MemberRef{UserID: memberID}})
+	}
+	return err
+}
+
+// ========== 检查清单服务装饰器 ==========
+
+type publishingChecklistService struct {
+	ChecklistService
+	bus *events.Bus
+}
+
+// PublishChecklistService 用事件发布装饰器包装检查清单服务
+func PublishChecklistService(next ChecklistService, bus *events.Bus) ChecklistService {
+	return &publishingChecklistService{ChecklistService: next, bus: bus}
+}
+
+func (s *publishingChecklistService) CreateChecklist(ctx context.Context, userID, boardID, listID, cardID, title string) (model.Checklist, error) {
+	c, err := s.ChecklistService.CreateChecklist(ctx, userID, boardID, listID, cardID, title)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistCreated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingChecklistService) RenameChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, title string) (model.Checklist, error) {
+	c, err := s.ChecklistService.RenameChecklist(ctx, userID, boardID, listID, cardID, checklistID, title)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingChecklistService) MoveChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string, position int) ([]model.Checklist, error) {
+	lists, err := s.ChecklistService.MoveChecklist(ctx, userID, boardID, listID, cardID, checklistID, position)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistMoved, BoardID: boardID, ActorID: userID, Data: ChecklistsMoved{ChecklistID: checklistID, CardID: cardID, Checklists: lists}})
+	}
+	return lists, err
+}
+
+func (s *publishingChecklistService) DeleteChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) error {
+	err := s.ChecklistService.DeleteChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistDeleted, BoardID: boardID, ActorID: userID, Data: IDRef{ID: checklistID, CardID: cardID}})
+	}
+	return err
+}
+
+// AddItem 条目的修改（添加、修改、移动、删除）都发 checklist.updated，带着修改后的整个清单（包括进度）
+func (s *publishingChecklistService) AddItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, text string) (model.Checklist, error) {
+	c, err := s.ChecklistService.AddItem(ctx, userID, boardID, listID, cardID, checklistID, text)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingChecklistService) UpdateItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, p ChecklistItemPatch) (model.Checklist, error) {
+	c, err := s.ChecklistService.UpdateItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, p)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingChecklistService) MoveItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, position int) (model.Checklist, error) {
+	c, err := s.ChecklistService.MoveItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, position)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+func (s *publishingChecklistService) DeleteItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string) (model.Checklist, error) {
+	c, err := s.ChecklistService.DeleteItem(ctx, userID, boardID, listID, cardID, checklistID, itemID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.ChecklistUpdated, BoardID: boardID, ActorID: userID, Data: c})
+	}
+	return c, err
+}
+
+// ========== 附件服务装饰器 ==========
+
+type publishingAttachmentService struct {
+	AttachmentService
+	bus *events.Bus
+}
+
+// PublishAttachmentService 用事件发布装饰器包装附件服务
+func PublishAttachmentService(next AttachmentService, bus *events.Bus) AttachmentService {
+	return &publishingAttachmentService{AttachmentService: next, bus: bus}
+}
+
+func (s *publishingAttachmentService) UploadAttachment(ctx context.Context, userID, boardID, listID, cardID string, in AttachmentUpload) (model.Attachment, error) {
+	a, err := s.AttachmentService.UploadAttachment(ctx, userID, boardID, listID, cardID, in)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.AttachmentCreated, BoardID: boardID, ActorID: userID, Data: a})
+	}
+	return a, err
+}
+
+// AppendUpload 分块上传收满之后才算创建了附件，中间的每一块不发事件
+func (s *publishingAttachmentService) AppendUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string, offset int64, body io.Reader) (model.ResumableUpload, *model.Attachment, error) {
+	u, a, err := s.AttachmentService.AppendUpload(ctx, userID, boardID, listID, cardID, uploadID, offset, body)
+	if err == nil && a != nil {
+		s.bus.Publish(events.Event{Type: events.AttachmentCreated, BoardID: boardID, ActorID: userID, Data: *a})
+	}
+	return u, a, err
+}
+
+func (s *publishingAttachmentService) DeleteAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) error {
+	err := s.AttachmentService.DeleteAttachment(ctx, userID, boardID, listID, cardID, attachmentID)
+	if err == nil {
+		s.bus.Publish(events.Event{Type: events.AttachmentDeleted, BoardID: boardID, ActorID: userID, Data: IDRef{ID: attachmentID, CardID: cardID}})
+	}
+	return err
+}
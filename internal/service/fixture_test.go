@@ -0,0 +1,24 @@
+package service
+
+import "kanban_api/internal/repository"
+
+// boardFixture 用内存仓储搭起来的看板服务，测试直接通过仓储准备数据、检查结果
+type boardFixture struct {
+	svc        BoardService
+	lists      repository.ListRepository
+	cards      repository.CardRepository
+	checklists repository.ChecklistRepository
+}
+
+// newBoardFixture 创建看板服务，默认列表是 Todo、Doing、Done
+func newBoardFixture() boardFixture {
+	f := boardFixture{
+		lists:      repository.NewMemListRepo(),
+		cards:      repository.NewMemCardRepo(),
+		checklists: repository.NewMemChecklistRepo(),
+	}
+	attachments := repository.NewMemAttachmentRepo()
+	storage := NewStorageCounters(repository.NewMemStorageRepo(), attachments, f.lists, f.cards, 0)
+	f.svc = NewBoardService(repository.NewMemBoardRepo(), f.lists, f.cards, f.checklists, attachments, repository.NewMemCardTemplateRepo(), storage, repository.NewMemMemberRepo(), []byte("secret"), 20, []string{"Todo", "Doing", "Done"})
+	return f
+}
@@ -0,0 +1,134 @@
+// Package service 列表业务逻辑层
+package service
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+)
+
+// ListService 列表服务接口
+// 列表属于看板，所以每个操作都先确认当前用户能访问看板：
+// 查看需要是看板成员，修改需要 editor 或 owner 角色
+type ListService interface {
+	// ListLists 按位置顺序列出看板的所有列表
+	ListLists(ctx context.Context, userID, boardID string) ([]model.List, error)
+
+	// CreateList 在看板末尾创建新列表
+	CreateList(ctx context.Context, userID, boardID, title string) (model.List, error)
+
+	// RenameList 修改列表标题
+	RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error)
+
+	// MoveList 把列表移动到指定位置，返回移动后看板的全部列表
+	MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error)
+
+	// DeleteList 删除列表
+	DeleteList(ctx context.Context, userID, boardID, listID string) error
+}
+
+// listService 列表服务的具体实现
+type listService struct {
+	lists  repository.ListRepository
+	access boardAccess
+
+	// cards、checklists、attachments 卡片、检查清单和附件仓储，删除列表时一并删除其中的卡片和卡片的清单、附件
+	cards       repository.CardRepository
+	checklists  repository.ChecklistRepository
+	attachments repository.AttachmentRepository
+
+	// storage 附件占用空间的计数器，删除列表时减去其中的附件
+	storage *StorageCounters
+}
+
+// NewListService 创建列表服务实例
+func NewListService(lists repository.ListRepository, boards repository.BoardRepository, cards repository.CardRepository, checklists repository.ChecklistRepository, attachments repository.AttachmentRepository, storage *StorageCounters, members repository.MemberRepository) ListService {
+	return &listService{lists: lists, access: boardAccess{boards: boards, members: members}, cards: cards, checklists: checklists, attachments: attachments, storage: storage}
+}
+
+// checkBoard 确认当前用户对看板至少有 need 角色
+// 看不到的看板返回 ErrNotFound，和看板不存在一样；角色不够返回 ErrForbidden
+func (s *listService) checkBoard(ctx context.Context, userID, boardID, need string) error {
+	_, _, err := s.access.check(ctx, userID, boardID, need)
+	return err
+}
+
+// ListLists 列出看板的所有列表
+func (s *listService) ListLists(ctx context.Context, userID, boardID string) ([]model.List, error) {
+	if err := s.checkBoard(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.lists.ListByBoard(ctx, boardID)
+}
+
+// CreateList 创建新列表
+func (s *listService) CreateList(ctx context.Context, userID, boardID, title string) (model.List, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return model.List{}, invalidInput("title required")
+	}
+	if err := s.checkBoard(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return model.List{}, err
+	}
+	return s.lists.Create(ctx, boardID, title)
+}
+
+// RenameList 修改列表标题
+func (s *listService) RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return model.List{}, invalidInput("title required")
+	}
+	if err := s.checkBoard(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return model.List{}, err
+	}
+	return s.lists.Rename(ctx, boardID, listID, title)
+}
+
+// MoveList 移动列表位置
+func (s *listService) MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error) {
+	if position < 0 {
+		return nil, invalidInput("invalid position")
+	}
+	if err := s.checkBoard(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	return s.lists.Move(ctx, boardID, listID, position)
+}
+
+// DeleteList 删除列表以及其中的所有卡片
+// 清单和附件只记着卡片 ID，要在删除卡片之前查出卡片 ID
+func (s *listService) DeleteList(ctx context.Context, userID, boardID, listID string) error {
+	if err := s.checkBoard(ctx, userID, boardID, model.RoleEditor); err != nil {
+		return err
+	}
+	cards, err := s.cards.ListByList(ctx, listID)
+	if err != nil {
+		return err
+	}
+	if err := s.lists.Delete(ctx, boardID, listID); err != nil {
+		return err
+	}
+	if err := s.cards.DeleteByList(ctx, listID); err != nil {
+		return err
+	}
+	if err := s.checklists.DeleteByCards(ctx, cardIDs(cards)); err != nil {
+		return err
+	}
+	usage := s.storage.usageOf(ctx, cardIDs(cards))
+	if err := s.attachments.DeleteByCards(ctx, cardIDs(cards)); err != nil {
+		return err
+	}
+	s.storage.sub(ctx, boardID, usage)
+	return nil
+}
+
+// cardIDs 取出卡片的 ID
+func cardIDs(cards []model.Card) []string {
+	ids := make([]string, 0, len(cards))
+	for _, c := range cards {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"strings"
+)
+
+// MemberService 看板成员服务接口
+// 看板所有者可以邀请其他用户成为 editor 或 viewer，实现多人协作
+type MemberService interface {
+	// ListMembers 列出看板的所有成员，第一个是所有者
+	// 看板的任何成员都可以查看
+	ListMembers(ctx context.Context, userID, boardID string) ([]model.BoardMember, error)
+
+	// AddMember 通过邮箱邀请用户加入看板，只有所有者可以操作
+	// 用户已经是成员时修改其角色
+	AddMember(ctx context.Context, userID, boardID, email, role string) (model.BoardMember, error)
+
+	// RemoveMember 移除成员
+	// 所有者可以移除任何成员；成员可以移除自己（退出看板）
+	RemoveMember(ctx context.Context, userID, boardID, memberID string) error
+}
+
+// memberService 成员服务的具体实现
+type memberService struct {
+	members repository.MemberRepository
+	users   repository.UserRepository
+	access  boardAccess
+}
+
+// NewMemberService 创建成员服务实例
+func NewMemberService(members repository.MemberRepository, boards repository.BoardRepository, users repository.UserRepository) MemberService {
+	return &memberService{
+		members: members,
+		users:   users,
+		access:  boardAccess{boards: boards, members: members},
+	}
+}
+
+// ListMembers 列出看板成员
+func (s *memberService) ListMembers(ctx context.Context, userID, boardID string) ([]model.BoardMember, error) {
+	b, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.members.ListByBoard(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 所有者没有成员记录，这里补上，客户端可以直接展示完整的成员列表
+	out := make([]model.BoardMember, 0, len(members)+1)
+	out = append(out, model.BoardMember{BoardID: b.ID, UserID: b.OwnerID, Role: model.RoleOwner, CreatedAt: b.CreatedAt})
+	out = append(out, members...)
+
+	// 填充邮箱，用户已被删除时留空
+	for i := range out {
+		u, err := s.users.GetByID(ctx, out[i].UserID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		out[i].Email = u.Email
+	}
+	return out, nil
+}
+
+// AddMember 邀请成员
+func (s *memberService) AddMember(ctx context.Context, userID, boardID, email, role string) (model.BoardMember, error) {
+	// 只能邀请为 editor 或 viewer，所有者只有一个
+	if role != model.RoleEditor && role != model.RoleViewer {
+		return model.BoardMember{}, invalidInput("role must be editor or viewer")
+	}
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleOwner); err != nil {
+		return model.BoardMember{}, err
+	}
+
+	// 邮箱和注册时一样标准化
+	u, err := s.users.GetByEmail(ctx, strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return model.BoardMember{}, invalidInput("user not registered")
+		}
+		return model.BoardMember{}, err
+	}
+	if u.ID == userID {
+		return model.BoardMember{}, newError(ErrConflict, "owner is already a member")
+	}
+
+	m, err := s.members.Upsert(ctx, boardID, u.ID, role)
+	if err != nil {
+		return model.BoardMember{}, err
+	}
+	m.Email = u.Email
+	return m, nil
+}
+
+// RemoveMember 移除成员
+func (s *memberService) RemoveMember(ctx context.Context, userID, boardID, memberID string) error {
+	// 成员退出看板只需要能看到看板；移除别人需要是所有者
+	need := model.RoleOwner
+	if memberID == userID {
+		need = model.RoleViewer
+	}
+	_, role, err := s.access.check(ctx, userID, boardID, need)
+	if err != nil {
+		return err
+	}
+	if memberID == userID && role == model.RoleOwner {
+		// 所有者不能退出自己的看板，想放弃看板应该删除它
+		return invalidInput("owner cannot leave the board")
+	}
+	return s.members.Remove(ctx, boardID, memberID)
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"log/slog"
+	"math"
+	"strings"
+)
+
+// mirrorQueueSize 等待同步的事件队列长度，满了之后新事件直接丢弃并打印警告
+const mirrorQueueSize = 1000
+
+// MirrorSync 卡片镜像的同步
+// 监听事件总线（见 Handle），一张卡片变了就把变化同步到链接另一头的所有卡片：
+// - card.updated：标题不一样时改成一样的
+// - card.moved：把另一头的卡片移到它所在看板上同名（不区分大小写）的列表末尾；没有同名列表时不动
+//
+// 列表名就是卡片的状态（例如 "To Do"、"Doing"、"Done"），所以按名字而不是按位置找列表
+// - card.deleted：删掉卡片的所有链接，另一头的卡片保留
+//
+// 同步后的修改同样发布事件（操作人是原来的操作人），实时推送和 webhook 都能收到；
+// 另一头收到事件后再同步回来时标题、列表已经一样，不会来回同步。同步的修改不记操作记录
+//
+// 注意：
+// - 和 webhook 一样在进程内排队，队列满了或者进程退出时没处理完的同步会丢失
+// - 另一头的看板只读或者停用时不同步
+type MirrorSync struct {
+	links  repository.CardLinkRepository
+	cards  repository.CardRepository
+	lists  repository.ListRepository
+	boards repository.BoardRepository
+	bus    *events.Bus
+	log    *slog.Logger
+
+	queue chan events.Event
+}
+
+// NewMirrorSync 创建镜像同步，创建之后要用 bus.Listen(m.Handle) 接上事件总线，再调用 Run
+func NewMirrorSync(links repository.CardLinkRepository, cards repository.CardRepository, lists repository.ListRepository, boards repository.BoardRepository, bus *events.Bus) *MirrorSync {
+	return &MirrorSync{
+		links:  links,
+		cards:  cards,
+		lists:  lists,
+		boards: boards,
+		bus:    bus,
+		log:    slog.Default().With("component", "mirror-sync"),
+		queue:  make(chan events.Event, mirrorQueueSize),
+	}
+}
+
+// Handle 接收一个事件，总线同步调用它，所以只把卡片的事件放进队列
+func (m *MirrorSync) Handle(e events.Event) {
+	switch e.Type {
+	case events.CardUpdated, events.CardMoved, events.CardDeleted:
+	default:
+		return
+	}
+	select {
+	case m.queue <- e:
+	default:
+		m.log.Warn("mirror sync queue full, event dropped", "type", e.Type, "board_id", e.BoardID)
+	}
+}
+
+// Run 处理排队的事件，直到 ctx 被取消
+func (m *MirrorSync) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-m.queue:
+			if err := m.handle(ctx, e); err != nil {
+				m.log.Warn("sync card mirrors", "type", e.Type, "board_id", e.BoardID, "err", err)
+			}
+		}
+	}
+}
+
+// handle 同步一个事件
+func (m *MirrorSync) handle(ctx context.Context, e events.Event) error {
+	switch e.Type {
+	case events.CardUpdated:
+		if c, ok := e.Data.(model.Card); ok {
+			return m.syncTitle(ctx, e.ActorID, c)
+		}
+	case events.CardMoved:
+		// 跨看板移动时原看板先收到一个事件，只处理发给目标看板的那个
+		if d, ok := e.Data.(CardMoved); ok && e.BoardID == d.ToBoardID {
+			return m.syncList(ctx, e.ActorID, d)
+		}
+	case events.CardDeleted:
+		if ref, ok := e.Data.(IDRef); ok {
+			return m.links.DeleteByCard(ctx, ref.ID)
+		}
+	}
+	return nil
+}
+
+// linked 卡片链接另一头的所有卡片，另一头已经被删除的链接顺便删掉
+func (m *MirrorSync) linked(ctx context.Context, cardID string) ([]model.Card, error) {
+	links, err := m.links.ListByCard(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.Card, 0, len(links))
+	for _, l := range links {
+		c, err := m.cards.GetByID(ctx, l.Other(cardID))
+		if errors.Is(err, repository.ErrNotFound) {
+			if err := m.links.Delete(ctx, l.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok, err := m.writable(ctx, c.BoardID); err != nil {
+			return nil, err
+		} else if ok {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// writable 看板还在，并且状态允许修改（见 checkState）
+func (m *MirrorSync) writable(ctx context.Context, boardID string) (bool, error) {
+	boards, err := m.boards.ListByIDs(ctx, []string{boardID})
+	if err != nil {
+		return false, err
+	}
+	return len(boards) > 0 && checkState(boards[0], model.RoleEditor) == nil, nil
+}
+
+// syncTitle 把卡片的标题同步到另一头
+func (m *MirrorSync) syncTitle(ctx context.Context, actorID string, c model.Card) error {
+	others, err := m.linked(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	for _, o := range others {
+		if o.Title == c.Title {
+			continue
+		}
+		title := c.Title
+		updated, err := m.cards.Update(ctx, o.ListID, o.ID, repository.CardChanges{Title: &title})
+		if errors.Is(err, repository.ErrNotFound) {
+			continue // 卡片刚被移走或删除
+		}
+		if err != nil {
+			return err
+		}
+		m.bus.Publish(events.Event{Type: events.CardUpdated, BoardID: updated.BoardID, ActorID: actorID, Data: updated})
+	}
+	return nil
+}
+
+// syncList 把另一头的卡片移到同名的列表
+func (m *MirrorSync) syncList(ctx context.Context, actorID string, d CardMoved) error {
+	to, err := m.lists.Get(ctx, d.ToBoardID, d.ToListID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil // 列表刚被删除
+	}
+	if err != nil {
+		return err
+	}
+	others, err := m.linked(ctx, d.CardID)
+	if err != nil {
+		return err
+	}
+	for _, o := range others {
+		lists, err := m.lists.ListByBoard(ctx, o.BoardID)
+		if err != nil {
+			return err
+		}
+		var target *model.List
+		for i := range lists {
+			if strings.EqualFold(strings.TrimSpace(lists[i].Title), strings.TrimSpace(to.Title)) {
+				target = &lists[i]
+				break
+			}
+		}
+		if target == nil || target.ID == o.ListID {
+			continue
+		}
+		// 位置超出范围时放到列表末尾
+		cards, err := m.cards.Move(ctx, o.ListID, o.ID, o.BoardID, target.ID, math.MaxInt)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		m.bus.Publish(events.Event{Type: events.CardMoved, BoardID: o.BoardID, ActorID: actorID, Data: CardMoved{
+			CardID:      o.ID,
+			FromBoardID: o.BoardID,
+			FromListID:  o.ListID,
+			ToBoardID:   o.BoardID,
+			ToListID:    target.ID,
+			Cards:       cards,
+		}})
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/oauth"
+	"kanban_api/internal/repository"
+	"sort"
+	"time"
+)
+
+// ErrUnknownProvider 没有这个第三方登录提供方，或者没有配置
+var ErrUnknownProvider = newError(ErrNotFound, "unknown oauth provider")
+
+// ErrOAuthFailed 用授权码换不到用户身份：授权码无效、过期、已经用过，或者提供方出错
+var ErrOAuthFailed = newError(ErrUnauthorized, "oauth login failed")
+
+// ErrEmailNotVerified 提供方没有验证过用户的邮箱，不能用来关联或者创建账号
+var ErrEmailNotVerified = newError(ErrForbidden, "email not verified by provider")
+
+// ErrAccountNotLinked 邮箱已经注册过，但本地账号没确认过邮箱，不能按邮箱关联
+// 用户先用这个邮箱走一遍忘记密码（确认邮箱是自己的），再用第三方登录就会关联上
+var ErrAccountNotLinked = newError(ErrConflict, "an account with this email exists; reset its password to confirm the email before signing in with this provider")
+
+// OAuthService 第三方登录服务接口
+// 第三方身份第一次登录时按邮箱关联：邮箱已经注册过、并且本地账号确认过邮箱就关联到这个用户，没注册过就创建一个新用户；
+// 之后按身份（提供方 + 提供方的用户 ID）找到用户，用户在提供方改了邮箱也不影响
+type OAuthService interface {
+	// Providers 启用了的提供方名字，按字母排序
+	Providers() []string
+
+	// Start 开始登录，返回提供方授权页的地址和这次登录的 state
+	// 调用方要把 state 保存在浏览器里（例如 cookie），回调时和提供方带回来的 state 比较，防止 CSRF
+	Start(provider string) (authURL, state string, err error)
+
+	// Login 用回调收到的授权码登录，返回用户和令牌对
+	// 停用、注销了的账号和密码登录一样返回 ErrAccountDisabled、ErrAccountDeleted
+	Login(ctx context.Context, provider, code string) (model.User, TokenPair, error)
+}
+
+// oauthService 第三方登录服务的具体实现
+type oauthService struct {
+	providers  map[string]oauth.Provider
+	users      repository.UserRepository
+	identities repository.UserIdentityRepository
+
+	// tokens 颁发令牌，和密码登录用同样的密钥和有效期
+	tokens *authService
+}
+
+// NewOAuthService 创建第三方登录服务实例
+// providers 按名字索引，见 oauth.FromEnv；后面几个参数和 NewAuthService 相同
+func NewOAuthService(providers map[string]oauth.Provider, users repository.UserRepository, identities repository.UserIdentityRepository, refreshTokens repository.RefreshTokenRepository, keys *jwtkeys.Set, tokenTTL, refreshTTL time.Duration) OAuthService {
+	return &oauthService{
+		providers:  providers,
+		users:      users,
+		identities: identities,
+		tokens: &authService{
+			users:         users,
+			refreshTokens: refreshTokens,
+			keys:          keys,
+			tokenTTL:      tokenTTL,
+			refreshTTL:    refreshTTL,
+		},
+	}
+}
+
+// Providers 启用了的提供方
+func (s *oauthService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start 生成 state，返回授权页地址
+func (s *oauthService) Start(provider string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	state, err := newOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	return p.AuthCodeURL(state), state, nil
+}
+
+// Login 换取身份，找到或创建用户，颁发令牌
+func (s *oauthService) Login(ctx context.Context, provider, code string) (model.User, TokenPair, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return model.User{}, TokenPair{}, ErrUnknownProvider
+	}
+	if code == "" {
+		return model.User{}, TokenPair{}, invalidInput("code required")
+	}
+	ident, err := p.Exchange(ctx, code)
+	if err != nil {
+		// 具体原因只写日志，提供方的错误信息不返回给客户端
+		logging.FromContext(ctx).Warn("oauth exchange failed", "provider", provider, "err", err)
+		return model.User{}, TokenPair{}, ErrOAuthFailed
+	}
+
+	u, err := s.userFor(ctx, provider, ident)
+	if err != nil {
+		return model.User{}, TokenPair{}, err
+	}
+	if u.DeletedAt != nil {
+		return model.User{}, TokenPair{}, ErrAccountDeleted
+	}
+	if u.DisabledAt != nil {
+		return model.User{}, TokenPair{}, ErrAccountDisabled
+	}
+	pair, err := s.tokens.issueTokens(ctx, u, "")
+	return u, pair, err
+}
+
+// userFor 找到第三方身份对应的用户，第一次登录时按邮箱关联或者创建用户
+func (s *oauthService) userFor(ctx context.Context, provider string, ident oauth.Identity) (model.User, error) {
+	link, err := s.identities.Get(ctx, provider, ident.Subject)
+	switch {
+	case err == nil:
+		u, err := s.users.GetByID(ctx, link.UserID)
+		if !errors.Is(err, repository.ErrNotFound) {
+			return u, err
+		}
+		// 用户已经被删除（注销过了宽限期），关联跟着作废，下面按新身份处理
+		if err := s.identities.Delete(ctx, link.ID); err != nil {
+			return model.User{}, err
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return model.User{}, err
+	}
+
+	// 没有验证过的邮箱谁都能填，按它关联就能登录别人的账号
+	if ident.Email == "" || !ident.EmailVerified {
+		return model.User{}, ErrEmailNotVerified
+	}
+	u, err := s.users.GetByEmail(ctx, ident.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		u, err = s.createUser(ctx, ident.Email)
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	// 注册和修改邮箱都不验证邮箱：别人可以先用受害者的邮箱注册一个账号，
+	// 等受害者用第三方登录关联上，就能用自己设的密码登录同一个账号
+	if u.EmailVerifiedAt == nil {
+		return model.User{}, ErrAccountNotLinked
+	}
+	if _, err := s.identities.Create(ctx, u.ID, provider, ident.Subject, ident.Email); err != nil {
+		// 同一个身份的另一次登录刚刚关联过，只要关联的是同一个用户就没关系
+		if errors.Is(err, repository.ErrIdentityLinked) {
+			if link, gerr := s.identities.Get(ctx, provider, ident.Subject); gerr == nil && link.UserID == u.ID {
+				return u, nil
+			}
+		}
+		return model.User{}, conflict(err)
+	}
+	return u, nil
+}
+
+// createUser 为第三方登录创建用户
+// 密码设成一串谁也不知道的随机字符的哈希：这个账号不能用密码登录，
+// 想用密码登录时走忘记密码的流程设置一个
+func (s *oauthService) createUser(ctx context.Context, email string) (model.User, error) {
+	random, err := newOpaqueToken()
+	if err != nil {
+		return model.User{}, err
+	}
+	// 随机串 43 个字节，在 bcrypt 的 72 字节限制之内
+	hash, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, err
+	}
+	u, err := s.users.Create(ctx, email, string(hash))
+	if errors.Is(err, repository.ErrUserExists) {
+		// 同一个邮箱同时在注册，用已经注册好的那个（还是要确认过邮箱才能关联）
+		return s.users.GetByEmail(ctx, email)
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+	// 邮箱是提供方验证过的
+	now := time.Now()
+	if err := s.users.SetEmailVerified(ctx, u.ID, now); err != nil {
+		return model.User{}, err
+	}
+	u.EmailVerifiedAt = &now
+	return u, nil
+}
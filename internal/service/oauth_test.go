@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/jwtkeys"
+	"kanban_api/internal/oauth"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// fakeProvider 按授权码返回事先准备好的身份，不认识的授权码当作无效
+type fakeProvider map[string]oauth.Identity
+
+func (fakeProvider) Name() string { return "fake" }
+
+func (fakeProvider) AuthCodeURL(state string) string {
+	return "https://provider.test/auth?state=" + state
+}
+
+func (p fakeProvider) Exchange(ctx context.Context, code string) (oauth.Identity, error) {
+	id, ok := p[code]
+	if !ok {
+		return oauth.Identity{}, errors.New("bad code")
+	}
+	return id, nil
+}
+
+// TestOAuthLogin 第一次登录按验证过的邮箱关联已有用户或者创建新用户，之后按身份找到用户；
+// 本地账号没确认过邮箱时不关联；没验证的邮箱、无效的授权码、停用的账号都不能登录
+func TestOAuthLogin(t *testing.T) {
+	ctx := context.Background()
+	users, refreshTokens, resets := repository.NewMemUserRepo(), repository.NewMemRefreshTokenRepo(), repository.NewMemPasswordResetRepo()
+	auth := NewAuthService(users, refreshTokens, resets, jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+	provider := fakeProvider{
+		"existing":   {Subject: "1", Email: "old@example.com", EmailVerified: true},
+		"new":        {Subject: "2", Email: "new@example.com", EmailVerified: true},
+		"renamed":    {Subject: "2", Email: "renamed@example.com", EmailVerified: true},
+		"unverified": {Subject: "3", Email: "old@example.com"},
+	}
+	svc := NewOAuthService(map[string]oauth.Provider{"fake": provider}, users, repository.NewMemUserIdentityRepo(), refreshTokens, jwtkeys.NewHMAC([]byte("secret")), time.Hour, 24*time.Hour)
+
+	if _, state, err := svc.Start("fake"); err != nil || state == "" {
+		t.Fatalf("start = %q, %v", state, err)
+	}
+	if _, _, err := svc.Start("nope"); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("start unknown provider: %v, want ErrUnknownProvider", err)
+	}
+
+	old, _, err := auth.Register(ctx, "old@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 注册不验证邮箱，这个账号可能是别人抢先用受害者的邮箱注册的，不能关联
+	if _, _, err := svc.Login(ctx, "fake", "existing"); !errors.Is(err, ErrAccountNotLinked) {
+		t.Fatalf("login with an unverified local account: %v, want ErrAccountNotLinked", err)
+	}
+	// 通过邮件里的链接重置过密码，邮箱就确认了
+	if _, err := resets.Create(ctx, old.ID, hashToken("reset-token"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPasswordService(users, resets, refreshTokens, nil, "", time.Hour).ResetPassword(ctx, "reset-token", "password456"); err != nil {
+		t.Fatal(err)
+	}
+	u, pair, err := svc.Login(ctx, "fake", "existing")
+	if err != nil || u.ID != old.ID || pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("login with registered email = %+v, %+v, %v, want user %s", u, pair, err, old.ID)
+	}
+	// 刷新令牌和密码登录颁发的一样能用
+	if _, err := auth.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	created, _, err := svc.Login(ctx, "fake", "new")
+	if err != nil || created.Email != "new@example.com" || created.ID == old.ID || created.EmailVerifiedAt == nil {
+		t.Fatalf("login with new email = %+v, %v", created, err)
+	}
+	// 新用户不能用密码登录
+	if _, _, err := auth.Login(ctx, "new@example.com", ""); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("password login of oauth user: %v, want ErrUnauthorized", err)
+	}
+	// 提供方那边改了邮箱，仍然按身份找到同一个用户
+	if u, _, err := svc.Login(ctx, "fake", "renamed"); err != nil || u.ID != created.ID {
+		t.Fatalf("login after email change = %+v, %v, want user %s", u, err, created.ID)
+	}
+
+	if _, _, err := svc.Login(ctx, "fake", "unverified"); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("unverified email: %v, want ErrEmailNotVerified", err)
+	}
+	if _, _, err := svc.Login(ctx, "fake", "bogus"); !errors.Is(err, ErrOAuthFailed) {
+		t.Fatalf("bad code: %v, want ErrOAuthFailed", err)
+	}
+	now := time.Now()
+	if _, err := users.SetDisabled(ctx, old.ID, &now); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Login(ctx, "fake", "existing"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("disabled account: %v, want ErrAccountDisabled", err)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/mail"
+	"kanban_api/internal/repository"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidResetToken 重置令牌不存在、已过期或已被使用
+var ErrInvalidResetToken = invalidInput("invalid or expired reset token")
+
+// ErrWeakPassword 新密码不满足强度要求
+var ErrWeakPassword = invalidInput("password must be 8-72 bytes and contain both letters and digits")
+
+// validatePassword 检查密码的强度，注册、修改密码和重置密码共用
+// 规则：
+// - 至少 8 个字符
+// - 不超过 72 字节：bcrypt 只使用前 72 字节，更长的部分会被忽略（新版本会直接报错）
+// - 同时包含字母和数字
+func validatePassword(password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return ErrWeakPassword
+	}
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// PasswordService 密码重置服务接口
+// 忘记密码的流程：
+// 1. 用户提交邮箱，服务端生成一个短期有效的重置令牌，通过邮件发给用户
+// 2. 用户凭邮件里的令牌提交新密码，服务端校验令牌后更新密码
+type PasswordService interface {
+	// ForgotPassword 给邮箱对应的用户发送重置邮件
+	// 邮箱没有注册时也返回 nil，不泄露"邮箱是否注册过"
+	ForgotPassword(ctx context.Context, email string) error
+
+	// ResetPassword 凭重置令牌设置新密码
+	// 成功后该用户所有的刷新令牌都会被吊销，其它设备需要用新密码重新登录
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+// passwordService 密码重置服务的具体实现
+type passwordService struct {
+	users         repository.UserRepository
+	resets        repository.PasswordResetRepository
+	refreshTokens repository.RefreshTokenRepository
+
+	// sender 邮件发送器
+	sender mail.Sender
+
+	// resetURL 邮件里重置链接的前缀，令牌直接拼在后面
+	// 例如 "https://kanban.example.com/reset-password?token="
+	resetURL string
+
+	// ttl 重置令牌的有效期，例如 30 分钟
+	ttl time.Duration
+}
+
+// NewPasswordService 创建密码重置服务实例
+func NewPasswordService(users repository.UserRepository, resets repository.PasswordResetRepository, refreshTokens repository.RefreshTokenRepository, sender mail.Sender, resetURL string, ttl time.Duration) PasswordService {
+	return &passwordService{
+		users:         users,
+		resets:        resets,
+		refreshTokens: refreshTokens,
+		sender:        sender,
+		resetURL:      resetURL,
+		ttl:           ttl,
+	}
+}
+
+// ForgotPassword 生成重置令牌并发送邮件
+func (s *passwordService) ForgotPassword(ctx context.Context, email string) error {
+	// 和注册、登录一样先标准化邮箱
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return invalidInput("email required")
+	}
+
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// 邮箱不存在也当作成功，和登录接口同样的考虑：防止枚举邮箱
+			return nil
+		}
+		return err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if _, err := s.resets.Create(ctx, u.ID, hashToken(token), time.Now().Add(s.ttl)); err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "重置你的看板密码",
+		Body: "你（或其他人）请求重置看板账号的密码。\n\n" +
+			"打开下面的链接设置新密码，链接 " + s.ttl.String() + " 内有效，只能使用一次：\n" +
+			s.resetURL + token + "\n\n" +
+			"如果不是你本人操作，忽略这封邮件即可，你的密码不会改变。\n",
+	}
+
+	// 在后台发送邮件，不等发送结果：
+	// 1. SMTP 可能很慢，不应该拖慢接口
+	// 2. 如果同步发送，"邮箱存在"的请求明显比"邮箱不存在"的慢，还是能被用来枚举邮箱
+	// 请求结束后 ctx 会被取消，所以用 WithoutCancel 脱离请求，另外加一个超时
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		if err := s.sender.Send(sendCtx, msg); err != nil {
+			logging.FromContext(sendCtx).Error("send password reset mail", "user_id", u.ID, "err", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword 校验重置令牌并更新密码
+func (s *passwordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	rec, err := s.resets.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if rec.UsedAt != nil || time.Now().After(rec.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	// 先把令牌标记为已使用，再改密码
+	// MarkUsed 返回 false 说明并发请求已经用掉了这个令牌
+	ok, err := s.resets.MarkUsed(ctx, rec.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, rec.UserID, string(hash)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	// 重置链接是发到用户邮箱的，能用它说明邮箱是用户自己的（修改邮箱时发到旧邮箱的链接已经作废了）
+	if err := s.users.SetEmailVerified(ctx, rec.UserID, time.Now()); err != nil {
+		return err
+	}
+
+	// 密码可能是因为泄露才被重置的，让所有已登录的设备都失效
+	return s.refreshTokens.RevokeUser(ctx, rec.UserID)
+}
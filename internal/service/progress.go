@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"maps"
+	"sync"
+)
+
+// ProgressRollups 检查清单完成进度的逐级汇总：条目 -> 清单 -> 卡片 -> 列表 -> 看板，缓存在内存里
+//
+// 看板第一次被读到时从数据库加载一次（每个列表查一次卡片，再一条查询查出所有清单的进度），
+// 之后监听事件总线（见 Handle），按事件里的清单、卡片增量更新，读的时候不再查询
+// 列表和看板的进度是条目数直接相加（和卡片汇总多个清单一样），不是卡片百分比的平均：
+// 十个条目的卡片比一个条目的卡片分量重
+//
+// 事件里的信息不够增量更新时（例如卡片从没有缓存的看板移过来），就把整个看板从缓存里去掉，下次读的时候重新加载
+//
+// 注意：
+// - 和事件总线一样只在进程内，多实例部署时收不到别的实例上的修改，缓存的进度会和数据库对不上
+// - 事件在修改完成之后才发布，中间很短的时间里读到的还是修改前的进度
+// - 缓存不过期也不淘汰，只在看板删除时去掉；每张卡片几十字节，看板很多时注意内存
+type ProgressRollups struct {
+	lists      repository.ListRepository
+	cards      repository.CardRepository
+	checklists repository.ChecklistRepository
+
+	mu sync.Mutex
+
+	// boards 看板 ID -> 已经加载的看板
+	boards map[string]*boardRollup
+
+	// cardBoards 已经缓存的卡片 ID -> 所在看板 ID，按卡片 ID 读进度时使用
+	cardBoards map[string]string
+
+	// loading 正在加载的看板 ID -> 加载状态
+	// 加载期间收到这个看板的事件时加载结果可能已经旧了，不放进缓存
+	loading map[string]*rollupLoad
+}
+
+// rollupLoad 一个看板的加载状态
+type rollupLoad struct {
+	// loaders 正在加载这个看板的请求数
+	loaders int
+
+	// events 开始加载以来收到的事件数
+	events int
+}
+
+// boardRollup 一个看板的进度，lists 和 total 随卡片的变化增量维护
+type boardRollup struct {
+	cards map[string]*cardRollup
+
+	// lists 列表 ID -> 列表中所有卡片的进度；没有条目的列表可能不在里面
+	lists map[string]model.ChecklistProgress
+
+	total model.ChecklistProgress
+}
+
+// cardRollup 一张卡片的进度
+type cardRollup struct {
+	listID string
+
+	// checklists 清单 ID -> 清单的进度，没有条目的清单不在里面
+	checklists map[string]model.ChecklistProgress
+
+	progress model.ChecklistProgress
+}
+
+// NewProgressRollups 创建进度缓存，创建之后要用 bus.Listen(r.Handle) 接上事件总线
+func NewProgressRollups(lists repository.ListRepository, cards repository.CardRepository, checklists repository.ChecklistRepository) *ProgressRollups {
+	return &ProgressRollups{
+		lists:      lists,
+		cards:      cards,
+		checklists: checklists,
+		boards:     make(map[string]*boardRollup),
+		cardBoards: make(map[string]string),
+		loading:    make(map[string]*rollupLoad),
+	}
+}
+
+// Board 看板的进度和每个列表的进度（列表 ID -> 进度），没有缓存时先加载
+// 没有条目的列表可能不在返回的 map 里，当作 0 处理
+func (r *ProgressRollups) Board(ctx context.Context, boardID string) (model.ChecklistProgress, map[string]model.ChecklistProgress, error) {
+	r.mu.Lock()
+	if b, ok := r.boards[boardID]; ok {
+		defer r.mu.Unlock()
+		return b.total, maps.Clone(b.lists), nil
+	}
+	st, ok := r.loading[boardID]
+	if !ok {
+		st = &rollupLoad{}
+		r.loading[boardID] = st
+	}
+	st.loaders++
+	seen := st.events
+	r.mu.Unlock()
+
+	b, err := r.fetch(ctx, boardID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st.loaders--; st.loaders == 0 {
+		delete(r.loading, boardID)
+	}
+	if err != nil {
+		return model.ChecklistProgress{}, nil, err
+	}
+	if _, ok := r.boards[boardID]; !ok && st.events == seen {
+		r.boards[boardID] = b
+		for id := range b.cards {
+			r.cardBoards[id] = boardID
+		}
+	}
+	return b.total, maps.Clone(b.lists), nil
+}
+
+// fetch 从数据库读出看板所有卡片的清单进度
+func (r *ProgressRollups) fetch(ctx context.Context, boardID string) (*boardRollup, error) {
+	lists, err := r.lists.ListByBoard(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	b := &boardRollup{cards: make(map[string]*cardRollup), lists: make(map[string]model.ChecklistProgress)}
+	var ids []string
+	for _, l := range lists {
+		cards, err := r.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cards {
+			b.cards[c.ID] = &cardRollup{listID: l.ID}
+			ids = append(ids, c.ID)
+		}
+	}
+	progress, err := r.checklists.ProgressByChecklists(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, c := range b.cards {
+		c.checklists = progress[id]
+		c.progress = sumProgress(c.checklists)
+		b.add(c)
+	}
+	return b, nil
+}
+
+// Handle 按事件增量更新缓存，用 bus.Listen 注册
+// 在 Publish 里同步执行，只改内存，不查数据库
+func (r *ProgressRollups) Handle(e events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touch(e.BoardID)
+
+	if e.Type == events.BoardDeleted {
+		r.drop(e.BoardID)
+		return
+	}
+	b, ok := r.boards[e.BoardID]
+	if !ok {
+		return
+	}
+	switch e.Type {
+	case events.ChecklistCreated, events.ChecklistUpdated:
+		if c, ok := e.Data.(model.Checklist); !ok || !r.setChecklist(b, c.CardID, c.ID, c.Progress) {
+			r.drop(e.BoardID)
+		}
+	case events.ChecklistDeleted:
+		if ref, ok := e.Data.(IDRef); !ok || !r.setChecklist(b, ref.CardID, ref.ID, model.ChecklistProgress{}) {
+			r.drop(e.BoardID)
+		}
+	case events.CardCreated:
+		// 新卡片上已经有清单时不知道每个清单的进度，重新加载
+		c, ok := e.Data.(model.Card)
+		if !ok || c.Checklists.Total > 0 {
+			r.drop(e.BoardID)
+			return
+		}
+		r.addCard(e.BoardID, b, c.ID, &cardRollup{listID: c.ListID})
+	case events.CardMoved:
+		d, ok := e.Data.(CardMoved)
+		if !ok {
+			r.drop(e.BoardID)
+			return
+		}
+		r.moveCard(e.BoardID, b, d)
+	case events.CardDeleted:
+		if ref, ok := e.Data.(IDRef); ok {
+			r.removeCard(b, ref.ID)
+		}
+	case events.ListDeleted:
+		// 列表里的卡片一起删除了，不会再有 card.deleted 事件
+		if ref, ok := e.Data.(IDRef); ok {
+			for id, c := range b.cards {
+				if c.listID == ref.ID {
+					r.removeCard(b, id)
+				}
+			}
+		}
+	}
+}
+
+// moveCard 处理 card.moved 事件
+// 跨看板移动时原看板先收到一个事件：把卡片从原看板移到目标看板（目标看板有缓存时）；
+// 目标看板随后收到的事件和同一看板内移动一样，只改卡片所在的列表
+func (r *ProgressRollups) moveCard(boardID string, b *boardRollup, d CardMoved) {
+	if boardID == d.FromBoardID && d.FromBoardID != d.ToBoardID {
+		c := r.removeCard(b, d.CardID)
+		r.touch(d.ToBoardID)
+		if to, ok := r.boards[d.ToBoardID]; ok && c != nil {
+			c.listID = d.ToListID
+			r.addCard(d.ToBoardID, to, d.CardID, c)
+		}
+		return
+	}
+	c := r.removeCard(b, d.CardID)
+	if c == nil {
+		// 从没有缓存的看板移过来的卡片
+		r.drop(boardID)
+		return
+	}
+	c.listID = d.ToListID
+	r.addCard(boardID, b, d.CardID, c)
+}
+
+// touch 记下看板收到了事件，正在加载的结果不再放进缓存
+func (r *ProgressRollups) touch(boardID string) {
+	if st, ok := r.loading[boardID]; ok {
+		st.events++
+	}
+}
+
+// drop 把看板从缓存里去掉
+func (r *ProgressRollups) drop(boardID string) {
+	b, ok := r.boards[boardID]
+	if !ok {
+		return
+	}
+	for id := range b.cards {
+		delete(r.cardBoards, id)
+	}
+	delete(r.boards, boardID)
+}
+
+func (r *ProgressRollups) addCard(boardID string, b *boardRollup, id string, c *cardRollup) {
+	c.progress = sumProgress(c.checklists)
+	b.cards[id] = c
+	b.add(c)
+	r.cardBoards[id] = boardID
+}
+
+func (r *ProgressRollups) removeCard(b *boardRollup, id string) *cardRollup {
+	c, ok := b.cards[id]
+	if !ok {
+		return nil
+	}
+	b.sub(c)
+	delete(b.cards, id)
+	delete(r.cardBoards, id)
+	return c
+}
+
+// setChecklist 替换卡片上一个清单的进度，卡片不在缓存里时返回 false
+func (r *ProgressRollups) setChecklist(b *boardRollup, cardID, checklistID string, p model.ChecklistProgress) bool {
+	c, ok := b.cards[cardID]
+	if !ok {
+		return false
+	}
+	b.sub(c)
+	if c.checklists == nil {
+		c.checklists = make(map[string]model.ChecklistProgress)
+	}
+	if p.Total == 0 {
+		delete(c.checklists, checklistID)
+	} else {
+		c.checklists[checklistID] = p
+	}
+	c.progress = sumProgress(c.checklists)
+	b.add(c)
+	return true
+}
+
+// add 把卡片的进度加到列表和看板上
+func (b *boardRollup) add(c *cardRollup) {
+	b.lists[c.listID] = b.lists[c.listID].Add(c.progress)
+	b.total = b.total.Add(c.progress)
+}
+
+// sub 把卡片的进度从列表和看板上减掉
+func (b *boardRollup) sub(c *cardRollup) {
+	neg := model.ChecklistProgress{Done: -c.progress.Done, Total: -c.progress.Total}
+	b.lists[c.listID] = b.lists[c.listID].Add(neg)
+	if b.lists[c.listID].Total == 0 {
+		delete(b.lists, c.listID)
+	}
+	b.total = b.total.Add(neg)
+}
+
+// sumProgress 合并卡片上所有清单的进度
+func sumProgress(checklists map[string]model.ChecklistProgress) model.ChecklistProgress {
+	var p model.ChecklistProgress
+	for _, c := range checklists {
+		p = p.Add(c)
+	}
+	return p
+}
+
+// Checklists 包装检查清单仓储：ProgressByCards 先读缓存，不在缓存里的卡片再查询
+// 交给卡片服务使用，返回卡片时填的进度（model.Card.Checklists）和列表、看板的进度来自同一份缓存
+func (r *ProgressRollups) Checklists(next repository.ChecklistRepository) repository.ChecklistRepository {
+	return &rollupChecklistRepo{ChecklistRepository: next, r: r}
+}
+
+type rollupChecklistRepo struct {
+	repository.ChecklistRepository
+	r *ProgressRollups
+}
+
+func (p *rollupChecklistRepo) ProgressByCards(ctx context.Context, cardIDs []string) (map[string]model.ChecklistProgress, error) {
+	out := make(map[string]model.ChecklistProgress)
+	var missing []string
+	p.r.mu.Lock()
+	for _, id := range cardIDs {
+		boardID, ok := p.r.cardBoards[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if c := p.r.boards[boardID].cards[id]; c.progress.Total > 0 {
+			out[id] = c.progress
+		}
+	}
+	p.r.mu.Unlock()
+	if len(missing) == 0 {
+		return out, nil
+	}
+	rest, err := p.ChecklistRepository.ProgressByCards(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(out, rest)
+	return out, nil
+}
+
+// ========== 填上进度的装饰器 ==========
+// 放在事件发布装饰器外面：修改后的事件已经更新过缓存，返回的就是修改后的进度
+
+type progressBoardService struct {
+	BoardService
+	r *ProgressRollups
+}
+
+// ProgressBoardService 获取单个看板时填上看板的完成进度（model.Board.Progress）
+func ProgressBoardService(next BoardService, r *ProgressRollups) BoardService {
+	return &progressBoardService{BoardService: next, r: r}
+}
+
+func (s *progressBoardService) GetBoard(ctx context.Context, userID, id string) (model.Board, error) {
+	b, err := s.BoardService.GetBoard(ctx, userID, id)
+	return s.withProgress(ctx, b, err)
+}
+
+func (s *progressBoardService) GetBoardBySlug(ctx context.Context, userID, slug string) (model.Board, error) {
+	b, err := s.BoardService.GetBoardBySlug(ctx, userID, slug)
+	return s.withProgress(ctx, b, err)
+}
+
+func (s *progressBoardService) withProgress(ctx context.Context, b model.Board, err error) (model.Board, error) {
+	if err != nil {
+		return b, err
+	}
+	total, _, err := s.r.Board(ctx, b.ID)
+	if err != nil {
+		return model.Board{}, err
+	}
+	b.Progress = &total
+	return b, nil
+}
+
+type progressListService struct {
+	ListService
+	r *ProgressRollups
+}
+
+// ProgressListService 返回列表时填上列表的完成进度（model.List.Progress）
+func ProgressListService(next ListService, r *ProgressRollups) ListService {
+	return &progressListService{ListService: next, r: r}
+}
+
+func (s *progressListService) ListLists(ctx context.Context, userID, boardID string) ([]model.List, error) {
+	lists, err := s.ListService.ListLists(ctx, userID, boardID)
+	return s.withProgress(ctx, boardID, lists, err)
+}
+
+func (s *progressListService) CreateList(ctx context.Context, userID, boardID, title string) (model.List, error) {
+	l, err := s.ListService.CreateList(ctx, userID, boardID, title)
+	return s.oneWithProgress(ctx, boardID, l, err)
+}
+
+func (s *progressListService) RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error) {
+	l, err := s.ListService.RenameList(ctx, userID, boardID, listID, title)
+	return s.oneWithProgress(ctx, boardID, l, err)
+}
+
+func (s *progressListService) MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error) {
+	lists, err := s.ListService.MoveList(ctx, userID, boardID, listID, position)
+	return s.withProgress(ctx, boardID, lists, err)
+}
+
+func (s *progressListService) withProgress(ctx context.Context, boardID string, lists []model.List, err error) ([]model.List, error) {
+	if err != nil {
+		return lists, err
+	}
+	_, byList, err := s.r.Board(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		p := byList[lists[i].ID]
+		lists[i].Progress = &p
+	}
+	return lists, nil
+}
+
+func (s *progressListService) oneWithProgress(ctx context.Context, boardID string, l model.List, err error) (model.List, error) {
+	if err != nil {
+		return l, err
+	}
+	lists, err := s.withProgress(ctx, boardID, []model.List{l}, nil)
+	if err != nil {
+		return model.List{}, err
+	}
+	return lists[0], nil
+}
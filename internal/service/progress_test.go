@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+)
+
+// TestProgressRollups 清单、卡片的修改通过事件增量更新缓存，每一步之后都和从数据库重新加载的结果一致
+func TestProgressRollups(t *testing.T) {
+	ctx := context.Background()
+	boards, lists, cards := repository.NewMemBoardRepo(), repository.NewMemListRepo(), repository.NewMemCardRepo()
+	checklists, members, attachments := repository.NewMemChecklistRepo(), repository.NewMemMemberRepo(), repository.NewMemAttachmentRepo()
+	storage := NewStorageCounters(repository.NewMemStorageRepo(), attachments, lists, cards, 0)
+
+	bus := events.NewBus()
+	rollups := NewProgressRollups(lists, cards, checklists)
+	bus.Listen(rollups.Handle)
+	listSvc := ProgressListService(PublishListService(NewListService(lists, boards, cards, checklists, attachments, storage, members), bus), rollups)
+	cardSvc := PublishCardService(NewCardService(cards, lists, rollups.Checklists(checklists), attachments, storage, repository.NewMemCardTemplateRepo(), boards, members), bus)
+	checklistSvc := PublishChecklistService(NewChecklistService(checklists, cards, lists, boards, members), bus)
+
+	a, _ := boards.Create(ctx, "u1", "A", "a")
+	b, _ := boards.Create(ctx, "u1", "B", "b")
+	todo, _ := lists.Create(ctx, a.ID, "Todo")
+	doing, _ := lists.Create(ctx, a.ID, "Doing")
+	other, _ := lists.Create(ctx, b.ID, "Other")
+
+	// 缓存之前就有的数据：一张卡片，两个条目完成了一个
+	c1, _ := cards.Create(ctx, model.Card{BoardID: a.ID, ListID: todo.ID, Title: "one"})
+	cl1, _ := checklists.Create(ctx, c1.ID, "steps")
+	first, _ := checklists.AddItem(ctx, cl1.ID, "first")
+	second, _ := checklists.AddItem(ctx, cl1.ID, "second")
+	first.Done = true
+	if _, err := checklists.UpdateItem(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// check 比较缓存和重新加载的进度，返回缓存里各列表的进度
+	check := func(step, boardID string) map[string]model.ChecklistProgress {
+		t.Helper()
+		total, byList, err := rollups.Board(ctx, boardID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantTotal, wantLists, err := NewProgressRollups(lists, cards, checklists).Board(ctx, boardID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != wantTotal {
+			t.Fatalf("%s: board progress = %+v, want %+v", step, total, wantTotal)
+		}
+		for _, l := range []string{todo.ID, doing.ID, other.ID} {
+			if byList[l] != wantLists[l] {
+				t.Fatalf("%s: list %s progress = %+v, want %+v", step, l, byList[l], wantLists[l])
+			}
+		}
+		return byList
+	}
+
+	got, err := listSvc.ListLists(ctx, "u1", a.ID)
+	if err != nil || len(got) != 2 || got[0].Progress == nil || *got[0].Progress != model.NewChecklistProgress(1, 2) {
+		t.Fatalf("lists = %+v, %v, want todo at 1/2", got, err)
+	}
+	if _, _, err := rollups.Board(ctx, b.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := cardSvc.CreateCard(ctx, "u1", a.ID, doing.ID, CardInput{Title: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl2, err := checklistSvc.CreateChecklist(ctx, "u1", a.ID, doing.ID, c2.ID, "more")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"x", "y", "z"} {
+		if cl2, err = checklistSvc.AddItem(ctx, "u1", a.ID, doing.ID, c2.ID, cl2.ID, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := true
+	if _, err := checklistSvc.UpdateItem(ctx, "u1", a.ID, doing.ID, c2.ID, cl2.ID, cl2.Items[0].ID, ChecklistItemPatch{Done: &done}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checklistSvc.UpdateItem(ctx, "u1", a.ID, todo.ID, c1.ID, cl1.ID, second.ID, ChecklistItemPatch{Done: &done}); err != nil {
+		t.Fatal(err)
+	}
+	if p := check("after checklist changes", a.ID); p[todo.ID] != model.NewChecklistProgress(2, 2) || p[doing.ID] != model.NewChecklistProgress(1, 3) {
+		t.Fatalf("after checklist changes: %+v", p)
+	}
+	card, err := cardSvc.GetCard(ctx, "u1", a.ID, doing.ID, c2.ID)
+	if err != nil || card.Checklists != model.NewChecklistProgress(1, 3) {
+		t.Fatalf("card = %+v, %v, want 1/3", card.Checklists, err)
+	}
+
+	if _, err := cardSvc.MoveCard(ctx, "u1", a.ID, todo.ID, c1.ID, "", doing.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	check("after move within board", a.ID)
+	if _, err := cardSvc.MoveCard(ctx, "u1", a.ID, doing.ID, c2.ID, b.ID, other.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if p := check("after move to other board", b.ID); p[other.ID] != model.NewChecklistProgress(1, 3) {
+		t.Fatalf("board b after move: %+v", p)
+	}
+	if p := check("after move to other board", a.ID); p[doing.ID] != model.NewChecklistProgress(2, 2) {
+		t.Fatalf("board a after move: %+v", p)
+	}
+
+	if err := checklistSvc.DeleteChecklist(ctx, "u1", a.ID, doing.ID, c1.ID, cl1.ID); err != nil {
+		t.Fatal(err)
+	}
+	check("after checklist delete", a.ID)
+	if err := listSvc.DeleteList(ctx, "u1", b.ID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	check("after list delete", b.ID)
+
+	// 上面的修改都是增量更新的，缓存没有被丢掉重新加载过
+	if _, ok := rollups.boards[a.ID]; !ok {
+		t.Fatal("board a was dropped from the cache")
+	}
+	if _, ok := rollups.boards[b.ID]; !ok {
+		t.Fatal("board b was dropped from the cache")
+	}
+}
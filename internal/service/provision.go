@@ -0,0 +1,432 @@
+// Package service 声明式看板配置
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"slices"
+	"strings"
+)
+
+// 一次配置最多包含的看板、每个看板最多的列表和成员数
+const (
+	maxProvisionBoards  = 20
+	maxProvisionLists   = 100
+	maxProvisionMembers = 100
+)
+
+// 配置变更的动作和对象类型
+const (
+	ProvisionCreate = "create"
+	ProvisionUpdate = "update"
+	ProvisionMove   = "move"
+	ProvisionDelete = "delete"
+
+	ProvisionKindBoard  = "board"
+	ProvisionKindList   = "list"
+	ProvisionKindMember = "member"
+)
+
+// ProvisionSpec 期望的看板状态，请求体可以是 JSON 也可以是 YAML：
+//
+//	boards:
+//	  - slug: roadmap
+//	    title: 产品路线图
+//	    lists: [待办, 进行中, 已完成]
+//	    members:
+//	      - {email: bob@example.com, role: editor}
+type ProvisionSpec struct {
+	Boards []BoardSpec `json:"boards"`
+}
+
+// BoardSpec 一个看板的期望状态
+// 看板按 slug 对应到当前用户自己的看板，没有就创建；不在配置里的看板不会被修改或删除
+type BoardSpec struct {
+	// Slug 看板的标识，必填
+	Slug string `json:"slug"`
+
+	// Title 看板标题，必填
+	Title string `json:"title"`
+
+	// Lists 列表标题，按期望的顺序排列，同一看板内不能重复
+	// 不写这个字段表示不管理列表；写成空数组并且 prune 时删除所有列表
+	Lists []string `json:"lists"`
+
+	// Members 看板成员（不包括所有者），按邮箱对应，不写表示不管理成员
+	Members []MemberSpec `json:"members"`
+}
+
+// MemberSpec 一个成员的期望状态
+type MemberSpec struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// ProvisionOptions 配置的执行方式
+type ProvisionOptions struct {
+	// Apply 为 false 时只计算变更（plan），不修改任何数据
+	Apply bool
+
+	// Prune 为 true 时删除配置里没有的列表、移除配置里没有的成员
+	// 默认不删：删除列表会连带删除其中的卡片，必须明确要求
+	Prune bool
+}
+
+// ProvisionChange 一项变更
+type ProvisionChange struct {
+	// Action 动作：create / update / move / delete
+	Action string `json:"action"`
+
+	// Kind 对象类型：board / list / member
+	Kind string `json:"kind"`
+
+	// Board 所在看板的 slug
+	Board string `json:"board"`
+
+	// Name 对象的名字：看板标题、列表标题或者成员邮箱
+	Name string `json:"name"`
+
+	// Detail 变更的说明，例如 "position 2 -> 0"、"viewer -> editor"
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProvisionResult 配置的结果
+type ProvisionResult struct {
+	// Applied 变更是否已经执行；plan 模式下为 false
+	Applied bool `json:"applied"`
+
+	// Changes 需要（或者已经）执行的变更，按执行顺序排列；实际状态和配置一致时为空数组
+	Changes []ProvisionChange `json:"changes"`
+}
+
+// ProvisionService 声明式看板配置服务接口
+// 客户端描述期望的看板、列表和成员，服务计算出和实际状态的差异，apply 时逐项执行
+// 同一份配置执行第二次不会再有变更
+type ProvisionService interface {
+	// Provision 按 spec 计算变更，opts.Apply 为 true 时执行
+	// 执行通过普通的看板、列表、成员服务完成，权限检查、活动记录和事件推送都和手动操作一样；
+	// 中途失败时已经执行的变更不会回滚，修正后重新执行同一份配置即可
+	Provision(ctx context.Context, userID string, spec ProvisionSpec, opts ProvisionOptions) (ProvisionResult, error)
+}
+
+// provisionService 声明式看板配置服务的具体实现
+type provisionService struct {
+	boards  BoardService
+	lists   ListService
+	members MemberService
+	users   repository.UserRepository
+}
+
+// NewProvisionService 创建声明式看板配置服务实例
+// 传入的服务应该是包装过活动记录、事件推送的版本，配置产生的修改和手动修改一样可以被追踪
+func NewProvisionService(boards BoardService, lists ListService, members MemberService, users repository.UserRepository) ProvisionService {
+	return &provisionService{boards: boards, lists: lists, members: members, users: users}
+}
+
+// Provision 逐个看板计算并执行变更
+// 先检查整份配置，有问题时一项也不执行
+func (s *provisionService) Provision(ctx context.Context, userID string, spec ProvisionSpec, opts ProvisionOptions) (ProvisionResult, error) {
+	spec, err := s.clean(ctx, userID, spec)
+	if err != nil {
+		return ProvisionResult{}, err
+	}
+
+	p := provisioner{s: s, userID: userID, opts: opts, changes: make([]ProvisionChange, 0)}
+	for _, bs := range spec.Boards {
+		if err := p.board(ctx, bs); err != nil {
+			return ProvisionResult{}, err
+		}
+	}
+	return ProvisionResult{Applied: opts.Apply, Changes: p.changes}, nil
+}
+
+// clean 规范化并检查配置，错误信息带上出错的位置，例如 boards[0].lists[2]
+// 成员的邮箱必须是已经注册的用户，这一步就查出来，避免执行到一半才失败
+func (s *provisionService) clean(ctx context.Context, userID string, spec ProvisionSpec) (ProvisionSpec, error) {
+	if len(spec.Boards) == 0 {
+		return spec, invalidInput("boards required")
+	}
+	if len(spec.Boards) > maxProvisionBoards {
+		return spec, invalidInput(fmt.Sprintf("at most %d boards", maxProvisionBoards))
+	}
+
+	slugs := make(map[string]bool)
+	for i := range spec.Boards {
+		b := &spec.Boards[i]
+		at := fmt.Sprintf("boards[%d]", i)
+
+		b.Slug = slugify(b.Slug)
+		if b.Slug == "" {
+			return spec, invalidInput(at + ".slug required")
+		}
+		if slugs[b.Slug] {
+			return spec, invalidInput(at + ".slug duplicated")
+		}
+		slugs[b.Slug] = true
+
+		b.Title = strings.TrimSpace(b.Title)
+		if b.Title == "" {
+			return spec, invalidInput(at + ".title required")
+		}
+
+		if len(b.Lists) > maxProvisionLists {
+			return spec, invalidInput(fmt.Sprintf("%s.lists: at most %d lists", at, maxProvisionLists))
+		}
+		titles := make(map[string]bool)
+		for j := range b.Lists {
+			b.Lists[j] = strings.TrimSpace(b.Lists[j])
+			if b.Lists[j] == "" {
+				return spec, invalidInput(fmt.Sprintf("%s.lists[%d] required", at, j))
+			}
+			// 列表按标题对应，标题重复就分不清是哪个
+			if titles[b.Lists[j]] {
+				return spec, invalidInput(fmt.Sprintf("%s.lists[%d] duplicated", at, j))
+			}
+			titles[b.Lists[j]] = true
+		}
+
+		if len(b.Members) > maxProvisionMembers {
+			return spec, invalidInput(fmt.Sprintf("%s.members: at most %d members", at, maxProvisionMembers))
+		}
+		emails := make(map[string]bool)
+		for j := range b.Members {
+			m := &b.Members[j]
+			mat := fmt.Sprintf("%s.members[%d]", at, j)
+			m.Email = strings.TrimSpace(strings.ToLower(m.Email))
+			if m.Email == "" {
+				return spec, invalidInput(mat + ".email required")
+			}
+			if emails[m.Email] {
+				return spec, invalidInput(mat + ".email duplicated")
+			}
+			emails[m.Email] = true
+			if m.Role != model.RoleEditor && m.Role != model.RoleViewer {
+				return spec, invalidInput(mat + ".role must be editor or viewer")
+			}
+
+			u, err := s.users.GetByEmail(ctx, m.Email)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return spec, invalidInput(mat + ".email: user not registered")
+				}
+				return spec, err
+			}
+			if u.ID == userID {
+				return spec, invalidInput(mat + ".email: owner is already a member")
+			}
+		}
+	}
+	return spec, nil
+}
+
+// provisioner 一次配置的执行状态
+// plan 和 apply 走同一段代码，只是 apply 时才真正调用服务，保证计划和执行的结果一致
+type provisioner struct {
+	s       *provisionService
+	userID  string
+	opts    ProvisionOptions
+	changes []ProvisionChange
+}
+
+// record 记下一项变更
+func (p *provisioner) record(action, kind, board, name, detail string) {
+	p.changes = append(p.changes, ProvisionChange{Action: action, Kind: kind, Board: board, Name: name, Detail: detail})
+}
+
+// board 对应一个看板：不存在就创建，标题不同就修改，然后处理列表和成员
+// plan 模式下要创建的看板没有 ID，它的列表和成员全部是新建
+func (p *provisioner) board(ctx context.Context, bs BoardSpec) error {
+	b, err := p.s.boards.GetBoardBySlug(ctx, p.userID, bs.Slug)
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	switch {
+	case !exists:
+		p.record(ProvisionCreate, ProvisionKindBoard, bs.Slug, bs.Title, "")
+		if p.opts.Apply {
+			if b, err = p.create(ctx, bs); err != nil {
+				return err
+			}
+		}
+	case b.Title != bs.Title:
+		p.record(ProvisionUpdate, ProvisionKindBoard, bs.Slug, bs.Title, "title "+b.Title+" -> "+bs.Title)
+		if p.opts.Apply {
+			if b, err = p.s.boards.UpdateBoard(ctx, p.userID, b.ID, bs.Title, "", b.Version); err != nil {
+				return err
+			}
+		}
+	}
+
+	if bs.Lists != nil {
+		if err := p.boardLists(ctx, b.ID, bs); err != nil {
+			return err
+		}
+	}
+	if bs.Members != nil {
+		if err := p.boardMembers(ctx, b.ID, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create 创建看板并设置配置里的 slug
+// CreateBoard 按标题生成 slug，所以要再改一次；slug 被占用时删掉刚创建的空看板，不留下半成品
+func (p *provisioner) create(ctx context.Context, bs BoardSpec) (model.Board, error) {
+	b, err := p.s.boards.CreateBoard(ctx, p.userID, BoardInput{Title: bs.Title})
+	if err != nil {
+		return model.Board{}, err
+	}
+	if b.Slug == bs.Slug {
+		return b, nil
+	}
+	updated, err := p.s.boards.UpdateBoard(ctx, p.userID, b.ID, bs.Title, bs.Slug, b.Version)
+	if err != nil {
+		_, _ = p.s.boards.DeleteBoard(ctx, p.userID, b.ID, "")
+		return model.Board{}, err
+	}
+	return updated, nil
+}
+
+// provisionedList 计算列表变更时的一个列表，plan 模式下新建的列表没有 ID
+type provisionedList struct {
+	id    string
+	title string
+}
+
+// boardLists 让看板的列表和配置一致
+// 顺序：prune 时先删除多余的列表，再在末尾创建缺少的列表，最后把配置里的列表依次移动到 0、1、2……
+// 不删除的多余列表（没有 prune）留在配置里的列表后面
+// 看板里有同名列表时，第一个对应配置，其余的算多余的列表
+func (p *provisioner) boardLists(ctx context.Context, boardID string, bs BoardSpec) error {
+	var current []model.List
+	if boardID != "" {
+		var err error
+		if current, err = p.s.lists.ListLists(ctx, p.userID, boardID); err != nil {
+			return err
+		}
+	}
+
+	wanted := make(map[string]bool, len(bs.Lists))
+	for _, t := range bs.Lists {
+		wanted[t] = true
+	}
+
+	// 现有列表里对应配置的和多余的
+	matched := make(map[string]bool)
+	sim := make([]provisionedList, 0, len(current)+len(bs.Lists))
+	for _, l := range current {
+		if wanted[l.Title] && !matched[l.Title] {
+			matched[l.Title] = true
+			sim = append(sim, provisionedList{id: l.ID, title: l.Title})
+			continue
+		}
+		if !p.opts.Prune {
+			sim = append(sim, provisionedList{id: l.ID, title: l.Title})
+			continue
+		}
+		p.record(ProvisionDelete, ProvisionKindList, bs.Slug, l.Title, "not in spec")
+		if p.opts.Apply {
+			if err := p.s.lists.DeleteList(ctx, p.userID, boardID, l.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, t := range bs.Lists {
+		if matched[t] {
+			continue
+		}
+		p.record(ProvisionCreate, ProvisionKindList, bs.Slug, t, "")
+		nl := provisionedList{title: t}
+		if p.opts.Apply {
+			l, err := p.s.lists.CreateList(ctx, p.userID, boardID, t)
+			if err != nil {
+				return err
+			}
+			nl.id = l.ID
+		}
+		sim = append(sim, nl)
+		matched[t] = true
+	}
+
+	// 按配置的顺序逐个移动，sim 同步模拟移动后的顺序
+	// 配置里的列表在 sim 中都是第一个同名的（多余的同名列表排在后面或者已被删除）
+	for i, t := range bs.Lists {
+		from := slices.IndexFunc(sim, func(l provisionedList) bool { return l.title == t })
+		if from == i {
+			continue
+		}
+		p.record(ProvisionMove, ProvisionKindList, bs.Slug, t, fmt.Sprintf("position %d -> %d", from, i))
+		if p.opts.Apply {
+			if _, err := p.s.lists.MoveList(ctx, p.userID, boardID, sim[from].id, i); err != nil {
+				return err
+			}
+		}
+		l := sim[from]
+		sim = slices.Insert(slices.Delete(sim, from, from+1), i, l)
+	}
+	return nil
+}
+
+// boardMembers 让看板的成员和配置一致：添加缺少的成员，修改角色不同的成员，prune 时移除多余的成员
+func (p *provisioner) boardMembers(ctx context.Context, boardID string, bs BoardSpec) error {
+	var current []model.BoardMember
+	if boardID != "" {
+		var err error
+		if current, err = p.s.members.ListMembers(ctx, p.userID, boardID); err != nil {
+			return err
+		}
+	}
+
+	// ListMembers 的邮箱已经是标准化过的，和配置里的直接比较
+	existing := make(map[string]model.BoardMember, len(current))
+	for _, m := range current {
+		if m.Role != model.RoleOwner {
+			existing[m.Email] = m
+		}
+	}
+
+	for _, ms := range bs.Members {
+		m, ok := existing[ms.Email]
+		switch {
+		case !ok:
+			p.record(ProvisionCreate, ProvisionKindMember, bs.Slug, ms.Email, ms.Role)
+		case m.Role != ms.Role:
+			p.record(ProvisionUpdate, ProvisionKindMember, bs.Slug, ms.Email, m.Role+" -> "+ms.Role)
+		default:
+			continue
+		}
+		if p.opts.Apply {
+			// AddMember 对已有成员就是修改角色
+			if _, err := p.s.members.AddMember(ctx, p.userID, boardID, ms.Email, ms.Role); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !p.opts.Prune {
+		return nil
+	}
+	wanted := make(map[string]bool, len(bs.Members))
+	for _, ms := range bs.Members {
+		wanted[ms.Email] = true
+	}
+	for _, m := range current {
+		if m.Role == model.RoleOwner || wanted[m.Email] {
+			continue
+		}
+		p.record(ProvisionDelete, ProvisionKindMember, bs.Slug, m.Email, "not in spec")
+		if p.opts.Apply {
+			if err := p.s.members.RemoveMember(ctx, p.userID, boardID, m.UserID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kanban_api/internal/events"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/mail"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"time"
+)
+
+// Reminders 截止日期提醒
+// 定期找出打开了提醒（Card.Reminder）、截止日期在 lead 之内的卡片，每张卡片提醒一次：
+// - 打一条 info 日志
+// - 发布 card.due_soon 事件，实时推送和 webhook 都会收到
+// - 给看板的所有者和编辑发邮件（只读成员改不了卡片，不发）
+//
+// 提醒过的卡片记下 RemindedAt，之后不再提醒；修改截止日期会清空它，按新的日期重新提醒
+type Reminders interface {
+	// Check 检查一次，发出所有该发的提醒
+	Check(ctx context.Context) error
+
+	// Run 立即检查一次，之后每隔 interval 检查一次，直到 ctx 被取消
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// reminders 截止日期提醒的具体实现
+type reminders struct {
+	cards   repository.CardRepository
+	boards  repository.BoardRepository
+	members repository.MemberRepository
+	users   repository.UserRepository
+	bus     *events.Bus
+	sender  mail.Sender
+
+	// lead 提前多久提醒
+	lead time.Duration
+}
+
+// NewReminders 创建截止日期提醒实例
+func NewReminders(cards repository.CardRepository, boards repository.BoardRepository, members repository.MemberRepository, users repository.UserRepository, bus *events.Bus, sender mail.Sender, lead time.Duration) Reminders {
+	return &reminders{cards: cards, boards: boards, members: members, users: users, bus: bus, sender: sender, lead: lead}
+}
+
+// Check 检查并发出提醒
+// 先用 MarkReminded 占下卡片再发：多个实例同时检查时只有一个实例会发；
+// 代价是发送失败（例如邮件服务器不可用）不会重试，提醒最多发一次
+func (r *reminders) Check(ctx context.Context) error {
+	now := time.Now()
+	cards, err := r.cards.ListReminders(ctx, now.Add(r.lead))
+	if err != nil {
+		return err
+	}
+	for _, c := range cards {
+		ok, err := r.cards.MarkReminded(ctx, c.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue // 别的实例已经提醒过
+		}
+		c.RemindedAt = &now
+		r.remind(ctx, c, now)
+	}
+	return nil
+}
+
+// remind 发出一张卡片的提醒
+func (r *reminders) remind(ctx context.Context, c model.Card, now time.Time) {
+	overdue := c.DueDate.Before(now)
+	logger := logging.FromContext(ctx)
+	logger.Info("card due soon", "card_id", c.ID, "board_id", c.BoardID, "due_date", c.DueDate.UTC().Format(time.RFC3339), "overdue", overdue)
+
+	r.bus.Publish(events.Event{Type: events.CardDueSoon, BoardID: c.BoardID, Data: c})
+
+	boards, err := r.boards.ListByIDs(ctx, []string{c.BoardID})
+	if err != nil {
+		logger.Warn("load board for reminder", "board_id", c.BoardID, "err", err)
+		return
+	}
+	if len(boards) == 0 {
+		return // 看板刚被删除
+	}
+	b := boards[0]
+	if b.State == model.BoardStateSuspended {
+		return // 停用的看板谁都打不开，不发邮件
+	}
+
+	to, err := r.recipients(ctx, b)
+	if err != nil {
+		logger.Warn("load reminder recipients", "board_id", b.ID, "err", err)
+		return
+	}
+	msg := reminderMail(b, c, overdue)
+	for _, addr := range to {
+		msg.To = addr
+		if err := r.sender.Send(ctx, msg); err != nil {
+			logger.Warn("send reminder mail", "card_id", c.ID, "err", err)
+		}
+	}
+}
+
+// recipients 看板所有者和编辑的邮箱
+func (r *reminders) recipients(ctx context.Context, b model.Board) ([]string, error) {
+	userIDs := []string{b.OwnerID}
+	members, err := r.members.ListByBoard(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if m.Role == model.RoleEditor {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+
+	out := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		u, err := r.users.GetByID(ctx, id)
+		if err != nil {
+			// 用户已经注销之类，跳过这一个，不影响其他人
+			logging.FromContext(ctx).Warn("load reminder recipient", "user_id", id, "err", err)
+			continue
+		}
+		out = append(out, u.Email)
+	}
+	return out, nil
+}
+
+// reminderMail 提醒邮件，To 由调用方填写
+func reminderMail(b model.Board, c model.Card, overdue bool) mail.Message {
+	when := "is due soon"
+	if overdue {
+		when = "is overdue"
+	}
+	return mail.Message{
+		Subject: fmt.Sprintf("%q %s", c.Title, when),
+		Body: fmt.Sprintf("Card: %s\nBoard: %s\nDue: %s\n",
+			c.Title, b.Title, c.DueDate.UTC().Format("2006-01-02 15:04 UTC")),
+	}
+}
+
+// Run 定时检查
+// 单次失败（例如数据库暂时不可用）只打日志，下一轮继续
+func (r *reminders) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Check(ctx); err != nil {
+			logging.FromContext(ctx).Warn("check card reminders", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"net/url"
+	"strings"
+)
+
+// 解析结果的对象类型
+const (
+	LinkTypeBoard = "board"
+	LinkTypeCard  = "card"
+)
+
+// ResolvedLink 分享链接对应的对象
+type ResolvedLink struct {
+	// Type 对象类型：board 或 card
+	Type string `json:"type"`
+
+	// ID 对象的 ID；看板链接就是看板 ID
+	ID string `json:"id"`
+
+	// BoardID、ListID 对象所在的看板和列表，客户端拼接口地址用；看板链接没有 ListID
+	BoardID string `json:"boardId"`
+	ListID  string `json:"listId,omitempty"`
+
+	// Role 当前用户对看板的角色（owner / editor / viewer），客户端据此决定显示哪些操作
+	Role string `json:"role"`
+}
+
+// ResolveService 分享链接解析服务接口
+type ResolveService interface {
+	// Resolve 解析用户粘贴的看板或卡片链接，支持的格式见 parseLink
+	// 链接格式不认识时返回 ErrInvalidInput；对象不存在或者用户看不到时返回 ErrNotFound
+	Resolve(ctx context.Context, userID, rawURL string) (ResolvedLink, error)
+}
+
+// resolveService 分享链接解析服务的具体实现
+type resolveService struct {
+	boards repository.BoardRepository
+	lists  repository.ListRepository
+	cards  repository.CardRepository
+	access boardAccess
+}
+
+// NewResolveService 创建分享链接解析服务实例
+func NewResolveService(boards repository.BoardRepository, lists repository.ListRepository, cards repository.CardRepository, members repository.MemberRepository) ResolveService {
+	return &resolveService{boards: boards, lists: lists, cards: cards, access: boardAccess{boards: boards, members: members}}
+}
+
+// link 从链接路径里解析出来的各部分
+// 看板用 boardID 或 slug 其中一个指定；cardID 为空表示看板链接
+type link struct {
+	boardID string
+	slug    string
+	listID  string
+	cardID  string
+}
+
+// parseLink 解析链接，只看路径，不检查域名（同一个服务可能有多个域名，也可以只粘贴路径）
+// 路径前面的 /api/v1 可有可无，支持的格式：
+//
+//	/boards/:id
+//	/boards/by-slug/:slug 或 /b/:slug
+//	/boards/:id/lists/:listId/cards/:cardId
+//	/boards/:id/cards/:cardId、/b/:slug/cards/:cardId（不带列表，按卡片 ID 在看板里查找）
+func parseLink(raw string) (link, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return link{}, invalidInput("url required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return link{}, invalidInput("invalid url")
+	}
+
+	var parts []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) >= 2 && parts[0] == "api" && parts[1] == "v1" {
+		parts = parts[2:]
+	}
+
+	// 先取出看板部分，剩下的是卡片部分
+	var l link
+	switch {
+	case len(parts) >= 3 && parts[0] == "boards" && parts[1] == "by-slug":
+		l.slug, parts = parts[2], parts[3:]
+	case len(parts) >= 2 && parts[0] == "b":
+		l.slug, parts = parts[1], parts[2:]
+	case len(parts) >= 2 && parts[0] == "boards":
+		l.boardID, parts = parts[1], parts[2:]
+	default:
+		return link{}, invalidInput("unrecognized link")
+	}
+
+	switch {
+	case len(parts) == 0:
+	case len(parts) == 2 && parts[0] == "cards":
+		l.cardID = parts[1]
+	case len(parts) == 4 && parts[0] == "lists" && parts[2] == "cards":
+		l.listID, l.cardID = parts[1], parts[3]
+	default:
+		return link{}, invalidInput("unrecognized link")
+	}
+	return l, nil
+}
+
+// Resolve 解析链接
+// 看板按当前用户的权限检查：看不到的看板和不存在的看板一样返回 ErrNotFound，不暴露看板是否存在
+func (s *resolveService) Resolve(ctx context.Context, userID, rawURL string) (ResolvedLink, error) {
+	l, err := parseLink(rawURL)
+	if err != nil {
+		return ResolvedLink{}, err
+	}
+
+	boardID := l.boardID
+	if l.slug != "" {
+		b, err := s.boardBySlug(ctx, userID, l.slug)
+		if err != nil {
+			return ResolvedLink{}, err
+		}
+		boardID = b.ID
+	}
+
+	b, role, err := s.access.check(ctx, userID, boardID, model.RoleViewer)
+	if err != nil {
+		return ResolvedLink{}, err
+	}
+	if l.cardID == "" {
+		return ResolvedLink{Type: LinkTypeBoard, ID: b.ID, BoardID: b.ID, Role: role}, nil
+	}
+
+	c, err := findCard(ctx, s.lists, s.cards, b.ID, l.listID, l.cardID)
+	if err != nil {
+		return ResolvedLink{}, err
+	}
+	return ResolvedLink{Type: LinkTypeCard, ID: c.ID, BoardID: b.ID, ListID: c.ListID, Role: role}, nil
+}
+
+// boardBySlug 在用户能看到的看板里按 slug 查找
+// GetBoardBySlug 只查用户自己的看板，分享链接多半是别人的看板，所以这里把成员看板也算上
+func (s *resolveService) boardBySlug(ctx context.Context, userID, slug string) (model.Board, error) {
+	ids, err := s.access.visibleBoardIDs(ctx, userID)
+	if err != nil {
+		return model.Board{}, err
+	}
+	boards, err := s.boards.ListByIDs(ctx, ids)
+	if err != nil {
+		return model.Board{}, err
+	}
+	slug = strings.ToLower(slug)
+	for _, b := range boards {
+		if b.Slug == slug {
+			return b, nil
+		}
+	}
+	return model.Board{}, ErrNotFound
+}
+
+// findCard 在看板里查找卡片
+// 链接带列表 ID 时直接查；不带时逐个列表查找（卡片可能已经被移到别的列表，带列表的链接也会失效，
+// 所以带列表 ID 查不到时同样退回到逐个查找）
+// 嵌入页面的链接预览也用它（见 embed.go）
+func findCard(ctx context.Context, lists repository.ListRepository, cards repository.CardRepository, boardID, listID, cardID string) (model.Card, error) {
+	if listID != "" {
+		_, err := lists.Get(ctx, boardID, listID)
+		if err == nil {
+			var c model.Card
+			c, err = cards.Get(ctx, listID, cardID)
+			if err == nil {
+				return c, nil
+			}
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return model.Card{}, err
+		}
+	}
+
+	all, err := lists.ListByBoard(ctx, boardID)
+	if err != nil {
+		return model.Card{}, err
+	}
+	for _, l := range all {
+		items, err := cards.ListByList(ctx, l.ID)
+		if err != nil {
+			return model.Card{}, err
+		}
+		for _, c := range items {
+			if c.ID == cardID {
+				return c, nil
+			}
+		}
+	}
+	return model.Card{}, ErrNotFound
+}
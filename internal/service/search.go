@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 搜索参数的限制
+const (
+	// maxSearchQuery 查询字符串的最大长度（字符数）
+	maxSearchQuery = 100
+
+	// maxSearchTerms 最多使用的关键词个数，多出来的忽略
+	maxSearchTerms = 8
+
+	// defaultSearchLimit、maxSearchLimit 每次返回的结果条数
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// SearchService 全文搜索服务接口
+type SearchService interface {
+	// Search 在用户能看到的看板（自己的和作为成员加入的）中搜索看板标题、卡片标题和描述
+	// query 按空白拆成关键词，每个关键词都必须匹配；limit <= 0 时使用默认值
+	Search(ctx context.Context, userID, query string, limit int) ([]model.SearchResult, error)
+}
+
+// searchService 搜索服务的具体实现
+// 具体怎么查由 SearchRepository 决定（FTS5 索引或者逐条扫描），
+// 这里负责解析查询、限定看板范围和计算高亮位置，两种实现的返回格式完全一致
+type searchService struct {
+	index  repository.SearchRepository
+	access boardAccess
+}
+
+// NewSearchService 创建搜索服务实例
+func NewSearchService(index repository.SearchRepository, boards repository.BoardRepository, members repository.MemberRepository) SearchService {
+	return &searchService{
+		index:  index,
+		access: boardAccess{boards: boards, members: members},
+	}
+}
+
+// Search 搜索
+func (s *searchService) Search(ctx context.Context, userID, query string, limit int) ([]model.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, invalidInput("q required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchQuery {
+		return nil, invalidInput("q too long")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	terms := searchTerms(query)
+	boardIDs, err := s.access.visibleBoardIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(boardIDs) == 0 {
+		return []model.SearchResult{}, nil
+	}
+
+	results, err := s.index.Search(ctx, boardIDs, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		r := &results[i]
+		r.Highlights = append(highlights("title", r.Title, terms), highlights("description", r.Description, terms)...)
+	}
+	if results == nil {
+		// 没有结果时返回空数组而不是 null，客户端不用判空
+		results = []model.SearchResult{}
+	}
+	return results, nil
+}
+
+// searchTerms 按空白拆分关键词，忽略大小写去重
+func searchTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range strings.Fields(query) {
+		key := strings.ToLower(t)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, t)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// highlights 找出 text 中所有关键词出现的位置（不区分大小写），合并重叠的区间
+// 偏移量按字符计算：strings.ToLower 逐个字符转换，不会改变字符个数，
+// 所以在小写文本里找到的位置就是原文的位置
+func highlights(field, text string, terms []string) []model.SearchHighlight {
+	if text == "" {
+		return nil
+	}
+	lower := []rune(strings.ToLower(text))
+
+	var spans [][2]int
+	for _, t := range terms {
+		needle := []rune(strings.ToLower(t))
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) == string(needle) {
+				spans = append(spans, [2]int{i, i + len(needle)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	out := []model.SearchHighlight{{Field: field, Start: spans[0][0], End: spans[0][1]}}
+	for _, sp := range spans[1:] {
+		last := &out[len(out)-1]
+		if sp[0] <= last.End {
+			if sp[1] > last.End {
+				last.End = sp[1]
+			}
+			continue
+		}
+		out = append(out, model.SearchHighlight{Field: field, Start: sp[0], End: sp[1]})
+	}
+	return out
+}
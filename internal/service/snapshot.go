@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"time"
+)
+
+// 查询快照的日期范围（天）
+const (
+	defaultSnapshotDays = 90
+	maxSnapshotDays     = 3 * 366
+)
+
+// SnapshotService 看板每日快照的查询接口
+type SnapshotService interface {
+	// ListSnapshots 看板在 [from, to] 之间（按 UTC 日期，包括两端）的每日快照，按日期从早到晚
+	// 看板的所有成员（包括只读成员）都可以查看；to 为零值表示今天，from 为零值表示 to 之前 90 天
+	ListSnapshots(ctx context.Context, userID, boardID string, from, to time.Time) ([]model.BoardSnapshot, error)
+}
+
+// BoardSnapshots 每天给所有看板记一次快照（每个列表的卡片数、过期卡片数、成员数），
+// 趋势图查快照表就够了，不用扫看板动态
+//
+// 快照按 UTC 日期保存，同一天记录多次会覆盖，所以重启服务或者多个实例都记录也没关系；
+// 看板删除后它的快照在下一次记录时清掉
+type BoardSnapshots struct {
+	snapshots repository.SnapshotRepository
+	boards    repository.BoardRepository
+	lists     repository.ListRepository
+	cards     repository.CardRepository
+	members   repository.MemberRepository
+	access    boardAccess
+}
+
+// NewBoardSnapshots 创建每日快照实例
+func NewBoardSnapshots(snapshots repository.SnapshotRepository, boards repository.BoardRepository, lists repository.ListRepository, cards repository.CardRepository, members repository.MemberRepository) *BoardSnapshots {
+	return &BoardSnapshots{
+		snapshots: snapshots,
+		boards:    boards,
+		lists:     lists,
+		cards:     cards,
+		members:   members,
+		access:    boardAccess{boards: boards, members: members},
+	}
+}
+
+// ListSnapshots 查询看板的快照
+func (s *BoardSnapshots) ListSnapshots(ctx context.Context, userID, boardID string, from, to time.Time) ([]model.BoardSnapshot, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultSnapshotDays)
+	}
+	first, last := from.UTC().Format(model.DayLayout), to.UTC().Format(model.DayLayout)
+	if first > last {
+		return nil, invalidInput("from must not be after to")
+	}
+	if to.Sub(from) > maxSnapshotDays*24*time.Hour {
+		return nil, invalidInput(fmt.Sprintf("date range must not exceed %d days", maxSnapshotDays))
+	}
+
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.snapshots.ListByBoard(ctx, boardID, first, last)
+}
+
+// Take 给所有看板记一次快照，日期是 now 的 UTC 日期，返回记录了几个看板
+// 过期按 now 计算：截止日期早于 now 的卡片算过期
+func (s *BoardSnapshots) Take(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.boards.ListIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// 所有看板的卡片数一次查出来，按列表分好
+	counts, err := s.cards.CountByList(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	byList := make(map[string]model.ListCardCount, len(counts))
+	for _, c := range counts {
+		byList[c.ListID] = c
+	}
+
+	day := now.UTC().Format(model.DayLayout)
+	out := make([]model.BoardSnapshot, 0, len(ids))
+	exists := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		exists[id] = true
+		lists, err := s.lists.ListByBoard(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		members, err := s.members.ListByBoard(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		snap := model.BoardSnapshot{
+			BoardID: id,
+			Day:     day,
+			Members: len(members) + 1, // 成员表里没有所有者
+			Lists:   make([]model.ListSnapshot, 0, len(lists)),
+			TakenAt: now,
+		}
+		for _, l := range lists {
+			c := byList[l.ID]
+			snap.Cards += c.Cards
+			snap.Overdue += c.Overdue
+			snap.Lists = append(snap.Lists, model.ListSnapshot{ListID: l.ID, Title: l.Title, Cards: c.Cards, Overdue: c.Overdue})
+		}
+		out = append(out, snap)
+	}
+	if err := s.snapshots.Save(ctx, out); err != nil {
+		return 0, err
+	}
+
+	// 清掉已经删除的看板的快照
+	old, err := s.snapshots.BoardIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range old {
+		if exists[id] {
+			continue
+		}
+		if err := s.snapshots.DeleteByBoard(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(out), nil
+}
+
+// Run 立即记录一次（当天的快照已经有了也会覆盖成最新的），之后每天 UTC 的 at 时刻记录一次，直到 ctx 被取消
+// at 是从 UTC 零点算起的时间，例如 23h55m
+func (s *BoardSnapshots) Run(ctx context.Context, at time.Duration) {
+	for {
+		if n, err := s.Take(ctx, time.Now()); err != nil {
+			logging.FromContext(ctx).Warn("take board snapshots", "err", err)
+		} else {
+			logging.FromContext(ctx).Info("took board snapshots", "boards", n)
+		}
+		timer := time.NewTimer(time.Until(nextDaily(time.Now(), at)))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextDaily now 之后下一个 UTC 的 at 时刻
+func nextDaily(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestBoardSnapshots 每个列表的卡片数和过期数、成员数（包括所有者）记进当天的快照，
+// 同一天再记覆盖；按日期范围查询只有成员能看；删除的看板下次记录时清掉快照
+func TestBoardSnapshots(t *testing.T) {
+	ctx := context.Background()
+	boards := repository.NewMemBoardRepo()
+	lists := repository.NewMemListRepo()
+	cards := repository.NewMemCardRepo()
+	members := repository.NewMemMemberRepo()
+	repo := repository.NewMemSnapshotRepo()
+	s := NewBoardSnapshots(repo, boards, lists, cards, members)
+
+	b, err := boards.Create(ctx, "u1", "Board", "board")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo, _ := lists.Create(ctx, b.ID, "Todo")
+	done, _ := lists.Create(ctx, b.ID, "Done")
+	if _, err := members.Upsert(ctx, b.ID, "u2", model.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	day1 := time.Date(2026, 3, 1, 23, 55, 0, 0, time.UTC)
+	past := day1.Add(-time.Hour)
+	future := day1.Add(time.Hour)
+	for _, due := range []*time.Time{&past, &future, nil} {
+		if _, err := cards.Create(ctx, model.Card{BoardID: b.ID, ListID: todo.ID, Title: "c", DueDate: due}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := s.Take(ctx, day1); err != nil || n != 1 {
+		t.Fatalf("take = %d, %v, want 1", n, err)
+	}
+	got, err := s.ListSnapshots(ctx, "u2", b.ID, day1, day1)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("list = %+v, %v, want one snapshot", got, err)
+	}
+	snap := got[0]
+	if snap.Day != "2026-03-01" || snap.Cards != 3 || snap.Overdue != 1 || snap.Members != 2 || len(snap.Lists) != 2 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if l := snap.Lists[1]; l.ListID != done.ID || l.Cards != 0 {
+		t.Fatalf("empty list = %+v, want zero cards", l)
+	}
+
+	// 同一天再记一次覆盖，第二天另记一条
+	if _, err := cards.Create(ctx, model.Card{BoardID: b.ID, ListID: done.ID, Title: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Take(ctx, day1.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	day2 := day1.AddDate(0, 0, 1)
+	if _, err := s.Take(ctx, day2); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.ListSnapshots(ctx, "u1", b.ID, day1, day2)
+	if err != nil || len(got) != 2 || got[0].Cards != 4 || got[1].Day != "2026-03-02" || got[1].Overdue != 2 {
+		t.Fatalf("range = %+v, %v", got, err)
+	}
+
+	if _, err := s.ListSnapshots(ctx, "u3", b.ID, day1, day2); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("non-member err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.ListSnapshots(ctx, "u1", b.ID, day2, day1); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("reversed range err = %v, want ErrInvalidInput", err)
+	}
+
+	if err := boards.Delete(ctx, "u1", b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Take(ctx, day2); err != nil || n != 0 {
+		t.Fatalf("take after delete = %d, %v, want 0", n, err)
+	}
+	if ids, err := repo.BoardIDs(ctx); err != nil || len(ids) != 0 {
+		t.Fatalf("snapshots left for %v, %v", ids, err)
+	}
+}
+
+// TestNextDaily 今天的时刻还没到就是今天，已经过了（包括正好是这一刻）就是明天
+func TestNextDaily(t *testing.T) {
+	at := 23*time.Hour + 55*time.Minute
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if got := nextDaily(now, at); !got.Equal(time.Date(2026, 3, 1, 23, 55, 0, 0, time.UTC)) {
+		t.Fatalf("before: %v", got)
+	}
+	now = time.Date(2026, 3, 1, 23, 55, 0, 0, time.UTC)
+	if got := nextDaily(now, at); !got.Equal(time.Date(2026, 3, 2, 23, 55, 0, 0, time.UTC)) {
+		t.Fatalf("at: %v", got)
+	}
+}
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// healthProbeTimeout 每个组件探测的超时时间，和 /readyz 相同
+	healthProbeTimeout = 800 * time.Millisecond
+
+	// healthHistoryRetention 探测记录保存多久，够算最长的可用率窗口（30 天）就行
+	healthHistoryRetention = 30 * 24 * time.Hour
+
+	// statusCacheTTL 状态页结果缓存多久；状态页不需要登录，不能每个请求都去统计探测记录
+	statusCacheTTL = 15 * time.Second
+
+	// maxIncidentMessage 事故公告内容的最大长度（字符数）
+	maxIncidentMessage = 500
+
+	// queueBacklogRatio 队列里排队的数量超过容量的这个比例就算不正常：消费跟不上，再多就要丢事件了
+	queueBacklogRatio = 0.8
+
+	// sloWindow SLO 的滚动窗口，和最长的可用率窗口一样是 30 天
+	sloWindow     = 30 * 24 * time.Hour
+	sloWindowName = "30d"
+
+	// 探测历史默认和最多返回多少条
+	defaultHealthHistory = 100
+	maxHealthHistory     = 1000
+)
+
+// uptimeWindows 状态页上显示的可用率窗口
+var uptimeWindows = []struct {
+	name string
+	d    time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// HealthProbe 探测一个组件，返回组件的读数（例如队列深度，没有读数时为 nil）和错误，错误表示不正常
+type HealthProbe func(ctx context.Context) (*int64, error)
+
+// PingProbe 探测存储：Ping 成功就算正常，没有读数
+func PingProbe(p repository.Pinger) HealthProbe {
+	return func(ctx context.Context) (*int64, error) {
+		return nil, p.Ping(ctx)
+	}
+}
+
+// QueueProbe 探测队列：读数是排队的数量，超过容量的 queueBacklogRatio 算不正常
+// depth 返回当前排队的数量和队列的容量
+func QueueProbe(depth func() (int, int)) HealthProbe {
+	return func(ctx context.Context) (*int64, error) {
+		n, capacity := depth()
+		v := int64(n)
+		if float64(n) >= queueBacklogRatio*float64(capacity) {
+			return &v, fmt.Errorf("queue backlog: %d of %d", n, capacity)
+		}
+		return &v, nil
+	}
+}
+
+// IncidentInput 发布事故公告的参数
+type IncidentInput struct {
+	// Message 公告内容，必填
+	Message string
+
+	// Severity 严重程度，model.IncidentMinor / IncidentMajor / IncidentMaintenance，为空时是 minor
+	Severity string
+}
+
+// HealthHistoryQuery 查询探测记录的条件，零值表示不限制
+type HealthHistoryQuery struct {
+	// Component 只查这个组件
+	Component string
+
+	// From、To 探测时间在 [From, To) 之间
+	From, To time.Time
+
+	// FailedOnly 只查失败的探测
+	FailedOnly bool
+
+	// Limit 最多返回多少条，<= 0 时使用默认值，超过上限按上限算
+	Limit int
+}
+
+// StatusService 公开状态页接口
+type StatusService interface {
+	// Status 状态页的内容：各组件最近一次探测的结果、可用率、SLO 和正在显示的事故公告
+	// 不需要登录；结果缓存 statusCacheTTL，发布和解除公告时清掉
+	Status(ctx context.Context) (model.ServiceStatus, error)
+
+	// SetIncident 发布事故公告，替换正在显示的公告（管理员）
+	SetIncident(ctx context.Context, actorID string, in IncidentInput) (model.Incident, error)
+
+	// ResolveIncident 解除正在显示的公告并返回它，没有公告时返回 ErrNotFound（管理员）
+	ResolveIncident(ctx context.Context) (model.Incident, error)
+
+	// HealthHistory 按条件查询探测记录，从新到旧（管理员）
+	HealthHistory(ctx context.Context, q HealthHistoryQuery) ([]model.HealthCheck, error)
+}
+
+// HealthMonitor 定期探测各个组件（存储的 ping 耗时、队列深度、附件存储能否写入），把结果记下来，
+// 状态页按记录计算可用率和 SLO，/metrics 输出最近一轮的结果和 SLO
+//
+// 每个实例各自探测、各自记录；多个实例时记录会多几份，可用率按次数算，结果不受影响
+type HealthMonitor struct {
+	checks    repository.HealthCheckRepository
+	incidents repository.IncidentRepository
+	probes    map[string]HealthProbe
+
+	// interval 探测间隔；最近一轮探测超过 3 个间隔还没有新的，就当作探测停了，组件状态是 unknown
+	interval time.Duration
+
+	// sloTarget 可用率目标（百分比），所有组件相同
+	sloTarget float64
+
+	mu       sync.Mutex
+	cached   model.ServiceStatus
+	cachedAt time.Time
+
+	// gauges 最近一轮探测的结果和 SLO，Run 每轮更新，WritePrometheus 输出
+	gaugeMu sync.Mutex
+	latest  []model.HealthCheck
+	slos    map[string]model.SLO
+}
+
+// NewHealthMonitor 创建健康探测实例
+// probes 是要探测的组件，key 是状态页上显示的名字；interval 为 0 时不探测（Run 不要调用），状态页只显示公告
+// sloTarget 是可用率目标（百分比，例如 99.9）
+func NewHealthMonitor(checks repository.HealthCheckRepository, incidents repository.IncidentRepository, probes map[string]HealthProbe, interval time.Duration, sloTarget float64) *HealthMonitor {
+	return &HealthMonitor{checks: checks, incidents: incidents, probes: probes, interval: interval, sloTarget: sloTarget}
+}
+
+// components 组件名，按字母排序
+func (m *HealthMonitor) components() []string {
+	names := make([]string, 0, len(m.probes))
+	for name := range m.probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Probe 探测一轮并保存结果，同一轮的结果时间都是 now
+// 探测失败的原因只打日志，记录里只有成功与否、耗时和读数
+func (m *HealthMonitor) Probe(ctx context.Context, now time.Time) ([]model.HealthCheck, error) {
+	out := make([]model.HealthCheck, 0, len(m.probes))
+	for _, name := range m.components() {
+		pctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+		start := time.Now()
+		value, err := m.probes[name](pctx)
+		cancel()
+		if err != nil {
+			logging.FromContext(ctx).Warn("health probe failed", "component", name, "err", err)
+		}
+		out = append(out, model.HealthCheck{
+			Component: name,
+			OK:        err == nil,
+			LatencyMs: time.Since(start).Milliseconds(),
+			Value:     value,
+			CheckedAt: now,
+		})
+	}
+	return out, m.checks.Save(ctx, out)
+}
+
+// Run 每个 interval 探测一轮，更新 /metrics 上的 SLO，顺便清理 healthHistoryRetention 之前的记录，ctx 取消后返回
+func (m *HealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		checks, err := m.Probe(ctx, now)
+		if err != nil {
+			logging.FromContext(ctx).Warn("save health checks", "err", err)
+		}
+		if err := m.updateGauges(ctx, now, checks); err != nil {
+			logging.FromContext(ctx).Warn("compute slo", "err", err)
+		}
+		if _, err := m.checks.DeleteBefore(ctx, now.Add(-healthHistoryRetention)); err != nil {
+			logging.FromContext(ctx).Warn("purge health checks", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Status 状态页的内容，缓存没过期时直接返回缓存
+func (m *HealthMonitor) Status(ctx context.Context) (model.ServiceStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if !m.cachedAt.IsZero() && now.Sub(m.cachedAt) < statusCacheTTL {
+		return m.cached, nil
+	}
+	s, err := m.status(ctx, now)
+	if err != nil {
+		return model.ServiceStatus{}, err
+	}
+	m.cached, m.cachedAt = s, now
+	return s, nil
+}
+
+// status 查探测记录和公告，拼出状态页
+func (m *HealthMonitor) status(ctx context.Context, now time.Time) (model.ServiceStatus, error) {
+	latest, err := m.checks.Latest(ctx)
+	if err != nil {
+		return model.ServiceStatus{}, err
+	}
+	s := model.ServiceStatus{Status: model.StatusOperational}
+	fresh := map[string]bool{}
+	if len(latest) > 0 {
+		checkedAt := latest[0].CheckedAt
+		s.CheckedAt = &checkedAt
+		if m.interval > 0 && now.Sub(checkedAt) <= 3*m.interval {
+			for _, c := range latest {
+				fresh[c.Component] = c.OK
+			}
+		}
+	}
+
+	uptime := map[string]map[string]*float64{}
+	var slos map[string]model.SLO
+	for _, w := range uptimeWindows {
+		counts, err := m.checks.Count(ctx, now.Add(-w.d))
+		if err != nil {
+			return model.ServiceStatus{}, err
+		}
+		for _, c := range counts {
+			if uptime[c.Component] == nil {
+				uptime[c.Component] = map[string]*float64{}
+			}
+			uptime[c.Component][w.name] = percent(c.OK, c.Total)
+		}
+		if w.d == sloWindow {
+			slos = m.slo(counts)
+		}
+	}
+
+	for _, name := range m.components() {
+		c := model.ComponentStatus{Name: name, Status: model.StatusUnknown, Uptime: map[string]*float64{}, SLO: slos[name]}
+		if ok, probed := fresh[name]; probed {
+			c.Status = model.StatusDown
+			if ok {
+				c.Status = model.StatusOperational
+			}
+		}
+		for _, w := range uptimeWindows {
+			c.Uptime[w.name] = uptime[name][w.name]
+		}
+		switch {
+		case c.Status == model.StatusDown:
+			s.Status = model.StatusDegraded
+		case c.Status == model.StatusUnknown && s.Status == model.StatusOperational:
+			s.Status = model.StatusUnknown
+		}
+		s.Components = append(s.Components, c)
+	}
+
+	inc, err := m.incidents.Current(ctx)
+	switch {
+	case err == nil:
+		s.Incident = &inc
+	case !errors.Is(err, repository.ErrNotFound):
+		return model.ServiceStatus{}, err
+	}
+	return s, nil
+}
+
+// percent ok / total 的百分比，保留两位小数；total 为 0 时返回 nil
+func percent(ok, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	p := math.Round(float64(ok)/float64(total)*10000) / 100
+	return &p
+}
+
+// slo 按 sloWindow 里的探测次数算每个组件的 SLO，所有组件都有（没有记录的组件可用率和预算为 nil）
+func (m *HealthMonitor) slo(counts []model.HealthCheckCount) map[string]model.SLO {
+	out := make(map[string]model.SLO, len(m.probes))
+	for _, name := range m.components() {
+		out[name] = model.SLO{Target: m.sloTarget, Window: sloWindowName}
+	}
+	for _, c := range counts {
+		s, ok := out[c.Component]
+		if !ok || c.Total == 0 {
+			continue
+		}
+		s.Availability = percent(c.OK, c.Total)
+		// 允许失败的次数，目标是 100% 时一次都不允许，失败一次预算就是负无穷，按 -100% 算
+		allowed := float64(c.Total) * (100 - m.sloTarget) / 100
+		failed := float64(c.Total - c.OK)
+		remaining := 100.0
+		switch {
+		case allowed > 0:
+			remaining = math.Round((1-failed/allowed)*10000) / 100
+		case failed > 0:
+			remaining = -100
+		}
+		s.ErrorBudgetRemaining = &remaining
+		out[c.Component] = s
+	}
+	return out
+}
+
+// updateGauges 记下最近一轮的结果，重新算 SLO，给 WritePrometheus 用
+// 抓取 /metrics 时不查数据库，每轮探测之后算一次
+func (m *HealthMonitor) updateGauges(ctx context.Context, now time.Time, checks []model.HealthCheck) error {
+	counts, err := m.checks.Count(ctx, now.Add(-sloWindow))
+	if err != nil {
+		return err
+	}
+	slos := m.slo(counts)
+
+	m.gaugeMu.Lock()
+	m.latest, m.slos = checks, slos
+	m.gaugeMu.Unlock()
+	return nil
+}
+
+// WritePrometheus 输出最近一轮探测的结果和 SLO（Prometheus 文本格式），还没探测过时什么也不输出
+// SLO 按比例输出（0.999 而不是 99.9），和 Prometheus 的习惯一致
+func (m *HealthMonitor) WritePrometheus(w io.Writer) {
+	m.gaugeMu.Lock()
+	latest, slos := m.latest, m.slos
+	m.gaugeMu.Unlock()
+	if len(latest) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "# HELP kanban_health_up Whether the latest health probe of the component succeeded.")
+	fmt.Fprintln(w, "# TYPE kanban_health_up gauge")
+	for _, c := range latest {
+		up := 0
+		if c.OK {
+			up = 1
+		}
+		fmt.Fprintf(w, "kanban_health_up{component=%q} %d\n", c.Component, up)
+	}
+	fmt.Fprintln(w, "# HELP kanban_health_probe_duration_seconds Duration of the latest health probe.")
+	fmt.Fprintln(w, "# TYPE kanban_health_probe_duration_seconds gauge")
+	for _, c := range latest {
+		fmt.Fprintf(w, "kanban_health_probe_duration_seconds{component=%q} %g\n", c.Component, float64(c.LatencyMs)/1000)
+	}
+	fmt.Fprintln(w, "# HELP kanban_health_queue_depth Items waiting in the queue at the latest health probe.")
+	fmt.Fprintln(w, "# TYPE kanban_health_queue_depth gauge")
+	for _, c := range latest {
+		if c.Value != nil {
+			fmt.Fprintf(w, "kanban_health_queue_depth{component=%q} %d\n", c.Component, *c.Value)
+		}
+	}
+
+	names := make([]string, 0, len(slos))
+	for name := range slos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "# HELP kanban_slo_target_ratio Availability target over the SLO window.")
+	fmt.Fprintln(w, "# TYPE kanban_slo_target_ratio gauge")
+	fmt.Fprintf(w, "kanban_slo_target_ratio{window=%q} %g\n", sloWindowName, m.sloTarget/100)
+	gauges := []struct {
+		name, help string
+		value      func(model.SLO) *float64
+	}{
+		{"kanban_slo_availability_ratio", "Share of successful health probes over the SLO window.", func(s model.SLO) *float64 { return s.Availability }},
+		{"kanban_slo_error_budget_remaining_ratio", "Share of the error budget left over the SLO window, negative when overspent.", func(s model.SLO) *float64 { return s.ErrorBudgetRemaining }},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+		for _, name := range names {
+			if v := g.value(slos[name]); v != nil {
+				fmt.Fprintf(w, "%s{component=%q,window=%q} %g\n", g.name, name, sloWindowName, *v/100)
+			}
+		}
+	}
+}
+
+// HealthHistory 查询探测记录
+func (m *HealthMonitor) HealthHistory(ctx context.Context, q HealthHistoryQuery) ([]model.HealthCheck, error) {
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return nil, invalidInput("from must be before to")
+	}
+	if q.Component != "" {
+		if _, ok := m.probes[q.Component]; !ok {
+			return nil, invalidInput("unknown component: " + q.Component)
+		}
+	}
+	limit := defaultHealthHistory
+	if q.Limit > 0 {
+		limit = min(q.Limit, maxHealthHistory)
+	}
+	return m.checks.List(ctx, repository.HealthCheckFilter{
+		Component:  q.Component,
+		From:       q.From,
+		To:         q.To,
+		FailedOnly: q.FailedOnly,
+		Limit:      limit,
+	})
+}
+
+// SetIncident 发布公告
+func (m *HealthMonitor) SetIncident(ctx context.Context, actorID string, in IncidentInput) (model.Incident, error) {
+	msg := strings.TrimSpace(in.Message)
+	if msg == "" || len([]rune(msg)) > maxIncidentMessage {
+		return model.Incident{}, invalidInput("message must be 1-500 characters")
+	}
+	severity := in.Severity
+	switch severity {
+	case "":
+		severity = model.IncidentMinor
+	case model.IncidentMinor, model.IncidentMajor, model.IncidentMaintenance:
+	default:
+		return model.Incident{}, invalidInput("severity must be minor, major or maintenance")
+	}
+
+	inc, err := m.incidents.Open(ctx, model.Incident{Message: msg, Severity: severity, CreatedBy: actorID, CreatedAt: time.Now()})
+	if err != nil {
+		return model.Incident{}, err
+	}
+	logging.FromContext(ctx).Warn("status incident opened", "incident_id", inc.ID, "severity", severity, "user_id", actorID)
+	m.invalidate()
+	return inc, nil
+}
+
+// ResolveIncident 解除公告
+func (m *HealthMonitor) ResolveIncident(ctx context.Context) (model.Incident, error) {
+	inc, err := m.incidents.Resolve(ctx, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return model.Incident{}, newError(ErrNotFound, "no active incident")
+	}
+	if err != nil {
+		return model.Incident{}, err
+	}
+	logging.FromContext(ctx).Info("status incident resolved", "incident_id", inc.ID)
+	m.invalidate()
+	return inc, nil
+}
+
+// invalidate 清掉状态页缓存，公告马上显示出来（只对这个实例；其它实例等缓存过期）
+func (m *HealthMonitor) invalidate() {
+	m.mu.Lock()
+	m.cachedAt = time.Time{}
+	m.mu.Unlock()
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"testing"
+	"time"
+)
+
+// TestStatus 状态页按最近一轮探测显示组件状态，按探测记录算可用率；过时的探测结果显示 unknown
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	checks := repository.NewMemHealthCheckRepo()
+	cardsDown := false
+	m := NewHealthMonitor(checks, repository.NewMemIncidentRepo(), map[string]HealthProbe{
+		"boards": func(context.Context) (*int64, error) { return nil, nil },
+		"cards": func(context.Context) (*int64, error) {
+			if cardsDown {
+				return nil, errors.New("database is locked")
+			}
+			return nil, nil
+		},
+	}, time.Minute, 90)
+
+	s, err := m.status(ctx, time.Now())
+	if err != nil || s.Status != model.StatusUnknown || s.CheckedAt != nil || len(s.Components) != 2 || s.Components[0].Uptime["24h"] != nil {
+		t.Fatalf("status before any probe = %+v, %v", s, err)
+	}
+
+	now := time.Now()
+	for i := 3; i >= 0; i-- {
+		cardsDown = i == 0
+		if _, err := m.Probe(ctx, now.Add(-time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 8 天前的一轮只算进 30 天的可用率
+	cardsDown = true
+	if _, err := m.Probe(ctx, now.Add(-8*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = m.status(ctx, now)
+	if err != nil || s.Status != model.StatusDegraded || s.CheckedAt == nil || !s.CheckedAt.Equal(now) {
+		t.Fatalf("status = %+v, %v", s, err)
+	}
+	boards, cards := s.Components[0], s.Components[1]
+	if boards.Name != "boards" || boards.Status != model.StatusOperational || *boards.Uptime["24h"] != 100 {
+		t.Fatalf("boards = %+v", boards)
+	}
+	if cards.Status != model.StatusDown || *cards.Uptime["24h"] != 75 || *cards.Uptime["7d"] != 75 || *cards.Uptime["30d"] != 60 {
+		t.Fatalf("cards = %+v, uptime 24h %v 7d %v 30d %v", cards, *cards.Uptime["24h"], *cards.Uptime["7d"], *cards.Uptime["30d"])
+	}
+
+	// 30 天里探测了 5 次，目标 90% 允许失败 0.5 次：boards 一次没失败，cards 失败 2 次，预算超出 3 倍
+	if slo := boards.SLO; slo.Target != 90 || slo.Window != "30d" || *slo.Availability != 100 || *slo.ErrorBudgetRemaining != 100 {
+		t.Fatalf("boards slo = %+v", slo)
+	}
+	if slo := cards.SLO; *slo.Availability != 60 || *slo.ErrorBudgetRemaining != -300 {
+		t.Fatalf("cards slo = %+v, availability %v, budget %v", slo, *slo.Availability, *slo.ErrorBudgetRemaining)
+	}
+
+	// 探测停了十分钟，最近的结果不再可信
+	s, err = m.status(ctx, now.Add(10*time.Minute))
+	if err != nil || s.Status != model.StatusUnknown || s.Components[1].Status != model.StatusUnknown {
+		t.Fatalf("stale status = %+v, %v", s, err)
+	}
+}
+
+// TestStatusIncident 发布的公告马上出现在状态页上（清掉缓存），新公告替换旧公告，解除后消失
+func TestStatusIncident(t *testing.T) {
+	ctx := context.Background()
+	m := NewHealthMonitor(repository.NewMemHealthCheckRepo(), repository.NewMemIncidentRepo(), nil, 0, 99.9)
+
+	if s, err := m.Status(ctx); err != nil || s.Incident != nil {
+		t.Fatalf("status = %+v, %v", s, err)
+	}
+	if _, err := m.SetIncident(ctx, "admin", IncidentInput{Message: " "}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("empty message: %v, want ErrInvalidInput", err)
+	}
+	if _, err := m.SetIncident(ctx, "admin", IncidentInput{Message: "x", Severity: "fatal"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("bad severity: %v, want ErrInvalidInput", err)
+	}
+
+	first, err := m.SetIncident(ctx, "admin", IncidentInput{Message: "slow uploads"})
+	if err != nil || first.Severity != model.IncidentMinor {
+		t.Fatalf("set incident = %+v, %v", first, err)
+	}
+	second, err := m.SetIncident(ctx, "admin", IncidentInput{Message: "database maintenance", Severity: model.IncidentMaintenance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := m.Status(ctx)
+	if err != nil || s.Incident == nil || s.Incident.ID != second.ID {
+		t.Fatalf("status incident = %+v, %v, want %s", s.Incident, err, second.ID)
+	}
+
+	resolved, err := m.ResolveIncident(ctx)
+	if err != nil || resolved.ID != second.ID || resolved.ResolvedAt == nil {
+		t.Fatalf("resolve = %+v, %v", resolved, err)
+	}
+	if _, err := m.ResolveIncident(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("resolve again: %v, want ErrNotFound", err)
+	}
+	if s, err := m.Status(ctx); err != nil || s.Incident != nil {
+		t.Fatalf("status after resolve = %+v, %v", s.Incident, err)
+	}
+}
+
+// TestHealthHistory 探测记录带着队列深度之类的读数，按组件、时间、是否失败筛选，从新到旧
+func TestHealthHistory(t *testing.T) {
+	ctx := context.Background()
+	depth := 0
+	m := NewHealthMonitor(repository.NewMemHealthCheckRepo(), repository.NewMemIncidentRepo(), map[string]HealthProbe{
+		"queue": QueueProbe(func() (int, int) { return depth, 10 }),
+	}, time.Minute, 99.9)
+
+	now := time.Now()
+	for i, d := range []int{2, 9, 3} {
+		depth = d
+		if _, err := m.Probe(ctx, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := m.HealthHistory(ctx, HealthHistoryQuery{})
+	if err != nil || len(all) != 3 || *all[0].Value != 3 || !all[0].OK || all[1].OK {
+		t.Fatalf("history = %+v, %v", all, err)
+	}
+	failed, err := m.HealthHistory(ctx, HealthHistoryQuery{Component: "queue", FailedOnly: true})
+	if err != nil || len(failed) != 1 || *failed[0].Value != 9 {
+		t.Fatalf("failed = %+v, %v", failed, err)
+	}
+	window, err := m.HealthHistory(ctx, HealthHistoryQuery{From: now, To: now.Add(2 * time.Minute), Limit: 1})
+	if err != nil || len(window) != 1 || *window[0].Value != 9 {
+		t.Fatalf("window = %+v, %v", window, err)
+	}
+
+	for _, q := range []HealthHistoryQuery{{Component: "nope"}, {From: now, To: now}} {
+		if _, err := m.HealthHistory(ctx, q); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("history %+v: %v, want ErrInvalidInput", q, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kanban_api/internal/logging"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"time"
+)
+
+// StorageCounters 看板附件占用空间的计数和配额
+//
+// 计数保存在 StorageRepository 里，附件的增删都要跟着改：
+//   - 上传附件：加上附件的大小
+//   - 删除附件、卡片、列表：减去被删掉的附件
+//   - 删除看板：删除计数
+//   - 卡片移到别的看板：从原看板减去、在目标看板加上卡片上的附件
+//
+// 计数和附件记录不在同一个事务里，更新计数失败只记日志，不影响附件本身的操作；
+// 偏差由 Reconcile 按实际的附件重算修正
+type StorageCounters struct {
+	storage     repository.StorageRepository
+	attachments repository.AttachmentRepository
+	lists       repository.ListRepository
+	cards       repository.CardRepository
+
+	// quota 每个看板最多能放多少字节的附件，0 表示不限制
+	quota int64
+}
+
+// NewStorageCounters 创建计数器实例
+func NewStorageCounters(storage repository.StorageRepository, attachments repository.AttachmentRepository, lists repository.ListRepository, cards repository.CardRepository, quota int64) *StorageCounters {
+	return &StorageCounters{storage: storage, attachments: attachments, lists: lists, cards: cards, quota: quota}
+}
+
+// Usage 看板当前的占用空间和配额
+func (c *StorageCounters) Usage(ctx context.Context, boardID string) (model.BoardStorage, error) {
+	u, err := c.storage.Get(ctx, boardID)
+	if err != nil {
+		return model.BoardStorage{}, err
+	}
+	return model.BoardStorage{BoardID: boardID, StorageUsage: u, Quota: c.quota}, nil
+}
+
+// check 看板再放 size 字节会不会超过配额，超过时返回 ErrQuotaExceeded
+// 检查和之后的加法不是原子的，同时上传的几个文件可能一起超过配额一点，下一次上传时就会被拒绝
+func (c *StorageCounters) check(ctx context.Context, boardID string, size int64) error {
+	if c.quota <= 0 {
+		return nil
+	}
+	u, err := c.storage.Get(ctx, boardID)
+	if err != nil {
+		return err
+	}
+	if u.Bytes+size > c.quota {
+		return newError(ErrQuotaExceeded, fmt.Sprintf("board attachments would use %d of %d bytes", u.Bytes+size, c.quota))
+	}
+	return nil
+}
+
+// add 给看板的计数加上 delta，失败只记日志
+func (c *StorageCounters) add(ctx context.Context, boardID string, delta model.StorageUsage) {
+	if delta == (model.StorageUsage{}) {
+		return
+	}
+	if err := c.storage.Add(ctx, boardID, delta); err != nil {
+		logging.FromContext(ctx).Warn("update board storage", "board_id", boardID, "err", err)
+	}
+}
+
+// sub 从看板的计数里减去 u
+func (c *StorageCounters) sub(ctx context.Context, boardID string, u model.StorageUsage) {
+	c.add(ctx, boardID, model.StorageUsage{Bytes: -u.Bytes, Attachments: -u.Attachments})
+}
+
+// usageOf 这些卡片的附件占用的空间，删除卡片之前调用
+// 查询失败时返回 0，计数留给 Reconcile 修正
+func (c *StorageCounters) usageOf(ctx context.Context, cardIDs []string) model.StorageUsage {
+	u, err := c.attachments.UsageByCards(ctx, cardIDs)
+	if err != nil {
+		logging.FromContext(ctx).Warn("attachment usage", "err", err)
+		return model.StorageUsage{}
+	}
+	return u
+}
+
+// remove 删除看板的计数，删除看板时调用
+func (c *StorageCounters) remove(ctx context.Context, boardID string) {
+	if err := c.storage.Delete(ctx, boardID); err != nil {
+		logging.FromContext(ctx).Warn("delete board storage", "board_id", boardID, "err", err)
+	}
+}
+
+// Recalculate 按看板里实际的附件重算计数，返回计数有没有变化
+// 看板已经没有列表（被删除了）并且没有附件时删除计数
+// 重算期间同时上传的附件可能被漏掉，下一次重算时补上
+func (c *StorageCounters) Recalculate(ctx context.Context, boardID string) (bool, error) {
+	ids, lists, err := c.boardCards(ctx, boardID)
+	if err != nil {
+		return false, err
+	}
+	actual, err := c.attachments.UsageByCards(ctx, ids)
+	if err != nil {
+		return false, err
+	}
+	stored, err := c.storage.Get(ctx, boardID)
+	if err != nil {
+		return false, err
+	}
+	if lists == 0 && actual == (model.StorageUsage{}) {
+		return stored != actual, c.storage.Delete(ctx, boardID)
+	}
+	if stored == actual {
+		return false, nil
+	}
+	return true, c.storage.Set(ctx, boardID, actual)
+}
+
+// boardCards 看板上所有卡片的 ID，以及看板有几个列表
+func (c *StorageCounters) boardCards(ctx context.Context, boardID string) ([]string, int, error) {
+	lists, err := c.lists.ListByBoard(ctx, boardID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var ids []string
+	for _, l := range lists {
+		cards, err := c.cards.ListByList(ctx, l.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, cardIDs(cards)...)
+	}
+	return ids, len(lists), nil
+}
+
+// Reconcile 重算所有有计数的看板，返回修正了几个
+// 没有计数的看板一定没有附件：迁移时按已有的附件建好了计数，之后每次上传都会创建
+func (c *StorageCounters) Reconcile(ctx context.Context) (int, error) {
+	ids, err := c.storage.ListBoardIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	fixed := 0
+	for _, id := range ids {
+		changed, err := c.Recalculate(ctx, id)
+		if err != nil {
+			return fixed, err
+		}
+		if changed {
+			logging.FromContext(ctx).Info("board storage corrected", "board_id", id)
+			fixed++
+		}
+	}
+	return fixed, nil
+}
+
+// Run 每隔 interval 重算一次，直到 ctx 被取消
+// 启动时不立即执行：计数平时就是准的，重算只用来兜底
+func (c *StorageCounters) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if n, err := c.Reconcile(ctx); err != nil {
+			logging.FromContext(ctx).Warn("reconcile board storage", "err", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("reconciled board storage", "corrected", n)
+		}
+	}
+}
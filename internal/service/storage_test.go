@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kanban_api/internal/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// usage 看板当前的计数
+func (f attachmentFixture) usage(t *testing.T, boardID string) model.StorageUsage {
+	t.Helper()
+	st, err := f.svc.BoardStorage(context.Background(), "u1", boardID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.StorageUsage
+}
+
+// TestBoardStorageCounters 上传加、删除附件和卡片减；同样的文件挂在两张卡片上算两份
+func TestBoardStorageCounters(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+
+	a := f.upload(t, f.card1, "a.txt", "hello")
+	f.upload(t, f.card2, "b.txt", "same content")
+	f.upload(t, f.card2, "c.txt", "same content")
+	if got, want := f.usage(t, f.board.ID), (model.StorageUsage{Bytes: 29, Attachments: 3}); got != want {
+		t.Fatalf("usage = %+v, want %+v", got, want)
+	}
+
+	if err := f.svc.DeleteAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.cards.DeleteCard(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.usage(t, f.board.ID); got != (model.StorageUsage{}) {
+		t.Fatalf("usage after deleting everything = %+v", got)
+	}
+
+	// 看不到的看板不能查
+	if _, err := f.svc.BoardStorage(ctx, "u2", f.board.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("other user: err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestBoardStorageQuota 超过配额的上传和跨看板移动被拒绝，不留下临时文件
+func TestBoardStorageQuota(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+	f.storage.quota = 10
+
+	f.upload(t, f.card1, "a.txt", "12345678")
+	_, err := f.svc.UploadAttachment(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID, AttachmentUpload{Filename: "b.txt", Body: strings.NewReader("12345")})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("upload over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(f.dir, "tmp")); len(tmp) != 0 {
+		t.Fatalf("%d temp files left", len(tmp))
+	}
+	if _, err := f.svc.StartUpload(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID, UploadStart{Filename: "c.txt", Size: 3}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("start upload over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := f.svc.StartUpload(ctx, "u1", f.board.ID, f.list.ID, f.card2.ID, UploadStart{Filename: "c.txt", Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 卡片移到另一个看板，附件的空间跟着过去
+	other, err := f.boards.Create(ctx, "u1", "Other", "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherList, err := f.lists.Create(ctx, other.ID, "L")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.cards.MoveCard(ctx, "u1", f.board.ID, f.list.ID, f.card1.ID, other.ID, otherList.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.usage(t, f.board.ID); got != (model.StorageUsage{}) {
+		t.Fatalf("source usage = %+v", got)
+	}
+	if got, want := f.usage(t, other.ID), (model.StorageUsage{Bytes: 8, Attachments: 1}); got != want {
+		t.Fatalf("target usage = %+v, want %+v", got, want)
+	}
+
+	// 原看板放满之后，卡片移不回来
+	f.upload(t, f.card2, "d.txt", "1234567")
+	_, err = f.cards.MoveCard(ctx, "u1", other.ID, otherList.ID, f.card1.ID, f.board.ID, f.list.ID, 0)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("move over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+// TestBoardStorageReconcile 重算修正计数的偏差，删除已经没有的看板的计数
+func TestBoardStorageReconcile(t *testing.T) {
+	ctx := context.Background()
+	f := newAttachmentFixture(t)
+	f.upload(t, f.card1, "a.txt", "hello")
+
+	repo := f.storage.storage
+	if err := repo.Set(ctx, f.board.ID, model.StorageUsage{Bytes: 999, Attachments: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Add(ctx, "deleted-board", model.StorageUsage{Bytes: 3, Attachments: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := f.storage.Reconcile(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("reconcile = %d, %v, want 2", n, err)
+	}
+	if got, want := f.usage(t, f.board.ID), (model.StorageUsage{Bytes: 5, Attachments: 1}); got != want {
+		t.Fatalf("usage = %+v, want %+v", got, want)
+	}
+	if ids, _ := repo.ListBoardIDs(ctx); len(ids) != 1 || ids[0] != f.board.ID {
+		t.Fatalf("counters left = %v", ids)
+	}
+	if n, err := f.storage.Reconcile(ctx); err != nil || n != 0 {
+		t.Fatalf("second reconcile = %d, %v, want 0", n, err)
+	}
+}
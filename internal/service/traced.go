@@ -0,0 +1,895 @@
+package service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"kanban_api/internal/model"
+	"time"
+)
+
+// 服务层的链路追踪装饰器
+// 和 repository/instrumented.go 的统计装饰器是同一个思路：实现同样的接口，每个方法外面包一个 span，
+// 处理器和服务本身的代码都不需要改动。span 名是 "接口名.方法名"，例如 BoardService.CreateBoard
+//
+// 注意 fn 收到的是 tracer.Start 返回的新 ctx，必须用它调用下一层，
+// 仓储层的 SQL span 才会挂在这个服务 span 下面
+
+// serviceTracer 服务层使用的 tracer
+// otel.Tracer 返回的是全局 TracerProvider 的代理，tracing.Setup 在它之后调用也没关系
+var serviceTracer = otel.Tracer("kanban_api/internal/service")
+
+// traced 在一个 span 中执行 fn，出错时把错误记到 span 上
+func traced[T any](ctx context.Context, name string, fn func(context.Context) (T, error)) (T, error) {
+	ctx, span := serviceTracer.Start(ctx, name)
+	defer span.End()
+	v, err := fn(ctx)
+	recordError(span, err)
+	return v, err
+}
+
+// tracedErr 与 traced 相同，用于只返回 error 的方法
+func tracedErr(ctx context.Context, name string, fn func(context.Context) error) error {
+	ctx, span := serviceTracer.Start(ctx, name)
+	defer span.End()
+	err := fn(ctx)
+	recordError(span, err)
+	return err
+}
+
+// recordError 把错误记到 span 上
+// 服务层的错误（包括"找不到"、"没有权限"）都记下来：排查问题时想知道的正是请求在哪一步失败了
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// ========== 认证服务装饰器 ==========
+
+type tracedAuthService struct {
+	next AuthService
+}
+
+// TraceAuthService 用链路追踪装饰器包装认证服务
+func TraceAuthService(next AuthService) AuthService {
+	return &tracedAuthService{next: next}
+}
+
+// authResult Register、Login 有两个返回值，打包后才能用 traced
+type authResult struct {
+	user   model.User
+	tokens TokenPair
+}
+
+func (s *tracedAuthService) Register(ctx context.Context, email, password string) (model.User, TokenPair, error) {
+	r, err := traced(ctx, "AuthService.Register", func(ctx context.Context) (authResult, error) {
+		u, t, err := s.next.Register(ctx, email, password)
+		return authResult{u, t}, err
+	})
+	return r.user, r.tokens, err
+}
+
+func (s *tracedAuthService) Login(ctx context.Context, email, password string) (model.User, TokenPair, error) {
+	r, err := traced(ctx, "AuthService.Login", func(ctx context.Context) (authResult, error) {
+		u, t, err := s.next.Login(ctx, email, password)
+		return authResult{u, t}, err
+	})
+	return r.user, r.tokens, err
+}
+
+func (s *tracedAuthService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	return traced(ctx, "AuthService.Refresh", func(ctx context.Context) (TokenPair, error) { return s.next.Refresh(ctx, refreshToken) })
+}
+
+func (s *tracedAuthService) Logout(ctx context.Context, refreshToken string) error {
+	return tracedErr(ctx, "AuthService.Logout", func(ctx context.Context) error { return s.next.Logout(ctx, refreshToken) })
+}
+
+func (s *tracedAuthService) Me(ctx context.Context, userID string) (model.User, error) {
+	return traced(ctx, "AuthService.Me", func(ctx context.Context) (model.User, error) { return s.next.Me(ctx, userID) })
+}
+
+func (s *tracedAuthService) CheckSession(ctx context.Context, userID string) (model.User, error) {
+	return traced(ctx, "AuthService.CheckSession", func(ctx context.Context) (model.User, error) { return s.next.CheckSession(ctx, userID) })
+}
+
+func (s *tracedAuthService) UpdateEmail(ctx context.Context, userID, email, currentPassword string) (model.User, error) {
+	return traced(ctx, "AuthService.UpdateEmail", func(ctx context.Context) (model.User, error) {
+		return s.next.UpdateEmail(ctx, userID, email, currentPassword)
+	})
+}
+
+func (s *tracedAuthService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) (TokenPair, error) {
+	return traced(ctx, "AuthService.ChangePassword", func(ctx context.Context) (TokenPair, error) {
+		return s.next.ChangePassword(ctx, userID, oldPassword, newPassword)
+	})
+}
+
+func (s *tracedAuthService) ListUsers(ctx context.Context) ([]model.User, error) {
+	return traced(ctx, "AuthService.ListUsers", func(ctx context.Context) ([]model.User, error) { return s.next.ListUsers(ctx) })
+}
+
+func (s *tracedAuthService) SetRole(ctx context.Context, userID, role string) (model.User, error) {
+	return traced(ctx, "AuthService.SetRole", func(ctx context.Context) (model.User, error) { return s.next.SetRole(ctx, userID, role) })
+}
+
+// ========== 看板服务装饰器 ==========
+
+type tracedBoardService struct {
+	next BoardService
+}
+
+// TraceBoardService 用链路追踪装饰器包装看板服务
+func TraceBoardService(next BoardService) BoardService {
+	return &tracedBoardService{next: next}
+}
+
+func (s *tracedBoardService) ListBoards(ctx context.Context, userID string) ([]model.Board, error) {
+	return traced(ctx, "BoardService.ListBoards", func(ctx context.Context) ([]model.Board, error) { return s.next.ListBoards(ctx, userID) })
+}
+
+func (s *tracedBoardService) GetBoard(ctx context.Context, userID, id string) (model.Board, error) {
+	return traced(ctx, "BoardService.GetBoard", func(ctx context.Context) (model.Board, error) { return s.next.GetBoard(ctx, userID, id) })
+}
+
+func (s *tracedBoardService) GetBoardBySlug(ctx context.Context, userID, slug string) (model.Board, error) {
+	return traced(ctx, "BoardService.GetBoardBySlug", func(ctx context.Context) (model.Board, error) { return s.next.GetBoardBySlug(ctx, userID, slug) })
+}
+
+func (s *tracedBoardService) CreateBoard(ctx context.Context, userID string, in BoardInput) (model.Board, error) {
+	return traced(ctx, "BoardService.CreateBoard", func(ctx context.Context) (model.Board, error) { return s.next.CreateBoard(ctx, userID, in) })
+}
+
+func (s *tracedBoardService) ImportBoard(ctx context.Context, userID string, in BoardImport) (ImportResult, error) {
+	return traced(ctx, "BoardService.ImportBoard", func(ctx context.Context) (ImportResult, error) { return s.next.ImportBoard(ctx, userID, in) })
+}
+
+func (s *tracedBoardService) ClearSample(ctx context.Context, userID, boardID string) ([]model.Card, error) {
+	return traced(ctx, "BoardService.ClearSample", func(ctx context.Context) ([]model.Card, error) { return s.next.ClearSample(ctx, userID, boardID) })
+}
+
+func (s *tracedBoardService) PatchBoard(ctx context.Context, userID, id string, p BoardPatch, version int64) (model.Board, error) {
+	return traced(ctx, "BoardService.PatchBoard", func(ctx context.Context) (model.Board, error) {
+		return s.next.PatchBoard(ctx, userID, id, p, version)
+	})
+}
+
+func (s *tracedBoardService) UpdateBoard(ctx context.Context, userID, id, title, slug string, version int64) (model.Board, error) {
+	return traced(ctx, "BoardService.UpdateBoard", func(ctx context.Context) (model.Board, error) {
+		return s.next.UpdateBoard(ctx, userID, id, title, slug, version)
+	})
+}
+
+func (s *tracedBoardService) DeleteBoard(ctx context.Context, userID, id, confirmToken string) (*DeleteConfirmation, error) {
+	return traced(ctx, "BoardService.DeleteBoard", func(ctx context.Context) (*DeleteConfirmation, error) {
+		return s.next.DeleteBoard(ctx, userID, id, confirmToken)
+	})
+}
+
+func (s *tracedBoardService) AdminDeleteBoard(ctx context.Context, id string) error {
+	return tracedErr(ctx, "BoardService.AdminDeleteBoard", func(ctx context.Context) error { return s.next.AdminDeleteBoard(ctx, id) })
+}
+
+func (s *tracedBoardService) TransferBoard(ctx context.Context, ownerID, id, newOwnerID string) (model.Board, error) {
+	return traced(ctx, "BoardService.TransferBoard", func(ctx context.Context) (model.Board, error) {
+		return s.next.TransferBoard(ctx, ownerID, id, newOwnerID)
+	})
+}
+
+func (s *tracedBoardService) AdminSetBoardState(ctx context.Context, adminID, id, state, reason string) (model.Board, error) {
+	return traced(ctx, "BoardService.AdminSetBoardState", func(ctx context.Context) (model.Board, error) {
+		return s.next.AdminSetBoardState(ctx, adminID, id, state, reason)
+	})
+}
+
+// ========== 列表服务装饰器 ==========
+
+type tracedListService struct {
+	next ListService
+}
+
+// TraceListService 用链路追踪装饰器包装列表服务
+func TraceListService(next ListService) ListService {
+	return &tracedListService{next: next}
+}
+
+func (s *tracedListService) ListLists(ctx context.Context, userID, boardID string) ([]model.List, error) {
+	return traced(ctx, "ListService.ListLists", func(ctx context.Context) ([]model.List, error) { return s.next.ListLists(ctx, userID, boardID) })
+}
+
+func (s *tracedListService) CreateList(ctx context.Context, userID, boardID, title string) (model.List, error) {
+	return traced(ctx, "ListService.CreateList", func(ctx context.Context) (model.List, error) { return s.next.CreateList(ctx, userID, boardID, title) })
+}
+
+func (s *tracedListService) RenameList(ctx context.Context, userID, boardID, listID, title string) (model.List, error) {
+	return traced(ctx, "ListService.RenameList", func(ctx context.Context) (model.List, error) {
+		return s.next.RenameList(ctx, userID, boardID, listID, title)
+	})
+}
+
+func (s *tracedListService) MoveList(ctx context.Context, userID, boardID, listID string, position int) ([]model.List, error) {
+	return traced(ctx, "ListService.MoveList", func(ctx context.Context) ([]model.List, error) {
+		return s.next.MoveList(ctx, userID, boardID, listID, position)
+	})
+}
+
+func (s *tracedListService) DeleteList(ctx context.Context, userID, boardID, listID string) error {
+	return tracedErr(ctx, "ListService.DeleteList", func(ctx context.Context) error { return s.next.DeleteList(ctx, userID, boardID, listID) })
+}
+
+// ========== 卡片服务装饰器 ==========
+
+type tracedCardService struct {
+	next CardService
+}
+
+// TraceCardService 用链路追踪装饰器包装卡片服务
+func TraceCardService(next CardService) CardService {
+	return &tracedCardService{next: next}
+}
+
+func (s *tracedCardService) ListCards(ctx context.Context, userID, boardID, listID string) ([]model.Card, error) {
+	return traced(ctx, "CardService.ListCards", func(ctx context.Context) ([]model.Card, error) { return s.next.ListCards(ctx, userID, boardID, listID) })
+}
+
+func (s *tracedCardService) GetCard(ctx context.Context, userID, boardID, listID, cardID string) (model.Card, error) {
+	return traced(ctx, "CardService.GetCard", func(ctx context.Context) (model.Card, error) {
+		return s.next.GetCard(ctx, userID, boardID, listID, cardID)
+	})
+}
+
+func (s *tracedCardService) CreateCard(ctx context.Context, userID, boardID, listID string, in CardInput) (model.Card, error) {
+	return traced(ctx, "CardService.CreateCard", func(ctx context.Context) (model.Card, error) {
+		return s.next.CreateCard(ctx, userID, boardID, listID, in)
+	})
+}
+
+func (s *tracedCardService) CreateCardFromTemplate(ctx context.Context, userID, boardID, listID, templateID string, in CardInput) (model.Card, error) {
+	return traced(ctx, "CardService.CreateCardFromTemplate", func(ctx context.Context) (model.Card, error) {
+		return s.next.CreateCardFromTemplate(ctx, userID, boardID, listID, templateID, in)
+	})
+}
+
+func (s *tracedCardService) ReplaceCard(ctx context.Context, userID, boardID, listID, cardID string, in CardInput) (model.Card, error) {
+	return traced(ctx, "CardService.ReplaceCard", func(ctx context.Context) (model.Card, error) {
+		return s.next.ReplaceCard(ctx, userID, boardID, listID, cardID, in)
+	})
+}
+
+func (s *tracedCardService) PatchCard(ctx context.Context, userID, boardID, listID, cardID string, p CardPatch) (model.Card, error) {
+	return traced(ctx, "CardService.PatchCard", func(ctx context.Context) (model.Card, error) {
+		return s.next.PatchCard(ctx, userID, boardID, listID, cardID, p)
+	})
+}
+
+func (s *tracedCardService) MoveCard(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string, position int) ([]model.Card, error) {
+	return traced(ctx, "CardService.MoveCard", func(ctx context.Context) ([]model.Card, error) {
+		return s.next.MoveCard(ctx, userID, boardID, listID, cardID, toBoardID, toListID, position)
+	})
+}
+
+func (s *tracedCardService) DeleteCard(ctx context.Context, userID, boardID, listID, cardID string) error {
+	return tracedErr(ctx, "CardService.DeleteCard", func(ctx context.Context) error { return s.next.DeleteCard(ctx, userID, boardID, listID, cardID) })
+}
+
+func (s *tracedCardService) UpcomingCards(ctx context.Context, userID string, within time.Duration, overdue bool) (UpcomingCards, error) {
+	return traced(ctx, "CardService.UpcomingCards", func(ctx context.Context) (UpcomingCards, error) {
+		return s.next.UpcomingCards(ctx, userID, within, overdue)
+	})
+}
+
+// ========== 看板成员服务装饰器 ==========
+
+type tracedMemberService struct {
+	next MemberService
+}
+
+// TraceMemberService 用链路追踪装饰器包装看板成员服务
+func TraceMemberService(next MemberService) MemberService {
+	return &tracedMemberService{next: next}
+}
+
+func (s *tracedMemberService) ListMembers(ctx context.Context, userID, boardID string) ([]model.BoardMember, error) {
+	return traced(ctx, "MemberService.ListMembers", func(ctx context.Context) ([]model.BoardMember, error) {
+		return s.next.ListMembers(ctx, userID, boardID)
+	})
+}
+
+func (s *tracedMemberService) AddMember(ctx context.Context, userID, boardID, email, role string) (model.BoardMember, error) {
+	return traced(ctx, "MemberService.AddMember", func(ctx context.Context) (model.BoardMember, error) {
+		return s.next.AddMember(ctx, userID, boardID, email, role)
+	})
+}
+
+func (s *tracedMemberService) RemoveMember(ctx context.Context, userID, boardID, memberID string) error {
+	return tracedErr(ctx, "MemberService.RemoveMember", func(ctx context.Context) error { return s.next.RemoveMember(ctx, userID, boardID, memberID) })
+}
+
+// ========== 看板嵌入服务装饰器 ==========
+
+type tracedEmbedService struct {
+	next EmbedService
+}
+
+// TraceEmbedService 用链路追踪装饰器包装看板嵌入服务
+func TraceEmbedService(next EmbedService) EmbedService {
+	return &tracedEmbedService{next: next}
+}
+
+func (s *tracedEmbedService) CreateToken(ctx context.Context, userID, boardID string, ttl time.Duration) (model.EmbedToken, string, error) {
+	type result struct {
+		token model.EmbedToken
+		raw   string
+	}
+	r, err := traced(ctx, "EmbedService.CreateToken", func(ctx context.Context) (result, error) {
+		t, raw, err := s.next.CreateToken(ctx, userID, boardID, ttl)
+		return result{t, raw}, err
+	})
+	return r.token, r.raw, err
+}
+
+func (s *tracedEmbedService) ListTokens(ctx context.Context, userID, boardID string) ([]model.EmbedToken, error) {
+	return traced(ctx, "EmbedService.ListTokens", func(ctx context.Context) ([]model.EmbedToken, error) { return s.next.ListTokens(ctx, userID, boardID) })
+}
+
+func (s *tracedEmbedService) RevokeToken(ctx context.Context, userID, boardID, tokenID string) (model.EmbedToken, error) {
+	return traced(ctx, "EmbedService.RevokeToken", func(ctx context.Context) (model.EmbedToken, error) {
+		return s.next.RevokeToken(ctx, userID, boardID, tokenID)
+	})
+}
+
+func (s *tracedEmbedService) ResolveBoard(ctx context.Context, token string) (model.Board, error) {
+	return traced(ctx, "EmbedService.ResolveBoard", func(ctx context.Context) (model.Board, error) { return s.next.ResolveBoard(ctx, token) })
+}
+
+func (s *tracedEmbedService) Preview(ctx context.Context, token, cardID string) (LinkPreview, error) {
+	return traced(ctx, "EmbedService.Preview", func(ctx context.Context) (LinkPreview, error) { return s.next.Preview(ctx, token, cardID) })
+}
+
+// ========== 密码重置服务装饰器 ==========
+
+type tracedPasswordService struct {
+	next PasswordService
+}
+
+// TracePasswordService 用链路追踪装饰器包装密码重置服务
+func TracePasswordService(next PasswordService) PasswordService {
+	return &tracedPasswordService{next: next}
+}
+
+func (s *tracedPasswordService) ForgotPassword(ctx context.Context, email string) error {
+	return tracedErr(ctx, "PasswordService.ForgotPassword", func(ctx context.Context) error { return s.next.ForgotPassword(ctx, email) })
+}
+
+func (s *tracedPasswordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return tracedErr(ctx, "PasswordService.ResetPassword", func(ctx context.Context) error { return s.next.ResetPassword(ctx, token, newPassword) })
+}
+
+// ========== 第三方登录服务装饰器 ==========
+
+type tracedOAuthService struct {
+	next OAuthService
+}
+
+// TraceOAuthService 用链路追踪装饰器包装第三方登录服务
+// Providers 和 Start 不访问数据库也不发请求，不生成 span
+func TraceOAuthService(next OAuthService) OAuthService {
+	return &tracedOAuthService{next: next}
+}
+
+func (s *tracedOAuthService) Providers() []string {
+	return s.next.Providers()
+}
+
+func (s *tracedOAuthService) Start(provider string) (string, string, error) {
+	return s.next.Start(provider)
+}
+
+func (s *tracedOAuthService) Login(ctx context.Context, provider, code string) (model.User, TokenPair, error) {
+	r, err := traced(ctx, "OAuthService.Login", func(ctx context.Context) (authResult, error) {
+		u, t, err := s.next.Login(ctx, provider, code)
+		return authResult{u, t}, err
+	})
+	return r.user, r.tokens, err
+}
+
+// ========== API 密钥服务装饰器 ==========
+
+type tracedAPIKeyService struct {
+	next APIKeyService
+}
+
+// TraceAPIKeyService 用链路追踪装饰器包装 API 密钥服务
+func TraceAPIKeyService(next APIKeyService) APIKeyService {
+	return &tracedAPIKeyService{next: next}
+}
+
+// apiKeyResult 把 CreateKey、Authenticate 的多个返回值打包，交给 traced
+type apiKeyResult struct {
+	key    model.APIKey
+	secret string
+	user   model.User
+}
+
+func (s *tracedAPIKeyService) CreateKey(ctx context.Context, userID string, in APIKeyInput) (model.APIKey, string, error) {
+	r, err := traced(ctx, "APIKeyService.CreateKey", func(ctx context.Context) (apiKeyResult, error) {
+		k, secret, err := s.next.CreateKey(ctx, userID, in)
+		return apiKeyResult{key: k, secret: secret}, err
+	})
+	return r.key, r.secret, err
+}
+
+func (s *tracedAPIKeyService) ListKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	return traced(ctx, "APIKeyService.ListKeys", func(ctx context.Context) ([]model.APIKey, error) { return s.next.ListKeys(ctx, userID) })
+}
+
+func (s *tracedAPIKeyService) RevokeKey(ctx context.Context, userID, id string) error {
+	return tracedErr(ctx, "APIKeyService.RevokeKey", func(ctx context.Context) error { return s.next.RevokeKey(ctx, userID, id) })
+}
+
+func (s *tracedAPIKeyService) Authenticate(ctx context.Context, key string) (model.APIKey, model.User, error) {
+	r, err := traced(ctx, "APIKeyService.Authenticate", func(ctx context.Context) (apiKeyResult, error) {
+		k, u, err := s.next.Authenticate(ctx, key)
+		return apiKeyResult{key: k, user: u}, err
+	})
+	return r.key, r.user, err
+}
+
+// ========== 搜索服务装饰器 ==========
+
+type tracedSearchService struct {
+	next SearchService
+}
+
+// TraceSearchService 用链路追踪装饰器包装搜索服务
+func TraceSearchService(next SearchService) SearchService {
+	return &tracedSearchService{next: next}
+}
+
+func (s *tracedSearchService) Search(ctx context.Context, userID, query string, limit int) ([]model.SearchResult, error) {
+	return traced(ctx, "SearchService.Search", func(ctx context.Context) ([]model.SearchResult, error) {
+		return s.next.Search(ctx, userID, query, limit)
+	})
+}
+
+// ========== webhook 服务装饰器 ==========
+
+type tracedWebhookService struct {
+	next WebhookService
+}
+
+// TraceWebhookService 用链路追踪装饰器包装 webhook 服务
+func TraceWebhookService(next WebhookService) WebhookService {
+	return &tracedWebhookService{next: next}
+}
+
+func (s *tracedWebhookService) CreateWebhook(ctx context.Context, userID, boardID, rawURL string, events []string) (model.Webhook, string, error) {
+	type result struct {
+		webhook model.Webhook
+		secret  string
+	}
+	r, err := traced(ctx, "WebhookService.CreateWebhook", func(ctx context.Context) (result, error) {
+		w, secret, err := s.next.CreateWebhook(ctx, userID, boardID, rawURL, events)
+		return result{w, secret}, err
+	})
+	return r.webhook, r.secret, err
+}
+
+func (s *tracedWebhookService) ListWebhooks(ctx context.Context, userID, boardID string) ([]model.Webhook, error) {
+	return traced(ctx, "WebhookService.ListWebhooks", func(ctx context.Context) ([]model.Webhook, error) { return s.next.ListWebhooks(ctx, userID, boardID) })
+}
+
+func (s *tracedWebhookService) DeleteWebhook(ctx context.Context, userID, boardID, webhookID string) error {
+	return tracedErr(ctx, "WebhookService.DeleteWebhook", func(ctx context.Context) error { return s.next.DeleteWebhook(ctx, userID, boardID, webhookID) })
+}
+
+// ========== 卡片镜像服务装饰器 ==========
+
+type tracedCardLinkService struct {
+	next CardLinkService
+}
+
+// TraceCardLinkService 用链路追踪装饰器包装卡片镜像服务
+func TraceCardLinkService(next CardLinkService) CardLinkService {
+	return &tracedCardLinkService{next: next}
+}
+
+func (s *tracedCardLinkService) CreateMirror(ctx context.Context, userID, boardID, listID, cardID, toBoardID, toListID string) (model.Card, error) {
+	return traced(ctx, "CardLinkService.CreateMirror", func(ctx context.Context) (model.Card, error) {
+		return s.next.CreateMirror(ctx, userID, boardID, listID, cardID, toBoardID, toListID)
+	})
+}
+
+func (s *tracedCardLinkService) ListLinks(ctx context.Context, userID, boardID, listID, cardID string) ([]model.CardLink, error) {
+	return traced(ctx, "CardLinkService.ListLinks", func(ctx context.Context) ([]model.CardLink, error) {
+		return s.next.ListLinks(ctx, userID, boardID, listID, cardID)
+	})
+}
+
+func (s *tracedCardLinkService) DeleteLink(ctx context.Context, userID, boardID, listID, cardID, linkID string) error {
+	return tracedErr(ctx, "CardLinkService.DeleteLink", func(ctx context.Context) error {
+		return s.next.DeleteLink(ctx, userID, boardID, listID, cardID, linkID)
+	})
+}
+
+// ========== 卡片模板服务装饰器 ==========
+
+type tracedCardTemplateService struct {
+	next CardTemplateService
+}
+
+// TraceCardTemplateService 用链路追踪装饰器包装卡片模板服务
+func TraceCardTemplateService(next CardTemplateService) CardTemplateService {
+	return &tracedCardTemplateService{next: next}
+}
+
+func (s *tracedCardTemplateService) ListTemplates(ctx context.Context, userID, boardID string) ([]model.CardTemplate, error) {
+	return traced(ctx, "CardTemplateService.ListTemplates", func(ctx context.Context) ([]model.CardTemplate, error) {
+		return s.next.ListTemplates(ctx, userID, boardID)
+	})
+}
+
+func (s *tracedCardTemplateService) GetTemplate(ctx context.Context, userID, boardID, templateID string) (model.CardTemplate, error) {
+	return traced(ctx, "CardTemplateService.GetTemplate", func(ctx context.Context) (model.CardTemplate, error) {
+		return s.next.GetTemplate(ctx, userID, boardID, templateID)
+	})
+}
+
+func (s *tracedCardTemplateService) CreateTemplate(ctx context.Context, userID, boardID string, in CardTemplateInput) (model.CardTemplate, error) {
+	return traced(ctx, "CardTemplateService.CreateTemplate", func(ctx context.Context) (model.CardTemplate, error) {
+		return s.next.CreateTemplate(ctx, userID, boardID, in)
+	})
+}
+
+func (s *tracedCardTemplateService) ReplaceTemplate(ctx context.Context, userID, boardID, templateID string, in CardTemplateInput) (model.CardTemplate, error) {
+	return traced(ctx, "CardTemplateService.ReplaceTemplate", func(ctx context.Context) (model.CardTemplate, error) {
+		return s.next.ReplaceTemplate(ctx, userID, boardID, templateID, in)
+	})
+}
+
+func (s *tracedCardTemplateService) DeleteTemplate(ctx context.Context, userID, boardID, templateID string) error {
+	return tracedErr(ctx, "CardTemplateService.DeleteTemplate", func(ctx context.Context) error {
+		return s.next.DeleteTemplate(ctx, userID, boardID, templateID)
+	})
+}
+
+// ========== 看板动态服务装饰器 ==========
+
+type tracedActivityService struct {
+	next ActivityService
+}
+
+// TraceActivityService 用链路追踪装饰器包装看板动态服务
+func TraceActivityService(next ActivityService) ActivityService {
+	return &tracedActivityService{next: next}
+}
+
+func (s *tracedActivityService) ListActivities(ctx context.Context, userID, boardID, cursor string, limit int) (ActivityPage, error) {
+	return traced(ctx, "ActivityService.ListActivities", func(ctx context.Context) (ActivityPage, error) {
+		return s.next.ListActivities(ctx, userID, boardID, cursor, limit)
+	})
+}
+
+// ========== 看板每日快照服务装饰器 ==========
+
+type tracedSnapshotService struct {
+	next SnapshotService
+}
+
+// TraceSnapshotService 用链路追踪装饰器包装每日快照服务
+func TraceSnapshotService(next SnapshotService) SnapshotService {
+	return &tracedSnapshotService{next: next}
+}
+
+func (s *tracedSnapshotService) ListSnapshots(ctx context.Context, userID, boardID string, from, to time.Time) ([]model.BoardSnapshot, error) {
+	return traced(ctx, "SnapshotService.ListSnapshots", func(ctx context.Context) ([]model.BoardSnapshot, error) {
+		return s.next.ListSnapshots(ctx, userID, boardID, from, to)
+	})
+}
+
+// ========== 异常检测服务装饰器 ==========
+
+type tracedAnomalyService struct {
+	next AnomalyService
+}
+
+// TraceAnomalyService 用链路追踪装饰器包装异常检测服务
+func TraceAnomalyService(next AnomalyService) AnomalyService {
+	return &tracedAnomalyService{next: next}
+}
+
+func (s *tracedAnomalyService) ListAnomalies(ctx context.Context, limit int) ([]model.Anomaly, error) {
+	return traced(ctx, "AnomalyService.ListAnomalies", func(ctx context.Context) ([]model.Anomaly, error) {
+		return s.next.ListAnomalies(ctx, limit)
+	})
+}
+
+// ========== 注销账号服务装饰器 ==========
+
+type tracedAccountService struct {
+	next AccountService
+}
+
+// TraceAccountService 用链路追踪装饰器包装注销账号服务
+func TraceAccountService(next AccountService) AccountService {
+	return &tracedAccountService{next: next}
+}
+
+func (s *tracedAccountService) DeleteAccount(ctx context.Context, userID, password string, transferBoards bool) (model.AccountDeletion, error) {
+	return traced(ctx, "AccountService.DeleteAccount", func(ctx context.Context) (model.AccountDeletion, error) {
+		return s.next.DeleteAccount(ctx, userID, password, transferBoards)
+	})
+}
+
+func (s *tracedAccountService) RestoreAccount(ctx context.Context, email, password string) (model.User, TokenPair, error) {
+	r, err := traced(ctx, "AccountService.RestoreAccount", func(ctx context.Context) (authResult, error) {
+		u, t, err := s.next.RestoreAccount(ctx, email, password)
+		return authResult{u, t}, err
+	})
+	return r.user, r.tokens, err
+}
+
+// ========== 运维接口服务装饰器 ==========
+
+type tracedAdminService struct {
+	next AdminService
+}
+
+// TraceAdminService 用链路追踪装饰器包装运维接口服务
+func TraceAdminService(next AdminService) AdminService {
+	return &tracedAdminService{next: next}
+}
+
+func (s *tracedAdminService) SearchUsers(ctx context.Context, q UserQuery) (UserPage, error) {
+	return traced(ctx, "AdminService.SearchUsers", func(ctx context.Context) (UserPage, error) {
+		return s.next.SearchUsers(ctx, q)
+	})
+}
+
+func (s *tracedAdminService) SetUserDisabled(ctx context.Context, adminID, userID string, disabled bool) (model.User, error) {
+	return traced(ctx, "AdminService.SetUserDisabled", func(ctx context.Context) (model.User, error) {
+		return s.next.SetUserDisabled(ctx, adminID, userID, disabled)
+	})
+}
+
+func (s *tracedAdminService) SearchBoards(ctx context.Context, q BoardQuery) (BoardPage, error) {
+	return traced(ctx, "AdminService.SearchBoards", func(ctx context.Context) (BoardPage, error) {
+		return s.next.SearchBoards(ctx, q)
+	})
+}
+
+func (s *tracedAdminService) GetBoard(ctx context.Context, id string) (model.BoardOverview, error) {
+	return traced(ctx, "AdminService.GetBoard", func(ctx context.Context) (model.BoardOverview, error) {
+		return s.next.GetBoard(ctx, id)
+	})
+}
+
+func (s *tracedAdminService) Stats(ctx context.Context, days int, now time.Time) (model.AdminStats, error) {
+	return traced(ctx, "AdminService.Stats", func(ctx context.Context) (model.AdminStats, error) {
+		return s.next.Stats(ctx, days, now)
+	})
+}
+
+// ========== 分享链接解析服务装饰器 ==========
+
+type tracedResolveService struct {
+	next ResolveService
+}
+
+// TraceResolveService 用链路追踪装饰器包装分享链接解析服务
+func TraceResolveService(next ResolveService) ResolveService {
+	return &tracedResolveService{next: next}
+}
+
+func (s *tracedResolveService) Resolve(ctx context.Context, userID, rawURL string) (ResolvedLink, error) {
+	return traced(ctx, "ResolveService.Resolve", func(ctx context.Context) (ResolvedLink, error) {
+		return s.next.Resolve(ctx, userID, rawURL)
+	})
+}
+
+// ========== 检查清单服务装饰器 ==========
+
+type tracedChecklistService struct {
+	next ChecklistService
+}
+
+// TraceChecklistService 用链路追踪装饰器包装检查清单服务
+func TraceChecklistService(next ChecklistService) ChecklistService {
+	return &tracedChecklistService{next: next}
+}
+
+func (s *tracedChecklistService) ListChecklists(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Checklist, error) {
+	return traced(ctx, "ChecklistService.ListChecklists", func(ctx context.Context) ([]model.Checklist, error) {
+		return s.next.ListChecklists(ctx, userID, boardID, listID, cardID)
+	})
+}
+
+func (s *tracedChecklistService) GetChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.GetChecklist", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.GetChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	})
+}
+
+func (s *tracedChecklistService) CreateChecklist(ctx context.Context, userID, boardID, listID, cardID, title string) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.CreateChecklist", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.CreateChecklist(ctx, userID, boardID, listID, cardID, title)
+	})
+}
+
+func (s *tracedChecklistService) RenameChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID, title string) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.RenameChecklist", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.RenameChecklist(ctx, userID, boardID, listID, cardID, checklistID, title)
+	})
+}
+
+func (s *tracedChecklistService) MoveChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string, position int) ([]model.Checklist, error) {
+	return traced(ctx, "ChecklistService.MoveChecklist", func(ctx context.Context) ([]model.Checklist, error) {
+		return s.next.MoveChecklist(ctx, userID, boardID, listID, cardID, checklistID, position)
+	})
+}
+
+func (s *tracedChecklistService) DeleteChecklist(ctx context.Context, userID, boardID, listID, cardID, checklistID string) error {
+	return tracedErr(ctx, "ChecklistService.DeleteChecklist", func(ctx context.Context) error {
+		return s.next.DeleteChecklist(ctx, userID, boardID, listID, cardID, checklistID)
+	})
+}
+
+func (s *tracedChecklistService) AddItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, text string) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.AddItem", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.AddItem(ctx, userID, boardID, listID, cardID, checklistID, text)
+	})
+}
+
+func (s *tracedChecklistService) UpdateItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, p ChecklistItemPatch) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.UpdateItem", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.UpdateItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, p)
+	})
+}
+
+func (s *tracedChecklistService) MoveItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string, position int) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.MoveItem", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.MoveItem(ctx, userID, boardID, listID, cardID, checklistID, itemID, position)
+	})
+}
+
+func (s *tracedChecklistService) DeleteItem(ctx context.Context, userID, boardID, listID, cardID, checklistID, itemID string) (model.Checklist, error) {
+	return traced(ctx, "ChecklistService.DeleteItem", func(ctx context.Context) (model.Checklist, error) {
+		return s.next.DeleteItem(ctx, userID, boardID, listID, cardID, checklistID, itemID)
+	})
+}
+
+// ========== 声明式看板配置服务装饰器 ==========
+
+type tracedProvisionService struct {
+	next ProvisionService
+}
+
+// TraceProvisionService 用链路追踪装饰器包装声明式看板配置服务
+// 执行时调用的看板、列表、成员服务也是包装过的，它们的 span 挂在这个 span 下面
+func TraceProvisionService(next ProvisionService) ProvisionService {
+	return &tracedProvisionService{next: next}
+}
+
+func (s *tracedProvisionService) Provision(ctx context.Context, userID string, spec ProvisionSpec, opts ProvisionOptions) (ProvisionResult, error) {
+	return traced(ctx, "ProvisionService.Provision", func(ctx context.Context) (ProvisionResult, error) {
+		return s.next.Provision(ctx, userID, spec, opts)
+	})
+}
+
+// ========== 附件服务装饰器 ==========
+
+type tracedAttachmentService struct {
+	next AttachmentService
+}
+
+// TraceAttachmentService 用链路追踪装饰器包装附件服务
+// OpenAttachment 的 span 只包括打开文件，不包括把内容写给客户端
+func TraceAttachmentService(next AttachmentService) AttachmentService {
+	return &tracedAttachmentService{next: next}
+}
+
+func (s *tracedAttachmentService) ListAttachments(ctx context.Context, userID, boardID, listID, cardID string) ([]model.Attachment, error) {
+	return traced(ctx, "AttachmentService.ListAttachments", func(ctx context.Context) ([]model.Attachment, error) {
+		return s.next.ListAttachments(ctx, userID, boardID, listID, cardID)
+	})
+}
+
+func (s *tracedAttachmentService) UploadAttachment(ctx context.Context, userID, boardID, listID, cardID string, in AttachmentUpload) (model.Attachment, error) {
+	return traced(ctx, "AttachmentService.UploadAttachment", func(ctx context.Context) (model.Attachment, error) {
+		return s.next.UploadAttachment(ctx, userID, boardID, listID, cardID, in)
+	})
+}
+
+func (s *tracedAttachmentService) OpenAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) (model.Attachment, io.ReadSeekCloser, error) {
+	type result struct {
+		attachment model.Attachment
+		file       io.ReadSeekCloser
+	}
+	r, err := traced(ctx, "AttachmentService.OpenAttachment", func(ctx context.Context) (result, error) {
+		a, f, err := s.next.OpenAttachment(ctx, userID, boardID, listID, cardID, attachmentID)
+		return result{a, f}, err
+	})
+	return r.attachment, r.file, err
+}
+
+func (s *tracedAttachmentService) DeleteAttachment(ctx context.Context, userID, boardID, listID, cardID, attachmentID string) error {
+	return tracedErr(ctx, "AttachmentService.DeleteAttachment", func(ctx context.Context) error {
+		return s.next.DeleteAttachment(ctx, userID, boardID, listID, cardID, attachmentID)
+	})
+}
+
+func (s *tracedAttachmentService) StartUpload(ctx context.Context, userID, boardID, listID, cardID string, in UploadStart) (model.ResumableUpload, error) {
+	return traced(ctx, "AttachmentService.StartUpload", func(ctx context.Context) (model.ResumableUpload, error) {
+		return s.next.StartUpload(ctx, userID, boardID, listID, cardID, in)
+	})
+}
+
+func (s *tracedAttachmentService) GetUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) (model.ResumableUpload, error) {
+	return traced(ctx, "AttachmentService.GetUpload", func(ctx context.Context) (model.ResumableUpload, error) {
+		return s.next.GetUpload(ctx, userID, boardID, listID, cardID, uploadID)
+	})
+}
+
+func (s *tracedAttachmentService) AppendUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string, offset int64, body io.Reader) (model.ResumableUpload, *model.Attachment, error) {
+	type result struct {
+		upload     model.ResumableUpload
+		attachment *model.Attachment
+	}
+	r, err := traced(ctx, "AttachmentService.AppendUpload", func(ctx context.Context) (result, error) {
+		u, a, err := s.next.AppendUpload(ctx, userID, boardID, listID, cardID, uploadID, offset, body)
+		return result{u, a}, err
+	})
+	return r.upload, r.attachment, err
+}
+
+func (s *tracedAttachmentService) CancelUpload(ctx context.Context, userID, boardID, listID, cardID, uploadID string) error {
+	return tracedErr(ctx, "AttachmentService.CancelUpload", func(ctx context.Context) error {
+		return s.next.CancelUpload(ctx, userID, boardID, listID, cardID, uploadID)
+	})
+}
+
+func (s *tracedAttachmentService) BoardStorage(ctx context.Context, userID, boardID string) (model.BoardStorage, error) {
+	return traced(ctx, "AttachmentService.BoardStorage", func(ctx context.Context) (model.BoardStorage, error) {
+		return s.next.BoardStorage(ctx, userID, boardID)
+	})
+}
+
+// ========== 公开状态页服务装饰器 ==========
+
+type tracedStatusService struct {
+	next StatusService
+}
+
+// TraceStatusService 用链路追踪装饰器包装公开状态页服务
+func TraceStatusService(next StatusService) StatusService {
+	return &tracedStatusService{next: next}
+}
+
+func (s *tracedStatusService) Status(ctx context.Context) (model.ServiceStatus, error) {
+	return traced(ctx, "StatusService.Status", func(ctx context.Context) (model.ServiceStatus, error) {
+		return s.next.Status(ctx)
+	})
+}
+
+func (s *tracedStatusService) SetIncident(ctx context.Context, actorID string, in IncidentInput) (model.Incident, error) {
+	return traced(ctx, "StatusService.SetIncident", func(ctx context.Context) (model.Incident, error) {
+		return s.next.SetIncident(ctx, actorID, in)
+	})
+}
+
+func (s *tracedStatusService) ResolveIncident(ctx context.Context) (model.Incident, error) {
+	return traced(ctx, "StatusService.ResolveIncident", func(ctx context.Context) (model.Incident, error) {
+		return s.next.ResolveIncident(ctx)
+	})
+}
+
+func (s *tracedStatusService) HealthHistory(ctx context.Context, q HealthHistoryQuery) ([]model.HealthCheck, error) {
+	return traced(ctx, "StatusService.HealthHistory", func(ctx context.Context) ([]model.HealthCheck, error) {
+		return s.next.HealthHistory(ctx, q)
+	})
+}
@@ -0,0 +1,103 @@
+// Package service webhook 业务逻辑
+package service
+
+import (
+	"context"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"net/url"
+	"strings"
+)
+
+// maxWebhooksPerBoard 每个看板最多多少个 webhook
+// 每个事件要对每个 webhook 发一次请求，数量不设上限的话一次修改就能让服务端发出大量请求
+const maxWebhooksPerBoard = 10
+
+// WebhookService webhook 服务接口
+// 只负责登记和删除，事件的投递见 webhook.Dispatcher
+// webhook 会把看板内容发到外部系统，所以和嵌入令牌一样只有所有者可以管理
+type WebhookService interface {
+	// CreateWebhook 为看板登记一个 webhook
+	// events 为空表示接收所有事件
+	// 返回：webhook、签名密钥（只在这里返回一次）、错误
+	CreateWebhook(ctx context.Context, userID, boardID, rawURL string, events []string) (model.Webhook, string, error)
+
+	// ListWebhooks 列出看板的所有 webhook
+	ListWebhooks(ctx context.Context, userID, boardID string) ([]model.Webhook, error)
+
+	// DeleteWebhook 删除看板的某个 webhook
+	DeleteWebhook(ctx context.Context, userID, boardID, webhookID string) error
+}
+
+// webhookService webhook 服务的具体实现
+type webhookService struct {
+	webhooks repository.WebhookRepository
+	access   boardAccess
+}
+
+// NewWebhookService 创建 webhook 服务实例
+func NewWebhookService(webhooks repository.WebhookRepository, boards repository.BoardRepository, members repository.MemberRepository) WebhookService {
+	return &webhookService{webhooks: webhooks, access: boardAccess{boards: boards, members: members}}
+}
+
+// CreateWebhook 登记 webhook
+func (s *webhookService) CreateWebhook(ctx context.Context, userID, boardID, rawURL string, types []string) (model.Webhook, string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return model.Webhook{}, "", invalidInput("url must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		// 用户名密码会出现在列表接口的响应里，认证请用签名
+		return model.Webhook{}, "", invalidInput("url must not contain credentials")
+	}
+	for _, t := range types {
+		if !events.IsType(t) {
+			return model.Webhook{}, "", invalidInput("unknown event type: " + t)
+		}
+	}
+
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleOwner); err != nil {
+		return model.Webhook{}, "", err
+	}
+	existing, err := s.webhooks.ListByBoard(ctx, boardID)
+	if err != nil {
+		return model.Webhook{}, "", err
+	}
+	if len(existing) >= maxWebhooksPerBoard {
+		return model.Webhook{}, "", newError(ErrConflict, "too many webhooks on this board")
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return model.Webhook{}, "", err
+	}
+	w, err := s.webhooks.Create(ctx, model.Webhook{
+		BoardID:   boardID,
+		URL:       u.String(),
+		Events:    types,
+		Secret:    secret,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		return model.Webhook{}, "", err
+	}
+	return w, secret, nil
+}
+
+// ListWebhooks 列出看板的所有 webhook
+func (s *webhookService) ListWebhooks(ctx context.Context, userID, boardID string) ([]model.Webhook, error) {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.webhooks.ListByBoard(ctx, boardID)
+}
+
+// DeleteWebhook 删除 webhook
+// 已经在重试队列里的投递会继续进行，最多再收到几次请求
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, boardID, webhookID string) error {
+	if _, _, err := s.access.check(ctx, userID, boardID, model.RoleOwner); err != nil {
+		return err
+	}
+	return s.webhooks.Delete(ctx, boardID, webhookID)
+}
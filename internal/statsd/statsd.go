@@ -0,0 +1,171 @@
+// Package statsd StatsD / DogStatsD 指标客户端
+//
+// StatsD 是一个很简单的文本协议，每个指标一行，通过 UDP 发给本机或内网的 agent（statsd、Telegraf、Datadog Agent）：
+//
+//	kanban.http.requests:1|c|#env:prod,route:/api/v1/boards
+//	名字:值|类型|#标签
+//
+// 类型：c 计数器（agent 累加）、g 仪表（agent 保留最后一次的值）、ms 耗时（agent 计算分位数）
+// 标签（|# 后面的部分）是 DogStatsD 的扩展，Datadog Agent 和 Telegraf（datadog_extensions = true）都支持
+//
+// UDP 发出去就不管了：agent 没启动或者丢包只会少几个点，不会拖慢请求或者报错
+package statsd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPacketSize 一个 UDP 包的最大字节数，多个指标用换行拼在一个包里发送
+// 1432 是常见以太网 MTU（1500）减去 IP、UDP 头部后留的余量，避免 IP 分片
+const maxPacketSize = 1432
+
+// flushInterval 缓冲区最长多久发送一次
+const flushInterval = time.Second
+
+// Config StatsD 客户端配置
+type Config struct {
+	// Addr agent 地址，例如 127.0.0.1:8125
+	Addr string
+
+	// Prefix 所有指标名的前缀，例如 kanban.
+	Prefix string
+
+	// Tags 附加到每个指标上的全局标签，例如 env:prod
+	Tags []string
+}
+
+// ConfigFromEnv 从环境变量读取配置
+// - STATSD_ADDR: agent 地址（必填）
+// - STATSD_PREFIX: 指标名前缀，默认 kanban.
+// - STATSD_TAGS: 全局标签，逗号分隔，例如 env:prod,region:eu
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Addr:   os.Getenv("STATSD_ADDR"),
+		Prefix: "kanban.",
+	}
+	if cfg.Addr == "" {
+		return cfg, errors.New("STATSD_ADDR is required")
+	}
+	if v, ok := os.LookupEnv("STATSD_PREFIX"); ok {
+		// 允许设置为空字符串，表示不加前缀
+		cfg.Prefix = v
+	}
+	for _, t := range strings.Split(os.Getenv("STATSD_TAGS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			cfg.Tags = append(cfg.Tags, t)
+		}
+	}
+	return cfg, nil
+}
+
+// Client StatsD 客户端，可以被多个 goroutine 同时使用
+// 指标先写进缓冲区，攒满一个包或者每秒发送一次，请求量大时不会每个指标一个 UDP 包
+type Client struct {
+	conn   net.Conn
+	prefix string
+	tags   []string
+
+	mu   sync.Mutex
+	buf  []byte
+	done chan struct{}
+}
+
+// New 创建客户端
+// UDP 是无连接的，这里的 Dial 只是解析地址，agent 没启动也不会报错
+func New(cfg Config) (*Client, error) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:   conn,
+		prefix: cfg.Prefix,
+		tags:   cfg.Tags,
+		buf:    make([]byte, 0, maxPacketSize),
+		done:   make(chan struct{}),
+	}
+	go c.flushLoop()
+	return c, nil
+}
+
+// Count 计数器加 n
+func (c *Client) Count(name string, n int64, tags ...string) {
+	c.send(name, strconv.FormatInt(n, 10), "c", tags)
+}
+
+// Gauge 设置仪表的当前值
+func (c *Client) Gauge(name string, v float64, tags ...string) {
+	c.send(name, strconv.FormatFloat(v, 'f', -1, 64), "g", tags)
+}
+
+// Timing 记录一次耗时，单位毫秒
+func (c *Client) Timing(name string, d time.Duration, tags ...string) {
+	c.send(name, strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64), "ms", tags)
+}
+
+// Close 发送缓冲区里剩下的指标并关闭连接
+func (c *Client) Close() error {
+	close(c.done)
+	c.mu.Lock()
+	c.flushLocked()
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// Tag 拼一个 key:value 标签
+// 值里的 | , # 和换行是协议的分隔符，会把一行拆坏，替换成下划线
+func Tag(key, value string) string {
+	return key + ":" + tagReplacer.Replace(value)
+}
+
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+// send 拼出一行指标写进缓冲区，放不下时先把缓冲区发出去
+func (c *Client) send(name, value, typ string, tags []string) {
+	line := c.prefix + name + ":" + value + "|" + typ
+	if len(c.tags)+len(tags) > 0 {
+		line += "|#" + strings.Join(append(append([]string{}, c.tags...), tags...), ",")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.buf) > 0 && len(c.buf)+1+len(line) > maxPacketSize {
+		c.flushLocked()
+	}
+	if len(c.buf) > 0 {
+		c.buf = append(c.buf, '\n')
+	}
+	c.buf = append(c.buf, line...)
+}
+
+// flushLoop 定时发送缓冲区，指标少的时候也不会一直攒着
+func (c *Client) flushLoop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			c.flushLocked()
+			c.mu.Unlock()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// flushLocked 发送缓冲区，调用方必须持有锁
+// 发送失败（例如 agent 没启动，收到 ICMP 端口不可达）直接丢掉，指标是尽力而为的
+func (c *Client) flushLocked() {
+	if len(c.buf) == 0 {
+		return
+	}
+	_, _ = c.conn.Write(c.buf)
+	c.buf = c.buf[:0]
+}
@@ -0,0 +1,76 @@
+// Package tracing 配置 OpenTelemetry 链路追踪
+//
+// 一个请求的链路（trace）由多个嵌套的片段（span）组成：
+//
+//	HTTP 请求（middleware.Tracing）
+//	└── 服务方法（service.TraceXxxService 装饰器）
+//	    └── SQL 语句（repository 包里注册的 GORM 回调）
+//
+// span 通过 context.Context 一层层往下传，所以每一层都必须把 ctx 传给下一层
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"kanban_api/internal/buildinfo"
+	"os"
+)
+
+// Enabled 是否配置了 OTLP 导出地址
+// 使用 OpenTelemetry 的标准环境变量，和其它语言的 SDK 一致：
+// - OTEL_EXPORTER_OTLP_ENDPOINT: 例如 http://localhost:4318
+// - OTEL_EXPORTER_OTLP_TRACES_ENDPOINT: 只针对链路数据的地址，优先级更高
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup 初始化全局的 TracerProvider 和跨服务传播格式
+// 没有配置导出地址时什么都不做：全局 TracerProvider 保持默认的 noop 实现，
+// 各层创建 span 的开销几乎为零，不需要到处判断是否开启了追踪
+//
+// 返回的 shutdown 在程序退出前调用，把缓冲中还没发出去的 span 发完
+//
+// 除了导出地址，下面这些标准环境变量也会生效（由 SDK 和导出器自己读取）：
+// - OTEL_SERVICE_NAME: 服务名，默认 kanban_api
+// - OTEL_EXPORTER_OTLP_HEADERS: 额外请求头，例如认证令牌
+// - OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG: 采样策略，例如 parentbased_traceidratio 和 0.1
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// resource 描述"是谁产生的 span"，后面的来源覆盖前面的：
+	// 版本号来自构建信息（见 internal/buildinfo），追踪后端可以按版本对比延迟、错误率
+	// 代码里的服务名只是默认值，OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES 设置了就以环境变量为准
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("kanban_api"), semconv.ServiceVersion(buildinfo.Version)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// WithBatcher 在后台批量发送，不阻塞请求
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	// W3C Trace Context（traceparent 请求头）：上游服务传来的链路可以接上
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,346 @@
+// Package webhook 把看板事件投递给外部系统登记的 URL
+//
+// 投递是异步的：事件总线同步调用 Dispatcher.Handle，Handle 只把事件放进队列就返回，
+// 不会因为对方服务器慢或者挂了拖慢修改看板的请求
+//
+// 每次投递是一个 POST 请求，请求体是 JSON 格式的事件，带这些请求头：
+//
+//	X-Kanban-Event: card.created
+//	X-Kanban-Delivery: 投递 ID，重试时不变，接收方可以用来去重
+//	X-Kanban-Timestamp: 发送时的 Unix 秒
+//	X-Kanban-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// 签名里带上时间戳，接收方拒绝太旧的请求就能防止重放
+//
+// 对方返回 2xx 算成功；连接失败、超时、5xx 和 429 按指数退避重试，其它 4xx 不重试
+// 队列在内存里，服务重启时还没投递完的事件会丢失
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"kanban_api/internal/buildinfo"
+	"kanban_api/internal/events"
+	"kanban_api/internal/model"
+	"kanban_api/internal/repository"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// queueSize 等待分发的事件队列长度，满了之后新事件直接丢弃并打印警告
+const queueSize = 1000
+
+// retryBase 第一次重试前等待的时间，之后每次乘以 3：5s、15s、45s、2m15s……
+const retryBase = 5 * time.Second
+
+// maxErrorLen LastError 最多保存多少字节
+const maxErrorLen = 200
+
+// Config 投递配置
+type Config struct {
+	// Workers 同时进行的投递数
+	Workers int
+
+	// MaxAttempts 每次投递最多尝试几次（包括第一次）
+	MaxAttempts int
+
+	// Timeout 单次请求的超时时间
+	Timeout time.Duration
+
+	// AllowPrivate 是否允许投递到内网地址
+	// 默认不允许：否则看板所有者登记一个 http://127.0.0.1:xxx 的 webhook，就能让服务端去请求内网服务（SSRF）
+	// 本地开发时接收方通常就在本机，可以打开
+	AllowPrivate bool
+}
+
+// ConfigFromEnv 从环境变量读取配置
+// - WEBHOOK_WORKERS: 同时进行的投递数，默认 4
+// - WEBHOOK_MAX_ATTEMPTS: 最多尝试次数，默认 5
+// - WEBHOOK_TIMEOUT: 单次请求超时，默认 10s
+// - WEBHOOK_ALLOW_PRIVATE: 设为 true 允许投递到回环和内网地址，默认 false
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Workers: 4, MaxAttempts: 5, Timeout: 10 * time.Second}
+	if v := os.Getenv("WEBHOOK_WORKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_WORKERS: %q", v)
+		}
+		cfg.Workers = n
+	}
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", v)
+		}
+		cfg.MaxAttempts = n
+	}
+	if v := os.Getenv("WEBHOOK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_TIMEOUT: %q", v)
+		}
+		cfg.Timeout = d
+	}
+	if v := os.Getenv("WEBHOOK_ALLOW_PRIVATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid WEBHOOK_ALLOW_PRIVATE: %q", v)
+		}
+		cfg.AllowPrivate = b
+	}
+	return cfg, nil
+}
+
+// errBlockedAddress 目标地址是回环或者内网地址
+var errBlockedAddress = errors.New("destination address is not allowed")
+
+// payload 投递的请求体：事件本身加上投递 ID
+type payload struct {
+	ID string `json:"id"`
+	events.Event
+}
+
+// delivery 一次投递（同一个事件发给同一个 webhook），重试时复用
+type delivery struct {
+	webhook model.Webhook
+	id      string
+	event   string
+	body    []byte
+	attempt int
+}
+
+// Dispatcher 事件分发器
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	cfg    Config
+	client *http.Client
+	log    *slog.Logger
+
+	queue chan events.Event
+	jobs  chan *delivery
+}
+
+// NewDispatcher 创建分发器，要调用 Run 之后才开始投递
+func NewDispatcher(repo repository.WebhookRepository, cfg Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		// Control 在 DNS 解析之后、建立连接之前调用，拿到的是真正要连的 IP，
+		// 域名解析到内网地址（包括 DNS rebinding）也能拦住
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		// 不读 HTTP_PROXY 等环境变量：经过代理时拦截的是代理的地址，而不是真正的目标
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: cfg.Timeout,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Dispatcher{
+		repo: repo,
+		cfg:  cfg,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			// 不跟随重定向：重定向的目标不受 URL 校验约束，而且签名是给原来的 URL 的
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		log:   slog.Default().With("component", "webhook"),
+		queue: make(chan events.Event, queueSize),
+		jobs:  make(chan *delivery, queueSize),
+	}
+}
+
+// Handle 接收一个事件，注册到事件总线上（bus.Listen(d.Handle)）
+// 总线同步调用它，所以只放进队列，不做任何 I/O
+func (d *Dispatcher) Handle(e events.Event) {
+	select {
+	case d.queue <- e:
+	default:
+		d.log.Warn("webhook queue full, event dropped", "type", e.Type, "board_id", e.BoardID)
+	}
+}
+
+// QueueDepth 排队等待分发的事件和等待投递的请求一共有多少，以及两个队列的总容量，健康探测使用
+func (d *Dispatcher) QueueDepth() (depth, capacity int) {
+	return len(d.queue) + len(d.jobs), cap(d.queue) + cap(d.jobs)
+}
+
+// Run 启动投递，直到 ctx 被取消
+func (d *Dispatcher) Run(ctx context.Context) {
+	for i := 0; i < d.cfg.Workers; i++ {
+		go d.worker(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			d.fanOut(ctx, e)
+		}
+	}
+}
+
+// fanOut 为订阅了这个事件的每个 webhook 生成一次投递
+func (d *Dispatcher) fanOut(ctx context.Context, e events.Event) {
+	hooks, err := d.repo.ListByBoard(ctx, e.BoardID)
+	if err != nil {
+		d.log.Error("list webhooks failed", "board_id", e.BoardID, "err", err)
+		return
+	}
+	for _, w := range hooks {
+		if !w.Wants(e.Type) {
+			continue
+		}
+		id := uuid.NewString()
+		body, err := json.Marshal(payload{ID: id, Event: e})
+		if err != nil {
+			d.log.Error("encode webhook payload failed", "type", e.Type, "err", err)
+			return
+		}
+		d.enqueue(ctx, &delivery{webhook: w, id: id, event: e.Type, body: body, attempt: 1})
+	}
+
+	if e.Type == events.BoardDeleted {
+		// 看板已经没了，它的 webhook 也不再需要
+		// 上面已经拿到了 webhook 的副本，board.deleted 这条事件仍然会投递出去
+		if err := d.repo.DeleteByBoard(ctx, e.BoardID); err != nil {
+			d.log.Error("delete webhooks of deleted board failed", "board_id", e.BoardID, "err", err)
+		}
+	}
+}
+
+// enqueue 把投递放进工作队列，队列满时等待
+func (d *Dispatcher) enqueue(ctx context.Context, job *delivery) {
+	select {
+	case d.jobs <- job:
+	case <-ctx.Done():
+	}
+}
+
+func (d *Dispatcher) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-d.jobs:
+			d.deliver(ctx, job)
+		}
+	}
+}
+
+// deliver 发送一次请求，记录结果，需要时安排重试
+func (d *Dispatcher) deliver(ctx context.Context, job *delivery) {
+	status, err := d.send(ctx, job)
+
+	errMsg := ""
+	switch {
+	case err != nil:
+		errMsg = err.Error()
+	case status < 200 || status > 299:
+		errMsg = "unexpected status " + strconv.Itoa(status)
+	}
+	if len(errMsg) > maxErrorLen {
+		errMsg = errMsg[:maxErrorLen]
+	}
+	// 投递结果只用来排查问题，记录失败（例如 SQLite 写冲突）不影响投递本身
+	if rerr := d.repo.RecordDelivery(ctx, job.webhook.ID, time.Now(), status, errMsg); rerr != nil {
+		d.log.Warn("record webhook delivery failed", "webhook_id", job.webhook.ID, "err", rerr)
+	}
+	if errMsg == "" {
+		return
+	}
+
+	retry := retryable(status, err) && job.attempt < d.cfg.MaxAttempts
+	d.log.Warn("webhook delivery failed",
+		"webhook_id", job.webhook.ID, "delivery", job.id, "type", job.event,
+		"attempt", job.attempt, "status", status, "err", errMsg, "retry", retry)
+	if !retry {
+		return
+	}
+
+	delay := retryBase
+	for i := 1; i < job.attempt; i++ {
+		delay *= 3
+	}
+	job.attempt++
+	time.AfterFunc(delay, func() { d.enqueue(ctx, job) })
+}
+
+// send 发出请求，返回对方的状态码（连接失败时为 0）
+func (d *Dispatcher) send(ctx context.Context, job *delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.webhook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kanban_api-webhook/"+buildinfo.Version)
+	req.Header.Set("X-Kanban-Event", job.event)
+	req.Header.Set("X-Kanban-Delivery", job.id)
+	req.Header.Set("X-Kanban-Timestamp", ts)
+	req.Header.Set("X-Kanban-Signature", "sha256="+Sign(job.webhook.Secret, ts, job.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 读掉（一部分）响应体，连接才能复用；内容不关心
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign 计算签名：hex(HMAC-SHA256(secret, timestamp + "." + body))
+// 接收方用同样的方法计算，再和 X-Kanban-Signature 里 sha256= 后面的部分做常量时间比较
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryable 失败是不是暂时的：连接失败、超时、5xx、408 和 429 重试，
+// 其它 4xx 说明请求本身不被接受，重试也一样；被 SSRF 检查拦下的也不重试
+func retryable(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, errBlockedAddress)
+	}
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
+
+// cgnat 运营商级 NAT 地址段（100.64.0.0/10），net.IP.IsPrivate 不包括它
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP 是否是可以投递的公网地址
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || cgnat.Contains(ip))
+}